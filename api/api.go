@@ -0,0 +1,9 @@
+// Package api embeds the OpenAPI spec so the server can serve it and validate traffic against it.
+package api
+
+import _ "embed"
+
+// Swagger is the spec in YAML as it lies in the repository
+//
+//go:embed swagger.yaml
+var Swagger []byte
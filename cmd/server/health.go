@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	httpGateway "homework/internal/gateways/http"
+)
+
+// schemaRepository is the state of the schema, kept by both the postgres and the embedded database
+type schemaRepository interface {
+	Ping(ctx context.Context) error
+	GetVersion(ctx context.Context) (version int64, dirty bool, err error)
+}
+
+// schemaChecks make the server unready while the database is unreachable or its schema is older than the code expects
+func schemaChecks(schema schemaRepository, latest int64) []httpGateway.Check {
+	return []httpGateway.Check{
+		{Name: "database", Check: schema.Ping},
+		{Name: "migrations", Check: func(ctx context.Context) error {
+			version, dirty, err := schema.GetVersion(ctx)
+			switch {
+			case err != nil:
+				return err
+			case dirty:
+				return fmt.Errorf("migration %d has failed midway", version)
+			case version < latest:
+				return fmt.Errorf("schema version %d is behind %d", version, latest)
+			}
+			return nil
+		}},
+	}
+}
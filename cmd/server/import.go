@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"homework/internal/config"
+	"homework/internal/gateways/importer"
+	"homework/internal/usecase"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	checkpointRepository "homework/internal/repository/checkpoint/postgres"
+	eventRepository "homework/internal/repository/event/postgres"
+	sensorRepository "homework/internal/repository/sensor/postgres"
+	sensorTypeRepository "homework/internal/repository/sensortype/postgres"
+)
+
+// runImport implements `server import`, which loads sensors and events from files into postgres
+func runImport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	databaseURL := fs.String("database", os.Getenv("DATABASE_URL"), "postgres connection string")
+	sensorsPath := fs.String("sensors", "", "file with sensors (.csv or .ndjson)")
+	eventsPath := fs.String("events", "", "file with events (.csv or .ndjson)")
+	format := fs.String("format", "", "input format: csv or ndjson, guessed by the file extension if empty")
+	id := fs.String("id", "", "import id used to resume an interrupted import, defaults to the absolute file path")
+	batchSize := fs.Int("batch", 0, "number of events written in one batch")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *databaseURL == "" {
+		return errors.New("database url is required: set DATABASE_URL or -database")
+	}
+	if _, ok := (config.Database{URL: *databaseURL}).SQLitePath(); ok {
+		return errors.New("import works with postgres only, the embedded database is filled through the api")
+	}
+	if *sensorsPath == "" && *eventsPath == "" {
+		return errors.New("nothing to import: set -sensors and/or -events")
+	}
+
+	dbConfig := config.Default().Database
+	dbConfig.URL = *databaseURL
+	pool, err := newPool(ctx, dbConfig)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	sr := sensorRepository.NewSensorRepository(pool)
+	imp := usecase.NewImport(
+		sr,
+		eventRepository.NewEventRepository(pool),
+		checkpointRepository.NewCheckpointRepository(pool),
+		usecase.WithImportBatchSize(*batchSize),
+		usecase.WithImportSensorTypes(usecase.NewSensorTypes(sensorTypeRepository.NewSensorTypeRepository(pool), sr)),
+	)
+
+	// sensors go first so that the events can reference them
+	if *sensorsPath != "" {
+		err := importFile(*sensorsPath, *format, *id, "sensors", func(f *os.File, format importer.Format, key string) (usecase.ImportResult, error) {
+			src, err := importer.NewSensorReader(f, format)
+			if err != nil {
+				return usecase.ImportResult{}, err
+			}
+			return imp.ImportSensors(ctx, key, src)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if *eventsPath != "" {
+		err := importFile(*eventsPath, *format, *id, "events", func(f *os.File, format importer.Format, key string) (usecase.ImportResult, error) {
+			src, err := importer.NewEventReader(f, format)
+			if err != nil {
+				return usecase.ImportResult{}, err
+			}
+			return imp.ImportEvents(ctx, key, src)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func importFile(path, rawFormat, id, kind string, run func(*os.File, importer.Format, string) (usecase.ImportResult, error)) error {
+	format := importer.Format(rawFormat)
+	if format == "" {
+		var err error
+		if format, err = importer.FormatFromPath(path); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	key := id
+	if key == "" {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		key = abs
+	}
+	key = kind + ":" + key
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	res, err := run(f, format, key)
+	slog.Info("Import is done", "kind", kind, "path", path,
+		"processed", res.Processed, "imported", res.Imported, "skipped", res.Skipped)
+	if err != nil {
+		return fmt.Errorf("%s import from %s: %w", kind, path, err)
+	}
+	return nil
+}
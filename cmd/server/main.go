@@ -3,73 +3,414 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
+	"fmt"
+	"homework/internal/config"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/metrics"
 	"homework/internal/usecase"
-	"log"
+	"homework/migrations"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"syscall"
 
 	httpGateway "homework/internal/gateways/http"
-	eventRepository "homework/internal/repository/event/inmemory"
-	sensorRepository "homework/internal/repository/sensor/inmemory"
-	userRepository "homework/internal/repository/user/inmemory"
+	actuatorInmemory "homework/internal/repository/actuator/inmemory"
+	actuatorPostgres "homework/internal/repository/actuator/postgres"
+	changeInmemory "homework/internal/repository/change/inmemory"
+	changePostgres "homework/internal/repository/change/postgres"
+	changeSqlite "homework/internal/repository/change/sqlite"
+	checkpointInmemory "homework/internal/repository/checkpoint/inmemory"
+	checkpointPostgres "homework/internal/repository/checkpoint/postgres"
+	"homework/internal/repository/durable"
+	eventInmemory "homework/internal/repository/event/inmemory"
+	eventPostgres "homework/internal/repository/event/postgres"
+	eventSqlite "homework/internal/repository/event/sqlite"
+	idempotencyInmemory "homework/internal/repository/idempotency/inmemory"
+	idempotencyPostgres "homework/internal/repository/idempotency/postgres"
+	"homework/internal/repository/instrumented"
+	ratelimitInmemory "homework/internal/repository/ratelimit/inmemory"
+	ratelimitPostgres "homework/internal/repository/ratelimit/postgres"
+	sceneInmemory "homework/internal/repository/scene/inmemory"
+	scenePostgres "homework/internal/repository/scene/postgres"
+	schemaPostgres "homework/internal/repository/schema/postgres"
+	schemaSqlite "homework/internal/repository/schema/sqlite"
+	sensorInmemory "homework/internal/repository/sensor/inmemory"
+	sensorPostgres "homework/internal/repository/sensor/postgres"
+	sensorSqlite "homework/internal/repository/sensor/sqlite"
+	sensorTypeInmemory "homework/internal/repository/sensortype/inmemory"
+	sensorTypePostgres "homework/internal/repository/sensortype/postgres"
+	sensorTypeSqlite "homework/internal/repository/sensortype/sqlite"
+	userInmemory "homework/internal/repository/user/inmemory"
+	userPostgres "homework/internal/repository/user/postgres"
+	userSqlite "homework/internal/repository/user/sqlite"
+	"homework/internal/tracing"
 
-	_ "github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-const (
-	MetricsAddr = "/metrics"
-	MetricsPort = 8000
-)
-
-func runMetrics() {
+func runMetrics(cfg config.Metrics) {
 	metricServer := http.NewServeMux()
-	metricServer.Handle(MetricsAddr, promhttp.Handler())
+	metricServer.Handle(cfg.Path, promhttp.Handler())
 
-	log.Printf("Listening metrics on :%d", MetricsPort)
-	err := http.ListenAndServe(":"+strconv.Itoa(MetricsPort), metricServer)
+	addr := cfg.Host + ":" + strconv.Itoa(cfg.Port)
+	slog.Info("Listening metrics", "addr", addr)
+	err := http.ListenAndServe(addr, metricServer)
 	if err != nil {
-		log.Fatal(err)
+		fatal("Can't serve metrics", err)
 	}
 }
 
-func main() {
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
-	defer cancel()
+// fatal logs the error and exits, like log.Fatal does
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+func newPool(ctx context.Context, cfg config.Database) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	poolConfig.MaxConns = cfg.MaxConns
+	poolConfig.MinConns = cfg.MinConns
+	poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	poolConfig.ConnConfig.ConnectTimeout = cfg.ConnectTimeout
+	poolConfig.ConnConfig.Tracer = tracing.NewPgxTracer()
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("can't connect to database: %w", err)
+	}
+	return pool, nil
+}
+
+// newUseCases keeps the data in postgres if the database url is set, in memory otherwise.
+// A sqlite:// url keeps the sensors, the events and the users in the embedded database file and the rest in memory.
+// The sensors, the events and the users in memory survive the restarts if the persistence directory is set.
+// The feed of their changes is kept along with them.
+// The readiness checks of the chosen storage are returned along with the usecases.
+func newUseCases(ctx context.Context, cfg *config.Config, reg prometheus.Registerer) (httpGateway.UseCases, []httpGateway.Check, func(), error) {
+	var (
+		er  usecase.EventRepository
+		sr  usecase.SensorRepository
+		ur  usecase.UserRepository
+		sor usecase.SensorOwnerRepository
+		cr  usecase.ImportCheckpointRepository
+		rr  usecase.RateLimitRepository
+		ir  usecase.IdempotencyRepository
+		tr  usecase.SensorTypeRepository
+		ar  usecase.ActuatorRepository
+		mr  usecase.CommandRepository
+		scr usecase.SceneRepository
+		shr usecase.ScheduleRepository
+		chr usecase.ChangeRepository
+	)
+	var checks []httpGateway.Check
+	closeRepositories := func() {}
+	backend := instrumented.BackendInMemory
 
-	er := eventRepository.NewEventRepository()
-	sr := sensorRepository.NewSensorRepository()
-	ur := userRepository.NewUserRepository()
-	sor := userRepository.NewSensorOwnerRepository()
+	if path, ok := cfg.Database.SQLitePath(); ok {
+		db, err := schemaSqlite.Open(ctx, path)
+		if err != nil {
+			return httpGateway.UseCases{}, nil, nil, err
+		}
+		closeRepositories = func() {
+			if err := db.Close(); err != nil {
+				slog.Error("Can't close database", "path", path, "error", err)
+			}
+		}
+		checks = schemaChecks(schemaSqlite.NewSchemaRepository(db), migrations.LatestSQLite())
+		backend = instrumented.BackendSQLite
 
+		er = eventSqlite.NewEventRepository(db)
+		sr = sensorSqlite.NewSensorRepository(db)
+		ur = userSqlite.NewUserRepository(db)
+		sor = userSqlite.NewSensorOwnerRepository(db)
+		chr = changeSqlite.NewChangeRepository(db)
+		tr = sensorTypeSqlite.NewSensorTypeRepository(db)
+	} else if cfg.Database.URL != "" {
+		pool, err := newPool(ctx, cfg.Database)
+		if err != nil {
+			return httpGateway.UseCases{}, nil, nil, err
+		}
+		closeRepositories = pool.Close
+		checks = schemaChecks(schemaPostgres.NewSchemaRepository(pool), migrations.Latest())
+		backend = instrumented.BackendPostgres
+
+		er = eventPostgres.NewEventRepository(pool)
+		sr = sensorPostgres.NewSensorRepository(pool)
+		ur = userPostgres.NewUserRepository(pool)
+		sor = userPostgres.NewSensorOwnerRepository(pool)
+		cr = checkpointPostgres.NewCheckpointRepository(pool)
+		ir = idempotencyPostgres.NewIdempotencyRepository(pool)
+		tr = sensorTypePostgres.NewSensorTypeRepository(pool)
+		ar = actuatorPostgres.NewActuatorRepository(pool)
+		mr = actuatorPostgres.NewCommandRepository(pool)
+		scr = scenePostgres.NewSceneRepository(pool)
+		shr = scenePostgres.NewScheduleRepository(pool)
+		chr = changePostgres.NewChangeRepository(pool)
+		if cfg.RateLimit.Backend == config.RateLimitBackendPostgres {
+			rr = ratelimitPostgres.NewRateLimitRepository(pool)
+		}
+	} else if cfg.Persistence.Dir != "" {
+		store, err := durable.Open(cfg.Persistence.Dir, durable.NewRepositories(), durable.Options{
+			SyncInterval:     cfg.Persistence.SyncInterval,
+			SnapshotInterval: cfg.Persistence.SnapshotInterval,
+		})
+		if err != nil {
+			return httpGateway.UseCases{}, nil, nil, fmt.Errorf("can't restore data from %s: %w", cfg.Persistence.Dir, err)
+		}
+		closeRepositories = func() {
+			if err := store.Close(); err != nil {
+				slog.Error("Can't close persistence", "dir", cfg.Persistence.Dir, "error", err)
+			}
+		}
+		checks = append(checks, httpGateway.Check{Name: "persistence", Check: store.Check})
+		go store.Run(ctx)
+
+		er = store.Events()
+		sr = store.Sensors()
+		ur = store.Users()
+		sor = store.SensorOwners()
+		chr = store.Changes()
+		tr = store.SensorTypes()
+	} else {
+		changes := changeInmemory.NewChangeRepository()
+		er = eventInmemory.NewEventRepository(eventInmemory.WithEventChanges(changes.Record))
+		sr = sensorInmemory.NewSensorRepository(sensorInmemory.WithSensorChanges(changes.Record))
+		ur = userInmemory.NewUserRepository(userInmemory.WithUserChanges(changes.Record))
+		sor = userInmemory.NewSensorOwnerRepository(userInmemory.WithSensorOwnerChanges(changes.Record))
+		chr = changes
+	}
+	if backend != instrumented.BackendPostgres {
+		cr = checkpointInmemory.NewCheckpointRepository()
+		ir = idempotencyInmemory.NewIdempotencyRepository(cfg.Idempotency.Capacity)
+		if tr == nil {
+			tr = sensorTypeInmemory.NewSensorTypeRepository()
+		}
+		ar = actuatorInmemory.NewActuatorRepository()
+		mr = actuatorInmemory.NewCommandRepository()
+		scr = sceneInmemory.NewSceneRepository()
+		shr = sceneInmemory.NewScheduleRepository()
+	}
+
+	in := instrumented.NewInstrument(backend, reg)
+	inMemoryIn := in
+	if backend != instrumented.BackendInMemory {
+		inMemoryIn = instrumented.NewInstrument(instrumented.BackendInMemory, reg)
+	}
+	// the embedded database keeps only the sensors with their types, the events and the users, the rest is in memory
+	restIn := in
+	if backend == instrumented.BackendSQLite {
+		restIn = inMemoryIn
+	}
+	er = instrumented.NewEventRepository(er, in)
+	sr = instrumented.NewSensorRepository(sr, in)
+	ur = instrumented.NewUserRepository(ur, in)
+	sor = instrumented.NewSensorOwnerRepository(sor, in)
+	// the feed is kept along with the entities it records
+	chr = instrumented.NewChangeRepository(chr, in)
+	cr = instrumented.NewCheckpointRepository(cr, restIn)
+	ir = instrumented.NewIdempotencyRepository(ir, restIn)
+	tr = instrumented.NewSensorTypeRepository(tr, in)
+	ar = instrumented.NewActuatorRepository(ar, restIn)
+	mr = instrumented.NewCommandRepository(mr, restIn)
+	scr = instrumented.NewSceneRepository(scr, restIn)
+	shr = instrumented.NewScheduleRepository(shr, restIn)
+	if rr != nil {
+		rr = instrumented.NewRateLimitRepository(rr, in)
+	} else {
+		// the limits are kept in memory even with the database unless they are shared
+		rr = instrumented.NewRateLimitRepository(ratelimitInmemory.NewRateLimitRepository(cfg.RateLimit.Capacity), inMemoryIn)
+	}
+	limiter := usecase.NewRateLimiter(rr, rateLimits(cfg.RateLimit))
+
+	export := usecase.NewExport(er, sr, cfg.Export.Dir, usecase.WithExportTTL(cfg.Export.TTL))
+	checks = append(checks, httpGateway.Check{Name: "exports", Check: export.Check})
+
+	domainMetrics := metrics.NewDomain(reg)
+	sensorTypes := usecase.NewSensorTypes(tr, sr)
+	commands := usecase.NewCommands(mr, ar, usecase.WithCommandSettings(usecase.CommandSettings{
+		AckTimeout:   cfg.Commands.AckTimeout,
+		Attempts:     cfg.Commands.Attempts,
+		TTL:          cfg.Commands.TTL,
+		PollInterval: cfg.Commands.PollInterval,
+	}))
+	scenes := usecase.NewScenes(scr, shr, ar, commands)
+	// the zone has been validated with the config
+	location, _ := cfg.Scheduler.Location()
+	virtual := usecase.NewVirtualSensors(sr, er)
 	useCases := httpGateway.UseCases{
-		Event:  usecase.NewEvent(er, sr),
-		Sensor: usecase.NewSensor(sr),
-		User:   usecase.NewUser(ur, sor, sr),
+		Event: usecase.NewEvent(er, sr, usecase.WithEventMetrics(domainMetrics), usecase.WithEventRateLimiter(limiter),
+			usecase.WithEventSensorTypes(sensorTypes), usecase.WithEventVirtualSensors(virtual)),
+		Sensor: usecase.NewSensor(sr, usecase.WithSensorTypes(sensorTypes), usecase.WithSensorVirtualSensors(virtual)),
+		User:   usecase.NewUser(ur, sor, sr, usecase.WithUserMetrics(domainMetrics)),
+		Export: export,
+		Import: usecase.NewImport(sr, er, cr, usecase.WithImportSensorTypes(sensorTypes),
+			usecase.WithImportVirtualSensors(virtual)),
+		SensorTypes: sensorTypes,
+		Actuator:    usecase.NewActuator(ar),
+		Commands:    commands,
+		Scenes:      scenes,
+		Schedules: usecase.NewSchedules(shr, scr, scenes, usecase.WithScheduleSettings(usecase.ScheduleSettings{
+			Location:     location,
+			Coordinates:  domain.Coordinates{Latitude: cfg.Scheduler.Latitude, Longitude: cfg.Scheduler.Longitude},
+			Tick:         cfg.Scheduler.Tick,
+			MisfireGrace: cfg.Scheduler.MisfireGrace,
+		})),
+
+		RateLimit:   limiter,
+		Idempotency: usecase.NewIdempotency(ir, cfg.Idempotency.Window),
+		Changes: usecase.NewChanges(chr, usecase.WithChangeSettings(usecase.ChangeSettings{
+			Retention:     cfg.Changes.Retention,
+			PruneInterval: cfg.Changes.PruneInterval,
+			PollInterval:  cfg.Changes.PollInterval,
+		})),
+	}
+	// the background workers are started by main, a stuck or failing one makes the server unready
+	checks = append(checks,
+		httpGateway.Check{Name: "schedules", Check: useCases.Schedules.Check},
+		httpGateway.Check{Name: "changes_pruning", Check: useCases.Changes.Check},
+		httpGateway.Check{Name: "exports_pruning", Check: export.CheckPruning},
+	)
+	reg.MustRegister(metrics.NewSensorCollector(useCases.Sensor.GetSensors, sensorTypes.GetSensorTypes,
+		cfg.Metrics.SensorOfflineAfter))
+	if cfg.Metrics.Readings {
+		reg.MustRegister(metrics.NewReadingsCollector(useCases.Sensor.GetSensors, cfg.Metrics.ReadingsAllow, cfg.Metrics.ReadingsLimit,
+			metrics.WithReadingsOwners(useCases.User.GetSensorOwners)))
 	}
 
-	host, present := os.LookupEnv("HTTP_HOST")
-	if !present {
-		host = httpGateway.DefaultHost
+	return useCases, checks, closeRepositories, nil
+}
+
+// rateLimits converts the config, it has been validated already
+func rateLimits(cfg config.RateLimit) usecase.RateLimits {
+	limits := usecase.RateLimits{
+		Client:      domain.RateLimit{Rate: cfg.ClientRate, Burst: cfg.ClientBurst},
+		Sensor:      domain.RateLimit{Rate: cfg.SensorRate, Burst: cfg.SensorBurst},
+		SensorTypes: make(map[domain.SensorType]domain.RateLimit, len(cfg.SensorTypes)),
 	}
-	portRaw, present := os.LookupEnv("HTTP_PORT")
-	port, err := strconv.Atoi(portRaw)
-	if !present || err != nil || port < 0 || port > 9999 {
-		log.Printf("Valid port number hasn't been provided, using default port")
-		port = httpGateway.DefaultPort
+	for _, item := range cfg.SensorTypes {
+		sensorType, rate, burst, _ := config.ParseSensorTypeLimit(item)
+		limits.SensorTypes[domain.SensorType(sensorType)] = domain.RateLimit{Rate: rate, Burst: burst}
 	}
+	return limits
+}
+
+func serverSettings(cfg *config.Config) (httpGateway.Settings, httpGateway.WebSocketSettings) {
+	settings := httpGateway.Settings{
+		Validation: httpGateway.SpecValidation{
+			Requests:  cfg.Features.ValidateRequests,
+			Responses: cfg.Features.ValidateResponses,
+		},
+		Exports: cfg.Features.Exports,
+		Imports: cfg.Features.Imports,
+
+		ClientKeyHeader: cfg.RateLimit.ClientKeyHeader,
+	}
+	// the config is validated, so the proxies parse
+	for _, item := range cfg.RateLimit.TrustedProxies {
+		proxy, _ := config.ParseTrustedProxy(item)
+		settings.TrustedProxies = append(settings.TrustedProxies, proxy)
+	}
+	ws := httpGateway.WebSocketSettings{Tick: cfg.WebSocket.Tick, Buffer: cfg.WebSocket.Buffer}
+	return settings, ws
+}
 
-	if port == MetricsPort {
-		log.Fatalf("Port number clashes with metrics port: %s\n", portRaw)
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(ctx, os.Args[2:]); err != nil {
+			fatal("Import has failed", err)
+		}
 		return
 	}
 
-	go runMetrics()
+	loadConfig := func() (*config.Config, error) {
+		return config.Load(os.Args[1:])
+	}
+	cfg, err := loadConfig()
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fatal("Invalid config", err)
+	}
+	if err := logging.Setup(os.Stderr, logging.Config{Level: cfg.Log.Level, Format: cfg.Log.Format}); err != nil {
+		fatal("Can't set up logging", err)
+	}
+	slog.Info("Effective config", "config", cfg.String())
+	// gin prints the routes and warnings of the debug mode as plain text, the log is structured now
+	gin.SetMode(gin.ReleaseMode)
+
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		Endpoint:    cfg.Tracing.Endpoint,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		fatal("Can't set up tracing", err)
+	}
+	defer func() {
+		// the spans of the drain are flushed too, so the context of main is done by now
+		flushCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			slog.Error("Can't flush traces", "error", err)
+		}
+	}()
+
+	reg := prometheus.DefaultRegisterer
+	useCases, checks, closeRepositories, err := newUseCases(ctx, cfg, reg)
+	if err != nil {
+		fatal("Can't set up storage", err)
+	}
+	defer closeRepositories()
+
+	go runMetrics(cfg.Metrics)
+	// the runs missed while the server was down are handled at once
+	go useCases.Schedules.Run(ctx)
+	go useCases.Changes.Run(ctx)
+	go useCases.Export.Run(ctx)
+
+	settings, wsSettings := serverSettings(cfg)
+	r := httpGateway.NewServer(useCases,
+		httpGateway.WithHost(cfg.HTTP.Host),
+		httpGateway.WithPort(uint16(cfg.HTTP.Port)),
+		httpGateway.WithTimeouts(httpGateway.Timeouts{
+			ReadHeader: cfg.HTTP.ReadHeaderTimeout,
+			Read:       cfg.HTTP.ReadTimeout,
+			Write:      cfg.HTTP.WriteTimeout,
+			Idle:       cfg.HTTP.IdleTimeout,
+			Shutdown:   cfg.HTTP.ShutdownTimeout,
+			DrainDelay: cfg.HTTP.DrainDelay,
+		}),
+		httpGateway.WithMetrics(httpGateway.NewMetricsExporter(reg)),
+		httpGateway.WithReadinessChecks(checks...),
+		// the running exports are finished after the server stops taking requests
+		httpGateway.WithFlushers(httpGateway.Flusher{Name: "exports", Flush: useCases.Export.Drain}),
+		httpGateway.WithSettings(settings),
+		httpGateway.WithWebSocketSettings(wsSettings),
+	)
+
+	go config.Watch(ctx, cfg, loadConfig, func(c *config.Config) {
+		r.Reload(serverSettings(c))
+		if err := logging.SetLevel(c.Log.Level); err != nil {
+			slog.Error("Log level is not changed", "error", err)
+		}
+	})
 
-	r := httpGateway.NewServer(useCases, httpGateway.WithHost(host), httpGateway.WithPort(uint16(port)))
-	if err := r.Run(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		log.Printf("error during server shutdown: %v", err)
+	if err := r.Run(ctx); err != nil {
+		slog.Error("Server has not shut down cleanly", "error", err)
 	}
 }
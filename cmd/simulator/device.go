@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"time"
+)
+
+// adcMax is the top of the 12-bit range the builtin adc type accepts
+const adcMax = 1<<12 - 1
+
+// event is a reading of a simulated sensor as every sink sends it
+type event struct {
+	id        string
+	serial    string
+	timestamp time.Time
+	payload   int64
+}
+
+type pattern struct {
+	adcPeriod  time.Duration
+	adcStep    int64
+	ccRate     float64
+	burstProb  float64
+	burstSize  int
+	skew       time.Duration
+	duplicates float64
+}
+
+// device generates the events of one sensor. Its random source is seeded by the run seed and its index,
+// so the readings of a run are reproducible whatever the scheduling is.
+type device struct {
+	serial     string
+	sensorType string
+	rnd        *rand.Rand
+	pattern    pattern
+	// skew is the fixed error of the device clock
+	skew  time.Duration
+	value int64
+	seq   int
+}
+
+func newDevice(serial, sensorType string, seed uint64, index int, p pattern) *device {
+	d := &device{
+		serial:     serial,
+		sensorType: sensorType,
+		rnd:        rand.New(rand.NewPCG(seed, uint64(index))),
+		pattern:    p,
+	}
+	if p.skew > 0 {
+		d.skew = time.Duration(d.rnd.Int64N(int64(2*p.skew+1))) - p.skew
+	}
+	if sensorType == "adc" {
+		d.value = d.rnd.Int64N(adcMax + 1)
+	}
+	return d
+}
+
+// wait returns the time until the next reading: a jittered period for adc, exponential for the cc,
+// so its opening and closing is a Poisson process
+func (d *device) wait() time.Duration {
+	if d.sensorType == "cc" {
+		return time.Duration(d.rnd.ExpFloat64() / d.pattern.ccRate * float64(time.Second))
+	}
+	half := int64(d.pattern.adcPeriod / 2)
+	return time.Duration(half + d.rnd.Int64N(2*half+1))
+}
+
+// next moves the reading: a random walk for adc, a toggle for cc
+func (d *device) next(now time.Time) event {
+	switch d.sensorType {
+	case "cc":
+		d.value = 1 - d.value
+	default:
+		d.value += d.rnd.Int64N(2*d.pattern.adcStep+1) - d.pattern.adcStep
+		d.value = min(max(d.value, 0), adcMax)
+	}
+	d.seq++
+	return event{
+		id:        d.serial + "-" + strconv.Itoa(d.seq),
+		serial:    d.serial,
+		timestamp: now.Add(d.skew),
+		payload:   d.value,
+	}
+}
+
+// run emits the events until ctx is done. A burst sends several readings back to back, a duplicate
+// is the same event sent again as a retrying device does.
+func (d *device) run(ctx context.Context, out chan<- event, generated func()) {
+	timer := time.NewTimer(d.wait())
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		n := 1
+		if d.pattern.burstProb > 0 && d.rnd.Float64() < d.pattern.burstProb {
+			n = d.pattern.burstSize
+		}
+		for range n {
+			e := d.next(time.Now())
+			copies := 1
+			if d.pattern.duplicates > 0 && d.rnd.Float64() < d.pattern.duplicates {
+				copies = 2
+			}
+			for range copies {
+				select {
+				case out <- e:
+					generated()
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+		timer.Reset(d.wait())
+	}
+}
+
+// serialNumber makes a stable serial number, so a rerun reuses the sensors registered before
+func serialNumber(prefix string, typeIndex, index int) string {
+	return fmt.Sprintf("%s%d%0*d", prefix, typeIndex, 9-len(prefix), index)
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testPattern = pattern{adcPeriod: time.Second, adcStep: 20, ccRate: 0.1, burstSize: 10}
+
+func TestDevice(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("ok, same seed makes the same readings", func(t *testing.T) {
+		a := newDevice("9100000001", "adc", 42, 3, testPattern)
+		b := newDevice("9100000001", "adc", 42, 3, testPattern)
+		other := newDevice("9100000001", "adc", 42, 4, testPattern)
+
+		var readingsA, readingsB, readingsOther []int64
+		for range 100 {
+			readingsA = append(readingsA, a.next(now).payload)
+			readingsB = append(readingsB, b.next(now).payload)
+			readingsOther = append(readingsOther, other.next(now).payload)
+			assert.Equal(t, a.wait(), b.wait())
+		}
+		assert.Equal(t, readingsA, readingsB, "Устройства с одним зерном должны давать одни показания")
+		assert.NotEqual(t, readingsA, readingsOther, "Устройства с разными индексами должны давать разные показания")
+	})
+
+	t.Run("ok, adc walks within the range", func(t *testing.T) {
+		p := testPattern
+		p.adcStep = 1000
+		d := newDevice("9100000001", "adc", 1, 0, p)
+		prev := d.value
+		for i := range 1000 {
+			e := d.next(now)
+			assert.GreaterOrEqual(t, e.payload, int64(0))
+			assert.LessOrEqual(t, e.payload, int64(adcMax))
+			assert.LessOrEqual(t, abs(e.payload-prev), p.adcStep, "Шаг %d больше допустимого", i)
+			prev = e.payload
+		}
+	})
+
+	t.Run("ok, cc toggles", func(t *testing.T) {
+		d := newDevice("9200000001", "cc", 1, 0, testPattern)
+		for i := range 10 {
+			assert.Equal(t, int64(1-i%2), d.next(now).payload)
+		}
+	})
+
+	t.Run("ok, ids are sequential", func(t *testing.T) {
+		d := newDevice("9200000001", "cc", 1, 0, testPattern)
+		assert.Equal(t, "9200000001-1", d.next(now).id)
+		assert.Equal(t, "9200000001-2", d.next(now).id)
+	})
+
+	t.Run("ok, adc period is jittered by half", func(t *testing.T) {
+		d := newDevice("9100000001", "adc", 1, 0, testPattern)
+		var lo, hi bool
+		for range 1000 {
+			w := d.wait()
+			require.GreaterOrEqual(t, w, testPattern.adcPeriod/2)
+			require.LessOrEqual(t, w, testPattern.adcPeriod*3/2)
+			lo = lo || w < testPattern.adcPeriod*3/4
+			hi = hi || w > testPattern.adcPeriod*5/4
+		}
+		assert.True(t, lo && hi, "Период должен разбрасываться по всему диапазону")
+	})
+
+	t.Run("ok, cc rate is the mean", func(t *testing.T) {
+		d := newDevice("9200000001", "cc", 1, 0, testPattern)
+		const n = 10000
+		var total time.Duration
+		for range n {
+			total += d.wait()
+		}
+		mean := total / n
+		assert.InDelta(t, float64(10*time.Second), float64(mean), float64(time.Second), "Среднее ожидание должно быть 1/cc-rate")
+	})
+
+	t.Run("ok, clock skew is fixed and bounded", func(t *testing.T) {
+		p := testPattern
+		p.skew = time.Minute
+		var skewed bool
+		for i := range 100 {
+			d := newDevice("9100000001", "adc", 1, i, p)
+			require.LessOrEqual(t, abs(d.skew), p.skew)
+			assert.Equal(t, now.Add(d.skew), d.next(now).timestamp)
+			assert.Equal(t, now.Add(d.skew), d.next(now).timestamp)
+			skewed = skewed || d.skew != 0
+		}
+		assert.True(t, skewed)
+
+		d := newDevice("9100000001", "adc", 1, 0, testPattern)
+		assert.Equal(t, now, d.next(now).timestamp, "Без -skew часы устройства точные")
+	})
+}
+
+func TestDeviceRun(t *testing.T) {
+	collect := func(t *testing.T, p pattern, sensorType string, d time.Duration) []event {
+		t.Helper()
+		dev := newDevice("9100000001", sensorType, 1, 0, p)
+		ctx, cancel := context.WithTimeout(context.Background(), d)
+		defer cancel()
+
+		out := make(chan event, 1000)
+		var generated int
+		dev.run(ctx, out, func() { generated++ })
+		close(out)
+
+		var events []event
+		for e := range out {
+			events = append(events, e)
+		}
+		assert.Len(t, events, generated, "Каждое отправленное событие должно быть посчитано")
+		return events
+	}
+
+	t.Run("ok, rate of adc", func(t *testing.T) {
+		p := testPattern
+		p.adcPeriod = 10 * time.Millisecond
+		events := collect(t, p, "adc", 300*time.Millisecond)
+		// 30 on average, the scheduler may only delay the readings
+		assert.GreaterOrEqual(t, len(events), 10)
+		assert.LessOrEqual(t, len(events), 60)
+	})
+
+	t.Run("ok, rate of cc", func(t *testing.T) {
+		p := testPattern
+		p.ccRate = 100
+		events := collect(t, p, "cc", 300*time.Millisecond)
+		assert.GreaterOrEqual(t, len(events), 10)
+		assert.LessOrEqual(t, len(events), 80)
+	})
+
+	t.Run("ok, bursts", func(t *testing.T) {
+		p := testPattern
+		p.adcPeriod, p.burstProb, p.burstSize = 20*time.Millisecond, 1, 5
+		events := collect(t, p, "adc", 200*time.Millisecond)
+		require.GreaterOrEqual(t, len(events), 10)
+
+		// the cancel may cut the last burst, the complete ones are sent back to back and apart from each other
+		for i := 1; i < len(events); i++ {
+			gap := events[i].timestamp.Sub(events[i-1].timestamp)
+			if i%5 == 0 {
+				assert.GreaterOrEqual(t, gap, p.adcPeriod/2, "Между всплесками должен быть период")
+			} else {
+				assert.Less(t, gap, p.adcPeriod/4, "Всплеск должен отправляться сразу")
+			}
+		}
+	})
+
+	t.Run("ok, duplicates", func(t *testing.T) {
+		p := testPattern
+		p.adcPeriod, p.duplicates = 10*time.Millisecond, 1
+		events := collect(t, p, "adc", 100*time.Millisecond)
+		require.NotEmpty(t, events)
+		// the cancel may cut the last pair
+		for i := 0; i+1 < len(events); i += 2 {
+			assert.Equal(t, events[i], events[i+1], "Повтор должен быть тем же событием")
+		}
+	})
+
+	t.Run("ok, stops on cancel while blocked", func(t *testing.T) {
+		p := testPattern
+		p.adcPeriod = time.Millisecond
+		dev := newDevice("9100000001", "adc", 1, 0, p)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			// nobody reads the channel, the device must not hang on it
+			dev.run(ctx, make(chan event), func() {})
+		}()
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Устройство должно остановиться после отмены")
+		}
+	})
+}
+
+func TestSerialNumber(t *testing.T) {
+	tests := []struct {
+		prefix           string
+		typeIndex, index int
+		want             string
+	}{
+		{"9", 1, 1, "9100000001"},
+		{"9", 2, 123, "9200000123"},
+		{"12345678", 1, 7, "1234567817"},
+		{"", 3, 42, "3000000042"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := serialNumber(tt.prefix, tt.typeIndex, tt.index)
+			assert.Equal(t, tt.want, got)
+			assert.Len(t, got, 10)
+		})
+	}
+}
+
+func abs[T int64 | time.Duration](v T) T {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,260 @@
+// Command simulator registers simulated sensors over the api and feeds the server with their events,
+// then reports the achieved throughput and the latency percentiles. A run with the same flags and seed
+// generates the same readings, so it is a repeatable benchmark.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+type options struct {
+	url          string
+	sensors      int
+	types        []string
+	duration     time.Duration
+	sink         string
+	workers      int
+	batch        int
+	batchWait    time.Duration
+	mqttAddr     string
+	mqttTopic    string
+	mqttUser     string
+	serialPrefix string
+	seed         uint64
+	progress     time.Duration
+	json         bool
+	pattern      pattern
+}
+
+func parseOptions(args []string) (options, error) {
+	o := options{}
+	fs := flag.NewFlagSet("simulator", flag.ContinueOnError)
+	fs.StringVar(&o.url, "url", "http://localhost:8080/api/v1", "base url of the api")
+	fs.IntVar(&o.sensors, "sensors", 10, "number of sensors of each type")
+	types := fs.String("types", "cc,adc", "types of the sensors: cc and adc")
+	fs.DurationVar(&o.duration, "duration", time.Minute, "how long the events are sent")
+	fs.StringVar(&o.sink, "sink", "http", "where the events go: http (POST /events), batch (POST /imports/events) or mqtt")
+	fs.IntVar(&o.workers, "workers", 8, "number of concurrent connections")
+	fs.IntVar(&o.batch, "batch", 100, "events in one batch of the batch sink")
+	fs.DurationVar(&o.batchWait, "batch-wait", time.Second, "longest wait for a batch to fill")
+	fs.StringVar(&o.mqttAddr, "mqtt", "localhost:1883", "address of the mqtt broker")
+	fs.StringVar(&o.mqttTopic, "mqtt-topic", "sensors/%s/events", "topic of the events, %s is the serial number")
+	fs.StringVar(&o.mqttUser, "mqtt-user", "", "mqtt user, the password is taken from MQTT_PASSWORD")
+	fs.StringVar(&o.serialPrefix, "serial-prefix", "9", "first digits of the serial numbers of the simulated sensors")
+	fs.Uint64Var(&o.seed, "seed", 1, "seed of the readings")
+	fs.DurationVar(&o.progress, "progress", 10*time.Second, "how often the progress is logged, 0 is never")
+	fs.BoolVar(&o.json, "json", false, "print the report as json")
+	fs.DurationVar(&o.pattern.adcPeriod, "adc-period", time.Second, "mean period of the adc readings")
+	fs.Int64Var(&o.pattern.adcStep, "adc-step", 20, "largest step of the adc random walk")
+	fs.Float64Var(&o.pattern.ccRate, "cc-rate", 0.1, "mean number of the cc openings and closings per second")
+	fs.Float64Var(&o.pattern.burstProb, "burst-prob", 0, "probability that a reading starts a burst")
+	fs.IntVar(&o.pattern.burstSize, "burst-size", 10, "number of the readings sent back to back in a burst")
+	fs.DurationVar(&o.pattern.skew, "skew", 0, "largest error of the device clocks, the http sink can't carry it")
+	fs.Float64Var(&o.pattern.duplicates, "duplicates", 0, "share of the events sent twice")
+	if err := fs.Parse(args); err != nil {
+		return o, err
+	}
+	o.types = strings.Split(*types, ",")
+	o.url = strings.TrimSuffix(o.url, "/")
+	return o, o.validate()
+}
+
+func (o options) validate() error {
+	var errs []error
+	check := func(ok bool, msg string) {
+		if !ok {
+			errs = append(errs, errors.New(msg))
+		}
+	}
+	for _, t := range o.types {
+		check(t == "cc" || t == "adc", fmt.Sprintf("types: unknown type %q", t))
+	}
+	check(len(o.serialPrefix) < 9 && strings.Trim(o.serialPrefix, "0123456789") == "", "serial-prefix: up to 8 digits")
+	width := 9 - len(o.serialPrefix)
+	check(o.sensors > 0 && o.sensors < pow10(width), "sensors: must be positive and fit the serial numbers")
+	check(o.duration > 0, "duration: must be positive")
+	check(slices.Contains([]string{"http", "batch", "mqtt"}, o.sink), "sink: must be http, batch or mqtt")
+	check(o.workers > 0, "workers: must be positive")
+	check(o.batch > 0 && o.batchWait > 0, "batch, batch-wait: must be positive")
+	check(o.pattern.adcPeriod > 0 && o.pattern.adcStep >= 0, "adc-period, adc-step: period must be positive")
+	check(o.pattern.ccRate > 0, "cc-rate: must be positive")
+	check(o.pattern.burstProb >= 0 && o.pattern.burstProb <= 1 && o.pattern.burstSize > 0, "burst-prob, burst-size: out of range")
+	check(o.pattern.duplicates >= 0 && o.pattern.duplicates <= 1, "duplicates: must be from 0 to 1")
+	check(o.pattern.skew >= 0, "skew: must not be negative")
+	return errors.Join(errs...)
+}
+
+func pow10(n int) int {
+	v := 1
+	for range n {
+		v *= 10
+	}
+	return v
+}
+
+func main() {
+	o, err := parseOptions(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	r, err := run(ctx, o)
+	if err != nil {
+		slog.Error("Simulation has failed", "error", err)
+		os.Exit(1)
+	}
+	if err := r.write(os.Stdout, o.json); err != nil {
+		slog.Error("Can't write report", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, o options) (report, error) {
+	client := &http.Client{Timeout: 10 * time.Second, Transport: &http.Transport{MaxIdleConnsPerHost: o.workers}}
+
+	var devices []*device
+	for ti, t := range o.types {
+		for i := 1; i <= o.sensors; i++ {
+			devices = append(devices, newDevice(serialNumber(o.serialPrefix, ti+1, i), t, o.seed, len(devices), o.pattern))
+		}
+	}
+	for _, d := range devices {
+		if err := registerSensor(ctx, client, o.url, d); err != nil {
+			return report{}, fmt.Errorf("can't register sensor %s: %w", d.serial, err)
+		}
+	}
+	slog.Info("Sensors are registered", "count", len(devices))
+
+	sinks := make([]sink, o.workers)
+	for i := range sinks {
+		switch o.sink {
+		case "http":
+			sinks[i] = &httpSink{client: client, url: o.url}
+		case "batch":
+			sinks[i] = &batchSink{client: client, url: o.url}
+		case "mqtt":
+			conn, err := dialMQTT(o.mqttAddr, "simulator-"+strconv.Itoa(i), o.mqttUser, os.Getenv("MQTT_PASSWORD"), 10*time.Second)
+			if err != nil {
+				return report{}, fmt.Errorf("can't connect to mqtt: %w", err)
+			}
+			sinks[i] = &mqttSink{conn: conn, topic: o.mqttTopic}
+		}
+	}
+
+	batch := 1
+	if o.sink == "batch" {
+		batch = o.batch
+	}
+	st := newStats()
+	out := make(chan event, o.workers*batch)
+	runCtx, cancel := context.WithTimeout(ctx, o.duration)
+	defer cancel()
+
+	start := time.Now()
+	var devicesDone sync.WaitGroup
+	for _, d := range devices {
+		devicesDone.Add(1)
+		go func() {
+			defer devicesDone.Done()
+			d.run(runCtx, out, func() { st.generated.Add(1) })
+		}()
+	}
+	go func() {
+		devicesDone.Wait()
+		close(out)
+	}()
+
+	// the events generated before the end are still delivered
+	sendCtx := context.WithoutCancel(ctx)
+	var workersDone sync.WaitGroup
+	for _, s := range sinks {
+		workersDone.Add(1)
+		go func() {
+			defer workersDone.Done()
+			defer s.close()
+			work(sendCtx, s, out, batch, o.batchWait, st)
+		}()
+	}
+
+	if o.progress > 0 {
+		go logProgress(runCtx, st, start, o.progress)
+	}
+	workersDone.Wait()
+
+	r := st.report(time.Since(start))
+	r.Sink, r.Sensors, r.Seed = o.sink, len(devices), o.seed
+	return r, nil
+}
+
+// work sends the events in batches of up to size, a batch that doesn't fill within wait is sent as it is
+func work(ctx context.Context, s sink, in <-chan event, size int, wait time.Duration, st *stats) {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	events := make([]event, 0, size)
+	flush := func() {
+		if len(events) == 0 {
+			return
+		}
+		started := time.Now()
+		err := s.send(ctx, events)
+		st.record(len(events), time.Since(started), err)
+		if err != nil {
+			slog.Debug("Events are not sent", "count", len(events), "error", err)
+		}
+		events = events[:0]
+	}
+
+	for {
+		select {
+		case e, ok := <-in:
+			if !ok {
+				flush()
+				return
+			}
+			events = append(events, e)
+			if len(events) >= size {
+				flush()
+			}
+		case <-timer.C:
+			flush()
+			timer.Reset(wait)
+		}
+	}
+}
+
+func logProgress(ctx context.Context, st *stats, start time.Time, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sent, failed := st.progress()
+			rate := float64(sent) / time.Since(start).Seconds()
+			slog.Info("Progress", "generated", st.generated.Load(), "sent", sent, "failed", failed,
+				"events_per_second", strconv.FormatFloat(rate, 'f', 1, 64))
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOptions(t *testing.T) {
+	t.Run("ok, defaults", func(t *testing.T) {
+		o, err := parseOptions(nil)
+		require.NoError(t, err)
+		assert.Equal(t, "http://localhost:8080/api/v1", o.url)
+		assert.Equal(t, []string{"cc", "adc"}, o.types)
+		assert.Equal(t, "http", o.sink)
+		assert.Equal(t, time.Second, o.pattern.adcPeriod)
+	})
+
+	t.Run("ok, flags", func(t *testing.T) {
+		o, err := parseOptions([]string{"-url", "http://server/api/v1/", "-types", "adc", "-sink", "batch",
+			"-serial-prefix", "12", "-sensors", "9999999", "-skew", "1s", "-duplicates", "0.5"})
+		require.NoError(t, err)
+		assert.Equal(t, "http://server/api/v1", o.url)
+		assert.Equal(t, []string{"adc"}, o.types)
+		assert.Equal(t, "batch", o.sink)
+		assert.Equal(t, time.Second, o.pattern.skew)
+		assert.Equal(t, 0.5, o.pattern.duplicates)
+	})
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"fail, unknown type", []string{"-types", "cc,pir"}, `types: unknown type "pir"`},
+		{"fail, prefix is not digits", []string{"-serial-prefix", "9a"}, "serial-prefix: up to 8 digits"},
+		{"fail, sensors don't fit the serials", []string{"-serial-prefix", "12345678", "-sensors", "10"}, "sensors: must be positive"},
+		{"fail, no sensors", []string{"-sensors", "0"}, "sensors: must be positive"},
+		{"fail, no duration", []string{"-duration", "0s"}, "duration: must be positive"},
+		{"fail, unknown sink", []string{"-sink", "kafka"}, "sink: must be http, batch or mqtt"},
+		{"fail, no workers", []string{"-workers", "0"}, "workers: must be positive"},
+		{"fail, empty batch", []string{"-batch", "0"}, "batch, batch-wait: must be positive"},
+		{"fail, no adc period", []string{"-adc-period", "0s"}, "adc-period, adc-step"},
+		{"fail, no cc rate", []string{"-cc-rate", "0"}, "cc-rate: must be positive"},
+		{"fail, burst probability", []string{"-burst-prob", "1.5"}, "burst-prob, burst-size: out of range"},
+		{"fail, duplicates", []string{"-duplicates", "-0.1"}, "duplicates: must be from 0 to 1"},
+		{"fail, negative skew", []string{"-skew", "-1s"}, "skew: must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseOptions(tt.args)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+// fakeSink records the batches it is sent
+type fakeSink struct {
+	mu      sync.Mutex
+	batches [][]event
+	err     error
+}
+
+func (s *fakeSink) send(_ context.Context, events []event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, append([]event(nil), events...))
+	return s.err
+}
+
+func (s *fakeSink) close() error { return nil }
+
+func TestWork(t *testing.T) {
+	events := func(n int) []event {
+		out := make([]event, n)
+		for i := range out {
+			out[i] = event{id: string(rune('a' + i)), serial: "9100000001", payload: int64(i)}
+		}
+		return out
+	}
+
+	t.Run("ok, full batches and the rest", func(t *testing.T) {
+		in := make(chan event, 10)
+		for _, e := range events(7) {
+			in <- e
+		}
+		close(in)
+
+		s, st := &fakeSink{}, newStats()
+		work(context.Background(), s, in, 3, time.Hour, st)
+
+		require.Len(t, s.batches, 3)
+		assert.Equal(t, events(7)[:3], s.batches[0])
+		assert.Equal(t, events(7)[3:6], s.batches[1])
+		assert.Equal(t, events(7)[6:], s.batches[2], "Остаток должен отправиться при закрытии")
+		r := st.report(time.Second)
+		assert.Equal(t, int64(7), r.Sent)
+		assert.Equal(t, 3, r.Requests)
+	})
+
+	t.Run("ok, batch that doesn't fill is sent after the wait", func(t *testing.T) {
+		in := make(chan event)
+		s := &fakeSink{}
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			work(context.Background(), s, in, 100, 20*time.Millisecond, newStats())
+		}()
+
+		in <- events(1)[0]
+		assert.Eventually(t, func() bool {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return len(s.batches) == 1
+		}, time.Second, 5*time.Millisecond, "Неполный пакет должен отправиться по таймеру")
+		close(in)
+		<-done
+	})
+
+	t.Run("ok, failures are counted", func(t *testing.T) {
+		in := make(chan event, 4)
+		for _, e := range events(4) {
+			in <- e
+		}
+		close(in)
+
+		st := newStats()
+		work(context.Background(), &fakeSink{err: &statusError{status: http.StatusTooManyRequests}}, in, 2, time.Hour, st)
+		r := st.report(time.Second)
+		assert.Equal(t, int64(0), r.Sent)
+		assert.Equal(t, int64(4), r.Failed)
+		assert.Equal(t, map[string]int64{"status 429": 2}, r.Errors)
+	})
+}
+
+// apiServer is a fake api that counts the registered sensors and the events it receives
+type apiServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	sensors map[string]string
+	events  []map[string]any
+	reject  int
+}
+
+func newAPIServer(t *testing.T) *apiServer {
+	t.Helper()
+	s := &apiServer{sensors: map[string]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/sensors", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			SerialNumber string `json:"serial_number"`
+			Type         string `json:"type"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		s.mu.Lock()
+		s.sensors[body.SerialNumber] = body.Type
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("POST /api/v1/events", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		s.receive(w, body)
+	})
+	mux.HandleFunc("POST /api/v1/imports/events", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			var body map[string]any
+			assert.NoError(t, json.Unmarshal(sc.Bytes(), &body))
+			s.receive(nil, body)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *apiServer) receive(w http.ResponseWriter, body map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w != nil && s.reject != 0 {
+		w.WriteHeader(s.reject)
+		_, _ = w.Write([]byte("rejected\n"))
+		return
+	}
+	s.events = append(s.events, body)
+	if w != nil {
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+func (s *apiServer) received() []map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]any(nil), s.events...)
+}
+
+func testOptions(url, sink string) options {
+	o, err := parseOptions([]string{"-url", url + "/api/v1", "-sink", sink, "-sensors", "3", "-duration", "300ms",
+		"-workers", "2", "-batch", "5", "-batch-wait", "20ms", "-adc-period", "20ms", "-cc-rate", "50", "-progress", "0"})
+	if err != nil {
+		panic(err)
+	}
+	return o
+}
+
+func TestRun(t *testing.T) {
+	t.Run("ok, http sink", func(t *testing.T) {
+		server := newAPIServer(t)
+		o := testOptions(server.URL, "http")
+
+		r, err := run(context.Background(), o)
+		require.NoError(t, err)
+
+		assert.Len(t, server.sensors, 6, "Должны быть зарегистрированы датчики каждого типа")
+		assert.Equal(t, "cc", server.sensors["9100000001"])
+		assert.Equal(t, "adc", server.sensors["9200000003"])
+
+		events := server.received()
+		// 6 sensors at about 50 events per second for 0.3s, the scheduler may only delay them
+		assert.Greater(t, len(events), 20)
+		assert.Less(t, len(events), 200)
+		assert.Equal(t, int64(len(events)), r.Sent, "Все созданные события должны быть доставлены")
+		assert.Equal(t, r.Generated, r.Sent)
+		assert.Equal(t, len(events), r.Requests, "http отправляет события по одному")
+		assert.Zero(t, r.Failed)
+		assert.Equal(t, "http", r.Sink)
+		assert.Equal(t, 6, r.Sensors)
+		for _, e := range events {
+			assert.Contains(t, e, "id")
+			assert.NotContains(t, e, "timestamp", "Время событий http ставит сервер")
+		}
+	})
+
+	t.Run("ok, batch sink", func(t *testing.T) {
+		server := newAPIServer(t)
+		o := testOptions(server.URL, "batch")
+		o.pattern.skew = time.Hour
+
+		started := time.Now()
+		r, err := run(context.Background(), o)
+		require.NoError(t, err)
+
+		events := server.received()
+		assert.Equal(t, int64(len(events)), r.Sent)
+		assert.Equal(t, r.Generated, r.Sent)
+		assert.Less(t, r.Requests, len(events), "Пакетный приёмник должен отправлять пакетами")
+		var skewed bool
+		for _, e := range events {
+			ts, err := time.Parse(time.RFC3339Nano, e["timestamp"].(string))
+			require.NoError(t, err)
+			skewed = skewed || ts.Sub(started).Abs() > time.Minute
+		}
+		assert.True(t, skewed, "Пакетный приёмник должен передавать время устройств")
+	})
+
+	t.Run("ok, rejected events are reported", func(t *testing.T) {
+		server := newAPIServer(t)
+		server.reject = http.StatusTooManyRequests
+
+		r, err := run(context.Background(), testOptions(server.URL, "http"))
+		require.NoError(t, err)
+		assert.Zero(t, r.Sent)
+		assert.Equal(t, r.Generated, r.Failed)
+		assert.Equal(t, map[string]int64{"status 429": r.Failed}, r.Errors)
+	})
+
+	t.Run("ok, stops on cancel", func(t *testing.T) {
+		server := newAPIServer(t)
+		o := testOptions(server.URL, "http")
+		o.duration = time.Hour
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+		started := time.Now()
+		r, err := run(ctx, o)
+		require.NoError(t, err)
+		assert.Less(t, time.Since(started), 5*time.Second, "Симуляция должна остановиться по отмене")
+		assert.Equal(t, r.Generated, r.Sent, "Созданные до отмены события должны быть доставлены")
+	})
+
+	t.Run("fail, sensor is not registered", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"detail":"invalid type"}`))
+		}))
+		t.Cleanup(server.Close)
+
+		_, err := run(context.Background(), testOptions(server.URL, "http"))
+		var se *statusError
+		require.True(t, errors.As(err, &se))
+		assert.Equal(t, http.StatusUnprocessableEntity, se.status)
+		assert.ErrorContains(t, err, "can't register sensor 9100000001")
+	})
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// MQTT 3.1.1 control packets, the simulator only connects and publishes with QoS 0
+const (
+	mqttConnect    = 0x10
+	mqttConnack    = 0x20
+	mqttPublish    = 0x30
+	mqttDisconnect = 0xe0
+)
+
+var errMQTTRefused = errors.New("mqtt connection refused")
+
+// mqttConn is a minimal MQTT publisher, enough to feed a broker without a client library
+type mqttConn struct {
+	conn net.Conn
+	w    *bufio.Writer
+}
+
+func dialMQTT(addr, clientID, user, password string, timeout time.Duration) (*mqttConn, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &mqttConn{conn: conn, w: bufio.NewWriter(conn)}
+
+	// the protocol name, level 4, the flags and no keep alive, so no pings are needed
+	flags := byte(0x02) // clean session
+	payload := mqttString(clientID)
+	if user != "" {
+		flags |= 0x80
+		payload = append(payload, mqttString(user)...)
+		if password != "" {
+			flags |= 0x40
+			payload = append(payload, mqttString(password)...)
+		}
+	}
+	body := append(mqttString("MQTT"), 4, flags, 0, 0)
+	body = append(body, payload...)
+
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	if err := c.write(mqttConnect, body); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	ack := make([]byte, 4)
+	if _, err := io.ReadFull(conn, ack); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("can't read connack: %w", err)
+	}
+	if ack[0] != mqttConnack || ack[3] != 0 {
+		conn.Close()
+		return nil, fmt.Errorf("%w: code %d", errMQTTRefused, ack[3])
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return c, nil
+}
+
+func (c *mqttConn) publish(topic string, payload []byte) error {
+	return c.write(mqttPublish, append(mqttString(topic), payload...))
+}
+
+func (c *mqttConn) close() error {
+	_ = c.write(mqttDisconnect, nil)
+	return c.conn.Close()
+}
+
+func (c *mqttConn) write(packetType byte, body []byte) error {
+	header := []byte{packetType}
+	// the remaining length is a varint of 7 bits per byte
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		header = append(header, b)
+		if n == 0 {
+			break
+		}
+	}
+	if _, err := c.w.Write(header); err != nil {
+		return err
+	}
+	if _, err := c.w.Write(body); err != nil {
+		return err
+	}
+	return c.w.Flush()
+}
+
+// mqttString is a string prefixed with its big-endian length
+func mqttString(s string) []byte {
+	return append([]byte{byte(len(s) >> 8), byte(len(s))}, s...)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// stats collects the results of the workers
+type stats struct {
+	generated atomic.Int64
+
+	mu        sync.Mutex
+	sent      int64
+	failed    int64
+	errors    map[string]int64
+	latencies []time.Duration
+}
+
+func newStats() *stats {
+	return &stats{errors: map[string]int64{}}
+}
+
+// record counts a request with n events
+func (s *stats) record(n int, latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latencies = append(s.latencies, latency)
+	if err == nil {
+		s.sent += int64(n)
+		return
+	}
+	s.failed += int64(n)
+	var se *statusError
+	if errors.As(err, &se) {
+		s.errors[fmt.Sprintf("status %d", se.status)]++
+	} else {
+		s.errors["transport"]++
+	}
+}
+
+func (s *stats) progress() (sent, failed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sent, s.failed
+}
+
+type latencyReport struct {
+	P50 float64 `json:"p50_ms"`
+	P90 float64 `json:"p90_ms"`
+	P99 float64 `json:"p99_ms"`
+	Max float64 `json:"max_ms"`
+}
+
+// report is the result of a run, in JSON it can be kept to compare the runs
+type report struct {
+	Sink       string           `json:"sink"`
+	Sensors    int              `json:"sensors"`
+	Seed       uint64           `json:"seed"`
+	Seconds    float64          `json:"seconds"`
+	Generated  int64            `json:"generated"`
+	Sent       int64            `json:"sent"`
+	Failed     int64            `json:"failed"`
+	Requests   int              `json:"requests"`
+	Throughput float64          `json:"events_per_second"`
+	Latency    latencyReport    `json:"latency"`
+	Errors     map[string]int64 `json:"errors,omitempty"`
+}
+
+func (s *stats) report(elapsed time.Duration) report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	latencies := slices.Clone(s.latencies)
+	slices.Sort(latencies)
+	r := report{
+		Seconds:   elapsed.Seconds(),
+		Generated: s.generated.Load(),
+		Sent:      s.sent,
+		Failed:    s.failed,
+		Requests:  len(latencies),
+		Latency: latencyReport{
+			P50: percentile(latencies, 50),
+			P90: percentile(latencies, 90),
+			P99: percentile(latencies, 99),
+			Max: percentile(latencies, 100),
+		},
+	}
+	if elapsed > 0 {
+		r.Throughput = float64(s.sent) / elapsed.Seconds()
+	}
+	if len(s.errors) > 0 {
+		r.Errors = s.errors
+	}
+	return r
+}
+
+// percentile takes the nearest rank of the sorted latencies in milliseconds
+func percentile(sorted []time.Duration, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	rank = min(max(rank, 1), len(sorted))
+	return float64(sorted[rank-1]) / float64(time.Millisecond)
+}
+
+func (r report) write(w io.Writer, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "sink:       %s, %d sensors, seed %d\n", r.Sink, r.Sensors, r.Seed)
+	fmt.Fprintf(&b, "events:     %d generated, %d sent, %d failed in %d requests\n", r.Generated, r.Sent, r.Failed, r.Requests)
+	fmt.Fprintf(&b, "throughput: %.1f events/s over %.1fs\n", r.Throughput, r.Seconds)
+	fmt.Fprintf(&b, "latency:    p50 %.2fms, p90 %.2fms, p99 %.2fms, max %.2fms\n", r.Latency.P50, r.Latency.P90, r.Latency.P99, r.Latency.Max)
+	if len(r.Errors) > 0 {
+		keys := make([]string, 0, len(r.Errors))
+		for k := range r.Errors {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = fmt.Sprintf("%s: %d", k, r.Errors[k])
+		}
+		fmt.Fprintf(&b, "errors:     %s\n", strings.Join(parts, ", "))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
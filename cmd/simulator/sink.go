@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// sink delivers the events of a worker, every call is one request whose latency is measured
+type sink interface {
+	send(ctx context.Context, events []event) error
+	close() error
+}
+
+// statusError is a response the server has rejected, the report counts them by status
+type statusError struct {
+	status int
+	body   string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.status, e.body)
+}
+
+func checkResponse(resp *http.Response) error {
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return &statusError{status: resp.StatusCode, body: string(bytes.TrimSpace(body))}
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	return checkResponse(resp)
+}
+
+// httpSink posts the events one by one, the server stamps them with its own time. The event id makes
+// a duplicate an idempotent retry.
+type httpSink struct {
+	client *http.Client
+	url    string
+}
+
+func (s *httpSink) send(ctx context.Context, events []event) error {
+	for _, e := range events {
+		body := map[string]any{"id": e.id, "sensor_serial_number": e.serial, "payload": e.payload}
+		if err := postJSON(ctx, s.client, s.url+"/events", body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *httpSink) close() error { return nil }
+
+// batchSink posts the events as NDJSON to the import, the timestamps are the ones of the devices,
+// so the clock skew reaches the server and a duplicate is skipped by its timestamp
+type batchSink struct {
+	client *http.Client
+	url    string
+}
+
+func (s *batchSink) send(ctx context.Context, events []event) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, e := range events {
+		rec := map[string]any{"sensor_serial_number": e.serial, "timestamp": e.timestamp.Format(time.RFC3339Nano), "payload": e.payload}
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url+"/imports/events", &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	return checkResponse(resp)
+}
+
+func (s *batchSink) close() error { return nil }
+
+// mqttSink publishes every event to the topic of its sensor, the payload is the JSON of the event.
+// QoS 0 has no acknowledgement, so the latency is the time of the write.
+type mqttSink struct {
+	conn  *mqttConn
+	topic string
+}
+
+func (s *mqttSink) send(_ context.Context, events []event) error {
+	for _, e := range events {
+		payload, err := json.Marshal(map[string]any{
+			"id": e.id, "sensor_serial_number": e.serial, "timestamp": e.timestamp.Format(time.RFC3339Nano), "payload": e.payload,
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.conn.publish(fmt.Sprintf(s.topic, e.serial), payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *mqttSink) close() error { return s.conn.close() }
+
+// registerSensor registers the sensor over the api, a sensor registered before is returned as it is
+func registerSensor(ctx context.Context, client *http.Client, url string, d *device) error {
+	body := map[string]any{
+		"serial_number": d.serial,
+		"type":          d.sensorType,
+		"description":   "Simulated " + d.sensorType,
+		"is_active":     true,
+	}
+	return postJSON(ctx, client, url+"/sensors", body)
+}
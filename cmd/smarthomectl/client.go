@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"nhooyr.io/websocket"
+)
+
+const apiPrefix = "/api/v1"
+
+// apiError is a response the server has rejected, it carries the problem the server has described
+type apiError struct {
+	status int
+	code   string
+	detail string
+}
+
+func (e *apiError) Error() string {
+	if e.code == "" {
+		return fmt.Sprintf("status %d: %s", e.status, e.detail)
+	}
+	return fmt.Sprintf("status %d, %s: %s", e.status, e.code, e.detail)
+}
+
+// client calls the REST api of the controller
+type client struct {
+	http   *http.Client
+	server string
+	token  string
+}
+
+func newClient(c config) *client {
+	return &client{http: &http.Client{Timeout: 30 * time.Second}, server: c.Server, token: c.Token}
+}
+
+// do sends the request with in encoded as the JSON body, if any, and decodes the response into out, if any
+func (c *client) do(ctx context.Context, method, path string, query url.Values, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		js, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(js)
+	}
+
+	u := c.server + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return readError(resp)
+	}
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// readError takes the problem the server has responded with, a body that is not a problem is shown as it is
+func readError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	e := &apiError{status: resp.StatusCode}
+	var problem struct {
+		Code   string `json:"code"`
+		Detail string `json:"detail"`
+		Reason string `json:"reason"`
+		Errors []struct {
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &problem); err != nil || problem.Detail == "" && problem.Reason == "" {
+		e.detail = string(bytes.TrimSpace(data))
+		return e
+	}
+
+	e.code, e.detail = problem.Code, problem.Detail
+	if e.detail == "" {
+		e.detail = problem.Reason
+	}
+	for _, fe := range problem.Errors {
+		e.detail += fmt.Sprintf("; %s: %s", fe.Field, fe.Message)
+	}
+	return e
+}
+
+// dial opens the websocket at the path, the scheme follows the one of the server
+func (c *client) dial(ctx context.Context, path string) (*websocket.Conn, error) {
+	u := c.server + path
+	switch {
+	case strings.HasPrefix(u, "https://"):
+		u = "wss://" + strings.TrimPrefix(u, "https://")
+	case strings.HasPrefix(u, "http://"):
+		u = "ws://" + strings.TrimPrefix(u, "http://")
+	}
+
+	opts := &websocket.DialOptions{HTTPHeader: http.Header{}}
+	if c.token != "" {
+		opts.HTTPHeader.Set("Authorization", "Bearer "+c.token)
+	}
+	conn, resp, err := websocket.Dial(ctx, u, opts)
+	if err != nil {
+		if resp != nil && resp.StatusCode >= 400 {
+			return nil, readError(resp)
+		}
+		return nil, err
+	}
+	return conn, nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// environment variables override the config file, the flags override both
+const (
+	configEnv   = "SMARTHOMECTL_CONFIG"
+	serverEnv   = "SMARTHOME_SERVER"
+	tokenEnv    = "SMARTHOME_TOKEN"
+	databaseEnv = "DATABASE_URL"
+)
+
+var errUnknownFormat = errors.New("output must be table, json or csv")
+
+// config is what the commands need to reach the controller
+type config struct {
+	Server string `yaml:"server"`
+	// Token is sent as a bearer token, the server itself doesn't check it, a proxy in front of it may
+	Token  string `yaml:"token"`
+	Output string `yaml:"output"`
+	// Database is used only by the migrations, the other commands go through the api
+	Database string `yaml:"database"`
+}
+
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "smarthomectl", "config.yaml")
+}
+
+// loadConfig parses the global flags and merges them with the environment and the config file.
+// A missing default config file is not an error, a missing explicit one is.
+func loadConfig(args []string, stderr io.Writer) (config, []string, error) {
+	c := config{Server: "http://localhost:8080", Output: "table"}
+
+	fs := flag.NewFlagSet("smarthomectl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { usage(fs) }
+	path := fs.String("config", "", "path to the config file, also taken from "+configEnv+" (default "+defaultConfigPath()+")")
+	server := fs.String("server", "", "address of the controller, also taken from "+serverEnv+" (default "+c.Server+")")
+	token := fs.String("token", "", "bearer token, also taken from "+tokenEnv)
+	output := fs.String("o", "", "output format: table, json or csv (default table)")
+	if err := fs.Parse(args); err != nil {
+		return c, nil, err
+	}
+
+	explicit := true
+	if *path == "" {
+		*path = os.Getenv(configEnv)
+	}
+	if *path == "" {
+		*path, explicit = defaultConfigPath(), false
+	}
+	if *path != "" {
+		if err := readConfigFile(*path, &c); err != nil && (explicit || !errors.Is(err, os.ErrNotExist)) {
+			return c, nil, err
+		}
+	}
+
+	for _, v := range []struct {
+		dst        *string
+		env, value string
+	}{
+		{&c.Server, serverEnv, *server},
+		{&c.Token, tokenEnv, *token},
+		{&c.Database, databaseEnv, ""},
+		{&c.Output, "", *output},
+	} {
+		if env := os.Getenv(v.env); v.env != "" && env != "" {
+			*v.dst = env
+		}
+		if v.value != "" {
+			*v.dst = v.value
+		}
+	}
+	c.Server = strings.TrimSuffix(c.Server, "/")
+
+	if c.Output != "table" && c.Output != "json" && c.Output != "csv" {
+		return c, nil, fmt.Errorf("%w, got %q", errUnknownFormat, c.Output)
+	}
+	return c, fs.Args(), nil
+}
+
+func readConfigFile(path string, c *config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("can't parse %s: %w", path, err)
+	}
+	return nil
+}
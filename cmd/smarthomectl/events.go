@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"homework/internal/domain"
+	"homework/internal/gateways/http/models"
+
+	"nhooyr.io/websocket"
+)
+
+var eventHeader = []string{"TIMESTAMP", "PAYLOAD", "READINGS", "RAW READINGS"}
+
+func runEvents(ctx context.Context, e *env, args []string) error {
+	return subcommand(ctx, e, args, map[string]func(context.Context, *env, []string) error{
+		"tail": tailEvents,
+	})
+}
+
+// the backoff of the reconnects after the server has restarted, it is reset once a connection is made
+var (
+	tailBackoff    = 500 * time.Millisecond
+	tailMaxBackoff = 30 * time.Second
+)
+
+// tailEvents prints the events of the sensor as the websocket delivers them, until it is interrupted
+// or the server closes the connection. A server that is restarting is reconnected to with a backoff,
+// the events sent while it is away are not delivered.
+func tailEvents(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("events tail", flag.ContinueOnError)
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	id, err := parseID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	t := &tailer{env: e, path: sensorPath(id) + "/events"}
+	delay, reconnecting := tailBackoff, false
+	for {
+		connected, err := t.tail(ctx)
+		if connected {
+			delay, reconnecting = tailBackoff, false
+		}
+		switch {
+		case err == nil:
+			return nil
+		case websocket.CloseStatus(err) == websocket.StatusServiceRestart:
+			reconnecting = true
+		case !reconnecting || !retryDial(err):
+			return err
+		}
+
+		fmt.Fprintf(e.log, "reconnecting in %s: %v\n", delay, err)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, tailMaxBackoff)
+	}
+}
+
+// tailer prints the events of the connections one after another, the header goes before the first one
+type tailer struct {
+	env  *env
+	path string
+	w    *streamWriter
+}
+
+// tail prints the events of one connection, connected tells whether the websocket was opened at all
+func (t *tailer) tail(ctx context.Context) (connected bool, err error) {
+	conn, err := t.env.client.dial(ctx, t.path)
+	if err != nil {
+		return false, err
+	}
+	defer conn.CloseNow()
+
+	if t.w == nil {
+		if t.w, err = newStreamWriter(t.env.out, t.env.config.Output, eventHeader); err != nil {
+			return true, err
+		}
+	}
+	for {
+		_, data, err := conn.Read(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return true, conn.Close(websocket.StatusNormalClosure, "")
+		case websocket.CloseStatus(err) == websocket.StatusNormalClosure || websocket.CloseStatus(err) == websocket.StatusGoingAway:
+			return true, nil
+		case err != nil:
+			return true, err
+		}
+
+		var event domain.Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			return true, fmt.Errorf("can't decode event: %w", err)
+		}
+		dto := historyEvent(event)
+		if err := t.w.write(historyRow(&dto), dto); err != nil {
+			return true, err
+		}
+	}
+}
+
+// retryDial tells whether a failed dial is worth repeating while the server restarts: it may be down
+// or not ready yet, but a rejected request, like the one of a deleted sensor, is going to be rejected again
+func retryDial(err error) bool {
+	var apiErr *apiError
+	return !errors.As(err, &apiErr) || apiErr.status >= http.StatusInternalServerError
+}
+
+// historyEvent turns an event of the websocket into the form of the history, so both are printed alike
+func historyEvent(event domain.Event) models.HistoryEvent {
+	ts, payload := event.Timestamp.Unix(), event.Payload.Int()
+	return models.HistoryEvent{
+		Timestamp:   &ts,
+		Payload:     &payload,
+		Readings:    readingsDto(event.Payload),
+		RawReadings: readingsDto(event.Raw),
+	}
+}
+
+func readingsDto(p domain.Payload) []*models.Reading {
+	if len(p) == 0 {
+		return nil
+	}
+	readings := make([]*models.Reading, len(p))
+	for i, r := range p {
+		value := json.Number(r.Value.String())
+		readings[i] = &models.Reading{Channel: &r.Channel, Value: &value, Unit: r.Unit}
+	}
+	return readings
+}
+
+func historyRow(h *models.HistoryEvent) []string {
+	return []string{
+		time.Unix(deref(h.Timestamp), 0).UTC().Format(time.RFC3339),
+		strconv.FormatInt(deref(h.Payload), 10),
+		readingsString(h.Readings),
+		readingsString(h.RawReadings),
+	}
+}
+
+// runHistory dumps the events of the sensor for the period, the last day by default
+func runHistory(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	from := fs.String("from", "24h", "start of the period: RFC 3339 time, unix seconds or a duration ago like 2h")
+	to := fs.String("to", "0s", "end of the period, in the same forms as -from")
+	channel := fs.String("channel", "", "leave only the readings of the channel")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	id, err := parseID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	start, err1 := parseTime(*from, now)
+	end, err2 := parseTime(*to, now)
+	if err := errors.Join(err1, err2); err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	query := url.Values{}
+	query.Set("start_date", strconv.FormatInt(start.Unix(), 10))
+	query.Set("end_date", strconv.FormatInt(end.Unix(), 10))
+	if *channel != "" {
+		query.Set("channel", *channel)
+	}
+	var events []*models.HistoryEvent
+	if err := e.client.do(ctx, http.MethodGet, sensorPath(id)+"/history", query, nil, &events); err != nil {
+		return err
+	}
+
+	t := table{header: eventHeader, v: events}
+	for _, h := range events {
+		t.rows = append(t.rows, historyRow(h))
+	}
+	return e.print(t)
+}
+
+// parseTime takes an RFC 3339 time, unix seconds or a duration before now
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if v, err := strconv.ParseInt(s, 10, 64); err == nil && v >= 0 {
+		return time.Unix(v, 0), nil
+	}
+	if d, err := time.ParseDuration(strings.TrimPrefix(s, "-")); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("can't parse time %q", s)
+}
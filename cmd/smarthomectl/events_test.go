@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"homework/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"nhooyr.io/websocket"
+)
+
+// session is what the fake server does with one connection: it rejects it with the status
+// or sends the events and closes it with the code
+type session struct {
+	status int
+	events []int64
+	close  websocket.StatusCode
+}
+
+// eventsServer plays the sessions one by one, the last one is repeated
+func eventsServer(t *testing.T, sessions ...session) (*httptest.Server, func() int) {
+	t.Helper()
+	var (
+		mu    sync.Mutex
+		count int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/sensors/7/events", r.URL.Path)
+		mu.Lock()
+		s := sessions[min(count, len(sessions)-1)]
+		count++
+		mu.Unlock()
+
+		if s.status != 0 {
+			w.WriteHeader(s.status)
+			_, _ = w.Write([]byte(`{"code":"rejected","detail":"rejected"}`))
+			return
+		}
+		conn, err := websocket.Accept(w, r, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.CloseNow()
+		for _, v := range s.events {
+			event := domain.Event{Timestamp: time.Unix(1714557600+v, 0), SensorID: 7, Payload: domain.IntPayload(v)}
+			js, err := json.Marshal(event)
+			require.NoError(t, err)
+			if err := conn.Write(r.Context(), websocket.MessageText, js); err != nil {
+				return
+			}
+		}
+		if s.close != 0 {
+			_ = conn.Close(s.close, "")
+			return
+		}
+		// the session with no close code stays open until the client goes
+		_, _, _ = conn.Read(r.Context())
+	}))
+	t.Cleanup(server.Close)
+	return server, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return count
+	}
+}
+
+// fastBackoff makes the reconnects of the test quick
+func fastBackoff(t *testing.T, backoff, max time.Duration) {
+	t.Helper()
+	prev, prevMax := tailBackoff, tailMaxBackoff
+	tailBackoff, tailMaxBackoff = backoff, max
+	t.Cleanup(func() { tailBackoff, tailMaxBackoff = prev, prevMax })
+}
+
+func TestTailEvents(t *testing.T) {
+	const header = "TIMESTAMP\tPAYLOAD\tREADINGS\tRAW READINGS\n"
+	row := func(v int64) string {
+		n := strconv.FormatInt(v, 10)
+		return time.Unix(1714557600+v, 0).UTC().Format(time.RFC3339) + "\t" + n + "\tvalue=" + n + "\t\n"
+	}
+
+	tests := []struct {
+		name        string
+		sessions    []session
+		code        int
+		stdout      string
+		connections int
+		stderr      []string
+	}{
+		{
+			name:        "ok, closed by the server",
+			sessions:    []session{{events: []int64{1, 2}, close: websocket.StatusNormalClosure}},
+			stdout:      header + row(1) + row(2),
+			connections: 1,
+		},
+		{
+			name:        "ok, server is going away",
+			sessions:    []session{{events: []int64{1}, close: websocket.StatusGoingAway}},
+			stdout:      header + row(1),
+			connections: 1,
+		},
+		{
+			name: "ok, reconnected after a restart",
+			sessions: []session{
+				{events: []int64{1}, close: websocket.StatusServiceRestart},
+				{events: []int64{2}, close: websocket.StatusNormalClosure},
+			},
+			stdout:      header + row(1) + row(2),
+			connections: 2,
+			stderr:      []string{"reconnecting in 1ms"},
+		},
+		{
+			name: "ok, server is not up yet after a restart",
+			sessions: []session{
+				{events: []int64{1}, close: websocket.StatusServiceRestart},
+				{status: http.StatusServiceUnavailable},
+				{status: http.StatusBadGateway},
+				{events: []int64{2}, close: websocket.StatusNormalClosure},
+			},
+			stdout:      header + row(1) + row(2),
+			connections: 4,
+			stderr:      []string{"reconnecting in 1ms", "reconnecting in 2ms", "reconnecting in 3ms: status 502"},
+		},
+		{
+			name: "ok, backoff is reset by a connection",
+			sessions: []session{
+				{close: websocket.StatusServiceRestart},
+				{status: http.StatusServiceUnavailable},
+				{close: websocket.StatusServiceRestart},
+				{close: websocket.StatusNormalClosure},
+			},
+			stdout:      header,
+			connections: 4,
+			stderr:      []string{"reconnecting in 1ms", "reconnecting in 2ms", "reconnecting in 1ms"},
+		},
+		{
+			name:        "fail, sensor is not found",
+			sessions:    []session{{status: http.StatusNotFound}},
+			code:        1,
+			connections: 1,
+			stderr:      []string{"error: status 404, rejected: rejected"},
+		},
+		{
+			name:        "fail, server is unavailable at first",
+			sessions:    []session{{status: http.StatusServiceUnavailable}},
+			code:        1,
+			connections: 1,
+			stderr:      []string{"error: status 503"},
+		},
+		{
+			name: "fail, sensor is deleted while the server restarts",
+			sessions: []session{
+				{events: []int64{1}, close: websocket.StatusServiceRestart},
+				{status: http.StatusNotFound},
+			},
+			code:        1,
+			stdout:      header + row(1),
+			connections: 2,
+			stderr:      []string{"reconnecting in 1ms", "error: status 404"},
+		},
+		{
+			name:        "fail, closed with an error",
+			sessions:    []session{{events: []int64{1}, close: websocket.StatusInternalError}},
+			code:        1,
+			stdout:      header + row(1),
+			connections: 1,
+			stderr:      []string{"error: "},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fastBackoff(t, time.Millisecond, 3*time.Millisecond)
+			server, connections := eventsServer(t, tt.sessions...)
+
+			code, stdout, stderr := ctl(t, server, "events", "tail", "7")
+			assert.Equal(t, tt.code, code, "Неверный код выхода, stderr: %s", stderr)
+			assert.Equal(t, tt.stdout, stdout)
+			assert.Equal(t, tt.connections, connections(), "Неверное число подключений")
+			for _, s := range tt.stderr {
+				assert.Contains(t, stderr, s)
+			}
+			if len(tt.stderr) == 0 {
+				assert.Empty(t, stderr)
+			}
+		})
+	}
+
+	t.Run("ok, json lines", func(t *testing.T) {
+		server, _ := eventsServer(t, session{events: []int64{1}, close: websocket.StatusNormalClosure})
+		code, stdout, _ := ctl(t, server, "-o", "json", "events", "tail", "7")
+		require.Equal(t, 0, code)
+		assert.JSONEq(t, `{"timestamp":1714557601,"payload":1,"readings":[{"channel":"value","value":1}]}`, stdout)
+	})
+
+	t.Run("ok, interrupted", func(t *testing.T) {
+		server, connections := eventsServer(t, session{events: []int64{1}})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		code, stdout, stderr := ctlContext(ctx, t, server, "events", "tail", "7")
+		assert.Equal(t, 0, code, "Прерванная команда должна завершиться успешно, stderr: %s", stderr)
+		assert.Equal(t, header+row(1), stdout)
+		assert.Equal(t, 1, connections())
+	})
+
+	t.Run("ok, interrupted while reconnecting", func(t *testing.T) {
+		fastBackoff(t, time.Hour, time.Hour)
+		server, connections := eventsServer(t, session{close: websocket.StatusServiceRestart})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		code, _, stderr := ctlContext(ctx, t, server, "events", "tail", "7")
+		assert.Equal(t, 0, code, "Прерванная команда должна завершиться успешно, stderr: %s", stderr)
+		assert.Contains(t, stderr, "reconnecting in 1h0m0s")
+		assert.Equal(t, 1, connections())
+	})
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "2024-05-01T10:00:00Z", want: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{in: "1714557600", want: time.Unix(1714557600, 0)},
+		{in: "2h", want: now.Add(-2 * time.Hour)},
+		{in: "-30m", want: now.Add(-30 * time.Minute)},
+		{in: "0s", want: now},
+		{in: "-5", wantErr: true},
+		{in: "yesterday", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseTime(tt.in, now)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "want %s, got %s", tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"sort"
+)
+
+var errUnhealthy = errors.New("controller is not healthy")
+
+type probe struct {
+	Probe  string            `json:"probe"`
+	Code   int               `json:"code"`
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// runHealth asks the liveness and the readiness probes, a failing one fails the command,
+// so it can be used in the scripts
+func runHealth(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("health", flag.ContinueOnError)
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	probes := make([]probe, 0, 2)
+	healthy := true
+	for _, path := range []string{"/healthz", "/readyz"} {
+		p, err := getProbe(ctx, e.client, path)
+		if err != nil {
+			return err
+		}
+		healthy = healthy && p.Code == http.StatusOK
+		probes = append(probes, p)
+	}
+
+	t := table{header: []string{"PROBE", "STATUS", "CHECK", "RESULT"}, v: probes}
+	for _, p := range probes {
+		names := make([]string, 0, len(p.Checks))
+		for name := range p.Checks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			t.rows = append(t.rows, []string{p.Probe, p.Status, "", ""})
+		}
+		for _, name := range names {
+			t.rows = append(t.rows, []string{p.Probe, p.Status, name, p.Checks[name]})
+		}
+	}
+	if err := e.print(t); err != nil {
+		return err
+	}
+	if !healthy {
+		return errUnhealthy
+	}
+	return nil
+}
+
+// getProbe reads the probe, 503 is a valid answer of a failing one
+func getProbe(ctx context.Context, c *client, path string) (probe, error) {
+	p := probe{Probe: path}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.server+path, nil)
+	if err != nil {
+		return p, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return p, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		return p, readError(resp)
+	}
+	p.Code = resp.StatusCode
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+		return p, err
+	}
+	return p, nil
+}
@@ -0,0 +1,132 @@
+// Command smarthomectl operates the controller through its REST api: it manages the sensors and the users,
+// tails the live events, dumps the history, checks the health and applies the schema migrations.
+// It also inspects and compacts the persistence directory of the in-memory server.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+)
+
+var errUsage = errors.New("usage")
+
+// env is what every command runs with
+type env struct {
+	config config
+	client *client
+	out    io.Writer
+	// log takes the notes beside the output, like the reconnects of a tail, so they don't mix with it
+	log io.Writer
+}
+
+func (e *env) print(t table) error {
+	return t.write(e.out, e.config.Output)
+}
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, e *env, args []string) error
+}
+
+// commands are the top level commands, the groups dispatch their own subcommands
+var commands = map[string]command{
+	"sensors": {"sensors list|get|register|activate|deactivate", runSensors},
+	"users":   {"users create|bind|sensors", runUsers},
+	"events":  {"events tail <sensor_id>", runEvents},
+	"history": {"history [-from time] [-to time] [-channel name] <sensor_id>", runHistory},
+	"health":  {"health", runHealth},
+	"migrate": {"migrate [-database url] up|down|version", runMigrate},
+	"wal":     {"wal inspect [-v] <dir> | wal compact <dir>", runWAL},
+}
+
+func usage(fs *flag.FlagSet) {
+	fmt.Fprintln(fs.Output(), "Usage: smarthomectl [flags] <command> [args]")
+	fmt.Fprintln(fs.Output(), "\nCommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(fs.Output(), "  "+commands[name].usage)
+	}
+	fmt.Fprintln(fs.Output(), "\nFlags:")
+	fs.PrintDefaults()
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run returns the exit code: 1 if the command has failed, 2 if it is misused
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	c, args, err := loadConfig(args, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "no command, see smarthomectl -h")
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q, see smarthomectl -h\n", args[0])
+		return 2
+	}
+
+	e := &env{config: c, client: newClient(c), out: stdout, log: stderr}
+	err = cmd.run(ctx, e, args[1:])
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(stderr, "%v\nusage: smarthomectl %s\n", err, cmd.usage)
+		return 2
+	default:
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
+	}
+}
+
+// subcommand dispatches the first argument to one of the subcommands of a group
+func subcommand(ctx context.Context, e *env, args []string, subs map[string]func(context.Context, *env, []string) error) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: no subcommand", errUsage)
+	}
+	run, ok := subs[args[0]]
+	if !ok {
+		return fmt.Errorf("%w: unknown subcommand %q", errUsage, args[0])
+	}
+	return run(ctx, e, args[1:])
+}
+
+// parseFlags parses the flags of a command and checks that it has got exactly n positional arguments
+func parseFlags(fs *flag.FlagSet, args []string, n int) error {
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fs.SetOutput(os.Stderr)
+			fs.PrintDefaults()
+			return err
+		}
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+	if fs.NArg() != n {
+		return fmt.Errorf("%w: want %d arguments, got %d", errUsage, n, fs.NArg())
+	}
+	return nil
+}
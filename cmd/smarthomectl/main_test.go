@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// isolate keeps the tests away from the config and the environment of the machine
+func isolate(t *testing.T) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	for _, key := range []string{configEnv, serverEnv, tokenEnv, databaseEnv} {
+		t.Setenv(key, "")
+	}
+}
+
+// ctl runs the command against the server and returns the exit code and what it has printed
+func ctl(t *testing.T, server *httptest.Server, args ...string) (int, string, string) {
+	t.Helper()
+	return ctlContext(context.Background(), t, server, args...)
+}
+
+func ctlContext(ctx context.Context, t *testing.T, server *httptest.Server, args ...string) (int, string, string) {
+	t.Helper()
+	isolate(t)
+	if server != nil {
+		args = append([]string{"-server", server.URL}, args...)
+	}
+	var stdout, stderr bytes.Buffer
+	code := run(ctx, args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// request is what the fake server has received
+type request struct {
+	method, path, query, auth string
+	body                      string
+}
+
+// fakeServer answers every request with the status and the body, and records the requests
+func fakeServer(t *testing.T, status int, body string) (*httptest.Server, *[]request) {
+	t.Helper()
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var b bytes.Buffer
+		_, _ = b.ReadFrom(r.Body)
+		requests = append(requests, request{
+			method: r.Method, path: r.URL.Path, query: r.URL.RawQuery, auth: r.Header.Get("Authorization"), body: b.String(),
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		stderr string
+	}{
+		{"ok, help", []string{"-h"}, 0, "Usage: smarthomectl"},
+		{"ok, help of a command", []string{"sensors", "list", "-h"}, 0, ""},
+		{"fail, no command", nil, 2, "no command"},
+		{"fail, unknown command", []string{"sensor"}, 2, `unknown command "sensor"`},
+		{"fail, unknown flag", []string{"-verbose", "health"}, 2, "flag provided but not defined"},
+		{"fail, unknown output", []string{"-o", "xml", "health"}, 2, errUnknownFormat.Error()},
+		{"fail, no subcommand", []string{"sensors"}, 2, "no subcommand\nusage: smarthomectl sensors"},
+		{"fail, unknown subcommand", []string{"users", "delete"}, 2, `unknown subcommand "delete"`},
+		{"fail, extra argument", []string{"sensors", "list", "1"}, 2, "want 0 arguments, got 1"},
+		{"fail, missing argument", []string{"sensors", "get"}, 2, "want 1 arguments, got 0"},
+		{"fail, bad id", []string{"sensors", "get", "0"}, 2, `id must be a positive integer, got "0"`},
+		{"fail, bad flag of a command", []string{"history", "-from", "yesterday", "1"}, 2, `can't parse time "yesterday"`},
+		{"fail, register without serial", []string{"sensors", "register", "-type", "cc"}, 2, "-serial and -type are required"},
+		{"fail, migrate without database", []string{"migrate", "up"}, 2, "no database"},
+		{"fail, migrate with no steps", []string{"migrate", "-database", "sqlite://x", "-steps", "0", "down"}, 2, "-steps must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := fakeServer(t, http.StatusOK, "[]")
+			code, _, stderr := ctl(t, server, tt.args...)
+			assert.Equal(t, tt.code, code, "Неверный код выхода, stderr: %s", stderr)
+			assert.Contains(t, stderr, tt.stderr)
+			assert.Empty(t, *requests, "Неверная команда не должна обращаться к серверу")
+		})
+	}
+
+	t.Run("fail, error of the server", func(t *testing.T) {
+		server, _ := fakeServer(t, http.StatusNotFound, `{"code":"sensor_not_found","detail":"sensor not found"}`)
+		code, stdout, stderr := ctl(t, server, "sensors", "get", "7")
+		assert.Equal(t, 1, code)
+		assert.Empty(t, stdout)
+		assert.Equal(t, "error: status 404, sensor_not_found: sensor not found\n", stderr)
+	})
+
+	t.Run("fail, server is unreachable", func(t *testing.T) {
+		server, _ := fakeServer(t, http.StatusOK, "[]")
+		server.Close()
+		code, _, stderr := ctl(t, server, "sensors", "list")
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr, "error: ")
+	})
+}
+
+func TestLoadConfig(t *testing.T) {
+	writeConfig := func(t *testing.T, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	t.Run("ok, defaults", func(t *testing.T) {
+		isolate(t)
+		c, args, err := loadConfig([]string{"health"}, &bytes.Buffer{})
+		require.NoError(t, err)
+		assert.Equal(t, config{Server: "http://localhost:8080", Output: "table"}, c)
+		assert.Equal(t, []string{"health"}, args)
+	})
+
+	t.Run("ok, file then env then flags", func(t *testing.T) {
+		isolate(t)
+		path := writeConfig(t, "server: http://file:8080/\ntoken: file\noutput: csv\ndatabase: postgres://file\n")
+		t.Setenv(configEnv, path)
+		t.Setenv(serverEnv, "http://env:8080/")
+		t.Setenv(databaseEnv, "postgres://env")
+
+		c, _, err := loadConfig([]string{"-token", "flag", "-o", "json"}, &bytes.Buffer{})
+		require.NoError(t, err)
+		assert.Equal(t, config{Server: "http://env:8080", Token: "flag", Output: "json", Database: "postgres://env"}, c)
+	})
+
+	t.Run("ok, default file", func(t *testing.T) {
+		isolate(t)
+		path := defaultConfigPath()
+		require.NotEmpty(t, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		require.NoError(t, os.WriteFile(path, []byte("output: csv\n"), 0o600))
+
+		c, _, err := loadConfig(nil, &bytes.Buffer{})
+		require.NoError(t, err)
+		assert.Equal(t, "csv", c.Output)
+	})
+
+	t.Run("fail, missing explicit file", func(t *testing.T) {
+		isolate(t)
+		_, _, err := loadConfig([]string{"-config", filepath.Join(t.TempDir(), "missing.yaml")}, &bytes.Buffer{})
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("fail, unknown field", func(t *testing.T) {
+		isolate(t)
+		_, _, err := loadConfig([]string{"-config", writeConfig(t, "servr: http://x\n")}, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "can't parse")
+	})
+}
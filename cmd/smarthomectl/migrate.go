@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"homework/migrations"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// runMigrate applies the migrations built into the binary to the database directly, the api has no say in
+// the schema. down rolls back one migration unless -steps says otherwise, so the schema is never dropped by accident.
+// A sqlite:// url gets the migrations of the embedded database, the server must be stopped for them.
+func runMigrate(ctx context.Context, e *env, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	database := flags.String("database", e.config.Database, "postgres or sqlite:// url, also taken from "+databaseEnv+" and the config file")
+	steps := flags.Int("steps", 1, "number of the migrations down rolls back")
+	if err := parseFlags(flags, args, 1); err != nil {
+		return err
+	}
+	if *database == "" {
+		return fmt.Errorf("%w: no database, set -database or %s", errUsage, databaseEnv)
+	}
+	if *steps < 1 {
+		return fmt.Errorf("%w: -steps must be positive", errUsage)
+	}
+
+	var fsys fs.FS = migrations.FS
+	latest := migrations.Latest()
+	if path, ok := strings.CutPrefix(*database, "sqlite://"); ok {
+		fsys, latest = migrations.SQLite, migrations.LatestSQLite()
+		// the driver can't create the directory, the server creates it the same way on start
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			return err
+		}
+	}
+	src, err := iofs.New(fsys, ".")
+	if err != nil {
+		return err
+	}
+	m, err := migrate.NewWithSourceInstance("iofs", src, *database)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	// a migration is not interrupted in the middle, the interrupt takes effect before the next one
+	stop := context.AfterFunc(ctx, func() { m.GracefulStop <- true })
+	defer stop()
+
+	switch flags.Arg(0) {
+	case "up":
+		err = m.Up()
+	case "down":
+		err = m.Steps(-*steps)
+	case "version":
+	default:
+		return fmt.Errorf("%w: unknown migration %q", errUsage, flags.Arg(0))
+	}
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		version, err = 0, nil
+	}
+	if err != nil {
+		return err
+	}
+	return e.print(table{
+		header: []string{"VERSION", "LATEST", "DIRTY"},
+		rows:   [][]string{{strconv.FormatUint(uint64(version), 10), strconv.FormatInt(latest, 10), strconv.FormatBool(dirty)}},
+		v:      map[string]any{"version": version, "latest": latest, "dirty": dirty},
+	})
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// table is what a command prints: the rows for the table and the csv, v as it came from the api for the json
+type table struct {
+	header []string
+	rows   [][]string
+	v      any
+}
+
+func (t table) write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(t.v)
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write(t.header); err != nil {
+			return err
+		}
+		if err := cw.WriteAll(t.rows); err != nil {
+			return err
+		}
+		return cw.Error()
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		if _, err := io.WriteString(tw, strings.Join(t.header, "\t")+"\n"); err != nil {
+			return err
+		}
+		for _, row := range t.rows {
+			if _, err := io.WriteString(tw, strings.Join(row, "\t")+"\n"); err != nil {
+				return err
+			}
+		}
+		return tw.Flush()
+	}
+}
+
+// streamWriter prints the rows one by one as they come, for the events that are tailed.
+// A table can't be aligned ahead of time, so it is tab separated.
+type streamWriter struct {
+	w      io.Writer
+	format string
+	csv    *csv.Writer
+}
+
+func newStreamWriter(w io.Writer, format string, header []string) (*streamWriter, error) {
+	s := &streamWriter{w: w, format: format}
+	switch format {
+	case "csv":
+		s.csv = csv.NewWriter(w)
+		return s, s.writeRow(header)
+	case "table":
+		return s, s.writeRow(header)
+	}
+	return s, nil
+}
+
+// write prints the row, or v as a single line of json
+func (s *streamWriter) write(row []string, v any) error {
+	if s.format == "json" {
+		return json.NewEncoder(s.w).Encode(v)
+	}
+	return s.writeRow(row)
+}
+
+func (s *streamWriter) writeRow(row []string) error {
+	if s.csv != nil {
+		if err := s.csv.Write(row); err != nil {
+			return err
+		}
+		s.csv.Flush()
+		return s.csv.Error()
+	}
+	_, err := io.WriteString(s.w, strings.Join(row, "\t")+"\n")
+	return err
+}
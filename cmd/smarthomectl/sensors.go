@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"homework/internal/gateways/http/models"
+
+	"github.com/go-openapi/strfmt"
+)
+
+var sensorHeader = []string{"ID", "SERIAL", "TYPE", "DESCRIPTION", "ACTIVE", "STATE", "READINGS", "LAST ACTIVITY"}
+
+func sensorRow(s *models.Sensor) []string {
+	return []string{
+		strconv.FormatInt(deref(s.ID), 10),
+		deref(s.SerialNumber),
+		deref(s.Type),
+		deref(s.Description),
+		strconv.FormatBool(deref(s.IsActive)),
+		strconv.FormatInt(deref(s.CurrentState), 10),
+		readingsString(s.CurrentReadings),
+		formatDateTime(s.LastActivity),
+	}
+}
+
+func sensorsTable(sensors []*models.Sensor) table {
+	t := table{header: sensorHeader, v: sensors}
+	for _, s := range sensors {
+		t.rows = append(t.rows, sensorRow(s))
+	}
+	return t
+}
+
+func runSensors(ctx context.Context, e *env, args []string) error {
+	return subcommand(ctx, e, args, map[string]func(context.Context, *env, []string) error{
+		"list":       listSensors,
+		"get":        getSensor,
+		"register":   registerSensor,
+		"activate":   func(ctx context.Context, e *env, args []string) error { return setSensorActive(ctx, e, args, true) },
+		"deactivate": func(ctx context.Context, e *env, args []string) error { return setSensorActive(ctx, e, args, false) },
+	})
+}
+
+func listSensors(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("sensors list", flag.ContinueOnError)
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	var sensors []*models.Sensor
+	if err := e.client.do(ctx, http.MethodGet, apiPrefix+"/sensors", nil, nil, &sensors); err != nil {
+		return err
+	}
+	return e.print(sensorsTable(sensors))
+}
+
+func getSensor(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("sensors get", flag.ContinueOnError)
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	id, err := parseID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	var s models.Sensor
+	if err := e.client.do(ctx, http.MethodGet, sensorPath(id), nil, nil, &s); err != nil {
+		return err
+	}
+	t := sensorsTable([]*models.Sensor{&s})
+	t.v = s
+	return e.print(t)
+}
+
+// registerSensor registers a sensor, a sensor with the same serial number is returned as it is
+func registerSensor(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("sensors register", flag.ContinueOnError)
+	serial := fs.String("serial", "", "serial number, 10 digits")
+	sensorType := fs.String("type", "", "type of the sensor, e.g. cc or adc")
+	description := fs.String("description", "", "description of the sensor")
+	expression := fs.String("expression", "", "expression of a virtual sensor, e.g. any(#1, #2)")
+	inactive := fs.Bool("inactive", false, "register the sensor deactivated")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if *serial == "" || *sensorType == "" {
+		return fmt.Errorf("%w: -serial and -type are required", errUsage)
+	}
+
+	active := !*inactive
+	in := models.SensorToCreate{
+		SerialNumber: serial,
+		Type:         sensorType,
+		Description:  description,
+		IsActive:     &active,
+		Expression:   *expression,
+	}
+	var s models.Sensor
+	if err := e.client.do(ctx, http.MethodPost, apiPrefix+"/sensors", nil, in, &s); err != nil {
+		return err
+	}
+	t := sensorsTable([]*models.Sensor{&s})
+	t.v = s
+	return e.print(t)
+}
+
+// setSensorActive activates or deactivates the sensor, the events of an inactive sensor are rejected
+func setSensorActive(ctx context.Context, e *env, args []string, active bool) error {
+	fs := flag.NewFlagSet("sensors", flag.ContinueOnError)
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	id, err := parseID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	var s models.Sensor
+	if err := e.client.do(ctx, http.MethodPatch, sensorPath(id), nil, models.SensorToUpdate{IsActive: &active}, &s); err != nil {
+		return err
+	}
+	t := sensorsTable([]*models.Sensor{&s})
+	t.v = s
+	return e.print(t)
+}
+
+func runUsers(ctx context.Context, e *env, args []string) error {
+	return subcommand(ctx, e, args, map[string]func(context.Context, *env, []string) error{
+		"create":  createUser,
+		"bind":    bindSensor,
+		"sensors": listUserSensors,
+	})
+}
+
+func createUser(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("users create", flag.ContinueOnError)
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	name := fs.Arg(0)
+	var u models.User
+	if err := e.client.do(ctx, http.MethodPost, apiPrefix+"/users", nil, models.UserToCreate{Name: &name}, &u); err != nil {
+		return err
+	}
+	return e.print(table{
+		header: []string{"ID", "NAME"},
+		rows:   [][]string{{strconv.FormatInt(deref(u.ID), 10), deref(u.Name)}},
+		v:      u,
+	})
+}
+
+func bindSensor(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("users bind", flag.ContinueOnError)
+	if err := parseFlags(fs, args, 2); err != nil {
+		return err
+	}
+	userID, err := parseID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	sensorID, err := parseID(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	in := models.SensorToUserBinding{SensorID: &sensorID}
+	if err := e.client.do(ctx, http.MethodPost, userSensorsPath(userID), nil, in, nil); err != nil {
+		return err
+	}
+	return e.print(table{
+		header: []string{"USER", "SENSOR"},
+		rows:   [][]string{{strconv.FormatInt(userID, 10), strconv.FormatInt(sensorID, 10)}},
+		v:      map[string]int64{"user_id": userID, "sensor_id": sensorID},
+	})
+}
+
+func listUserSensors(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("users sensors", flag.ContinueOnError)
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	id, err := parseID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	var sensors []*models.Sensor
+	if err := e.client.do(ctx, http.MethodGet, userSensorsPath(id), nil, nil, &sensors); err != nil {
+		return err
+	}
+	return e.print(sensorsTable(sensors))
+}
+
+func sensorPath(id int64) string {
+	return apiPrefix + "/sensors/" + strconv.FormatInt(id, 10)
+}
+
+func userSensorsPath(id int64) string {
+	return apiPrefix + "/users/" + strconv.FormatInt(id, 10) + "/sensors"
+}
+
+func parseID(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("%w: id must be a positive integer, got %q", errUsage, s)
+	}
+	return id, nil
+}
+
+// readingsString shows the readings as channel=value pairs with their units
+func readingsString(readings []*models.Reading) string {
+	parts := make([]string, 0, len(readings))
+	for _, r := range readings {
+		if r == nil || r.Value == nil {
+			continue
+		}
+		parts = append(parts, deref(r.Channel)+"="+r.Value.String()+r.Unit)
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatDateTime leaves the time empty if the sensor has sent nothing yet
+func formatDateTime(dt *strfmt.DateTime) string {
+	if dt == nil || time.Time(*dt).IsZero() {
+		return ""
+	}
+	return time.Time(*dt).Format(time.RFC3339)
+}
+
+func deref[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sensorJSON = `{"id":7,"serial_number":"0000000007","type":"adc","description":"кухня","is_active":true,` +
+	`"current_state":21,"current_readings":[{"channel":"temperature","value":21.5,"unit":"C"}],` +
+	`"last_activity":"2024-05-01T10:00:00Z","registered_at":"2024-04-01T10:00:00Z"}`
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		response string
+		want     request
+		stdout   string
+	}{
+		{
+			name:     "sensors list",
+			args:     []string{"sensors", "list"},
+			response: "[" + sensorJSON + "]",
+			want:     request{method: http.MethodGet, path: "/api/v1/sensors"},
+			stdout: "ID  SERIAL      TYPE  DESCRIPTION  ACTIVE  STATE  READINGS           LAST ACTIVITY\n" +
+				"7   0000000007  adc   кухня        true    21     temperature=21.5C  2024-05-01T10:00:00Z\n",
+		},
+		{
+			name:     "sensors get",
+			args:     []string{"sensors", "get", "7"},
+			response: sensorJSON,
+			want:     request{method: http.MethodGet, path: "/api/v1/sensors/7"},
+			stdout: "ID  SERIAL      TYPE  DESCRIPTION  ACTIVE  STATE  READINGS           LAST ACTIVITY\n" +
+				"7   0000000007  adc   кухня        true    21     temperature=21.5C  2024-05-01T10:00:00Z\n",
+		},
+		{
+			name:     "sensors register",
+			args:     []string{"-o", "csv", "sensors", "register", "-serial", "0000000007", "-type", "adc", "-description", "кухня", "-inactive"},
+			response: sensorJSON,
+			want: request{method: http.MethodPost, path: "/api/v1/sensors",
+				body: `{"description":"кухня","is_active":false,"serial_number":"0000000007","type":"adc"}`},
+			stdout: "ID,SERIAL,TYPE,DESCRIPTION,ACTIVE,STATE,READINGS,LAST ACTIVITY\n" +
+				"7,0000000007,adc,кухня,true,21,temperature=21.5C,2024-05-01T10:00:00Z\n",
+		},
+		{
+			name:     "sensors activate",
+			args:     []string{"-o", "json", "sensors", "activate", "7"},
+			response: `{"id":7,"serial_number":"0000000007","type":"cc","description":"","is_active":true,"current_state":0}`,
+			want:     request{method: http.MethodPatch, path: "/api/v1/sensors/7", body: `{"is_active":true}`},
+			stdout: `{
+  "current_state": 0,
+  "description": "",
+  "id": 7,
+  "is_active": true,
+  "last_activity": null,
+  "registered_at": null,
+  "serial_number": "0000000007",
+  "type": "cc"
+}
+`,
+		},
+		{
+			name:     "sensors deactivate",
+			args:     []string{"-o", "csv", "sensors", "deactivate", "7"},
+			response: `{"id":7,"serial_number":"0000000007","type":"cc","description":"","is_active":false,"current_state":0}`,
+			want:     request{method: http.MethodPatch, path: "/api/v1/sensors/7", body: `{"is_active":false}`},
+			stdout:   "ID,SERIAL,TYPE,DESCRIPTION,ACTIVE,STATE,READINGS,LAST ACTIVITY\n7,0000000007,cc,,false,0,,\n",
+		},
+		{
+			name:     "users create",
+			args:     []string{"users", "create", "Иван"},
+			response: `{"id":3,"name":"Иван"}`,
+			want:     request{method: http.MethodPost, path: "/api/v1/users", body: `{"name":"Иван"}`},
+			stdout:   "ID  NAME\n3   Иван\n",
+		},
+		{
+			name:   "users bind",
+			args:   []string{"-o", "json", "users", "bind", "3", "7"},
+			want:   request{method: http.MethodPost, path: "/api/v1/users/3/sensors", body: `{"sensor_id":7}`},
+			stdout: "{\n  \"sensor_id\": 7,\n  \"user_id\": 3\n}\n",
+		},
+		{
+			name:     "users sensors",
+			args:     []string{"-o", "csv", "users", "sensors", "3"},
+			response: "[]",
+			want:     request{method: http.MethodGet, path: "/api/v1/users/3/sensors"},
+			stdout:   "ID,SERIAL,TYPE,DESCRIPTION,ACTIVE,STATE,READINGS,LAST ACTIVITY\n",
+		},
+		{
+			name:     "history",
+			args:     []string{"history", "-from", "1714550400", "-to", "2024-05-01T12:00:00Z", "-channel", "temperature", "7"},
+			response: `[{"timestamp":1714557600,"payload":21,"readings":[{"channel":"temperature","value":21.5,"unit":"C"}]}]`,
+			want: request{method: http.MethodGet, path: "/api/v1/sensors/7/history",
+				query: "channel=temperature&end_date=1714564800&start_date=1714550400"},
+			stdout: "TIMESTAMP             PAYLOAD  READINGS           RAW READINGS\n" +
+				"2024-05-01T10:00:00Z  21       temperature=21.5C  \n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := fakeServer(t, http.StatusOK, tt.response)
+			code, stdout, stderr := ctl(t, server, tt.args...)
+			require.Equal(t, 0, code, "Команда должна выполниться, stderr: %s", stderr)
+
+			require.Len(t, *requests, 1, "Команда должна сделать один запрос")
+			assert.Equal(t, tt.want, (*requests)[0])
+			assert.Equal(t, tt.stdout, stdout)
+		})
+	}
+
+	t.Run("ok, token is sent", func(t *testing.T) {
+		server, requests := fakeServer(t, http.StatusOK, "[]")
+		code, _, _ := ctl(t, server, "-token", "secret", "sensors", "list")
+		require.Equal(t, 0, code)
+		require.Len(t, *requests, 1)
+		assert.Equal(t, "Bearer secret", (*requests)[0].auth)
+	})
+
+	t.Run("fail, validation of the server", func(t *testing.T) {
+		server, _ := fakeServer(t, http.StatusUnprocessableEntity,
+			`{"code":"validation","detail":"invalid sensor","errors":[{"field":"serial_number","message":"must be 10 digits"}]}`)
+		code, _, stderr := ctl(t, server, "sensors", "register", "-serial", "1", "-type", "cc")
+		assert.Equal(t, 1, code)
+		assert.Equal(t, "error: status 422, validation: invalid sensor; serial_number: must be 10 digits\n", stderr)
+	})
+
+	t.Run("fail, response that is not a problem", func(t *testing.T) {
+		server, _ := fakeServer(t, http.StatusBadGateway, "bad gateway\n")
+		code, _, stderr := ctl(t, server, "users", "sensors", "3")
+		assert.Equal(t, 1, code)
+		assert.Equal(t, "error: status 502: bad gateway\n", stderr)
+	})
+}
+
+func TestHealth(t *testing.T) {
+	probes := func(ready int) *http.ServeMux {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"status":"ok"}`))
+		})
+		mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(ready)
+			if ready == http.StatusOK {
+				_, _ = w.Write([]byte(`{"status":"ok","checks":{"database":"ok","events":"ok"}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"status":"fail","checks":{"database":"connection refused"}}`))
+		})
+		return mux
+	}
+
+	tests := []struct {
+		name   string
+		ready  int
+		code   int
+		stdout string
+	}{
+		{
+			name:  "ok",
+			ready: http.StatusOK,
+			code:  0,
+			stdout: "PROBE     STATUS  CHECK     RESULT\n" +
+				"/healthz  ok                \n" +
+				"/readyz   ok      database  ok\n" +
+				"/readyz   ok      events    ok\n",
+		},
+		{
+			name:  "fail, not ready",
+			ready: http.StatusServiceUnavailable,
+			code:  1,
+			stdout: "PROBE     STATUS  CHECK     RESULT\n" +
+				"/healthz  ok                \n" +
+				"/readyz   fail    database  connection refused\n",
+		},
+		{
+			name:  "fail, unexpected status",
+			ready: http.StatusNotFound,
+			code:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(probes(tt.ready))
+			t.Cleanup(server.Close)
+
+			code, stdout, stderr := ctl(t, server, "health")
+			assert.Equal(t, tt.code, code, "Неверный код выхода, stderr: %s", stderr)
+			assert.Equal(t, tt.stdout, stdout)
+		})
+	}
+}
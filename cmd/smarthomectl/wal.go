@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"homework/internal/repository/durable"
+)
+
+var errDamaged = errors.New("persistence directory is damaged")
+
+// runWAL works on the persistence directory of the in-memory server directly, not through the api
+func runWAL(ctx context.Context, e *env, args []string) error {
+	return subcommand(ctx, e, args, map[string]func(context.Context, *env, []string) error{
+		"inspect": inspectWAL,
+		"compact": compactWAL,
+	})
+}
+
+// inspectWAL reads the directory without changing it, so it is safe beside the running server.
+// A corrupt file fails the command, a torn tail doesn't: the server cuts it off on start.
+func inspectWAL(_ context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("wal inspect", flag.ContinueOnError)
+	verbose := fs.Bool("v", false, "print every record as well")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+
+	var (
+		records *streamWriter
+		werr    error
+		dump    func(string, durable.Record)
+	)
+	if *verbose {
+		var err error
+		if records, err = newStreamWriter(e.out, e.config.Output, []string{"FILE", "OFFSET", "KIND", "DATA"}); err != nil {
+			return err
+		}
+		dump = func(file string, r durable.Record) {
+			if werr == nil {
+				werr = records.write([]string{file, strconv.FormatInt(r.Offset, 10), r.Kind, string(r.Data)},
+					map[string]any{"file": file, "offset": r.Offset, "kind": r.Kind, "data": r.Data})
+			}
+		}
+	}
+	reports, err := durable.Inspect(fs.Arg(0), dump)
+	if err != nil {
+		return err
+	}
+	if werr != nil {
+		return werr
+	}
+	if *verbose && e.config.Output != "json" {
+		fmt.Fprintln(e.out)
+	}
+
+	if err := e.print(reportTable(reports)); err != nil {
+		return err
+	}
+	for _, r := range reports {
+		if r.Status == durable.StatusCorrupt {
+			return errDamaged
+		}
+	}
+	return nil
+}
+
+// compactWAL takes a snapshot and removes the segments it replaces. The server must be stopped:
+// the directory is locked while it runs.
+func compactWAL(_ context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("wal compact", flag.ContinueOnError)
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+
+	// the interrupt is not honored, a half written snapshot is removed on the next open anyway
+	store, err := durable.Open(fs.Arg(0), durable.NewRepositories(), durable.Options{})
+	if err != nil {
+		return err
+	}
+	if err := store.Snapshot(context.Background()); err != nil {
+		return errors.Join(err, store.Close())
+	}
+	if err := store.Close(); err != nil {
+		return err
+	}
+
+	reports, err := durable.Inspect(fs.Arg(0), nil)
+	if err != nil {
+		return err
+	}
+	return e.print(reportTable(reports))
+}
+
+func reportTable(reports []durable.FileReport) table {
+	t := table{header: []string{"FILE", "KIND", "SEQ", "SIZE", "RECORDS", "STATUS"}, v: reports}
+	for _, r := range reports {
+		kind := "segment"
+		if r.Snapshot {
+			kind = "snapshot"
+		}
+		status := r.Status
+		if r.Status != durable.StatusOK {
+			status = fmt.Sprintf("%s at %d: %s", r.Status, r.Offset, r.Reason)
+		}
+		t.rows = append(t.rows, []string{r.Name, kind, strconv.FormatUint(r.Seq, 10), strconv.FormatInt(r.Size, 10),
+			formatCounts(r.Records), status})
+	}
+	return t
+}
+
+// formatCounts prints the counts of the records as kind=count ordered by kind
+func formatCounts(counts map[string]int) string {
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	parts := make([]string, len(kinds))
+	for i, kind := range kinds {
+		parts[i] = kind + "=" + strconv.Itoa(counts[kind])
+	}
+	return strings.Join(parts, " ")
+}
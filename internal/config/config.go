@@ -0,0 +1,550 @@
+// Package config loads the server configuration from a file, the environment and the command line flags.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"net/netip"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	HTTP        HTTP
+	Metrics     Metrics
+	Database    Database
+	WebSocket   WebSocket
+	Export      Export
+	Features    Features
+	Tracing     Tracing
+	Log         Log
+	RateLimit   RateLimit
+	Idempotency Idempotency
+	Commands    Commands
+	Scheduler   Scheduler
+	Persistence Persistence
+	Changes     Changes
+}
+
+type HTTP struct {
+	Host              string
+	Port              int
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
+	DrainDelay        time.Duration
+}
+
+type Metrics struct {
+	Host string
+	Port int
+	Path string
+	// SensorOfflineAfter is how long an active sensor may stay silent before it is counted as offline
+	SensorOfflineAfter time.Duration
+	// Readings exports the state of every sensor, it is off by default as it is a series per sensor
+	Readings bool
+	// ReadingsAllow limits the exported readings to these serial numbers and sensor types, all are exported if empty
+	ReadingsAllow []string
+	// ReadingsLimit is the most sensors the readings are exported for
+	ReadingsLimit int
+}
+
+// Database is used only when the URL is set, otherwise the data is kept in memory.
+// A sqlite:// URL is the path of the embedded database file, the pool settings don't apply to it.
+type Database struct {
+	URL             string
+	MaxConns        int32
+	MinConns        int32
+	MaxConnLifetime time.Duration
+	MaxConnIdleTime time.Duration
+	ConnectTimeout  time.Duration
+}
+
+const sqliteScheme = "sqlite://"
+
+// SQLitePath returns the path of the embedded database file, ok is false for postgres or no database at all
+func (d Database) SQLitePath() (path string, ok bool) {
+	return strings.CutPrefix(d.URL, sqliteScheme)
+}
+
+type WebSocket struct {
+	// Tick is how often the last event of a sensor is polled for a subscriber
+	Tick time.Duration
+	// Buffer is how many events may wait for a slow subscriber before it is disconnected
+	Buffer int
+}
+
+type Export struct {
+	Dir string
+	// TTL is how long a finished export and its file are kept, forever if 0
+	TTL time.Duration
+}
+
+// Tracing is off unless the endpoint is set
+type Tracing struct {
+	Endpoint    string
+	SampleRatio float64
+}
+
+type Log struct {
+	// Level is one of debug, info, warn and error
+	Level string
+	// Format is text or json
+	Format string
+}
+
+// Backends of the rate limits
+const (
+	RateLimitBackendMemory   = "memory"
+	RateLimitBackendPostgres = "postgres"
+)
+
+// RateLimit is off for the clients and the sensors whose rate is 0
+type RateLimit struct {
+	// Backend is memory or postgres, the latter shares the limits between the replicas
+	Backend         string
+	ClientRate      float64
+	ClientBurst     int
+	ClientKeyHeader string
+	SensorRate      float64
+	SensorBurst     int
+	// SensorTypes overrides the sensor limit by type, every item is type:rate:burst
+	SensorTypes []string
+	// TrustedProxies are the ips and the networks of the proxies whose X-Forwarded-For and ClientKeyHeader are believed
+	TrustedProxies []string
+	// Capacity is the most buckets kept in memory, the least recently used are forgotten first
+	Capacity int
+}
+
+// ParseSensorTypeLimit parses an item of RateLimit.SensorTypes, e.g. adc:0.5:5
+func ParseSensorTypeLimit(s string) (sensorType string, rate float64, burst int, err error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 || parts[0] == "" {
+		return "", 0, 0, fmt.Errorf("%q must be type:rate:burst", s)
+	}
+	if rate, err = strconv.ParseFloat(parts[1], 64); err != nil || rate < 0 {
+		return "", 0, 0, fmt.Errorf("%q: rate must be a non-negative number", s)
+	}
+	if burst, err = strconv.Atoi(parts[2]); err != nil || burst < 1 {
+		return "", 0, 0, fmt.Errorf("%q: burst must be a positive integer", s)
+	}
+	return parts[0], rate, burst, nil
+}
+
+// ParseTrustedProxy parses an item of RateLimit.TrustedProxies, an ip or a network, e.g. 10.0.0.0/8
+func ParseTrustedProxy(s string) (netip.Prefix, error) {
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("%q must be an ip or a network", s)
+		}
+		return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%q must be an ip or a network", s)
+	}
+	return prefix.Masked(), nil
+}
+
+type Idempotency struct {
+	// Window is how long the result of a request is returned to its retries
+	Window time.Duration
+	// Capacity is the most keys kept in memory, the least recently used are forgotten first. The database keeps all.
+	Capacity int
+}
+
+// Commands are the defaults of the commands to the actuators that do not set their own limits
+type Commands struct {
+	AckTimeout time.Duration
+	Attempts   int
+	TTL        time.Duration
+	// PollInterval is how often a waiting device poll looks at the queue again
+	PollInterval time.Duration
+}
+
+// Scheduler tells where the home is, the schedules run by its clock and the sun rises at its coordinates
+type Scheduler struct {
+	Latitude  float64
+	Longitude float64
+	// Timezone is the IANA name of the zone, Local is the zone of the server
+	Timezone string
+	// Tick is how often the due schedules are looked for
+	Tick time.Duration
+	// MisfireGrace is how late a run may be and still not count as missed
+	MisfireGrace time.Duration
+}
+
+// Location loads the zone of the home
+func (s Scheduler) Location() (*time.Location, error) {
+	return time.LoadLocation(s.Timezone)
+}
+
+// Persistence keeps the in-memory data in a directory, it is used only without the database
+type Persistence struct {
+	// Dir is off if empty
+	Dir string
+	// SyncInterval is how often the log is synced, 0 syncs every save before it is acknowledged
+	SyncInterval time.Duration
+	// SnapshotInterval is how often the log is compacted into a snapshot, 0 never
+	SnapshotInterval time.Duration
+}
+
+// Changes is the retention of the change feed
+type Changes struct {
+	// Retention is how long the changes are kept, forever if 0
+	Retention time.Duration
+	// PruneInterval is how often the changes older than the retention are deleted
+	PruneInterval time.Duration
+	// PollInterval is how often a waiting read looks at the feed again, the feed of another replica may have grown
+	PollInterval time.Duration
+}
+
+type Features struct {
+	ValidateRequests  bool
+	ValidateResponses bool
+	Exports           bool
+	Imports           bool
+}
+
+func Default() *Config {
+	return &Config{
+		HTTP: HTTP{
+			Host:              "localhost",
+			Port:              8080,
+			ReadHeaderTimeout: 5 * time.Second,
+			IdleTimeout:       time.Minute,
+			ShutdownTimeout:   3 * time.Second,
+		},
+		Metrics: Metrics{Port: 8000, Path: "/metrics", SensorOfflineAfter: 15 * time.Minute, ReadingsLimit: 1000},
+		Database: Database{
+			MaxConns:        10,
+			MaxConnLifetime: time.Hour,
+			MaxConnIdleTime: 30 * time.Minute,
+			ConnectTimeout:  5 * time.Second,
+		},
+		WebSocket:   WebSocket{Tick: 2 * time.Second, Buffer: 16},
+		Export:      Export{Dir: os.TempDir(), TTL: 24 * time.Hour},
+		Features:    Features{ValidateRequests: true, Exports: true, Imports: true},
+		Tracing:     Tracing{SampleRatio: 1},
+		Log:         Log{Level: "info", Format: logging.FormatText},
+		RateLimit:   RateLimit{Backend: RateLimitBackendMemory, ClientBurst: 20, SensorBurst: 10, Capacity: 100000},
+		Idempotency: Idempotency{Window: 24 * time.Hour, Capacity: 100000},
+		Commands:    Commands{AckTimeout: 10 * time.Second, Attempts: 3, TTL: 5 * time.Minute, PollInterval: time.Second},
+		Scheduler:   Scheduler{Timezone: "Local", Tick: 15 * time.Second, MisfireGrace: time.Minute},
+		Persistence: Persistence{SnapshotInterval: 10 * time.Minute},
+		Changes:     Changes{Retention: 7 * 24 * time.Hour, PruneInterval: 10 * time.Minute, PollInterval: time.Second},
+	}
+}
+
+// field describes a single setting. The same key is used in the file, the environment and the flags:
+// http.read_timeout is HTTP_READ_TIMEOUT and -http-read-timeout.
+type field struct {
+	key   string
+	usage string
+	// env overrides the variable name derived from the key
+	env string
+	// secret values are redacted when the config is printed
+	secret bool
+	// reloadable settings are applied on SIGHUP, the rest need a restart
+	reloadable bool
+	value      any
+}
+
+func (f field) envName() string {
+	if f.env != "" {
+		return f.env
+	}
+	return strings.ToUpper(strings.ReplaceAll(f.key, ".", "_"))
+}
+
+func (f field) flagName() string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(f.key)
+}
+
+func (c *Config) fields() []field {
+	return []field{
+		{key: "http.host", usage: "address the api listens on", value: &c.HTTP.Host},
+		{key: "http.port", usage: "port the api listens on", value: &c.HTTP.Port},
+		{key: "http.read_header_timeout", usage: "time to read the request headers", value: &c.HTTP.ReadHeaderTimeout},
+		{key: "http.read_timeout", usage: "time to read the whole request, 0 means no limit", value: &c.HTTP.ReadTimeout},
+		{key: "http.write_timeout", usage: "time to write the response, 0 means no limit", value: &c.HTTP.WriteTimeout},
+		{key: "http.idle_timeout", usage: "time to keep an idle connection", value: &c.HTTP.IdleTimeout},
+		{key: "http.shutdown_timeout", usage: "time given to the whole drain on shutdown", value: &c.HTTP.ShutdownTimeout},
+		{key: "http.drain_delay", usage: "time the readiness probe fails before the server stops accepting connections",
+			value: &c.HTTP.DrainDelay},
+
+		{key: "metrics.host", usage: "address the metrics are served on", value: &c.Metrics.Host},
+		{key: "metrics.port", usage: "port the metrics are served on", value: &c.Metrics.Port},
+		{key: "metrics.path", usage: "path the metrics are served on", value: &c.Metrics.Path},
+		{key: "metrics.sensor_offline_after", usage: "silence after which an active sensor is counted as offline",
+			value: &c.Metrics.SensorOfflineAfter},
+		{key: "metrics.readings", usage: "export the state of every sensor", value: &c.Metrics.Readings},
+		{key: "metrics.readings_allow", usage: "comma separated serial numbers and sensor types the readings are exported for, all if empty",
+			value: &c.Metrics.ReadingsAllow},
+		{key: "metrics.readings_limit", usage: "most sensors the readings are exported for", value: &c.Metrics.ReadingsLimit},
+
+		{key: "database.url", usage: "postgres connection string or sqlite:///path/to/file.db, the data is kept in memory if empty",
+			env: "DATABASE_URL", secret: true, value: &c.Database.URL},
+		{key: "database.max_conns", usage: "maximum size of the connection pool", value: &c.Database.MaxConns},
+		{key: "database.min_conns", usage: "minimum size of the connection pool", value: &c.Database.MinConns},
+		{key: "database.max_conn_lifetime", usage: "time after which a connection is closed", value: &c.Database.MaxConnLifetime},
+		{key: "database.max_conn_idle_time", usage: "time after which an idle connection is closed", value: &c.Database.MaxConnIdleTime},
+		{key: "database.connect_timeout", usage: "time to establish a connection", value: &c.Database.ConnectTimeout},
+
+		{key: "websocket.tick", usage: "how often the last event is polled for a subscriber",
+			reloadable: true, value: &c.WebSocket.Tick},
+		{key: "websocket.buffer", usage: "events kept for a slow subscriber before it is disconnected",
+			reloadable: true, value: &c.WebSocket.Buffer},
+
+		{key: "export.dir", usage: "directory for the export files", value: &c.Export.Dir},
+		{key: "export.ttl", usage: "how long a finished export and its file are kept, forever if 0", value: &c.Export.TTL},
+
+		{key: "features.validate_requests", usage: "reject /api/v1 requests that do not match the spec",
+			reloadable: true, value: &c.Features.ValidateRequests},
+		{key: "features.validate_responses", usage: "log /api/v1 responses that do not match the spec",
+			reloadable: true, value: &c.Features.ValidateResponses},
+		{key: "features.exports", usage: "enable the exports api", reloadable: true, value: &c.Features.Exports},
+		{key: "features.imports", usage: "enable the imports api", reloadable: true, value: &c.Features.Imports},
+
+		{key: "tracing.endpoint", usage: "url of the OTLP/HTTP collector, tracing is off if empty", value: &c.Tracing.Endpoint},
+		{key: "tracing.sample_ratio", usage: "share of the new traces that are recorded, from 0 to 1", value: &c.Tracing.SampleRatio},
+
+		{key: "log.level", usage: "least level of the logged messages: debug, info, warn or error",
+			reloadable: true, value: &c.Log.Level},
+		{key: "log.format", usage: "format of the log: text or json", value: &c.Log.Format},
+
+		{key: "ratelimit.backend", usage: "where the rate limits are kept: memory or postgres to share them between replicas",
+			value: &c.RateLimit.Backend},
+		{key: "ratelimit.client_rate", usage: "requests per second of a client, no limit if 0", value: &c.RateLimit.ClientRate},
+		{key: "ratelimit.client_burst", usage: "requests a client may send at once", value: &c.RateLimit.ClientBurst},
+		{key: "ratelimit.client_key_header", usage: "header the clients are told apart by instead of the ip, e.g. X-API-Key",
+			reloadable: true, value: &c.RateLimit.ClientKeyHeader},
+		{key: "ratelimit.trusted_proxies", usage: "comma separated ips and networks of the proxies whose client headers are believed",
+			reloadable: true, value: &c.RateLimit.TrustedProxies},
+		{key: "ratelimit.capacity", usage: "most buckets kept in memory", value: &c.RateLimit.Capacity},
+		{key: "ratelimit.sensor_rate", usage: "events per second of a sensor, no limit if 0", value: &c.RateLimit.SensorRate},
+		{key: "ratelimit.sensor_burst", usage: "events a sensor may send at once", value: &c.RateLimit.SensorBurst},
+		{key: "ratelimit.sensor_types", usage: "comma separated limits of the sensor types as type:rate:burst, e.g. adc:0.5:5",
+			value: &c.RateLimit.SensorTypes},
+
+		{key: "idempotency.window", usage: "how long the retries of a request get its result", value: &c.Idempotency.Window},
+		{key: "idempotency.capacity", usage: "most idempotency keys kept in memory", value: &c.Idempotency.Capacity},
+
+		{key: "commands.ack_timeout", usage: "how long a sent command waits for the acknowledgement before it is sent again",
+			value: &c.Commands.AckTimeout},
+		{key: "commands.attempts", usage: "how many times a command is sent before it fails", value: &c.Commands.Attempts},
+		{key: "commands.ttl", usage: "how long a command may wait for the delivery before it expires", value: &c.Commands.TTL},
+		{key: "commands.poll_interval", usage: "how often a waiting device poll looks at the queue again",
+			value: &c.Commands.PollInterval},
+
+		{key: "scheduler.latitude", usage: "latitude of the home the sunrise and the sunset are computed for", value: &c.Scheduler.Latitude},
+		{key: "scheduler.longitude", usage: "longitude of the home, east is positive", value: &c.Scheduler.Longitude},
+		{key: "scheduler.timezone", usage: "IANA time zone the schedules run in, e.g. Europe/Moscow", value: &c.Scheduler.Timezone},
+		{key: "scheduler.tick", usage: "how often the due schedules are looked for", value: &c.Scheduler.Tick},
+		{key: "scheduler.misfire_grace", usage: "how late a run may start before it counts as missed, e.g. after a restart",
+			value: &c.Scheduler.MisfireGrace},
+
+		{key: "persistence.dir", usage: "directory the in-memory data is kept in across the restarts, it is lost on exit if empty",
+			value: &c.Persistence.Dir},
+		{key: "persistence.sync_interval", usage: "how often the log is synced to the disk, 0 syncs every save",
+			value: &c.Persistence.SyncInterval},
+		{key: "persistence.snapshot_interval", usage: "how often the log is compacted into a snapshot, never if 0",
+			value: &c.Persistence.SnapshotInterval},
+
+		{key: "changes.retention", usage: "how long the change feed is kept, forever if 0", value: &c.Changes.Retention},
+		{key: "changes.prune_interval", usage: "how often the changes older than the retention are deleted",
+			value: &c.Changes.PruneInterval},
+		{key: "changes.poll_interval", usage: "how often a waiting read of the change feed looks at it again",
+			value: &c.Changes.PollInterval},
+	}
+}
+
+// set parses the raw value into the field
+func (f field) set(raw string) error {
+	var err error
+	switch v := f.value.(type) {
+	case *string:
+		*v = raw
+	case *bool:
+		*v, err = strconv.ParseBool(raw)
+	case *int:
+		*v, err = strconv.Atoi(raw)
+	case *int32:
+		var i int64
+		i, err = strconv.ParseInt(raw, 10, 32)
+		*v = int32(i)
+	case *float64:
+		*v, err = strconv.ParseFloat(raw, 64)
+	case *time.Duration:
+		*v, err = time.ParseDuration(raw)
+	case *[]string:
+		*v = nil
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*v = append(*v, item)
+			}
+		}
+	default:
+		panic(fmt.Sprintf("config: unsupported type %T of %s", f.value, f.key))
+	}
+	if err != nil {
+		return fmt.Errorf("%s: invalid value %q", f.key, raw)
+	}
+	return nil
+}
+
+func (f field) get() any {
+	return reflect.ValueOf(f.value).Elem().Interface()
+}
+
+func (f field) String() string {
+	v := fmt.Sprint(f.get())
+	if f.secret && v != "" {
+		return redact(v)
+	}
+	return v
+}
+
+// redact hides the password of a connection string, other secrets are hidden completely
+func redact(v string) string {
+	u, err := url.Parse(v)
+	if err != nil || u.Scheme == "" || u.User == nil {
+		return "***"
+	}
+	if _, has := u.User.Password(); !has {
+		return v
+	}
+	return u.Redacted()
+}
+
+// String prints the effective config, one setting per line with the secrets redacted
+func (c *Config) String() string {
+	var b strings.Builder
+	for _, f := range c.fields() {
+		fmt.Fprintf(&b, "%s = %s\n", f.key, f)
+	}
+	return b.String()
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+// Validate reports all the problems at once
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(validPort(c.HTTP.Port), "http.port: %d is not a valid port", c.HTTP.Port)
+	check(validPort(c.Metrics.Port), "metrics.port: %d is not a valid port", c.Metrics.Port)
+	check(c.HTTP.Port != c.Metrics.Port, "metrics.port: clashes with http.port %d", c.HTTP.Port)
+	check(strings.HasPrefix(c.Metrics.Path, "/"), "metrics.path: %q must start with /", c.Metrics.Path)
+	check(c.Metrics.SensorOfflineAfter > 0, "metrics.sensor_offline_after: must be positive")
+	check(c.Metrics.ReadingsLimit > 0, "metrics.readings_limit: must be positive")
+
+	for _, d := range []struct {
+		key string
+		v   time.Duration
+	}{
+		{"http.read_header_timeout", c.HTTP.ReadHeaderTimeout},
+		{"http.read_timeout", c.HTTP.ReadTimeout},
+		{"http.write_timeout", c.HTTP.WriteTimeout},
+		{"http.idle_timeout", c.HTTP.IdleTimeout},
+		{"http.drain_delay", c.HTTP.DrainDelay},
+		{"database.max_conn_lifetime", c.Database.MaxConnLifetime},
+		{"database.max_conn_idle_time", c.Database.MaxConnIdleTime},
+		{"database.connect_timeout", c.Database.ConnectTimeout},
+		{"persistence.sync_interval", c.Persistence.SyncInterval},
+		{"persistence.snapshot_interval", c.Persistence.SnapshotInterval},
+	} {
+		check(d.v >= 0, "%s: must not be negative", d.key)
+	}
+	check(c.HTTP.ShutdownTimeout > 0, "http.shutdown_timeout: must be positive")
+	check(c.HTTP.DrainDelay < c.HTTP.ShutdownTimeout, "http.drain_delay: must be less than http.shutdown_timeout")
+
+	check(c.Database.MaxConns > 0, "database.max_conns: must be positive")
+	check(c.Database.MinConns >= 0 && c.Database.MinConns <= c.Database.MaxConns,
+		"database.min_conns: must be between 0 and database.max_conns")
+	if path, ok := c.Database.SQLitePath(); ok {
+		check(path != "", "database.url: sqlite:// needs the path of the database file")
+	} else if c.Database.URL != "" {
+		_, err := url.Parse(c.Database.URL)
+		check(err == nil, "database.url: is not a valid url")
+	}
+
+	check(c.WebSocket.Tick > 0, "websocket.tick: must be positive")
+	check(c.WebSocket.Buffer > 0, "websocket.buffer: must be positive")
+	check(c.Export.Dir != "", "export.dir: must not be empty")
+	check(c.Export.TTL >= 0, "export.ttl: must not be negative")
+
+	if c.Tracing.Endpoint != "" {
+		u, err := url.Parse(c.Tracing.Endpoint)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"tracing.endpoint: %q must be an http or https url", c.Tracing.Endpoint)
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio: must be between 0 and 1")
+	_, err := logging.ParseLevel(c.Log.Level)
+	check(err == nil, "log.level: %q must be debug, info, warn or error", c.Log.Level)
+	check(c.Log.Format == logging.FormatText || c.Log.Format == logging.FormatJSON,
+		"log.format: %q must be text or json", c.Log.Format)
+
+	switch c.RateLimit.Backend {
+	case RateLimitBackendMemory:
+	case RateLimitBackendPostgres:
+		_, sqlite := c.Database.SQLitePath()
+		check(c.Database.URL != "", "ratelimit.backend: postgres needs database.url")
+		check(!sqlite, "ratelimit.backend: postgres doesn't work with the sqlite database.url")
+	default:
+		check(false, "ratelimit.backend: %q must be memory or postgres", c.RateLimit.Backend)
+	}
+	check(c.RateLimit.ClientRate >= 0, "ratelimit.client_rate: must not be negative")
+	check(c.RateLimit.ClientBurst > 0, "ratelimit.client_burst: must be positive")
+	check(c.RateLimit.SensorRate >= 0, "ratelimit.sensor_rate: must not be negative")
+	check(c.RateLimit.SensorBurst > 0, "ratelimit.sensor_burst: must be positive")
+	for _, item := range c.RateLimit.SensorTypes {
+		_, _, _, err := ParseSensorTypeLimit(item)
+		check(err == nil, "ratelimit.sensor_types: %v", err)
+	}
+	for _, item := range c.RateLimit.TrustedProxies {
+		_, err := ParseTrustedProxy(item)
+		check(err == nil, "ratelimit.trusted_proxies: %v", err)
+	}
+	check(c.RateLimit.Capacity > 0, "ratelimit.capacity: must be positive")
+
+	check(c.Idempotency.Window > 0, "idempotency.window: must be positive")
+	check(c.Idempotency.Capacity > 0, "idempotency.capacity: must be positive")
+
+	check(c.Commands.AckTimeout > 0, "commands.ack_timeout: must be positive")
+	check(c.Commands.Attempts >= 1 && c.Commands.Attempts <= domain.MaxCommandAttempts,
+		"commands.attempts: must be from 1 to %d", domain.MaxCommandAttempts)
+	check(c.Commands.TTL > 0, "commands.ttl: must be positive")
+	check(c.Commands.PollInterval > 0, "commands.poll_interval: must be positive")
+	if _, err := c.Scheduler.Location(); err != nil {
+		check(false, "scheduler.timezone: %v", err)
+	}
+	coordinates := domain.Coordinates{Latitude: c.Scheduler.Latitude, Longitude: c.Scheduler.Longitude}
+	if err := coordinates.Validate(); err != nil {
+		check(false, "scheduler.latitude, scheduler.longitude: %v", err)
+	}
+	check(c.Scheduler.Tick > 0, "scheduler.tick: must be positive")
+	check(c.Scheduler.MisfireGrace >= c.Scheduler.Tick, "scheduler.misfire_grace: must not be less than scheduler.tick")
+	check(c.Persistence.Dir == "" || c.Database.URL == "", "persistence.dir: is not used with database.url")
+	check(c.Changes.Retention >= 0, "changes.retention: must not be negative")
+	check(c.Changes.Retention == 0 || c.Changes.PruneInterval > 0, "changes.prune_interval: must be positive")
+	check(c.Changes.PollInterval > 0, "changes.poll_interval: must be positive")
+
+	return errors.Join(errs...)
+}
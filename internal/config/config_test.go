@@ -0,0 +1,209 @@
+package config
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, has := vars[key]
+		return v, has
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("ok, defaults", func(t *testing.T) {
+		c, err := load(nil, env(nil), io.Discard)
+		require.NoError(t, err)
+		assert.Equal(t, Default(), c)
+	})
+
+	t.Run("ok, file then env then flags", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", `
+http:
+  host: 0.0.0.0
+  port: 9000
+  shutdown_timeout: 10s
+websocket:
+  buffer: 4
+features:
+  exports: false
+metrics:
+  readings_allow: [adc, "0000000001"]
+`)
+		c, err := load([]string{"-config", path, "-http-port", "9100"}, env(map[string]string{
+			"HTTP_PORT":    "9001",
+			"DATABASE_URL": "postgres://u:p@db:5432/db",
+			"EXPORT_DIR":   "/exports",
+		}), io.Discard)
+		require.NoError(t, err)
+
+		assert.Equal(t, "0.0.0.0", c.HTTP.Host)
+		assert.Equal(t, 9100, c.HTTP.Port)
+		assert.Equal(t, 10*time.Second, c.HTTP.ShutdownTimeout)
+		assert.Equal(t, 4, c.WebSocket.Buffer)
+		assert.False(t, c.Features.Exports)
+		assert.Equal(t, "postgres://u:p@db:5432/db", c.Database.URL)
+		assert.Equal(t, "/exports", c.Export.Dir)
+		assert.Equal(t, []string{"adc", "0000000001"}, c.Metrics.ReadingsAllow)
+	})
+
+	t.Run("ok, toml file from env", func(t *testing.T) {
+		path := writeFile(t, "config.toml", `
+[database]
+max_conns = 20
+min_conns = 2
+
+[websocket]
+tick = "500ms"
+
+[tracing]
+sample_ratio = 0.25
+`)
+		c, err := load(nil, env(map[string]string{"CONFIG_FILE": path, "METRICS_READINGS_ALLOW": "cc, ,adc"}), io.Discard)
+		require.NoError(t, err)
+
+		assert.Equal(t, int32(20), c.Database.MaxConns)
+		assert.Equal(t, int32(2), c.Database.MinConns)
+		assert.Equal(t, 500*time.Millisecond, c.WebSocket.Tick)
+		assert.Equal(t, 0.25, c.Tracing.SampleRatio)
+		assert.Equal(t, []string{"cc", "adc"}, c.Metrics.ReadingsAllow)
+	})
+
+	t.Run("err, unknown setting in file", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", "http:\n  prot: 80\n")
+		_, err := load([]string{"-config", path}, env(nil), io.Discard)
+		assert.ErrorContains(t, err, `unknown setting "http.prot"`)
+	})
+
+	t.Run("err, unknown file format", func(t *testing.T) {
+		path := writeFile(t, "config.json", "{}")
+		_, err := load([]string{"-config", path}, env(nil), io.Discard)
+		assert.ErrorIs(t, err, ErrUnknownFileFormat)
+	})
+
+	t.Run("err, invalid env value", func(t *testing.T) {
+		_, err := load(nil, env(map[string]string{"HTTP_PORT": "http"}), io.Discard)
+		assert.ErrorContains(t, err, "HTTP_PORT")
+	})
+
+	t.Run("err, invalid flag value", func(t *testing.T) {
+		_, err := load([]string{"-websocket-tick", "often"}, env(nil), io.Discard)
+		assert.ErrorContains(t, err, "websocket.tick")
+	})
+}
+
+func TestConfig_Validate(t *testing.T) {
+	t.Run("ok, ports above 9999", func(t *testing.T) {
+		c := Default()
+		c.HTTP.Port = 18080
+		assert.NoError(t, c.Validate())
+	})
+
+	t.Run("err, every problem is reported", func(t *testing.T) {
+		c := Default()
+		c.HTTP.Port = 70000
+		c.Metrics.Port = 70000
+		c.Database.MinConns = 100
+		c.WebSocket.Tick = 0
+		c.HTTP.DrainDelay = time.Hour
+		c.Tracing.Endpoint = "localhost:4318"
+		c.Tracing.SampleRatio = 2
+		c.Log.Level = "verbose"
+		c.Log.Format = "xml"
+		c.RateLimit.Backend = RateLimitBackendPostgres
+		c.RateLimit.SensorTypes = []string{"adc:1:5", "cc:fast:5"}
+		c.RateLimit.TrustedProxies = []string{"10.0.0.0/8", "proxy"}
+		c.Idempotency.Window = 0
+		c.Commands.Attempts = 11
+		c.Scheduler.Timezone = "Mars/Olympus"
+		c.Scheduler.Latitude = 91
+		c.Scheduler.MisfireGrace = time.Second
+		c.Persistence.SyncInterval = -time.Second
+		c.Changes.PruneInterval = 0
+
+		err := c.Validate()
+		assert.ErrorContains(t, err, "http.port")
+		assert.ErrorContains(t, err, "metrics.port: clashes")
+		assert.ErrorContains(t, err, "database.min_conns")
+		assert.ErrorContains(t, err, "websocket.tick")
+		assert.ErrorContains(t, err, "http.drain_delay")
+		assert.ErrorContains(t, err, "tracing.endpoint")
+		assert.ErrorContains(t, err, "tracing.sample_ratio")
+		assert.ErrorContains(t, err, "log.level")
+		assert.ErrorContains(t, err, "log.format")
+		assert.ErrorContains(t, err, "ratelimit.backend: postgres needs database.url")
+		assert.ErrorContains(t, err, `ratelimit.sensor_types: "cc:fast:5"`)
+		assert.NotContains(t, err.Error(), "adc:1:5")
+		assert.ErrorContains(t, err, `ratelimit.trusted_proxies: "proxy"`)
+		assert.NotContains(t, err.Error(), "10.0.0.0/8")
+		assert.ErrorContains(t, err, "idempotency.window")
+		assert.ErrorContains(t, err, "commands.attempts")
+		assert.ErrorContains(t, err, "scheduler.timezone")
+		assert.ErrorContains(t, err, "scheduler.latitude")
+		assert.ErrorContains(t, err, "scheduler.misfire_grace")
+		assert.ErrorContains(t, err, "persistence.sync_interval")
+		assert.ErrorContains(t, err, "changes.prune_interval")
+	})
+
+	t.Run("ok, sqlite database", func(t *testing.T) {
+		c := Default()
+		c.Database.URL = "sqlite:///var/lib/smarthome/smarthome.db"
+		assert.NoError(t, c.Validate())
+
+		path, ok := c.Database.SQLitePath()
+		assert.True(t, ok)
+		assert.Equal(t, "/var/lib/smarthome/smarthome.db", path)
+	})
+
+	t.Run("err, sqlite database", func(t *testing.T) {
+		c := Default()
+		c.Database.URL = "sqlite://"
+		c.RateLimit.Backend = RateLimitBackendPostgres
+		c.Persistence.Dir = "/var/lib/smarthome"
+
+		err := c.Validate()
+		assert.ErrorContains(t, err, "database.url: sqlite:// needs the path")
+		assert.ErrorContains(t, err, "ratelimit.backend: postgres doesn't work with the sqlite database.url")
+		assert.ErrorContains(t, err, "persistence.dir: is not used with database.url")
+	})
+}
+
+func TestConfig_String(t *testing.T) {
+	c := Default()
+	c.Database.URL = "postgres://postgres:secret@db:5432/db"
+
+	s := c.String()
+	assert.Contains(t, s, "http.port = 8080\n")
+	assert.Contains(t, s, "database.url = postgres://postgres:xxxxx@db:5432/db\n")
+	assert.NotContains(t, s, "secret")
+}
+
+func TestConfig_Reloaded(t *testing.T) {
+	current := Default()
+	next := Default()
+	next.HTTP.Port = 9000
+	next.WebSocket.Tick = time.Second
+	next.Features.ValidateResponses = true
+
+	merged, ignored := current.Reloaded(next)
+	assert.Equal(t, []string{"http.port"}, ignored)
+	assert.Equal(t, 8080, merged.HTTP.Port)
+	assert.Equal(t, time.Second, merged.WebSocket.Tick)
+	assert.True(t, merged.Features.ValidateResponses)
+	assert.Equal(t, 2*time.Second, current.WebSocket.Tick, "Текущий конфиг не должен меняться")
+}
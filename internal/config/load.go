@@ -0,0 +1,153 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// fileEnv names the variable with the config file path, the -config flag takes precedence over it
+const fileEnv = "CONFIG_FILE"
+
+var ErrUnknownFileFormat = errors.New("config file must be .yaml, .yml or .toml")
+
+// Load builds the config from the defaults, then the file, then the environment, then the flags, and validates it.
+// flag.ErrHelp is returned if the usage has been requested.
+func Load(args []string) (*Config, error) {
+	return load(args, os.LookupEnv, os.Stderr)
+}
+
+func load(args []string, lookupEnv func(string) (string, bool), output io.Writer) (*Config, error) {
+	c := Default()
+	fields := c.fields()
+
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.SetOutput(output)
+	path := fs.String("config", "", "path to the config file (.yaml or .toml), also taken from "+fileEnv)
+	flags := make(map[string]*string, len(fields))
+	for _, f := range fields {
+		flags[f.key] = fs.String(f.flagName(), "", fmt.Sprintf("%s (env %s, default %s)", f.usage, f.envName(), f))
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	if *path == "" {
+		*path, _ = lookupEnv(fileEnv)
+	}
+	if *path != "" {
+		values, err := readFile(*path)
+		if err != nil {
+			return nil, err
+		}
+		if err := apply(fields, values); err != nil {
+			return nil, fmt.Errorf("%s: %w", *path, err)
+		}
+	}
+
+	for _, f := range fields {
+		if raw, has := lookupEnv(f.envName()); has {
+			if err := f.set(raw); err != nil {
+				return nil, fmt.Errorf("env %s: %w", f.envName(), err)
+			}
+		}
+	}
+
+	var err error
+	fs.Visit(func(fl *flag.Flag) {
+		for _, f := range fields {
+			if err == nil && fl.Name == f.flagName() {
+				err = f.set(*flags[f.key])
+			}
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("flag: %w", err)
+	}
+
+	return c, c.Validate()
+}
+
+// readFile reads the file into flat key-value pairs, e.g. {"http": {"port": 80}} becomes "http.port": "80"
+func readFile(path string) (map[string]string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := make(map[string]any)
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &doc)
+	case ".toml":
+		err = toml.Unmarshal(raw, &doc)
+	default:
+		return nil, fmt.Errorf("%s: %w", path, ErrUnknownFileFormat)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	flatten("", doc, values)
+	return values, nil
+}
+
+func flatten(prefix string, doc map[string]any, values map[string]string) {
+	for k, v := range doc {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if nested, ok := v.(map[string]any); ok {
+			flatten(key, nested, values)
+			continue
+		}
+		// a list is set the same way as in the environment, comma separated
+		if list, ok := v.([]any); ok {
+			items := make([]string, len(list))
+			for i, item := range list {
+				items[i] = fmt.Sprint(item)
+			}
+			values[key] = strings.Join(items, ",")
+			continue
+		}
+		values[key] = fmt.Sprint(v)
+	}
+}
+
+// apply sets the values from the file, unknown keys are reported as they are most likely typos
+func apply(fields []field, values map[string]string) error {
+	known := make(map[string]field, len(fields))
+	for _, f := range fields {
+		known[f.key] = f
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		f, ok := known[k]
+		if !ok {
+			return fmt.Errorf("unknown setting %q", k)
+		}
+		if err := f.set(values[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+)
+
+// Reloaded takes the reloadable settings from next, the rest are kept from c.
+// The keys of the settings that have changed but need a restart are returned.
+func (c *Config) Reloaded(next *Config) (*Config, []string) {
+	merged := *c
+	var ignored []string
+
+	dst, src := merged.fields(), next.fields()
+	for i := range dst {
+		to, from := reflect.ValueOf(dst[i].value).Elem(), reflect.ValueOf(src[i].value).Elem()
+		switch {
+		case dst[i].reloadable:
+			to.Set(from)
+		case !reflect.DeepEqual(to.Interface(), from.Interface()):
+			ignored = append(ignored, dst[i].key)
+		}
+	}
+	return &merged, ignored
+}
+
+// Watch reloads the config on SIGHUP until the context is done. A config that fails to load is skipped,
+// otherwise apply is called with the current config updated with the reloadable settings.
+func Watch(ctx context.Context, current *Config, load func() (*Config, error), apply func(*Config)) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			next, err := load()
+			if err != nil {
+				slog.Error("Config is not reloaded", "error", err)
+				continue
+			}
+			merged, ignored := current.Reloaded(next)
+			if len(ignored) > 0 {
+				slog.Warn("Config reload needs a restart to change some settings, they keep the old values", "settings", ignored)
+			}
+			current = merged
+			apply(current)
+			slog.Info("Config is reloaded", "config", current.String())
+		}
+	}
+}
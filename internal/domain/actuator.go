@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+var (
+	ErrInvalidActuator = errors.New("invalid actuator")
+
+	actuatorSerialPattern = regexp.MustCompile(`^\d{10}$`)
+	actuatorTypePattern   = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+)
+
+// Actuator - исполнительное устройство умного дома: реле, термостат, замок. В отличие от датчика
+// оно не присылает события, а забирает адресованные ему команды
+type Actuator struct {
+	ID           int64
+	SerialNumber string
+	// Type - тип устройства, например relay или thermostat, команды им не ограничиваются
+	Type         string
+	Description  string
+	RegisteredAt time.Time
+}
+
+// Validate checks the serial number and the type
+func (a Actuator) Validate() error {
+	if !actuatorSerialPattern.MatchString(a.SerialNumber) {
+		return fmt.Errorf("%w: serial number %q must match %s", ErrInvalidActuator, a.SerialNumber, actuatorSerialPattern)
+	}
+	if !actuatorTypePattern.MatchString(a.Type) {
+		return fmt.Errorf("%w: type %q must match %s", ErrInvalidActuator, a.Type, actuatorTypePattern)
+	}
+	return nil
+}
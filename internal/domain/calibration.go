@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// MaxCalibrationPoints - наибольшее количество точек в таблице калибровки
+const MaxCalibrationPoints = 64
+
+var ErrInvalidCalibration = errors.New("invalid calibration")
+
+// CalibrationPoint - точка таблицы калибровки: сырое значение и соответствующее ему значение в единицах измерения
+type CalibrationPoint struct {
+	Raw   Decimal
+	Value Decimal
+}
+
+// Calibration - калибровка канала датчика. Значение равно Raw * Gain + Offset, а если задана таблица Table,
+// то линейной интерполяции по ее точкам, Gain и Offset тогда не используются
+type Calibration struct {
+	Channel string
+	Gain    Decimal
+	Offset  Decimal
+	Table   []CalibrationPoint
+	// Unit - единица измерения откалиброванного значения, пустая - единица сырого значения
+	Unit string
+}
+
+// Validate checks the channel and the unit, that the gain is not zero and the raw values of the table grow
+func (c Calibration) Validate() error {
+	if !channelPattern.MatchString(c.Channel) {
+		return fmt.Errorf("%w: channel %q must match %s", ErrInvalidCalibration, c.Channel, channelPattern)
+	}
+	if len(c.Unit) > MaxUnitLength {
+		return fmt.Errorf("%w: unit of channel %q is longer than %d", ErrInvalidCalibration, c.Channel, MaxUnitLength)
+	}
+
+	if len(c.Table) == 0 {
+		if c.Gain.Units == 0 {
+			return fmt.Errorf("%w: gain of channel %q is zero", ErrInvalidCalibration, c.Channel)
+		}
+		return nil
+	}
+	if len(c.Table) < 2 || len(c.Table) > MaxCalibrationPoints {
+		return fmt.Errorf("%w: table of channel %q must have from 2 to %d points", ErrInvalidCalibration, c.Channel, MaxCalibrationPoints)
+	}
+	for i := 1; i < len(c.Table); i++ {
+		if c.Table[i-1].Raw.Cmp(c.Table[i].Raw) >= 0 {
+			return fmt.Errorf("%w: raw values of the table of channel %q must grow", ErrInvalidCalibration, c.Channel)
+		}
+	}
+	return nil
+}
+
+// Apply converts the raw value. Beyond the table the values are extrapolated by its first or last segment.
+func (c Calibration) Apply(raw Decimal) (Decimal, error) {
+	x := raw.rat()
+	if len(c.Table) == 0 {
+		return decimalFromRat(x.Mul(x, c.Gain.rat()).Add(x, c.Offset.rat()))
+	}
+
+	i := 1
+	for i < len(c.Table)-1 && raw.Cmp(c.Table[i].Raw) > 0 {
+		i++
+	}
+	x0, y0 := c.Table[i-1].Raw.rat(), c.Table[i-1].Value.rat()
+	x1, y1 := c.Table[i].Raw.rat(), c.Table[i].Value.rat()
+
+	// y0 + (x - x0) * (y1 - y0) / (x1 - x0)
+	slope := new(big.Rat).Quo(new(big.Rat).Sub(y1, y0), new(big.Rat).Sub(x1, x0))
+	y := new(big.Rat).Mul(new(big.Rat).Sub(x, x0), slope)
+	return decimalFromRat(y.Add(y, y0))
+}
+
+// ValidateCalibrations checks every calibration and that a channel is calibrated once
+func ValidateCalibrations(cs []Calibration) error {
+	if len(cs) > MaxChannels {
+		return fmt.Errorf("%w: more than %d channels", ErrInvalidCalibration, MaxChannels)
+	}
+	seen := make(map[string]struct{}, len(cs))
+	for _, c := range cs {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+		if _, ok := seen[c.Channel]; ok {
+			return fmt.Errorf("%w: channel %q is repeated", ErrInvalidCalibration, c.Channel)
+		}
+		seen[c.Channel] = struct{}{}
+	}
+	return nil
+}
+
+// Calibrate converts the readings of the calibrated channels, the others are left as they are.
+// The payload is not changed, a new one is returned.
+func (p Payload) Calibrate(cs []Calibration) (Payload, error) {
+	calibrated := make(Payload, len(p))
+	copy(calibrated, p)
+	for _, c := range cs {
+		for i, r := range calibrated {
+			if r.Channel != c.Channel {
+				continue
+			}
+			v, err := c.Apply(r.Value)
+			if err != nil {
+				return nil, err
+			}
+			calibrated[i].Value = v
+			if c.Unit != "" {
+				calibrated[i].Unit = c.Unit
+			}
+		}
+	}
+	return calibrated, nil
+}
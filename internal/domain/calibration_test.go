@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalibration_Validate(t *testing.T) {
+	point := func(raw, value int64) CalibrationPoint {
+		return CalibrationPoint{Raw: Decimal{Units: raw}, Value: Decimal{Units: value}}
+	}
+	assert.NoError(t, Calibration{Channel: "value", Gain: Decimal{Units: 1}}.Validate())
+	assert.NoError(t, Calibration{Channel: "value", Table: []CalibrationPoint{point(0, 0), point(10, 5)}}.Validate(),
+		"С таблицей множитель не нужен")
+
+	tests := []struct {
+		name        string
+		calibration Calibration
+	}{
+		{"bad channel", Calibration{Channel: "1st", Gain: Decimal{Units: 1}}},
+		{"long unit", Calibration{Channel: "value", Gain: Decimal{Units: 1}, Unit: "very long unit name"}},
+		{"zero gain", Calibration{Channel: "value"}},
+		{"single point", Calibration{Channel: "value", Table: []CalibrationPoint{point(0, 0)}}},
+		{"raw does not grow", Calibration{Channel: "value", Table: []CalibrationPoint{point(0, 0), point(0, 5)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.calibration.Validate(), ErrInvalidCalibration)
+		})
+	}
+}
+
+func TestCalibration_Apply(t *testing.T) {
+	linear := Calibration{Channel: "value", Gain: Decimal{Units: 25, Scale: 1}, Offset: Decimal{Units: -1}}
+	table := Calibration{Channel: "value", Table: []CalibrationPoint{
+		{Raw: Decimal{Units: 0}, Value: Decimal{Units: -40}},
+		{Raw: Decimal{Units: 1000}, Value: Decimal{Units: 10}},
+		{Raw: Decimal{Units: 4000}, Value: Decimal{Units: 100}},
+	}}
+	thirds := Calibration{Channel: "value", Table: []CalibrationPoint{
+		{Raw: Decimal{Units: 0}, Value: Decimal{Units: 0}},
+		{Raw: Decimal{Units: 3}, Value: Decimal{Units: 2}},
+	}}
+
+	tests := []struct {
+		name        string
+		calibration Calibration
+		raw         Decimal
+		want        Decimal
+	}{
+		{"linear", linear, Decimal{Units: 100}, Decimal{Units: 249}},
+		{"linear, fraction", linear, Decimal{Units: 1}, Decimal{Units: 15, Scale: 1}},
+		{"table, point", table, Decimal{Units: 1000}, Decimal{Units: 10}},
+		{"table, first segment", table, Decimal{Units: 500}, Decimal{Units: -15}},
+		{"table, last segment", table, Decimal{Units: 2500}, Decimal{Units: 55}},
+		{"table, below", table, Decimal{Units: -100}, Decimal{Units: -45}},
+		{"table, above", table, Decimal{Units: 5000}, Decimal{Units: 130}},
+		{"rounded to 9 places", thirds, Decimal{Units: 1}, Decimal{Units: 666666667, Scale: 9}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.calibration.Apply(tt.raw)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateCalibrations(t *testing.T) {
+	c := Calibration{Channel: "value", Gain: Decimal{Units: 1}}
+	assert.NoError(t, ValidateCalibrations(nil))
+	assert.NoError(t, ValidateCalibrations([]Calibration{c}))
+	assert.ErrorIs(t, ValidateCalibrations([]Calibration{c, c}), ErrInvalidCalibration, "Канал калибруется один раз")
+}
+
+func TestPayload_Calibrate(t *testing.T) {
+	raw := Payload{
+		{Channel: "value", Value: Decimal{Units: 2048}},
+		{Channel: "humidity", Value: Decimal{Units: 40}, Unit: "%"},
+	}
+	calibrated, err := raw.Calibrate([]Calibration{
+		{Channel: "value", Gain: Decimal{Units: 5, Scale: 1}, Unit: "°C"},
+		{Channel: "pressure", Gain: Decimal{Units: 2}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Payload{
+		{Channel: "value", Value: Decimal{Units: 1024}, Unit: "°C"},
+		{Channel: "humidity", Value: Decimal{Units: 40}, Unit: "%"},
+	}, calibrated)
+	assert.Equal(t, Decimal{Units: 2048}, raw[0].Value, "Исходные показания не должны меняться")
+}
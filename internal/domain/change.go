@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// ChangeKind - вид измененной сущности
+type ChangeKind string
+
+const (
+	ChangeKindSensor      ChangeKind = "sensor"
+	ChangeKindUser        ChangeKind = "user"
+	ChangeKindSensorOwner ChangeKind = "sensor_owner"
+	ChangeKindEvent       ChangeKind = "event"
+)
+
+var (
+	ErrInvalidConsumer = errors.New("invalid consumer")
+
+	consumerNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+)
+
+// Change - запись журнала изменений: сущность такой, какой она сохранена. Изменение - это создание сущности
+// или ее обновление, из полей сущностей задано только то, что соответствует Kind.
+type Change struct {
+	// Cursor - позиция записи в журнале, растет в порядке, в котором записи становятся видны читателям
+	Cursor int64
+	Kind   ChangeKind
+	// Time - время записи изменения
+	Time        time.Time
+	Sensor      *Sensor
+	User        *User
+	SensorOwner *SensorOwner
+	Event       *Event
+}
+
+// SensorChange - изменение датчика, датчик копируется
+func SensorChange(sensor Sensor) Change {
+	sensor = sensor.Clone()
+	return Change{Kind: ChangeKindSensor, Sensor: &sensor}
+}
+
+// UserChange - изменение пользователя
+func UserChange(user User) Change {
+	return Change{Kind: ChangeKindUser, User: &user}
+}
+
+// SensorOwnerChange - привязка датчика к пользователю
+func SensorOwnerChange(owner SensorOwner) Change {
+	return Change{Kind: ChangeKindSensorOwner, SensorOwner: &owner}
+}
+
+// EventChange - новое событие датчика, событие копируется
+func EventChange(event Event) Change {
+	event = event.Clone()
+	return Change{Kind: ChangeKindEvent, Event: &event}
+}
+
+// Validate checks that the entity of the kind is set, as the record read from the storage must have it
+func (c Change) Validate() error {
+	var set bool
+	switch c.Kind {
+	case ChangeKindSensor:
+		set = c.Sensor != nil
+	case ChangeKindUser:
+		set = c.User != nil
+	case ChangeKindSensorOwner:
+		set = c.SensorOwner != nil
+	case ChangeKindEvent:
+		set = c.Event != nil
+	default:
+		return fmt.Errorf("unknown change kind %q", c.Kind)
+	}
+	if !set {
+		return fmt.Errorf("change %d has no %s", c.Cursor, c.Kind)
+	}
+	return nil
+}
+
+// ChangeConsumer - потребитель журнала изменений и курсор последней обработанной им записи
+type ChangeConsumer struct {
+	Name      string
+	Cursor    int64
+	UpdatedAt time.Time
+}
+
+// Validate checks the name and the cursor, the name is a short slug so it fits the path
+func (c ChangeConsumer) Validate() error {
+	if !consumerNamePattern.MatchString(c.Name) {
+		return fmt.Errorf("%w: name must match %s", ErrInvalidConsumer, consumerNamePattern)
+	}
+	if c.Cursor < 0 {
+		return fmt.Errorf("%w: cursor must not be negative", ErrInvalidConsumer)
+	}
+	return nil
+}
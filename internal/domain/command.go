@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+type CommandStatus string
+
+// Команда ставится в очередь, отправляется устройству и подтверждается им. Неподтвержденная за AckTimeout
+// команда снова встает в очередь, пока не кончатся попытки. Не завершенная к ExpiresAt команда истекает.
+const (
+	CommandStatusQueued       CommandStatus = "queued"
+	CommandStatusSent         CommandStatus = "sent"
+	CommandStatusAcknowledged CommandStatus = "acknowledged"
+	CommandStatusFailed       CommandStatus = "failed"
+	CommandStatusExpired      CommandStatus = "expired"
+)
+
+// MaxCommandAttempts - наибольшее количество попыток доставки команды
+const MaxCommandAttempts = 10
+
+var (
+	ErrInvalidCommand = errors.New("invalid command")
+
+	commandActionPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+)
+
+// Command - команда исполнительному устройству, например switch с аргументом state = 1
+type Command struct {
+	ID         int64
+	ActuatorID int64
+	Action     string
+	// Args - аргументы команды в виде показаний по каналам, могут отсутствовать
+	Args   Payload
+	Status CommandStatus
+	// Attempts - сколько раз команда была отправлена устройству
+	Attempts    int
+	MaxAttempts int
+	// AckTimeout - сколько ждать подтверждения отправленной команды, прежде чем отправить ее снова
+	AckTimeout  time.Duration
+	CreatedAt   time.Time
+	SentAt      time.Time
+	ExpiresAt   time.Time
+	CompletedAt time.Time
+	// Error - причина неудачи, которую сообщило устройство, или таймаут
+	Error string
+}
+
+// Validate checks the action, the arguments and the delivery limits
+func (c Command) Validate() error {
+	if !commandActionPattern.MatchString(c.Action) {
+		return fmt.Errorf("%w: action %q must match %s", ErrInvalidCommand, c.Action, commandActionPattern)
+	}
+	if len(c.Args) > 0 {
+		if err := c.Args.Validate(); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidCommand, err)
+		}
+	}
+	if c.MaxAttempts < 1 || c.MaxAttempts > MaxCommandAttempts {
+		return fmt.Errorf("%w: attempts must be from 1 to %d", ErrInvalidCommand, MaxCommandAttempts)
+	}
+	if c.AckTimeout <= 0 {
+		return fmt.Errorf("%w: ack timeout must be positive", ErrInvalidCommand)
+	}
+	if !c.ExpiresAt.After(c.CreatedAt) {
+		return fmt.Errorf("%w: command must expire after it is created", ErrInvalidCommand)
+	}
+	return nil
+}
+
+// Finished tells whether the command has reached its final status
+func (c Command) Finished() bool {
+	switch c.Status {
+	case CommandStatusAcknowledged, CommandStatusFailed, CommandStatusExpired:
+		return true
+	}
+	return false
+}
+
+// Refresh applies the timeouts at the moment now and tells whether the command has changed:
+// a command past ExpiresAt expires, a sent one without the acknowledgement is queued again or fails
+// when the attempts are over.
+func (c *Command) Refresh(now time.Time) bool {
+	if c.Finished() {
+		return false
+	}
+	if !now.Before(c.ExpiresAt) {
+		c.finish(CommandStatusExpired, now, "not acknowledged before it expired")
+		return true
+	}
+	if c.Status != CommandStatusSent || now.Before(c.SentAt.Add(c.AckTimeout)) {
+		return false
+	}
+	if c.Attempts >= c.MaxAttempts {
+		c.finish(CommandStatusFailed, now, fmt.Sprintf("not acknowledged after %d attempts", c.Attempts))
+		return true
+	}
+	c.Status = CommandStatusQueued
+	return true
+}
+
+// Send marks the queued command as sent to the device at the moment now
+func (c *Command) Send(now time.Time) {
+	c.Status = CommandStatusSent
+	c.Attempts++
+	c.SentAt = now
+}
+
+// Acknowledge finishes the command with the answer of the device, an empty failure means success
+func (c *Command) Acknowledge(now time.Time, failure string) {
+	if failure != "" {
+		c.finish(CommandStatusFailed, now, failure)
+		return
+	}
+	c.finish(CommandStatusAcknowledged, now, "")
+}
+
+func (c *Command) finish(status CommandStatus, now time.Time, reason string) {
+	c.Status = status
+	c.CompletedAt = now
+	c.Error = reason
+}
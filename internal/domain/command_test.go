@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActuator_Validate(t *testing.T) {
+	assert.NoError(t, Actuator{SerialNumber: "0123456789", Type: "relay"}.Validate())
+	assert.ErrorIs(t, Actuator{SerialNumber: "123", Type: "relay"}.Validate(), ErrInvalidActuator)
+	assert.ErrorIs(t, Actuator{SerialNumber: "0123456789", Type: "Relay"}.Validate(), ErrInvalidActuator)
+}
+
+func TestCommand_Validate(t *testing.T) {
+	created := time.Unix(100, 0)
+	valid := func() Command {
+		return Command{Action: "switch", MaxAttempts: 3, AckTimeout: time.Second, CreatedAt: created, ExpiresAt: created.Add(time.Minute)}
+	}
+	assert.NoError(t, valid().Validate())
+
+	tests := []struct {
+		name   string
+		modify func(c *Command)
+	}{
+		{"bad action", func(c *Command) { c.Action = "Switch" }},
+		{"bad args", func(c *Command) { c.Args = Payload{{Channel: "1st"}} }},
+		{"no attempts", func(c *Command) { c.MaxAttempts = 0 }},
+		{"too many attempts", func(c *Command) { c.MaxAttempts = MaxCommandAttempts + 1 }},
+		{"no ack timeout", func(c *Command) { c.AckTimeout = 0 }},
+		{"expires at once", func(c *Command) { c.ExpiresAt = c.CreatedAt }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid()
+			tt.modify(&c)
+			assert.ErrorIs(t, c.Validate(), ErrInvalidCommand)
+		})
+	}
+}
+
+func TestCommand_Refresh(t *testing.T) {
+	start := time.Unix(100, 0)
+	sent := func(attempts int) Command {
+		return Command{
+			Status: CommandStatusSent, Attempts: attempts, MaxAttempts: 2, AckTimeout: 10 * time.Second,
+			SentAt: start, ExpiresAt: start.Add(time.Minute),
+		}
+	}
+
+	t.Run("waits for the ack", func(t *testing.T) {
+		c := sent(1)
+		assert.False(t, c.Refresh(start.Add(9*time.Second)))
+		assert.Equal(t, CommandStatusSent, c.Status)
+	})
+
+	t.Run("queued again after the ack timeout", func(t *testing.T) {
+		c := sent(1)
+		assert.True(t, c.Refresh(start.Add(10*time.Second)))
+		assert.Equal(t, CommandStatusQueued, c.Status, "Неподтвержденная команда должна быть отправлена снова")
+		assert.False(t, c.Finished())
+	})
+
+	t.Run("fails when the attempts are over", func(t *testing.T) {
+		c := sent(2)
+		assert.True(t, c.Refresh(start.Add(10*time.Second)))
+		assert.Equal(t, CommandStatusFailed, c.Status)
+		assert.Equal(t, start.Add(10*time.Second), c.CompletedAt)
+		assert.NotEmpty(t, c.Error)
+	})
+
+	t.Run("expires", func(t *testing.T) {
+		c := sent(1)
+		c.Status = CommandStatusQueued
+		assert.True(t, c.Refresh(start.Add(time.Minute)))
+		assert.Equal(t, CommandStatusExpired, c.Status)
+	})
+
+	t.Run("finished is kept", func(t *testing.T) {
+		c := sent(1)
+		c.Acknowledge(start, "")
+		assert.False(t, c.Refresh(start.Add(time.Hour)))
+		assert.Equal(t, CommandStatusAcknowledged, c.Status, "Завершенная команда не должна истекать")
+	})
+}
+
+func TestCommand_SendAndAcknowledge(t *testing.T) {
+	now := time.Unix(100, 0)
+	c := Command{Status: CommandStatusQueued}
+	c.Send(now)
+	c.Send(now.Add(time.Second))
+	assert.Equal(t, CommandStatusSent, c.Status)
+	assert.Equal(t, 2, c.Attempts)
+	assert.Equal(t, now.Add(time.Second), c.SentAt)
+
+	failed := c
+	failed.Acknowledge(now.Add(2*time.Second), "jammed")
+	assert.Equal(t, CommandStatusFailed, failed.Status)
+	assert.Equal(t, "jammed", failed.Error)
+
+	c.Acknowledge(now.Add(2*time.Second), "")
+	assert.Equal(t, CommandStatusAcknowledged, c.Status)
+	assert.Empty(t, c.Error)
+	assert.True(t, c.Finished())
+}
@@ -0,0 +1,196 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCron = errors.New("invalid cron expression")
+
+// cronSearchYears bounds the search of the next run, an expression like 0 0 30 2 * never matches
+const cronSearchYears = 5
+
+// Cron - расписание в формате crontab из пяти полей: минута, час, день месяца, месяц, день недели.
+// Поддерживаются *, списки, диапазоны, шаги, имена месяцев и дней недели и макросы вроде @daily.
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+	// domAny and dowAny keep the rule of cron: when both days are restricted, either of them matches
+	domAny, dowAny bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var (
+	cronMinute = cronField{name: "minute", min: 0, max: 59}
+	cronHour   = cronField{name: "hour", min: 0, max: 23}
+	cronDom    = cronField{name: "day of month", min: 1, max: 31}
+	cronMonth  = cronField{name: "month", min: 1, max: 12,
+		names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	// 7 is sunday too, as in most crons
+	cronDow = cronField{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+
+	cronMacros = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// ParseCron parses the expression, the names are case-insensitive
+func ParseCron(expr string) (Cron, error) {
+	expr = strings.ToLower(strings.TrimSpace(expr))
+	if macro, ok := cronMacros[expr]; ok {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return Cron{}, fmt.Errorf("%w: %q must have 5 fields", ErrInvalidCron, expr)
+	}
+
+	var c Cron
+	var err error
+	if c.minute, err = cronMinute.parse(fields[0]); err != nil {
+		return Cron{}, err
+	}
+	if c.hour, err = cronHour.parse(fields[1]); err != nil {
+		return Cron{}, err
+	}
+	if c.dom, err = cronDom.parse(fields[2]); err != nil {
+		return Cron{}, err
+	}
+	if c.month, err = cronMonth.parse(fields[3]); err != nil {
+		return Cron{}, err
+	}
+	if c.dow, err = cronDow.parse(fields[4]); err != nil {
+		return Cron{}, err
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domAny, c.dowAny = fields[2] == "*", fields[4] == "*"
+	return c, nil
+}
+
+// parse turns the field into the set of its values
+func (f cronField) parse(s string) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(s, ",") {
+		rng, stepRaw, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepRaw); err != nil || step < 1 {
+				return 0, fmt.Errorf("%w: %s step %q must be a positive integer", ErrInvalidCron, f.name, stepRaw)
+			}
+		}
+
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			loRaw, hiRaw, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(loRaw); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = f.value(hiRaw); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				// 5/15 means from 5 to the end by 15
+				hi = f.max
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("%w: %s range %q is reversed", ErrInvalidCron, f.name, rng)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if s == name {
+			return i + f.min, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%w: %s %q must be from %d to %d", ErrInvalidCron, f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+func (c Cron) matchDay(t time.Time) bool {
+	dom, dow := c.dom&(1<<t.Day()) != 0, c.dow&(1<<int(t.Weekday())) != 0
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+// Next returns the first moment after t that matches, in the location of t.
+// A time skipped by the daylight saving shift is run at the moment the clock has been moved to.
+// The zero time means the expression never matches.
+func (c Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Year() + cronSearchYears
+
+	// shifted is set for the hour the clock has been moved to, if one of the hours it skipped matches
+	shifted := false
+	for t.Year() <= limit {
+		if c.month&(1<<int(t.Month())) == 0 {
+			t, shifted = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc), false
+			continue
+		}
+		if !c.matchDay(t) {
+			t, shifted = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc), false
+			continue
+		}
+		if !shifted && c.hour&(1<<t.Hour()) == 0 {
+			next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			if !next.After(t) {
+				// the clock went back an hour, the same hour comes again
+				next = t.Add(time.Hour).Truncate(time.Hour)
+			}
+			for h := t.Hour() + 1; h < next.Hour() && next.Day() == t.Day(); h++ {
+				shifted = shifted || c.hour&(1<<h) != 0
+			}
+			t = next
+			continue
+		}
+		if c.minute&(1<<t.Minute()) == 0 {
+			// the next matching minute of the hour, or the next hour
+			rest := c.minute >> (t.Minute() + 1)
+			if rest == 0 {
+				t, shifted = t.Add(time.Duration(60-t.Minute())*time.Minute), false
+				continue
+			}
+			t = t.Add(time.Duration(bits.TrailingZeros64(rest)+1) * time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCron(t *testing.T) {
+	for _, expr := range []string{"* * * * *", "*/15 9-18 * * mon-fri", "0 0 1,15 * *", "5/10 * * jan,JUL 7", "@daily"} {
+		_, err := ParseCron(expr)
+		assert.NoError(t, err, expr)
+	}
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8",
+		"*/0 * * * *", "5-1 * * * *", "* * * foo *", "@often"} {
+		_, err := ParseCron(expr)
+		assert.ErrorIs(t, err, ErrInvalidCron, expr)
+	}
+}
+
+func TestCron_Next(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	// a wednesday
+	start := time.Date(2024, 3, 13, 10, 7, 30, 0, moscow)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 3, 13, 10, 8, 0, 0, moscow)},
+		{"*/15 * * * *", time.Date(2024, 3, 13, 10, 15, 0, 0, moscow)},
+		{"0 7 * * *", time.Date(2024, 3, 14, 7, 0, 0, 0, moscow)},
+		{"30 8 * * sat,sun", time.Date(2024, 3, 16, 8, 30, 0, 0, moscow)},
+		{"0 0 1 * *", time.Date(2024, 4, 1, 0, 0, 0, 0, moscow)},
+		{"0 12 29 2 *", time.Date(2028, 2, 29, 12, 0, 0, 0, moscow)},
+		// either of the days matches when both are set
+		{"0 0 20 * fri", time.Date(2024, 3, 15, 0, 0, 0, 0, moscow)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, c.Next(start))
+		})
+	}
+}
+
+func TestCron_Next_DaylightSaving(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	c, err := ParseCron("30 2 * * *")
+	require.NoError(t, err)
+	// 2:30 does not exist on 31 March, the clock goes from 2:00 to 3:00
+	next := c.Next(time.Date(2024, 3, 30, 23, 0, 0, 0, berlin))
+	assert.Equal(t, time.Date(2024, 3, 31, 3, 30, 0, 0, berlin), next, "Пропущенное время выполняется после перевода часов")
+	assert.Equal(t, time.Date(2024, 4, 1, 2, 30, 0, 0, berlin), c.Next(next))
+}
@@ -7,5 +7,16 @@ type Event struct {
 	Timestamp          time.Time
 	SensorSerialNumber string
 	SensorID           int64
-	Payload            int64
+	// Payload - откалиброванные показания
+	Payload Payload
+	// Raw - показания, как их прислал датчик, nil если калибровки не было
+	Raw Payload
+	// Received - время приёма события, если Timestamp задало устройство, иначе нулевое
+	Received time.Time `json:"-"`
+}
+
+// Clone copies the event with its readings
+func (e Event) Clone() Event {
+	e.Payload, e.Raw = e.Payload.Clone(), e.Raw.Clone()
+	return e
 }
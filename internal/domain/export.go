@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+type ExportStatus string
+
+const (
+	ExportStatusPending   ExportStatus = "pending"
+	ExportStatusRunning   ExportStatus = "running"
+	ExportStatusCompleted ExportStatus = "completed"
+	ExportStatusFailed    ExportStatus = "failed"
+)
+
+// Export - структура задачи на выгрузку истории датчиков в файл
+type Export struct {
+	ID          int64
+	SensorIDs   []int64
+	From        time.Time
+	To          time.Time
+	Status      ExportStatus
+	Error       string
+	CreatedAt   time.Time
+	CompletedAt time.Time
+	// Path - путь к готовому файлу, заполняется после завершения выгрузки
+	Path string
+}
@@ -0,0 +1,311 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxExpressionLength - наибольшая длина формулы виртуального датчика
+	MaxExpressionLength = 512
+	// MaxExpressionInputs - наибольшее количество датчиков, от которых зависит виртуальный датчик
+	MaxExpressionInputs = 32
+)
+
+var (
+	ErrInvalidExpression = errors.New("invalid expression")
+	// ErrNoReading - у датчика, от которого зависит формула, еще нет показаний
+	ErrNoReading = errors.New("input sensor has no reading")
+)
+
+// Expression - формула виртуального датчика над показаниями других датчиков, например any(#1, #2, #3)
+// или avg(#4.temperature, #5.temperature). #ID - основной канал датчика, #ID.channel - его канал.
+// Функции: any и all дают 1 или 0, not инвертирует, avg, min, max и sum считают по аргументам.
+type Expression struct {
+	root exprNode
+}
+
+type exprNode interface {
+	eval(states map[int64]Payload) (exprValue, error)
+}
+
+// exprValue keeps the unit, so the average of the temperatures is in degrees too
+type exprValue struct {
+	value *big.Rat
+	unit  string
+}
+
+type exprNumber struct{ value Decimal }
+
+type exprRef struct {
+	sensorID int64
+	channel  string
+}
+
+type exprCall struct {
+	name string
+	args []exprNode
+}
+
+// exprArity is the least and the most number of the arguments of the functions, 0 is no limit
+var exprArity = map[string][2]int{
+	"any": {1, 0}, "all": {1, 0}, "not": {1, 1}, "avg": {1, 0}, "min": {1, 0}, "max": {1, 0}, "sum": {1, 0},
+}
+
+// ParseExpression parses the formula and checks the number of the sensors it reads
+func ParseExpression(s string) (*Expression, error) {
+	if len(s) > MaxExpressionLength {
+		return nil, fmt.Errorf("%w: longer than %d", ErrInvalidExpression, MaxExpressionLength)
+	}
+	p := exprParser{src: s}
+	root, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos:])
+	}
+
+	e := &Expression{root: root}
+	if inputs := e.Inputs(); len(inputs) == 0 || len(inputs) > MaxExpressionInputs {
+		return nil, fmt.Errorf("%w: must read from 1 to %d sensors", ErrInvalidExpression, MaxExpressionInputs)
+	}
+	return e, nil
+}
+
+// Inputs returns the IDs of the sensors the formula reads, sorted and without repeats
+func (e *Expression) Inputs() []int64 {
+	var ids []int64
+	var walk func(n exprNode)
+	walk = func(n exprNode) {
+		switch n := n.(type) {
+		case exprRef:
+			ids = append(ids, n.sensorID)
+		case exprCall:
+			for _, a := range n.args {
+				walk(a)
+			}
+		}
+	}
+	walk(e.root)
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+// Eval computes the formula over the current readings of the inputs. The result is the main channel,
+// it keeps the unit if all the averaged readings have the same one.
+func (e *Expression) Eval(states map[int64]Payload) (Payload, error) {
+	v, err := e.root.eval(states)
+	if err != nil {
+		return nil, err
+	}
+	d, err := decimalFromRat(v.value)
+	if err != nil {
+		return nil, err
+	}
+	return Payload{{Channel: DefaultChannel, Value: d, Unit: v.unit}}, nil
+}
+
+func (n exprNumber) eval(map[int64]Payload) (exprValue, error) {
+	return exprValue{value: n.value.rat()}, nil
+}
+
+func (n exprRef) eval(states map[int64]Payload) (exprValue, error) {
+	p := states[n.sensorID]
+	if len(p) == 0 {
+		return exprValue{}, fmt.Errorf("%w: #%d", ErrNoReading, n.sensorID)
+	}
+	r := p[0]
+	if n.channel != "" {
+		var ok bool
+		if r, ok = p.Channel(n.channel); !ok {
+			return exprValue{}, fmt.Errorf("%w: #%d.%s", ErrNoReading, n.sensorID, n.channel)
+		}
+	}
+	return exprValue{value: r.Value.rat(), unit: r.Unit}, nil
+}
+
+func (n exprCall) eval(states map[int64]Payload) (exprValue, error) {
+	args := make([]exprValue, len(n.args))
+	for i, a := range n.args {
+		v, err := a.eval(states)
+		if err != nil {
+			return exprValue{}, err
+		}
+		args[i] = v
+	}
+
+	truth := func(ok bool) exprValue {
+		if ok {
+			return exprValue{value: big.NewRat(1, 1)}
+		}
+		return exprValue{value: new(big.Rat)}
+	}
+	switch n.name {
+	case "any":
+		return truth(slices.ContainsFunc(args, func(v exprValue) bool { return v.value.Sign() != 0 })), nil
+	case "all":
+		return truth(!slices.ContainsFunc(args, func(v exprValue) bool { return v.value.Sign() == 0 })), nil
+	case "not":
+		return truth(args[0].value.Sign() == 0), nil
+	}
+
+	result := exprValue{value: new(big.Rat).Set(args[0].value), unit: args[0].unit}
+	for _, v := range args[1:] {
+		switch n.name {
+		case "min":
+			if v.value.Cmp(result.value) < 0 {
+				result.value.Set(v.value)
+			}
+		case "max":
+			if v.value.Cmp(result.value) > 0 {
+				result.value.Set(v.value)
+			}
+		default:
+			result.value.Add(result.value, v.value)
+		}
+		if v.unit != result.unit {
+			result.unit = ""
+		}
+	}
+	if n.name == "avg" {
+		result.value.Quo(result.value, big.NewRat(int64(len(args)), 1))
+	}
+	return result, nil
+}
+
+type exprParser struct {
+	src string
+	pos int
+}
+
+func (p *exprParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: at %d: %s", ErrInvalidExpression, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// take reads the longest run of the bytes that fit
+func (p *exprParser) take(fits func(c byte) bool) string {
+	start := p.pos
+	for p.pos < len(p.src) && fits(p.src[p.pos]) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isNameByte(c byte) bool { return c >= 'a' && c <= 'z' || c == '_' || isDigit(c) }
+
+func (p *exprParser) parse() (exprNode, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return nil, p.errorf("unexpected end")
+	}
+
+	switch c := p.src[p.pos]; {
+	case c == '#':
+		p.pos++
+		id, err := strconv.ParseInt(p.take(isDigit), 10, 64)
+		if err != nil || id < 1 {
+			return nil, p.errorf("sensor id must be a positive integer")
+		}
+		ref := exprRef{sensorID: id}
+		if p.pos < len(p.src) && p.src[p.pos] == '.' {
+			p.pos++
+			ref.channel = p.take(isNameByte)
+			if !channelPattern.MatchString(ref.channel) {
+				return nil, p.errorf("channel %q must match %s", ref.channel, channelPattern)
+			}
+		}
+		return ref, nil
+	case isDigit(c) || c == '-':
+		raw := p.take(func(c byte) bool { return isDigit(c) || c == '.' || c == '-' })
+		d, err := ParseDecimal(raw)
+		if err != nil {
+			return nil, p.errorf("%q is not a number", raw)
+		}
+		return exprNumber{value: d}, nil
+	case c >= 'a' && c <= 'z':
+		return p.parseCall()
+	}
+	return nil, p.errorf("unexpected %q", p.src[p.pos])
+}
+
+func (p *exprParser) parseCall() (exprNode, error) {
+	name := p.take(isNameByte)
+	arity, ok := exprArity[name]
+	if !ok {
+		return nil, p.errorf("unknown function %q", name)
+	}
+	p.skipSpace()
+	if p.pos >= len(p.src) || p.src[p.pos] != '(' {
+		return nil, p.errorf("%s needs (", name)
+	}
+	p.pos++
+
+	call := exprCall{name: name}
+	for {
+		arg, err := p.parse()
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("%s is not closed", name)
+		}
+		if p.src[p.pos] == ')' {
+			p.pos++
+			break
+		}
+		if p.src[p.pos] != ',' {
+			return nil, p.errorf("unexpected %q in %s", p.src[p.pos], name)
+		}
+		p.pos++
+	}
+
+	if len(call.args) < arity[0] || arity[1] > 0 && len(call.args) > arity[1] {
+		return nil, p.errorf("wrong number of arguments of %s", name)
+	}
+	return call, nil
+}
+
+// String returns the formula in the canonical form
+func (e *Expression) String() string {
+	var b strings.Builder
+	var write func(n exprNode)
+	write = func(n exprNode) {
+		switch n := n.(type) {
+		case exprNumber:
+			b.WriteString(n.value.String())
+		case exprRef:
+			fmt.Fprintf(&b, "#%d", n.sensorID)
+			if n.channel != "" {
+				b.WriteString("." + n.channel)
+			}
+		case exprCall:
+			b.WriteString(n.name + "(")
+			for i, a := range n.args {
+				if i > 0 {
+					b.WriteString(", ")
+				}
+				write(a)
+			}
+			b.WriteString(")")
+		}
+	}
+	write(e.root)
+	return b.String()
+}
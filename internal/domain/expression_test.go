@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExpression(t *testing.T) {
+	tests := []struct {
+		in        string
+		canonical string
+		inputs    []int64
+	}{
+		{"any(#3,#1, #2)", "any(#3, #1, #2)", []int64{1, 2, 3}},
+		{"avg( #4.temperature , #5.temperature )", "avg(#4.temperature, #5.temperature)", []int64{4, 5}},
+		{"not(all(#1, #1))", "not(all(#1, #1))", []int64{1}},
+		{"max(#2, 0.50)", "max(#2, 0.5)", []int64{2}},
+		{"sum(#7, -1)", "sum(#7, -1)", []int64{7}},
+		{"#8.battery", "#8.battery", []int64{8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			e, err := ParseExpression(tt.in)
+			require.NoError(t, err)
+			assert.Equal(t, tt.canonical, e.String())
+			assert.Equal(t, tt.inputs, e.Inputs())
+		})
+	}
+
+	for _, in := range []string{"", "avg()", "avg(#1", "avg(#1,)", "avg(#1) #2", "median(#1)", "not(#1, #2)",
+		"avg(#0)", "avg(#1.Temp)", "avg(1, 2)", "avg(#1; #2)", "any(#1, 1/2)"} {
+		t.Run("err "+in, func(t *testing.T) {
+			_, err := ParseExpression(in)
+			assert.ErrorIs(t, err, ErrInvalidExpression)
+		})
+	}
+}
+
+func TestExpression_Eval(t *testing.T) {
+	states := map[int64]Payload{
+		1: IntPayload(0),
+		2: IntPayload(1),
+		3: IntPayload(0),
+		4: {{Channel: "temperature", Value: Decimal{Units: 215, Scale: 1}, Unit: "°C"}, {Channel: "humidity", Value: Decimal{Units: 40}}},
+		5: {{Channel: "temperature", Value: Decimal{Units: 22}, Unit: "°C"}},
+		6: {{Channel: "temperature", Value: Decimal{Units: 70}, Unit: "°F"}},
+	}
+	tests := []struct {
+		in       string
+		expected Payload
+	}{
+		{"any(#1, #2, #3)", IntPayload(1)},
+		{"all(#1, #2, #3)", IntPayload(0)},
+		{"not(any(#1, #3))", IntPayload(1)},
+		{"avg(#4, #5)", Payload{{Channel: DefaultChannel, Value: Decimal{Units: 2175, Scale: 2}, Unit: "°C"}}},
+		{"max(#4.temperature, #5.temperature)", Payload{{Channel: DefaultChannel, Value: Decimal{Units: 22}, Unit: "°C"}}},
+		{"min(#4, #6)", Payload{{Channel: DefaultChannel, Value: Decimal{Units: 215, Scale: 1}}}},
+		{"sum(#4.humidity, 2.5)", Payload{{Channel: DefaultChannel, Value: Decimal{Units: 425, Scale: 1}}}},
+		{"avg(#1, #2, #2)", Payload{{Channel: DefaultChannel, Value: Decimal{Units: 666666667, Scale: 9}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			e, err := ParseExpression(tt.in)
+			require.NoError(t, err)
+			p, err := e.Eval(states)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, p)
+		})
+	}
+
+	for _, in := range []string{"any(#1, #9)", "avg(#5.humidity)"} {
+		t.Run("no reading "+in, func(t *testing.T) {
+			e, err := ParseExpression(in)
+			require.NoError(t, err)
+			_, err = e.Eval(states)
+			assert.ErrorIs(t, err, ErrNoReading, "Без показаний входа датчик не вычисляется")
+		})
+	}
+}
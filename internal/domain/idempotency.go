@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// IdempotencyRecord - результат запроса с ключом идемпотентности, повтор запроса получает его вместо повторной обработки
+type IdempotencyRecord struct {
+	Key string
+	// Fingerprint - отпечаток запроса, тот же ключ с другим запросом отклоняется
+	Fingerprint string
+	// Completed - запрос обработан, иначе он еще выполняется
+	Completed   bool
+	Status      int
+	ContentType string
+	Body        []byte
+	// ExpiresAt - время, после которого ключ можно использовать заново
+	ExpiresAt time.Time
+}
@@ -0,0 +1,279 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultChannel - канал единственного показания датчиков, присылающих одно число
+	DefaultChannel = "value"
+	// MaxChannels - наибольшее количество каналов в одном событии
+	MaxChannels = 16
+	// MaxScale - наибольшее количество знаков после запятой
+	MaxScale = 9
+	// MaxUnitLength - наибольшая длина единицы измерения
+	MaxUnitLength = 16
+)
+
+var (
+	ErrInvalidPayload = errors.New("invalid payload")
+
+	decimalPattern = regexp.MustCompile(`^-?\d+(\.\d+)?([eE][+-]?\d{1,3})?$`)
+	channelPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+)
+
+// Decimal - десятичное число с фиксированной точкой, равное Units * 10^-Scale: {215, 1} это 21.5
+type Decimal struct {
+	Units int64
+	Scale int32
+}
+
+// ParseDecimal reads a decimal like 21.5, -3 or 2.15e1. The scale is the smallest one the number fits in.
+func ParseDecimal(s string) (Decimal, error) {
+	if !decimalPattern.MatchString(s) {
+		return Decimal{}, fmt.Errorf("%w: %q is not a decimal", ErrInvalidPayload, s)
+	}
+	r, _ := new(big.Rat).SetString(s)
+
+	ten := big.NewRat(10, 1)
+	for scale := int32(0); scale <= MaxScale; scale++ {
+		if r.IsInt() {
+			if !r.Num().IsInt64() {
+				return Decimal{}, fmt.Errorf("%w: %s is too big", ErrInvalidPayload, s)
+			}
+			return Decimal{Units: r.Num().Int64(), Scale: scale}, nil
+		}
+		r.Mul(r, ten)
+	}
+	return Decimal{}, fmt.Errorf("%w: %s has more than %d decimal places", ErrInvalidPayload, s, MaxScale)
+}
+
+// normalize drops the trailing zeros, so the equal decimals are the same
+func (d Decimal) normalize() Decimal {
+	for d.Scale > 0 && d.Units%10 == 0 {
+		d.Units /= 10
+		d.Scale--
+	}
+	return d
+}
+
+func (d Decimal) Equal(o Decimal) bool {
+	return d.normalize() == o.normalize()
+}
+
+// Cmp compares the decimals as -1, 0 or +1, whatever their scales are
+func (d Decimal) Cmp(o Decimal) int {
+	return d.rat().Cmp(o.rat())
+}
+
+func (d Decimal) rat() *big.Rat {
+	denom := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(d.Scale)), nil)
+	return new(big.Rat).SetFrac(big.NewInt(d.Units), denom)
+}
+
+// decimalFromRat rounds the number half away from zero to MaxScale places
+func decimalFromRat(r *big.Rat) (Decimal, error) {
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(MaxScale), nil)))
+	units, rem := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	if twice := new(big.Int).Mul(rem.Abs(rem), big.NewInt(2)); twice.Cmp(scaled.Denom()) >= 0 {
+		units.Add(units, big.NewInt(int64(scaled.Sign())))
+	}
+	if !units.IsInt64() {
+		return Decimal{}, fmt.Errorf("%w: %s is too big", ErrInvalidPayload, r.FloatString(MaxScale))
+	}
+	return Decimal{Units: units.Int64(), Scale: MaxScale}.normalize(), nil
+}
+
+// Int returns the integer part of the decimal
+func (d Decimal) Int() int64 {
+	v := d.Units
+	for i := int32(0); i < d.Scale; i++ {
+		v /= 10
+	}
+	return v
+}
+
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+func (d Decimal) String() string {
+	if d.Scale <= 0 {
+		return strconv.FormatInt(d.Units, 10)
+	}
+
+	digits := strconv.FormatInt(d.Units, 10)
+	sign := ""
+	if d.Units < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if pad := int(d.Scale) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	point := len(digits) - int(d.Scale)
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// Reading - показание одного канала датчика
+type Reading struct {
+	Channel string
+	Value   Decimal
+	Unit    string
+}
+
+// Payload - показания датчика в событии. Первый канал основной, его целая часть отдаётся клиентам,
+// которые знают только целочисленные показания.
+type Payload []Reading
+
+// IntPayload makes the payload of a sensor which sends a single integer
+func IntPayload(v int64) Payload {
+	return Payload{{Channel: DefaultChannel, Value: Decimal{Units: v}}}
+}
+
+// Validate checks the number of the channels, their names, units and scales
+func (p Payload) Validate() error {
+	if len(p) == 0 {
+		return fmt.Errorf("%w: no readings", ErrInvalidPayload)
+	}
+	if len(p) > MaxChannels {
+		return fmt.Errorf("%w: more than %d channels", ErrInvalidPayload, MaxChannels)
+	}
+
+	seen := make(map[string]struct{}, len(p))
+	for _, r := range p {
+		if !channelPattern.MatchString(r.Channel) {
+			return fmt.Errorf("%w: channel %q must match %s", ErrInvalidPayload, r.Channel, channelPattern)
+		}
+		if _, ok := seen[r.Channel]; ok {
+			return fmt.Errorf("%w: channel %q is repeated", ErrInvalidPayload, r.Channel)
+		}
+		seen[r.Channel] = struct{}{}
+
+		if len(r.Unit) > MaxUnitLength {
+			return fmt.Errorf("%w: unit of channel %q is longer than %d", ErrInvalidPayload, r.Channel, MaxUnitLength)
+		}
+		if r.Value.Scale < 0 || r.Value.Scale > MaxScale {
+			return fmt.Errorf("%w: channel %q has more than %d decimal places", ErrInvalidPayload, r.Channel, MaxScale)
+		}
+	}
+	return nil
+}
+
+// Int returns the integer part of the main channel
+func (p Payload) Int() int64 {
+	if len(p) == 0 {
+		return 0
+	}
+	return p[0].Value.Int()
+}
+
+// Channel finds the reading of the channel
+func (p Payload) Channel(name string) (Reading, bool) {
+	for _, r := range p {
+		if r.Channel == name {
+			return r, true
+		}
+	}
+	return Reading{}, false
+}
+
+// Select leaves only the reading of the channel, so it becomes the main one
+func (p Payload) Select(channel string) (Payload, bool) {
+	r, ok := p.Channel(channel)
+	if !ok {
+		return nil, false
+	}
+	return Payload{r}, true
+}
+
+// Clone copies the readings, so the copy can be changed apart from the original
+func (p Payload) Clone() Payload {
+	return slices.Clone(p)
+}
+
+func (p Payload) Equal(o Payload) bool {
+	if len(p) != len(o) {
+		return false
+	}
+	for i := range p {
+		if p[i].Channel != o[i].Channel || p[i].Unit != o[i].Unit || !p[i].Value.Equal(o[i].Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// scalar tells whether the payload is a single number without a unit, as the sensors sent before the channels
+func (p Payload) scalar() bool {
+	return len(p) == 1 && p[0].Channel == DefaultChannel && p[0].Unit == ""
+}
+
+func (p Payload) String() string {
+	js, _ := p.MarshalJSON()
+	return string(js)
+}
+
+type readingJSON struct {
+	Channel string      `json:"channel"`
+	Value   json.Number `json:"value"`
+	Unit    string      `json:"unit,omitempty"`
+}
+
+// MarshalJSON writes a scalar payload as a number, as it was before the channels, and the others as a list of readings
+func (p Payload) MarshalJSON() ([]byte, error) {
+	if len(p) == 0 {
+		return []byte("null"), nil
+	}
+	if p.scalar() {
+		return []byte(p[0].Value.String()), nil
+	}
+
+	readings := make([]readingJSON, len(p))
+	for i, r := range p {
+		readings[i] = readingJSON{Channel: r.Channel, Value: json.Number(r.Value.String()), Unit: r.Unit}
+	}
+	return json.Marshal(readings)
+}
+
+// UnmarshalJSON reads a number or a list of readings
+func (p *Payload) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if !bytes.HasPrefix(data, []byte("[")) {
+		v, err := ParseDecimal(string(data))
+		if err != nil {
+			return err
+		}
+		*p = Payload{{Channel: DefaultChannel, Value: v}}
+		return nil
+	}
+
+	var readings []readingJSON
+	if err := json.Unmarshal(data, &readings); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+	}
+	payload := make(Payload, len(readings))
+	for i, r := range readings {
+		v, err := ParseDecimal(r.Value.String())
+		if err != nil {
+			return err
+		}
+		payload[i] = Reading{Channel: r.Channel, Value: v, Unit: r.Unit}
+	}
+	if err := payload.Validate(); err != nil {
+		return err
+	}
+	*p = payload
+	return nil
+}
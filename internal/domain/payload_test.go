@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected Decimal
+	}{
+		{"21.5", Decimal{Units: 215, Scale: 1}},
+		{"-3", Decimal{Units: -3}},
+		{"21.50", Decimal{Units: 215, Scale: 1}},
+		{"2.15e1", Decimal{Units: 215, Scale: 1}},
+		{"0.000000001", Decimal{Units: 1, Scale: 9}},
+		{"15e2", Decimal{Units: 1500}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			d, err := ParseDecimal(tt.in)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, d)
+		})
+	}
+
+	for _, in := range []string{"", "1.", "0x10", "1/3", "NaN", "0.0000000001", "99999999999999999999"} {
+		t.Run("err "+in, func(t *testing.T) {
+			_, err := ParseDecimal(in)
+			assert.ErrorIs(t, err, ErrInvalidPayload)
+		})
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	assert.Equal(t, "21.5", Decimal{Units: 215, Scale: 1}.String())
+	assert.Equal(t, "-0.05", Decimal{Units: -5, Scale: 2}.String())
+	assert.Equal(t, int64(-21), Decimal{Units: -215, Scale: 1}.Int(), "Дробная часть отбрасывается")
+	assert.Equal(t, 21.5, Decimal{Units: 215, Scale: 1}.Float64())
+	assert.True(t, Decimal{Units: 2150, Scale: 2}.Equal(Decimal{Units: 215, Scale: 1}))
+	assert.False(t, Decimal{Units: 215, Scale: 2}.Equal(Decimal{Units: 215, Scale: 1}))
+}
+
+func TestPayload_JSON(t *testing.T) {
+	t.Run("ok, scalar is a number", func(t *testing.T) {
+		js, err := json.Marshal(IntPayload(10))
+		require.NoError(t, err)
+		assert.Equal(t, "10", string(js))
+
+		var p Payload
+		require.NoError(t, json.Unmarshal([]byte("21.5"), &p))
+		assert.Equal(t, Payload{{Channel: DefaultChannel, Value: Decimal{Units: 215, Scale: 1}}}, p)
+	})
+
+	t.Run("ok, channels are a list", func(t *testing.T) {
+		p := Payload{
+			{Channel: "temperature", Value: Decimal{Units: 215, Scale: 1}, Unit: "°C"},
+			{Channel: "battery", Value: Decimal{Units: 87}},
+		}
+		js, err := json.Marshal(p)
+		require.NoError(t, err)
+		assert.JSONEq(t, `[{"channel":"temperature","value":21.5,"unit":"°C"},{"channel":"battery","value":87}]`, string(js))
+
+		var decoded Payload
+		require.NoError(t, json.Unmarshal(js, &decoded))
+		assert.Equal(t, p, decoded)
+	})
+
+	t.Run("err, invalid readings", func(t *testing.T) {
+		inputs := []string{
+			`[]`,
+			`[{"channel":"Temperature","value":1}]`,
+			`[{"channel":"t","value":1},{"channel":"t","value":2}]`,
+			`[{"channel":"t","value":1,"unit":"much too long unit"}]`,
+			`"many"`,
+		}
+		for _, in := range inputs {
+			var p Payload
+			assert.ErrorIs(t, json.Unmarshal([]byte(in), &p), ErrInvalidPayload, in)
+		}
+	})
+}
+
+func TestPayload_Select(t *testing.T) {
+	p := Payload{
+		{Channel: "temperature", Value: Decimal{Units: 215, Scale: 1}},
+		{Channel: "humidity", Value: Decimal{Units: 40}},
+	}
+	assert.Equal(t, int64(21), p.Int())
+
+	humidity, ok := p.Select("humidity")
+	assert.True(t, ok)
+	assert.Equal(t, int64(40), humidity.Int(), "Выбранный канал становится основным")
+
+	_, ok = p.Select("battery")
+	assert.False(t, ok)
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// RateLimit - ограничение частоты запросов: в среднем Rate в секунду, но не больше Burst подряд.
+// Нулевой Rate означает отсутствие ограничения.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// Unlimited - ограничение не задано
+func (l RateLimit) Unlimited() bool {
+	return l.Rate <= 0
+}
+
+// Interval - время, за которое восстанавливается один запрос
+func (l RateLimit) Interval() time.Duration {
+	return time.Duration(float64(time.Second) / l.Rate)
+}
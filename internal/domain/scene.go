@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// MaxSceneCommands - наибольшее количество команд в сцене
+const MaxSceneCommands = 32
+
+var (
+	ErrInvalidScene = errors.New("invalid scene")
+
+	sceneNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+)
+
+// Scene - именованная группа команд, например good_night выключает свет и закрывает замок.
+// Сцена запускается по расписанию или через API, команды ставятся в очереди устройств разом.
+type Scene struct {
+	ID          int64
+	Name        string
+	Description string
+	Commands    []SceneCommand
+}
+
+// SceneCommand - команда сцены одному исполнительному устройству
+type SceneCommand struct {
+	ActuatorID int64
+	Action     string
+	Args       Payload
+}
+
+// Validate checks the name and the commands, the actuators are not looked up
+func (s Scene) Validate() error {
+	if !sceneNamePattern.MatchString(s.Name) {
+		return fmt.Errorf("%w: name %q must match %s", ErrInvalidScene, s.Name, sceneNamePattern)
+	}
+	if len(s.Commands) == 0 || len(s.Commands) > MaxSceneCommands {
+		return fmt.Errorf("%w: must have from 1 to %d commands", ErrInvalidScene, MaxSceneCommands)
+	}
+	for i, c := range s.Commands {
+		if c.ActuatorID < 1 {
+			return fmt.Errorf("%w: command %d has no actuator", ErrInvalidScene, i)
+		}
+		if !commandActionPattern.MatchString(c.Action) {
+			return fmt.Errorf("%w: command %d action %q must match %s", ErrInvalidScene, i, c.Action, commandActionPattern)
+		}
+		if len(c.Args) > 0 {
+			if err := c.Args.Validate(); err != nil {
+				return fmt.Errorf("%w: command %d: %w", ErrInvalidScene, i, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+type ScheduleKind string
+
+// Виды расписаний: по cron, относительно восхода или заката и однократный таймер
+const (
+	ScheduleKindCron    ScheduleKind = "cron"
+	ScheduleKindSunrise ScheduleKind = "sunrise"
+	ScheduleKindSunset  ScheduleKind = "sunset"
+	ScheduleKindOnce    ScheduleKind = "once"
+)
+
+type MissedPolicy string
+
+// Что делать с запусками, пропущенными, пока сервер не работал: пропустить их или выполнить один раз
+const (
+	MissedPolicySkip MissedPolicy = "skip"
+	MissedPolicyRun  MissedPolicy = "run"
+)
+
+// MaxSunOffset - наибольшее смещение от восхода или заката
+const MaxSunOffset = 12 * time.Hour
+
+// sunSearchDays bounds the search of the next sunrise, the polar night is shorter
+const sunSearchDays = 366
+
+var (
+	ErrInvalidSchedule = errors.New("invalid schedule")
+
+	scheduleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+)
+
+// Schedule - расписание запуска сцены
+type Schedule struct {
+	ID      int64
+	Name    string
+	SceneID int64
+	Kind    ScheduleKind
+	// Cron - выражение для ScheduleKindCron
+	Cron string
+	// Offset - смещение от восхода или заката, отрицательное - раньше
+	Offset time.Duration
+	// At - время однократного запуска
+	At     time.Time
+	Missed MissedPolicy
+	// Enabled - расписание еще будет запускаться, однократное выключается после запуска
+	Enabled bool
+	NextRun time.Time
+	LastRun time.Time
+	// LastError - причина неудачи последнего запуска
+	LastError string
+	CreatedAt time.Time
+}
+
+// Validate checks the name and the trigger of its kind
+func (s Schedule) Validate() error {
+	if !scheduleNamePattern.MatchString(s.Name) {
+		return fmt.Errorf("%w: name %q must match %s", ErrInvalidSchedule, s.Name, scheduleNamePattern)
+	}
+	if s.SceneID < 1 {
+		return fmt.Errorf("%w: scene is required", ErrInvalidSchedule)
+	}
+	switch s.Kind {
+	case ScheduleKindCron:
+		if _, err := ParseCron(s.Cron); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidSchedule, err)
+		}
+	case ScheduleKindSunrise, ScheduleKindSunset:
+		if s.Offset < -MaxSunOffset || s.Offset > MaxSunOffset {
+			return fmt.Errorf("%w: offset must be within %v", ErrInvalidSchedule, MaxSunOffset)
+		}
+	case ScheduleKindOnce:
+		if s.At.IsZero() {
+			return fmt.Errorf("%w: once needs the time", ErrInvalidSchedule)
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidSchedule, s.Kind)
+	}
+	if s.Missed != MissedPolicySkip && s.Missed != MissedPolicyRun {
+		return fmt.Errorf("%w: missed must be %s or %s", ErrInvalidSchedule, MissedPolicySkip, MissedPolicyRun)
+	}
+	return nil
+}
+
+// Next returns the first run after t. The cron and the days of the sun are taken in the location of t.
+// The zero time means the schedule will not run anymore.
+func (s Schedule) Next(t time.Time, at Coordinates) time.Time {
+	switch s.Kind {
+	case ScheduleKindCron:
+		c, err := ParseCron(s.Cron)
+		if err != nil {
+			return time.Time{}
+		}
+		return c.Next(t)
+	case ScheduleKindOnce:
+		if s.At.After(t) {
+			return s.At
+		}
+	case ScheduleKindSunrise, ScheduleKindSunset:
+		// the offset may move the event of yesterday past t
+		day := time.Date(t.Year(), t.Month(), t.Day()-1, 12, 0, 0, 0, t.Location())
+		for i := 0; i <= sunSearchDays; i++ {
+			sunrise, sunset, ok := SunTimes(day.AddDate(0, 0, i), at)
+			if !ok {
+				continue
+			}
+			event := sunrise
+			if s.Kind == ScheduleKindSunset {
+				event = sunset
+			}
+			if run := event.Add(s.Offset); run.After(t) {
+				return run
+			}
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var moscowHome = Coordinates{Latitude: 55.7558, Longitude: 37.6173}
+
+func TestSunTimes(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	sunrise, sunset, ok := SunTimes(time.Date(2024, 6, 21, 0, 0, 0, 0, moscow), moscowHome)
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Date(2024, 6, 21, 3, 44, 0, 0, moscow), sunrise, 3*time.Minute)
+	assert.WithinDuration(t, time.Date(2024, 6, 21, 21, 18, 0, 0, moscow), sunset, 3*time.Minute)
+
+	sunrise, sunset, ok = SunTimes(time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC), Coordinates{})
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Date(2024, 3, 20, 6, 4, 0, 0, time.UTC), sunrise, 3*time.Minute)
+	assert.WithinDuration(t, time.Date(2024, 3, 20, 18, 11, 0, 0, time.UTC), sunset, 3*time.Minute)
+
+	_, _, ok = SunTimes(time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC), Coordinates{Latitude: 68.97, Longitude: 33.07})
+	assert.False(t, ok, "В полярную ночь солнце не восходит")
+}
+
+func TestSchedule_Validate(t *testing.T) {
+	valid := Schedule{Name: "morning", SceneID: 1, Kind: ScheduleKindCron, Cron: "0 7 * * *", Missed: MissedPolicySkip}
+	assert.NoError(t, valid.Validate())
+
+	tests := []struct {
+		name   string
+		modify func(s *Schedule)
+	}{
+		{"bad name", func(s *Schedule) { s.Name = "Morning" }},
+		{"no scene", func(s *Schedule) { s.SceneID = 0 }},
+		{"bad cron", func(s *Schedule) { s.Cron = "0 25 * * *" }},
+		{"unknown kind", func(s *Schedule) { s.Kind = "weekly" }},
+		{"far offset", func(s *Schedule) { s.Kind, s.Offset = ScheduleKindSunset, 13*time.Hour }},
+		{"once without time", func(s *Schedule) { s.Kind = ScheduleKindOnce }},
+		{"unknown policy", func(s *Schedule) { s.Missed = "all" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := valid
+			tt.modify(&s)
+			assert.ErrorIs(t, s.Validate(), ErrInvalidSchedule)
+		})
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	now := time.Date(2024, 6, 21, 12, 0, 0, 0, moscow)
+
+	t.Run("once", func(t *testing.T) {
+		at := now.Add(time.Hour)
+		s := Schedule{Kind: ScheduleKindOnce, At: at}
+		assert.Equal(t, at, s.Next(now, moscowHome))
+		assert.True(t, s.Next(at, moscowHome).IsZero(), "Однократный таймер не повторяется")
+	})
+
+	t.Run("sunset with offset", func(t *testing.T) {
+		s := Schedule{Kind: ScheduleKindSunset, Offset: -30 * time.Minute}
+		_, sunset, _ := SunTimes(now, moscowHome)
+		assert.Equal(t, sunset.Add(-30*time.Minute), s.Next(now, moscowHome))
+	})
+
+	t.Run("sunrise is tomorrow", func(t *testing.T) {
+		s := Schedule{Kind: ScheduleKindSunrise}
+		sunrise, _, _ := SunTimes(now.AddDate(0, 0, 1), moscowHome)
+		assert.Equal(t, sunrise, s.Next(now, moscowHome))
+	})
+
+	t.Run("sunrise after the polar night", func(t *testing.T) {
+		s := Schedule{Kind: ScheduleKindSunrise}
+		next := s.Next(time.Date(2024, 12, 21, 12, 0, 0, 0, time.UTC), Coordinates{Latitude: 68.97, Longitude: 33.07})
+		assert.Equal(t, 2025, next.Year(), "Первый восход после полярной ночи в январе")
+		assert.Equal(t, time.January, next.Month())
+	})
+}
+
+func TestScene_Validate(t *testing.T) {
+	valid := Scene{Name: "good_night", Commands: []SceneCommand{{ActuatorID: 1, Action: "switch", Args: IntPayload(0)}}}
+	assert.NoError(t, valid.Validate())
+
+	for name, s := range map[string]Scene{
+		"bad name":    {Name: "Good night", Commands: valid.Commands},
+		"no commands": {Name: "good_night"},
+		"no actuator": {Name: "good_night", Commands: []SceneCommand{{Action: "switch"}}},
+		"bad action":  {Name: "good_night", Commands: []SceneCommand{{ActuatorID: 1, Action: "Switch"}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, s.Validate(), ErrInvalidScene)
+		})
+	}
+}
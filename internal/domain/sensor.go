@@ -1,24 +1,52 @@
 package domain
 
-import "time"
+import (
+	"slices"
+	"time"
+)
 
 type SensorType string
 
+// Встроенные типы датчиков, остальные задаются в реестре типов
 const (
 	SensorTypeContactClosure SensorType = "cc"
 	SensorTypeADC            SensorType = "adc"
+	// SensorTypeVirtual - датчик, показания которого вычисляются по формуле из показаний других датчиков
+	SensorTypeVirtual SensorType = "virtual"
 )
 
-var AcceptableSensorTypes = map[SensorType]struct{}{SensorTypeADC: {}, SensorTypeContactClosure: {}}
-
 // Sensor - структура для хранения данных датчика
 type Sensor struct {
 	ID           int64
 	SerialNumber string
 	Type         SensorType
-	CurrentState int64
+	CurrentState Payload
 	Description  string
 	IsActive     bool
 	RegisteredAt time.Time
 	LastActivity time.Time
+	// Calibration - калибровки каналов, переводящие сырые показания в единицы измерения
+	Calibration []Calibration
+	// Expression - формула виртуального датчика, у остальных пустая
+	Expression string
+}
+
+// Clone copies the sensor with its readings and calibrations, so the copy shares nothing with the original
+func (s Sensor) Clone() Sensor {
+	s.CurrentState = s.CurrentState.Clone()
+	if s.Calibration != nil {
+		calibration := make([]Calibration, len(s.Calibration))
+		for i, c := range s.Calibration {
+			c.Table = slices.Clone(c.Table)
+			calibration[i] = c
+		}
+		s.Calibration = calibration
+	}
+	return s
+}
+
+// SensorPatch - изменение датчика, nil - поле остается прежним
+type SensorPatch struct {
+	Description *string
+	IsActive    *bool
 }
@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+const (
+	// MaxBits - наибольшая разрядность канала, при которой значения помещаются в int64
+	MaxBits = 62
+	// DefaultADCBits - разрядность встроенного типа adc, для других преобразователей она меняется в реестре
+	DefaultADCBits = 12
+)
+
+var (
+	ErrInvalidSensorType = errors.New("invalid sensor type")
+
+	sensorTypeNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+)
+
+// SensorTypeSpec - описание типа датчиков
+type SensorTypeSpec struct {
+	Name        SensorType
+	Description string
+	// Channels - каналы показаний датчиков типа. Если список пуст, принимаются любые каналы
+	Channels []ChannelSpec
+	// SerialPattern - регулярное выражение серийного номера, сужающее общий формат из 10 цифр. Пустое - любой номер
+	SerialPattern string
+	// Heartbeat - сколько датчик может молчать, прежде чем считается пропавшим, 0 - значение по умолчанию
+	Heartbeat time.Duration
+
+	// serial is SerialPattern compiled by CompileSerial
+	serial *regexp.Regexp
+}
+
+// ChannelSpec - описание канала показаний
+type ChannelSpec struct {
+	Name string
+	Unit string
+	// Min и Max - границы допустимых значений, nil - без ограничения
+	Min, Max *Decimal
+	// Bits - разрядность АЦП: значения целые от 0 до 2^Bits-1, 0 - без ограничения
+	Bits int
+}
+
+// BuiltinSensorTypes are the types known before the registry, they are registered from the start
+var BuiltinSensorTypes = []SensorTypeSpec{
+	{
+		Name:        SensorTypeContactClosure,
+		Description: "Контактный датчик",
+		Channels:    []ChannelSpec{{Name: DefaultChannel, Bits: 1}},
+	},
+	{
+		Name:        SensorTypeADC,
+		Description: "Аналого-цифровой преобразователь",
+		Channels:    []ChannelSpec{{Name: DefaultChannel, Bits: DefaultADCBits}},
+	},
+	{
+		Name:        SensorTypeVirtual,
+		Description: "Виртуальный датчик, вычисляемый по другим датчикам",
+	},
+}
+
+// Validate checks the name of the type, its channels and the serial number pattern
+func (t SensorTypeSpec) Validate() error {
+	if !sensorTypeNamePattern.MatchString(string(t.Name)) {
+		return fmt.Errorf("%w: name %q must match %s", ErrInvalidSensorType, t.Name, sensorTypeNamePattern)
+	}
+	if len(t.Channels) > MaxChannels {
+		return fmt.Errorf("%w: more than %d channels", ErrInvalidSensorType, MaxChannels)
+	}
+
+	seen := make(map[string]struct{}, len(t.Channels))
+	for _, c := range t.Channels {
+		if !channelPattern.MatchString(c.Name) {
+			return fmt.Errorf("%w: channel %q must match %s", ErrInvalidSensorType, c.Name, channelPattern)
+		}
+		if _, ok := seen[c.Name]; ok {
+			return fmt.Errorf("%w: channel %q is repeated", ErrInvalidSensorType, c.Name)
+		}
+		seen[c.Name] = struct{}{}
+
+		if len(c.Unit) > MaxUnitLength {
+			return fmt.Errorf("%w: unit of channel %q is longer than %d", ErrInvalidSensorType, c.Name, MaxUnitLength)
+		}
+		if c.Min != nil && c.Max != nil && c.Min.Cmp(*c.Max) > 0 {
+			return fmt.Errorf("%w: channel %q has min above max", ErrInvalidSensorType, c.Name)
+		}
+		if c.Bits < 0 || c.Bits > MaxBits {
+			return fmt.Errorf("%w: channel %q must have from 0 to %d bits", ErrInvalidSensorType, c.Name, MaxBits)
+		}
+	}
+
+	if _, err := regexp.Compile(t.SerialPattern); err != nil {
+		return fmt.Errorf("%w: serial pattern: %w", ErrInvalidSensorType, err)
+	}
+	if t.Heartbeat < 0 {
+		return fmt.Errorf("%w: heartbeat must not be negative", ErrInvalidSensorType)
+	}
+	return nil
+}
+
+// CompileSerial compiles the serial number pattern once for MatchSerial,
+// the repositories do it when the type is saved or loaded
+func (t *SensorTypeSpec) CompileSerial() error {
+	if t.SerialPattern == "" {
+		t.serial = nil
+		return nil
+	}
+	serial, err := regexp.Compile(t.SerialPattern)
+	if err != nil {
+		return fmt.Errorf("%w: serial pattern: %w", ErrInvalidSensorType, err)
+	}
+	t.serial = serial
+	return nil
+}
+
+// MatchSerial checks the serial number against the pattern of the type.
+// The pattern of a type that isn't compiled is compiled on every call.
+func (t SensorTypeSpec) MatchSerial(sn string) bool {
+	if t.SerialPattern == "" {
+		return true
+	}
+	if t.serial == nil {
+		if err := t.CompileSerial(); err != nil {
+			return false
+		}
+	}
+	return t.serial.MatchString(sn)
+}
+
+// CheckPayload checks the readings against the channels of the type: the channel must be known,
+// the value must be in its range and the unit must be its own. The readings without a unit get the unit of the channel.
+func (t SensorTypeSpec) CheckPayload(p Payload) (Payload, error) {
+	if len(t.Channels) == 0 {
+		return p, nil
+	}
+
+	checked := make(Payload, len(p))
+	for i, r := range p {
+		c, ok := t.channel(r.Channel)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s has no channel %q", ErrInvalidPayload, t.Name, r.Channel)
+		}
+		if r.Unit == "" {
+			r.Unit = c.Unit
+		}
+		if r.Unit != c.Unit {
+			return nil, fmt.Errorf("%w: channel %q is measured in %q, not %q", ErrInvalidPayload, r.Channel, c.Unit, r.Unit)
+		}
+		if c.Min != nil && r.Value.Cmp(*c.Min) < 0 || c.Max != nil && r.Value.Cmp(*c.Max) > 0 {
+			return nil, fmt.Errorf("%w: %s of channel %q is out of range", ErrInvalidPayload, r.Value, r.Channel)
+		}
+		if !c.fitsBits(r.Value) {
+			return nil, fmt.Errorf("%w: %s of channel %q is not a %d-bit value", ErrInvalidPayload, r.Value, r.Channel, c.Bits)
+		}
+		checked[i] = r
+	}
+	return checked, nil
+}
+
+func (c ChannelSpec) fitsBits(v Decimal) bool {
+	if c.Bits == 0 {
+		return true
+	}
+	v = v.normalize()
+	return v.Scale == 0 && v.Units >= 0 && v.Units < int64(1)<<c.Bits
+}
+
+func (t SensorTypeSpec) channel(name string) (ChannelSpec, bool) {
+	for _, c := range t.Channels {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return ChannelSpec{}, false
+}
@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSensorTypeSpec_Validate(t *testing.T) {
+	low, high := Decimal{Units: 10}, Decimal{Units: 5}
+	for _, b := range BuiltinSensorTypes {
+		assert.NoError(t, b.Validate(), "Встроенный тип %s должен быть валиден", b.Name)
+	}
+
+	tests := []struct {
+		name string
+		spec SensorTypeSpec
+	}{
+		{"bad name", SensorTypeSpec{Name: "Door"}},
+		{"bad channel", SensorTypeSpec{Name: "door", Channels: []ChannelSpec{{Name: "1st"}}}},
+		{"repeated channel", SensorTypeSpec{Name: "door", Channels: []ChannelSpec{{Name: "open"}, {Name: "open"}}}},
+		{"long unit", SensorTypeSpec{Name: "door", Channels: []ChannelSpec{{Name: "open", Unit: "very long unit name"}}}},
+		{"min above max", SensorTypeSpec{Name: "door", Channels: []ChannelSpec{{Name: "open", Min: &low, Max: &high}}}},
+		{"bad serial pattern", SensorTypeSpec{Name: "door", SerialPattern: "("}},
+		{"negative heartbeat", SensorTypeSpec{Name: "door", Heartbeat: -time.Second}},
+		{"too many bits", SensorTypeSpec{Name: "door", Channels: []ChannelSpec{{Name: "open", Bits: MaxBits + 1}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.spec.Validate(), ErrInvalidSensorType)
+		})
+	}
+}
+
+func TestSensorTypeSpec_MatchSerial(t *testing.T) {
+	assert.True(t, SensorTypeSpec{}.MatchSerial("1234567890"), "Без шаблона подходит любой номер")
+
+	spec := SensorTypeSpec{SerialPattern: `^7\d+$`}
+	assert.True(t, spec.MatchSerial("7000000001"))
+	assert.False(t, spec.MatchSerial("1000000001"))
+
+	require.NoError(t, spec.CompileSerial())
+	assert.NotNil(t, spec.serial, "Шаблон должен компилироваться один раз")
+	assert.True(t, spec.MatchSerial("7000000001"))
+	assert.False(t, spec.MatchSerial("1000000001"))
+
+	assert.False(t, SensorTypeSpec{SerialPattern: "("}.MatchSerial("1234567890"), "С неверным шаблоном не подходит ни один номер")
+}
+
+func TestSensorTypeSpec_CompileSerial(t *testing.T) {
+	spec := SensorTypeSpec{SerialPattern: "("}
+	assert.ErrorIs(t, spec.CompileSerial(), ErrInvalidSensorType)
+
+	spec.SerialPattern = ""
+	require.NoError(t, spec.CompileSerial())
+	assert.Nil(t, spec.serial)
+}
+
+func TestSensorTypeSpec_CheckPayload(t *testing.T) {
+	low, high := Decimal{Units: -400, Scale: 1}, Decimal{Units: 125}
+	spec := SensorTypeSpec{Name: "meter", Channels: []ChannelSpec{
+		{Name: "temperature", Unit: "°C", Min: &low, Max: &high},
+		{Name: "humidity", Unit: "%"},
+	}}
+
+	t.Run("ok, unit is filled", func(t *testing.T) {
+		p, err := spec.CheckPayload(Payload{
+			{Channel: "temperature", Value: Decimal{Units: 215, Scale: 1}},
+			{Channel: "humidity", Value: Decimal{Units: 40}, Unit: "%"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, Payload{
+			{Channel: "temperature", Value: Decimal{Units: 215, Scale: 1}, Unit: "°C"},
+			{Channel: "humidity", Value: Decimal{Units: 40}, Unit: "%"},
+		}, p)
+	})
+
+	t.Run("ok, bounds are included", func(t *testing.T) {
+		_, err := spec.CheckPayload(Payload{{Channel: "temperature", Value: Decimal{Units: -40}}})
+		assert.NoError(t, err)
+		_, err = spec.CheckPayload(Payload{{Channel: "temperature", Value: Decimal{Units: 1250, Scale: 1}}})
+		assert.NoError(t, err)
+	})
+
+	t.Run("ok, any channels without the channels", func(t *testing.T) {
+		p := IntPayload(100500)
+		checked, err := SensorTypeSpec{Name: "adc"}.CheckPayload(p)
+		assert.NoError(t, err)
+		assert.Equal(t, p, checked)
+	})
+
+	tests := []struct {
+		name    string
+		payload Payload
+	}{
+		{"unknown channel", IntPayload(1)},
+		{"other unit", Payload{{Channel: "temperature", Value: Decimal{Units: 20}, Unit: "K"}}},
+		{"below min", Payload{{Channel: "temperature", Value: Decimal{Units: -401, Scale: 1}}}},
+		{"above max", Payload{{Channel: "temperature", Value: Decimal{Units: 126}}}},
+	}
+	for _, tt := range tests {
+		t.Run("err, "+tt.name, func(t *testing.T) {
+			_, err := spec.CheckPayload(tt.payload)
+			assert.ErrorIs(t, err, ErrInvalidPayload)
+		})
+	}
+}
+
+func TestSensorTypeSpec_CheckPayload_Bits(t *testing.T) {
+	spec := SensorTypeSpec{Name: "adc", Channels: []ChannelSpec{{Name: DefaultChannel, Bits: 12}}}
+
+	for _, v := range []Decimal{{Units: 0}, {Units: 4095}, {Units: 40950, Scale: 1}} {
+		_, err := spec.CheckPayload(Payload{{Channel: DefaultChannel, Value: v}})
+		assert.NoError(t, err, "%s помещается в 12 бит", v)
+	}
+	for _, v := range []Decimal{{Units: -1}, {Units: 4096}, {Units: 15, Scale: 1}} {
+		_, err := spec.CheckPayload(Payload{{Channel: DefaultChannel, Value: v}})
+		assert.ErrorIs(t, err, ErrInvalidPayload, "%s не помещается в 12 бит", v)
+	}
+}
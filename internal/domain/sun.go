@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+var ErrInvalidCoordinates = errors.New("invalid coordinates")
+
+// Coordinates - координаты дома в градусах, по ним считаются восход и закат.
+// Долгота к востоку от Гринвича положительная.
+type Coordinates struct {
+	Latitude  float64
+	Longitude float64
+}
+
+func (c Coordinates) Validate() error {
+	if math.IsNaN(c.Latitude) || c.Latitude < -90 || c.Latitude > 90 {
+		return fmt.Errorf("%w: latitude %v must be from -90 to 90", ErrInvalidCoordinates, c.Latitude)
+	}
+	if math.IsNaN(c.Longitude) || c.Longitude < -180 || c.Longitude > 180 {
+		return fmt.Errorf("%w: longitude %v must be from -180 to 180", ErrInvalidCoordinates, c.Longitude)
+	}
+	return nil
+}
+
+const (
+	julianUnixEpoch = 2440587.5
+	julian2000      = 2451545.0
+	secondsPerDay   = 86400
+	// the sun is 0.833 degrees below the horizon at the sunrise, the refraction and its radius lift it
+	sunriseAltitude = -0.833
+	earthTilt       = 23.4397
+)
+
+func sinDeg(d float64) float64 { return math.Sin(d * math.Pi / 180) }
+func cosDeg(d float64) float64 { return math.Cos(d * math.Pi / 180) }
+
+// SunTimes computes the sunrise and the sunset of the day of date (its year, month and day in its location)
+// by the sunrise equation, they are precise to a minute or two. ok is false in the polar day and night.
+func SunTimes(date time.Time, at Coordinates) (sunrise, sunset time.Time, ok bool) {
+	noon := time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, time.UTC)
+	n := math.Round(float64(noon.Unix())/secondsPerDay + julianUnixEpoch - julian2000)
+
+	// the mean solar noon at the longitude
+	j := n - at.Longitude/360
+	anomaly := math.Mod(357.5291+0.98560028*j, 360)
+	center := 1.9148*sinDeg(anomaly) + 0.02*sinDeg(2*anomaly) + 0.0003*sinDeg(3*anomaly)
+	longitude := math.Mod(anomaly+center+180+102.9372, 360)
+	transit := julian2000 + j + 0.0053*sinDeg(anomaly) - 0.0069*sinDeg(2*longitude)
+
+	sinDeclination := sinDeg(longitude) * sinDeg(earthTilt)
+	cosDeclination := math.Cos(math.Asin(sinDeclination))
+	cosHourAngle := (sinDeg(sunriseAltitude) - sinDeg(at.Latitude)*sinDeclination) / (cosDeg(at.Latitude) * cosDeclination)
+	if cosHourAngle < -1 || cosHourAngle > 1 {
+		return time.Time{}, time.Time{}, false
+	}
+	hourAngle := math.Acos(cosHourAngle) * 180 / math.Pi
+
+	toTime := func(julian float64) time.Time {
+		seconds := (julian - julianUnixEpoch) * secondsPerDay
+		return time.Unix(0, int64(seconds*float64(time.Second))).Truncate(time.Second).In(date.Location())
+	}
+	return toTime(transit - hourAngle/360), toTime(transit + hourAngle/360), true
+}
@@ -0,0 +1,307 @@
+package http
+
+import (
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-openapi/strfmt"
+)
+
+const (
+	// defaultCommandsWait is how long the poll of the pending commands waits unless the device asks otherwise
+	defaultCommandsWait = 30 * time.Second
+	maxCommandsWait     = time.Minute
+)
+
+func getActuatorDto(a *domain.Actuator) models.Actuator {
+	return models.Actuator{
+		ID:           &a.ID,
+		SerialNumber: &a.SerialNumber,
+		Type:         &a.Type,
+		Description:  &a.Description,
+		RegisteredAt: (*strfmt.DateTime)(&a.RegisteredAt),
+	}
+}
+
+func getCommandDto(c *domain.Command) models.Command {
+	status := string(c.Status)
+	ackTimeout := int64(c.AckTimeout / time.Second)
+	attempts, maxAttempts := int64(c.Attempts), int64(c.MaxAttempts)
+	dto := models.Command{
+		ID:          &c.ID,
+		ActuatorID:  &c.ActuatorID,
+		Action:      &c.Action,
+		Status:      &status,
+		Attempts:    &attempts,
+		MaxAttempts: &maxAttempts,
+		AckTimeout:  &ackTimeout,
+		CreatedAt:   (*strfmt.DateTime)(&c.CreatedAt),
+		ExpiresAt:   (*strfmt.DateTime)(&c.ExpiresAt),
+		Error:       c.Error,
+	}
+	if len(c.Args) > 0 {
+		dto.Args = readingsDto(c.Args)
+	}
+	if !c.SentAt.IsZero() {
+		dto.SentAt = (*strfmt.DateTime)(&c.SentAt)
+	}
+	if !c.CompletedAt.IsZero() {
+		dto.CompletedAt = (*strfmt.DateTime)(&c.CompletedAt)
+	}
+	return dto
+}
+
+func getCommandsDto(commands []*domain.Command) []models.Command {
+	dtos := make([]models.Command, len(commands))
+	for i, c := range commands {
+		dtos[i] = getCommandDto(c)
+	}
+	return dtos
+}
+
+func setupPostActuatorHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkContentType(ctx) {
+			return
+		}
+		a := models.ActuatorToCreate{}
+		if !bindAndValidate(ctx, &a) {
+			return
+		}
+		newItem := domain.Actuator{SerialNumber: *a.SerialNumber, Type: *a.Type, Description: *a.Description}
+		item, err := uc.Actuator.RegisterActuator(ctx, &newItem)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, getActuatorDto(item))
+	}
+}
+
+func setupGetActuatorsHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkAccept(ctx) {
+			return
+		}
+		items, err := uc.Actuator.GetActuators(ctx)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		dtos := make([]models.Actuator, len(items))
+		for i := range items {
+			dtos[i] = getActuatorDto(&items[i])
+		}
+		ctx.JSON(http.StatusOK, dtos)
+	}
+}
+
+func setupGetActuatorHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkAccept(ctx) {
+			return
+		}
+		id, err := strconv.ParseInt(ctx.Param("actuator_id"), 10, 64)
+		if err != nil {
+			abortInvalidID(ctx, "actuator_id")
+			return
+		}
+		a, err := uc.Actuator.GetActuatorByID(ctx, id)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, getActuatorDto(a))
+	}
+}
+
+func setupPostCommandHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkContentType(ctx) {
+			return
+		}
+		id, err := strconv.ParseInt(ctx.Param("actuator_id"), 10, 64)
+		if err != nil {
+			abortInvalidID(ctx, "actuator_id")
+			return
+		}
+		c := models.CommandToSend{}
+		if !bindAndValidate(ctx, &c) {
+			return
+		}
+		args, err := readingsFromDto(c.Args)
+		if err != nil {
+			field, message := "args", err.Error()
+			abortWithProblem(ctx, http.StatusUnprocessableEntity, codeValidationFailed, "request body is invalid",
+				&models.ValidationError{Field: &field, Message: &message})
+			return
+		}
+
+		command := domain.Command{
+			Action:      *c.Action,
+			Args:        args,
+			MaxAttempts: int(c.MaxAttempts),
+			AckTimeout:  time.Duration(c.AckTimeout) * time.Second,
+		}
+		queued, err := uc.Commands.SendCommand(ctx, id, &command, time.Duration(c.TTL)*time.Second)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+
+		ctx.Header("Location", fmt.Sprintf("/actuators/%d/commands/%d", id, queued.ID))
+		ctx.JSON(http.StatusAccepted, getCommandDto(queued))
+	}
+}
+
+func setupGetCommandHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkAccept(ctx) {
+			return
+		}
+		actuatorID, id, ok := parseCommandPath(ctx)
+		if !ok {
+			return
+		}
+		c, err := uc.Commands.GetCommand(ctx, actuatorID, id)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, getCommandDto(c))
+	}
+}
+
+// setupGetPendingCommandsHandler is the long poll of the devices: the queued commands are sent at once,
+// otherwise the request waits for them up to wait seconds and ends with 204 if none has come
+func setupGetPendingCommandsHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkAccept(ctx) {
+			return
+		}
+		id, err := strconv.ParseInt(ctx.Param("actuator_id"), 10, 64)
+		if err != nil {
+			abortInvalidID(ctx, "actuator_id")
+			return
+		}
+		wait := defaultCommandsWait
+		if raw, has := ctx.GetQuery("wait"); has {
+			seconds, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || seconds < 0 || time.Duration(seconds)*time.Second > maxCommandsWait {
+				abortWithProblem(ctx, http.StatusBadRequest, codeInvalidQuery,
+					fmt.Sprintf("wait must be from 0 to %d seconds", int(maxCommandsWait/time.Second)))
+				return
+			}
+			wait = time.Duration(seconds) * time.Second
+		}
+
+		commands, err := uc.Commands.PollCommands(ctx, id, wait)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		if len(commands) == 0 {
+			ctx.Status(http.StatusNoContent)
+			return
+		}
+		ctx.JSON(http.StatusOK, getCommandsDto(commands))
+	}
+}
+
+func setupPostCommandAckHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkContentType(ctx) {
+			return
+		}
+		actuatorID, id, ok := parseCommandPath(ctx)
+		if !ok {
+			return
+		}
+		ack := models.CommandAck{}
+		if !bindAndValidate(ctx, &ack) {
+			return
+		}
+		// the id in the body is for the websocket, here it may only repeat the path
+		if ack.CommandID != 0 && ack.CommandID != id {
+			abortWithProblem(ctx, http.StatusUnprocessableEntity, codeValidationFailed, "command_id must match the path")
+			return
+		}
+
+		c, err := uc.Commands.Acknowledge(ctx, actuatorID, id, ackFailure(&ack))
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, getCommandDto(c))
+	}
+}
+
+func setupGetCommandStreamHandler(ws *WebSocketHandler, me *MetricsExporter) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.ParseInt(ctx.Param("actuator_id"), 10, 64)
+		if err != nil {
+			abortInvalidID(ctx, "actuator_id")
+			return
+		}
+
+		gauge := me.activeWebsockets.WithLabelValues(ctx.FullPath())
+		gauge.Inc()
+		defer gauge.Dec()
+
+		if err := ws.HandleCommands(ctx, id); err != nil {
+			abortWithError(ctx, err)
+		}
+	}
+}
+
+// ackFailure is the failure the usecase expects, a failed command without the reason still fails
+func ackFailure(ack *models.CommandAck) string {
+	if *ack.Status == models.CommandAckStatusAcknowledged {
+		return ""
+	}
+	if ack.Error == "" {
+		return "failed on the device"
+	}
+	return ack.Error
+}
+
+func parseCommandPath(ctx *gin.Context) (actuatorID, id int64, ok bool) {
+	actuatorID, err := strconv.ParseInt(ctx.Param("actuator_id"), 10, 64)
+	if err != nil {
+		abortInvalidID(ctx, "actuator_id")
+		return 0, 0, false
+	}
+	id, err = strconv.ParseInt(ctx.Param("command_id"), 10, 64)
+	if err != nil {
+		abortInvalidID(ctx, "command_id")
+		return 0, 0, false
+	}
+	return actuatorID, id, true
+}
+
+func setupOptionsActuatorsHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("Allow", strings.Join([]string{http.MethodOptions, http.MethodPost, http.MethodGet}, ","))
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+func setupOptionsActuatorHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("Allow", strings.Join([]string{http.MethodOptions, http.MethodGet}, ","))
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+func setupOptionsCommandsHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("Allow", strings.Join([]string{http.MethodOptions, http.MethodPost}, ","))
+		ctx.Status(http.StatusNoContent)
+	}
+}
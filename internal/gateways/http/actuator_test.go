@@ -0,0 +1,73 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/models"
+	actuatorRepository "homework/internal/repository/actuator/inmemory"
+	"homework/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommands(t *testing.T) {
+	ar := actuatorRepository.NewActuatorRepository()
+	commands := usecase.NewCommands(actuatorRepository.NewCommandRepository(), ar)
+	r := gin.New()
+	setupRouter(r, UseCases{Actuator: usecase.NewActuator(ar), Commands: commands},
+		nil, newLiveSettings(DefaultSettings), testMetrics)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, "/api/v1"+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/actuators", `{"serial_number": "0123456789", "type": "relay", "description": "реле"}`)
+	require.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+
+	t.Run("ok, poll waits for the command", func(t *testing.T) {
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			_, _ = commands.SendCommand(context.Background(), 1, &domain.Command{Action: "switch", Args: domain.IntPayload(1)}, 0)
+		}()
+
+		w := do(http.MethodGet, "/actuators/1/commands/pending?wait=5", "")
+		require.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+		var dtos []models.Command
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &dtos))
+		require.Len(t, dtos, 1)
+		assert.Equal(t, models.CommandStatusSent, *dtos[0].Status)
+		assert.Equal(t, int64(1), *dtos[0].Attempts)
+		assert.Len(t, dtos[0].Args, 1)
+	})
+
+	t.Run("fail, ack of another command", func(t *testing.T) {
+		w := do(http.MethodPost, "/actuators/1/commands/1/ack", `{"command_id": 2, "status": "acknowledged"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "Идентификатор в теле должен совпадать с путем")
+	})
+
+	t.Run("ok, failure is correlated", func(t *testing.T) {
+		w := do(http.MethodPost, "/actuators/1/commands/1/ack", `{"status": "failed"}`)
+		require.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+		var dto models.Command
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &dto))
+		assert.Equal(t, models.CommandStatusFailed, *dto.Status)
+		assert.NotEmpty(t, dto.Error, "Ошибка без причины должна получить причину по умолчанию")
+	})
+
+	t.Run("fail, wait too long", func(t *testing.T) {
+		w := do(http.MethodGet, "/actuators/1/commands/pending?wait=61", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Получили в ответ не тот код")
+	})
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/models"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func calibrationDto(cs []domain.Calibration) []*models.Calibration {
+	if len(cs) == 0 {
+		return nil
+	}
+	number := func(d domain.Decimal) *json.Number {
+		n := json.Number(d.String())
+		return &n
+	}
+
+	dtos := make([]*models.Calibration, len(cs))
+	for i, c := range cs {
+		channel := c.Channel
+		dto := &models.Calibration{Channel: &channel, Unit: c.Unit}
+		if len(c.Table) == 0 {
+			dto.Gain, dto.Offset = number(c.Gain), number(c.Offset)
+		}
+		for _, p := range c.Table {
+			dto.Table = append(dto.Table, &models.CalibrationPoint{Raw: number(p.Raw), Value: number(p.Value)})
+		}
+		dtos[i] = dto
+	}
+	return dtos
+}
+
+// calibrationFromDto converts the calibration, a number that is not a decimal is reported as its field.
+// The gain is 1 and the offset is 0 unless they are given.
+func calibrationFromDto(dtos []*models.Calibration) ([]domain.Calibration, *models.ValidationError) {
+	number := func(n *json.Number, def domain.Decimal, field string) (domain.Decimal, *models.ValidationError) {
+		if n == nil {
+			return def, nil
+		}
+		d, err := domain.ParseDecimal(n.String())
+		if err != nil {
+			message := err.Error()
+			return d, &models.ValidationError{Field: &field, Message: &message}
+		}
+		return d, nil
+	}
+
+	cs := make([]domain.Calibration, 0, len(dtos))
+	for i, dto := range dtos {
+		if dto == nil {
+			continue
+		}
+		c := domain.Calibration{Channel: *dto.Channel, Unit: dto.Unit}
+		var invalid *models.ValidationError
+		if c.Gain, invalid = number(dto.Gain, domain.Decimal{Units: 1}, fmt.Sprintf("calibration.%d.gain", i)); invalid != nil {
+			return nil, invalid
+		}
+		if c.Offset, invalid = number(dto.Offset, domain.Decimal{}, fmt.Sprintf("calibration.%d.offset", i)); invalid != nil {
+			return nil, invalid
+		}
+		for j, p := range dto.Table {
+			if p == nil {
+				continue
+			}
+			var point domain.CalibrationPoint
+			if point.Raw, invalid = number(p.Raw, domain.Decimal{}, fmt.Sprintf("calibration.%d.table.%d.raw", i, j)); invalid != nil {
+				return nil, invalid
+			}
+			if point.Value, invalid = number(p.Value, domain.Decimal{}, fmt.Sprintf("calibration.%d.table.%d.value", i, j)); invalid != nil {
+				return nil, invalid
+			}
+			c.Table = append(c.Table, point)
+		}
+		cs = append(cs, c)
+	}
+	return cs, nil
+}
+
+func setupPutSensorCalibrationHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkContentType(ctx) {
+			return
+		}
+		id, err := strconv.ParseInt(ctx.Param("sensor_id"), 10, 64)
+		if err != nil {
+			abortInvalidID(ctx, "sensor_id")
+			return
+		}
+		c := models.SensorCalibration{}
+		if !bindAndValidate(ctx, &c) {
+			return
+		}
+		calibration, invalid := calibrationFromDto(c.Calibration)
+		if invalid != nil {
+			abortWithProblem(ctx, http.StatusUnprocessableEntity, codeValidationFailed, "request body is invalid", invalid)
+			return
+		}
+
+		s, err := uc.Sensor.SetCalibration(ctx, id, calibration)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, getSensorsDto(*s)[0])
+	}
+}
+
+func setupOptionsSensorCalibrationHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("Allow", strings.Join([]string{http.MethodOptions, http.MethodPut}, ","))
+		ctx.Status(http.StatusNoContent)
+	}
+}
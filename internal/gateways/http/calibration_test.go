@@ -0,0 +1,133 @@
+package http
+
+import (
+	"encoding/json"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/models"
+	"homework/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSensorCalibration(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	srMock := usecase.NewMockSensorRepository(ctrl)
+	srMock.EXPECT().GetSensorByID(gomock.Any(), int64(1)).DoAndReturn(func(_ any, _ int64) (*domain.Sensor, error) {
+		return &domain.Sensor{ID: 1, SerialNumber: "0000000001", Type: domain.SensorTypeADC, IsActive: true}, nil
+	}).AnyTimes()
+	srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Any()).Return(nil, usecase.ErrSensorNotFound).AnyTimes()
+	erMock := usecase.NewMockEventRepository(ctrl)
+
+	r := gin.New()
+	setupRouter(r, UseCases{
+		Sensor: usecase.NewSensor(srMock),
+		Event:  usecase.NewEvent(erMock, srMock),
+	}, nil, newLiveSettings(DefaultSettings), testMetrics)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, "/api/v1"+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("ok, gain is 1 by default", func(t *testing.T) {
+		var saved *domain.Sensor
+		srMock.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, s *domain.Sensor) error {
+			saved = s
+			return nil
+		}).Times(1)
+
+		w := do(http.MethodPut, "/sensors/1/calibration", `{"calibration": [{"channel": "value", "offset": -273.15, "unit": "°C"}]}`)
+		require.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код: %s", w.Body.String())
+		assert.Equal(t, []domain.Calibration{{
+			Channel: domain.DefaultChannel, Gain: domain.Decimal{Units: 1}, Offset: domain.Decimal{Units: -27315, Scale: 2}, Unit: "°C",
+		}}, saved.Calibration)
+
+		var dto models.Sensor
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &dto))
+		require.Len(t, dto.Calibration, 1)
+		assert.Equal(t, "1", dto.Calibration[0].Gain.String())
+		assert.Equal(t, "-273.15", dto.Calibration[0].Offset.String())
+	})
+
+	t.Run("ok, table", func(t *testing.T) {
+		var saved *domain.Sensor
+		srMock.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, s *domain.Sensor) error {
+			saved = s
+			return nil
+		}).Times(1)
+
+		w := do(http.MethodPut, "/sensors/1/calibration",
+			`{"calibration": [{"channel": "value", "table": [{"raw": 0, "value": -40}, {"raw": 4095, "value": 125}]}]}`)
+		require.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код: %s", w.Body.String())
+		require.Len(t, saved.Calibration, 1)
+		assert.Equal(t, []domain.CalibrationPoint{
+			{Raw: domain.Decimal{Units: 0}, Value: domain.Decimal{Units: -40}},
+			{Raw: domain.Decimal{Units: 4095}, Value: domain.Decimal{Units: 125}},
+		}, saved.Calibration[0].Table)
+
+		var dto models.Sensor
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &dto))
+		require.Len(t, dto.Calibration, 1)
+		assert.Nil(t, dto.Calibration[0].Gain, "С таблицей множитель не возвращается")
+		assert.Len(t, dto.Calibration[0].Table, 2)
+	})
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		want int
+		code string
+	}{
+		{"sensor not found", "/sensors/2/calibration", `{"calibration": []}`, http.StatusNotFound, codeSensorNotFound},
+		{"malformed body", "/sensors/1/calibration", `{`, http.StatusBadRequest, codeMalformedBody},
+		{"missing calibration", "/sensors/1/calibration", `{}`, http.StatusUnprocessableEntity, codeValidationFailed},
+		{"zero gain", "/sensors/1/calibration", `{"calibration": [{"channel": "value", "gain": 0}]}`,
+			http.StatusUnprocessableEntity, codeInvalidCalibration},
+		{"repeated channel", "/sensors/1/calibration", `{"calibration": [{"channel": "value"}, {"channel": "value"}]}`,
+			http.StatusUnprocessableEntity, codeInvalidCalibration},
+		{"too precise gain", "/sensors/1/calibration", `{"calibration": [{"channel": "value", "gain": 0.0000000001}]}`,
+			http.StatusUnprocessableEntity, codeValidationFailed},
+	}
+	for _, tt := range tests {
+		t.Run("err, "+tt.name, func(t *testing.T) {
+			w := do(http.MethodPut, tt.path, tt.body)
+			require.Equal(t, tt.want, w.Code, "Получили в ответ не тот код: %s", w.Body.String())
+
+			var p models.Error
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+			assert.Equal(t, tt.code, *p.Code)
+		})
+	}
+
+	t.Run("ok, history has raw readings", func(t *testing.T) {
+		erMock.EXPECT().GetHistoryBySensorID(gomock.Any(), int64(1), gomock.Any(), gomock.Any()).Return([]*domain.Event{{
+			Timestamp: time.Unix(10, 0),
+			SensorID:  1,
+			Payload:   domain.Payload{{Channel: domain.DefaultChannel, Value: domain.Decimal{Units: 215, Scale: 1}, Unit: "°C"}},
+			Raw:       domain.IntPayload(2048),
+		}}, nil).Times(1)
+
+		w := do(http.MethodGet, "/sensors/1/history?start_date=0&end_date=100", "")
+		require.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код: %s", w.Body.String())
+
+		var dtos []models.HistoryEvent
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &dtos))
+		require.Len(t, dtos, 1)
+		assert.Equal(t, int64(21), *dtos[0].Payload, "payload - целая часть откалиброванного значения")
+		require.Len(t, dtos[0].RawReadings, 1)
+		assert.Equal(t, "2048", dtos[0].RawReadings[0].Value.String())
+	})
+}
@@ -0,0 +1,202 @@
+package http
+
+import (
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-openapi/strfmt"
+)
+
+const (
+	// defaultChangesWait is how long the read of the feed waits for the changes unless the consumer asks otherwise
+	defaultChangesWait = 30 * time.Second
+	maxChangesWait     = time.Minute
+	defaultChangesPage = 100
+	maxChangesPage     = 1000
+)
+
+func getChangeDto(c domain.Change) models.Change {
+	kind := string(c.Kind)
+	dto := models.Change{Cursor: &c.Cursor, Kind: &kind, Time: (*strfmt.DateTime)(&c.Time)}
+	switch c.Kind {
+	case domain.ChangeKindSensor:
+		sensor := getSensorsDto(*c.Sensor)[0]
+		dto.Sensor = &sensor
+	case domain.ChangeKindUser:
+		dto.User = &models.User{ID: &c.User.ID, Name: &c.User.Name}
+	case domain.ChangeKindSensorOwner:
+		dto.SensorOwner = &models.ChangeSensorOwner{SensorID: &c.SensorOwner.SensorID, UserID: &c.SensorOwner.UserID}
+	case domain.ChangeKindEvent:
+		dto.Event = getChangeEventDto(c.Event)
+	}
+	return dto
+}
+
+func getChangeEventDto(e *domain.Event) *models.ChangeEvent {
+	payload := e.Payload.Int()
+	dto := &models.ChangeEvent{
+		SensorID:           &e.SensorID,
+		SensorSerialNumber: &e.SensorSerialNumber,
+		Timestamp:          (*strfmt.DateTime)(&e.Timestamp),
+		Payload:            &payload,
+		Readings:           readingsDto(e.Payload),
+	}
+	if len(e.Raw) > 0 {
+		dto.RawReadings = readingsDto(e.Raw)
+	}
+	return dto
+}
+
+func getChangesDto(changes []domain.Change) []models.Change {
+	dtos := make([]models.Change, len(changes))
+	for i, c := range changes {
+		dtos[i] = getChangeDto(c)
+	}
+	return dtos
+}
+
+func getChangeConsumerDto(c *domain.ChangeConsumer) models.ChangeConsumer {
+	return models.ChangeConsumer{Name: &c.Name, Cursor: &c.Cursor, UpdatedAt: (*strfmt.DateTime)(&c.UpdatedAt)}
+}
+
+// parseChangesStart returns the consumer and the cursor to read the feed after: the after query if it is set,
+// otherwise the cursor the consumer has stopped at, otherwise the beginning of the feed
+func parseChangesStart(ctx *gin.Context, uc UseCases) (string, int64, bool) {
+	consumer := ctx.Query("consumer")
+	if consumer != "" {
+		if err := (domain.ChangeConsumer{Name: consumer}).Validate(); err != nil {
+			abortWithProblem(ctx, http.StatusBadRequest, codeInvalidQuery, err.Error())
+			return "", 0, false
+		}
+	}
+	if raw, has := ctx.GetQuery("after"); has {
+		after, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || after < 0 {
+			abortWithProblem(ctx, http.StatusBadRequest, codeInvalidQuery, "after must be a non-negative integer")
+			return "", 0, false
+		}
+		return consumer, after, true
+	}
+	if consumer == "" {
+		return "", 0, true
+	}
+	after, err := uc.Changes.GetConsumerCursor(ctx, consumer)
+	if err != nil {
+		abortWithError(ctx, err)
+		return "", 0, false
+	}
+	return consumer, after, true
+}
+
+// setupGetChangesHandler is the long poll of the feed: the changes after the cursor are sent at once,
+// otherwise the request waits for them up to wait seconds and ends with 204 if none has come
+func setupGetChangesHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkAccept(ctx) {
+			return
+		}
+		_, after, ok := parseChangesStart(ctx, uc)
+		if !ok {
+			return
+		}
+		limit := defaultChangesPage
+		if raw, has := ctx.GetQuery("limit"); has {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > maxChangesPage {
+				abortWithProblem(ctx, http.StatusBadRequest, codeInvalidQuery, fmt.Sprintf("limit must be from 1 to %d", maxChangesPage))
+				return
+			}
+			limit = n
+		}
+		wait := defaultChangesWait
+		if raw, has := ctx.GetQuery("wait"); has {
+			seconds, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || seconds < 0 || time.Duration(seconds)*time.Second > maxChangesWait {
+				abortWithProblem(ctx, http.StatusBadRequest, codeInvalidQuery,
+					fmt.Sprintf("wait must be from 0 to %d seconds", int(maxChangesWait/time.Second)))
+				return
+			}
+			wait = time.Duration(seconds) * time.Second
+		}
+
+		changes, err := uc.Changes.GetChanges(ctx, after, limit, wait)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		if len(changes) == 0 {
+			ctx.Status(http.StatusNoContent)
+			return
+		}
+		ctx.JSON(http.StatusOK, getChangesDto(changes))
+	}
+}
+
+func setupGetChangeStreamHandler(uc UseCases, ws *WebSocketHandler, me *MetricsExporter) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		consumer, after, ok := parseChangesStart(ctx, uc)
+		if !ok {
+			return
+		}
+
+		gauge := me.activeWebsockets.WithLabelValues(ctx.FullPath())
+		gauge.Inc()
+		defer gauge.Dec()
+
+		if err := ws.HandleChanges(ctx, consumer, after); err != nil {
+			abortWithError(ctx, err)
+		}
+	}
+}
+
+func setupGetChangeConsumerHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkAccept(ctx) {
+			return
+		}
+		consumer, err := uc.Changes.GetConsumer(ctx, ctx.Param("name"))
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, getChangeConsumerDto(consumer))
+	}
+}
+
+func setupPutChangeConsumerHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkContentType(ctx) {
+			return
+		}
+		cursor := models.ChangeCursor{}
+		if !bindAndValidate(ctx, &cursor) {
+			return
+		}
+		consumer, err := uc.Changes.CommitConsumer(ctx, ctx.Param("name"), *cursor.Cursor)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, getChangeConsumerDto(consumer))
+	}
+}
+
+func setupOptionsChangesHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("Allow", strings.Join([]string{http.MethodOptions, http.MethodGet}, ","))
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+func setupOptionsChangeConsumerHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("Allow", strings.Join([]string{http.MethodOptions, http.MethodGet, http.MethodPut}, ","))
+		ctx.Status(http.StatusNoContent)
+	}
+}
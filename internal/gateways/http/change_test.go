@@ -0,0 +1,98 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/models"
+	changeRepository "homework/internal/repository/change/inmemory"
+	userRepository "homework/internal/repository/user/inmemory"
+	"homework/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChanges(t *testing.T) {
+	changes := changeRepository.NewChangeRepository()
+	users := usecase.NewUser(userRepository.NewUserRepository(userRepository.WithUserChanges(changes.Record)),
+		userRepository.NewSensorOwnerRepository(), nil)
+	r := gin.New()
+	setupRouter(r, UseCases{User: users, Changes: usecase.NewChanges(changes)}, nil, newLiveSettings(DefaultSettings), testMetrics)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, "/api/v1"+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+	read := func(w *httptest.ResponseRecorder) []models.Change {
+		require.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+		var dtos []models.Change
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &dtos))
+		return dtos
+	}
+
+	for _, name := range []string{"first", "second", "third"} {
+		_, err := users.RegisterUser(context.Background(), &domain.User{Name: name})
+		require.NoError(t, err)
+	}
+
+	t.Run("ok, changes are paged", func(t *testing.T) {
+		dtos := read(do(http.MethodGet, "/changes?limit=2&wait=0", ""))
+		require.Len(t, dtos, 2)
+		assert.Equal(t, "first", *dtos[0].User.Name)
+		assert.Equal(t, int64(2), *dtos[1].Cursor)
+
+		dtos = read(do(http.MethodGet, "/changes?after=2&wait=0", ""))
+		require.Len(t, dtos, 1)
+		assert.Equal(t, "third", *dtos[0].User.Name)
+	})
+
+	t.Run("ok, read starts from the consumer", func(t *testing.T) {
+		w := do(http.MethodPut, "/changes/consumers/audit", `{"cursor": 2}`)
+		require.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+
+		dtos := read(do(http.MethodGet, "/changes?consumer=audit&wait=0", ""))
+		require.Len(t, dtos, 1)
+		assert.Equal(t, int64(3), *dtos[0].Cursor, "Чтение должно начинаться с курсора потребителя")
+
+		dtos = read(do(http.MethodGet, "/changes?consumer=audit&after=0&wait=0", ""))
+		assert.Len(t, dtos, 3, "Заданный after должен быть важнее курсора потребителя")
+	})
+
+	t.Run("ok, poll waits for the change", func(t *testing.T) {
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			_, _ = users.RegisterUser(context.Background(), &domain.User{Name: "fourth"})
+		}()
+
+		dtos := read(do(http.MethodGet, "/changes?after=3&wait=5", ""))
+		require.Len(t, dtos, 1)
+		assert.Equal(t, "fourth", *dtos[0].User.Name)
+	})
+
+	t.Run("fail, changes are expired", func(t *testing.T) {
+		_, err := changes.DeleteChanges(context.Background(), time.Now().Add(time.Hour))
+		require.NoError(t, err)
+
+		w := do(http.MethodGet, "/changes?after=1&wait=0", "")
+		assert.Equal(t, http.StatusGone, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, codeChangesExpired, decodeProblem(t, w)["code"])
+
+		w = do(http.MethodGet, "/changes?after=4&wait=0", "")
+		assert.Equal(t, http.StatusNoContent, w.Code, "Курсор последнего удаленного изменения действителен")
+	})
+
+	t.Run("fail, consumer name not valid", func(t *testing.T) {
+		w := do(http.MethodPut, "/changes/consumers/Audit", `{"cursor": 1}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "Получили в ответ не тот код")
+	})
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-openapi/strfmt"
+)
+
+const mimeParquet = "application/vnd.apache.parquet"
+
+func getExportDto(e *domain.Export) models.Export {
+	status := string(e.Status)
+	from, to := e.From.Unix(), e.To.Unix()
+	dto := models.Export{
+		ID:        &e.ID,
+		SensorIds: e.SensorIDs,
+		StartDate: &from,
+		EndDate:   &to,
+		Status:    &status,
+		Error:     e.Error,
+		CreatedAt: (*strfmt.DateTime)(&e.CreatedAt),
+	}
+	if !e.CompletedAt.IsZero() {
+		dto.CompletedAt = (*strfmt.DateTime)(&e.CompletedAt)
+	}
+	return dto
+}
+
+func setupPostExportHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkContentType(ctx) {
+			return
+		}
+		e := models.ExportToCreate{}
+		if !bindAndValidate(ctx, &e) {
+			return
+		}
+		if *e.StartDate > *e.EndDate {
+			abortWithProblem(ctx, http.StatusUnprocessableEntity, codeValidationFailed, "start_date must not be after end_date")
+			return
+		}
+
+		export, err := uc.Export.StartExport(ctx.Request.Context(), e.SensorIds, time.Unix(*e.StartDate, 0), time.Unix(*e.EndDate, 0))
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+
+		ctx.Header("Location", fmt.Sprintf("/exports/%d", export.ID))
+		ctx.JSON(http.StatusAccepted, getExportDto(export))
+	}
+}
+
+func setupGetExportHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkAccept(ctx) {
+			return
+		}
+		id, err := strconv.ParseInt(ctx.Param("export_id"), 10, 64)
+		if err != nil {
+			abortInvalidID(ctx, "export_id")
+			return
+		}
+		export, err := uc.Export.GetExport(ctx, id)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, getExportDto(export))
+	}
+}
+
+func setupGetExportFileHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.ParseInt(ctx.Param("export_id"), 10, 64)
+		if err != nil {
+			abortInvalidID(ctx, "export_id")
+			return
+		}
+		path, err := uc.Export.GetExportFile(ctx, id)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+
+		ctx.Header("Content-Type", mimeParquet)
+		ctx.FileAttachment(path, fmt.Sprintf("export-%d.parquet", id))
+	}
+}
+
+func setupOptionsExportHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("Allow", strings.Join([]string{http.MethodOptions, http.MethodPost}, ","))
+		ctx.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// probeTimeout bounds every check, the orchestrator gives up on a probe soon anyway
+const probeTimeout = 2 * time.Second
+
+const (
+	statusOK       = "ok"
+	statusFailing  = "failing"
+	statusDraining = "draining"
+)
+
+// Check is a named probe of something the server depends on
+type Check struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// Flusher finishes the background work that is still pending when the server shuts down
+type Flusher struct {
+	Name  string
+	Flush func(ctx context.Context) error
+}
+
+type health struct {
+	liveness  []Check
+	readiness []Check
+	// draining is set as soon as the shutdown starts, so the server is taken out of the rotation
+	draining atomic.Bool
+}
+
+type probeResult struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// setupHealthRoutes registers the probes. They are not a part of the api, so they live outside of /api/v1.
+// notProbe keeps the probes out of the traces, they are polled too often to be of any interest
+func notProbe(r *http.Request) bool {
+	return r.URL.Path != "/healthz" && r.URL.Path != "/readyz"
+}
+
+func setupHealthRoutes(r *gin.Engine, h *health) {
+	r.GET("/healthz", setupProbeHandler(h, false))
+	r.HEAD("/healthz", setupProbeHandler(h, false))
+	r.GET("/readyz", setupProbeHandler(h, true))
+	r.HEAD("/readyz", setupProbeHandler(h, true))
+}
+
+// setupProbeHandler runs the liveness checks, or the readiness ones if ready is set.
+// A draining server is alive, but not ready.
+func setupProbeHandler(h *health, ready bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		checks := h.liveness
+		if ready {
+			checks = h.readiness
+		}
+
+		res := probeResult{Status: statusOK, Checks: runChecks(ctx.Request.Context(), checks)}
+		for _, status := range res.Checks {
+			if status != statusOK {
+				res.Status = statusFailing
+			}
+		}
+		if ready && h.draining.Load() {
+			res.Status = statusDraining
+		}
+
+		code := http.StatusOK
+		if res.Status != statusOK {
+			code = http.StatusServiceUnavailable
+		}
+		ctx.Header("Cache-Control", "no-store")
+		ctx.JSON(code, res)
+	}
+}
+
+func runChecks(ctx context.Context, checks []Check) map[string]string {
+	res := make(map[string]string, len(checks))
+	for _, c := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+		if err := c.Check(checkCtx); err != nil {
+			res[c.Name] = err.Error()
+		} else {
+			res[c.Name] = statusOK
+		}
+		cancel()
+	}
+	return res
+}
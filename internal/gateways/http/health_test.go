@@ -0,0 +1,115 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func probe(t *testing.T, s *Server, path string) (int, probeResult) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	s.router.ServeHTTP(w, req)
+
+	var res probeResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	return w.Code, res
+}
+
+func TestProbes(t *testing.T) {
+	var dbErr error
+	s := NewServer(UseCases{},
+		WithLivenessChecks(Check{Name: "loop", Check: func(context.Context) error { return nil }}),
+		WithReadinessChecks(Check{Name: "database", Check: func(context.Context) error { return dbErr }}),
+	)
+
+	t.Run("ok", func(t *testing.T) {
+		code, res := probe(t, s, "/readyz")
+		assert.Equal(t, http.StatusOK, code, "Получили в ответ не тот код")
+		assert.Equal(t, probeResult{Status: statusOK, Checks: map[string]string{"database": statusOK}}, res)
+	})
+
+	t.Run("failing_check", func(t *testing.T) {
+		dbErr = errors.New("connection refused")
+		defer func() { dbErr = nil }()
+
+		code, res := probe(t, s, "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, code, "Получили в ответ не тот код")
+		assert.Equal(t, statusFailing, res.Status)
+		assert.Equal(t, "connection refused", res.Checks["database"])
+
+		code, _ = probe(t, s, "/healthz")
+		assert.Equal(t, http.StatusOK, code, "Недоступная база не повод перезапускать сервер")
+	})
+
+	t.Run("draining", func(t *testing.T) {
+		s.health.draining.Store(true)
+		defer s.health.draining.Store(false)
+
+		code, res := probe(t, s, "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, code, "Получили в ответ не тот код")
+		assert.Equal(t, statusDraining, res.Status)
+
+		code, _ = probe(t, s, "/healthz")
+		assert.Equal(t, http.StatusOK, code, "Получили в ответ не тот код")
+	})
+}
+
+func TestDrain(t *testing.T) {
+	var flushed []string
+	flusher := func(name string) Flusher {
+		return Flusher{Name: name, Flush: func(context.Context) error {
+			flushed = append(flushed, name)
+			return nil
+		}}
+	}
+	s := NewServer(UseCases{},
+		WithTimeouts(Timeouts{Shutdown: 5 * time.Second, DrainDelay: 50 * time.Millisecond}),
+		WithFlushers(flusher("first"), flusher("second")),
+	)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	s.router.POST("/slow", func(ctx *gin.Context) {
+		close(started)
+		<-release
+		ctx.Status(http.StatusAccepted)
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- s.serve(ctx, l)
+	}()
+
+	answered := make(chan int, 1)
+	go func() {
+		resp, err := http.Post("http://"+l.Addr().String()+"/slow", "text/plain", nil)
+		if !assert.NoError(t, err) {
+			answered <- 0
+			return
+		}
+		resp.Body.Close()
+		answered <- resp.StatusCode
+	}()
+
+	<-started
+	cancel()
+	require.Eventually(t, s.health.draining.Load, time.Second, 10*time.Millisecond, "Сервер должен перестать быть готовым")
+
+	close(release)
+	assert.Equal(t, http.StatusAccepted, <-answered, "Запрос в процессе должен быть обработан до конца")
+	assert.NoError(t, <-served)
+	assert.Equal(t, []string{"first", "second"}, flushed, "Отложенная работа должна быть завершена по порядку")
+}
@@ -0,0 +1,156 @@
+package http
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	mimeJSON   = "application/json"
+	mimeCSV    = "text/csv"
+	mimeNDJSON = "application/x-ndjson"
+)
+
+var historyFormats = []string{mimeJSON, mimeCSV, mimeNDJSON}
+
+// historyEncoder writes history events to the response one by one
+type historyEncoder interface {
+	// begin is called once before the first event
+	begin() error
+	encode(event *domain.Event) error
+	// end flushes everything that is still buffered
+	end() error
+}
+
+func newHistoryEncoder(ctx *gin.Context, format string) historyEncoder {
+	buf := bufio.NewWriter(ctx.Writer)
+	if format == mimeCSV {
+		return &csvHistoryEncoder{w: csv.NewWriter(buf), buf: buf}
+	}
+	return &ndjsonHistoryEncoder{enc: json.NewEncoder(buf), buf: buf}
+}
+
+type csvHistoryEncoder struct {
+	w   *csv.Writer
+	buf *bufio.Writer
+}
+
+// the rows hold the main channel only, the others are picked with the channel query
+func (e *csvHistoryEncoder) begin() error {
+	return e.w.Write([]string{"timestamp", "payload", "value", "unit"})
+}
+
+func (e *csvHistoryEncoder) encode(event *domain.Event) error {
+	var main domain.Reading
+	if len(event.Payload) > 0 {
+		main = event.Payload[0]
+	}
+	return e.w.Write([]string{
+		strconv.FormatInt(event.Timestamp.Unix(), 10),
+		strconv.FormatInt(event.Payload.Int(), 10),
+		main.Value.String(),
+		main.Unit,
+	})
+}
+
+func (e *csvHistoryEncoder) end() error {
+	e.w.Flush()
+	if err := e.w.Error(); err != nil {
+		return err
+	}
+	return e.buf.Flush()
+}
+
+type ndjsonHistoryEncoder struct {
+	enc *json.Encoder
+	buf *bufio.Writer
+}
+
+func (e *ndjsonHistoryEncoder) begin() error {
+	return nil
+}
+
+func (e *ndjsonHistoryEncoder) encode(event *domain.Event) error {
+	return e.enc.Encode(historyEventDto(event))
+}
+
+func (e *ndjsonHistoryEncoder) end() error {
+	return e.buf.Flush()
+}
+
+func historyEventDto(event *domain.Event) models.HistoryEvent {
+	unixTime, payload := event.Timestamp.Unix(), event.Payload.Int()
+	dto := models.HistoryEvent{Timestamp: &unixTime, Payload: &payload, Readings: readingsDto(event.Payload)}
+	if len(event.Raw) > 0 {
+		dto.RawReadings = readingsDto(event.Raw)
+	}
+	return dto
+}
+
+// selectChannel leaves only the reading of the channel in a copy of the event, so its integer part becomes the payload.
+// The events without the channel are skipped. An empty channel selects everything.
+func selectChannel(event *domain.Event, channel string) (*domain.Event, bool) {
+	if channel == "" {
+		return event, true
+	}
+	payload, ok := event.Payload.Select(channel)
+	if !ok {
+		return nil, false
+	}
+	selected := *event
+	selected.Payload = payload
+	selected.Raw, _ = event.Raw.Select(channel)
+	return &selected, true
+}
+
+// negotiateHistoryFormat picks the history representation requested in the Accept header
+func negotiateHistoryFormat(ctx *gin.Context) (string, bool) {
+	return negotiateOrAbort(ctx, historyFormats...)
+}
+
+// streamHistory writes the events as they come from the usecase, so the whole range is never buffered.
+// The status line is sent with the first event, therefore an error before it still gets a proper code,
+// an error after it only breaks the body.
+func streamHistory(ctx *gin.Context, uc UseCases, format string, id int64, from, to time.Time, channel string) error {
+	enc := newHistoryEncoder(ctx, format)
+	started := false
+	start := func() error {
+		if started {
+			return nil
+		}
+		started = true
+		ctx.Header("Content-Type", format)
+		ctx.Status(http.StatusOK)
+		return enc.begin()
+	}
+
+	err := uc.Event.StreamHistoryBySensorID(ctx, id, from, to, func(event *domain.Event) error {
+		event, ok := selectChannel(event, channel)
+		if !ok {
+			return nil
+		}
+		if err := start(); err != nil {
+			return err
+		}
+		return enc.encode(event)
+	})
+	if err != nil {
+		if started {
+			_ = enc.end()
+		}
+		return err
+	}
+
+	if err := start(); err != nil {
+		return err
+	}
+	return enc.end()
+}
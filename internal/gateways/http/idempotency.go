@@ -0,0 +1,76 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/usecase"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	headerIdempotencyKey     = "Idempotency-Key"
+	headerIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// idempotent runs handle only for the first request with the key, the retries get the response it has written.
+// The response is forgotten if it is worth retrying: a server error or a throttled request.
+// The key is stored under the scope, the client's key is checked before it, so the scope doesn't count to its length.
+func idempotent(ctx *gin.Context, uc *usecase.Idempotency, scope, key, fingerprint string, handle func()) {
+	if !validID(key) {
+		abortWithProblem(ctx, http.StatusBadRequest, codeInvalidHeader,
+			fmt.Sprintf("%s must be up to %d printable ASCII characters", headerIdempotencyKey, maxIDLength))
+		return
+	}
+	key = scope + key
+
+	replay, err := uc.Begin(ctx, key, fingerprint)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+	if replay != nil {
+		ctx.Header(headerIdempotentReplayed, "true")
+		if len(replay.Body) > 0 {
+			ctx.Data(replay.Status, replay.ContentType, replay.Body)
+		} else {
+			ctx.Status(replay.Status)
+		}
+		return
+	}
+
+	// the result is stored even if the client has gone, it is going to retry
+	storeCtx := context.WithoutCancel(ctx.Request.Context())
+	w := &recordingWriter{ResponseWriter: ctx.Writer}
+	ctx.Writer = w
+	defer func() {
+		ctx.Writer = w.ResponseWriter
+		if p := recover(); p != nil {
+			release(storeCtx, uc, key)
+			panic(p)
+		}
+
+		status := w.Status()
+		if status >= http.StatusInternalServerError || status == http.StatusTooManyRequests {
+			release(storeCtx, uc, key)
+			return
+		}
+		err := uc.Complete(storeCtx, domain.IdempotencyRecord{
+			Key: key, Fingerprint: fingerprint, Status: status, ContentType: w.Header().Get("Content-Type"), Body: w.body.Bytes(),
+		})
+		if err != nil {
+			logging.FromContext(storeCtx).Error("Idempotency key is not completed", "error", err)
+		}
+	}()
+
+	handle()
+}
+
+func release(ctx context.Context, uc *usecase.Idempotency, key string) {
+	if err := uc.Release(ctx, key); err != nil {
+		logging.FromContext(ctx).Error("Idempotency key is not released", "error", err)
+	}
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"errors"
+	"homework/internal/domain"
+	idempotencyRepository "homework/internal/repository/idempotency/inmemory"
+	"homework/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotentEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	srMock := usecase.NewMockSensorRepository(ctrl)
+	srMock.EXPECT().GetSensorBySerialNumber(gomock.Any(), "0000000001").Return(&domain.Sensor{
+		ID: 1, SerialNumber: "0000000001", Type: domain.SensorTypeADC, IsActive: true,
+	}, nil).AnyTimes()
+	srMock.EXPECT().GetSensorBySerialNumber(gomock.Any(), "0000000002").Return(&domain.Sensor{ID: 2}, nil).AnyTimes()
+	srMock.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	var saved int
+	var saveErr error
+	erMock := usecase.NewMockEventRepository(ctrl)
+	erMock.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).DoAndReturn(func(any, any) error {
+		if saveErr != nil {
+			return saveErr
+		}
+		saved++
+		return nil
+	}).AnyTimes()
+
+	uc := UseCases{
+		Event:       usecase.NewEvent(erMock, srMock),
+		Idempotency: usecase.NewIdempotency(idempotencyRepository.NewIdempotencyRepository(100), time.Hour),
+	}
+	r := gin.New()
+	setupRouter(r, uc, nil, newLiveSettings(DefaultSettings), testMetrics)
+
+	post := func(body, key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/events", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set(headerIdempotencyKey, key)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("ok, retry gets the original result", func(t *testing.T) {
+		body := `{"sensor_serial_number": "0000000001", "payload": 10}`
+
+		w := post(body, "retry")
+		assert.Equal(t, http.StatusCreated, w.Code, "Получили в ответ не тот код")
+		assert.Empty(t, w.Header().Get(headerIdempotentReplayed))
+
+		w = post(body, "retry")
+		assert.Equal(t, http.StatusCreated, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, "true", w.Header().Get(headerIdempotentReplayed))
+		assert.Equal(t, 1, saved, "Повтор не должен сохранять событие")
+	})
+
+	t.Run("ok, event id is the key", func(t *testing.T) {
+		saved = 0
+		body := `{"id": "42", "sensor_serial_number": "0000000001", "payload": 10}`
+
+		post(body, "")
+		w := post(body, "")
+		assert.Equal(t, "true", w.Header().Get(headerIdempotentReplayed))
+		assert.Equal(t, 1, saved, "Повтор не должен сохранять событие")
+	})
+
+	t.Run("ok, error is replayed", func(t *testing.T) {
+		body := `{"sensor_serial_number": "0000000002", "payload": 10}`
+
+		w := post(body, "inactive")
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "Получили в ответ не тот код")
+
+		w = post(body, "inactive")
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, "true", w.Header().Get(headerIdempotentReplayed))
+		assert.Equal(t, codeSensorInactive, decodeProblem(t, w)["code"])
+	})
+
+	t.Run("ok, server error is retried", func(t *testing.T) {
+		saved = 0
+		body := `{"sensor_serial_number": "0000000001", "payload": 10}`
+
+		saveErr = errors.New("connection refused")
+		w := post(body, "failed")
+		assert.Equal(t, http.StatusInternalServerError, w.Code, "Получили в ответ не тот код")
+
+		saveErr = nil
+		w = post(body, "failed")
+		assert.Equal(t, http.StatusCreated, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, 1, saved)
+	})
+
+	t.Run("err, key reused with another event", func(t *testing.T) {
+		post(`{"sensor_serial_number": "0000000001", "payload": 10}`, "reused")
+
+		w := post(`{"sensor_serial_number": "0000000001", "payload": 20}`, "reused")
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, codeIdempotencyKeyReused, decodeProblem(t, w)["code"])
+	})
+
+	t.Run("ok, key of the longest length", func(t *testing.T) {
+		w := post(`{"sensor_serial_number": "0000000001", "payload": 10}`, strings.Repeat("k", maxIDLength))
+		assert.Equal(t, http.StatusCreated, w.Code, "Префикс ключа не должен учитываться в его длине")
+	})
+
+	t.Run("err, invalid key", func(t *testing.T) {
+		w := post(`{"sensor_serial_number": "0000000001", "payload": 10}`, strings.Repeat("k", maxIDLength+1))
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Получили в ответ не тот код")
+	})
+}
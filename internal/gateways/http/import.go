@@ -0,0 +1,85 @@
+package http
+
+import (
+	"errors"
+	"homework/internal/gateways/http/models"
+	"homework/internal/gateways/importer"
+	"homework/internal/usecase"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// importIDHeader lets a client resume an interrupted import by sending the same value again
+const importIDHeader = "Import-ID"
+
+func importFormat(ctx *gin.Context) (importer.Format, bool) {
+	format, err := importer.FormatFromMIME(ctx.GetHeader("Content-Type"))
+	if err != nil {
+		abortWithProblem(ctx, http.StatusUnsupportedMediaType, codeUnsupportedMediaType, err.Error())
+		return "", false
+	}
+	return format, true
+}
+
+func handleImportResult(ctx *gin.Context, res usecase.ImportResult, err error) {
+	if err != nil {
+		switch {
+		case errors.Is(err, importer.ErrInvalidRecord), errors.Is(err, importer.ErrMissingColumn):
+			_ = ctx.Error(err)
+			abortWithProblem(ctx, http.StatusUnprocessableEntity, codeMalformedBody, err.Error())
+		case errors.Is(err, usecase.ErrSensorNotFound):
+			// an unknown sensor in the file is a problem of the file, not of the route
+			_ = ctx.Error(err)
+			abortWithProblem(ctx, http.StatusUnprocessableEntity, codeSensorNotFound, err.Error())
+		default:
+			abortWithError(ctx, err)
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, models.ImportResult{
+		Processed: &res.Processed,
+		Imported:  &res.Imported,
+		Skipped:   &res.Skipped,
+	})
+}
+
+func setupPostImportSensorsHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		format, ok := importFormat(ctx)
+		if !ok {
+			return
+		}
+		src, err := importer.NewSensorReader(ctx.Request.Body, format)
+		if err != nil {
+			handleImportResult(ctx, usecase.ImportResult{}, err)
+			return
+		}
+		res, err := uc.Import.ImportSensors(ctx, ctx.GetHeader(importIDHeader), src)
+		handleImportResult(ctx, res, err)
+	}
+}
+
+func setupPostImportEventsHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		format, ok := importFormat(ctx)
+		if !ok {
+			return
+		}
+		src, err := importer.NewEventReader(ctx.Request.Body, format)
+		if err != nil {
+			handleImportResult(ctx, usecase.ImportResult{}, err)
+			return
+		}
+		res, err := uc.Import.ImportEvents(ctx, ctx.GetHeader(importIDHeader), src)
+		handleImportResult(ctx, res, err)
+	}
+}
+
+func setupOptionsImportHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("Allow", strings.Join([]string{http.MethodOptions, http.MethodPost}, ","))
+		ctx.Status(http.StatusNoContent)
+	}
+}
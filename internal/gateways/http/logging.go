@@ -0,0 +1,93 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"homework/internal/logging"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	headerRequestID = "X-Request-ID"
+	// maxIDLength keeps a client from stuffing the logs and the stores through the id headers
+	maxIDLength = 128
+)
+
+// validID accepts the ids made of printable ASCII, whatever scheme the client or the proxy uses
+func validID(id string) bool {
+	if id == "" || len(id) > maxIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// requestLogHandler takes the request id from X-Request-ID or makes a new one, returns it in the response and puts
+// a logger with it into the request context. When the request is done, it is logged along with the errors behind it.
+func requestLogHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		id := ctx.GetHeader(headerRequestID)
+		if !validID(id) {
+			id = newRequestID()
+		}
+		ctx.Header(headerRequestID, id)
+
+		logger := slog.Default().With("request_id", id)
+		if span := trace.SpanContextFromContext(ctx.Request.Context()); span.IsValid() {
+			logger = logger.With("trace_id", span.TraceID().String())
+		}
+		ctx.Request = ctx.Request.WithContext(logging.WithLogger(ctx.Request.Context(), logger))
+
+		ctx.Next()
+
+		if !notProbe(ctx.Request) {
+			return
+		}
+		status := ctx.Writer.Status()
+		attrs := []any{
+			"method", ctx.Request.Method,
+			"path", ctx.Request.URL.Path,
+			"status", status,
+			"duration", time.Since(start),
+			"size", ctx.Writer.Size(),
+			"client_ip", ctx.ClientIP(),
+		}
+		if len(ctx.Errors) > 0 {
+			attrs = append(attrs, "error", ctx.Errors.String())
+		}
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logger.Log(ctx.Request.Context(), level, "Request is served", attrs...)
+	}
+}
+
+// recoveryHandler turns a panic into a 500, the panic is logged as the error of the request
+func recoveryHandler() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(ctx *gin.Context, err any) {
+		logging.FromContext(ctx.Request.Context()).Error("Handler has panicked", "panic", err, "stack", string(debug.Stack()))
+		_ = ctx.Error(fmt.Errorf("panic: %v", err))
+		abortWithProblem(ctx, http.StatusInternalServerError, codeInternal, http.StatusText(http.StatusInternalServerError))
+	})
+}
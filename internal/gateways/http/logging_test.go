@@ -0,0 +1,75 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"homework/internal/usecase"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLog(t *testing.T) {
+	var out bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&out, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	ctrl := gomock.NewController(t)
+	srMock := usecase.NewMockSensorRepository(ctrl)
+	srMock.EXPECT().GetSensors(gomock.Any()).Return(nil, errors.New("connection refused")).AnyTimes()
+
+	r := gin.New()
+	setupRouter(r, UseCases{Sensor: usecase.NewSensor(srMock)}, nil, newLiveSettings(DefaultSettings), testMetrics)
+	r.GET("/panic", func(*gin.Context) { panic("boom") })
+
+	request := func(path, requestID string) (*httptest.ResponseRecorder, map[string]any) {
+		out.Reset()
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		if requestID != "" {
+			req.Header.Set(headerRequestID, requestID)
+		}
+		r.ServeHTTP(w, req)
+
+		// the access log is the last line
+		lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(lines[len(lines)-1], &entry))
+		return w, entry
+	}
+
+	t.Run("ok, cause of 500 is logged with the request id", func(t *testing.T) {
+		w, entry := request("/api/v1/sensors", "req-42")
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, "req-42", w.Header().Get(headerRequestID), "Идентификатор запроса должен вернуться клиенту")
+		assert.Equal(t, "ERROR", entry["level"])
+		assert.Equal(t, "req-42", entry["request_id"])
+		assert.Equal(t, float64(http.StatusInternalServerError), entry["status"])
+		assert.Contains(t, entry["error"], "connection refused", "Причина ошибки должна быть в логе")
+	})
+
+	t.Run("ok, invalid id is replaced", func(t *testing.T) {
+		w, entry := request("/api/v1/sensors", "bad id")
+
+		id := w.Header().Get(headerRequestID)
+		assert.Len(t, id, 32)
+		assert.Equal(t, id, entry["request_id"])
+	})
+
+	t.Run("ok, panic is recovered", func(t *testing.T) {
+		w, entry := request("/panic", "")
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, codeInternal, decodeProblem(t, w)["code"])
+		assert.Contains(t, entry["error"], "boom")
+	})
+}
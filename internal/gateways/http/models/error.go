@@ -6,6 +6,8 @@ package models
 // Editing this file might prove futile when you re-run the swagger generate command
 
 import (
+	"strconv"
+
 	"github.com/go-openapi/errors"
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
@@ -14,21 +16,52 @@ import (
 
 // Error Error
 //
-// # Ошибка исполнения запроса
+// # Ошибка исполнения запроса в формате RFC 7807
 //
 // swagger:model Error
 type Error struct {
 
+	// Машиночитаемый код ошибки
+	// Required: true
+	// Min Length: 1
+	Code *string `json:"code"`
+
+	// Подробное описание ошибки
+	Detail string `json:"detail,omitempty"`
+
+	// Ошибки валидации тела запроса
+	Errors []*ValidationError `json:"errors,omitempty"`
+
+	// Адрес запроса, вызвавшего ошибку
+	Instance string `json:"instance,omitempty"`
+
 	// Причина
 	// Required: true
 	// Min Length: 1
 	Reason *string `json:"reason"`
+
+	// HTTP код ответа
+	Status int64 `json:"status,omitempty"`
+
+	// Краткое описание типа ошибки
+	Title string `json:"title,omitempty"`
+
+	// Тип ошибки
+	Type string `json:"type,omitempty"`
 }
 
 // Validate validates this error
 func (m *Error) Validate(formats strfmt.Registry) error {
 	var res []error
 
+	if err := m.validateCode(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateErrors(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.validateReason(formats); err != nil {
 		res = append(res, err)
 	}
@@ -39,6 +72,45 @@ func (m *Error) Validate(formats strfmt.Registry) error {
 	return nil
 }
 
+func (m *Error) validateCode(formats strfmt.Registry) error {
+
+	if err := validate.Required("code", "body", m.Code); err != nil {
+		return err
+	}
+
+	if err := validate.MinLength("code", "body", string(*m.Code), 1); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *Error) validateErrors(formats strfmt.Registry) error {
+	if swag.IsZero(m.Errors) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Errors); i++ {
+		if swag.IsZero(m.Errors[i]) { // not required
+			continue
+		}
+
+		if m.Errors[i] != nil {
+			if err := m.Errors[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("errors" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("errors" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
 func (m *Error) validateReason(formats strfmt.Registry) error {
 
 	if err := validate.Required("reason", "body", m.Reason); err != nil {
@@ -6,6 +6,8 @@ package models
 // Editing this file might prove futile when you re-run the swagger generate command
 
 import (
+	"strconv"
+
 	"github.com/go-openapi/errors"
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
@@ -23,6 +25,12 @@ type HistoryEvent struct {
 	// Required: true
 	Payload *int64 `json:"payload"`
 
+	// Показания, как их прислал датчик, если они откалиброваны
+	RawReadings []*Reading `json:"raw_readings,omitempty"`
+
+	// Показания датчика по каналам, payload - целая часть первого из них
+	Readings []*Reading `json:"readings,omitempty"`
+
 	// Временная метка
 	// Required: true
 	Timestamp *int64 `json:"timestamp"`
@@ -36,6 +44,14 @@ func (m *HistoryEvent) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateRawReadings(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateReadings(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.validateTimestamp(formats); err != nil {
 		res = append(res, err)
 	}
@@ -55,6 +71,58 @@ func (m *HistoryEvent) validatePayload(formats strfmt.Registry) error {
 	return nil
 }
 
+func (m *HistoryEvent) validateRawReadings(formats strfmt.Registry) error {
+	if swag.IsZero(m.RawReadings) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.RawReadings); i++ {
+		if swag.IsZero(m.RawReadings[i]) { // not required
+			continue
+		}
+
+		if m.RawReadings[i] != nil {
+			if err := m.RawReadings[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("raw_readings" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("raw_readings" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *HistoryEvent) validateReadings(formats strfmt.Registry) error {
+	if swag.IsZero(m.Readings) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Readings); i++ {
+		if swag.IsZero(m.Readings[i]) { // not required
+			continue
+		}
+
+		if m.Readings[i] != nil {
+			if err := m.Readings[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("readings" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("readings" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
 func (m *HistoryEvent) validateTimestamp(formats strfmt.Registry) error {
 
 	if err := validate.Required("timestamp", "body", m.Timestamp); err != nil {
@@ -6,7 +6,7 @@ package models
 // Editing this file might prove futile when you re-run the swagger generate command
 
 import (
-	"encoding/json"
+	"strconv"
 
 	"github.com/go-openapi/errors"
 	"github.com/go-openapi/strfmt"
@@ -21,6 +21,12 @@ import (
 // swagger:model Sensor
 type Sensor struct {
 
+	// Калибровки каналов датчика
+	Calibration []*Calibration `json:"calibration,omitempty"`
+
+	// Показания датчика по каналам из последнего обработанного события
+	CurrentReadings []*Reading `json:"current_readings,omitempty"`
+
 	// Состояние датчика, соответствует значению в payload последнего обработанного события.
 	// Required: true
 	CurrentState *int64 `json:"current_state"`
@@ -29,6 +35,9 @@ type Sensor struct {
 	// Required: true
 	Description *string `json:"description"`
 
+	// Выражение виртуального датчика
+	Expression string `json:"expression,omitempty"`
+
 	// Идентификатор
 	// Required: true
 	// Minimum: 1
@@ -55,7 +64,7 @@ type Sensor struct {
 
 	// Тип
 	// Required: true
-	// Enum: [cc adc]
+	// Pattern: ^[a-z][a-z0-9_]{0,31}$
 	Type *string `json:"type"`
 }
 
@@ -63,6 +72,14 @@ type Sensor struct {
 func (m *Sensor) Validate(formats strfmt.Registry) error {
 	var res []error
 
+	if err := m.validateCalibration(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateCurrentReadings(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.validateCurrentState(formats); err != nil {
 		res = append(res, err)
 	}
@@ -101,6 +118,58 @@ func (m *Sensor) Validate(formats strfmt.Registry) error {
 	return nil
 }
 
+func (m *Sensor) validateCalibration(formats strfmt.Registry) error {
+	if swag.IsZero(m.Calibration) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Calibration); i++ {
+		if swag.IsZero(m.Calibration[i]) { // not required
+			continue
+		}
+
+		if m.Calibration[i] != nil {
+			if err := m.Calibration[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("calibration" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("calibration" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *Sensor) validateCurrentReadings(formats strfmt.Registry) error {
+	if swag.IsZero(m.CurrentReadings) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.CurrentReadings); i++ {
+		if swag.IsZero(m.CurrentReadings[i]) { // not required
+			continue
+		}
+
+		if m.CurrentReadings[i] != nil {
+			if err := m.CurrentReadings[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("current_readings" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("current_readings" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
 func (m *Sensor) validateCurrentState(formats strfmt.Registry) error {
 
 	if err := validate.Required("current_state", "body", m.CurrentState); err != nil {
@@ -180,43 +249,13 @@ func (m *Sensor) validateSerialNumber(formats strfmt.Registry) error {
 	return nil
 }
 
-var sensorTypeTypePropEnum []interface{}
-
-func init() {
-	var res []string
-	if err := json.Unmarshal([]byte(`["cc","adc"]`), &res); err != nil {
-		panic(err)
-	}
-	for _, v := range res {
-		sensorTypeTypePropEnum = append(sensorTypeTypePropEnum, v)
-	}
-}
-
-const (
-
-	// SensorTypeCc captures enum value "cc"
-	SensorTypeCc string = "cc"
-
-	// SensorTypeAdc captures enum value "adc"
-	SensorTypeAdc string = "adc"
-)
-
-// prop value enum
-func (m *Sensor) validateTypeEnum(path, location string, value string) error {
-	if err := validate.EnumCase(path, location, value, sensorTypeTypePropEnum, true); err != nil {
-		return err
-	}
-	return nil
-}
-
 func (m *Sensor) validateType(formats strfmt.Registry) error {
 
 	if err := validate.Required("type", "body", m.Type); err != nil {
 		return err
 	}
 
-	// value enum
-	if err := m.validateTypeEnum("type", "body", *m.Type); err != nil {
+	if err := validate.Pattern("type", "body", string(*m.Type), `^[a-z][a-z0-9_]{0,31}$`); err != nil {
 		return err
 	}
 
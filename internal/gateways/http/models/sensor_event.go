@@ -6,6 +6,9 @@ package models
 // Editing this file might prove futile when you re-run the swagger generate command
 
 import (
+	"encoding/json"
+	"strconv"
+
 	"github.com/go-openapi/errors"
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
@@ -19,21 +22,36 @@ import (
 // swagger:model SensorEvent
 type SensorEvent struct {
 
-	// Информация от датчика
-	// Required: true
-	Payload *int64 `json:"payload"`
+	// Идентификатор события, заменяет заголовок Idempotency-Key
+	// Max Length: 128
+	ID string `json:"id,omitempty"`
+
+	// Показание датчика с одним каналом, заменяется readings
+	Payload *json.Number `json:"payload,omitempty"`
+
+	// Показания датчика по каналам
+	// Max Items: 16
+	Readings []*Reading `json:"readings,omitempty"`
 
 	// Серийный номер датчика
 	// Required: true
 	// Pattern: ^\d{10}$
 	SensorSerialNumber *string `json:"sensor_serial_number"`
+
+	// Время события по часам устройства, без него временем события считается время приёма
+	// Format: date-time
+	Timestamp *strfmt.DateTime `json:"timestamp,omitempty"`
 }
 
 // Validate validates this sensor event
 func (m *SensorEvent) Validate(formats strfmt.Registry) error {
 	var res []error
 
-	if err := m.validatePayload(formats); err != nil {
+	if err := m.validateID(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateReadings(formats); err != nil {
 		res = append(res, err)
 	}
 
@@ -41,18 +59,57 @@ func (m *SensorEvent) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateTimestamp(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if len(res) > 0 {
 		return errors.CompositeValidationError(res...)
 	}
 	return nil
 }
 
-func (m *SensorEvent) validatePayload(formats strfmt.Registry) error {
+func (m *SensorEvent) validateID(formats strfmt.Registry) error {
+	if swag.IsZero(m.ID) { // not required
+		return nil
+	}
+
+	if err := validate.MaxLength("id", "body", m.ID, 128); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *SensorEvent) validateReadings(formats strfmt.Registry) error {
+	if swag.IsZero(m.Readings) { // not required
+		return nil
+	}
+
+	iReadingsSize := int64(len(m.Readings))
 
-	if err := validate.Required("payload", "body", m.Payload); err != nil {
+	if err := validate.MaxItems("readings", "body", iReadingsSize, 16); err != nil {
 		return err
 	}
 
+	for i := 0; i < len(m.Readings); i++ {
+		if swag.IsZero(m.Readings[i]) { // not required
+			continue
+		}
+
+		if m.Readings[i] != nil {
+			if err := m.Readings[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("readings" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("readings" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
 	return nil
 }
 
@@ -69,6 +126,18 @@ func (m *SensorEvent) validateSensorSerialNumber(formats strfmt.Registry) error
 	return nil
 }
 
+func (m *SensorEvent) validateTimestamp(formats strfmt.Registry) error {
+	if swag.IsZero(m.Timestamp) { // not required
+		return nil
+	}
+
+	if err := validate.FormatOf("timestamp", "body", "date-time", m.Timestamp.String(), formats); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // MarshalBinary interface implementation
 func (m *SensorEvent) MarshalBinary() ([]byte, error) {
 	if m == nil {
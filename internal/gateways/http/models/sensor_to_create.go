@@ -6,8 +6,6 @@ package models
 // Editing this file might prove futile when you re-run the swagger generate command
 
 import (
-	"encoding/json"
-
 	"github.com/go-openapi/errors"
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
@@ -25,6 +23,10 @@ type SensorToCreate struct {
 	// Required: true
 	Description *string `json:"description"`
 
+	// Выражение виртуального датчика над показаниями других датчиков, например any(#1, #2)
+	// Max Length: 512
+	Expression string `json:"expression,omitempty"`
+
 	// Флаг активности датчика
 	// Required: true
 	IsActive *bool `json:"is_active"`
@@ -36,7 +38,7 @@ type SensorToCreate struct {
 
 	// Тип
 	// Required: true
-	// Enum: [cc adc]
+	// Pattern: ^[a-z][a-z0-9_]{0,31}$
 	Type *string `json:"type"`
 }
 
@@ -48,6 +50,10 @@ func (m *SensorToCreate) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateExpression(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.validateIsActive(formats); err != nil {
 		res = append(res, err)
 	}
@@ -75,54 +81,37 @@ func (m *SensorToCreate) validateDescription(formats strfmt.Registry) error {
 	return nil
 }
 
-func (m *SensorToCreate) validateIsActive(formats strfmt.Registry) error {
+func (m *SensorToCreate) validateExpression(formats strfmt.Registry) error {
+	if swag.IsZero(m.Expression) { // not required
+		return nil
+	}
 
-	if err := validate.Required("is_active", "body", m.IsActive); err != nil {
+	if err := validate.MaxLength("expression", "body", m.Expression, 512); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (m *SensorToCreate) validateSerialNumber(formats strfmt.Registry) error {
-
-	if err := validate.Required("serial_number", "body", m.SerialNumber); err != nil {
-		return err
-	}
+func (m *SensorToCreate) validateIsActive(formats strfmt.Registry) error {
 
-	if err := validate.Pattern("serial_number", "body", string(*m.SerialNumber), `^\d{10}$`); err != nil {
+	if err := validate.Required("is_active", "body", m.IsActive); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-var sensorToCreateTypeTypePropEnum []interface{}
+func (m *SensorToCreate) validateSerialNumber(formats strfmt.Registry) error {
 
-func init() {
-	var res []string
-	if err := json.Unmarshal([]byte(`["cc","adc"]`), &res); err != nil {
-		panic(err)
-	}
-	for _, v := range res {
-		sensorToCreateTypeTypePropEnum = append(sensorToCreateTypeTypePropEnum, v)
+	if err := validate.Required("serial_number", "body", m.SerialNumber); err != nil {
+		return err
 	}
-}
 
-const (
-
-	// SensorToCreateTypeCc captures enum value "cc"
-	SensorToCreateTypeCc string = "cc"
-
-	// SensorToCreateTypeAdc captures enum value "adc"
-	SensorToCreateTypeAdc string = "adc"
-)
-
-// prop value enum
-func (m *SensorToCreate) validateTypeEnum(path, location string, value string) error {
-	if err := validate.EnumCase(path, location, value, sensorToCreateTypeTypePropEnum, true); err != nil {
+	if err := validate.Pattern("serial_number", "body", string(*m.SerialNumber), `^\d{10}$`); err != nil {
 		return err
 	}
+
 	return nil
 }
 
@@ -132,8 +121,7 @@ func (m *SensorToCreate) validateType(formats strfmt.Registry) error {
 		return err
 	}
 
-	// value enum
-	if err := m.validateTypeEnum("type", "body", *m.Type); err != nil {
+	if err := validate.Pattern("type", "body", string(*m.Type), `^[a-z][a-z0-9_]{0,31}$`); err != nil {
 		return err
 	}
 
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// mediaRange is a single element of the Accept header, see RFC 7231 section 5.3.2
+type mediaRange struct {
+	typ, subtype string
+	charset      string
+	q            float64
+}
+
+// specificity orders ranges so that the most specific one decides the quality of an offer
+func (r mediaRange) specificity() int {
+	switch {
+	case r.typ == "*":
+		return 0
+	case r.subtype == "*":
+		return 1
+	case r.charset != "":
+		return 3
+	default:
+		return 2
+	}
+}
+
+func (r mediaRange) matches(typ, subtype string) bool {
+	if r.charset != "" && r.charset != "*" && !strings.EqualFold(r.charset, "utf-8") {
+		// everything we produce is utf-8
+		return false
+	}
+	return (r.typ == "*" || r.typ == typ) && (r.subtype == "*" || r.subtype == subtype)
+}
+
+// parseAccept parses the Accept header, malformed ranges are ignored
+func parseAccept(header string) []mediaRange {
+	ranges := make([]mediaRange, 0)
+	for _, raw := range strings.Split(header, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		mediaType, params, err := mime.ParseMediaType(raw)
+		if err != nil {
+			continue
+		}
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok || typ == "" || subtype == "" || (typ == "*" && subtype != "*") {
+			continue
+		}
+		r := mediaRange{typ: typ, subtype: subtype, charset: params["charset"], q: 1}
+		if qRaw, has := params["q"]; has {
+			q, err := strconv.ParseFloat(qRaw, 64)
+			if err != nil || q < 0 || q > 1 {
+				continue
+			}
+			r.q = q
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// negotiate picks the offer the client prefers the most. Offers are listed in the order of server preference,
+// which breaks ties between equally acceptable ones. A missing Accept header means that anything goes.
+func negotiate(header string, offers []string) (string, bool) {
+	if strings.TrimSpace(header) == "" {
+		return offers[0], true
+	}
+	ranges := parseAccept(header)
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		typ, subtype, _ := strings.Cut(offer, "/")
+		for _, r := range ranges {
+			if !r.matches(typ, subtype) {
+				continue
+			}
+			if r.q > bestQ {
+				best, bestQ = offer, r.q
+			}
+			break
+		}
+	}
+	return best, bestQ > 0
+}
+
+// isMediaType reports whether the Content-Type header denotes the given media type in utf-8
+func isMediaType(contentType, want string) bool {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != want {
+		return false
+	}
+	charset, has := params["charset"]
+	return !has || strings.EqualFold(charset, "utf-8")
+}
@@ -0,0 +1,44 @@
+package http
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{mimeJSON, mimeCSV, mimeNDJSON}
+	tests := []struct {
+		name   string
+		header string
+		want   string
+		ok     bool
+	}{
+		{"empty_header_first_offer", "", mimeJSON, true},
+		{"exact_match", "text/csv", mimeCSV, true},
+		{"wildcard_first_offer", "*/*", mimeJSON, true},
+		{"subtype_wildcard", "text/*", mimeCSV, true},
+		{"highest_q_wins", "application/json;q=0.5, application/x-ndjson", mimeNDJSON, true},
+		{"specific_range_overrides_wildcard", "*/*, application/json;q=0", mimeCSV, true},
+		{"malformed_ranges_ignored", "application, ;;, text/csv", mimeCSV, true},
+		{"invalid_q_ignored", "application/json;q=2, text/csv;q=0.1", mimeCSV, true},
+		{"nothing_acceptable", "application/xml", "", false},
+		{"everything_refused", "*/*;q=0", "", false},
+		{"foreign_charset", "text/csv; charset=koi8-r", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := negotiate(tt.header, offers)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIsMediaType(t *testing.T) {
+	assert.True(t, isMediaType("application/json", mimeJSON))
+	assert.True(t, isMediaType("application/json; charset=UTF-8", mimeJSON))
+	assert.False(t, isMediaType("application/json; charset=latin1", mimeJSON))
+	assert.False(t, isMediaType("text/plain", mimeJSON))
+	assert.False(t, isMediaType("", mimeJSON))
+}
@@ -0,0 +1,195 @@
+package http
+
+import (
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/models"
+	"homework/internal/usecase"
+	"math"
+	"net/http"
+	"strconv"
+
+	openapiErrors "github.com/go-openapi/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+const mimeProblem = "application/problem+json"
+
+// Stable machine-readable error codes, clients may rely on them
+const (
+	codeSensorNotFound          = "sensor_not_found"
+	codeSensorInactive          = "sensor_inactive"
+	codeUserNotFound            = "user_not_found"
+	codeEventNotFound           = "event_not_found"
+	codeExportNotFound          = "export_not_found"
+	codeExportNotReady          = "export_not_ready"
+	codeEmptyExport             = "empty_export"
+	codeWrongSensorSerialNumber = "wrong_sensor_serial_number"
+	codeWrongSensorType         = "wrong_sensor_type"
+	codeInvalidEventTimestamp   = "invalid_event_timestamp"
+	codeInvalidUserName         = "invalid_user_name"
+	codeRateLimited             = "rate_limited"
+	codeEventConflict           = "event_conflict"
+	codeIdempotencyKeyInUse     = "idempotency_key_in_use"
+	codeIdempotencyKeyReused    = "idempotency_key_reused"
+	codeSensorTypeNotFound      = "sensor_type_not_found"
+	codeSensorTypeInUse         = "sensor_type_in_use"
+	codeInvalidSensorType       = "invalid_sensor_type"
+	codeInvalidReadings         = "invalid_readings"
+	codeInvalidCalibration      = "invalid_calibration"
+	codeActuatorNotFound        = "actuator_not_found"
+	codeInvalidActuator         = "invalid_actuator"
+	codeCommandNotFound         = "command_not_found"
+	codeCommandFinished         = "command_finished"
+	codeInvalidCommand          = "invalid_command"
+	codeSceneNotFound           = "scene_not_found"
+	codeSceneInUse              = "scene_in_use"
+	codeInvalidScene            = "invalid_scene"
+	codeScheduleNotFound        = "schedule_not_found"
+	codeInvalidSchedule         = "invalid_schedule"
+	codeInvalidExpression       = "invalid_expression"
+	codeChangesExpired          = "changes_expired"
+	codeConsumerNotFound        = "consumer_not_found"
+	codeInvalidConsumer         = "invalid_consumer"
+
+	codeInvalidID            = "invalid_id"
+	codeInvalidQuery         = "invalid_query"
+	codeMalformedBody        = "malformed_body"
+	codeBodyTooLarge         = "body_too_large"
+	codeValidationFailed     = "validation_failed"
+	codeNotAcceptable        = "not_acceptable"
+	codeUnsupportedMediaType = "unsupported_media_type"
+	codeInvalidHeader        = "invalid_header"
+	codeFeatureDisabled      = "feature_disabled"
+	codeRouteNotFound        = "route_not_found"
+	codeMethodNotAllowed     = "method_not_allowed"
+	codeInternal             = "internal_error"
+)
+
+type problemKind struct {
+	err    error
+	status int
+	code   string
+}
+
+// usecaseProblems maps the usecase errors to responses
+var usecaseProblems = []problemKind{
+	{usecase.ErrSensorNotFound, http.StatusNotFound, codeSensorNotFound},
+	{usecase.ErrSensorInactive, http.StatusUnprocessableEntity, codeSensorInactive},
+	{usecase.ErrUserNotFound, http.StatusNotFound, codeUserNotFound},
+	{usecase.ErrEventNotFound, http.StatusNotFound, codeEventNotFound},
+	{usecase.ErrExportNotFound, http.StatusNotFound, codeExportNotFound},
+	{usecase.ErrExportNotReady, http.StatusConflict, codeExportNotReady},
+	{usecase.ErrEmptyExport, http.StatusUnprocessableEntity, codeEmptyExport},
+	{usecase.ErrWrongSensorSerialNumber, http.StatusUnprocessableEntity, codeWrongSensorSerialNumber},
+	{usecase.ErrWrongSensorType, http.StatusUnprocessableEntity, codeWrongSensorType},
+	{usecase.ErrInvalidEventTimestamp, http.StatusUnprocessableEntity, codeInvalidEventTimestamp},
+	{usecase.ErrInvalidUserName, http.StatusUnprocessableEntity, codeInvalidUserName},
+	{usecase.ErrRateLimited, http.StatusTooManyRequests, codeRateLimited},
+	{usecase.ErrEventConflict, http.StatusConflict, codeEventConflict},
+	{usecase.ErrIdempotencyKeyInUse, http.StatusConflict, codeIdempotencyKeyInUse},
+	{usecase.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity, codeIdempotencyKeyReused},
+	{usecase.ErrSensorTypeNotFound, http.StatusNotFound, codeSensorTypeNotFound},
+	{usecase.ErrSensorTypeInUse, http.StatusConflict, codeSensorTypeInUse},
+	{domain.ErrInvalidSensorType, http.StatusUnprocessableEntity, codeInvalidSensorType},
+	{usecase.ErrInvalidReadings, http.StatusUnprocessableEntity, codeInvalidReadings},
+	{domain.ErrInvalidCalibration, http.StatusUnprocessableEntity, codeInvalidCalibration},
+	{usecase.ErrActuatorNotFound, http.StatusNotFound, codeActuatorNotFound},
+	{domain.ErrInvalidActuator, http.StatusUnprocessableEntity, codeInvalidActuator},
+	{usecase.ErrCommandNotFound, http.StatusNotFound, codeCommandNotFound},
+	{usecase.ErrCommandFinished, http.StatusConflict, codeCommandFinished},
+	{domain.ErrInvalidCommand, http.StatusUnprocessableEntity, codeInvalidCommand},
+	{usecase.ErrSceneNotFound, http.StatusNotFound, codeSceneNotFound},
+	{usecase.ErrSceneInUse, http.StatusConflict, codeSceneInUse},
+	{domain.ErrInvalidScene, http.StatusUnprocessableEntity, codeInvalidScene},
+	{usecase.ErrScheduleNotFound, http.StatusNotFound, codeScheduleNotFound},
+	{domain.ErrInvalidSchedule, http.StatusUnprocessableEntity, codeInvalidSchedule},
+	{domain.ErrInvalidExpression, http.StatusUnprocessableEntity, codeInvalidExpression},
+	{usecase.ErrChangesExpired, http.StatusGone, codeChangesExpired},
+	{usecase.ErrConsumerNotFound, http.StatusNotFound, codeConsumerNotFound},
+	{domain.ErrInvalidConsumer, http.StatusUnprocessableEntity, codeInvalidConsumer},
+}
+
+// abortWithProblem responds with an RFC 7807 body. If the response has already been started
+// (e.g. a stream broke in the middle), the error is only recorded.
+func abortWithProblem(ctx *gin.Context, status int, code, reason string, details ...*models.ValidationError) {
+	if ctx.Writer.Written() {
+		ctx.Abort()
+		return
+	}
+	p := models.Error{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   int64(status),
+		Detail:   reason,
+		Instance: ctx.Request.URL.Path,
+		Code:     &code,
+		Reason:   &reason,
+		Errors:   details,
+	}
+	ctx.Header("Content-Type", mimeProblem)
+	ctx.AbortWithStatusJSON(status, p)
+}
+
+// abortWithError picks the response for the usecase error, unknown errors become 500
+func abortWithError(ctx *gin.Context, err error) {
+	_ = ctx.Error(err)
+	var throttled *usecase.ThrottledError
+	if errors.As(err, &throttled) {
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+	}
+	for _, p := range usecaseProblems {
+		if errors.Is(err, p.err) {
+			abortWithProblem(ctx, p.status, p.code, err.Error())
+			return
+		}
+	}
+	abortWithProblem(ctx, http.StatusInternalServerError, codeInternal, http.StatusText(http.StatusInternalServerError))
+}
+
+func abortInvalidID(ctx *gin.Context, param string) {
+	abortWithProblem(ctx, http.StatusUnprocessableEntity, codeInvalidID, param+" must be an integer")
+}
+
+// validationDetails flattens go-openapi validation errors into per-field details
+func validationDetails(err error) []*models.ValidationError {
+	details := make([]*models.ValidationError, 0)
+
+	var walk func(err error)
+	walk = func(err error) {
+		var composite *openapiErrors.CompositeError
+		if errors.As(err, &composite) {
+			for _, e := range composite.Errors {
+				walk(e)
+			}
+			return
+		}
+		field, message := "", err.Error()
+		var v *openapiErrors.Validation
+		if errors.As(err, &v) {
+			field = v.Name
+		}
+		details = append(details, &models.ValidationError{Field: &field, Message: &message})
+	}
+	walk(err)
+
+	return details
+}
+
+// featureHandler hides the routes of a feature while it is turned off
+func featureHandler(settings *liveSettings, enabled func(Settings) bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !enabled(settings.get()) {
+			abortWithProblem(ctx, http.StatusNotFound, codeFeatureDisabled, "the feature is turned off")
+		}
+	}
+}
+
+func noRouteHandler(ctx *gin.Context) {
+	abortWithProblem(ctx, http.StatusNotFound, codeRouteNotFound, "no such route")
+}
+
+func noMethodHandler(ctx *gin.Context) {
+	abortWithProblem(ctx, http.StatusMethodNotAllowed, codeMethodNotAllowed, ctx.Request.Method+" is not allowed here")
+}
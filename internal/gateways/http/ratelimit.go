@@ -0,0 +1,74 @@
+package http
+
+import (
+	"errors"
+	"homework/internal/usecase"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ingestion tells the routes the sensors post to, they are limited by the sensor instead of the client:
+// a gateway sends the events of many sensors from the same address
+func ingestion(ctx *gin.Context) bool {
+	path := ctx.FullPath()
+	return ctx.Request.Method == http.MethodPost && (path == "/events" || path == apiV1Prefix+"/events")
+}
+
+// clientOf tells the client of the request. The headers can be forged by anyone, so X-Forwarded-For
+// and the key header are only believed when the request comes from a trusted proxy.
+func clientOf(ctx *gin.Context, settings Settings) string {
+	trusted := func(addr netip.Addr) bool {
+		return slices.ContainsFunc(settings.TrustedProxies, func(p netip.Prefix) bool { return p.Contains(addr.Unmap()) })
+	}
+
+	remote, err := netip.ParseAddr(ctx.RemoteIP())
+	if err != nil || !trusted(remote) {
+		return "ip:" + ctx.RemoteIP()
+	}
+	if header := settings.ClientKeyHeader; header != "" && ctx.GetHeader(header) != "" {
+		return "key:" + ctx.GetHeader(header)
+	}
+	// the proxies append the address they got the request from, the first untrusted one from the end is the client
+	hops := strings.Split(strings.Join(ctx.Request.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		if !trusted(addr) {
+			return "ip:" + addr.String()
+		}
+	}
+	return "ip:" + remote.String()
+}
+
+// rateLimitHandler limits the clients and counts the throttled requests, including the events throttled by the sensor.
+// The events are limited by the client only while their serial numbers are unknown, see usecase.RateLimiter.
+func rateLimitHandler(limiter *usecase.RateLimiter, settings *liveSettings, metrics *MetricsExporter) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if limiter == nil || !notProbe(ctx.Request) {
+			ctx.Next()
+			return
+		}
+
+		client := clientOf(ctx, settings.get())
+		if ingestion(ctx) {
+			ctx.Request = ctx.Request.WithContext(usecase.ContextWithClient(ctx.Request.Context(), client))
+		} else if err := limiter.AllowClient(ctx, client); err != nil {
+			abortWithError(ctx, err)
+		}
+		// does nothing if the client has been throttled
+		ctx.Next()
+
+		for _, e := range ctx.Errors {
+			var throttled *usecase.ThrottledError
+			if errors.As(e.Err, &throttled) {
+				metrics.throttledRequests.WithLabelValues(throttled.Scope).Inc()
+			}
+		}
+	}
+}
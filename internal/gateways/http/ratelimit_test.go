@@ -0,0 +1,124 @@
+package http
+
+import (
+	"homework/internal/domain"
+	rateLimitRepository "homework/internal/repository/ratelimit/inmemory"
+	"homework/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	srMock := usecase.NewMockSensorRepository(ctrl)
+	srMock.EXPECT().GetSensors(gomock.Any()).Return([]domain.Sensor{}, nil).AnyTimes()
+	srMock.EXPECT().GetSensorBySerialNumber(gomock.Any(), "0000000001").Return(&domain.Sensor{
+		ID: 1, SerialNumber: "0000000001", Type: domain.SensorTypeADC, IsActive: true,
+	}, nil).AnyTimes()
+	srMock.EXPECT().GetSensorBySerialNumber(gomock.Any(), "0000000009").Return(nil, usecase.ErrSensorNotFound).AnyTimes()
+	srMock.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	erMock := usecase.NewMockEventRepository(ctrl)
+	erMock.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	limiter := usecase.NewRateLimiter(rateLimitRepository.NewRateLimitRepository(100), usecase.RateLimits{
+		Client: domain.RateLimit{Rate: 0.5, Burst: 2},
+		Sensor: domain.RateLimit{Rate: 0.1, Burst: 1},
+	})
+	uc := UseCases{
+		Event:     usecase.NewEvent(erMock, srMock, usecase.WithEventRateLimiter(limiter)),
+		Sensor:    usecase.NewSensor(srMock),
+		RateLimit: limiter,
+	}
+	reg := prometheus.NewRegistry()
+	r := gin.New()
+	settings := Settings{ClientKeyHeader: "X-API-Key", TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}}
+	setupRouter(r, uc, nil, newLiveSettings(settings), NewMetricsExporter(reg))
+
+	// the requests come from the proxy unless the header tells otherwise
+	request := func(method, path, body string, header http.Header) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.RemoteAddr = "10.0.0.1:1234"
+		if remote := header.Get("X-Remote-Addr"); remote != "" {
+			req.RemoteAddr = remote
+			header.Del("X-Remote-Addr")
+		}
+		req.Header = header
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("err, client is throttled", func(t *testing.T) {
+		for range 2 {
+			w := request(http.MethodGet, "/api/v1/sensors", "", http.Header{})
+			assert.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+		}
+
+		w := request(http.MethodGet, "/api/v1/sensors", "", http.Header{})
+		assert.Equal(t, http.StatusTooManyRequests, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, codeRateLimited, decodeProblem(t, w)["code"])
+		assert.Equal(t, "2", w.Header().Get("Retry-After"))
+
+		w = request(http.MethodGet, "/api/v1/sensors", "", http.Header{"X-Api-Key": {"gateway"}})
+		assert.Equal(t, http.StatusOK, w.Code, "Клиент с ключом ограничивается отдельно от адреса")
+
+		w = request(http.MethodGet, "/healthz", "", http.Header{})
+		assert.NotEqual(t, http.StatusTooManyRequests, w.Code, "Пробы не ограничиваются")
+	})
+
+	t.Run("err, headers of an untrusted client are ignored", func(t *testing.T) {
+		for range 2 {
+			w := request(http.MethodGet, "/api/v1/sensors", "", http.Header{"X-Remote-Addr": {"192.0.2.1:1234"}})
+			assert.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+		}
+
+		header := http.Header{"X-Remote-Addr": {"192.0.2.1:1234"}, "X-Api-Key": {"forged"}, "X-Forwarded-For": {"198.51.100.1"}}
+		w := request(http.MethodGet, "/api/v1/sensors", "", header)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code, "Клиент не за прокси ограничивается по своему адресу")
+
+		w = request(http.MethodGet, "/api/v1/sensors", "", http.Header{"X-Forwarded-For": {"198.51.100.1, 10.0.0.2"}})
+		assert.Equal(t, http.StatusOK, w.Code, "Клиент за прокси ограничивается по адресу из X-Forwarded-For")
+	})
+
+	t.Run("err, unknown serials are throttled by the client", func(t *testing.T) {
+		body := `{"sensor_serial_number": "0000000009", "payload": 10}`
+		header := func() http.Header { return http.Header{"X-Remote-Addr": {"192.0.2.2:1234"}} }
+
+		for range 2 {
+			w := request(http.MethodPost, "/api/v1/events", body, header())
+			assert.Equal(t, http.StatusNotFound, w.Code, "Получили в ответ не тот код")
+		}
+		w := request(http.MethodPost, "/api/v1/events", body, header())
+		assert.Equal(t, http.StatusTooManyRequests, w.Code, "Неизвестные серийные номера ограничиваются по адресу клиента")
+	})
+
+	t.Run("err, sensor is throttled", func(t *testing.T) {
+		body := `{"sensor_serial_number": "0000000001", "payload": 10}`
+
+		w := request(http.MethodPost, "/api/v1/events", body, http.Header{})
+		assert.Equal(t, http.StatusCreated, w.Code, "Прием событий не ограничивается по адресу клиента")
+
+		w = request(http.MethodPost, "/api/v1/events", body, http.Header{})
+		assert.Equal(t, http.StatusTooManyRequests, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, "10", w.Header().Get("Retry-After"))
+	})
+
+	expected := `
+# HELP throttled_requests_total Counts the requests rejected by the rate limits by scope
+# TYPE throttled_requests_total counter
+throttled_requests_total{scope="client"} 2
+throttled_requests_total{scope="sensor"} 1
+throttled_requests_total{scope="unknown_serial"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "throttled_requests_total"))
+}
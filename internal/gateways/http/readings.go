@@ -0,0 +1,52 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/models"
+)
+
+var errPayloadOrReadings = errors.New("either payload or readings is required")
+
+// payloadFromDto reads the readings of the event. The sensors that know nothing of the channels
+// send a single number in payload, it goes to the default channel.
+func payloadFromDto(e *models.SensorEvent) (domain.Payload, error) {
+	if (e.Payload == nil) == (len(e.Readings) == 0) {
+		return nil, errPayloadOrReadings
+	}
+	if e.Payload != nil {
+		v, err := domain.ParseDecimal(e.Payload.String())
+		if err != nil {
+			return nil, err
+		}
+		return domain.Payload{{Channel: domain.DefaultChannel, Value: v}}, nil
+	}
+
+	return readingsFromDto(e.Readings)
+}
+
+// readingsFromDto converts the readings by channels, no readings make an empty payload
+func readingsFromDto(readings []*models.Reading) (domain.Payload, error) {
+	if len(readings) == 0 {
+		return nil, nil
+	}
+	payload := make(domain.Payload, len(readings))
+	for i, r := range readings {
+		v, err := domain.ParseDecimal(r.Value.String())
+		if err != nil {
+			return nil, err
+		}
+		payload[i] = domain.Reading{Channel: *r.Channel, Value: v, Unit: r.Unit}
+	}
+	return payload, payload.Validate()
+}
+
+func readingsDto(p domain.Payload) []*models.Reading {
+	dtos := make([]*models.Reading, len(p))
+	for i, r := range p {
+		channel, value := r.Channel, json.Number(r.Value.String())
+		dtos[i] = &models.Reading{Channel: &channel, Value: &value, Unit: r.Unit}
+	}
+	return dtos
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"encoding/json"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/models"
+	"homework/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadings(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	srMock := usecase.NewMockSensorRepository(ctrl)
+	srMock.EXPECT().GetSensorBySerialNumber(gomock.Any(), "0000000001").Return(&domain.Sensor{
+		ID: 1, SerialNumber: "0000000001", Type: domain.SensorTypeADC, IsActive: true,
+	}, nil).AnyTimes()
+	srMock.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	var saved *domain.Event
+	erMock := usecase.NewMockEventRepository(ctrl)
+	erMock.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, e *domain.Event) error {
+		saved = e
+		return nil
+	}).AnyTimes()
+	erMock.EXPECT().GetHistoryBySensorID(gomock.Any(), int64(1), gomock.Any(), gomock.Any()).Return([]*domain.Event{
+		{Timestamp: time.Unix(1, 0), SensorID: 1, Payload: domain.IntPayload(7)},
+		{Timestamp: time.Unix(2, 0), SensorID: 1, Payload: domain.Payload{
+			{Channel: "temperature", Value: domain.Decimal{Units: 215, Scale: 1}, Unit: "°C"},
+			{Channel: "humidity", Value: domain.Decimal{Units: 40}, Unit: "%"},
+		}},
+	}, nil).AnyTimes()
+
+	r := gin.New()
+	setupRouter(r, UseCases{Event: usecase.NewEvent(erMock, srMock)}, nil, newLiveSettings(DefaultSettings), testMetrics)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/events", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("ok, readings", func(t *testing.T) {
+		w := post(`{"sensor_serial_number": "0000000001", "readings": [
+			{"channel": "temperature", "value": 21.5, "unit": "°C"}, {"channel": "humidity", "value": 40, "unit": "%"}]}`)
+		require.Equal(t, http.StatusCreated, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, domain.Payload{
+			{Channel: "temperature", Value: domain.Decimal{Units: 215, Scale: 1}, Unit: "°C"},
+			{Channel: "humidity", Value: domain.Decimal{Units: 40}, Unit: "%"},
+		}, saved.Payload)
+	})
+
+	t.Run("ok, legacy payload", func(t *testing.T) {
+		w := post(`{"sensor_serial_number": "0000000001", "payload": 10}`)
+		require.Equal(t, http.StatusCreated, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, domain.IntPayload(10), saved.Payload)
+	})
+
+	t.Run("err, invalid readings", func(t *testing.T) {
+		inputs := []string{
+			`{"sensor_serial_number": "0000000001"}`,
+			`{"sensor_serial_number": "0000000001", "payload": 10, "readings": [{"channel": "value", "value": 10}]}`,
+			`{"sensor_serial_number": "0000000001", "readings": [{"channel": "t", "value": 1}, {"channel": "t", "value": 2}]}`,
+			`{"sensor_serial_number": "0000000001", "readings": [{"channel": "Temperature", "value": 1}]}`,
+			`{"sensor_serial_number": "0000000001", "payload": 0.0000000001}`,
+		}
+		for _, in := range inputs {
+			w := post(in)
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code, in)
+			assert.Equal(t, codeValidationFailed, decodeProblem(t, w)["code"], in)
+		}
+	})
+
+	history := func(query, accept string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/sensors/1/history?start_date=0&end_date=10"+query, nil)
+		req.Header.Set("Accept", accept)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("ok, history has readings", func(t *testing.T) {
+		w := history("", mimeJSON)
+		require.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+
+		var events []models.HistoryEvent
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		require.Len(t, events, 2)
+		assert.Equal(t, int64(7), *events[0].Payload)
+		assert.Equal(t, int64(21), *events[1].Payload, "payload - целая часть основного канала")
+		require.Len(t, events[1].Readings, 2)
+		assert.Equal(t, "21.5", events[1].Readings[0].Value.String())
+		assert.Equal(t, "°C", events[1].Readings[0].Unit)
+	})
+
+	t.Run("ok, channel is selected", func(t *testing.T) {
+		w := history("&channel=humidity", mimeJSON)
+		require.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+
+		var events []models.HistoryEvent
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		require.Len(t, events, 1, "События без канала пропускаются")
+		assert.Equal(t, int64(40), *events[0].Payload)
+		assert.Len(t, events[0].Readings, 1)
+
+		w = history("&channel=temperature", mimeCSV)
+		require.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, "timestamp,payload,value,unit\n2,21,21.5,°C\n", w.Body.String())
+	})
+}
@@ -2,13 +2,11 @@ package http
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"homework/internal/domain"
 	"homework/internal/gateways/http/models"
-	"homework/internal/usecase"
+	"homework/internal/tracing"
 	"io"
-	"log"
 	"net/http"
 	"slices"
 	"strconv"
@@ -22,10 +20,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/jeanfric/goembed/countingwriter"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
-var metrics = newMetricsExporter()
-
 type MetricsExporter struct {
 	// red metrics
 	totalRequests   *prometheus.CounterVec
@@ -38,51 +35,65 @@ type MetricsExporter struct {
 	// read/write metrics
 	totalReads  *prometheus.CounterVec
 	totalWrites *prometheus.CounterVec
+
+	// shutdown metrics
+	inFlightRequests   prometheus.Gauge
+	drainStage         *prometheus.GaugeVec
+	drainStageDuration *prometheus.GaugeVec
+
+	// rate limit metrics
+	throttledRequests *prometheus.CounterVec
 }
 
-func newMetricsExporter() *MetricsExporter {
-	metrics := &MetricsExporter{
-		totalRequests: promauto.NewCounterVec(prometheus.CounterOpts{
+// NewMetricsExporter registers the metrics in reg, so the tests can use a registry of their own
+func NewMetricsExporter(reg prometheus.Registerer) *MetricsExporter {
+	factory := promauto.With(reg)
+	return &MetricsExporter{
+		totalRequests: factory.NewCounterVec(prometheus.CounterOpts{
 			Name: "total_requests",
 			Help: "Counts all requests by path",
 		}, []string{"path"}),
-		requestDuration: promauto.NewHistogramVec(prometheus.HistogramOpts{
+		requestDuration: factory.NewHistogramVec(prometheus.HistogramOpts{
 			Name: "requests_duration",
 			Help: "Keeps track of the duration of requests grouped by request path",
 		}, []string{"path"}),
-		requestsErrors: promauto.NewCounterVec(prometheus.CounterOpts{
+		requestsErrors: factory.NewCounterVec(prometheus.CounterOpts{
 			Name: "requests_errors",
 			Help: "Counts all errors by path",
 		}, []string{"path"}),
 
-		activeWebsockets: promauto.NewGaugeVec(prometheus.GaugeOpts{
+		activeWebsockets: factory.NewGaugeVec(prometheus.GaugeOpts{
 			Name: "active_websockets",
 			Help: "Represents active websockets on the path",
 		}, []string{"path"}),
 
-		totalReads: promauto.NewCounterVec(prometheus.CounterOpts{
+		totalReads: factory.NewCounterVec(prometheus.CounterOpts{
 			Name: "total_reads",
 			Help: "Counts all reads requests by path",
 		}, []string{"path"}),
-		totalWrites: promauto.NewCounterVec(prometheus.CounterOpts{
+		totalWrites: factory.NewCounterVec(prometheus.CounterOpts{
 			Name: "total_writes",
 			Help: "Counts all writes requests by path",
 		}, []string{"path"}),
-	}
 
-	err := errors.Join(
-		prometheus.Register(metrics.totalRequests),
-		prometheus.Register(metrics.requestDuration),
-		prometheus.Register(metrics.requestsErrors),
-		prometheus.Register(metrics.activeWebsockets),
-		prometheus.Register(metrics.totalReads),
-		prometheus.Register(metrics.totalWrites),
-	)
-	if err != nil {
-		log.Printf("Cant register metrics: %v", err)
+		inFlightRequests: factory.NewGauge(prometheus.GaugeOpts{
+			Name: "in_flight_requests",
+			Help: "Represents requests that are being handled right now",
+		}),
+		drainStage: factory.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "drain_stage",
+			Help: "Is 1 while the shutdown stage is in progress",
+		}, []string{"stage"}),
+		drainStageDuration: factory.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "drain_stage_duration_seconds",
+			Help: "Keeps track of how long the finished shutdown stages have taken",
+		}, []string{"stage"}),
+
+		throttledRequests: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "throttled_requests_total",
+			Help: "Counts the requests rejected by the rate limits by scope",
+		}, []string{"scope"}),
 	}
-
-	return metrics
 }
 
 func redMetricsHandler(me *MetricsExporter) gin.HandlerFunc {
@@ -111,20 +122,50 @@ func readWriteMetrics(me *MetricsExporter) gin.HandlerFunc {
 	}
 }
 
-func setupRouter(r *gin.Engine, uc UseCases, ws *WebSocketHandler) {
+func inFlightMetrics(me *MetricsExporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		me.inFlightRequests.Inc()
+		defer me.inFlightRequests.Dec()
+		c.Next()
+	}
+}
+
+func setupRouter(r *gin.Engine, uc UseCases, ws *WebSocketHandler, settings *liveSettings, metrics *MetricsExporter) {
 	r.HandleMethodNotAllowed = true
+	// the usecases get the gin context, it must carry the span and the deadline of the request
+	r.ContextWithFallback = true
+	r.NoRoute(noRouteHandler)
+	r.NoMethod(noMethodHandler)
 
+	r.Use(otelgin.Middleware(tracing.ServiceName, otelgin.WithFilter(notProbe)))
+	r.Use(requestLogHandler())
 	r.Use(redMetricsHandler(metrics))
 	r.Use(readWriteMetrics(metrics))
+	r.Use(inFlightMetrics(metrics))
+	// the panic is recovered the last, so the metrics and the log see the 500
+	r.Use(recoveryHandler())
+	r.Use(rateLimitHandler(uc.RateLimit, settings, metrics))
 
+	v1 := r.Group(apiV1Prefix, specValidationHandler(openAPI, settings))
+	v1.GET("/openapi.json", setupGetSpecHandler(openAPI))
+	setupRoutes(v1, uc, ws, settings, metrics, false)
+
+	// the unversioned paths are kept as they were for the clients that have not moved to /api/v1 yet
+	setupRoutes(&r.RouterGroup, uc, ws, settings, metrics, true)
+}
+
+// setupRoutes registers the API on the group. Legacy routes wrap a single sensor into an array,
+// as the API did before it was versioned.
+func setupRoutes(r *gin.RouterGroup, uc UseCases, ws *WebSocketHandler, settings *liveSettings, metrics *MetricsExporter, legacy bool) {
 	r.POST("/events", setupPostEventHandler(uc))
 	r.OPTIONS("/events", setupOptionsEventHandler())
 	r.GET("/sensors", setupGetSensorHandler(uc))
 	r.HEAD("/sensors", setupHeadSensorHandler(uc))
-	r.POST("/sensors", setupPostSensorHandler(uc))
+	r.POST("/sensors", setupPostSensorHandler(uc, legacy))
 	r.OPTIONS("/sensors", setupOptionsSensorHandler())
-	r.GET("/sensors/:sensor_id", setupGetSensorIdHandler(uc))
-	r.HEAD("/sensors/:sensor_id", setupHeadSensorIdHandler(uc))
+	r.GET("/sensors/:sensor_id", setupGetSensorIdHandler(uc, legacy))
+	r.HEAD("/sensors/:sensor_id", setupHeadSensorIdHandler(uc, legacy))
+	r.PATCH("/sensors/:sensor_id", setupPatchSensorIdHandler(uc))
 	r.OPTIONS("/sensors/:sensor_id", setupOptionsSensorIdHandler())
 	r.OPTIONS("/users", setupOptionsUserHandler())
 	r.POST("/users", setupPostUserHandler(uc))
@@ -134,13 +175,65 @@ func setupRouter(r *gin.Engine, uc UseCases, ws *WebSocketHandler) {
 	r.GET("/users/:user_id/sensors", setupGetUserIdHandler(uc))
 	r.GET("/sensors/:sensor_id/events", setupGetSensorEventHandler(ws, metrics))
 	r.GET("/sensors/:sensor_id/history", setupGetSensorHistory(uc))
+	r.PUT("/sensors/:sensor_id/calibration", setupPutSensorCalibrationHandler(uc))
+	r.OPTIONS("/sensors/:sensor_id/calibration", setupOptionsSensorCalibrationHandler())
+	r.GET("/sensor-types", setupGetSensorTypesHandler(uc))
+	r.OPTIONS("/sensor-types", setupOptionsSensorTypesHandler())
+	r.GET("/sensor-types/:name", setupGetSensorTypeHandler(uc))
+	r.PUT("/sensor-types/:name", setupPutSensorTypeHandler(uc))
+	r.DELETE("/sensor-types/:name", setupDeleteSensorTypeHandler(uc))
+	r.OPTIONS("/sensor-types/:name", setupOptionsSensorTypeHandler())
+	r.GET("/actuators", setupGetActuatorsHandler(uc))
+	r.POST("/actuators", setupPostActuatorHandler(uc))
+	r.OPTIONS("/actuators", setupOptionsActuatorsHandler())
+	r.GET("/actuators/:actuator_id", setupGetActuatorHandler(uc))
+	r.OPTIONS("/actuators/:actuator_id", setupOptionsActuatorHandler())
+	r.POST("/actuators/:actuator_id/commands", setupPostCommandHandler(uc))
+	r.OPTIONS("/actuators/:actuator_id/commands", setupOptionsCommandsHandler())
+	r.GET("/actuators/:actuator_id/commands/pending", setupGetPendingCommandsHandler(uc))
+	r.GET("/actuators/:actuator_id/commands/stream", setupGetCommandStreamHandler(ws, metrics))
+	r.GET("/actuators/:actuator_id/commands/:command_id", setupGetCommandHandler(uc))
+	r.POST("/actuators/:actuator_id/commands/:command_id/ack", setupPostCommandAckHandler(uc))
+	r.GET("/scenes", setupGetScenesHandler(uc))
+	r.POST("/scenes", setupPostSceneHandler(uc))
+	r.OPTIONS("/scenes", setupOptionsScenesHandler())
+	r.GET("/scenes/:scene_id", setupGetSceneHandler(uc))
+	r.PUT("/scenes/:scene_id", setupPutSceneHandler(uc))
+	r.DELETE("/scenes/:scene_id", setupDeleteSceneHandler(uc))
+	r.OPTIONS("/scenes/:scene_id", setupOptionsSceneHandler())
+	r.POST("/scenes/:scene_id/trigger", setupPostSceneTriggerHandler(uc))
+	r.OPTIONS("/scenes/:scene_id/trigger", setupOptionsSceneTriggerHandler())
+	r.GET("/schedules", setupGetSchedulesHandler(uc))
+	r.POST("/schedules", setupPostScheduleHandler(uc))
+	r.OPTIONS("/schedules", setupOptionsSchedulesHandler())
+	r.GET("/schedules/:schedule_id", setupGetScheduleHandler(uc))
+	r.DELETE("/schedules/:schedule_id", setupDeleteScheduleHandler(uc))
+	r.OPTIONS("/schedules/:schedule_id", setupOptionsScheduleHandler())
+	r.GET("/changes", setupGetChangesHandler(uc))
+	r.OPTIONS("/changes", setupOptionsChangesHandler())
+	r.GET("/changes/stream", setupGetChangeStreamHandler(uc, ws, metrics))
+	r.GET("/changes/consumers/:name", setupGetChangeConsumerHandler(uc))
+	r.PUT("/changes/consumers/:name", setupPutChangeConsumerHandler(uc))
+	r.OPTIONS("/changes/consumers/:name", setupOptionsChangeConsumerHandler())
+
+	exports := r.Group("/exports", featureHandler(settings, func(s Settings) bool { return s.Exports }))
+	exports.POST("", setupPostExportHandler(uc))
+	exports.OPTIONS("", setupOptionsExportHandler())
+	exports.GET("/:export_id", setupGetExportHandler(uc))
+	exports.GET("/:export_id/file", setupGetExportFileHandler(uc))
+
+	imports := r.Group("/imports", featureHandler(settings, func(s Settings) bool { return s.Imports }))
+	imports.POST("/sensors", setupPostImportSensorsHandler(uc))
+	imports.OPTIONS("/sensors", setupOptionsImportHandler())
+	imports.POST("/events", setupPostImportEventsHandler(uc))
+	imports.OPTIONS("/events", setupOptionsImportHandler())
 }
 
 func setupGetSensorEventHandler(ws *WebSocketHandler, me *MetricsExporter) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		id, err := strconv.ParseInt(ctx.Param("sensor_id"), 10, 64)
 		if err != nil {
-			ctx.AbortWithStatus(http.StatusUnprocessableEntity)
+			abortInvalidID(ctx, "sensor_id")
 			return
 		}
 
@@ -149,11 +242,7 @@ func setupGetSensorEventHandler(ws *WebSocketHandler, me *MetricsExporter) gin.H
 		defer gauge.Dec()
 
 		if err := ws.Handle(ctx, id); err != nil {
-			if errors.Is(err, usecase.ErrSensorNotFound) {
-				ctx.AbortWithStatus(http.StatusNotFound)
-			} else {
-				ctx.AbortWithStatus(http.StatusInternalServerError)
-			}
+			abortWithError(ctx, err)
 		}
 	}
 }
@@ -172,39 +261,43 @@ func parseQueryTimestamp(ctx *gin.Context, query string) (time.Time, bool) {
 
 func setupGetSensorHistory(uc UseCases) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		if !checkAccept(ctx) {
+		format, ok := negotiateHistoryFormat(ctx)
+		if !ok {
 			return
 		}
 
 		id, err := strconv.ParseInt(ctx.Param("sensor_id"), 10, 64)
 		if err != nil {
-			ctx.AbortWithStatus(http.StatusUnprocessableEntity)
+			abortInvalidID(ctx, "sensor_id")
 			return
 		}
 
 		from, ok1 := parseQueryTimestamp(ctx, "start_date")
 		to, ok2 := parseQueryTimestamp(ctx, "end_date")
 		if !ok1 || !ok2 {
-			ctx.AbortWithStatus(http.StatusBadRequest)
+			abortWithProblem(ctx, http.StatusBadRequest, codeInvalidQuery, "start_date and end_date must be non-negative unix timestamps")
+			return
+		}
+
+		channel := ctx.Query("channel")
+
+		if format != mimeJSON {
+			if err := streamHistory(ctx, uc, format, id, from, to, channel); err != nil {
+				abortWithError(ctx, err)
+			}
 			return
 		}
 
 		events, err := uc.Event.GetHistoryBySensorID(ctx, id, from, to)
 		if err != nil {
-			if errors.Is(err, usecase.ErrEventNotFound) {
-				ctx.AbortWithStatus(http.StatusNotFound)
-			} else {
-				ctx.AbortWithStatus(http.StatusInternalServerError)
-			}
+			abortWithError(ctx, err)
 			return
 		}
 
-		dtos := make([]models.HistoryEvent, len(events))
-		for i, it := range events {
-			unixTime := it.Timestamp.Unix()
-			dtos[i] = models.HistoryEvent{
-				Timestamp: &unixTime,
-				Payload:   &it.Payload,
+		dtos := make([]models.HistoryEvent, 0, len(events))
+		for _, it := range events {
+			if event, ok := selectChannel(it, channel); ok {
+				dtos = append(dtos, historyEventDto(event))
 			}
 		}
 		ctx.JSON(http.StatusOK, dtos)
@@ -212,16 +305,23 @@ func setupGetSensorHistory(uc UseCases) gin.HandlerFunc {
 }
 
 func checkAccept(ctx *gin.Context) bool {
-	if ctx.GetHeader("Accept") != "application/json" {
-		ctx.AbortWithStatus(http.StatusNotAcceptable)
-		return false
+	_, ok := negotiateOrAbort(ctx, mimeJSON)
+	return ok
+}
+
+// negotiateOrAbort picks one of the offers by the Accept header or responds with 406
+func negotiateOrAbort(ctx *gin.Context, offers ...string) (string, bool) {
+	format, ok := negotiate(ctx.GetHeader("Accept"), offers)
+	if !ok {
+		abortWithProblem(ctx, http.StatusNotAcceptable, codeNotAcceptable, "acceptable formats: "+strings.Join(offers, ", "))
+		return "", false
 	}
-	return true
+	return format, true
 }
 
 func checkContentType(ctx *gin.Context) bool {
-	if ctx.GetHeader("Content-Type") != "application/json" {
-		ctx.AbortWithStatus(http.StatusUnsupportedMediaType)
+	if !isMediaType(ctx.GetHeader("Content-Type"), mimeJSON) {
+		abortWithProblem(ctx, http.StatusUnsupportedMediaType, codeUnsupportedMediaType, "request body must be "+mimeJSON)
 		return false
 	}
 	return true
@@ -233,15 +333,19 @@ func getSensorsDto(items ...domain.Sensor) []models.Sensor {
 	for i, it := range items {
 		item := it
 		name := string(item.Type)
+		state := item.CurrentState.Int()
 		itemsDto[i] = models.Sensor{
-			CurrentState: &item.CurrentState,
-			Description:  &item.Description,
-			ID:           &item.ID,
-			IsActive:     &item.IsActive,
-			LastActivity: (*strfmt.DateTime)(&item.LastActivity),
-			RegisteredAt: (*strfmt.DateTime)(&item.RegisteredAt),
-			SerialNumber: &item.SerialNumber,
-			Type:         &name,
+			CurrentState:    &state,
+			CurrentReadings: readingsDto(item.CurrentState),
+			Calibration:     calibrationDto(item.Calibration),
+			Description:     &item.Description,
+			Expression:      item.Expression,
+			ID:              &item.ID,
+			IsActive:        &item.IsActive,
+			LastActivity:    (*strfmt.DateTime)(&item.LastActivity),
+			RegisteredAt:    (*strfmt.DateTime)(&item.RegisteredAt),
+			SerialNumber:    &item.SerialNumber,
+			Type:            &name,
 		}
 	}
 	return itemsDto
@@ -254,7 +358,7 @@ func setupGetSensorHandler(uc UseCases) gin.HandlerFunc {
 		}
 		items, err := uc.Sensor.GetSensors(ctx)
 		if err != nil {
-			ctx.AbortWithStatus(http.StatusInternalServerError)
+			abortWithError(ctx, err)
 			return
 		}
 
@@ -262,26 +366,50 @@ func setupGetSensorHandler(uc UseCases) gin.HandlerFunc {
 	}
 }
 
-func setupGetSensorIdHandler(uc UseCases) gin.HandlerFunc {
+func setupGetSensorIdHandler(uc UseCases, legacy bool) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		if !checkAccept(ctx) {
 			return
 		}
 		id, err := strconv.ParseInt(ctx.Param("sensor_id"), 10, 64)
 		if err != nil {
-			ctx.AbortWithStatus(http.StatusUnprocessableEntity)
+			abortInvalidID(ctx, "sensor_id")
 			return
 		}
 		s, err := uc.Sensor.GetSensorByID(ctx, id)
 		if err != nil {
-			if errors.Is(err, usecase.ErrSensorNotFound) {
-				ctx.AbortWithStatus(http.StatusNotFound)
-			} else {
-				ctx.AbortWithStatus(http.StatusInternalServerError)
-			}
+			abortWithError(ctx, err)
+			return
+		}
+		if legacy {
+			ctx.JSON(http.StatusOK, getSensorsDto(*s))
 			return
 		}
-		ctx.JSON(http.StatusOK, getSensorsDto(*s))
+		ctx.JSON(http.StatusOK, getSensorsDto(*s)[0])
+	}
+}
+
+// setupPatchSensorIdHandler changes the fields present in the body, e.g. deactivates the sensor
+func setupPatchSensorIdHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkContentType(ctx) {
+			return
+		}
+		id, err := strconv.ParseInt(ctx.Param("sensor_id"), 10, 64)
+		if err != nil {
+			abortInvalidID(ctx, "sensor_id")
+			return
+		}
+		p := models.SensorToUpdate{}
+		if !bindAndValidate(ctx, &p) {
+			return
+		}
+		s, err := uc.Sensor.UpdateSensor(ctx, id, domain.SensorPatch{Description: p.Description, IsActive: p.IsActive})
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, getSensorsDto(*s)[0])
 	}
 }
 
@@ -292,16 +420,12 @@ func setupGetUserIdHandler(uc UseCases) gin.HandlerFunc {
 		}
 		id, err := strconv.ParseInt(ctx.Param("user_id"), 10, 64)
 		if err != nil {
-			ctx.AbortWithStatus(http.StatusUnprocessableEntity)
+			abortInvalidID(ctx, "user_id")
 			return
 		}
 		items, err := uc.User.GetUserSensors(ctx, id)
 		if err != nil {
-			if errors.Is(err, usecase.ErrUserNotFound) {
-				ctx.AbortWithStatus(http.StatusNotFound)
-			} else {
-				ctx.AbortWithStatus(http.StatusInternalServerError)
-			}
+			abortWithError(ctx, err)
 			return
 		}
 		ctx.JSON(http.StatusOK, getSensorsDto(items...))
@@ -313,7 +437,7 @@ func setContentLength(ctx *gin.Context, items ...domain.Sensor) {
 	for _, item := range getSensorsDto(items...) {
 		e := json.NewEncoder(cW).Encode(item)
 		if e != nil {
-			ctx.AbortWithStatus(http.StatusInternalServerError)
+			abortWithError(ctx, e)
 			return
 		}
 	}
@@ -322,6 +446,18 @@ func setContentLength(ctx *gin.Context, items ...domain.Sensor) {
 	ctx.Status(http.StatusOK)
 }
 
+// setContentLengthOf sets the length of the body GET would respond with
+func setContentLengthOf(ctx *gin.Context, dto any) {
+	body, err := json.Marshal(dto)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.Header("Content-Length", strconv.Itoa(len(body)))
+	ctx.Status(http.StatusOK)
+}
+
 func setupHeadSensorHandler(uc UseCases) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		if !checkAccept(ctx) {
@@ -329,33 +465,33 @@ func setupHeadSensorHandler(uc UseCases) gin.HandlerFunc {
 		}
 		items, err := uc.Sensor.GetSensors(ctx)
 		if err != nil {
-			ctx.AbortWithStatus(http.StatusInternalServerError)
+			abortWithError(ctx, err)
 			return
 		}
 		setContentLength(ctx, items...)
 	}
 }
 
-func setupHeadSensorIdHandler(uc UseCases) gin.HandlerFunc {
+func setupHeadSensorIdHandler(uc UseCases, legacy bool) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		if !checkAccept(ctx) {
 			return
 		}
 		id, err := strconv.ParseInt(ctx.Param("sensor_id"), 10, 64)
 		if err != nil {
-			ctx.AbortWithStatus(http.StatusUnprocessableEntity)
+			abortInvalidID(ctx, "sensor_id")
 			return
 		}
 		s, err := uc.Sensor.GetSensorByID(ctx, id)
 		if err != nil {
-			if errors.Is(err, usecase.ErrSensorNotFound) {
-				ctx.AbortWithStatus(http.StatusNotFound)
-			} else {
-				ctx.AbortWithStatus(http.StatusInternalServerError)
-			}
+			abortWithError(ctx, err)
 			return
 		}
-		setContentLength(ctx, *s)
+		if legacy {
+			setContentLength(ctx, *s)
+			return
+		}
+		setContentLengthOf(ctx, getSensorsDto(*s)[0])
 	}
 }
 
@@ -366,16 +502,12 @@ func setupHeadUserIdHandler(uc UseCases) gin.HandlerFunc {
 		}
 		id, err := strconv.ParseInt(ctx.Param("user_id"), 10, 64)
 		if err != nil {
-			ctx.AbortWithStatus(http.StatusUnprocessableEntity)
+			abortInvalidID(ctx, "user_id")
 			return
 		}
 		items, err := uc.User.GetUserSensors(ctx, id)
 		if err != nil {
-			if errors.Is(err, usecase.ErrUserNotFound) {
-				ctx.AbortWithStatus(http.StatusNotFound)
-			} else {
-				ctx.AbortWithStatus(http.StatusInternalServerError)
-			}
+			abortWithError(ctx, err)
 			return
 		}
 		setContentLength(ctx, items...)
@@ -383,18 +515,20 @@ func setupHeadUserIdHandler(uc UseCases) gin.HandlerFunc {
 }
 
 type validatable interface {
-	*models.SensorEvent | *models.SensorToCreate | *models.UserToCreate | *models.SensorToUserBinding
+	*models.SensorEvent | *models.SensorToCreate | *models.UserToCreate | *models.SensorToUserBinding | *models.ExportToCreate |
+		*models.SensorTypeToSave | *models.SensorCalibration | *models.ActuatorToCreate | *models.CommandToSend | *models.CommandAck |
+		*models.SceneToSave | *models.ScheduleToCreate | *models.SensorToUpdate | *models.ChangeCursor
 	Validate(formats strfmt.Registry) error
 }
 
 func bindAndValidate[T validatable](ctx *gin.Context, item T) bool {
 	if err := ctx.ShouldBindJSON(item); err != nil {
-		ctx.AbortWithStatus(http.StatusBadRequest)
+		abortWithProblem(ctx, http.StatusBadRequest, codeMalformedBody, err.Error())
 		return false
 	}
 
 	if err := item.Validate(nil); err != nil {
-		ctx.AbortWithStatus(http.StatusUnprocessableEntity)
+		abortWithProblem(ctx, http.StatusUnprocessableEntity, codeValidationFailed, "request body is invalid", validationDetails(err)...)
 		return false
 	}
 	return true
@@ -409,17 +543,44 @@ func setupPostEventHandler(uc UseCases) gin.HandlerFunc {
 		if !bindAndValidate(ctx, &e) {
 			return
 		}
+		payload, err := payloadFromDto(&e)
+		if err != nil {
+			field, message := "readings", err.Error()
+			abortWithProblem(ctx, http.StatusUnprocessableEntity, codeValidationFailed, "request body is invalid",
+				&models.ValidationError{Field: &field, Message: &message})
+			return
+		}
 
-		newEvent := domain.Event{SensorSerialNumber: *e.SensorSerialNumber, Payload: *e.Payload, Timestamp: time.Now()}
-		if err := uc.Event.ReceiveEvent(ctx, &newEvent); err != nil {
-			ctx.AbortWithStatus(http.StatusInternalServerError)
-		} else {
-			ctx.Status(http.StatusCreated)
+		receive := func() {
+			newEvent := domain.Event{SensorSerialNumber: *e.SensorSerialNumber, Payload: payload, Timestamp: time.Now()}
+			if e.Timestamp != nil {
+				newEvent.Received, newEvent.Timestamp = newEvent.Timestamp, time.Time(*e.Timestamp)
+			}
+			if err := uc.Event.ReceiveEvent(ctx, &newEvent); err != nil {
+				abortWithError(ctx, err)
+			} else {
+				ctx.Status(http.StatusCreated)
+			}
+		}
+
+		key := ctx.GetHeader(headerIdempotencyKey)
+		if key == "" {
+			key = e.ID
 		}
+		if key == "" || uc.Idempotency == nil {
+			receive()
+			return
+		}
+		// the keys of different sensors do not clash, a gateway may number the events of every sensor from 1
+		fingerprint := *e.SensorSerialNumber + ":" + payload.String()
+		if e.Timestamp != nil {
+			fingerprint += "@" + e.Timestamp.String()
+		}
+		idempotent(ctx, uc.Idempotency, "events:"+*e.SensorSerialNumber+":", key, fingerprint, receive)
 	}
 }
 
-func setupPostSensorHandler(uc UseCases) gin.HandlerFunc {
+func setupPostSensorHandler(uc UseCases, legacy bool) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		if !checkContentType(ctx) {
 			return
@@ -430,13 +591,18 @@ func setupPostSensorHandler(uc UseCases) gin.HandlerFunc {
 		}
 		newItem := domain.Sensor{
 			SerialNumber: *e.SerialNumber, Description: *e.Description,
-			IsActive: *e.IsActive, Type: domain.SensorType(*e.Type),
+			IsActive: *e.IsActive, Type: domain.SensorType(*e.Type), Expression: e.Expression,
 		}
-		if item, err := uc.Sensor.RegisterSensor(ctx, &newItem); err != nil {
-			ctx.AbortWithStatus(http.StatusInternalServerError)
-		} else {
+		item, err := uc.Sensor.RegisterSensor(ctx, &newItem)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		if legacy {
 			ctx.JSON(http.StatusOK, getSensorsDto(*item))
+			return
 		}
+		ctx.JSON(http.StatusOK, getSensorsDto(*item)[0])
 	}
 }
 
@@ -451,7 +617,7 @@ func setupPostUserHandler(uc UseCases) gin.HandlerFunc {
 		}
 		newItem := domain.User{Name: *e.Name}
 		if u, err := uc.User.RegisterUser(ctx, &newItem); err != nil {
-			ctx.AbortWithStatus(http.StatusInternalServerError)
+			abortWithError(ctx, err)
 		} else {
 			ctx.JSON(http.StatusOK, models.User{
 				ID:   &u.ID,
@@ -468,7 +634,7 @@ func setupPostUserIdHandler(uc UseCases) gin.HandlerFunc {
 		}
 		userId, err := strconv.ParseInt(ctx.Param("user_id"), 10, 64)
 		if err != nil {
-			ctx.AbortWithStatus(http.StatusUnprocessableEntity)
+			abortInvalidID(ctx, "user_id")
 			return
 		}
 		e := models.SensorToUserBinding{}
@@ -478,11 +644,7 @@ func setupPostUserIdHandler(uc UseCases) gin.HandlerFunc {
 
 		err = uc.User.AttachSensorToUser(ctx, userId, *e.SensorID)
 		if err != nil {
-			if errors.Is(err, usecase.ErrUserNotFound) || errors.Is(err, usecase.ErrSensorNotFound) {
-				ctx.AbortWithStatus(http.StatusNotFound)
-			} else {
-				ctx.AbortWithStatus(http.StatusInternalServerError)
-			}
+			abortWithError(ctx, err)
 			return
 		}
 		ctx.Status(http.StatusCreated)
@@ -505,7 +667,7 @@ func setupOptionsSensorHandler() gin.HandlerFunc {
 
 func setupOptionsSensorIdHandler() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		ctx.Header("Allow", strings.Join([]string{http.MethodOptions, http.MethodGet, http.MethodHead}, ","))
+		ctx.Header("Allow", strings.Join([]string{http.MethodOptions, http.MethodGet, http.MethodHead, http.MethodPatch}, ","))
 		ctx.Status(http.StatusNoContent)
 	}
 }
@@ -2,19 +2,25 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"homework/internal/domain"
+	checkpointRepository "homework/internal/repository/checkpoint/inmemory"
 	eventRepository "homework/internal/repository/event/inmemory"
 	sensorRepository "homework/internal/repository/sensor/inmemory"
 	userRepository "homework/internal/repository/user/inmemory"
 	"homework/internal/usecase"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -29,12 +35,18 @@ var useCases = UseCases{
 	Event:  usecase.NewEvent(er, sr),
 	Sensor: usecase.NewSensor(sr),
 	User:   usecase.NewUser(ur, sor, sr),
+	Export: usecase.NewExport(er, sr, os.TempDir()),
+	Import: usecase.NewImport(sr, er, checkpointRepository.NewCheckpointRepository()),
 }
 
 var router = gin.Default()
 
+var testMetrics = NewMetricsExporter(prometheus.NewRegistry())
+
 func init() {
-	setupRouter(router, useCases, NewWebSocketHandler(useCases))
+	setupRouter(router, useCases, NewWebSocketHandler(useCases), newLiveSettings(Settings{
+		Validation: SpecValidation{Requests: true, Responses: true}, Exports: true, Imports: true,
+	}), testMetrics)
 }
 
 // Все неизвестные пути должны возвращать http.StatusNotFound.
@@ -410,6 +422,7 @@ func TestSensorsRoutes(t *testing.T) {
 		assert.Contains(t, allowed, http.MethodOptions, "В разрешённых методах нет OPTIONS")
 		assert.Contains(t, allowed, http.MethodGet, "В разрешённых методах нет GET")
 		assert.Contains(t, allowed, http.MethodHead, "В разрешённых методах нет HEAD")
+		assert.Contains(t, allowed, http.MethodPatch, "В разрешённых методах нет PATCH")
 	})
 
 	// Другие методы не поддерживаем.
@@ -422,7 +435,6 @@ func TestSensorsRoutes(t *testing.T) {
 			{http.MethodPost, http.MethodPost, http.StatusMethodNotAllowed},
 			{http.MethodPut, http.MethodPut, http.StatusMethodNotAllowed},
 			{http.MethodDelete, http.MethodDelete, http.StatusMethodNotAllowed},
-			{http.MethodPatch, http.MethodPatch, http.StatusMethodNotAllowed},
 			{http.MethodConnect, http.MethodConnect, http.StatusMethodNotAllowed},
 			{http.MethodTrace, http.MethodTrace, http.StatusMethodNotAllowed},
 		}
@@ -748,6 +760,46 @@ func TestSensorsHistory(t *testing.T) {
 		assert.True(t, json.Valid(w.Body.Bytes()), "В ответе не json")
 	})
 
+	t.Run("success_csv_200", func(t *testing.T) {
+		w := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/sensors/1/history?start_date=0&end_date="+strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10), nil)
+		req.Header.Add("Accept", "text/csv")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		assert.Equal(t, "timestamp,payload,value,unit", lines[0], "В ответе нет заголовка csv")
+		assert.Greater(t, len(lines), 1, "В ответе нет событий")
+	})
+
+	t.Run("success_ndjson_200", func(t *testing.T) {
+		w := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/sensors/1/history?start_date=0&end_date="+strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10), nil)
+		req.Header.Add("Accept", "application/x-ndjson")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		assert.NotEmpty(t, lines, "В ответе нет событий")
+		for _, line := range lines {
+			assert.True(t, json.Valid([]byte(line)), "Строка ответа не json")
+		}
+	})
+
+	t.Run("sensor_without_events_csv_404", func(t *testing.T) {
+		w := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/sensors/100500/history?start_date=0&end_date=1", nil)
+		req.Header.Add("Accept", "text/csv")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Получили в ответ не тот код")
+	})
+
 	t.Run("requested_unsupported_body_format_406", func(t *testing.T) {
 		w := httptest.NewRecorder()
 
@@ -827,3 +879,411 @@ func TestSensorsHistory(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, w.Code, "Получили в ответ не тот код")
 	})
 }
+
+// Тесты /exports
+func TestExportsRoutes(t *testing.T) {
+	t.Run("POST_GET_exports_200", func(t *testing.T) {
+		w := httptest.NewRecorder()
+
+		body := `{
+			"sensor_ids": [1],
+			"start_date": 0,
+			"end_date": ` + strconv.FormatInt(time.Now().Unix(), 10) + `
+		}`
+		req, _ := http.NewRequest(http.MethodPost, "/exports", bytes.NewReader([]byte(body)))
+		req.Header.Add("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusAccepted, w.Code, "Получили в ответ не тот код")
+		export := struct {
+			ID int64 `json:"id"`
+		}{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &export))
+		useCases.Export.Wait()
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest(http.MethodGet, "/exports/"+strconv.FormatInt(export.ID, 10), nil)
+		req.Header.Add("Accept", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+		assert.Contains(t, w.Body.String(), `"status":"completed"`)
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest(http.MethodGet, "/exports/"+strconv.FormatInt(export.ID, 10)+"/file", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, "application/vnd.apache.parquet", w.Header().Get("Content-Type"))
+		assert.True(t, bytes.HasPrefix(w.Body.Bytes(), []byte("PAR1")), "В ответе не parquet")
+	})
+
+	t.Run("POST_exports_unknown_sensor_404", func(t *testing.T) {
+		w := httptest.NewRecorder()
+
+		body := `{"sensor_ids": [100500], "start_date": 0, "end_date": 1}`
+		req, _ := http.NewRequest(http.MethodPost, "/exports", bytes.NewReader([]byte(body)))
+		req.Header.Add("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Получили в ответ не тот код")
+	})
+
+	t.Run("POST_exports_invalid_data_422", func(t *testing.T) {
+		tests := []struct {
+			name string
+			body string
+		}{
+			{"no_sensors", `{"sensor_ids": [], "start_date": 0, "end_date": 1}`},
+			{"negative_date", `{"sensor_ids": [1], "start_date": -1, "end_date": 1}`},
+			{"reversed_range", `{"sensor_ids": [1], "start_date": 2, "end_date": 1}`},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				req, _ := http.NewRequest(http.MethodPost, "/exports", bytes.NewReader([]byte(tt.body)))
+				req.Header.Add("Content-Type", "application/json")
+				router.ServeHTTP(w, req)
+
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "Получили в ответ не тот код")
+			})
+		}
+	})
+
+	t.Run("GET_exports_export_id_404", func(t *testing.T) {
+		w := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/exports/100500", nil)
+		req.Header.Add("Accept", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Получили в ответ не тот код")
+	})
+
+	t.Run("GET_exports_export_id_file_404", func(t *testing.T) {
+		w := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodGet, "/exports/100500/file", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Получили в ответ не тот код")
+	})
+
+	t.Run("OPTIONS_exports_204", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodOptions, "/exports", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code, "Получили в ответ не тот код")
+		allowed := strings.Split(w.Header().Get("Allow"), ",")
+		assert.Contains(t, allowed, http.MethodPost, "В разрешённых методах нет POST")
+	})
+}
+
+// Тесты /imports
+func TestImportsRoutes(t *testing.T) {
+	t.Run("POST_imports_sensors_and_events_200", func(t *testing.T) {
+		w := httptest.NewRecorder()
+
+		body := "serial_number,type,description,is_active\n" +
+			"5550000001,adc,imported,true\n" +
+			"5550000002,cc,imported,true\n"
+		req, _ := http.NewRequest(http.MethodPost, "/imports/sensors", strings.NewReader(body))
+		req.Header.Add("Content-Type", "text/csv")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+		assert.JSONEq(t, `{"processed":2,"imported":2,"skipped":0}`, w.Body.String())
+
+		w = httptest.NewRecorder()
+		body = `{"sensor_serial_number":"5550000001","timestamp":1000,"payload":1}` + "\n" +
+			`{"sensor_serial_number":"5550000001","timestamp":1000,"payload":1}` + "\n" +
+			`{"sensor_serial_number":"5550000002","timestamp":"1970-01-01T00:20:00Z","payload":0}` + "\n"
+		req, _ = http.NewRequest(http.MethodPost, "/imports/events", strings.NewReader(body))
+		req.Header.Add("Content-Type", "application/x-ndjson")
+		req.Header.Add("Import-ID", "router-test")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+		assert.JSONEq(t, `{"processed":3,"imported":2,"skipped":1}`, w.Body.String())
+
+		// the same import is resumed and nothing is written twice
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest(http.MethodPost, "/imports/events", strings.NewReader(body))
+		req.Header.Add("Content-Type", "application/x-ndjson")
+		req.Header.Add("Import-ID", "router-test")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+		assert.JSONEq(t, `{"processed":3,"imported":0,"skipped":3}`, w.Body.String())
+	})
+
+	t.Run("request_body_has_unsupported_format_415", func(t *testing.T) {
+		w := httptest.NewRecorder()
+
+		req, _ := http.NewRequest(http.MethodPost, "/imports/sensors", strings.NewReader("{}"))
+		req.Header.Add("Content-Type", "application/xml")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code, "Получили в ответ не тот код")
+	})
+
+	t.Run("request_body_has_invalid_data_422", func(t *testing.T) {
+		tests := []struct {
+			name string
+			path string
+			body string
+		}{
+			{"missing_column", "/imports/sensors", "serial_number,type\n"},
+			{"wrong_type", "/imports/sensors", "serial_number,type,description,is_active\n5550000003,abc,d,true\n"},
+			{"wrong_serial", "/imports/events", "sensor_serial_number,timestamp,payload\n555,1,1\n"},
+			{"unknown_sensor", "/imports/events", "sensor_serial_number,timestamp,payload\n5559999999,1,1\n"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				req, _ := http.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+				req.Header.Add("Content-Type", "text/csv")
+				router.ServeHTTP(w, req)
+
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "Получили в ответ не тот код")
+			})
+		}
+	})
+
+	t.Run("OPTIONS_imports_204", func(t *testing.T) {
+		for _, path := range []string{"/imports/sensors", "/imports/events"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodOptions, path, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusNoContent, w.Code, "Получили в ответ не тот код")
+			allowed := strings.Split(w.Header().Get("Allow"), ",")
+			assert.Contains(t, allowed, http.MethodPost, "В разрешённых методах нет POST")
+		}
+	})
+}
+
+// Тесты согласования формата и тела ошибок
+func TestContentNegotiation(t *testing.T) {
+	t.Run("GET_sensors_accept", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			accept string
+			want   int
+		}{
+			{"missing_accept_200", "", http.StatusOK},
+			{"any_200", "*/*", http.StatusOK},
+			{"list_with_q_200", "text/html;q=0.9, application/json;q=0.8", http.StatusOK},
+			{"charset_utf8_200", "application/json; charset=utf-8", http.StatusOK},
+			{"json_refused_406", "application/json;q=0, */*", http.StatusNotAcceptable},
+			{"other_charset_406", "application/json; charset=latin1", http.StatusNotAcceptable},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				req, _ := http.NewRequest(http.MethodGet, "/sensors", nil)
+				if tt.accept != "" {
+					req.Header.Add("Accept", tt.accept)
+				}
+				router.ServeHTTP(w, req)
+
+				assert.Equal(t, tt.want, w.Code, "Получили в ответ не тот код")
+			})
+		}
+	})
+
+	t.Run("GET_sensors_history_picks_csv_by_wildcard", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/sensors/100500/history?start_date=0&end_date=1", nil)
+		req.Header.Add("Accept", "text/*, application/json;q=0.5")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, mimeProblem, w.Header().Get("Content-Type"))
+	})
+
+	t.Run("POST_users_content_type", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			contentType string
+			want        int
+		}{
+			{"charset_utf8_200", "application/json; charset=utf-8", http.StatusOK},
+			{"charset_upper_case_200", "Application/JSON; charset=UTF-8", http.StatusOK},
+			{"other_charset_415", "application/json; charset=latin1", http.StatusUnsupportedMediaType},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				req, _ := http.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name": "Пользователь"}`))
+				req.Header.Add("Content-Type", tt.contentType)
+				router.ServeHTTP(w, req)
+
+				assert.Equal(t, tt.want, w.Code, "Получили в ответ не тот код")
+			})
+		}
+	})
+}
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+	assert.Equal(t, mimeProblem, w.Header().Get("Content-Type"), "Ошибка не в формате problem+json")
+	var p map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &p), "В ответе не json")
+	return p
+}
+
+func TestProblemResponses(t *testing.T) {
+	t.Run("not_found_has_code", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/sensors/100500", nil)
+		req.Header.Add("Accept", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code, "Получили в ответ не тот код")
+		p := decodeProblem(t, w)
+		assert.Equal(t, codeSensorNotFound, p["code"])
+		assert.Equal(t, float64(http.StatusNotFound), p["status"])
+		assert.Equal(t, "/sensors/100500", p["instance"])
+		assert.NotEmpty(t, p["reason"])
+	})
+
+	t.Run("validation_has_field_details", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		body := `{"serial_number": "12", "type": "Abc", "description": "d", "is_active": true}`
+		req, _ := http.NewRequest(http.MethodPost, "/sensors", strings.NewReader(body))
+		req.Header.Add("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "Получили в ответ не тот код")
+		p := decodeProblem(t, w)
+		assert.Equal(t, codeValidationFailed, p["code"])
+		errs, _ := p["errors"].([]any)
+		fields := make([]any, 0, len(errs))
+		for _, e := range errs {
+			fields = append(fields, e.(map[string]any)["field"])
+		}
+		assert.ElementsMatch(t, []any{"serial_number", "type"}, fields, "Не те поля в ошибке валидации")
+	})
+
+	t.Run("unsupported_media_type_has_code", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/events", strings.NewReader(`{}`))
+		req.Header.Add("Content-Type", "text/plain")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, codeUnsupportedMediaType, decodeProblem(t, w)["code"])
+	})
+
+	t.Run("unknown_route_has_code", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/unknown", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, codeRouteNotFound, decodeProblem(t, w)["code"])
+	})
+
+	t.Run("wrong_method_has_code", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/sensors", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, codeMethodNotAllowed, decodeProblem(t, w)["code"])
+	})
+}
+
+func TestFeatureToggles(t *testing.T) {
+	settings := newLiveSettings(Settings{Exports: false, Imports: true})
+	r := gin.New()
+	setupRouter(r, useCases, NewWebSocketHandler(useCases), settings, testMetrics)
+
+	request := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/exports/100500", nil)
+		req.Header.Add("Accept", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request()
+	assert.Equal(t, http.StatusNotFound, w.Code, "Получили в ответ не тот код")
+	assert.Equal(t, codeFeatureDisabled, decodeProblem(t, w)["code"])
+
+	settings.set(Settings{Exports: true, Imports: true})
+	w = request()
+	assert.Equal(t, http.StatusNotFound, w.Code, "Получили в ответ не тот код")
+	assert.Equal(t, codeExportNotFound, decodeProblem(t, w)["code"], "Выгрузки должны включиться без перезапуска")
+}
+
+func TestMetricsRegistry(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	r := gin.New()
+	setupRouter(r, useCases, NewWebSocketHandler(useCases), newLiveSettings(DefaultSettings), NewMetricsExporter(reg))
+
+	for range 2 {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/sensors", nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+	}
+
+	expected := `
+# HELP total_requests Counts all requests by path
+# TYPE total_requests counter
+total_requests{path="/api/v1/sensors"} 2
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "total_requests"))
+}
+
+func TestInactiveSensorEvents(t *testing.T) {
+	_, err := useCases.Sensor.RegisterSensor(context.Background(), &domain.Sensor{
+		SerialNumber: "5550000099", Type: domain.SensorTypeADC, Description: "выключен", IsActive: false,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	w := httptest.NewRecorder()
+	body := `{"sensor_serial_number": "5550000099", "payload": 10}`
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/events", strings.NewReader(body))
+	req.Header.Add("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "Получили в ответ не тот код")
+	assert.Equal(t, codeSensorInactive, decodeProblem(t, w)["code"])
+}
+
+func TestDeviceTimeEvents(t *testing.T) {
+	s, err := useCases.Sensor.RegisterSensor(context.Background(), &domain.Sensor{
+		SerialNumber: "5550000098", Type: domain.SensorTypeADC, Description: "со своими часами", IsActive: true,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/events", strings.NewReader(body))
+		req.Header.Add("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("ok, time of the device", func(t *testing.T) {
+		w := post(`{"sensor_serial_number": "5550000098", "payload": 10, "timestamp": "2024-05-01T10:00:00Z"}`)
+		assert.Equal(t, http.StatusCreated, w.Code, "Получили в ответ не тот код")
+
+		event, err := er.GetLastEventBySensorID(context.Background(), s.ID)
+		if assert.NoError(t, err) {
+			assert.True(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC).Equal(event.Timestamp), "Должно сохраниться время устройства")
+		}
+	})
+
+	t.Run("fail, zero time of the device", func(t *testing.T) {
+		w := post(`{"sensor_serial_number": "5550000098", "payload": 10, "timestamp": "0001-01-01T00:00:00Z"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, codeInvalidEventTimestamp, decodeProblem(t, w)["code"])
+	})
+}
@@ -0,0 +1,319 @@
+package http
+
+import (
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-openapi/strfmt"
+)
+
+func getSceneDto(s *domain.Scene) models.Scene {
+	commands := make([]*models.SceneCommand, len(s.Commands))
+	for i, c := range s.Commands {
+		dto := &models.SceneCommand{ActuatorID: &c.ActuatorID, Action: &c.Action}
+		if len(c.Args) > 0 {
+			dto.Args = readingsDto(c.Args)
+		}
+		commands[i] = dto
+	}
+	return models.Scene{
+		ID:          &s.ID,
+		Name:        &s.Name,
+		Description: &s.Description,
+		Commands:    commands,
+	}
+}
+
+func getScheduleDto(s *domain.Schedule) models.Schedule {
+	kind, missed := string(s.Kind), string(s.Missed)
+	dto := models.Schedule{
+		ID:        &s.ID,
+		Name:      &s.Name,
+		SceneID:   &s.SceneID,
+		Kind:      &kind,
+		Cron:      s.Cron,
+		Offset:    int64(s.Offset / time.Second),
+		Missed:    &missed,
+		Enabled:   &s.Enabled,
+		LastError: s.LastError,
+		CreatedAt: (*strfmt.DateTime)(&s.CreatedAt),
+	}
+	if !s.At.IsZero() {
+		dto.At = (*strfmt.DateTime)(&s.At)
+	}
+	if !s.NextRun.IsZero() {
+		dto.NextRun = (*strfmt.DateTime)(&s.NextRun)
+	}
+	if !s.LastRun.IsZero() {
+		dto.LastRun = (*strfmt.DateTime)(&s.LastRun)
+	}
+	return dto
+}
+
+// bindScene reads the scene from the body, false means the response is already sent
+func bindScene(ctx *gin.Context) (*domain.Scene, bool) {
+	s := models.SceneToSave{}
+	if !bindAndValidate(ctx, &s) {
+		return nil, false
+	}
+	scene := domain.Scene{Name: *s.Name, Description: s.Description, Commands: make([]domain.SceneCommand, len(s.Commands))}
+	for i, c := range s.Commands {
+		if c == nil {
+			field, message := fmt.Sprintf("commands.%d", i), "command is required"
+			abortWithProblem(ctx, http.StatusUnprocessableEntity, codeValidationFailed, "request body is invalid",
+				&models.ValidationError{Field: &field, Message: &message})
+			return nil, false
+		}
+		args, err := readingsFromDto(c.Args)
+		if err != nil {
+			field, message := fmt.Sprintf("commands.%d.args", i), err.Error()
+			abortWithProblem(ctx, http.StatusUnprocessableEntity, codeValidationFailed, "request body is invalid",
+				&models.ValidationError{Field: &field, Message: &message})
+			return nil, false
+		}
+		scene.Commands[i] = domain.SceneCommand{ActuatorID: *c.ActuatorID, Action: *c.Action, Args: args}
+	}
+	return &scene, true
+}
+
+func setupGetScenesHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkAccept(ctx) {
+			return
+		}
+		items, err := uc.Scenes.GetScenes(ctx)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		dtos := make([]models.Scene, len(items))
+		for i := range items {
+			dtos[i] = getSceneDto(&items[i])
+		}
+		ctx.JSON(http.StatusOK, dtos)
+	}
+}
+
+func setupPostSceneHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkContentType(ctx) {
+			return
+		}
+		scene, ok := bindScene(ctx)
+		if !ok {
+			return
+		}
+		saved, err := uc.Scenes.SaveScene(ctx, scene)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		ctx.Header("Location", fmt.Sprintf("/scenes/%d", saved.ID))
+		ctx.JSON(http.StatusCreated, getSceneDto(saved))
+	}
+}
+
+func setupGetSceneHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkAccept(ctx) {
+			return
+		}
+		id, err := strconv.ParseInt(ctx.Param("scene_id"), 10, 64)
+		if err != nil {
+			abortInvalidID(ctx, "scene_id")
+			return
+		}
+		s, err := uc.Scenes.GetSceneByID(ctx, id)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, getSceneDto(s))
+	}
+}
+
+func setupPutSceneHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkContentType(ctx) {
+			return
+		}
+		id, err := strconv.ParseInt(ctx.Param("scene_id"), 10, 64)
+		if err != nil {
+			abortInvalidID(ctx, "scene_id")
+			return
+		}
+		scene, ok := bindScene(ctx)
+		if !ok {
+			return
+		}
+		scene.ID = id
+		saved, err := uc.Scenes.SaveScene(ctx, scene)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, getSceneDto(saved))
+	}
+}
+
+func setupDeleteSceneHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.ParseInt(ctx.Param("scene_id"), 10, 64)
+		if err != nil {
+			abortInvalidID(ctx, "scene_id")
+			return
+		}
+		if err := uc.Scenes.DeleteScene(ctx, id); err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// setupPostSceneTriggerHandler queues the commands of the scene, they are delivered as the ones sent one by one
+func setupPostSceneTriggerHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkAccept(ctx) {
+			return
+		}
+		id, err := strconv.ParseInt(ctx.Param("scene_id"), 10, 64)
+		if err != nil {
+			abortInvalidID(ctx, "scene_id")
+			return
+		}
+		commands, err := uc.Scenes.TriggerScene(ctx, id)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusAccepted, getCommandsDto(commands))
+	}
+}
+
+func setupGetSchedulesHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkAccept(ctx) {
+			return
+		}
+		items, err := uc.Schedules.GetSchedules(ctx)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		dtos := make([]models.Schedule, len(items))
+		for i := range items {
+			dtos[i] = getScheduleDto(&items[i])
+		}
+		ctx.JSON(http.StatusOK, dtos)
+	}
+}
+
+func setupPostScheduleHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkContentType(ctx) {
+			return
+		}
+		s := models.ScheduleToCreate{}
+		if !bindAndValidate(ctx, &s) {
+			return
+		}
+		schedule := domain.Schedule{
+			Name:    *s.Name,
+			SceneID: *s.SceneID,
+			Kind:    domain.ScheduleKind(*s.Kind),
+			Cron:    s.Cron,
+			Offset:  time.Duration(s.Offset) * time.Second,
+			Missed:  domain.MissedPolicy(s.Missed),
+		}
+		if s.At != nil {
+			schedule.At = time.Time(*s.At)
+		}
+		if schedule.Missed == "" {
+			schedule.Missed = domain.MissedPolicySkip
+		}
+
+		created, err := uc.Schedules.CreateSchedule(ctx, &schedule)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		ctx.Header("Location", fmt.Sprintf("/schedules/%d", created.ID))
+		ctx.JSON(http.StatusCreated, getScheduleDto(created))
+	}
+}
+
+func setupGetScheduleHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkAccept(ctx) {
+			return
+		}
+		id, err := strconv.ParseInt(ctx.Param("schedule_id"), 10, 64)
+		if err != nil {
+			abortInvalidID(ctx, "schedule_id")
+			return
+		}
+		s, err := uc.Schedules.GetScheduleByID(ctx, id)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, getScheduleDto(s))
+	}
+}
+
+func setupDeleteScheduleHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.ParseInt(ctx.Param("schedule_id"), 10, 64)
+		if err != nil {
+			abortInvalidID(ctx, "schedule_id")
+			return
+		}
+		if err := uc.Schedules.DeleteSchedule(ctx, id); err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+func setupOptionsScenesHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("Allow", strings.Join([]string{http.MethodOptions, http.MethodPost, http.MethodGet}, ","))
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+func setupOptionsSceneHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("Allow", strings.Join([]string{http.MethodOptions, http.MethodGet, http.MethodPut, http.MethodDelete}, ","))
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+func setupOptionsSceneTriggerHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("Allow", strings.Join([]string{http.MethodOptions, http.MethodPost}, ","))
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+func setupOptionsSchedulesHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("Allow", strings.Join([]string{http.MethodOptions, http.MethodPost, http.MethodGet}, ","))
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+func setupOptionsScheduleHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("Allow", strings.Join([]string{http.MethodOptions, http.MethodGet, http.MethodDelete}, ","))
+		ctx.Status(http.StatusNoContent)
+	}
+}
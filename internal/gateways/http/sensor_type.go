@@ -0,0 +1,158 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func getSensorTypeDto(t domain.SensorTypeSpec) models.SensorType {
+	bound := func(d *domain.Decimal) *json.Number {
+		if d == nil {
+			return nil
+		}
+		n := json.Number(d.String())
+		return &n
+	}
+
+	name, description := string(t.Name), t.Description
+	channels := make([]*models.Channel, len(t.Channels))
+	for i, c := range t.Channels {
+		channels[i] = &models.Channel{Name: &c.Name, Unit: c.Unit, Bits: int64(c.Bits), Min: bound(c.Min), Max: bound(c.Max)}
+	}
+	return models.SensorType{
+		Name:          &name,
+		Description:   &description,
+		Channels:      channels,
+		SerialPattern: t.SerialPattern,
+		Heartbeat:     int64(t.Heartbeat / time.Second),
+	}
+}
+
+// sensorTypeFromDto converts the type, a bound that is not a decimal is reported as the field of the channel
+func sensorTypeFromDto(name string, t *models.SensorTypeToSave) (domain.SensorTypeSpec, *models.ValidationError) {
+	bound := func(n *json.Number, field string) (*domain.Decimal, *models.ValidationError) {
+		if n == nil {
+			return nil, nil
+		}
+		d, err := domain.ParseDecimal(n.String())
+		if err != nil {
+			message := err.Error()
+			return nil, &models.ValidationError{Field: &field, Message: &message}
+		}
+		return &d, nil
+	}
+
+	spec := domain.SensorTypeSpec{
+		Name:          domain.SensorType(name),
+		Description:   t.Description,
+		SerialPattern: t.SerialPattern,
+		Heartbeat:     time.Duration(t.Heartbeat) * time.Second,
+	}
+	for i, c := range t.Channels {
+		if c == nil {
+			continue
+		}
+		channel := domain.ChannelSpec{Name: *c.Name, Unit: c.Unit, Bits: int(c.Bits)}
+		var invalid *models.ValidationError
+		if channel.Min, invalid = bound(c.Min, fmt.Sprintf("channels.%d.min", i)); invalid != nil {
+			return spec, invalid
+		}
+		if channel.Max, invalid = bound(c.Max, fmt.Sprintf("channels.%d.max", i)); invalid != nil {
+			return spec, invalid
+		}
+		spec.Channels = append(spec.Channels, channel)
+	}
+	return spec, nil
+}
+
+func setupGetSensorTypesHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkAccept(ctx) {
+			return
+		}
+		types, err := uc.SensorTypes.GetSensorTypes(ctx)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+
+		dtos := make([]models.SensorType, len(types))
+		for i, t := range types {
+			dtos[i] = getSensorTypeDto(t)
+		}
+		ctx.JSON(http.StatusOK, dtos)
+	}
+}
+
+func setupGetSensorTypeHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkAccept(ctx) {
+			return
+		}
+		t, err := uc.SensorTypes.GetSensorType(ctx, domain.SensorType(ctx.Param("name")))
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, getSensorTypeDto(*t))
+	}
+}
+
+func setupPutSensorTypeHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !checkContentType(ctx) {
+			return
+		}
+		t := models.SensorTypeToSave{}
+		if !bindAndValidate(ctx, &t) {
+			return
+		}
+		spec, invalid := sensorTypeFromDto(ctx.Param("name"), &t)
+		if invalid != nil {
+			abortWithProblem(ctx, http.StatusUnprocessableEntity, codeValidationFailed, "request body is invalid", invalid)
+			return
+		}
+
+		created, err := uc.SensorTypes.SaveSensorType(ctx, spec)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+		}
+		ctx.JSON(status, getSensorTypeDto(spec))
+	}
+}
+
+func setupDeleteSensorTypeHandler(uc UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := uc.SensorTypes.DeleteSensorType(ctx, domain.SensorType(ctx.Param("name"))); err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+func setupOptionsSensorTypesHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("Allow", strings.Join([]string{http.MethodOptions, http.MethodGet}, ","))
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+func setupOptionsSensorTypeHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("Allow", strings.Join([]string{http.MethodOptions, http.MethodGet, http.MethodPut, http.MethodDelete}, ","))
+		ctx.Status(http.StatusNoContent)
+	}
+}
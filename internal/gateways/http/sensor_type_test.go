@@ -0,0 +1,124 @@
+package http
+
+import (
+	"encoding/json"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/models"
+	"homework/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSensorTypes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	low, high := domain.Decimal{Units: -40}, domain.Decimal{Units: 125}
+	meter := domain.SensorTypeSpec{
+		Name:        "meter",
+		Description: "Термометр",
+		Channels:    []domain.ChannelSpec{{Name: "temperature", Unit: "°C", Min: &low, Max: &high}},
+		Heartbeat:   5 * time.Minute,
+	}
+
+	trMock := usecase.NewMockSensorTypeRepository(ctrl)
+	trMock.EXPECT().GetSensorType(gomock.Any(), meter.Name).Return(&meter, nil).AnyTimes()
+	trMock.EXPECT().GetSensorType(gomock.Any(), gomock.Any()).Return(nil, usecase.ErrSensorTypeNotFound).AnyTimes()
+	srMock := usecase.NewMockSensorRepository(ctrl)
+	srMock.EXPECT().GetSensorBySerialNumber(gomock.Any(), "0000000001").Return(&domain.Sensor{
+		ID: 1, SerialNumber: "0000000001", Type: meter.Name, IsActive: true,
+	}, nil).AnyTimes()
+	srMock.EXPECT().GetSensors(gomock.Any()).Return([]domain.Sensor{{ID: 1, Type: meter.Name}}, nil).AnyTimes()
+	erMock := usecase.NewMockEventRepository(ctrl)
+
+	types := usecase.NewSensorTypes(trMock, srMock)
+	r := gin.New()
+	setupRouter(r, UseCases{
+		Event:       usecase.NewEvent(erMock, srMock, usecase.WithEventSensorTypes(types)),
+		SensorTypes: types,
+	}, nil, newLiveSettings(DefaultSettings), testMetrics)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, "/api/v1"+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("ok, get type", func(t *testing.T) {
+		w := do(http.MethodGet, "/sensor-types/meter", "")
+		require.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+
+		var dto models.SensorType
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &dto))
+		assert.Equal(t, "meter", *dto.Name)
+		assert.Equal(t, int64(300), dto.Heartbeat)
+		require.Len(t, dto.Channels, 1)
+		assert.Equal(t, "-40", dto.Channels[0].Min.String())
+		assert.Equal(t, "125", dto.Channels[0].Max.String())
+	})
+
+	t.Run("ok, save type", func(t *testing.T) {
+		var saved domain.SensorTypeSpec
+		trMock.EXPECT().SaveSensorType(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, t domain.SensorTypeSpec) (bool, error) {
+			saved = t
+			return false, nil
+		}).Times(1)
+
+		w := do(http.MethodPut, "/sensor-types/meter",
+			`{"channels": [{"name": "temperature", "unit": "°C", "min": -40, "max": 125}], "heartbeat": 300}`)
+		require.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, domain.SensorTypeSpec{
+			Name:      "meter",
+			Channels:  []domain.ChannelSpec{{Name: "temperature", Unit: "°C", Min: &low, Max: &high}},
+			Heartbeat: 5 * time.Minute,
+		}, saved)
+	})
+
+	t.Run("err, invalid type", func(t *testing.T) {
+		tests := []struct {
+			path, body, code string
+		}{
+			{"/sensor-types/meter", `{"channels": [{"name": "temperature", "min": "cold"}]}`, codeValidationFailed},
+			{"/sensor-types/meter", `{"channels": [{"name": "temperature", "min": 0.0000000001}]}`, codeValidationFailed},
+			{"/sensor-types/meter", `{"channels": [{"name": "Temperature"}]}`, codeValidationFailed},
+			{"/sensor-types/meter", `{"serial_pattern": "("}`, codeInvalidSensorType},
+			// the name is checked against the pattern of the path parameter
+			{"/sensor-types/Meter", `{}`, codeInvalidID},
+		}
+		for _, tt := range tests {
+			w := do(http.MethodPut, tt.path, tt.body)
+			assert.Contains(t, []int{http.StatusBadRequest, http.StatusUnprocessableEntity}, w.Code, tt.body)
+			assert.Equal(t, tt.code, decodeProblem(t, w)["code"], tt.body)
+		}
+	})
+
+	t.Run("err, type in use", func(t *testing.T) {
+		w := do(http.MethodDelete, "/sensor-types/meter", "")
+		require.Equal(t, http.StatusConflict, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, codeSensorTypeInUse, decodeProblem(t, w)["code"])
+
+		w = do(http.MethodDelete, "/sensor-types/door", "")
+		require.Equal(t, http.StatusNotFound, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, codeSensorTypeNotFound, decodeProblem(t, w)["code"])
+	})
+
+	t.Run("err, readings don't fit the type", func(t *testing.T) {
+		for _, body := range []string{
+			`{"sensor_serial_number": "0000000001", "payload": 1}`,
+			`{"sensor_serial_number": "0000000001", "readings": [{"channel": "temperature", "value": 200}]}`,
+			`{"sensor_serial_number": "0000000001", "readings": [{"channel": "temperature", "value": 20, "unit": "K"}]}`,
+		} {
+			w := do(http.MethodPost, "/events", body)
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code, body)
+			assert.Equal(t, codeInvalidReadings, decodeProblem(t, w)["code"], body)
+		}
+	})
+}
@@ -5,17 +5,28 @@ import (
 	"errors"
 	"fmt"
 	"homework/internal/usecase"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/netip"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 type Server struct {
-	host      string
-	port      uint16
-	router    *gin.Engine
-	wsHandler *WebSocketHandler
+	host       string
+	port       uint16
+	timeouts   Timeouts
+	router     *gin.Engine
+	wsHandler  *WebSocketHandler
+	wsSettings WebSocketSettings
+	settings   *liveSettings
+	health     *health
+	flushers   []Flusher
+	metrics    *MetricsExporter
 }
 
 const (
@@ -24,20 +35,90 @@ const (
 )
 
 type UseCases struct {
-	Event  *usecase.Event
-	Sensor *usecase.Sensor
-	User   *usecase.User
+	Event    *usecase.Event
+	Sensor   *usecase.Sensor
+	User     *usecase.User
+	Export   *usecase.Export
+	Import   *usecase.Import
+	Actuator *usecase.Actuator
+	// Commands delivers the commands to the actuators
+	Commands *usecase.Commands
+	Scenes   *usecase.Scenes
+	// Schedules runs the scenes by the cron, the sun and the timers
+	Schedules *usecase.Schedules
+	// SensorTypes is the registry of the sensor types
+	SensorTypes *usecase.SensorTypes
+	// RateLimit limits the clients of the api, there is no limit if it is nil
+	RateLimit *usecase.RateLimiter
+	// Idempotency makes the retries of the events return the original result, the keys are ignored if it is nil
+	Idempotency *usecase.Idempotency
+	// Changes is the feed of the changes of the sensors, the users, the bindings and the events
+	Changes *usecase.Changes
 }
 
-func NewServer(useCases UseCases, options ...func(*Server)) *Server {
-	r := gin.Default()
-	ws := NewWebSocketHandler(useCases)
-	setupRouter(r, useCases, ws)
+// Timeouts of the underlying http.Server, zero means no limit. Shutdown is the time given to the whole drain,
+// DrainDelay is how long the readiness probe fails before the server stops accepting connections.
+type Timeouts struct {
+	ReadHeader time.Duration
+	Read       time.Duration
+	Write      time.Duration
+	Idle       time.Duration
+	Shutdown   time.Duration
+	DrainDelay time.Duration
+}
+
+var DefaultTimeouts = Timeouts{ReadHeader: 5 * time.Second, Idle: time.Minute, Shutdown: 3 * time.Second}
+
+// Settings are the part of the router configuration that can be changed while the server runs
+type Settings struct {
+	Validation SpecValidation
+	Exports    bool
+	Imports    bool
+	// ClientKeyHeader is the header the clients are told apart by, e.g. the api key set by an authenticating proxy.
+	// The client ip is used if it is empty, the header is missing or the request doesn't come from a trusted proxy.
+	ClientKeyHeader string
+	// TrustedProxies are the addresses whose X-Forwarded-For and ClientKeyHeader are believed,
+	// the clients are told apart by the address they connect from if it is empty
+	TrustedProxies []netip.Prefix
+}
 
-	s := &Server{router: r, host: DefaultHost, port: DefaultPort, wsHandler: ws}
+var DefaultSettings = Settings{Validation: SpecValidation{Requests: true}, Exports: true, Imports: true}
+
+type liveSettings struct {
+	p atomic.Pointer[Settings]
+}
+
+func newLiveSettings(s Settings) *liveSettings {
+	l := &liveSettings{}
+	l.set(s)
+	return l
+}
+
+func (l *liveSettings) get() Settings {
+	return *l.p.Load()
+}
+
+func (l *liveSettings) set(s Settings) {
+	l.p.Store(&s)
+}
+
+func NewServer(useCases UseCases, options ...func(*Server)) *Server {
+	s := &Server{
+		router: gin.New(), host: DefaultHost, port: DefaultPort, timeouts: DefaultTimeouts,
+		wsSettings: DefaultWebSocketSettings, settings: newLiveSettings(DefaultSettings), health: &health{},
+	}
 	for _, o := range options {
 		o(s)
 	}
+	if s.metrics == nil {
+		// nobody scrapes them, but the handlers need somewhere to write
+		s.metrics = NewMetricsExporter(prometheus.NewRegistry())
+	}
+
+	s.wsHandler = NewWebSocketHandler(useCases)
+	s.wsHandler.SetSettings(s.wsSettings)
+	setupRouter(s.router, useCases, s.wsHandler, s.settings, s.metrics)
+	setupHealthRoutes(s.router, s.health)
 
 	return s
 }
@@ -54,25 +135,145 @@ func WithPort(port uint16) func(*Server) {
 	}
 }
 
+func WithTimeouts(timeouts Timeouts) func(*Server) {
+	return func(s *Server) {
+		s.timeouts = timeouts
+	}
+}
+
+func WithSettings(settings Settings) func(*Server) {
+	return func(s *Server) {
+		s.settings.set(settings)
+	}
+}
+
+func WithWebSocketSettings(settings WebSocketSettings) func(*Server) {
+	return func(s *Server) {
+		s.wsSettings = settings
+	}
+}
+
+func WithMetrics(metrics *MetricsExporter) func(*Server) {
+	return func(s *Server) {
+		s.metrics = metrics
+	}
+}
+
+// WithLivenessChecks sets the checks of /healthz, a failing one means the server should be restarted
+func WithLivenessChecks(checks ...Check) func(*Server) {
+	return func(s *Server) {
+		s.health.liveness = checks
+	}
+}
+
+// WithReadinessChecks sets the checks of /readyz, a failing one means the server should get no traffic for now
+func WithReadinessChecks(checks ...Check) func(*Server) {
+	return func(s *Server) {
+		s.health.readiness = checks
+	}
+}
+
+// WithFlushers sets the background work to finish on shutdown, after the requests and the websockets are done
+func WithFlushers(flushers ...Flusher) func(*Server) {
+	return func(s *Server) {
+		s.flushers = flushers
+	}
+}
+
+// Reload applies the settings to the running server
+func (s *Server) Reload(settings Settings, ws WebSocketSettings) {
+	s.settings.set(settings)
+	s.wsHandler.SetSettings(ws)
+}
+
 func (s *Server) Run(ctx context.Context) error {
+	l, err := net.Listen("tcp", fmt.Sprintf("%s:%d", s.host, s.port))
+	if err != nil {
+		return err
+	}
+	return s.serve(ctx, l)
+}
+
+func (s *Server) serve(ctx context.Context, l net.Listener) error {
 	server := &http.Server{
-		Addr:    fmt.Sprintf("%s:%d", s.host, s.port),
-		Handler: s.router,
+		Handler:           s.router,
+		ReadHeaderTimeout: s.timeouts.ReadHeader,
+		ReadTimeout:       s.timeouts.Read,
+		WriteTimeout:      s.timeouts.Write,
+		IdleTimeout:       s.timeouts.Idle,
 	}
 
-	done := make(chan error)
+	done := make(chan error, 1)
 	go func() {
-		done <- server.ListenAndServe()
+		done <- server.Serve(l)
 	}()
 
 	select {
 	case <-ctx.Done():
-		c, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-		defer cancel()
-
-		es := []error{server.Shutdown(c), s.wsHandler.Shutdown()}
-		return errors.Join(es...)
+		return s.drain(server)
 	case err := <-done:
 		return err
 	}
 }
+
+// Stages of the drain, in the order they run
+const (
+	stageStopAccepting = "stop_accepting"
+	stageInFlight      = "in_flight"
+	stageWebSockets    = "websockets"
+	stageFlush         = "flush"
+)
+
+// drain shuts the server down in stages, all of them share the shutdown timeout.
+// The requests that have not finished in time are cut off, the later stages still run.
+func (s *Server) drain(server *http.Server) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeouts.Shutdown)
+	defer cancel()
+
+	var errs []error
+	stage := func(name string, run func() error) {
+		s.metrics.drainStage.WithLabelValues(name).Set(1)
+		start := time.Now()
+
+		err := run()
+
+		elapsed := time.Since(start)
+		s.metrics.drainStage.WithLabelValues(name).Set(0)
+		s.metrics.drainStageDuration.WithLabelValues(name).Set(elapsed.Seconds())
+		slog.Info("Shutdown stage is done", "stage", name, "duration", elapsed)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	stage(stageStopAccepting, func() error {
+		s.health.draining.Store(true)
+		server.SetKeepAlivesEnabled(false)
+
+		t := time.NewTimer(s.timeouts.DrainDelay)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+		}
+		return nil
+	})
+	stage(stageInFlight, func() error {
+		if err := server.Shutdown(ctx); err != nil {
+			return errors.Join(err, server.Close())
+		}
+		return nil
+	})
+	stage(stageWebSockets, s.wsHandler.Shutdown)
+	stage(stageFlush, func() error {
+		var errs []error
+		for _, f := range s.flushers {
+			if err := f.Flush(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", f.Name, err))
+			}
+		}
+		return errors.Join(errs...)
+	})
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,318 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework/api"
+	"homework/internal/logging"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/go-openapi/loads"
+	"github.com/go-openapi/spec"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
+
+	"github.com/gin-gonic/gin"
+)
+
+const apiV1Prefix = "/api/v1"
+
+// maxJSONBody bounds the JSON bodies read for the validation, the largest documented one is a few kilobytes
+const maxJSONBody = 1 << 20
+
+// SpecValidation toggles checking of the /api/v1 traffic against the OpenAPI spec
+type SpecValidation struct {
+	// Requests that do not match the spec are rejected before they reach a handler
+	Requests bool
+	// Responses that do not match the spec are logged and counted as errors, they are sent as is
+	Responses bool
+}
+
+var openAPI = mustLoadOpenAPI(api.Swagger)
+
+type openAPISpec struct {
+	doc *loads.Document
+	// raw is the spec as it is served to the clients
+	raw []byte
+}
+
+func mustLoadOpenAPI(yaml []byte) *openAPISpec {
+	s, err := loadOpenAPI(yaml)
+	if err != nil {
+		panic(fmt.Sprintf("embedded OpenAPI spec is broken: %v", err))
+	}
+	return s
+}
+
+func loadOpenAPI(yaml []byte) (*openAPISpec, error) {
+	yamlDoc, err := swag.BytesToYAMLDoc(yaml)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := swag.YAMLToJSON(yamlDoc)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := loads.Analyzed(raw, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := validate.Spec(doc, strfmt.Default); err != nil {
+		return nil, err
+	}
+	doc, err = doc.Expanded()
+	if err != nil {
+		return nil, err
+	}
+	return &openAPISpec{doc: doc, raw: raw}, nil
+}
+
+var routeParamRegexp = regexp.MustCompile(`:([^/]+)`)
+
+// specPath turns a gin route into the spec path, e.g. /sensors/:sensor_id into /sensors/{sensor_id}
+func specPath(route string) string {
+	return routeParamRegexp.ReplaceAllString(route, "{$1}")
+}
+
+func (s *openAPISpec) operation(method, route string) (*spec.Operation, bool) {
+	return s.doc.Analyzer.OperationFor(method, specPath(route))
+}
+
+func (s *openAPISpec) params(method, route string) map[string]spec.Parameter {
+	return s.doc.Analyzer.ParamsFor(method, specPath(route))
+}
+
+// checkRequest validates the parameters and the JSON body of the request, on mismatch the request is aborted
+// with the same problem the handler would have responded with
+func (s *openAPISpec) checkRequest(ctx *gin.Context, op *spec.Operation, route string) bool {
+	for _, p := range s.params(ctx.Request.Method, route) {
+		var (
+			raw string
+			has bool
+		)
+		switch p.In {
+		case "path":
+			raw, has = ctx.Param(p.Name), true
+		case "query":
+			raw, has = ctx.GetQuery(p.Name)
+		case "header":
+			raw = ctx.GetHeader(p.Name)
+			has = raw != ""
+		case "body":
+			if !s.checkRequestBody(ctx, op, p) {
+				return false
+			}
+			continue
+		default:
+			continue
+		}
+
+		if err := validateParam(p, raw, has); err != nil {
+			switch p.In {
+			case "path":
+				abortWithProblem(ctx, http.StatusUnprocessableEntity, codeInvalidID, err.Error())
+			case "query":
+				abortWithProblem(ctx, http.StatusBadRequest, codeInvalidQuery, err.Error())
+			default:
+				abortWithProblem(ctx, http.StatusBadRequest, codeInvalidHeader, err.Error())
+			}
+			return false
+		}
+	}
+	return true
+}
+
+func validateParam(p spec.Parameter, raw string, has bool) error {
+	if !has {
+		if p.Required {
+			return fmt.Errorf("%s is required", p.Name)
+		}
+		return nil
+	}
+
+	var (
+		value any = raw
+		err   error
+	)
+	switch p.Type {
+	case "integer":
+		value, err = strconv.ParseInt(raw, 10, 64)
+	case "number":
+		value, err = strconv.ParseFloat(raw, 64)
+	case "boolean":
+		value, err = strconv.ParseBool(raw)
+	}
+	if err != nil {
+		return fmt.Errorf("%s must be of type %s", p.Name, p.Type)
+	}
+	return validate.NewParamValidator(&p, strfmt.Default).Validate(value).AsError()
+}
+
+// checkRequestBody validates JSON bodies only, other formats are parsed and checked by the handlers.
+// The body is read in full and put back for the handler, a body over maxJSONBody is rejected with 413.
+func (s *openAPISpec) checkRequestBody(ctx *gin.Context, op *spec.Operation, p spec.Parameter) bool {
+	consumes := op.Consumes
+	if len(consumes) == 0 {
+		consumes = s.doc.Spec().Consumes
+	}
+	if p.Schema == nil || !slices.Contains(consumes, mimeJSON) || !isMediaType(ctx.GetHeader("Content-Type"), mimeJSON) {
+		return true
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxJSONBody))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		abortWithProblem(ctx, http.StatusRequestEntityTooLarge, codeBodyTooLarge,
+			fmt.Sprintf("request body is larger than %d bytes", tooLarge.Limit))
+		return false
+	}
+	if err != nil {
+		abortWithProblem(ctx, http.StatusBadRequest, codeMalformedBody, err.Error())
+		return false
+	}
+	ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var data any
+	if err := json.Unmarshal(body, &data); err != nil {
+		abortWithProblem(ctx, http.StatusBadRequest, codeMalformedBody, err.Error())
+		return false
+	}
+	if err := validate.AgainstSchema(p.Schema, data, strfmt.Default); err != nil {
+		abortWithProblem(ctx, http.StatusUnprocessableEntity, codeValidationFailed, "request body is invalid", validationDetails(err)...)
+		return false
+	}
+	return true
+}
+
+var errUndocumentedResponse = errors.New("response is not documented")
+
+// validateResponse checks the status, the content type, the documented headers and the JSON body of the response
+func (s *openAPISpec) validateResponse(method, route string, status int, header http.Header, body []byte) error {
+	op, ok := s.operation(method, route)
+	if !ok {
+		return fmt.Errorf("%w: no operation %s %s", errUndocumentedResponse, method, route)
+	}
+
+	resp, ok := op.Responses.StatusCodeResponses[status]
+	if !ok {
+		if op.Responses.Default == nil {
+			return fmt.Errorf("%w: status %d", errUndocumentedResponse, status)
+		}
+		resp = *op.Responses.Default
+	}
+
+	for name := range resp.Headers {
+		if header.Get(name) == "" {
+			return fmt.Errorf("header %s is missing", name)
+		}
+	}
+
+	if method == http.MethodHead || resp.Schema == nil {
+		return nil
+	}
+	contentType := header.Get("Content-Type")
+	if !s.produces(op, status, contentType) {
+		return fmt.Errorf("%w: content type %q", errUndocumentedResponse, contentType)
+	}
+	if !isJSONResponse(contentType) {
+		// streams and files are not validated
+		return nil
+	}
+
+	var data any
+	if err := json.Unmarshal(body, &data); err != nil {
+		return fmt.Errorf("body is not json: %w", err)
+	}
+	return validate.AgainstSchema(resp.Schema, data, strfmt.Default)
+}
+
+// produces reports whether the operation may respond with the content type. Errors are always allowed to be problems.
+func (s *openAPISpec) produces(op *spec.Operation, status int, contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if status >= http.StatusBadRequest && mediaType == mimeProblem {
+		return true
+	}
+	produces := op.Produces
+	if len(produces) == 0 {
+		produces = s.doc.Spec().Produces
+	}
+	return slices.Contains(produces, mediaType)
+}
+
+func isJSONResponse(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == mimeJSON || mediaType == mimeProblem)
+}
+
+// recordingWriter keeps a copy of JSON bodies for the response validation
+type recordingWriter struct {
+	gin.ResponseWriter
+	body    bytes.Buffer
+	capture *bool
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.record(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.record([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *recordingWriter) record(b []byte) {
+	if w.capture == nil {
+		capture := isJSONResponse(w.Header().Get("Content-Type"))
+		w.capture = &capture
+	}
+	if *w.capture {
+		w.body.Write(b)
+	}
+}
+
+func specValidationHandler(s *openAPISpec, settings *liveSettings) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		v := settings.get().Validation
+		route := ctx.FullPath()[len(apiV1Prefix):]
+		op, ok := s.operation(ctx.Request.Method, route)
+		if !ok {
+			ctx.Next()
+			return
+		}
+
+		if v.Requests && !s.checkRequest(ctx, op, route) {
+			return
+		}
+		if !v.Responses {
+			ctx.Next()
+			return
+		}
+
+		w := &recordingWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = w
+		ctx.Next()
+
+		err := s.validateResponse(ctx.Request.Method, route, w.Status(), w.Header(), w.body.Bytes())
+		if err != nil {
+			_ = ctx.Error(err)
+			logging.FromContext(ctx.Request.Context()).Warn("Response does not match the spec", "error", err)
+		}
+	}
+}
+
+func setupGetSpecHandler(s *openAPISpec) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Data(http.StatusOK, mimeJSON, s.raw)
+	}
+}
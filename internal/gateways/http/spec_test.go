@@ -0,0 +1,553 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/models"
+	actuatorRepository "homework/internal/repository/actuator/inmemory"
+	changeRepository "homework/internal/repository/change/inmemory"
+	checkpointRepository "homework/internal/repository/checkpoint/inmemory"
+	eventRepository "homework/internal/repository/event/inmemory"
+	sceneRepository "homework/internal/repository/scene/inmemory"
+	sensorRepository "homework/internal/repository/sensor/inmemory"
+	sensorTypeRepository "homework/internal/repository/sensortype/inmemory"
+	userRepository "homework/internal/repository/user/inmemory"
+	"homework/internal/usecase"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"nhooyr.io/websocket"
+)
+
+type contractCase struct {
+	name        string
+	operationID string
+	method      string
+	path        string
+	header      map[string]string
+	body        string
+	want        int
+	// before prepares the state the case depends on
+	before func()
+}
+
+// contractSuite runs every documented operation of /api/v1 and checks the responses against the spec
+type contractSuite struct {
+	suite.Suite
+
+	uc     UseCases
+	router *gin.Engine
+	ws     *WebSocketHandler
+	// sensorID and userID are taken from the shared id sequences, so they are not known in advance
+	sensorID, userID int64
+	actuatorID       int64
+	// covered keeps the operations that have been exercised
+	covered map[string]bool
+}
+
+func (s *contractSuite) SetupSuite() {
+	changes := changeRepository.NewChangeRepository()
+	sr := sensorRepository.NewSensorRepository(sensorRepository.WithSensorChanges(changes.Record))
+	er := eventRepository.NewEventRepository(eventRepository.WithEventChanges(changes.Record))
+	types := usecase.NewSensorTypes(sensorTypeRepository.NewSensorTypeRepository(), sr)
+	ar := actuatorRepository.NewActuatorRepository()
+	commands := usecase.NewCommands(actuatorRepository.NewCommandRepository(), ar)
+	scr, shr := sceneRepository.NewSceneRepository(), sceneRepository.NewScheduleRepository()
+	scenes := usecase.NewScenes(scr, shr, ar, commands)
+	s.uc = UseCases{
+		Event:  usecase.NewEvent(er, sr, usecase.WithEventSensorTypes(types)),
+		Sensor: usecase.NewSensor(sr, usecase.WithSensorTypes(types)),
+		User: usecase.NewUser(userRepository.NewUserRepository(userRepository.WithUserChanges(changes.Record)),
+			userRepository.NewSensorOwnerRepository(userRepository.WithSensorOwnerChanges(changes.Record)), sr),
+		Export: usecase.NewExport(er, sr, s.T().TempDir()),
+		Import: usecase.NewImport(sr, er, checkpointRepository.NewCheckpointRepository(), usecase.WithImportSensorTypes(types)),
+
+		Actuator:    usecase.NewActuator(ar),
+		Commands:    commands,
+		Scenes:      scenes,
+		Schedules:   usecase.NewSchedules(shr, scr, scenes),
+		SensorTypes: types,
+		Changes:     usecase.NewChanges(changes),
+	}
+	s.router = gin.New()
+	s.ws = NewWebSocketHandler(s.uc)
+	setupRouter(s.router, s.uc, s.ws, newLiveSettings(DefaultSettings), testMetrics)
+	s.covered = make(map[string]bool)
+
+	ctx := context.Background()
+	sensor, err := s.uc.Sensor.RegisterSensor(ctx, &domain.Sensor{
+		SerialNumber: "1234567890", Type: domain.SensorTypeContactClosure, Description: "датчик", IsActive: true,
+	})
+	s.Require().NoError(err)
+	s.sensorID = sensor.ID
+	user, err := s.uc.User.RegisterUser(ctx, &domain.User{Name: "Пользователь"})
+	s.Require().NoError(err)
+	s.userID = user.ID
+	actuator, err := s.uc.Actuator.RegisterActuator(ctx, &domain.Actuator{
+		SerialNumber: "1234567890", Type: "relay", Description: "реле",
+	})
+	s.Require().NoError(err)
+	s.actuatorID = actuator.ID
+}
+
+func (s *contractSuite) TearDownSuite() {
+	s.NoError(s.ws.Shutdown())
+
+	for _, ops := range openAPI.doc.Analyzer.Operations() {
+		for _, op := range ops {
+			s.True(s.covered[op.ID], "Операция %s не проверена", op.ID)
+		}
+	}
+}
+
+func (s *contractSuite) run(tt contractCase) {
+	s.Run(tt.operationID+"/"+tt.name, func() {
+		if tt.before != nil {
+			tt.before()
+		}
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(tt.method, apiV1Prefix+tt.path, strings.NewReader(tt.body))
+		for k, v := range tt.header {
+			req.Header.Set(k, v)
+		}
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(tt.want, w.Code, "Получили в ответ не тот код: %s", w.Body.String())
+
+		method, route, op, ok := openAPI.doc.Analyzer.OperationForName(tt.operationID)
+		s.Require().True(ok, "Операции нет в спецификации")
+		s.Equal(strings.ToUpper(method), tt.method)
+		s.NoError(openAPI.validateResponse(tt.method, ginRoute(route), w.Code, w.Header(), w.Body.Bytes()),
+			"Ответ не соответствует спецификации")
+		s.covered[op.ID] = true
+	})
+}
+
+// ginRoute turns the spec path back into a gin route
+func ginRoute(path string) string {
+	return strings.NewReplacer("{", ":", "}", "").Replace(path)
+}
+
+func (s *contractSuite) TestOperations() {
+	jsonBody := map[string]string{"Content-Type": mimeJSON}
+	acceptJSON := map[string]string{"Accept": mimeJSON}
+	future := time.Now().Add(time.Hour).Unix()
+	sensorPath := fmt.Sprintf("/sensors/%d", s.sensorID)
+	userPath := fmt.Sprintf("/users/%d", s.userID)
+	binding := fmt.Sprintf(`{"sensor_id": %d}`, s.sensorID)
+	actuatorPath := fmt.Sprintf("/actuators/%d", s.actuatorID)
+	sceneBody := fmt.Sprintf(`{"name": "good_night", "commands": [{"actuator_id": %d, "action": "switch", "args": [{"channel": "state", "value": 0}]}]}`,
+		s.actuatorID)
+
+	cases := []contractCase{
+		{name: "ok", operationID: "getSpec", method: http.MethodGet, path: "/openapi.json", want: http.StatusOK},
+
+		{name: "ok", operationID: "createUser", method: http.MethodPost, path: "/users", header: jsonBody,
+			body: `{"name": "Пользователь"}`, want: http.StatusOK},
+		{name: "invalid", operationID: "createUser", method: http.MethodPost, path: "/users", header: jsonBody,
+			body: `{"name": ""}`, want: http.StatusUnprocessableEntity},
+		{name: "malformed", operationID: "createUser", method: http.MethodPost, path: "/users", header: jsonBody,
+			body: `{`, want: http.StatusBadRequest},
+		{name: "unsupported", operationID: "createUser", method: http.MethodPost, path: "/users",
+			header: map[string]string{"Content-Type": "text/plain"}, body: `{}`, want: http.StatusUnsupportedMediaType},
+		{name: "ok", operationID: "usersOptions", method: http.MethodOptions, path: "/users", want: http.StatusNoContent},
+
+		{name: "ok", operationID: "registerSensor", method: http.MethodPost, path: "/sensors", header: jsonBody,
+			body: `{"serial_number": "1234567892", "type": "cc", "description": "датчик", "is_active": true}`, want: http.StatusOK},
+		{name: "invalid", operationID: "registerSensor", method: http.MethodPost, path: "/sensors", header: jsonBody,
+			body: `{"serial_number": "12", "type": "cc", "description": "датчик", "is_active": true}`, want: http.StatusUnprocessableEntity},
+		{name: "ok", operationID: "sensorsOptions", method: http.MethodOptions, path: "/sensors", want: http.StatusNoContent},
+		{name: "ok", operationID: "getSensors", method: http.MethodGet, path: "/sensors", header: acceptJSON, want: http.StatusOK},
+		{name: "not_acceptable", operationID: "getSensors", method: http.MethodGet, path: "/sensors",
+			header: map[string]string{"Accept": "application/xml"}, want: http.StatusNotAcceptable},
+		{name: "ok", operationID: "headSensors", method: http.MethodHead, path: "/sensors", want: http.StatusOK},
+		{name: "ok", operationID: "getSensor", method: http.MethodGet, path: sensorPath, header: acceptJSON, want: http.StatusOK},
+		{name: "not_found", operationID: "getSensor", method: http.MethodGet, path: "/sensors/100500", want: http.StatusNotFound},
+		{name: "invalid_id", operationID: "getSensor", method: http.MethodGet, path: "/sensors/abc", want: http.StatusUnprocessableEntity},
+		{name: "ok", operationID: "headSensor", method: http.MethodHead, path: sensorPath, want: http.StatusOK},
+		{name: "ok", operationID: "updateSensor", method: http.MethodPatch, path: sensorPath, header: jsonBody,
+			body: `{"description": "Кухня"}`, want: http.StatusOK},
+		{name: "not_found", operationID: "updateSensor", method: http.MethodPatch, path: "/sensors/100500", header: jsonBody,
+			body: `{"is_active": false}`, want: http.StatusNotFound},
+		{name: "malformed", operationID: "updateSensor", method: http.MethodPatch, path: sensorPath, header: jsonBody,
+			body: `{`, want: http.StatusBadRequest},
+		{name: "ok", operationID: "sensorOptions", method: http.MethodOptions, path: sensorPath, want: http.StatusNoContent},
+		{name: "ok", operationID: "saveSensorCalibration", method: http.MethodPut, path: sensorPath + "/calibration", header: jsonBody,
+			body: `{"calibration": [{"channel": "value", "gain": 2.5, "offset": -1, "unit": "V"}]}`, want: http.StatusOK},
+		{name: "invalid", operationID: "saveSensorCalibration", method: http.MethodPut, path: sensorPath + "/calibration", header: jsonBody,
+			body: `{"calibration": [{"channel": "value", "gain": 0}]}`, want: http.StatusUnprocessableEntity},
+		{name: "not_found", operationID: "saveSensorCalibration", method: http.MethodPut, path: "/sensors/100500/calibration",
+			header: jsonBody, body: `{"calibration": []}`, want: http.StatusNotFound},
+		{name: "ok", operationID: "sensorCalibrationOptions", method: http.MethodOptions, path: sensorPath + "/calibration",
+			want: http.StatusNoContent},
+
+		{name: "ok", operationID: "getSensorTypes", method: http.MethodGet, path: "/sensor-types", header: acceptJSON, want: http.StatusOK},
+		{name: "not_acceptable", operationID: "getSensorTypes", method: http.MethodGet, path: "/sensor-types",
+			header: map[string]string{"Accept": "text/html"}, want: http.StatusNotAcceptable},
+		{name: "ok", operationID: "sensorTypesOptions", method: http.MethodOptions, path: "/sensor-types", want: http.StatusNoContent},
+		{name: "created", operationID: "saveSensorType", method: http.MethodPut, path: "/sensor-types/door", header: jsonBody,
+			body: `{"description": "Дверь", "channels": [{"name": "open", "min": 0, "max": 1}], "heartbeat": 60}`, want: http.StatusCreated},
+		{name: "replaced", operationID: "saveSensorType", method: http.MethodPut, path: "/sensor-types/door", header: jsonBody,
+			body: `{"channels": [{"name": "open", "min": 0, "max": 1}]}`, want: http.StatusOK},
+		{name: "invalid", operationID: "saveSensorType", method: http.MethodPut, path: "/sensor-types/door", header: jsonBody,
+			body: `{"channels": [{"name": "open", "min": 1, "max": 0}]}`, want: http.StatusUnprocessableEntity},
+		{name: "ok", operationID: "getSensorType", method: http.MethodGet, path: "/sensor-types/door", want: http.StatusOK},
+		{name: "not_found", operationID: "getSensorType", method: http.MethodGet, path: "/sensor-types/window", want: http.StatusNotFound},
+		{name: "in_use", operationID: "deleteSensorType", method: http.MethodDelete, path: "/sensor-types/cc", want: http.StatusConflict},
+		{name: "ok", operationID: "deleteSensorType", method: http.MethodDelete, path: "/sensor-types/door", want: http.StatusNoContent},
+		{name: "not_found", operationID: "deleteSensorType", method: http.MethodDelete, path: "/sensor-types/door", want: http.StatusNotFound},
+		{name: "ok", operationID: "sensorTypeOptions", method: http.MethodOptions, path: "/sensor-types/door", want: http.StatusNoContent},
+
+		{name: "ok", operationID: "bindSensorToUser", method: http.MethodPost, path: userPath + "/sensors", header: jsonBody,
+			body: binding, want: http.StatusCreated},
+		{name: "user_not_found", operationID: "bindSensorToUser", method: http.MethodPost, path: "/users/100500/sensors",
+			header: jsonBody, body: binding, want: http.StatusNotFound},
+		{name: "ok", operationID: "getUserSensors", method: http.MethodGet, path: userPath + "/sensors", want: http.StatusOK},
+		{name: "ok", operationID: "headUserSensors", method: http.MethodHead, path: userPath + "/sensors", want: http.StatusOK},
+		{name: "ok", operationID: "usersSensorsOptions", method: http.MethodOptions, path: userPath + "/sensors", want: http.StatusNoContent},
+
+		{name: "ok", operationID: "registerEvent", method: http.MethodPost, path: "/events", header: jsonBody,
+			body: `{"sensor_serial_number": "1234567890", "payload": 1}`, want: http.StatusCreated},
+		{name: "invalid", operationID: "registerEvent", method: http.MethodPost, path: "/events", header: jsonBody,
+			body: `{"sensor_serial_number": "1234567890"}`, want: http.StatusUnprocessableEntity},
+		{name: "ok", operationID: "eventsOptions", method: http.MethodOptions, path: "/events", want: http.StatusNoContent},
+
+		{name: "json", operationID: "getHistory", method: http.MethodGet,
+			path: fmt.Sprintf("%s/history?start_date=0&end_date=%d", sensorPath, future), want: http.StatusOK},
+		{name: "csv", operationID: "getHistory", method: http.MethodGet,
+			path:   fmt.Sprintf("%s/history?start_date=0&end_date=%d", sensorPath, future),
+			header: map[string]string{"Accept": mimeCSV}, want: http.StatusOK},
+		{name: "missing_query", operationID: "getHistory", method: http.MethodGet, path: sensorPath + "/history",
+			want: http.StatusBadRequest},
+
+		{name: "ok", operationID: "createExport", method: http.MethodPost, path: "/exports", header: jsonBody,
+			body: fmt.Sprintf(`{"sensor_ids": [%d], "start_date": 0, "end_date": %d}`, s.sensorID, future), want: http.StatusAccepted},
+		{name: "sensor_not_found", operationID: "createExport", method: http.MethodPost, path: "/exports", header: jsonBody,
+			body: `{"sensor_ids": [100500], "start_date": 0, "end_date": 1}`, want: http.StatusNotFound},
+		{name: "ok", operationID: "exportsOptions", method: http.MethodOptions, path: "/exports", want: http.StatusNoContent},
+		{name: "ok", operationID: "getExport", method: http.MethodGet, path: "/exports/1", want: http.StatusOK,
+			before: s.uc.Export.Wait},
+		{name: "not_found", operationID: "getExport", method: http.MethodGet, path: "/exports/100500", want: http.StatusNotFound},
+		{name: "ok", operationID: "downloadExport", method: http.MethodGet, path: "/exports/1/file", want: http.StatusOK},
+
+		{name: "ok", operationID: "importSensors", method: http.MethodPost, path: "/imports/sensors",
+			header: map[string]string{"Content-Type": mimeCSV},
+			body:   "serial_number,type,description,is_active\n1234567891,adc,импорт,true\n", want: http.StatusOK},
+		{name: "unsupported", operationID: "importSensors", method: http.MethodPost, path: "/imports/sensors",
+			header: jsonBody, body: `{}`, want: http.StatusUnsupportedMediaType},
+		{name: "ok", operationID: "importsSensorsOptions", method: http.MethodOptions, path: "/imports/sensors", want: http.StatusNoContent},
+		{name: "ok", operationID: "importEvents", method: http.MethodPost, path: "/imports/events",
+			header: map[string]string{"Content-Type": mimeNDJSON},
+			body:   `{"sensor_serial_number": "1234567891", "timestamp": 1, "payload": 1}` + "\n", want: http.StatusOK},
+		{name: "invalid", operationID: "importEvents", method: http.MethodPost, path: "/imports/events",
+			header: map[string]string{"Content-Type": mimeCSV}, body: "payload\n1\n", want: http.StatusUnprocessableEntity},
+		{name: "ok", operationID: "importsEventsOptions", method: http.MethodOptions, path: "/imports/events", want: http.StatusNoContent},
+
+		{name: "ok", operationID: "registerActuator", method: http.MethodPost, path: "/actuators", header: jsonBody,
+			body: `{"serial_number": "0987654321", "type": "valve", "description": "кран"}`, want: http.StatusOK},
+		{name: "invalid", operationID: "registerActuator", method: http.MethodPost, path: "/actuators", header: jsonBody,
+			body: `{"serial_number": "1", "type": "valve", "description": "кран"}`, want: http.StatusUnprocessableEntity},
+		{name: "ok", operationID: "getActuators", method: http.MethodGet, path: "/actuators", header: acceptJSON, want: http.StatusOK},
+		{name: "ok", operationID: "actuatorsOptions", method: http.MethodOptions, path: "/actuators", want: http.StatusNoContent},
+		{name: "ok", operationID: "getActuator", method: http.MethodGet, path: actuatorPath, want: http.StatusOK},
+		{name: "not_found", operationID: "getActuator", method: http.MethodGet, path: "/actuators/100500", want: http.StatusNotFound},
+		{name: "invalid_id", operationID: "getActuator", method: http.MethodGet, path: "/actuators/abc", want: http.StatusUnprocessableEntity},
+		{name: "ok", operationID: "actuatorOptions", method: http.MethodOptions, path: actuatorPath, want: http.StatusNoContent},
+		{name: "ok", operationID: "sendCommand", method: http.MethodPost, path: actuatorPath + "/commands", header: jsonBody,
+			body: `{"action": "switch", "args": [{"channel": "state", "value": 1}], "ttl": 60}`, want: http.StatusAccepted},
+		{name: "invalid", operationID: "sendCommand", method: http.MethodPost, path: actuatorPath + "/commands", header: jsonBody,
+			body: `{"action": "switch", "max_attempts": 11}`, want: http.StatusUnprocessableEntity},
+		{name: "not_found", operationID: "sendCommand", method: http.MethodPost, path: "/actuators/100500/commands", header: jsonBody,
+			body: `{"action": "switch"}`, want: http.StatusNotFound},
+		{name: "ok", operationID: "commandsOptions", method: http.MethodOptions, path: actuatorPath + "/commands", want: http.StatusNoContent},
+		{name: "not_sent", operationID: "acknowledgeCommand", method: http.MethodPost, path: actuatorPath + "/commands/1/ack",
+			header: jsonBody, body: `{"status": "acknowledged"}`, want: http.StatusNotFound},
+		{name: "ok", operationID: "pollCommands", method: http.MethodGet, path: actuatorPath + "/commands/pending?wait=0", want: http.StatusOK},
+		{name: "empty", operationID: "pollCommands", method: http.MethodGet, path: actuatorPath + "/commands/pending?wait=0",
+			want: http.StatusNoContent},
+		{name: "invalid_wait", operationID: "pollCommands", method: http.MethodGet, path: actuatorPath + "/commands/pending?wait=-1",
+			want: http.StatusBadRequest},
+		{name: "ok", operationID: "getCommand", method: http.MethodGet, path: actuatorPath + "/commands/1", want: http.StatusOK},
+		{name: "not_found", operationID: "getCommand", method: http.MethodGet, path: actuatorPath + "/commands/100500",
+			want: http.StatusNotFound},
+		{name: "ok", operationID: "acknowledgeCommand", method: http.MethodPost, path: actuatorPath + "/commands/1/ack",
+			header: jsonBody, body: `{"status": "acknowledged"}`, want: http.StatusOK},
+		{name: "repeated", operationID: "acknowledgeCommand", method: http.MethodPost, path: actuatorPath + "/commands/1/ack",
+			header: jsonBody, body: `{"command_id": 1, "status": "acknowledged"}`, want: http.StatusOK},
+		{name: "finished", operationID: "acknowledgeCommand", method: http.MethodPost, path: actuatorPath + "/commands/1/ack",
+			header: jsonBody, body: `{"status": "failed", "error": "jammed"}`, want: http.StatusConflict},
+		{name: "invalid", operationID: "acknowledgeCommand", method: http.MethodPost, path: actuatorPath + "/commands/1/ack",
+			header: jsonBody, body: `{"status": "done"}`, want: http.StatusUnprocessableEntity},
+
+		{name: "ok", operationID: "createScene", method: http.MethodPost, path: "/scenes", header: jsonBody,
+			body: sceneBody, want: http.StatusCreated},
+		{name: "invalid", operationID: "createScene", method: http.MethodPost, path: "/scenes", header: jsonBody,
+			body: `{"name": "Good night", "commands": []}`, want: http.StatusUnprocessableEntity},
+		{name: "actuator_not_found", operationID: "createScene", method: http.MethodPost, path: "/scenes", header: jsonBody,
+			body: `{"name": "good_night", "commands": [{"actuator_id": 100500, "action": "switch"}]}`, want: http.StatusNotFound},
+		{name: "ok", operationID: "getScenes", method: http.MethodGet, path: "/scenes", header: acceptJSON, want: http.StatusOK},
+		{name: "ok", operationID: "scenesOptions", method: http.MethodOptions, path: "/scenes", want: http.StatusNoContent},
+		{name: "ok", operationID: "getScene", method: http.MethodGet, path: "/scenes/1", want: http.StatusOK},
+		{name: "not_found", operationID: "getScene", method: http.MethodGet, path: "/scenes/100500", want: http.StatusNotFound},
+		{name: "invalid_id", operationID: "getScene", method: http.MethodGet, path: "/scenes/abc", want: http.StatusUnprocessableEntity},
+		{name: "ok", operationID: "saveScene", method: http.MethodPut, path: "/scenes/1", header: jsonBody,
+			body: sceneBody, want: http.StatusOK},
+		{name: "not_found", operationID: "saveScene", method: http.MethodPut, path: "/scenes/100500", header: jsonBody,
+			body: sceneBody, want: http.StatusNotFound},
+		{name: "ok", operationID: "sceneOptions", method: http.MethodOptions, path: "/scenes/1", want: http.StatusNoContent},
+		{name: "ok", operationID: "triggerScene", method: http.MethodPost, path: "/scenes/1/trigger", want: http.StatusAccepted},
+		{name: "not_found", operationID: "triggerScene", method: http.MethodPost, path: "/scenes/100500/trigger", want: http.StatusNotFound},
+		{name: "ok", operationID: "sceneTriggerOptions", method: http.MethodOptions, path: "/scenes/1/trigger", want: http.StatusNoContent},
+		{name: "ok", operationID: "createSchedule", method: http.MethodPost, path: "/schedules", header: jsonBody,
+			body: `{"name": "morning", "scene_id": 1, "kind": "cron", "cron": "0 7 * * mon-fri"}`, want: http.StatusCreated},
+		{name: "invalid", operationID: "createSchedule", method: http.MethodPost, path: "/schedules", header: jsonBody,
+			body: `{"name": "morning", "scene_id": 1, "kind": "weekly"}`, want: http.StatusUnprocessableEntity},
+		{name: "never_runs", operationID: "createSchedule", method: http.MethodPost, path: "/schedules", header: jsonBody,
+			body: `{"name": "once", "scene_id": 1, "kind": "once", "at": "2018-01-01T00:00:00Z"}`, want: http.StatusUnprocessableEntity},
+		{name: "scene_not_found", operationID: "createSchedule", method: http.MethodPost, path: "/schedules", header: jsonBody,
+			body: `{"name": "evening", "scene_id": 100500, "kind": "sunset", "offset": -1800}`, want: http.StatusNotFound},
+		{name: "ok", operationID: "getSchedules", method: http.MethodGet, path: "/schedules", header: acceptJSON, want: http.StatusOK},
+		{name: "ok", operationID: "schedulesOptions", method: http.MethodOptions, path: "/schedules", want: http.StatusNoContent},
+		{name: "ok", operationID: "getSchedule", method: http.MethodGet, path: "/schedules/1", want: http.StatusOK},
+		{name: "not_found", operationID: "getSchedule", method: http.MethodGet, path: "/schedules/100500", want: http.StatusNotFound},
+		{name: "ok", operationID: "scheduleOptions", method: http.MethodOptions, path: "/schedules/1", want: http.StatusNoContent},
+		{name: "in_use", operationID: "deleteScene", method: http.MethodDelete, path: "/scenes/1", want: http.StatusConflict},
+		{name: "ok", operationID: "deleteSchedule", method: http.MethodDelete, path: "/schedules/1", want: http.StatusNoContent},
+		{name: "not_found", operationID: "deleteSchedule", method: http.MethodDelete, path: "/schedules/1", want: http.StatusNotFound},
+		{name: "ok", operationID: "deleteScene", method: http.MethodDelete, path: "/scenes/1", want: http.StatusNoContent},
+		{name: "not_found", operationID: "deleteScene", method: http.MethodDelete, path: "/scenes/1", want: http.StatusNotFound},
+
+		{name: "ok", operationID: "getChanges", method: http.MethodGet, path: "/changes?after=0&wait=0", header: acceptJSON, want: http.StatusOK},
+		{name: "empty", operationID: "getChanges", method: http.MethodGet, path: "/changes?after=100500&wait=0", want: http.StatusNoContent},
+		{name: "invalid_limit", operationID: "getChanges", method: http.MethodGet, path: "/changes?limit=0&wait=0", want: http.StatusBadRequest},
+		{name: "ok", operationID: "changesOptions", method: http.MethodOptions, path: "/changes", want: http.StatusNoContent},
+		{name: "not_found", operationID: "getChangeConsumer", method: http.MethodGet, path: "/changes/consumers/search", want: http.StatusNotFound},
+		{name: "ok", operationID: "saveChangeConsumer", method: http.MethodPut, path: "/changes/consumers/search", header: jsonBody,
+			body: `{"cursor": 1}`, want: http.StatusOK},
+		{name: "invalid", operationID: "saveChangeConsumer", method: http.MethodPut, path: "/changes/consumers/search", header: jsonBody,
+			body: `{"cursor": -1}`, want: http.StatusUnprocessableEntity},
+		{name: "ok", operationID: "getChangeConsumer", method: http.MethodGet, path: "/changes/consumers/search", want: http.StatusOK},
+		{name: "consumer", operationID: "getChanges", method: http.MethodGet, path: "/changes?consumer=search&wait=0", want: http.StatusOK},
+		{name: "ok", operationID: "changeConsumerOptions", method: http.MethodOptions, path: "/changes/consumers/search", want: http.StatusNoContent},
+	}
+
+	for _, tt := range cases {
+		s.run(tt)
+	}
+}
+
+func (s *contractSuite) TestSubscribeSensorEvents() {
+	srv := httptest.NewServer(s.router)
+	defer srv.Close()
+
+	srvURL, _ := url.Parse(srv.URL)
+	srvURL.Scheme = "ws"
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, resp, err := websocket.Dial(ctx, srvURL.String()+apiV1Prefix+fmt.Sprintf("/sensors/%d/events", s.sensorID), nil)
+	s.Require().NoError(err)
+	s.NoError(openAPI.validateResponse(http.MethodGet, "/sensors/:sensor_id/events", resp.StatusCode, resp.Header, nil))
+	s.NoError(conn.Close(websocket.StatusNormalClosure, ""))
+	s.covered["subscribeSensorEvents"] = true
+}
+
+func (s *contractSuite) TestSubscribeCommands() {
+	srv := httptest.NewServer(s.router)
+	defer srv.Close()
+
+	srvURL, _ := url.Parse(srv.URL)
+	srvURL.Scheme = "ws"
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	actuator, err := s.uc.Actuator.RegisterActuator(ctx, &domain.Actuator{SerialNumber: "1111111111", Type: "relay"})
+	s.Require().NoError(err)
+	path := fmt.Sprintf("/actuators/%d/commands/stream", actuator.ID)
+	conn, resp, err := websocket.Dial(ctx, srvURL.String()+apiV1Prefix+path, nil)
+	s.Require().NoError(err)
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	s.NoError(openAPI.validateResponse(http.MethodGet, "/actuators/:actuator_id/commands/stream", resp.StatusCode, resp.Header, nil))
+
+	queued, err := s.uc.Commands.SendCommand(ctx, actuator.ID, &domain.Command{Action: "switch"}, 0)
+	s.Require().NoError(err)
+
+	var sent models.Command
+	_, msg, err := conn.Read(ctx)
+	s.Require().NoError(err)
+	s.Require().NoError(json.Unmarshal(msg, &sent))
+	s.Equal(queued.ID, *sent.ID, "Пришла не та команда")
+	s.Equal(models.CommandStatusSent, *sent.Status)
+
+	s.Require().NoError(conn.Write(ctx, websocket.MessageText, []byte(fmt.Sprintf(`{"command_id": %d, "status": "acknowledged"}`, queued.ID))))
+	var acked models.Command
+	_, msg, err = conn.Read(ctx)
+	s.Require().NoError(err)
+	s.Require().NoError(json.Unmarshal(msg, &acked))
+	s.Equal(models.CommandStatusAcknowledged, *acked.Status, "Подтверждение не сопоставлено с командой")
+
+	s.Require().NoError(conn.Write(ctx, websocket.MessageText, []byte(`{"status": "acknowledged"}`)))
+	var p models.Error
+	_, msg, err = conn.Read(ctx)
+	s.Require().NoError(err)
+	s.Require().NoError(json.Unmarshal(msg, &p))
+	s.Equal(codeValidationFailed, *p.Code, "Подтверждение без command_id должно быть отклонено")
+	s.covered["subscribeCommands"] = true
+}
+
+func (s *contractSuite) TestSubscribeChanges() {
+	srv := httptest.NewServer(s.router)
+	defer srv.Close()
+
+	srvURL, _ := url.Parse(srv.URL)
+	srvURL.Scheme = "ws"
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	changes, err := s.uc.Changes.GetChanges(ctx, 0, 1000, 0)
+	s.Require().NoError(err)
+	s.Require().NotEmpty(changes)
+	path := fmt.Sprintf("/changes/stream?consumer=stream&after=%d", changes[len(changes)-1].Cursor)
+	conn, resp, err := websocket.Dial(ctx, srvURL.String()+apiV1Prefix+path, nil)
+	s.Require().NoError(err)
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	s.NoError(openAPI.validateResponse(http.MethodGet, "/changes/stream", resp.StatusCode, resp.Header, nil))
+
+	user, err := s.uc.User.RegisterUser(ctx, &domain.User{Name: "Подписчик"})
+	s.Require().NoError(err)
+
+	var change models.Change
+	_, msg, err := conn.Read(ctx)
+	s.Require().NoError(err)
+	s.Require().NoError(json.Unmarshal(msg, &change))
+	s.Require().NoError(change.Validate(nil))
+	s.Equal(string(domain.ChangeKindUser), *change.Kind, "Пришло не то изменение")
+	s.Equal(user.ID, *change.User.ID)
+
+	s.Require().NoError(conn.Write(ctx, websocket.MessageText, []byte(fmt.Sprintf(`{"cursor": %d}`, *change.Cursor))))
+	var consumer models.ChangeConsumer
+	_, msg, err = conn.Read(ctx)
+	s.Require().NoError(err)
+	s.Require().NoError(json.Unmarshal(msg, &consumer))
+	s.Equal(*change.Cursor, *consumer.Cursor, "Курсор потребителя не сдвинут")
+
+	cursor, err := s.uc.Changes.GetConsumerCursor(ctx, "stream")
+	s.Require().NoError(err)
+	s.Equal(*change.Cursor, cursor)
+	s.covered["subscribeChanges"] = true
+}
+
+// Каждый маршрут /api/v1 должен быть описан в спецификации и наоборот
+func (s *contractSuite) TestRoutesMatchSpec() {
+	routes := make(map[string]bool)
+	for _, r := range s.router.Routes() {
+		route, ok := strings.CutPrefix(r.Path, apiV1Prefix)
+		if !ok {
+			continue
+		}
+		routes[r.Method+" "+route] = true
+		_, documented := openAPI.operation(r.Method, route)
+		s.True(documented, "Маршрут %s %s не описан в спецификации", r.Method, r.Path)
+	}
+	for method, ops := range openAPI.doc.Analyzer.Operations() {
+		for path := range ops {
+			s.True(routes[method+" "+ginRoute(path)], "Операция %s %s не реализована", method, path)
+		}
+	}
+}
+
+func (s *contractSuite) TestSpecIsServed() {
+	t := s.T()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, apiV1Prefix+"/openapi.json", nil)
+	s.router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+	loaded, err := loadOpenAPI(w.Body.Bytes())
+	require.NoError(t, err, "Отдана невалидная спецификация")
+	assert.Equal(t, apiV1Prefix, loaded.doc.Spec().BasePath)
+
+	raw, err := os.ReadFile("../../../api/swagger.yaml")
+	require.NoError(t, err)
+	fromRepo, err := loadOpenAPI(raw)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(fromRepo.raw), w.Body.String(), "Отдана не та спецификация")
+}
+
+func (s *contractSuite) TestSpecValidation() {
+	t := s.T()
+	t.Run("request_is_rejected_before_handler", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, apiV1Prefix+fmt.Sprintf("/sensors/%d/history?start_date=abc&end_date=1", s.sensorID), nil)
+		s.router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, codeInvalidQuery, decodeProblem(t, w)["code"])
+	})
+
+	t.Run("body_is_kept_for_handler", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, apiV1Prefix+"/users", strings.NewReader(`{"name": "Пользователь"}`))
+		req.Header.Set("Content-Type", mimeJSON)
+		s.router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+	})
+
+	t.Run("body_is_too_large", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		body := `{"name": "` + strings.Repeat("я", maxJSONBody/2) + `"}`
+		req, _ := http.NewRequest(http.MethodPost, apiV1Prefix+"/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", mimeJSON)
+		s.router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, "Получили в ответ не тот код")
+		assert.Equal(t, codeBodyTooLarge, decodeProblem(t, w)["code"])
+	})
+
+	t.Run("undocumented_response", func(t *testing.T) {
+		err := openAPI.validateResponse(http.MethodGet, "/sensors/:sensor_id", http.StatusTeapot, http.Header{}, nil)
+		assert.ErrorIs(t, err, errUndocumentedResponse)
+	})
+
+	t.Run("response_body_mismatch", func(t *testing.T) {
+		header := http.Header{"Content-Type": []string{mimeJSON}}
+		err := openAPI.validateResponse(http.MethodGet, "/sensors/:sensor_id", http.StatusOK, header, []byte(`[]`))
+		assert.Error(t, err)
+	})
+
+	t.Run("legacy_paths_keep_old_shape", func(t *testing.T) {
+		path := fmt.Sprintf("/sensors/%d", s.sensorID)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		s.router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+		body, _ := io.ReadAll(w.Body)
+		assert.True(t, strings.HasPrefix(string(body), "["), "Старый путь должен возвращать массив")
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest(http.MethodGet, apiV1Prefix+path, nil)
+		s.router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+		assert.True(t, strings.HasPrefix(w.Body.String(), "{"), "Новый путь должен возвращать объект")
+	})
+}
+
+func TestContractSuite(t *testing.T) {
+	suite.Run(t, new(contractSuite))
+}
@@ -0,0 +1,44 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracePropagation(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	r := gin.New()
+	setupRouter(r, useCases, NewWebSocketHandler(useCases), newLiveSettings(DefaultSettings), testMetrics)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/sensors", nil)
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+	}
+	server, ok := spans["/api/v1/sensors"]
+	require.True(t, ok, "Нет спана запроса")
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String(), "Трейс не продолжен")
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+
+	uc, ok := spans["Sensor.GetSensors"]
+	require.True(t, ok, "Нет спана usecase")
+	assert.Equal(t, server.SpanContext().SpanID(), uc.Parent().SpanID(), "Спан usecase должен быть дочерним к спану запроса")
+}
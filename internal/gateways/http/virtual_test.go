@@ -0,0 +1,81 @@
+package http
+
+import (
+	"encoding/json"
+	"homework/internal/domain"
+	"homework/internal/gateways/http/models"
+	"homework/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVirtualSensor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	srMock := usecase.NewMockSensorRepository(ctrl)
+	srMock.EXPECT().GetSensorByID(gomock.Any(), int64(1)).Return(&domain.Sensor{ID: 1}, nil).AnyTimes()
+	srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Any()).Return(nil, usecase.ErrSensorNotFound).AnyTimes()
+	srMock.EXPECT().GetSensorBySerialNumber(gomock.Any(), "0000000003").Return(&domain.Sensor{
+		ID: 3, SerialNumber: "0000000003", Type: domain.SensorTypeVirtual, IsActive: true, Expression: "not(#1)",
+	}, nil).AnyTimes()
+	srMock.EXPECT().GetSensorBySerialNumber(gomock.Any(), gomock.Any()).Return(nil, usecase.ErrSensorNotFound).AnyTimes()
+	erMock := usecase.NewMockEventRepository(ctrl)
+
+	r := gin.New()
+	setupRouter(r, UseCases{
+		Sensor: usecase.NewSensor(srMock),
+		Event:  usecase.NewEvent(erMock, srMock),
+	}, nil, newLiveSettings(DefaultSettings), testMetrics)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, "/api/v1"+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("ok, registered", func(t *testing.T) {
+		srMock.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+		w := do(http.MethodPost, "/sensors", `{"serial_number": "0000000004", "type": "virtual", "description": "Все закрыто",
+			"is_active": true, "expression": "not( #1 )"}`)
+		require.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код: %s", w.Body.String())
+
+		var dto models.Sensor
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &dto))
+		assert.Equal(t, "not(#1)", dto.Expression)
+	})
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		code string
+	}{
+		{"unknown input", "/sensors", `{"serial_number": "0000000004", "type": "virtual", "description": "",
+			"is_active": true, "expression": "any(#1, #2)"}`, codeInvalidExpression},
+		{"bad expression", "/sensors", `{"serial_number": "0000000004", "type": "virtual", "description": "",
+			"is_active": true, "expression": "any(#1"}`, codeInvalidExpression},
+		{"expression of physical sensor", "/sensors", `{"serial_number": "0000000004", "type": "cc", "description": "",
+			"is_active": true, "expression": "any(#1)"}`, codeInvalidExpression},
+		{"event of virtual sensor", "/events", `{"sensor_serial_number": "0000000003", "payload": 1}`, codeWrongSensorType},
+	}
+	for _, tt := range tests {
+		t.Run("err, "+tt.name, func(t *testing.T) {
+			w := do(http.MethodPost, tt.path, tt.body)
+			require.Equal(t, http.StatusUnprocessableEntity, w.Code, "Получили в ответ не тот код: %s", w.Body.String())
+
+			var p models.Error
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+			assert.Equal(t, tt.code, *p.Code)
+		})
+	}
+}
@@ -1,10 +1,15 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"homework/internal/domain"
+	"homework/internal/gateways/http/models"
+	"homework/internal/usecase"
+	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -12,19 +17,46 @@ import (
 	"nhooyr.io/websocket"
 )
 
+type WebSocketSettings struct {
+	// Tick is how often the last event of the sensor is polled
+	Tick time.Duration
+	// Buffer is how many events may wait for a slow client before it is disconnected
+	Buffer int
+}
+
+var DefaultWebSocketSettings = WebSocketSettings{Tick: 2 * time.Second, Buffer: 16}
+
+// commandStreamWait is how long a single poll of the command stream lasts, the stream polls again after it
+const commandStreamWait = 30 * time.Second
+
+const (
+	// changeStreamWait and changeStreamPage are how long a single read of the change stream lasts
+	// and how many changes it takes at most
+	changeStreamWait = 30 * time.Second
+	changeStreamPage = 100
+)
+
 type WebSocketHandler struct {
 	useCases UseCases
+	settings atomic.Pointer[WebSocketSettings]
 
 	connections map[*websocket.Conn]struct{}
 	m           sync.Mutex
 }
 
 func NewWebSocketHandler(useCases UseCases) *WebSocketHandler {
-	return &WebSocketHandler{
+	h := &WebSocketHandler{
 		useCases:    useCases,
 		connections: make(map[*websocket.Conn]struct{}),
 		m:           sync.Mutex{},
 	}
+	h.SetSettings(DefaultWebSocketSettings)
+	return h
+}
+
+// SetSettings changes the settings on the fly: the tick applies to the open connections, the buffer to the new ones
+func (h *WebSocketHandler) SetSettings(settings WebSocketSettings) {
+	h.settings.Store(&settings)
 }
 
 func (h *WebSocketHandler) Handle(ctx *gin.Context, id int64) error {
@@ -42,11 +74,19 @@ func (h *WebSocketHandler) Handle(ctx *gin.Context, id int64) error {
 	h.connections[conn] = struct{}{}
 	h.m.Unlock()
 
+	// the request context is done as soon as the handler returns, the connection outlives it
+	connCtx := context.WithoutCancel(ctx.Request.Context())
 	go func() {
-		c := conn.CloseRead(ctx)
-		t := time.NewTicker(time.Second * 2)
+		c := conn.CloseRead(connCtx)
+		settings := h.settings.Load()
+		tick := settings.Tick
+		t := time.NewTicker(tick)
 		defer t.Stop()
 
+		out := make(chan []byte, settings.Buffer)
+		defer close(out)
+		go h.write(c, conn, out)
+
 		lastEvent := domain.Event{}
 		for {
 			select {
@@ -54,17 +94,23 @@ func (h *WebSocketHandler) Handle(ctx *gin.Context, id int64) error {
 				h.closeConn(conn, websocket.StatusNormalClosure, c.Err().Error())
 				return
 			case <-t.C:
+				if current := h.settings.Load().Tick; current != tick {
+					tick = current
+					t.Reset(tick)
+				}
+
 				event, err := h.useCases.Event.GetLastEventBySensorID(c, id)
 				if err != nil {
 					h.closeConn(conn, websocket.StatusInternalError, err.Error())
 					return
 				}
-				if lastEvent != *event {
+				if !lastEvent.Timestamp.Equal(event.Timestamp) || !lastEvent.Payload.Equal(event.Payload) {
 					lastEvent = *event
 					js, _ := json.Marshal(event)
-					err := conn.Write(c, websocket.MessageText, js)
-					if err != nil {
-						h.closeConn(conn, websocket.StatusInternalError, err.Error())
+					select {
+					case out <- js:
+					default:
+						h.closeConn(conn, websocket.StatusPolicyViolation, "client is too slow")
 						return
 					}
 				}
@@ -75,6 +121,191 @@ func (h *WebSocketHandler) Handle(ctx *gin.Context, id int64) error {
 	return nil
 }
 
+// HandleCommands streams the commands of the actuator to the device and takes its acknowledgements.
+// Every ack carries the command_id, the updated command or the problem is written back in reply.
+func (h *WebSocketHandler) HandleCommands(ctx *gin.Context, actuatorID int64) error {
+	if _, err := h.useCases.Actuator.GetActuatorByID(ctx, actuatorID); err != nil {
+		return err
+	}
+
+	conn, err := websocket.Accept(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		return err
+	}
+
+	h.m.Lock()
+	h.connections[conn] = struct{}{}
+	h.m.Unlock()
+
+	connCtx, cancel := context.WithCancel(context.WithoutCancel(ctx.Request.Context()))
+	path := ctx.Request.URL.Path
+	go func() {
+		defer cancel()
+		for {
+			_, msg, err := conn.Read(connCtx)
+			if err != nil {
+				h.closeConn(conn, websocket.StatusNormalClosure, err.Error())
+				return
+			}
+			if err := conn.Write(connCtx, websocket.MessageText, h.acknowledge(connCtx, actuatorID, path, msg)); err != nil {
+				h.closeConn(conn, websocket.StatusInternalError, err.Error())
+				return
+			}
+		}
+	}()
+	go func() {
+		defer cancel()
+		for {
+			commands, err := h.useCases.Commands.PollCommands(connCtx, actuatorID, commandStreamWait)
+			if connCtx.Err() != nil {
+				return
+			}
+			if err != nil {
+				h.closeConn(conn, websocket.StatusInternalError, err.Error())
+				return
+			}
+			for _, c := range commands {
+				js, _ := json.Marshal(getCommandDto(c))
+				if err := conn.Write(connCtx, websocket.MessageText, js); err != nil {
+					h.closeConn(conn, websocket.StatusInternalError, err.Error())
+					return
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// acknowledge applies the ack message and returns the reply to it
+func (h *WebSocketHandler) acknowledge(ctx context.Context, actuatorID int64, path string, msg []byte) []byte {
+	ack := models.CommandAck{}
+	if err := json.Unmarshal(msg, &ack); err != nil {
+		return problemMessage(path, http.StatusBadRequest, codeMalformedBody, err.Error())
+	}
+	if err := ack.Validate(nil); err != nil {
+		return problemMessage(path, http.StatusUnprocessableEntity, codeValidationFailed, "message is invalid", validationDetails(err)...)
+	}
+	if ack.CommandID == 0 {
+		return problemMessage(path, http.StatusUnprocessableEntity, codeValidationFailed, "command_id is required")
+	}
+
+	c, err := h.useCases.Commands.Acknowledge(ctx, actuatorID, ack.CommandID, ackFailure(&ack))
+	if err != nil {
+		return usecaseProblemMessage(path, err)
+	}
+	js, _ := json.Marshal(getCommandDto(c))
+	return js
+}
+
+// HandleChanges streams the feed after the cursor to the consumer and takes the cursors it has handled.
+// Every cursor moves the consumer, the consumer or the problem is written back in reply.
+func (h *WebSocketHandler) HandleChanges(ctx *gin.Context, consumer string, after int64) error {
+	conn, err := websocket.Accept(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		return err
+	}
+
+	h.m.Lock()
+	h.connections[conn] = struct{}{}
+	h.m.Unlock()
+
+	connCtx, cancel := context.WithCancel(context.WithoutCancel(ctx.Request.Context()))
+	path := ctx.Request.URL.Path
+	go func() {
+		defer cancel()
+		for {
+			_, msg, err := conn.Read(connCtx)
+			if err != nil {
+				h.closeConn(conn, websocket.StatusNormalClosure, err.Error())
+				return
+			}
+			if err := conn.Write(connCtx, websocket.MessageText, h.commit(connCtx, consumer, path, msg)); err != nil {
+				h.closeConn(conn, websocket.StatusInternalError, err.Error())
+				return
+			}
+		}
+	}()
+	go func() {
+		defer cancel()
+		for {
+			changes, err := h.useCases.Changes.GetChanges(connCtx, after, changeStreamPage, changeStreamWait)
+			if connCtx.Err() != nil {
+				return
+			}
+			if errors.Is(err, usecase.ErrChangesExpired) {
+				// the consumer has fallen behind the retention, it has to start over
+				h.closeConn(conn, websocket.StatusPolicyViolation, err.Error())
+				return
+			}
+			if err != nil {
+				h.closeConn(conn, websocket.StatusInternalError, err.Error())
+				return
+			}
+			for _, c := range changes {
+				js, _ := json.Marshal(getChangeDto(c))
+				if err := conn.Write(connCtx, websocket.MessageText, js); err != nil {
+					h.closeConn(conn, websocket.StatusInternalError, err.Error())
+					return
+				}
+				after = c.Cursor
+			}
+		}
+	}()
+
+	return nil
+}
+
+// commit moves the cursor of the consumer and returns the reply to the message
+func (h *WebSocketHandler) commit(ctx context.Context, consumer, path string, msg []byte) []byte {
+	cursor := models.ChangeCursor{}
+	if err := json.Unmarshal(msg, &cursor); err != nil {
+		return problemMessage(path, http.StatusBadRequest, codeMalformedBody, err.Error())
+	}
+	if err := cursor.Validate(nil); err != nil {
+		return problemMessage(path, http.StatusUnprocessableEntity, codeValidationFailed, "message is invalid", validationDetails(err)...)
+	}
+	if consumer == "" {
+		return problemMessage(path, http.StatusUnprocessableEntity, codeValidationFailed, "consumer is required to commit the cursor")
+	}
+
+	c, err := h.useCases.Changes.CommitConsumer(ctx, consumer, *cursor.Cursor)
+	if err != nil {
+		return usecaseProblemMessage(path, err)
+	}
+	js, _ := json.Marshal(getChangeConsumerDto(c))
+	return js
+}
+
+// problemMessage is the problem written to a websocket in reply to a message
+func problemMessage(path string, status int, code, reason string, details ...*models.ValidationError) []byte {
+	js, _ := json.Marshal(models.Error{
+		Type: "about:blank", Title: http.StatusText(status), Status: int64(status), Detail: reason,
+		Instance: path, Code: &code, Reason: &reason, Errors: details,
+	})
+	return js
+}
+
+// usecaseProblemMessage picks the problem for the usecase error as abortWithError does
+func usecaseProblemMessage(path string, err error) []byte {
+	for _, p := range usecaseProblems {
+		if errors.Is(err, p.err) {
+			return problemMessage(path, p.status, p.code, err.Error())
+		}
+	}
+	return problemMessage(path, http.StatusInternalServerError, codeInternal, http.StatusText(http.StatusInternalServerError))
+}
+
+// write sends the events queued for the connection until the queue is closed
+func (h *WebSocketHandler) write(ctx context.Context, conn *websocket.Conn, out <-chan []byte) {
+	for js := range out {
+		if err := conn.Write(ctx, websocket.MessageText, js); err != nil {
+			h.closeConn(conn, websocket.StatusInternalError, err.Error())
+			return
+		}
+	}
+}
+
 func (h *WebSocketHandler) closeConn(conn *websocket.Conn, code websocket.StatusCode, reason string) {
 	conn.Close(code, reason)
 
@@ -83,12 +314,26 @@ func (h *WebSocketHandler) closeConn(conn *websocket.Conn, code websocket.Status
 	h.m.Unlock()
 }
 
+// Shutdown closes all the connections at once. The clients are told that the server is restarting,
+// so they should reconnect later instead of giving up.
 func (h *WebSocketHandler) Shutdown() error {
 	h.m.Lock()
-	var e []error
+	conns := make([]*websocket.Conn, 0, len(h.connections))
 	for c := range h.connections {
-		e = append(e, c.Close(websocket.StatusNormalClosure, "server shutting down"))
+		conns = append(conns, c)
 	}
 	h.m.Unlock()
+
+	// every close waits for the client to answer, so the slow clients must not hold up the rest
+	e := make([]error, len(conns))
+	var wg sync.WaitGroup
+	for i, c := range conns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e[i] = c.Close(websocket.StatusServiceRestart, "server is restarting, reconnect later")
+		}()
+	}
+	wg.Wait()
 	return errors.Join(e...)
 }
@@ -34,7 +34,7 @@ func (t *testSuite) TestWebSocketConnection() {
 	engine := gin.Default()
 
 	erMock := usecase.NewMockEventRepository(t.ctrl)
-	erMock.EXPECT().GetLastEventBySensorID(gomock.Any(), gomock.Eq(int64(1))).Return(&domain.Event{SensorID: 1, Payload: 100}, nil).Times(1)
+	erMock.EXPECT().GetLastEventBySensorID(gomock.Any(), gomock.Eq(int64(1))).Return(&domain.Event{SensorID: 1, Payload: domain.IntPayload(100)}, nil).Times(1)
 	srMock := usecase.NewMockSensorRepository(t.ctrl)
 	srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(1))).Return(&domain.Sensor{ID: 1}, nil).Times(1)
 	urMock := usecase.NewMockUserRepository(t.ctrl)
@@ -47,7 +47,7 @@ func (t *testSuite) TestWebSocketConnection() {
 	}
 
 	ws := NewWebSocketHandler(uc)
-	setupRouter(engine, uc, ws)
+	setupRouter(engine, uc, ws, newLiveSettings(DefaultSettings), testMetrics)
 
 	srv := httptest.NewServer(engine)
 	defer srv.Close()
@@ -66,7 +66,7 @@ func (t *testSuite) TestWebSocketConnection() {
 	require.NoError(t.T(), json.Unmarshal(msg, &event))
 
 	require.Equal(t.T(), int64(1), event.SensorID)
-	require.Equal(t.T(), int64(100), event.Payload)
+	require.Equal(t.T(), domain.IntPayload(100), event.Payload)
 }
 
 func (t *testSuite) TestWebSocketConnectionFail() {
@@ -85,7 +85,7 @@ func (t *testSuite) TestWebSocketConnectionFail() {
 	}
 
 	ws := NewWebSocketHandler(uc)
-	setupRouter(engine, uc, ws)
+	setupRouter(engine, uc, ws, newLiveSettings(DefaultSettings), testMetrics)
 
 	srv := httptest.NewServer(engine)
 	defer srv.Close()
@@ -115,7 +115,7 @@ func (t *testSuite) TestWebSocketShutdown_Server() {
 	}
 
 	ws := NewWebSocketHandler(uc)
-	setupRouter(engine, uc, ws)
+	setupRouter(engine, uc, ws, newLiveSettings(DefaultSettings), testMetrics)
 
 	srv := httptest.NewServer(engine)
 	defer srv.Close()
@@ -133,7 +133,7 @@ func (t *testSuite) TestWebSocketShutdown_Server() {
 	}()
 	op, _, err := conn.Read(ctx)
 	assert.Equal(t.T(), websocket.MessageType(0), op)
-	assert.Error(t.T(), websocket.CloseError{Code: websocket.StatusNormalClosure, Reason: "server shutting down"}, err)
+	assert.Equal(t.T(), websocket.StatusServiceRestart, websocket.CloseStatus(err), "Клиент должен получить подсказку переподключиться")
 }
 
 func (t *testSuite) TestWebSocketShutdown_Client() {
@@ -151,7 +151,7 @@ func (t *testSuite) TestWebSocketShutdown_Client() {
 	}
 
 	ws := NewWebSocketHandler(uc)
-	setupRouter(engine, uc, ws)
+	setupRouter(engine, uc, ws, newLiveSettings(DefaultSettings), testMetrics)
 
 	srv := httptest.NewServer(engine)
 	defer srv.Close()
@@ -0,0 +1,254 @@
+// Package importer decodes sensors and events for the bulk import from CSV and NDJSON.
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"io"
+	"mime"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown import format")
+	ErrMissingColumn = errors.New("missing csv column")
+	ErrInvalidRecord = errors.New("invalid record")
+)
+
+var (
+	sensorColumns = []string{"serial_number", "type", "description", "is_active"}
+	eventColumns  = []string{"sensor_serial_number", "timestamp", "payload"}
+)
+
+// FormatFromMIME maps the Content-Type of a request to the import format
+func FormatFromMIME(contentType string) (Format, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", ErrUnknownFormat
+	}
+	switch mediaType {
+	case "text/csv":
+		return FormatCSV, nil
+	case "application/x-ndjson":
+		return FormatNDJSON, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+// FormatFromPath guesses the import format by the file extension
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+type sensorRecord struct {
+	SerialNumber string `json:"serial_number"`
+	Type         string `json:"type"`
+	Description  string `json:"description"`
+	IsActive     bool   `json:"is_active"`
+}
+
+type eventRecord struct {
+	SensorSerialNumber string          `json:"sensor_serial_number"`
+	Timestamp          json.RawMessage `json:"timestamp"`
+	Payload            domain.Payload  `json:"payload"`
+}
+
+// SensorReader implements usecase.SensorSource
+type SensorReader struct {
+	next func() (*domain.Sensor, error)
+}
+
+func NewSensorReader(r io.Reader, format Format) (*SensorReader, error) {
+	switch format {
+	case FormatCSV:
+		cr, err := newCSVReader(r, sensorColumns)
+		if err != nil {
+			return nil, err
+		}
+		return &SensorReader{next: func() (*domain.Sensor, error) {
+			row, err := cr.read()
+			if err != nil {
+				return nil, err
+			}
+			isActive, err := strconv.ParseBool(row["is_active"])
+			if err != nil {
+				return nil, fmt.Errorf("%w: is_active: %w", ErrInvalidRecord, err)
+			}
+			return &domain.Sensor{
+				SerialNumber: row["serial_number"],
+				Type:         domain.SensorType(row["type"]),
+				Description:  row["description"],
+				IsActive:     isActive,
+			}, nil
+		}}, nil
+	case FormatNDJSON:
+		dec := json.NewDecoder(r)
+		return &SensorReader{next: func() (*domain.Sensor, error) {
+			rec := sensorRecord{}
+			if err := decodeLine(dec, &rec); err != nil {
+				return nil, err
+			}
+			return &domain.Sensor{
+				SerialNumber: rec.SerialNumber,
+				Type:         domain.SensorType(rec.Type),
+				Description:  rec.Description,
+				IsActive:     rec.IsActive,
+			}, nil
+		}}, nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+func (r *SensorReader) Next() (*domain.Sensor, error) {
+	return r.next()
+}
+
+// EventReader implements usecase.EventSource
+type EventReader struct {
+	next func() (*domain.Event, error)
+}
+
+func NewEventReader(r io.Reader, format Format) (*EventReader, error) {
+	switch format {
+	case FormatCSV:
+		cr, err := newCSVReader(r, eventColumns)
+		if err != nil {
+			return nil, err
+		}
+		return &EventReader{next: func() (*domain.Event, error) {
+			row, err := cr.read()
+			if err != nil {
+				return nil, err
+			}
+			ts, err := parseTimestamp(row["timestamp"])
+			if err != nil {
+				return nil, err
+			}
+			payload, err := domain.ParseDecimal(row["payload"])
+			if err != nil {
+				return nil, fmt.Errorf("%w: payload: %w", ErrInvalidRecord, err)
+			}
+			return &domain.Event{
+				SensorSerialNumber: row["sensor_serial_number"],
+				Timestamp:          ts,
+				Payload:            domain.Payload{{Channel: domain.DefaultChannel, Value: payload}},
+			}, nil
+		}}, nil
+	case FormatNDJSON:
+		dec := json.NewDecoder(r)
+		return &EventReader{next: func() (*domain.Event, error) {
+			rec := eventRecord{}
+			if err := decodeLine(dec, &rec); err != nil {
+				return nil, err
+			}
+			if len(rec.Payload) == 0 {
+				return nil, fmt.Errorf("%w: payload is missing", ErrInvalidRecord)
+			}
+			var raw string
+			if err := json.Unmarshal(rec.Timestamp, &raw); err != nil {
+				// not a string, so it must be a unix timestamp
+				raw = string(rec.Timestamp)
+			}
+			ts, err := parseTimestamp(raw)
+			if err != nil {
+				return nil, err
+			}
+			return &domain.Event{SensorSerialNumber: rec.SensorSerialNumber, Timestamp: ts, Payload: rec.Payload}, nil
+		}}, nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+func (r *EventReader) Next() (*domain.Event, error) {
+	return r.next()
+}
+
+// parseTimestamp accepts either unix seconds or RFC 3339
+func parseTimestamp(raw string) (time.Time, error) {
+	if unix, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(unix, 0), nil
+	}
+	ts, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: timestamp: %w", ErrInvalidRecord, err)
+	}
+	return ts, nil
+}
+
+func decodeLine(dec *json.Decoder, v any) error {
+	if err := dec.Decode(v); err != nil {
+		if errors.Is(err, io.EOF) {
+			return io.EOF
+		}
+		return fmt.Errorf("%w: %w", ErrInvalidRecord, err)
+	}
+	return nil
+}
+
+// csvReader reads rows as maps from the header column to the value
+type csvReader struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+func newCSVReader(r io.Reader, required []string) (*csvReader, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: empty csv", ErrInvalidRecord)
+		}
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRecord, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, h := range header {
+		columns[strings.TrimSpace(h)] = i
+	}
+	for _, c := range required {
+		if _, has := columns[c]; !has {
+			return nil, fmt.Errorf("%w: %s", ErrMissingColumn, c)
+		}
+	}
+	return &csvReader{r: cr, columns: columns}, nil
+}
+
+func (r *csvReader) read() (map[string]string, error) {
+	rec, err := r.r.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRecord, err)
+	}
+	row := make(map[string]string, len(r.columns))
+	for c, i := range r.columns {
+		row[c] = rec[i]
+	}
+	return row, nil
+}
@@ -0,0 +1,167 @@
+package importer
+
+import (
+	"errors"
+	"homework/internal/domain"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Format
+		err   error
+	}{
+		{"text/csv", FormatCSV, nil},
+		{"text/csv; charset=utf-8", FormatCSV, nil},
+		{"application/x-ndjson", FormatNDJSON, nil},
+		{"application/json", "", ErrUnknownFormat},
+		{"", "", ErrUnknownFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			f, err := FormatFromMIME(tt.input)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.want, f)
+		})
+	}
+
+	f, err := FormatFromPath("/tmp/events.JSONL")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatNDJSON, f)
+
+	_, err = FormatFromPath("/tmp/events.xml")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestSensorReader(t *testing.T) {
+	t.Run("ok, csv with reordered columns", func(t *testing.T) {
+		r, err := NewSensorReader(strings.NewReader(
+			"type,serial_number,is_active,description\n"+
+				"cc,1234567890,true,door\n"+
+				"adc,0987654321,false,\"kitchen, temperature\"\n"), FormatCSV)
+		require.NoError(t, err)
+
+		s, err := r.Next()
+		require.NoError(t, err)
+		assert.Equal(t, &domain.Sensor{SerialNumber: "1234567890", Type: domain.SensorTypeContactClosure, Description: "door", IsActive: true}, s)
+
+		s, err = r.Next()
+		require.NoError(t, err)
+		assert.Equal(t, "kitchen, temperature", s.Description)
+
+		_, err = r.Next()
+		assert.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("err, csv without column", func(t *testing.T) {
+		_, err := NewSensorReader(strings.NewReader("serial_number,type\n"), FormatCSV)
+		assert.ErrorIs(t, err, ErrMissingColumn)
+	})
+
+	t.Run("err, csv with invalid flag", func(t *testing.T) {
+		r, err := NewSensorReader(strings.NewReader("serial_number,type,description,is_active\n1,cc,d,maybe\n"), FormatCSV)
+		require.NoError(t, err)
+
+		_, err = r.Next()
+		assert.ErrorIs(t, err, ErrInvalidRecord)
+	})
+
+	t.Run("ok, ndjson", func(t *testing.T) {
+		r, err := NewSensorReader(strings.NewReader(
+			`{"serial_number":"1234567890","type":"adc","description":"d","is_active":true}`+"\n"), FormatNDJSON)
+		require.NoError(t, err)
+
+		s, err := r.Next()
+		require.NoError(t, err)
+		assert.Equal(t, &domain.Sensor{SerialNumber: "1234567890", Type: domain.SensorTypeADC, Description: "d", IsActive: true}, s)
+
+		_, err = r.Next()
+		assert.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("err, unknown format", func(t *testing.T) {
+		_, err := NewSensorReader(strings.NewReader(""), "xml")
+		assert.ErrorIs(t, err, ErrUnknownFormat)
+	})
+}
+
+func TestEventReader(t *testing.T) {
+	t.Run("ok, csv", func(t *testing.T) {
+		r, err := NewEventReader(strings.NewReader(
+			"sensor_serial_number,timestamp,payload\n"+
+				"1234567890,1700000000,5\n"+
+				"1234567890,2023-11-14T22:13:21Z,6.5\n"), FormatCSV)
+		require.NoError(t, err)
+
+		e, err := r.Next()
+		require.NoError(t, err)
+		assert.Equal(t, "1234567890", e.SensorSerialNumber)
+		assert.True(t, time.Unix(1700000000, 0).Equal(e.Timestamp))
+		assert.Equal(t, domain.IntPayload(5), e.Payload)
+
+		e, err = r.Next()
+		require.NoError(t, err)
+		assert.True(t, time.Unix(1700000001, 0).Equal(e.Timestamp))
+		assert.Equal(t, domain.Payload{{Channel: domain.DefaultChannel, Value: domain.Decimal{Units: 65, Scale: 1}}}, e.Payload)
+
+		_, err = r.Next()
+		assert.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("ok, ndjson with readings", func(t *testing.T) {
+		r, err := NewEventReader(strings.NewReader(
+			`{"sensor_serial_number":"1234567890","timestamp":1700000000,"payload":[`+
+				`{"channel":"temperature","value":21.5,"unit":"°C"},{"channel":"humidity","value":40,"unit":"%"}]}`+"\n"), FormatNDJSON)
+		require.NoError(t, err)
+
+		e, err := r.Next()
+		require.NoError(t, err)
+		assert.Equal(t, domain.Payload{
+			{Channel: "temperature", Value: domain.Decimal{Units: 215, Scale: 1}, Unit: "°C"},
+			{Channel: "humidity", Value: domain.Decimal{Units: 40}, Unit: "%"},
+		}, e.Payload)
+	})
+
+	t.Run("ok, ndjson with both timestamp kinds", func(t *testing.T) {
+		r, err := NewEventReader(strings.NewReader(
+			`{"sensor_serial_number":"1234567890","timestamp":1700000000,"payload":1}`+"\n"+
+				`{"sensor_serial_number":"1234567890","timestamp":"2023-11-14T22:13:21Z","payload":2}`+"\n"), FormatNDJSON)
+		require.NoError(t, err)
+
+		e, err := r.Next()
+		require.NoError(t, err)
+		assert.True(t, time.Unix(1700000000, 0).Equal(e.Timestamp))
+
+		e, err = r.Next()
+		require.NoError(t, err)
+		assert.True(t, time.Unix(1700000001, 0).Equal(e.Timestamp))
+		assert.Equal(t, domain.IntPayload(2), e.Payload)
+	})
+
+	t.Run("err, invalid records", func(t *testing.T) {
+		inputs := []struct {
+			format Format
+			input  string
+		}{
+			{FormatCSV, "sensor_serial_number,timestamp,payload\n1,yesterday,1\n"},
+			{FormatCSV, "sensor_serial_number,timestamp,payload\n1,1,a lot\n"},
+			{FormatNDJSON, `{"sensor_serial_number":"1","timestamp":1}`},
+			{FormatNDJSON, `{ not json }`},
+			{FormatNDJSON, `{"sensor_serial_number":"1","timestamp":1,"payload":[{"channel":"t","value":1},{"channel":"t","value":2}]}`},
+		}
+		for _, in := range inputs {
+			r, err := NewEventReader(strings.NewReader(in.input), in.format)
+			require.NoError(t, err)
+
+			_, err = r.Next()
+			assert.True(t, errors.Is(err, ErrInvalidRecord), in.input)
+		}
+	})
+}
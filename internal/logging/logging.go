@@ -0,0 +1,85 @@
+// Package logging sets up the structured logger and carries the per-request loggers in the context.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+type Config struct {
+	// Level is one of debug, info, warn and error
+	Level string
+	// Format is text or json
+	Format string
+}
+
+// level is shared by all the loggers made by Setup, so it can be changed on the fly
+var level slog.LevelVar
+
+// ParseLevel accepts the level names of slog in any case, e.g. info or WARN
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", s)
+	}
+	return l, nil
+}
+
+// New makes a logger writing to w
+func New(w io.Writer, format string, leveler slog.Leveler) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: leveler}
+	switch strings.ToLower(format) {
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
+
+// Setup makes the default logger, the standard log package writes to it too
+func Setup(w io.Writer, cfg Config) error {
+	if err := SetLevel(cfg.Level); err != nil {
+		return err
+	}
+	logger, err := New(w, cfg.Format, &level)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// SetLevel changes the level of the default logger made by Setup
+func SetLevel(s string) error {
+	l, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	level.Set(l)
+	return nil
+}
+
+type loggerKey struct{}
+
+// WithLogger returns a context carrying the logger, e.g. the one with the request id
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger of the context, the default one if there is none
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
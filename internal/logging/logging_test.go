@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("ok, json", func(t *testing.T) {
+		var out bytes.Buffer
+		logger, err := New(&out, "JSON", slog.LevelInfo)
+		require.NoError(t, err)
+
+		logger.Debug("hidden")
+		logger.Info("Request is served", "status", 200)
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(out.Bytes(), &entry), "Должна быть ровно одна запись")
+		assert.Equal(t, "Request is served", entry["msg"])
+		assert.Equal(t, float64(200), entry["status"])
+	})
+
+	t.Run("ok, text", func(t *testing.T) {
+		var out bytes.Buffer
+		logger, err := New(&out, FormatText, slog.LevelInfo)
+		require.NoError(t, err)
+
+		logger.Info("Request is served", "status", 200)
+		assert.Contains(t, out.String(), `msg="Request is served" status=200`)
+	})
+
+	t.Run("fail, unknown format", func(t *testing.T) {
+		_, err := New(&bytes.Buffer{}, "xml", slog.LevelInfo)
+		assert.Error(t, err)
+	})
+}
+
+func TestSetup(t *testing.T) {
+	defaultLogger := slog.Default()
+	defer slog.SetDefault(defaultLogger)
+
+	var out bytes.Buffer
+	require.NoError(t, Setup(&out, Config{Level: "warn", Format: FormatText}))
+	slog.Info("hidden")
+	assert.Empty(t, out.String())
+
+	require.NoError(t, SetLevel("debug"))
+	slog.Debug("shown")
+	assert.Contains(t, out.String(), "shown", "Уровень должен меняться без пересоздания логгера")
+
+	assert.Error(t, SetLevel("verbose"))
+	assert.Error(t, Setup(&out, Config{Level: "info", Format: "xml"}))
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Equal(t, slog.Default(), FromContext(context.Background()))
+
+	logger := slog.Default().With("request_id", "42")
+	assert.Equal(t, logger, FromContext(WithLogger(context.Background(), logger)))
+}
@@ -0,0 +1,60 @@
+// Package metrics exports the business metrics of the smart home: the events, the sensors and the users.
+package metrics
+
+import (
+	"homework/internal/domain"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Domain implements the metrics interfaces of the usecases
+type Domain struct {
+	eventsReceived *prometheus.CounterVec
+	ingestionLag   *prometheus.HistogramVec
+	eventsRejected *prometheus.CounterVec
+	sensorBindings *prometheus.GaugeVec
+}
+
+// NewDomain registers the metrics in reg, so the tests can use a registry of their own
+func NewDomain(reg prometheus.Registerer) *Domain {
+	factory := promauto.With(reg)
+	return &Domain{
+		eventsReceived: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "events_received_total",
+			Help: "Counts the stored events by sensor type",
+		}, []string{"sensor_type"}),
+		ingestionLag: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "event_ingestion_lag_seconds",
+			Help:    "Keeps track of the time between the event and its receipt by sensor type",
+			Buckets: []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300, 900, 3600},
+		}, []string{"sensor_type"}),
+		eventsRejected: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "events_rejected_total",
+			Help: "Counts the rejected events by reason",
+		}, []string{"reason"}),
+		sensorBindings: factory.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "user_sensor_bindings",
+			Help: "Represents the number of sensors bound to the user",
+		}, []string{"user_id"}),
+	}
+}
+
+func (d *Domain) EventReceived(sensorType domain.SensorType) {
+	d.eventsReceived.WithLabelValues(string(sensorType)).Inc()
+}
+
+func (d *Domain) EventLag(sensorType domain.SensorType, lag time.Duration) {
+	// the clock of a sensor may run ahead, such events have no lag rather than a negative one
+	d.ingestionLag.WithLabelValues(string(sensorType)).Observe(max(lag, 0).Seconds())
+}
+
+func (d *Domain) EventRejected(reason string) {
+	d.eventsRejected.WithLabelValues(reason).Inc()
+}
+
+func (d *Domain) SensorsBound(userID int64, count int) {
+	d.sensorBindings.WithLabelValues(strconv.FormatInt(userID, 10)).Set(float64(count))
+}
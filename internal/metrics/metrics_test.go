@@ -0,0 +1,194 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDomain(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	d := NewDomain(reg)
+
+	d.EventReceived(domain.SensorTypeADC)
+	d.EventReceived(domain.SensorTypeADC)
+	d.EventLag(domain.SensorTypeADC, 2*time.Second)
+	d.EventLag(domain.SensorTypeADC, -time.Second)
+	d.EventRejected("inactive")
+	d.SensorsBound(1, 3)
+
+	expected := `
+# HELP events_received_total Counts the stored events by sensor type
+# TYPE events_received_total counter
+events_received_total{sensor_type="adc"} 2
+# HELP events_rejected_total Counts the rejected events by reason
+# TYPE events_rejected_total counter
+events_rejected_total{reason="inactive"} 1
+# HELP user_sensor_bindings Represents the number of sensors bound to the user
+# TYPE user_sensor_bindings gauge
+user_sensor_bindings{user_id="1"} 3
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"events_received_total", "events_rejected_total", "user_sensor_bindings"))
+
+	families, err := reg.Gather()
+	assert.NoError(t, err)
+	for _, f := range families {
+		if f.GetName() == "event_ingestion_lag_seconds" {
+			h := f.Metric[0].Histogram
+			assert.Equal(t, uint64(2), h.GetSampleCount())
+			assert.Equal(t, 2.0, h.GetSampleSum(), "Отрицательная задержка должна считаться нулевой")
+		}
+	}
+}
+
+func TestSensorCollector(t *testing.T) {
+	t.Run("ok, sensors are counted", func(t *testing.T) {
+		now := time.Now()
+		c := NewSensorCollector(func(context.Context) ([]domain.Sensor, error) {
+			return []domain.Sensor{
+				{Type: domain.SensorTypeADC, IsActive: true, LastActivity: now},
+				{Type: domain.SensorTypeADC, IsActive: true, LastActivity: now.Add(-time.Hour)},
+				{Type: domain.SensorTypeADC},
+				{Type: domain.SensorTypeContactClosure},
+				{Type: "door", IsActive: true, LastActivity: now.Add(-time.Hour)},
+				{Type: domain.SensorTypeVirtual, IsActive: true, LastActivity: now.Add(-24 * time.Hour)},
+			}, nil
+		}, func(context.Context) ([]domain.SensorTypeSpec, error) {
+			return append(domain.BuiltinSensorTypes,
+				domain.SensorTypeSpec{Name: "door", Heartbeat: 2 * time.Hour},
+				domain.SensorTypeSpec{Name: "meter"},
+			), nil
+		}, 15*time.Minute)
+
+		expected := `
+# HELP sensors_active Represents the active sensors by type
+# TYPE sensors_active gauge
+sensors_active{sensor_type="adc"} 2
+sensors_active{sensor_type="cc"} 0
+sensors_active{sensor_type="door"} 1
+sensors_active{sensor_type="meter"} 0
+sensors_active{sensor_type="virtual"} 1
+# HELP sensors_offline Represents the active sensors that have sent no events lately by type
+# TYPE sensors_offline gauge
+sensors_offline{sensor_type="adc"} 1
+sensors_offline{sensor_type="cc"} 0
+sensors_offline{sensor_type="door"} 0
+sensors_offline{sensor_type="meter"} 0
+sensors_offline{sensor_type="virtual"} 0
+# HELP sensors_registered Represents the registered sensors by type
+# TYPE sensors_registered gauge
+sensors_registered{sensor_type="adc"} 3
+sensors_registered{sensor_type="cc"} 1
+sensors_registered{sensor_type="door"} 1
+sensors_registered{sensor_type="meter"} 0
+sensors_registered{sensor_type="virtual"} 1
+`
+		assert.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(expected)))
+	})
+
+	t.Run("ok, nothing on error", func(t *testing.T) {
+		c := NewSensorCollector(func(context.Context) ([]domain.Sensor, error) {
+			return nil, errors.New("connection refused")
+		}, func(context.Context) ([]domain.SensorTypeSpec, error) {
+			return domain.BuiltinSensorTypes, nil
+		}, time.Minute)
+
+		assert.Equal(t, 0, testutil.CollectAndCount(c))
+	})
+}
+
+func TestReadingsCollector(t *testing.T) {
+	sensors := func(context.Context) ([]domain.Sensor, error) {
+		return []domain.Sensor{
+			{SerialNumber: "0000000003", Type: domain.SensorTypeADC, CurrentState: domain.IntPayload(30), LastActivity: time.Now()},
+			{SerialNumber: "0000000001", Type: domain.SensorTypeADC, CurrentState: domain.IntPayload(10), LastActivity: time.Now()},
+			{SerialNumber: "0000000002", Type: domain.SensorTypeContactClosure, CurrentState: domain.IntPayload(1)},
+			{SerialNumber: "0000000004", Type: domain.SensorTypeADC, CurrentState: domain.Payload{
+				{Channel: "temperature", Value: domain.Decimal{Units: 215, Scale: 1}, Unit: "°C"},
+				{Channel: "humidity", Value: domain.Decimal{Units: 40}, Unit: "%"},
+			}},
+		}, nil
+	}
+
+	t.Run("ok, all sensors", func(t *testing.T) {
+		c := NewReadingsCollector(sensors, nil, 10)
+
+		expected := `
+# HELP sensor_reading Represents the current state of the sensor, a series per channel
+# TYPE sensor_reading gauge
+sensor_reading{channel="value",sensor_type="adc",serial_number="0000000001"} 10
+sensor_reading{channel="value",sensor_type="adc",serial_number="0000000003"} 30
+sensor_reading{channel="humidity",sensor_type="adc",serial_number="0000000004"} 40
+sensor_reading{channel="temperature",sensor_type="adc",serial_number="0000000004"} 21.5
+sensor_reading{channel="value",sensor_type="cc",serial_number="0000000002"} 1
+# HELP sensor_readings_dropped Represents the allowed sensors that are not exported because of the limit
+# TYPE sensor_readings_dropped gauge
+sensor_readings_dropped 0
+`
+		assert.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(expected), "sensor_reading", "sensor_readings_dropped"))
+		assert.Equal(t, 2, testutil.CollectAndCount(c, "sensor_last_activity_age_seconds"),
+			"Возраст активности без событий не экспортируется")
+	})
+
+	t.Run("ok, allow-list and limit", func(t *testing.T) {
+		c := NewReadingsCollector(sensors, []string{"adc", " 0000000002"}, 2)
+
+		expected := `
+# HELP sensor_reading Represents the current state of the sensor, a series per channel
+# TYPE sensor_reading gauge
+sensor_reading{channel="value",sensor_type="adc",serial_number="0000000001"} 10
+sensor_reading{channel="value",sensor_type="cc",serial_number="0000000002"} 1
+# HELP sensor_readings_dropped Represents the allowed sensors that are not exported because of the limit
+# TYPE sensor_readings_dropped gauge
+sensor_readings_dropped 2
+`
+		assert.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(expected), "sensor_reading", "sensor_readings_dropped"))
+	})
+
+	t.Run("ok, nothing on error", func(t *testing.T) {
+		c := NewReadingsCollector(func(context.Context) ([]domain.Sensor, error) {
+			return nil, errors.New("connection refused")
+		}, nil, 10)
+
+		assert.Equal(t, 0, testutil.CollectAndCount(c))
+	})
+
+	t.Run("ok, owners", func(t *testing.T) {
+		owned := func(context.Context) ([]domain.Sensor, error) {
+			return []domain.Sensor{
+				{ID: 1, SerialNumber: "0000000001", Type: domain.SensorTypeADC, CurrentState: domain.IntPayload(10)},
+				{ID: 2, SerialNumber: "0000000002", Type: domain.SensorTypeADC, CurrentState: domain.IntPayload(20)},
+				{ID: 3, SerialNumber: "0000000003", Type: domain.SensorTypeADC, CurrentState: domain.IntPayload(30)},
+			}, nil
+		}
+		c := NewReadingsCollector(owned, nil, 10, WithReadingsOwners(func(context.Context) (map[int64][]int64, error) {
+			return map[int64][]int64{1: {1}, 2: {1, 2}}, nil
+		}))
+
+		expected := `
+# HELP sensor_reading Represents the current state of the sensor, a series per channel
+# TYPE sensor_reading gauge
+sensor_reading{channel="value",sensor_type="adc",serial_number="0000000001",user_id="1"} 10
+sensor_reading{channel="value",sensor_type="adc",serial_number="0000000002",user_id="1,2"} 20
+sensor_reading{channel="value",sensor_type="adc",serial_number="0000000003",user_id=""} 30
+`
+		assert.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(expected), "sensor_reading"),
+			"Датчик нескольких пользователей помечается их id через запятую")
+	})
+
+	t.Run("ok, nothing on error of owners", func(t *testing.T) {
+		c := NewReadingsCollector(sensors, nil, 10, WithReadingsOwners(func(context.Context) (map[int64][]int64, error) {
+			return nil, errors.New("connection refused")
+		}))
+
+		assert.Equal(t, 0, testutil.CollectAndCount(c))
+	})
+}
@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"context"
+	"homework/internal/domain"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var sensorReadingsDroppedDesc = prometheus.NewDesc("sensor_readings_dropped",
+	"Represents the allowed sensors that are not exported because of the limit", nil, nil)
+
+// ReadingsCollector exports the state of every sensor, so the readings can be graphed without a database of their own.
+// A series per sensor is expensive, so only the allowed sensors are exported and no more than the limit of them.
+type ReadingsCollector struct {
+	sensors func(ctx context.Context) ([]domain.Sensor, error)
+	owners  func(ctx context.Context) (map[int64][]int64, error)
+	allow   map[string]struct{}
+	limit   int
+
+	readingDesc *prometheus.Desc
+	ageDesc     *prometheus.Desc
+}
+
+// NewReadingsCollector exports the sensors whose serial number or type is in allow, all of them if allow is empty.
+// If there are more than limit such sensors, the ones with the smallest serial numbers are exported.
+func NewReadingsCollector(sensors func(ctx context.Context) ([]domain.Sensor, error), allow []string, limit int,
+	options ...func(*ReadingsCollector)) *ReadingsCollector {
+	c := &ReadingsCollector{sensors: sensors, allow: make(map[string]struct{}, len(allow)), limit: limit}
+	for _, a := range allow {
+		if a = strings.TrimSpace(a); a != "" {
+			c.allow[a] = struct{}{}
+		}
+	}
+	for _, o := range options {
+		o(c)
+	}
+
+	labels := []string{"serial_number", "sensor_type"}
+	if c.owners != nil {
+		labels = append(labels, "user_id")
+	}
+	c.readingDesc = prometheus.NewDesc("sensor_reading",
+		"Represents the current state of the sensor, a series per channel", append(slices.Clone(labels), "channel"), nil)
+	c.ageDesc = prometheus.NewDesc("sensor_last_activity_age_seconds",
+		"Represents the time since the last event of the sensor", labels, nil)
+	return c
+}
+
+// WithReadingsOwners adds the user_id label, the ids of the users the sensor is bound to, owners returns them
+// by sensor id. The ids of a sensor bound to several users are joined with commas, the label of an unbound one is empty.
+func WithReadingsOwners(owners func(ctx context.Context) (map[int64][]int64, error)) func(*ReadingsCollector) {
+	return func(c *ReadingsCollector) {
+		c.owners = owners
+	}
+}
+
+func (c *ReadingsCollector) allowed(s domain.Sensor) bool {
+	if len(c.allow) == 0 {
+		return true
+	}
+	_, serial := c.allow[s.SerialNumber]
+	_, kind := c.allow[string(s.Type)]
+	return serial || kind
+}
+
+func (c *ReadingsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.readingDesc
+	ch <- c.ageDesc
+	ch <- sensorReadingsDroppedDesc
+}
+
+func (c *ReadingsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	sensors, err := c.sensors(ctx)
+	if err != nil {
+		slog.Error("Can't collect sensor readings", "error", err)
+		return
+	}
+	// the series without the owners would replace the ones with them, so nothing is better
+	var owners map[int64][]int64
+	if c.owners != nil {
+		if owners, err = c.owners(ctx); err != nil {
+			slog.Error("Can't collect sensor owners", "error", err)
+			return
+		}
+	}
+
+	allowed := slices.DeleteFunc(sensors, func(s domain.Sensor) bool { return !c.allowed(s) })
+	// the same sensors are kept from scrape to scrape, otherwise the series would come and go
+	slices.SortFunc(allowed, func(a, b domain.Sensor) int { return strings.Compare(a.SerialNumber, b.SerialNumber) })
+	dropped := max(len(allowed)-c.limit, 0)
+	allowed = allowed[:len(allowed)-dropped]
+
+	now := time.Now()
+	for _, s := range allowed {
+		labels := []string{s.SerialNumber, string(s.Type)}
+		if c.owners != nil {
+			labels = append(labels, joinIDs(owners[s.ID]))
+		}
+		for _, r := range s.CurrentState {
+			ch <- prometheus.MustNewConstMetric(c.readingDesc, prometheus.GaugeValue,
+				r.Value.Float64(), append(slices.Clone(labels), r.Channel)...)
+		}
+		if !s.LastActivity.IsZero() {
+			ch <- prometheus.MustNewConstMetric(c.ageDesc, prometheus.GaugeValue,
+				now.Sub(s.LastActivity).Seconds(), labels...)
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(sensorReadingsDroppedDesc, prometheus.GaugeValue, float64(dropped))
+}
+
+func joinIDs(ids []int64) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"context"
+	"homework/internal/domain"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collectTimeout bounds the query of the sensors made on every scrape
+const collectTimeout = 5 * time.Second
+
+var (
+	sensorsRegisteredDesc = prometheus.NewDesc("sensors_registered",
+		"Represents the registered sensors by type", []string{"sensor_type"}, nil)
+	sensorsActiveDesc = prometheus.NewDesc("sensors_active",
+		"Represents the active sensors by type", []string{"sensor_type"}, nil)
+	sensorsOfflineDesc = prometheus.NewDesc("sensors_offline",
+		"Represents the active sensors that have sent no events lately by type", []string{"sensor_type"}, nil)
+)
+
+// SensorCollector counts the sensors on scrape, so the numbers are right whoever has changed the sensors
+type SensorCollector struct {
+	sensors      func(ctx context.Context) ([]domain.Sensor, error)
+	types        func(ctx context.Context) ([]domain.SensorTypeSpec, error)
+	offlineAfter time.Duration
+}
+
+// NewSensorCollector reads the sensors and their types with the given functions, usually the ones of the usecases.
+// An active sensor is offline if its last event is older than the heartbeat of its type or offlineAfter if the type has none.
+func NewSensorCollector(
+	sensors func(ctx context.Context) ([]domain.Sensor, error),
+	types func(ctx context.Context) ([]domain.SensorTypeSpec, error),
+	offlineAfter time.Duration,
+) *SensorCollector {
+	return &SensorCollector{sensors: sensors, types: types, offlineAfter: offlineAfter}
+}
+
+func (c *SensorCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sensorsRegisteredDesc
+	ch <- sensorsActiveDesc
+	ch <- sensorsOfflineDesc
+}
+
+func (c *SensorCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	sensors, err := c.sensors(ctx)
+	if err != nil {
+		slog.Error("Can't collect sensor metrics", "error", err)
+		return
+	}
+	types, err := c.types(ctx)
+	if err != nil {
+		slog.Error("Can't collect sensor metrics", "error", err)
+		return
+	}
+
+	registered := make(map[domain.SensorType]int)
+	active := make(map[domain.SensorType]int)
+	offline := make(map[domain.SensorType]int)
+	offlineAfter := make(map[domain.SensorType]time.Duration)
+	for _, t := range types {
+		registered[t.Name], active[t.Name], offline[t.Name] = 0, 0, 0
+		if t.Heartbeat > 0 {
+			offlineAfter[t.Name] = t.Heartbeat
+		}
+	}
+
+	now := time.Now()
+	for _, s := range sensors {
+		registered[s.Type]++
+		if !s.IsActive {
+			continue
+		}
+		active[s.Type]++
+		after, has := offlineAfter[s.Type]
+		if !has {
+			after = c.offlineAfter
+		}
+		// a virtual sensor writes only when its value changes, so its silence means nothing
+		if s.Type != domain.SensorTypeVirtual && now.Sub(s.LastActivity) > after {
+			offline[s.Type]++
+		}
+	}
+
+	for t := range registered {
+		ch <- prometheus.MustNewConstMetric(sensorsRegisteredDesc, prometheus.GaugeValue, float64(registered[t]), string(t))
+		ch <- prometheus.MustNewConstMetric(sensorsActiveDesc, prometheus.GaugeValue, float64(active[t]), string(t))
+		ch <- prometheus.MustNewConstMetric(sensorsOfflineDesc, prometheus.GaugeValue, float64(offline[t]), string(t))
+	}
+}
@@ -0,0 +1,84 @@
+package inmemory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"slices"
+	"sync"
+	"time"
+)
+
+var ErrNilActuatorPointer = errors.New("nil actuator is provided")
+
+type ActuatorRepository struct {
+	storage map[int64]domain.Actuator
+	lastID  int64
+	m       sync.RWMutex
+}
+
+func NewActuatorRepository() *ActuatorRepository {
+	return &ActuatorRepository{storage: map[int64]domain.Actuator{}, m: sync.RWMutex{}}
+}
+
+func (r *ActuatorRepository) SaveActuator(ctx context.Context, actuator *domain.Actuator) error {
+	if actuator == nil {
+		return ErrNilActuatorPointer
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	if actuator.ID == 0 {
+		r.lastID++
+		actuator.ID = r.lastID
+		actuator.RegisteredAt = time.Now()
+	}
+	r.storage[actuator.ID] = *actuator
+	return nil
+}
+
+func (r *ActuatorRepository) GetActuators(ctx context.Context) ([]domain.Actuator, error) {
+	r.m.RLock()
+	actuators := make([]domain.Actuator, 0, len(r.storage))
+	for _, a := range r.storage {
+		actuators = append(actuators, a)
+	}
+	r.m.RUnlock()
+
+	slices.SortFunc(actuators, func(a, b domain.Actuator) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return actuators, ctx.Err()
+}
+
+func (r *ActuatorRepository) GetActuatorByID(ctx context.Context, id int64) (*domain.Actuator, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	r.m.RLock()
+	defer r.m.RUnlock()
+	a, ok := r.storage[id]
+	if !ok {
+		return nil, usecase.ErrActuatorNotFound
+	}
+	return &a, nil
+}
+
+func (r *ActuatorRepository) GetActuatorBySerialNumber(ctx context.Context, sn string) (*domain.Actuator, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	r.m.RLock()
+	defer r.m.RUnlock()
+	for _, a := range r.storage {
+		if a.SerialNumber == sn {
+			return &a, nil
+		}
+	}
+	return nil, usecase.ErrActuatorNotFound
+}
@@ -0,0 +1,52 @@
+package inmemory
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActuatorRepository(t *testing.T) {
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		ar := NewActuatorRepository()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		assert.ErrorIs(t, ar.SaveActuator(ctx, &domain.Actuator{SerialNumber: "1234567890"}), context.Canceled)
+		_, err := ar.GetActuatorByID(ctx, 1)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("fail, nil actuator", func(t *testing.T) {
+		assert.ErrorIs(t, NewActuatorRepository().SaveActuator(context.Background(), nil), ErrNilActuatorPointer)
+	})
+
+	t.Run("ok, ids are assigned", func(t *testing.T) {
+		ar := NewActuatorRepository()
+		ctx := context.Background()
+		relay := &domain.Actuator{SerialNumber: "0000000001", Type: "relay"}
+		thermostat := &domain.Actuator{SerialNumber: "0000000002", Type: "thermostat"}
+		require.NoError(t, ar.SaveActuator(ctx, relay))
+		require.NoError(t, ar.SaveActuator(ctx, thermostat))
+		assert.Equal(t, int64(1), relay.ID)
+		assert.Equal(t, int64(2), thermostat.ID)
+		assert.NotZero(t, relay.RegisteredAt)
+
+		got, err := ar.GetActuatorBySerialNumber(ctx, "0000000002")
+		require.NoError(t, err)
+		assert.Equal(t, *thermostat, *got)
+
+		actuators, err := ar.GetActuators(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.Actuator{*relay, *thermostat}, actuators)
+
+		_, err = ar.GetActuatorByID(ctx, 3)
+		assert.ErrorIs(t, err, usecase.ErrActuatorNotFound)
+		_, err = ar.GetActuatorBySerialNumber(ctx, "0000000003")
+		assert.ErrorIs(t, err, usecase.ErrActuatorNotFound)
+	})
+}
@@ -0,0 +1,105 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"slices"
+	"sync"
+)
+
+var ErrNilCommandPointer = errors.New("nil command is provided")
+
+// CommandRepository keeps the finished commands too, the active ones of an actuator are indexed apart
+type CommandRepository struct {
+	storage map[int64]domain.Command
+	active  map[int64][]int64
+	lastID  int64
+	m       sync.RWMutex
+}
+
+func NewCommandRepository() *CommandRepository {
+	return &CommandRepository{storage: map[int64]domain.Command{}, active: map[int64][]int64{}, m: sync.RWMutex{}}
+}
+
+func (r *CommandRepository) SaveCommand(ctx context.Context, command *domain.Command) error {
+	if command == nil {
+		return ErrNilCommandPointer
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	if command.ID == 0 {
+		r.lastID++
+		command.ID = r.lastID
+		r.active[command.ActuatorID] = append(r.active[command.ActuatorID], command.ID)
+	}
+	r.store(command)
+	return nil
+}
+
+func (r *CommandRepository) UpdateCommand(ctx context.Context, command *domain.Command, status domain.CommandStatus, attempts int) error {
+	if command == nil {
+		return ErrNilCommandPointer
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	stored, ok := r.storage[command.ID]
+	if !ok {
+		return usecase.ErrCommandNotFound
+	}
+	if stored.Status != status || stored.Attempts != attempts {
+		return usecase.ErrCommandChanged
+	}
+	r.store(command)
+	return nil
+}
+
+// store keeps the command and takes the finished one out of the active ones, r.m must be held
+func (r *CommandRepository) store(command *domain.Command) {
+	r.storage[command.ID] = *command
+	if command.Finished() {
+		ids := slices.DeleteFunc(r.active[command.ActuatorID], func(id int64) bool { return id == command.ID })
+		if len(ids) == 0 {
+			delete(r.active, command.ActuatorID)
+		} else {
+			r.active[command.ActuatorID] = ids
+		}
+	}
+}
+
+func (r *CommandRepository) GetCommandByID(ctx context.Context, id int64) (*domain.Command, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	r.m.RLock()
+	defer r.m.RUnlock()
+	c, ok := r.storage[id]
+	if !ok {
+		return nil, usecase.ErrCommandNotFound
+	}
+	return &c, nil
+}
+
+func (r *CommandRepository) GetActiveCommands(ctx context.Context, actuatorID int64) ([]*domain.Command, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	r.m.RLock()
+	defer r.m.RUnlock()
+	ids := r.active[actuatorID]
+	commands := make([]*domain.Command, len(ids))
+	for i, id := range ids {
+		c := r.storage[id]
+		commands[i] = &c
+	}
+	return commands, nil
+}
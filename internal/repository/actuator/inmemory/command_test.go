@@ -0,0 +1,80 @@
+package inmemory
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandRepository(t *testing.T) {
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		cr := NewCommandRepository()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		assert.ErrorIs(t, cr.SaveCommand(ctx, &domain.Command{ActuatorID: 1}), context.Canceled)
+		_, err := cr.GetActiveCommands(ctx, 1)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("ok, finished commands are not active", func(t *testing.T) {
+		cr := NewCommandRepository()
+		ctx := context.Background()
+		first := &domain.Command{ActuatorID: 1, Action: "switch", Status: domain.CommandStatusQueued}
+		second := &domain.Command{ActuatorID: 1, Action: "switch", Status: domain.CommandStatusQueued}
+		other := &domain.Command{ActuatorID: 2, Action: "set", Status: domain.CommandStatusQueued}
+		for _, c := range []*domain.Command{first, second, other} {
+			require.NoError(t, cr.SaveCommand(ctx, c))
+		}
+		assert.Equal(t, int64(3), other.ID)
+
+		active, err := cr.GetActiveCommands(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []*domain.Command{first, second}, active, "Команды должны идти в порядке постановки в очередь")
+
+		first.Acknowledge(time.Now(), "")
+		require.NoError(t, cr.SaveCommand(ctx, first))
+		active, err = cr.GetActiveCommands(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []*domain.Command{second}, active)
+
+		got, err := cr.GetCommandByID(ctx, first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.CommandStatusAcknowledged, got.Status, "Завершенная команда должна храниться")
+
+		_, err = cr.GetCommandByID(ctx, 100500)
+		assert.ErrorIs(t, err, usecase.ErrCommandNotFound)
+	})
+
+	t.Run("ok, command is updated only in the state it was read in", func(t *testing.T) {
+		cr := NewCommandRepository()
+		ctx := context.Background()
+		now := time.Now()
+		command := &domain.Command{ActuatorID: 1, Action: "switch", Status: domain.CommandStatusQueued, MaxAttempts: 2}
+		require.NoError(t, cr.SaveCommand(ctx, command))
+
+		// two replicas read the queued command and send it
+		first, err := cr.GetCommandByID(ctx, command.ID)
+		require.NoError(t, err)
+		second, err := cr.GetCommandByID(ctx, command.ID)
+		require.NoError(t, err)
+		first.Send(now)
+		second.Send(now)
+		require.NoError(t, cr.UpdateCommand(ctx, first, domain.CommandStatusQueued, 0))
+		assert.ErrorIs(t, cr.UpdateCommand(ctx, second, domain.CommandStatusQueued, 0), usecase.ErrCommandChanged,
+			"Команда должна отправиться один раз")
+
+		first.Acknowledge(now, "")
+		require.NoError(t, cr.UpdateCommand(ctx, first, domain.CommandStatusSent, 1))
+		active, err := cr.GetActiveCommands(ctx, 1)
+		require.NoError(t, err)
+		assert.Empty(t, active, "Завершенная команда не должна быть в очереди")
+
+		assert.ErrorIs(t, cr.UpdateCommand(ctx, &domain.Command{ID: 100500}, domain.CommandStatusQueued, 0), usecase.ErrCommandNotFound)
+	})
+}
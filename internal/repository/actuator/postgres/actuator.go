@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ActuatorRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewActuatorRepository(pool *pgxpool.Pool) *ActuatorRepository {
+	return &ActuatorRepository{
+		pool: pool,
+	}
+}
+
+const saveActuatorQuery = `
+insert into db.public.actuators (serial_number, type, description, registered_at)
+values ($1, $2, $3, $4)
+returning id;`
+
+const updateActuatorQuery = `
+update db.public.actuators
+set type = $2, description = $3
+where id = $1`
+
+func (r *ActuatorRepository) SaveActuator(ctx context.Context, actuator *domain.Actuator) error {
+	if actuator.ID != 0 {
+		if _, err := r.pool.Exec(ctx, updateActuatorQuery, actuator.ID, actuator.Type, actuator.Description); err != nil {
+			return fmt.Errorf("can't update actuator: %w", err)
+		}
+		return ctx.Err()
+	}
+
+	registeredAt := time.Now()
+	err := r.pool.QueryRow(ctx, saveActuatorQuery, actuator.SerialNumber, actuator.Type, actuator.Description, registeredAt).
+		Scan(&actuator.ID)
+	if err != nil {
+		return fmt.Errorf("can't insert actuator: %w", err)
+	}
+	actuator.RegisteredAt = registeredAt
+	return ctx.Err()
+}
+
+const getActuatorsQuery = `
+select id, serial_number, type, description, registered_at
+from db.public.actuators
+order by id;`
+
+func (r *ActuatorRepository) GetActuators(ctx context.Context) ([]domain.Actuator, error) {
+	rows, err := r.pool.Query(ctx, getActuatorsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("can't query actuators: %w", err)
+	}
+	defer rows.Close()
+
+	actuators := make([]domain.Actuator, 0)
+	for rows.Next() {
+		a, err := scanActuator(rows)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan actuator: %w", err)
+		}
+		actuators = append(actuators, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't read actuators: %w", err)
+	}
+	return actuators, ctx.Err()
+}
+
+const getActuatorByIDQuery = `
+select id, serial_number, type, description, registered_at
+from db.public.actuators
+where id = $1;`
+
+func (r *ActuatorRepository) GetActuatorByID(ctx context.Context, id int64) (*domain.Actuator, error) {
+	return r.getActuator(ctx, getActuatorByIDQuery, id)
+}
+
+const getActuatorBySerialNumberQuery = `
+select id, serial_number, type, description, registered_at
+from db.public.actuators
+where serial_number = $1;`
+
+func (r *ActuatorRepository) GetActuatorBySerialNumber(ctx context.Context, sn string) (*domain.Actuator, error) {
+	return r.getActuator(ctx, getActuatorBySerialNumberQuery, sn)
+}
+
+func (r *ActuatorRepository) getActuator(ctx context.Context, query string, arg any) (*domain.Actuator, error) {
+	a, err := scanActuator(r.pool.QueryRow(ctx, query, arg))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, usecase.ErrActuatorNotFound
+		}
+		return nil, fmt.Errorf("can't scan actuator: %w", err)
+	}
+	return &a, ctx.Err()
+}
+
+func scanActuator(row pgx.Row) (domain.Actuator, error) {
+	var a domain.Actuator
+	err := row.Scan(&a.ID, &a.SerialNumber, &a.Type, &a.Description, &a.RegisteredAt)
+	return a, err
+}
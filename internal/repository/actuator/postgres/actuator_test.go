@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"homework/pkg/pg_test"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ActuatorTestSuite struct {
+	suite.Suite
+	testDbInstance *pgxpool.Pool
+	testDB         *pg_test.TestDatabase
+
+	repo *ActuatorRepository
+}
+
+func (suite *ActuatorTestSuite) SetupSuite() {
+	suite.testDB = pg_test.SetupTestDatabase()
+	suite.testDbInstance = suite.testDB.DbInstance
+
+	suite.repo = NewActuatorRepository(suite.testDbInstance)
+}
+
+func (suite *ActuatorTestSuite) TearDownSuite() {
+	suite.testDB.TearDown()
+}
+
+func (suite *ActuatorTestSuite) TestActuatorRepository_SaveActuator() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	relay := domain.Actuator{SerialNumber: "1234567890", Type: "relay", Description: "Свет в прихожей"}
+	assert.Nil(suite.T(), suite.repo.SaveActuator(ctx, &relay))
+	assert.NotZero(suite.T(), relay.ID)
+
+	relay.Description = "Свет в коридоре"
+	assert.Nil(suite.T(), suite.repo.SaveActuator(ctx, &relay))
+
+	got, err := suite.repo.GetActuatorBySerialNumber(ctx, "1234567890")
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), relay.ID, got.ID)
+	assert.Equal(suite.T(), "Свет в коридоре", got.Description)
+	assert.WithinDuration(suite.T(), relay.RegisteredAt, got.RegisteredAt, time.Millisecond)
+
+	got, err = suite.repo.GetActuatorByID(ctx, relay.ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "1234567890", got.SerialNumber)
+
+	_, err = suite.repo.GetActuatorByID(ctx, 100500)
+	assert.ErrorIs(suite.T(), err, usecase.ErrActuatorNotFound)
+}
+
+func (suite *ActuatorTestSuite) TestActuatorRepository_GetActuators() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	first := domain.Actuator{SerialNumber: "0000000011", Type: "relay"}
+	second := domain.Actuator{SerialNumber: "0000000012", Type: "thermostat"}
+	assert.Nil(suite.T(), suite.repo.SaveActuator(ctx, &first))
+	assert.Nil(suite.T(), suite.repo.SaveActuator(ctx, &second))
+
+	actuators, err := suite.repo.GetActuators(ctx)
+	assert.Nil(suite.T(), err)
+	var ids []int64
+	for _, a := range actuators {
+		ids = append(ids, a.ID)
+	}
+	assert.IsIncreasing(suite.T(), ids, "Устройства должны быть упорядочены по ID")
+	assert.Contains(suite.T(), ids, first.ID)
+	assert.Contains(suite.T(), ids, second.ID)
+}
+
+func TestActuatorTestSuite(t *testing.T) {
+	suite.Run(t, new(ActuatorTestSuite))
+}
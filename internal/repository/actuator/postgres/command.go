@@ -0,0 +1,160 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CommandRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewCommandRepository(pool *pgxpool.Pool) *CommandRepository {
+	return &CommandRepository{
+		pool: pool,
+	}
+}
+
+const saveCommandQuery = `
+insert into db.public.commands (actuator_id, action, args, status, attempts, max_attempts, ack_timeout_ms,
+                                created_at, sent_at, expires_at, completed_at, error)
+values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+returning id;`
+
+// updateCommandQuery changes only what the delivery changes
+const updateCommandQuery = `
+update db.public.commands
+set status = $2, attempts = $3, sent_at = $4, completed_at = $5, error = $6
+where id = $1`
+
+func (r *CommandRepository) SaveCommand(ctx context.Context, command *domain.Command) error {
+	if command.ID != 0 {
+		_, err := r.pool.Exec(ctx, updateCommandQuery, command.ID, command.Status, command.Attempts,
+			nullTime(command.SentAt), nullTime(command.CompletedAt), command.Error)
+		if err != nil {
+			return fmt.Errorf("can't update command: %w", err)
+		}
+		return ctx.Err()
+	}
+
+	var args any
+	if len(command.Args) > 0 {
+		args = command.Args
+	}
+	err := r.pool.QueryRow(ctx, saveCommandQuery, command.ActuatorID, command.Action, args, command.Status,
+		command.Attempts, command.MaxAttempts, command.AckTimeout.Milliseconds(), command.CreatedAt,
+		nullTime(command.SentAt), command.ExpiresAt, nullTime(command.CompletedAt), command.Error).Scan(&command.ID)
+	if err != nil {
+		return fmt.Errorf("can't insert command: %w", err)
+	}
+	return ctx.Err()
+}
+
+// updateCommandIfQuery changes the command only if it is still in the state it was read in
+const updateCommandIfQuery = `
+update db.public.commands
+set status = $2, attempts = $3, sent_at = $4, completed_at = $5, error = $6
+where id = $1 and status = $7 and attempts = $8`
+
+func (r *CommandRepository) UpdateCommand(ctx context.Context, command *domain.Command, status domain.CommandStatus, attempts int) error {
+	tag, err := r.pool.Exec(ctx, updateCommandIfQuery, command.ID, command.Status, command.Attempts,
+		nullTime(command.SentAt), nullTime(command.CompletedAt), command.Error, status, attempts)
+	if err != nil {
+		return fmt.Errorf("can't update command: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		if _, err := r.GetCommandByID(ctx, command.ID); err != nil {
+			return err
+		}
+		return usecase.ErrCommandChanged
+	}
+	return ctx.Err()
+}
+
+// nullTime keeps the moments that have not come yet as NULL
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+const commandColumns = `id, actuator_id, action, args, status, attempts, max_attempts, ack_timeout_ms,
+       created_at, sent_at, expires_at, completed_at, error`
+
+const getCommandByIDQuery = `
+select ` + commandColumns + `
+from db.public.commands
+where id = $1;`
+
+func (r *CommandRepository) GetCommandByID(ctx context.Context, id int64) (*domain.Command, error) {
+	c, err := scanCommand(r.pool.QueryRow(ctx, getCommandByIDQuery, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, usecase.ErrCommandNotFound
+		}
+		return nil, fmt.Errorf("can't scan command: %w", err)
+	}
+	return c, ctx.Err()
+}
+
+const getActiveCommandsQuery = `
+select ` + commandColumns + `
+from db.public.commands
+where actuator_id = $1 and status in ('queued', 'sent')
+order by id;`
+
+func (r *CommandRepository) GetActiveCommands(ctx context.Context, actuatorID int64) ([]*domain.Command, error) {
+	rows, err := r.pool.Query(ctx, getActiveCommandsQuery, actuatorID)
+	if err != nil {
+		return nil, fmt.Errorf("can't query commands: %w", err)
+	}
+	defer rows.Close()
+
+	commands := make([]*domain.Command, 0)
+	for rows.Next() {
+		c, err := scanCommand(rows)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan command: %w", err)
+		}
+		commands = append(commands, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't read commands: %w", err)
+	}
+	return commands, ctx.Err()
+}
+
+func scanCommand(row pgx.Row) (*domain.Command, error) {
+	var c domain.Command
+	var args []byte
+	var ackTimeout int64
+	var sentAt, completedAt *time.Time
+	err := row.Scan(&c.ID, &c.ActuatorID, &c.Action, &args, &c.Status, &c.Attempts, &c.MaxAttempts, &ackTimeout,
+		&c.CreatedAt, &sentAt, &c.ExpiresAt, &completedAt, &c.Error)
+	if err != nil {
+		return nil, err
+	}
+
+	if args != nil {
+		if err := json.Unmarshal(args, &c.Args); err != nil {
+			return nil, fmt.Errorf("can't decode args: %w", err)
+		}
+	}
+	c.AckTimeout = time.Duration(ackTimeout) * time.Millisecond
+	if sentAt != nil {
+		c.SentAt = *sentAt
+	}
+	if completedAt != nil {
+		c.CompletedAt = *completedAt
+	}
+	return &c, nil
+}
@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"homework/pkg/pg_test"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type CommandTestSuite struct {
+	suite.Suite
+	testDbInstance *pgxpool.Pool
+	testDB         *pg_test.TestDatabase
+
+	repo     *CommandRepository
+	actuator domain.Actuator
+}
+
+func (suite *CommandTestSuite) SetupSuite() {
+	suite.testDB = pg_test.SetupTestDatabase()
+	suite.testDbInstance = suite.testDB.DbInstance
+
+	suite.repo = NewCommandRepository(suite.testDbInstance)
+	suite.actuator = domain.Actuator{SerialNumber: "2234567890", Type: "thermostat"}
+	suite.Require().NoError(NewActuatorRepository(suite.testDbInstance).SaveActuator(context.Background(), &suite.actuator))
+}
+
+func (suite *CommandTestSuite) TearDownSuite() {
+	suite.testDB.TearDown()
+}
+
+func (suite *CommandTestSuite) TestCommandRepository_Lifecycle() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	command := domain.Command{
+		ActuatorID:  suite.actuator.ID,
+		Action:      "set",
+		Args:        domain.Payload{{Channel: "temperature", Value: domain.Decimal{Units: 215, Scale: 1}, Unit: "°C"}},
+		Status:      domain.CommandStatusQueued,
+		MaxAttempts: 3,
+		AckTimeout:  10 * time.Second,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Minute),
+	}
+	assert.Nil(suite.T(), suite.repo.SaveCommand(ctx, &command))
+	assert.NotZero(suite.T(), command.ID)
+
+	got, err := suite.repo.GetCommandByID(ctx, command.ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), command, *got)
+
+	command.Send(now.Add(time.Second))
+	assert.Nil(suite.T(), suite.repo.SaveCommand(ctx, &command))
+	active, err := suite.repo.GetActiveCommands(ctx, suite.actuator.ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []*domain.Command{&command}, active)
+
+	command.Acknowledge(now.Add(2*time.Second), "")
+	assert.Nil(suite.T(), suite.repo.SaveCommand(ctx, &command))
+	active, err = suite.repo.GetActiveCommands(ctx, suite.actuator.ID)
+	assert.Nil(suite.T(), err)
+	assert.Empty(suite.T(), active, "Завершенная команда не должна быть в очереди")
+
+	got, err = suite.repo.GetCommandByID(ctx, command.ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), command, *got)
+
+	_, err = suite.repo.GetCommandByID(ctx, 100500)
+	assert.ErrorIs(suite.T(), err, usecase.ErrCommandNotFound)
+}
+
+func (suite *CommandTestSuite) TestCommandRepository_UpdateCommand() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	command := domain.Command{
+		ActuatorID:  suite.actuator.ID,
+		Action:      "switch",
+		Status:      domain.CommandStatusQueued,
+		MaxAttempts: 3,
+		AckTimeout:  10 * time.Second,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Minute),
+	}
+	suite.Require().NoError(suite.repo.SaveCommand(ctx, &command))
+
+	// two replicas read the queued command and send it
+	first, second := command, command
+	first.Send(now)
+	second.Send(now)
+	assert.Nil(suite.T(), suite.repo.UpdateCommand(ctx, &first, domain.CommandStatusQueued, 0))
+	assert.ErrorIs(suite.T(), suite.repo.UpdateCommand(ctx, &second, domain.CommandStatusQueued, 0), usecase.ErrCommandChanged,
+		"Команда должна отправиться один раз")
+
+	got, err := suite.repo.GetCommandByID(ctx, command.ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), first, *got)
+
+	missing := first
+	missing.ID = 100500
+	assert.ErrorIs(suite.T(), suite.repo.UpdateCommand(ctx, &missing, domain.CommandStatusSent, 1), usecase.ErrCommandNotFound)
+}
+
+func TestCommandTestSuite(t *testing.T) {
+	suite.Run(t, new(CommandTestSuite))
+}
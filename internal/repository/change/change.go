@@ -0,0 +1,55 @@
+// Package change keeps the encoding of the change feed the databases share: the kind of the change in a column
+// and the entity as json, the same json the persistence of the in-memory mode writes.
+package change
+
+import (
+	"encoding/json"
+	"fmt"
+	"homework/internal/domain"
+	"time"
+)
+
+// Encode returns the entity of the change as it is kept
+func Encode(c domain.Change) ([]byte, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	var entity any
+	switch c.Kind {
+	case domain.ChangeKindSensor:
+		entity = c.Sensor
+	case domain.ChangeKindUser:
+		entity = c.User
+	case domain.ChangeKindSensorOwner:
+		entity = c.SensorOwner
+	default:
+		entity = c.Event
+	}
+	return json.Marshal(entity)
+}
+
+// Decode makes the change of the kept row
+func Decode(cursor int64, kind string, t time.Time, data []byte) (domain.Change, error) {
+	c := domain.Change{Cursor: cursor, Kind: domain.ChangeKind(kind), Time: t}
+	var entity any
+	switch c.Kind {
+	case domain.ChangeKindSensor:
+		c.Sensor = &domain.Sensor{}
+		entity = c.Sensor
+	case domain.ChangeKindUser:
+		c.User = &domain.User{}
+		entity = c.User
+	case domain.ChangeKindSensorOwner:
+		c.SensorOwner = &domain.SensorOwner{}
+		entity = c.SensorOwner
+	case domain.ChangeKindEvent:
+		c.Event = &domain.Event{}
+		entity = c.Event
+	default:
+		return domain.Change{}, fmt.Errorf("unknown change kind %q", kind)
+	}
+	if err := json.Unmarshal(data, entity); err != nil {
+		return domain.Change{}, fmt.Errorf("can't decode %s of change %d: %w", kind, cursor, err)
+	}
+	return c, nil
+}
@@ -0,0 +1,192 @@
+package inmemory
+
+import (
+	"cmp"
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ChangeRepository keeps the feed in memory. The in-memory repositories record their changes into it
+// under their own locks, so the changes of an entity are in the feed in the order they were made.
+// The cursors are assigned without gaps, and a change is readable as soon as it is recorded.
+type ChangeRepository struct {
+	m sync.RWMutex
+	// changes are ordered by the cursor
+	changes []domain.Change
+	// last is the cursor of the last recorded change, pruned is the cursor of the last deleted one
+	last, pruned int64
+	// changed is closed and replaced by every change
+	changed   chan struct{}
+	consumers map[string]domain.ChangeConsumer
+	// paused drops the recorded changes, the store that restores the repositories brings its own
+	paused bool
+	now    func() time.Time
+}
+
+func NewChangeRepository() *ChangeRepository {
+	return &ChangeRepository{changed: make(chan struct{}), consumers: map[string]domain.ChangeConsumer{}, now: time.Now}
+}
+
+// Record assigns the change the next cursor and the time and appends it to the feed
+func (r *ChangeRepository) Record(change domain.Change) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.paused {
+		return
+	}
+	r.last++
+	change.Cursor, change.Time = r.last, r.now()
+	r.append(change)
+}
+
+// Restore appends the change with the cursor and the time it was recorded with. A change the feed already has
+// or has already deleted is skipped, so a replay may repeat the changes.
+func (r *ChangeRepository) Restore(change domain.Change) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if change.Cursor <= r.pruned || len(r.changes) > 0 && change.Cursor <= r.changes[len(r.changes)-1].Cursor {
+		return
+	}
+	r.last = max(r.last, change.Cursor)
+	r.append(change)
+}
+
+func (r *ChangeRepository) append(change domain.Change) {
+	r.changes = append(r.changes, change)
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// Pause stops recording the changes until the returned resume is called
+func (r *ChangeRepository) Pause() (resume func()) {
+	r.m.Lock()
+	r.paused = true
+	r.m.Unlock()
+	return func() {
+		r.m.Lock()
+		r.paused = false
+		r.m.Unlock()
+	}
+}
+
+// Last returns the cursor of the last change
+func (r *ChangeRepository) Last() int64 {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	return r.last
+}
+
+// Horizon returns the cursors of the last recorded and the last deleted changes
+func (r *ChangeRepository) Horizon() (last, pruned int64) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	return r.last, r.pruned
+}
+
+// SetHorizon restores the cursors of the last recorded and the last deleted changes, they never go back
+func (r *ChangeRepository) SetHorizon(last, pruned int64) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.last, r.pruned = max(r.last, last), max(r.pruned, pruned)
+}
+
+// GetConsumers returns all the consumers ordered by name
+func (r *ChangeRepository) GetConsumers(ctx context.Context) ([]domain.ChangeConsumer, error) {
+	r.m.RLock()
+	consumers := make([]domain.ChangeConsumer, 0, len(r.consumers))
+	for _, c := range r.consumers {
+		consumers = append(consumers, c)
+	}
+	r.m.RUnlock()
+	slices.SortFunc(consumers, func(a, b domain.ChangeConsumer) int { return cmp.Compare(a.Name, b.Name) })
+	return consumers, ctx.Err()
+}
+
+func (r *ChangeRepository) Changed() <-chan struct{} {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	return r.changed
+}
+
+func (r *ChangeRepository) GetChanges(ctx context.Context, after int64, limit int) ([]domain.Change, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	if after > 0 && after < r.pruned {
+		return nil, usecase.ErrChangesExpired
+	}
+
+	i := sort.Search(len(r.changes), func(i int) bool { return r.changes[i].Cursor > after })
+	end := i + min(len(r.changes)-i, max(limit, 0))
+	changes := make([]domain.Change, 0, end-i)
+	for _, c := range r.changes[i:end] {
+		changes = append(changes, clone(c))
+	}
+	return changes, ctx.Err()
+}
+
+// clone copies the entity of the change, so the caller can't change the stored one
+func clone(c domain.Change) domain.Change {
+	switch {
+	case c.Sensor != nil:
+		sensor := c.Sensor.Clone()
+		c.Sensor = &sensor
+	case c.User != nil:
+		user := *c.User
+		c.User = &user
+	case c.SensorOwner != nil:
+		owner := *c.SensorOwner
+		c.SensorOwner = &owner
+	case c.Event != nil:
+		event := c.Event.Clone()
+		c.Event = &event
+	}
+	return c
+}
+
+func (r *ChangeRepository) DeleteChanges(ctx context.Context, before time.Time) (int64, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	// the times grow with the cursors, apart from a clock going back a little
+	i := 0
+	for i < len(r.changes) && r.changes[i].Time.Before(before) {
+		i++
+	}
+	if i == 0 {
+		return 0, ctx.Err()
+	}
+	r.pruned = r.changes[i-1].Cursor
+	// the kept ones are moved to a new array, the old one would hold the deleted entities
+	r.changes = slices.Clone(r.changes[i:])
+	return int64(i), ctx.Err()
+}
+
+func (r *ChangeRepository) SaveConsumer(ctx context.Context, consumer domain.ChangeConsumer) error {
+	r.m.Lock()
+	r.consumers[consumer.Name] = consumer
+	r.m.Unlock()
+	return ctx.Err()
+}
+
+func (r *ChangeRepository) GetConsumer(ctx context.Context, name string) (*domain.ChangeConsumer, error) {
+	r.m.RLock()
+	consumer, has := r.consumers[name]
+	r.m.RUnlock()
+	if !has {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, usecase.ErrConsumerNotFound
+	}
+	return &consumer, ctx.Err()
+}
+
+var (
+	_ usecase.ChangeRepository = (*ChangeRepository)(nil)
+	_ usecase.ChangeNotifier   = (*ChangeRepository)(nil)
+)
@@ -0,0 +1,25 @@
+package inmemory
+
+import (
+	"homework/internal/repository/conformance"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	eventInmemory "homework/internal/repository/event/inmemory"
+	sensorInmemory "homework/internal/repository/sensor/inmemory"
+	userInmemory "homework/internal/repository/user/inmemory"
+)
+
+func TestChangeRepository_Conformance(t *testing.T) {
+	suite.Run(t, &conformance.ChangeSuite{NewRepositories: func(*testing.T) conformance.ChangeRepositories {
+		changes := NewChangeRepository()
+		return conformance.ChangeRepositories{
+			Sensors:      sensorInmemory.NewSensorRepository(sensorInmemory.WithSensorChanges(changes.Record)),
+			Events:       eventInmemory.NewEventRepository(eventInmemory.WithEventChanges(changes.Record)),
+			Users:        userInmemory.NewUserRepository(userInmemory.WithUserChanges(changes.Record)),
+			SensorOwners: userInmemory.NewSensorOwnerRepository(userInmemory.WithSensorOwnerChanges(changes.Record)),
+			Changes:      changes,
+		}
+	}})
+}
@@ -0,0 +1,209 @@
+// Package postgres keeps the change feed in postgres. The repositories of the entities record
+// their changes with Record in the transactions of their saves.
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/repository/change"
+	"homework/internal/usecase"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// publishLockKey is the advisory lock the readers of the feed take turns by while they publish the changes
+const publishLockKey int64 = 0x6368616e676573
+
+const lockPublishQuery = `select pg_advisory_xact_lock($1)`
+
+var changeColumns = []string{"kind", "time", "data"}
+
+// Record writes the changes in the transaction of the save. A change has no place in the feed until
+// GetChanges publishes it: the ids come from a sequence, and a transaction that has taken a smaller id may commit
+// after the one with a bigger id, so a consumer that has read the bigger one would skip the smaller.
+// The writers don't wait for each other, the committed changes are given their positions one publish at a time.
+func Record(ctx context.Context, tx pgx.Tx, changes ...domain.Change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	rows := make([][]any, len(changes))
+	for i, c := range changes {
+		data, err := change.Encode(c)
+		if err != nil {
+			return fmt.Errorf("can't encode change: %w", err)
+		}
+		rows[i] = []any{string(c.Kind), now, data}
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"changes"}, changeColumns, pgx.CopyFromRows(rows)); err != nil {
+		return fmt.Errorf("can't record changes: %w", err)
+	}
+	return nil
+}
+
+type ChangeRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewChangeRepository(pool *pgxpool.Pool) *ChangeRepository {
+	return &ChangeRepository{
+		pool: pool,
+	}
+}
+
+// publishChangesQuery numbers the committed changes that have no position yet after the last position given,
+// the deleted changes included. It sees only what has been committed when it starts, the rest waits for the next one.
+const publishChangesQuery = `
+with last as (
+    select greatest(coalesce(max(position), 0), (select pruned from db.public.change_horizon)) as position
+    from db.public.changes
+),
+pending as (
+    select id, row_number() over (order by id) as n
+    from db.public.changes
+    where position is null
+)
+update db.public.changes c
+set position = last.position + pending.n
+from last, pending
+where c.id = pending.id`
+
+const getChangesQuery = `
+select position, kind, time, data from db.public.changes where position > $1 order by position limit $2`
+
+const getPrunedQuery = `select pruned from db.public.change_horizon`
+
+func (r *ChangeRepository) GetChanges(ctx context.Context, after int64, limit int) ([]domain.Change, error) {
+	if err := r.publish(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, getChangesQuery, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("can't select changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := make([]domain.Change, 0)
+	for rows.Next() {
+		var cursor int64
+		var kind string
+		var t time.Time
+		var data []byte
+		if err := rows.Scan(&cursor, &kind, &t, &data); err != nil {
+			return nil, fmt.Errorf("can't scan change: %w", err)
+		}
+		c, err := change.Decode(cursor, kind, t, data)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't read changes: %w", err)
+	}
+
+	// the horizon is read after the changes, so a deletion in between is noticed rather than skipped over
+	if after > 0 {
+		var pruned int64
+		if err := r.pool.QueryRow(ctx, getPrunedQuery).Scan(&pruned); err != nil {
+			return nil, fmt.Errorf("can't scan change horizon: %w", err)
+		}
+		if after < pruned {
+			return nil, usecase.ErrChangesExpired
+		}
+	}
+	return changes, ctx.Err()
+}
+
+// publish gives the committed changes their positions. The publishes take turns, so a position is never given
+// after a bigger one is visible, and the cursor of a consumer passes no change that is still to come.
+func (r *ChangeRepository) publish(ctx context.Context) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := tx.Exec(ctx, lockPublishQuery, publishLockKey); err != nil {
+		return fmt.Errorf("can't lock changes: %w", err)
+	}
+	// the lock is taken by its own statement, so the numbering sees the positions given by the previous publish
+	if _, err := tx.Exec(ctx, publishChangesQuery); err != nil {
+		return fmt.Errorf("can't publish changes: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("can't commit publish: %w", err)
+	}
+	return nil
+}
+
+// the changes that are not published yet are kept, they get positions after the deleted ones
+const (
+	getLastBeforeQuery  = `select coalesce(max(position), 0) from db.public.changes where time < $1`
+	deleteChangesQuery  = `delete from db.public.changes where position <= $1`
+	advanceHorizonQuery = `update db.public.change_horizon set pruned = greatest(pruned, $1)`
+)
+
+// DeleteChanges deletes the changes up to the last one made before the time, so the feed has no holes
+func (r *ChangeRepository) DeleteChanges(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("can't begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var last int64
+	if err := tx.QueryRow(ctx, getLastBeforeQuery, before).Scan(&last); err != nil {
+		return 0, fmt.Errorf("can't scan last change: %w", err)
+	}
+	if last == 0 {
+		return 0, ctx.Err()
+	}
+	tag, err := tx.Exec(ctx, deleteChangesQuery, last)
+	if err != nil {
+		return 0, fmt.Errorf("can't delete changes: %w", err)
+	}
+	if _, err := tx.Exec(ctx, advanceHorizonQuery, last); err != nil {
+		return 0, fmt.Errorf("can't advance change horizon: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("can't commit deletion: %w", err)
+	}
+	return tag.RowsAffected(), ctx.Err()
+}
+
+const saveConsumerQuery = `
+insert into db.public.change_consumers (name, cursor, updated_at) values ($1, $2, $3)
+on conflict (name) do update set cursor = excluded.cursor, updated_at = excluded.updated_at;`
+
+func (r *ChangeRepository) SaveConsumer(ctx context.Context, consumer domain.ChangeConsumer) error {
+	if _, err := r.pool.Exec(ctx, saveConsumerQuery, consumer.Name, consumer.Cursor, consumer.UpdatedAt); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+const getConsumerQuery = `select name, cursor, updated_at from db.public.change_consumers where name = $1`
+
+func (r *ChangeRepository) GetConsumer(ctx context.Context, name string) (*domain.ChangeConsumer, error) {
+	consumer := &domain.ChangeConsumer{}
+	if err := r.pool.QueryRow(ctx, getConsumerQuery, name).Scan(&consumer.Name, &consumer.Cursor, &consumer.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, usecase.ErrConsumerNotFound
+		}
+		return nil, fmt.Errorf("can't scan consumer: %w", err)
+	}
+	return consumer, ctx.Err()
+}
+
+var _ usecase.ChangeRepository = (*ChangeRepository)(nil)
@@ -0,0 +1,72 @@
+package postgres_test
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/repository/conformance"
+	"homework/pkg/pg_test"
+	"testing"
+	"time"
+
+	changePostgres "homework/internal/repository/change/postgres"
+	eventPostgres "homework/internal/repository/event/postgres"
+	sensorPostgres "homework/internal/repository/sensor/postgres"
+	userPostgres "homework/internal/repository/user/postgres"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// the test is external, the repositories of the entities import the package to record their changes
+
+const truncateQuery = `
+truncate db.public.sensors, db.public.events, db.public.users, db.public.sensors_users, db.public.changes, db.public.change_consumers restart identity;
+update db.public.change_horizon set pruned = 0;`
+
+func TestChangeRepository_Conformance(t *testing.T) {
+	testDB := pg_test.SetupTestDatabase()
+	defer testDB.TearDown()
+
+	suite.Run(t, &conformance.ChangeSuite{NewRepositories: func(t *testing.T) conformance.ChangeRepositories {
+		_, err := testDB.DbInstance.Exec(context.Background(), truncateQuery)
+		require.NoError(t, err)
+		return conformance.ChangeRepositories{
+			Sensors:      sensorPostgres.NewSensorRepository(testDB.DbInstance),
+			Events:       eventPostgres.NewEventRepository(testDB.DbInstance),
+			Users:        userPostgres.NewUserRepository(testDB.DbInstance),
+			SensorOwners: userPostgres.NewSensorOwnerRepository(testDB.DbInstance),
+			Changes:      changePostgres.NewChangeRepository(testDB.DbInstance),
+		}
+	}})
+
+	t.Run("ok, change committed late is not skipped", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err := testDB.DbInstance.Exec(ctx, truncateQuery)
+		require.NoError(t, err)
+		changes := changePostgres.NewChangeRepository(testDB.DbInstance)
+		sensors := sensorPostgres.NewSensorRepository(testDB.DbInstance)
+
+		// the change of the user takes the smaller id, but is committed after the one of the sensor
+		tx, err := testDB.DbInstance.Begin(ctx)
+		require.NoError(t, err)
+		defer func() {
+			_ = tx.Rollback(ctx)
+		}()
+		require.NoError(t, changePostgres.Record(ctx, tx, domain.UserChange(domain.User{ID: 1, Name: "user"})))
+		sensor := domain.Sensor{SerialNumber: "1000000001", Type: domain.SensorTypeADC, RegisteredAt: time.Now()}
+		require.NoError(t, sensors.SaveSensor(ctx, &sensor), "Писатели не должны ждать друг друга")
+
+		got, err := changes.GetChanges(ctx, 0, 100)
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, domain.ChangeKindSensor, got[0].Kind)
+
+		require.NoError(t, tx.Commit(ctx))
+		got, err = changes.GetChanges(ctx, got[0].Cursor, 100)
+		require.NoError(t, err)
+		require.Len(t, got, 1, "Изменение, зафиксированное позже, не должно пропускаться")
+		assert.Equal(t, domain.ChangeKindUser, got[0].Kind)
+	})
+}
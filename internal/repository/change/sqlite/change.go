@@ -0,0 +1,160 @@
+// Package sqlite keeps the change feed in the embedded database. The repositories of the entities
+// record their changes with Record in the transactions of their saves.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/repository/change"
+	"homework/internal/usecase"
+	"time"
+)
+
+const recordChangeQuery = `insert into changes (kind, time, data) values (?, ?, ?)`
+
+// Record writes the changes in the transaction of the save. The writers of the embedded database take turns,
+// so a change is never committed behind the ones a consumer has already read.
+func Record(ctx context.Context, tx *sql.Tx, changes ...domain.Change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	stmt, err := tx.PrepareContext(ctx, recordChangeQuery)
+	if err != nil {
+		return fmt.Errorf("can't prepare change: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UnixNano()
+	for _, c := range changes {
+		data, err := change.Encode(c)
+		if err != nil {
+			return fmt.Errorf("can't encode change: %w", err)
+		}
+		if _, err := stmt.ExecContext(ctx, string(c.Kind), now, string(data)); err != nil {
+			return fmt.Errorf("can't record change: %w", err)
+		}
+	}
+	return nil
+}
+
+type ChangeRepository struct {
+	db *sql.DB
+}
+
+func NewChangeRepository(db *sql.DB) *ChangeRepository {
+	return &ChangeRepository{
+		db: db,
+	}
+}
+
+const getChangesQuery = `select id, kind, time, data from changes where id > ? order by id limit ?`
+
+const getPrunedQuery = `select pruned from change_horizon`
+
+func (r *ChangeRepository) GetChanges(ctx context.Context, after int64, limit int) ([]domain.Change, error) {
+	rows, err := r.db.QueryContext(ctx, getChangesQuery, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("can't select changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := make([]domain.Change, 0)
+	for rows.Next() {
+		var cursor, t int64
+		var kind, data string
+		if err := rows.Scan(&cursor, &kind, &t, &data); err != nil {
+			return nil, fmt.Errorf("can't scan change: %w", err)
+		}
+		c, err := change.Decode(cursor, kind, time.Unix(0, t).UTC(), []byte(data))
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't read changes: %w", err)
+	}
+
+	// the horizon is read after the changes, so a deletion in between is noticed rather than skipped over
+	if after > 0 {
+		var pruned int64
+		if err := r.db.QueryRowContext(ctx, getPrunedQuery).Scan(&pruned); err != nil {
+			return nil, fmt.Errorf("can't scan change horizon: %w", err)
+		}
+		if after < pruned {
+			return nil, usecase.ErrChangesExpired
+		}
+	}
+	return changes, ctx.Err()
+}
+
+const (
+	getLastBeforeQuery  = `select coalesce(max(id), 0) from changes where time < ?`
+	deleteChangesQuery  = `delete from changes where id <= ?`
+	advanceHorizonQuery = `update change_horizon set pruned = max(pruned, ?)`
+)
+
+// DeleteChanges deletes the changes up to the last one made before the time, so the feed has no holes
+func (r *ChangeRepository) DeleteChanges(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("can't begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var last int64
+	if err := tx.QueryRowContext(ctx, getLastBeforeQuery, before.UnixNano()).Scan(&last); err != nil {
+		return 0, fmt.Errorf("can't scan last change: %w", err)
+	}
+	if last == 0 {
+		return 0, ctx.Err()
+	}
+	res, err := tx.ExecContext(ctx, deleteChangesQuery, last)
+	if err != nil {
+		return 0, fmt.Errorf("can't delete changes: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, advanceHorizonQuery, last); err != nil {
+		return 0, fmt.Errorf("can't advance change horizon: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("can't commit deletion: %w", err)
+	}
+	return deleted, ctx.Err()
+}
+
+const saveConsumerQuery = `
+insert into change_consumers (name, cursor, updated_at) values (?, ?, ?)
+on conflict (name) do update set cursor = excluded.cursor, updated_at = excluded.updated_at`
+
+func (r *ChangeRepository) SaveConsumer(ctx context.Context, consumer domain.ChangeConsumer) error {
+	if _, err := r.db.ExecContext(ctx, saveConsumerQuery, consumer.Name, consumer.Cursor, consumer.UpdatedAt.UnixNano()); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+const getConsumerQuery = `select name, cursor, updated_at from change_consumers where name = ?`
+
+func (r *ChangeRepository) GetConsumer(ctx context.Context, name string) (*domain.ChangeConsumer, error) {
+	consumer := &domain.ChangeConsumer{}
+	var updatedAt int64
+	if err := r.db.QueryRowContext(ctx, getConsumerQuery, name).Scan(&consumer.Name, &consumer.Cursor, &updatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, usecase.ErrConsumerNotFound
+		}
+		return nil, fmt.Errorf("can't scan consumer: %w", err)
+	}
+	consumer.UpdatedAt = time.Unix(0, updatedAt).UTC()
+	return consumer, ctx.Err()
+}
+
+var _ usecase.ChangeRepository = (*ChangeRepository)(nil)
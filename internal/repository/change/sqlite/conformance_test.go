@@ -0,0 +1,34 @@
+package sqlite_test
+
+import (
+	"context"
+	"homework/internal/repository/conformance"
+	"path/filepath"
+	"testing"
+
+	changeSqlite "homework/internal/repository/change/sqlite"
+	eventSqlite "homework/internal/repository/event/sqlite"
+	schemaSqlite "homework/internal/repository/schema/sqlite"
+	sensorSqlite "homework/internal/repository/sensor/sqlite"
+	userSqlite "homework/internal/repository/user/sqlite"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// the test is external, the repositories of the entities import the package to record their changes
+
+func TestChangeRepository_Conformance(t *testing.T) {
+	suite.Run(t, &conformance.ChangeSuite{NewRepositories: func(t *testing.T) conformance.ChangeRepositories {
+		db, err := schemaSqlite.Open(context.Background(), filepath.Join(t.TempDir(), "test.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		return conformance.ChangeRepositories{
+			Sensors:      sensorSqlite.NewSensorRepository(db),
+			Events:       eventSqlite.NewEventRepository(db),
+			Users:        userSqlite.NewUserRepository(db),
+			SensorOwners: userSqlite.NewSensorOwnerRepository(db),
+			Changes:      changeSqlite.NewChangeRepository(db),
+		}
+	}})
+}
@@ -0,0 +1,32 @@
+package inmemory
+
+import (
+	"context"
+	"sync"
+)
+
+type CheckpointRepository struct {
+	storage map[string]int64
+	m       sync.RWMutex
+}
+
+func NewCheckpointRepository() *CheckpointRepository {
+	return &CheckpointRepository{storage: map[string]int64{}, m: sync.RWMutex{}}
+}
+
+func (r *CheckpointRepository) SaveCheckpoint(ctx context.Context, key string, processed int64) error {
+	r.m.Lock()
+	r.storage[key] = processed
+	r.m.Unlock()
+	return ctx.Err()
+}
+
+func (r *CheckpointRepository) GetCheckpoint(ctx context.Context, key string) (int64, error) {
+	r.m.RLock()
+	processed := r.storage[key]
+	r.m.RUnlock()
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+	return processed, nil
+}
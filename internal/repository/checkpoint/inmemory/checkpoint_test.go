@@ -0,0 +1,80 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpointRepository_SaveCheckpoint(t *testing.T) {
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		cr := NewCheckpointRepository()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := cr.SaveCheckpoint(ctx, "key", 1)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("fail, ctx deadline exceeded", func(t *testing.T) {
+		cr := NewCheckpointRepository()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+		defer cancel()
+
+		err := cr.SaveCheckpoint(ctx, "key", 1)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("ok, save and overwrite", func(t *testing.T) {
+		cr := NewCheckpointRepository()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		assert.NoError(t, cr.SaveCheckpoint(ctx, "key", 10))
+		assert.NoError(t, cr.SaveCheckpoint(ctx, "key", 20))
+
+		processed, err := cr.GetCheckpoint(ctx, "key")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(20), processed)
+	})
+
+	t.Run("ok, collision test", func(t *testing.T) {
+		cr := NewCheckpointRepository()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		wg := sync.WaitGroup{}
+		for i := int64(0); i < 1000; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, cr.SaveCheckpoint(ctx, fmt.Sprintf("key %d", i), i))
+			}()
+		}
+
+		wg.Wait()
+	})
+}
+
+func TestCheckpointRepository_GetCheckpoint(t *testing.T) {
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		cr := NewCheckpointRepository()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := cr.GetCheckpoint(ctx, "key")
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("ok, unknown key", func(t *testing.T) {
+		cr := NewCheckpointRepository()
+
+		processed, err := cr.GetCheckpoint(context.Background(), "key")
+		assert.NoError(t, err)
+		assert.Zero(t, processed)
+	})
+}
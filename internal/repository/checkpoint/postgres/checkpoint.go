@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CheckpointRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewCheckpointRepository(pool *pgxpool.Pool) *CheckpointRepository {
+	return &CheckpointRepository{
+		pool: pool,
+	}
+}
+
+const saveCheckpointQuery = `
+insert into db.public.import_checkpoints (key, processed, updated_at)
+values ($1, $2, now())
+on conflict (key) do update set processed = excluded.processed, updated_at = excluded.updated_at;`
+
+func (r *CheckpointRepository) SaveCheckpoint(ctx context.Context, key string, processed int64) error {
+	_, err := r.pool.Exec(ctx, saveCheckpointQuery, key, processed)
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+const getCheckpointQuery = `select processed from db.public.import_checkpoints where key=$1`
+
+func (r *CheckpointRepository) GetCheckpoint(ctx context.Context, key string) (int64, error) {
+	var processed int64
+	if err := r.pool.QueryRow(ctx, getCheckpointQuery, key).Scan(&processed); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ctx.Err()
+		}
+		return 0, fmt.Errorf("can't scan checkpoint: %w", err)
+	}
+	return processed, ctx.Err()
+}
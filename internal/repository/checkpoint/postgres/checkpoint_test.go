@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"context"
+	"homework/pkg/pg_test"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type CheckpointTestSuite struct {
+	suite.Suite
+	testDbInstance *pgxpool.Pool
+	testDB         *pg_test.TestDatabase
+
+	repo *CheckpointRepository
+}
+
+func (suite *CheckpointTestSuite) SetupSuite() {
+	suite.testDB = pg_test.SetupTestDatabase()
+	suite.testDbInstance = suite.testDB.DbInstance
+
+	suite.repo = NewCheckpointRepository(suite.testDbInstance)
+}
+
+func (suite *CheckpointTestSuite) TearDownSuite() {
+	suite.testDB.TearDown()
+}
+
+func (suite *CheckpointTestSuite) TestCheckpointRepository_GetCheckpoint_Unknown() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	processed, err := suite.repo.GetCheckpoint(ctx, "unknown")
+	assert.Nil(suite.T(), err)
+	assert.Zero(suite.T(), processed)
+}
+
+func (suite *CheckpointTestSuite) TestCheckpointRepository_SaveCheckpoint() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.Nil(suite.T(), suite.repo.SaveCheckpoint(ctx, "events:/tmp/events.csv", 100))
+	assert.Nil(suite.T(), suite.repo.SaveCheckpoint(ctx, "events:/tmp/events.csv", 200))
+
+	processed, err := suite.repo.GetCheckpoint(ctx, "events:/tmp/events.csv")
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), int64(200), processed)
+}
+
+func TestCheckpointTestSuite(t *testing.T) {
+	suite.Run(t, new(CheckpointTestSuite))
+}
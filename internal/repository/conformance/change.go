@@ -0,0 +1,232 @@
+package conformance
+
+import (
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// ChangeRepositories are the repositories of a backend that record their changes into its feed
+type ChangeRepositories struct {
+	Sensors      usecase.SensorRepository
+	Events       usecase.EventRepository
+	Users        usecase.UserRepository
+	SensorOwners usecase.SensorOwnerRepository
+	Changes      usecase.ChangeRepository
+}
+
+// ChangeSuite checks a usecase.ChangeRepository together with the repositories that record into it
+type ChangeSuite struct {
+	suite.Suite
+	// NewRepositories returns empty repositories sharing an empty feed, it is called before every test
+	NewRepositories func(t *testing.T) ChangeRepositories
+
+	repos ChangeRepositories
+}
+
+func (s *ChangeSuite) SetupTest() {
+	s.repos = s.NewRepositories(s.T())
+}
+
+// inUTC drops the location and the monotonic clock of the times of the sensor, the backends return the same
+// instants in different locations
+func inUTC(sensor domain.Sensor) domain.Sensor {
+	sensor.RegisteredAt, sensor.LastActivity = sensor.RegisteredAt.UTC(), sensor.LastActivity.UTC()
+	return sensor
+}
+
+// changes reads the whole feed after the cursor
+func (s *ChangeSuite) changes(after int64) []domain.Change {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	changes, err := s.repos.Changes.GetChanges(ctx, after, 1000)
+	s.Require().NoError(err)
+	return changes
+}
+
+func (s *ChangeSuite) TestRecord() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	sensor := newSensor("1000000001")
+	s.Require().NoError(s.repos.Sensors.SaveSensor(ctx, &sensor))
+	user := domain.User{Name: "user"}
+	s.Require().NoError(s.repos.Users.SaveUser(ctx, &user))
+	owner := domain.SensorOwner{SensorID: sensor.ID, UserID: user.ID}
+	s.Require().NoError(s.repos.SensorOwners.SaveSensorOwner(ctx, owner))
+	event := newEvent(sensor.ID, 0, 1)
+	s.Require().NoError(s.repos.Events.SaveEvent(ctx, &event))
+	sensor.Description = "updated"
+	s.Require().NoError(s.repos.Sensors.SaveSensor(ctx, &sensor))
+
+	changes := s.changes(0)
+	s.Require().Len(changes, 5, "Каждое сохранение записывает одно изменение")
+	kinds := make([]domain.ChangeKind, len(changes))
+	for i, c := range changes {
+		kinds[i] = c.Kind
+		s.NoError(c.Validate())
+		s.False(c.Time.IsZero(), "Изменению присваивается время")
+		if i > 0 {
+			s.Greater(c.Cursor, changes[i-1].Cursor, "Курсоры растут в порядке изменений")
+		}
+	}
+	s.Positive(changes[0].Cursor)
+	s.Equal([]domain.ChangeKind{domain.ChangeKindSensor, domain.ChangeKindUser, domain.ChangeKindSensorOwner, domain.ChangeKindEvent, domain.ChangeKindSensor}, kinds)
+
+	s.Equal("sensor 1000000001", changes[0].Sensor.Description, "В изменении датчик такой, каким был сохранен")
+	s.Equal(inUTC(sensor), inUTC(*changes[4].Sensor))
+	s.Equal(user, *changes[1].User)
+	s.Equal(owner, *changes[2].SensorOwner)
+	s.Equal(event, *changes[3].Event)
+}
+
+func (s *ChangeSuite) TestRecord_Copies() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	sensor := newSensor("1000000001")
+	s.Require().NoError(s.repos.Sensors.SaveSensor(ctx, &sensor))
+	changes := s.changes(0)
+	s.Require().Len(changes, 1)
+	changes[0].Sensor.Description = "changed"
+
+	s.Equal("sensor 1000000001", s.changes(0)[0].Sensor.Description, "Прочитанное изменение не меняет журнал")
+}
+
+func (s *ChangeSuite) TestRecord_EventRepeat() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	event := newEvent(1, 0, 1)
+	s.Require().NoError(s.repos.Events.SaveEvent(ctx, &event))
+	s.Require().NoError(s.repos.Events.SaveEvent(ctx, &event))
+	conflicting := newEvent(1, 0, 2)
+	s.Require().ErrorIs(s.repos.Events.SaveEvent(ctx, &conflicting), usecase.ErrEventConflict)
+
+	changes := s.changes(0)
+	s.Require().Len(changes, 1, "Повтор и конфликт события не записываются")
+	s.Equal(event, *changes[0].Event)
+
+	saver, ok := s.repos.Events.(usecase.EventBatchSaver)
+	if !ok {
+		return
+	}
+	batch := []*domain.Event{&event, new(domain.Event), new(domain.Event)}
+	*batch[1], *batch[2] = newEvent(1, 1, 2), newEvent(1, 2, 3)
+	s.Require().NoError(saver.SaveEvents(ctx, batch))
+
+	changes = s.changes(changes[0].Cursor)
+	s.Require().Len(changes, 2, "Из пачки записываются только новые события")
+	s.Equal(*batch[1], *changes[0].Event)
+	s.Equal(*batch[2], *changes[1].Event)
+}
+
+func (s *ChangeSuite) TestGetChanges_Paging() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	for i := 0; i < 5; i++ {
+		s.Require().NoError(s.repos.Users.SaveUser(ctx, &domain.User{Name: fmt.Sprintf("user %d", i)}))
+	}
+
+	page, err := s.repos.Changes.GetChanges(ctx, 0, 2)
+	s.Require().NoError(err)
+	s.Require().Len(page, 2, "Возвращается не больше limit изменений")
+	s.Equal("user 0", page[0].User.Name)
+
+	rest, err := s.repos.Changes.GetChanges(ctx, page[1].Cursor, 10)
+	s.Require().NoError(err)
+	s.Require().Len(rest, 3, "Изменения читаются после курсора")
+	s.Equal("user 2", rest[0].User.Name)
+
+	tail, err := s.repos.Changes.GetChanges(ctx, rest[2].Cursor, 10)
+	s.Require().NoError(err)
+	s.Empty(tail)
+
+	_, err = s.repos.Changes.GetChanges(cancelledContext(), 0, 10)
+	s.Error(err)
+}
+
+func (s *ChangeSuite) TestDeleteChanges() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	for i := 0; i < 3; i++ {
+		s.Require().NoError(s.repos.Users.SaveUser(ctx, &domain.User{Name: fmt.Sprintf("user %d", i)}))
+	}
+	saved := s.changes(0)
+	s.Require().Len(saved, 3)
+
+	deleted, err := s.repos.Changes.DeleteChanges(ctx, saved[0].Time.Add(-time.Hour))
+	s.Require().NoError(err)
+	s.Zero(deleted, "Более новые изменения не удаляются")
+
+	deleted, err = s.repos.Changes.DeleteChanges(ctx, time.Now().Add(time.Hour))
+	s.Require().NoError(err)
+	s.EqualValues(3, deleted)
+	s.Empty(s.changes(0))
+
+	s.Require().NoError(s.repos.Users.SaveUser(ctx, &domain.User{Name: "user 3"}))
+	changes := s.changes(0)
+	s.Require().Len(changes, 1)
+	s.Greater(changes[0].Cursor, saved[2].Cursor, "Курсоры не переиспользуются после удаления")
+	s.Equal(changes, s.changes(saved[2].Cursor), "Курсор последнего удаленного изменения действителен")
+
+	_, err = s.repos.Changes.GetChanges(ctx, saved[0].Cursor, 10)
+	s.ErrorIs(err, usecase.ErrChangesExpired, "Изменения после курсора удалены, их пропуск - ошибка")
+}
+
+func (s *ChangeSuite) TestConsumers() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	_, err := s.repos.Changes.GetConsumer(ctx, "search")
+	s.ErrorIs(err, usecase.ErrConsumerNotFound)
+
+	consumer := domain.ChangeConsumer{Name: "search", Cursor: 3, UpdatedAt: base}
+	s.Require().NoError(s.repos.Changes.SaveConsumer(ctx, consumer))
+	consumer.Cursor, consumer.UpdatedAt = 1, base.Add(time.Minute)
+	s.Require().NoError(s.repos.Changes.SaveConsumer(ctx, consumer), "Курсор может вернуться назад")
+	s.Require().NoError(s.repos.Changes.SaveConsumer(ctx, domain.ChangeConsumer{Name: "audit", Cursor: 7, UpdatedAt: base}))
+
+	saved, err := s.repos.Changes.GetConsumer(ctx, "search")
+	s.Require().NoError(err)
+	s.Equal(consumer.Name, saved.Name)
+	s.Equal(consumer.Cursor, saved.Cursor)
+	s.True(consumer.UpdatedAt.Equal(saved.UpdatedAt), "Время сохраняется, %v != %v", consumer.UpdatedAt, saved.UpdatedAt)
+
+	_, err = s.repos.Changes.GetConsumer(cancelledContext(), "search")
+	s.Error(err)
+}
+
+func (s *ChangeSuite) TestRecord_Concurrent() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	const saves = 10
+	parallel(concurrency, func(i int) {
+		sensor := newSensor(fmt.Sprintf("%010d", i))
+		for j := 0; j < saves; j++ {
+			sensor.LastActivity = base.Add(time.Duration(j) * time.Second)
+			s.NoError(s.repos.Sensors.SaveSensor(ctx, &sensor))
+		}
+	})
+
+	changes := s.changes(0)
+	s.Require().Len(changes, concurrency*saves)
+	last := map[string]time.Time{}
+	for i, c := range changes {
+		if i > 0 {
+			s.Greater(c.Cursor, changes[i-1].Cursor)
+		}
+		previous, has := last[c.Sensor.SerialNumber]
+		s.True(!has || c.Sensor.LastActivity.After(previous), "Изменения датчика в журнале в порядке сохранений")
+		last[c.Sensor.SerialNumber] = c.Sensor.LastActivity
+	}
+	s.Len(last, concurrency)
+}
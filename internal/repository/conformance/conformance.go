@@ -0,0 +1,50 @@
+// Package conformance checks that a repository behaves as the usecases expect, whatever keeps the data.
+// Every backend runs the same suites from its tests, e.g.
+//
+//	suite.Run(t, &conformance.SensorSuite{NewRepository: func(*testing.T) usecase.SensorRepository {
+//		return NewSensorRepository()
+//	}})
+//
+// The times are whole microseconds in UTC, postgres keeps no more.
+package conformance
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// timeout bounds every test, a backend that hangs fails instead of blocking the run
+const timeout = 10 * time.Second
+
+// concurrency is the number of the goroutines the concurrent tests save from
+const concurrency = 32
+
+// base is the time the events of the tests are counted from
+var base = time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+func testContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+func cancelledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
+
+// parallel runs fn from n goroutines at once and waits for them
+func parallel(n int, fn func(i int)) {
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			fn(i)
+		}()
+	}
+	close(start)
+	wg.Wait()
+}
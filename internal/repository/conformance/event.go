@@ -0,0 +1,284 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// EventSuite checks a usecase.EventRepository, and its usecase.EventBatchSaver and usecase.EventHistoryStreamer
+// if it has them
+type EventSuite struct {
+	suite.Suite
+	// NewRepository returns an empty repository, it is called before every test
+	NewRepository func(t *testing.T) usecase.EventRepository
+
+	repo usecase.EventRepository
+}
+
+func (s *EventSuite) SetupTest() {
+	s.repo = s.NewRepository(s.T())
+}
+
+func newEvent(sensorID int64, minute int, payload int64) domain.Event {
+	return domain.Event{
+		Timestamp:          base.Add(time.Duration(minute) * time.Minute),
+		SensorSerialNumber: "1000000000",
+		SensorID:           sensorID,
+		Payload:            domain.IntPayload(payload),
+	}
+}
+
+// saveEvents saves the events of the minutes, in the given order, and returns them ordered by time
+func (s *EventSuite) saveEvents(ctx context.Context, sensorID int64, minutes ...int) []*domain.Event {
+	events := make([]*domain.Event, 60)
+	for _, m := range minutes {
+		event := newEvent(sensorID, m, int64(m))
+		s.Require().NoError(s.repo.SaveEvent(ctx, &event))
+		events[m] = &event
+	}
+	ordered := make([]*domain.Event, 0, len(minutes))
+	for _, e := range events {
+		if e != nil {
+			ordered = append(ordered, e)
+		}
+	}
+	return ordered
+}
+
+func (s *EventSuite) TestSaveEvent_Repeat() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	event := newEvent(1, 0, 1)
+	s.Require().NoError(s.repo.SaveEvent(ctx, &event))
+	s.Require().NoError(s.repo.SaveEvent(ctx, &event), "Повтор того же события не ошибка")
+
+	conflicting := newEvent(1, 0, 2)
+	s.ErrorIs(s.repo.SaveEvent(ctx, &conflicting), usecase.ErrEventConflict)
+
+	// another sensor has its own timeline
+	other := newEvent(2, 0, 2)
+	s.NoError(s.repo.SaveEvent(ctx, &other))
+
+	history, err := s.repo.GetHistoryBySensorID(ctx, 1, base, base)
+	s.Require().NoError(err)
+	s.Equal([]*domain.Event{&event}, history, "Событие сохраняется один раз, конфликт ничего не меняет")
+}
+
+func (s *EventSuite) TestSaveEvent_Readings() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	calibrated := newEvent(1, 0, 0)
+	calibrated.Payload = domain.Payload{
+		{Channel: "temperature", Value: domain.Decimal{Units: 215, Scale: 1}, Unit: "°C"},
+		{Channel: "humidity", Value: domain.Decimal{Units: 40}, Unit: "%"},
+	}
+	calibrated.Raw = domain.Payload{
+		{Channel: "temperature", Value: domain.Decimal{Units: 2048}},
+		{Channel: "humidity", Value: domain.Decimal{Units: 40}},
+	}
+	plain := newEvent(1, 1, 7)
+	s.Require().NoError(s.repo.SaveEvent(ctx, &calibrated))
+	s.Require().NoError(s.repo.SaveEvent(ctx, &calibrated), "Повтор события с показаниями не ошибка")
+	s.Require().NoError(s.repo.SaveEvent(ctx, &plain))
+
+	history, err := s.repo.GetHistoryBySensorID(ctx, 1, base, base.Add(time.Minute))
+	s.Require().NoError(err)
+	s.Equal([]*domain.Event{&calibrated, &plain}, history, "Сырые показания есть только у откалиброванного события")
+}
+
+func (s *EventSuite) TestGetLastEventBySensorID() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	_, err := s.repo.GetLastEventBySensorID(ctx, 1)
+	s.ErrorIs(err, usecase.ErrEventNotFound)
+
+	events := s.saveEvents(ctx, 1, 5, 9, 1)
+	s.saveEvents(ctx, 2, 20)
+
+	last, err := s.repo.GetLastEventBySensorID(ctx, 1)
+	s.Require().NoError(err)
+	s.Equal(events[len(events)-1], last, "Последнее событие выбирается по времени, а не по порядку сохранения")
+
+	_, err = s.repo.GetLastEventBySensorID(ctx, 3)
+	s.ErrorIs(err, usecase.ErrEventNotFound)
+}
+
+func (s *EventSuite) TestGetHistoryBySensorID_Order() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	minutes := rand.Perm(30)
+	events := s.saveEvents(ctx, 1, minutes...)
+	s.saveEvents(ctx, 2, 0, 10, 20)
+
+	history, err := s.repo.GetHistoryBySensorID(ctx, 1, base, base.Add(time.Hour))
+	s.Require().NoError(err)
+	s.Equal(events, history, "История упорядочена по времени и содержит только события датчика")
+}
+
+func (s *EventSuite) TestGetHistoryBySensorID_Bounds() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	events := s.saveEvents(ctx, 1, 0, 1, 2, 3, 4)
+	at := func(minute int) time.Time { return base.Add(time.Duration(minute) * time.Minute) }
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     []*domain.Event
+		msg      string
+	}{
+		{"closed", at(1), at(3), events[1:4], "Границы периода включаются"},
+		{"inside", at(1).Add(time.Microsecond), at(3).Add(-time.Microsecond), events[2:3], "События на микросекунду за границей не включаются"},
+		{"point", at(2), at(2), events[2:3], "Период из одного момента"},
+		{"zero from", time.Time{}, at(1), events[:2], "Нулевое время начала не ограничивает историю"},
+		{"far to", at(3), at(3).AddDate(100, 0, 0), events[3:], "Далекий конец периода"},
+		{"before", at(-10), at(-1), []*domain.Event{}, "Период до событий пуст"},
+		{"after", at(5), at(10), []*domain.Event{}, "Период после событий пуст"},
+		{"reversed", at(3), at(1), []*domain.Event{}, "Начало позже конца"},
+	}
+	for _, tt := range tests {
+		history, err := s.repo.GetHistoryBySensorID(ctx, 1, tt.from, tt.to)
+		s.Require().NoError(err, tt.name)
+		if len(tt.want) == 0 {
+			s.Empty(history, tt.msg)
+			continue
+		}
+		s.Equal(tt.want, history, tt.msg)
+	}
+}
+
+func (s *EventSuite) TestGetHistoryBySensorID_NoEvents() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	// the usecases take both for no history
+	history, err := s.repo.GetHistoryBySensorID(ctx, 1, time.Time{}, base)
+	if !errors.Is(err, usecase.ErrEventNotFound) {
+		s.Require().NoError(err)
+		s.Empty(history, "У датчика без событий пустая история или ErrEventNotFound")
+	}
+}
+
+func (s *EventSuite) TestCancelled() {
+	ctx, cancel := testContext()
+	defer cancel()
+	s.saveEvents(ctx, 1, 0, 1)
+
+	cancelled := cancelledContext()
+	event := newEvent(1, 2, 2)
+	s.ErrorIs(s.repo.SaveEvent(cancelled, &event), context.Canceled)
+	_, err := s.repo.GetLastEventBySensorID(cancelled, 1)
+	s.ErrorIs(err, context.Canceled)
+	_, err = s.repo.GetLastEventBySensorID(cancelled, 2)
+	s.ErrorIs(err, context.Canceled, "Отмена важнее, чем отсутствие событий")
+	_, err = s.repo.GetHistoryBySensorID(cancelled, 1, base, base.Add(time.Hour))
+	s.ErrorIs(err, context.Canceled)
+	_, err = s.repo.GetHistoryBySensorID(cancelled, 1, base.Add(time.Hour), base.Add(2*time.Hour))
+	s.ErrorIs(err, context.Canceled, "Отмена важнее, чем пустой период")
+
+	if saver, ok := s.repo.(usecase.EventBatchSaver); ok {
+		s.ErrorIs(saver.SaveEvents(cancelled, []*domain.Event{&event}), context.Canceled)
+	}
+	if streamer, ok := s.repo.(usecase.EventHistoryStreamer); ok {
+		err := streamer.StreamHistoryBySensorID(cancelled, 1, base, base.Add(time.Hour), func(*domain.Event) error { return nil })
+		s.ErrorIs(err, context.Canceled)
+	}
+}
+
+func (s *EventSuite) TestConcurrent() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	// every goroutine saves a minute of two sensors, the first one sends the same events twice
+	errs := make([]error, concurrency)
+	parallel(concurrency, func(i int) {
+		for _, sensorID := range []int64{1, 2} {
+			event := newEvent(sensorID, i, int64(i))
+			if errs[i] = s.repo.SaveEvent(ctx, &event); errs[i] != nil {
+				return
+			}
+		}
+	})
+	parallel(concurrency, func(i int) {
+		event := newEvent(1, i, int64(i))
+		errs[i] = s.repo.SaveEvent(ctx, &event)
+	})
+	for _, err := range errs {
+		s.Require().NoError(err)
+	}
+
+	for _, sensorID := range []int64{1, 2} {
+		history, err := s.repo.GetHistoryBySensorID(ctx, sensorID, base, base.Add(time.Hour))
+		s.Require().NoError(err)
+		s.Len(history, concurrency, "Все события сохранены по одному разу")
+	}
+}
+
+func (s *EventSuite) TestSaveEvents() {
+	saver, ok := s.repo.(usecase.EventBatchSaver)
+	if !ok {
+		s.T().Skip("the repository saves no batches")
+	}
+	ctx, cancel := testContext()
+	defer cancel()
+
+	taken := newEvent(1, 0, 1)
+	s.Require().NoError(s.repo.SaveEvent(ctx, &taken))
+
+	conflicting := newEvent(1, 0, 2)
+	batch := []*domain.Event{&conflicting}
+	for i := 1; i < 5; i++ {
+		event := newEvent(1+int64(i%2), i, int64(i))
+		batch = append(batch, &event)
+	}
+	s.Require().NoError(saver.SaveEvents(ctx, batch), "Пачка пропускает занятое время")
+	s.Require().NoError(saver.SaveEvents(ctx, batch), "Повтор пачки не ошибка")
+	s.Require().NoError(saver.SaveEvents(ctx, nil))
+
+	history, err := s.repo.GetHistoryBySensorID(ctx, 1, base, base.Add(time.Hour))
+	s.Require().NoError(err)
+	s.Equal([]*domain.Event{&taken, batch[2], batch[4]}, history)
+	history, err = s.repo.GetHistoryBySensorID(ctx, 2, base, base.Add(time.Hour))
+	s.Require().NoError(err)
+	s.Equal([]*domain.Event{batch[1], batch[3]}, history)
+}
+
+func (s *EventSuite) TestStreamHistoryBySensorID() {
+	streamer, ok := s.repo.(usecase.EventHistoryStreamer)
+	if !ok {
+		s.T().Skip("the repository streams no history")
+	}
+	ctx, cancel := testContext()
+	defer cancel()
+
+	events := s.saveEvents(ctx, 1, 3, 0, 4, 1, 2)
+
+	var streamed []*domain.Event
+	err := streamer.StreamHistoryBySensorID(ctx, 1, base.Add(time.Minute), base.Add(3*time.Minute), func(e *domain.Event) error {
+		streamed = append(streamed, e)
+		return nil
+	})
+	s.Require().NoError(err)
+	s.Equal(events[1:4], streamed, "Обход идет по времени в границах периода, как история")
+
+	stop := errors.New("stop")
+	calls := 0
+	err = streamer.StreamHistoryBySensorID(ctx, 1, base, base.Add(time.Hour), func(*domain.Event) error {
+		calls++
+		return stop
+	})
+	s.ErrorIs(err, stop, "Ошибка обработчика прерывает обход")
+	s.Equal(1, calls)
+}
@@ -0,0 +1,194 @@
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// SensorSuite checks a usecase.SensorRepository
+type SensorSuite struct {
+	suite.Suite
+	// NewRepository returns an empty repository, it is called before every test
+	NewRepository func(t *testing.T) usecase.SensorRepository
+
+	repo usecase.SensorRepository
+}
+
+func (s *SensorSuite) SetupTest() {
+	s.repo = s.NewRepository(s.T())
+}
+
+func newSensor(sn string) domain.Sensor {
+	return domain.Sensor{
+		SerialNumber: sn,
+		Type:         domain.SensorTypeADC,
+		CurrentState: domain.IntPayload(0),
+		Description:  "sensor " + sn,
+		IsActive:     true,
+	}
+}
+
+func (s *SensorSuite) TestSaveSensor_New() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	first, second := newSensor("1000000001"), newSensor("1000000002")
+	s.Require().NoError(s.repo.SaveSensor(ctx, &first))
+	s.Require().NoError(s.repo.SaveSensor(ctx, &second))
+	s.Positive(first.ID, "Новому датчику присваивается id")
+	s.Greater(second.ID, first.ID, "id присваиваются по возрастанию")
+	s.False(first.RegisteredAt.IsZero(), "Новому датчику присваивается время регистрации")
+
+	sensor, err := s.repo.GetSensorByID(ctx, first.ID)
+	s.Require().NoError(err)
+	s.Equal(first, *sensor, "Сохраненный датчик совпадает с тем, что вернул SaveSensor")
+	sensor, err = s.repo.GetSensorBySerialNumber(ctx, second.SerialNumber)
+	s.Require().NoError(err)
+	s.Equal(second, *sensor)
+}
+
+func (s *SensorSuite) TestSaveSensor_Update() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	sensor := newSensor("1000000004")
+	s.Require().NoError(s.repo.SaveSensor(ctx, &sensor))
+
+	updated := newSensor(sensor.SerialNumber)
+	updated.CurrentState = domain.IntPayload(5)
+	updated.Description = "updated"
+	updated.IsActive = false
+	updated.LastActivity = base
+	s.Require().NoError(s.repo.SaveSensor(ctx, &updated))
+	s.Equal(sensor.ID, updated.ID, "Обновление сохраняет id")
+	s.True(sensor.RegisteredAt.Equal(updated.RegisteredAt), "Обновление сохраняет время регистрации")
+
+	stored, err := s.repo.GetSensorBySerialNumber(ctx, sensor.SerialNumber)
+	s.Require().NoError(err)
+	s.Equal(updated, *stored)
+
+	sensors, err := s.repo.GetSensors(ctx)
+	s.Require().NoError(err)
+	s.Len(sensors, 1, "Обновление не добавляет датчик")
+}
+
+func (s *SensorSuite) TestSaveSensor_Readings() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	sensor := newSensor("1000000005")
+	sensor.CurrentState = domain.Payload{
+		{Channel: "temperature", Value: domain.Decimal{Units: 215, Scale: 1}, Unit: "°C"},
+		{Channel: "humidity", Value: domain.Decimal{Units: -40}, Unit: "%"},
+	}
+	sensor.Calibration = []domain.Calibration{
+		{Channel: "temperature", Gain: domain.Decimal{Units: 5, Scale: 1}, Offset: domain.Decimal{Units: -50}, Unit: "°C"},
+		{Channel: "humidity", Table: []domain.CalibrationPoint{
+			{Raw: domain.Decimal{Units: 0}, Value: domain.Decimal{Units: 0}},
+			{Raw: domain.Decimal{Units: 4095}, Value: domain.Decimal{Units: 100}},
+		}},
+	}
+	s.Require().NoError(s.repo.SaveSensor(ctx, &sensor))
+
+	stored, err := s.repo.GetSensorByID(ctx, sensor.ID)
+	s.Require().NoError(err)
+	s.Equal(sensor.CurrentState, stored.CurrentState)
+	s.Equal(sensor.Calibration, stored.Calibration)
+}
+
+func (s *SensorSuite) TestGetSensors() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	sensors, err := s.repo.GetSensors(ctx)
+	s.Require().NoError(err)
+	s.Empty(sensors)
+
+	// the serial numbers are not in the order of the ids
+	var saved []domain.Sensor
+	for _, sn := range []string{"3000000000", "1000000000", "2000000000"} {
+		sensor := newSensor(sn)
+		s.Require().NoError(s.repo.SaveSensor(ctx, &sensor))
+		saved = append(saved, sensor)
+	}
+
+	sensors, err = s.repo.GetSensors(ctx)
+	s.Require().NoError(err)
+	s.Equal(saved, sensors, "Датчики упорядочены по id")
+}
+
+func (s *SensorSuite) TestNotFound() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	_, err := s.repo.GetSensorByID(ctx, 1)
+	s.ErrorIs(err, usecase.ErrSensorNotFound)
+	_, err = s.repo.GetSensorBySerialNumber(ctx, "1000000000")
+	s.ErrorIs(err, usecase.ErrSensorNotFound)
+
+	sensor := newSensor("1000000000")
+	s.Require().NoError(s.repo.SaveSensor(ctx, &sensor))
+	_, err = s.repo.GetSensorByID(ctx, sensor.ID+1)
+	s.ErrorIs(err, usecase.ErrSensorNotFound)
+}
+
+func (s *SensorSuite) TestCancelled() {
+	ctx, cancel := testContext()
+	defer cancel()
+	sensor := newSensor("1000000000")
+	s.Require().NoError(s.repo.SaveSensor(ctx, &sensor))
+
+	cancelled := cancelledContext()
+	other := newSensor("1000000001")
+	s.ErrorIs(s.repo.SaveSensor(cancelled, &other), context.Canceled)
+	_, err := s.repo.GetSensors(cancelled)
+	s.ErrorIs(err, context.Canceled)
+	_, err = s.repo.GetSensorByID(cancelled, sensor.ID)
+	s.ErrorIs(err, context.Canceled)
+	_, err = s.repo.GetSensorBySerialNumber(cancelled, sensor.SerialNumber)
+	s.ErrorIs(err, context.Canceled)
+	_, err = s.repo.GetSensorByID(cancelled, sensor.ID+100)
+	s.ErrorIs(err, context.Canceled, "Отмена важнее, чем отсутствие датчика")
+}
+
+func (s *SensorSuite) TestConcurrent() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	sensors := make([]domain.Sensor, concurrency)
+	errs := make([]error, concurrency)
+	parallel(concurrency, func(i int) {
+		sensors[i] = newSensor(fmt.Sprintf("%010d", i))
+		errs[i] = s.repo.SaveSensor(ctx, &sensors[i])
+	})
+
+	ids := map[int64]bool{}
+	for i, sensor := range sensors {
+		s.Require().NoError(errs[i])
+		s.False(ids[sensor.ID], "id %d присвоен дважды", sensor.ID)
+		ids[sensor.ID] = true
+	}
+
+	// the same sensors saved again at once keep their ids
+	parallel(concurrency, func(i int) {
+		sensor := sensors[i]
+		sensor.LastActivity = base.Add(time.Duration(i) * time.Second)
+		errs[i] = s.repo.SaveSensor(ctx, &sensor)
+		if errs[i] == nil && sensor.ID != sensors[i].ID {
+			errs[i] = fmt.Errorf("sensor %s has got id %d instead of %d", sensor.SerialNumber, sensor.ID, sensors[i].ID)
+		}
+	})
+	for _, err := range errs {
+		s.NoError(err)
+	}
+
+	stored, err := s.repo.GetSensors(ctx)
+	s.Require().NoError(err)
+	s.Len(stored, concurrency)
+}
@@ -0,0 +1,172 @@
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// UserSuite checks a usecase.UserRepository
+type UserSuite struct {
+	suite.Suite
+	// NewRepository returns an empty repository, it is called before every test
+	NewRepository func(t *testing.T) usecase.UserRepository
+
+	repo usecase.UserRepository
+}
+
+func (s *UserSuite) SetupTest() {
+	s.repo = s.NewRepository(s.T())
+}
+
+func (s *UserSuite) TestSaveUser() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	first, second := domain.User{Name: "vasya pupkin"}, domain.User{Name: "vasya pupkin"}
+	s.Require().NoError(s.repo.SaveUser(ctx, &first))
+	s.Require().NoError(s.repo.SaveUser(ctx, &second))
+	s.Positive(first.ID, "Новому пользователю присваивается id")
+	s.Greater(second.ID, first.ID, "Пользователи с одним именем разные")
+
+	for _, want := range []domain.User{first, second} {
+		user, err := s.repo.GetUserByID(ctx, want.ID)
+		s.Require().NoError(err)
+		s.Equal(want, *user)
+	}
+}
+
+func (s *UserSuite) TestNotFound() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	_, err := s.repo.GetUserByID(ctx, 1)
+	s.ErrorIs(err, usecase.ErrUserNotFound)
+
+	user := domain.User{Name: "vasya pupkin"}
+	s.Require().NoError(s.repo.SaveUser(ctx, &user))
+	_, err = s.repo.GetUserByID(ctx, user.ID+1)
+	s.ErrorIs(err, usecase.ErrUserNotFound)
+}
+
+func (s *UserSuite) TestCancelled() {
+	ctx, cancel := testContext()
+	defer cancel()
+	user := domain.User{Name: "vasya pupkin"}
+	s.Require().NoError(s.repo.SaveUser(ctx, &user))
+
+	cancelled := cancelledContext()
+	other := domain.User{Name: "petya pupkin"}
+	s.ErrorIs(s.repo.SaveUser(cancelled, &other), context.Canceled)
+	_, err := s.repo.GetUserByID(cancelled, user.ID)
+	s.ErrorIs(err, context.Canceled)
+	_, err = s.repo.GetUserByID(cancelled, user.ID+100)
+	s.ErrorIs(err, context.Canceled, "Отмена важнее, чем отсутствие пользователя")
+}
+
+func (s *UserSuite) TestConcurrent() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	users := make([]domain.User, concurrency)
+	errs := make([]error, concurrency)
+	parallel(concurrency, func(i int) {
+		users[i] = domain.User{Name: fmt.Sprintf("user %d", i)}
+		errs[i] = s.repo.SaveUser(ctx, &users[i])
+	})
+
+	ids := map[int64]bool{}
+	for i, want := range users {
+		s.Require().NoError(errs[i])
+		s.False(ids[want.ID], "id %d присвоен дважды", want.ID)
+		ids[want.ID] = true
+
+		user, err := s.repo.GetUserByID(ctx, want.ID)
+		s.Require().NoError(err)
+		s.Equal(want, *user)
+	}
+}
+
+// SensorOwnerSuite checks a usecase.SensorOwnerRepository
+type SensorOwnerSuite struct {
+	suite.Suite
+	// NewRepository returns an empty repository, it is called before every test
+	NewRepository func(t *testing.T) usecase.SensorOwnerRepository
+
+	repo usecase.SensorOwnerRepository
+}
+
+func (s *SensorOwnerSuite) SetupTest() {
+	s.repo = s.NewRepository(s.T())
+}
+
+func (s *SensorOwnerSuite) TestGetSensorsByUserID() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	sensors, err := s.repo.GetSensorsByUserID(ctx, 1)
+	s.Require().NoError(err)
+	s.Empty(sensors)
+
+	owners := []domain.SensorOwner{
+		{UserID: 1, SensorID: 3},
+		{UserID: 2, SensorID: 3},
+		{UserID: 1, SensorID: 1},
+		{UserID: 1, SensorID: 2},
+	}
+	for _, owner := range owners {
+		s.Require().NoError(s.repo.SaveSensorOwner(ctx, owner))
+	}
+
+	sensors, err = s.repo.GetSensorsByUserID(ctx, 1)
+	s.Require().NoError(err)
+	s.Equal([]domain.SensorOwner{owners[0], owners[2], owners[3]}, sensors, "Привязки возвращаются в порядке сохранения")
+
+	sensors, err = s.repo.GetSensorsByUserID(ctx, 3)
+	s.Require().NoError(err)
+	s.Empty(sensors)
+
+	all, err := s.repo.GetSensorOwners(ctx)
+	s.Require().NoError(err)
+	s.Equal(owners, all, "Все привязки возвращаются в порядке сохранения")
+}
+
+func (s *SensorOwnerSuite) TestCancelled() {
+	ctx, cancel := testContext()
+	defer cancel()
+	s.Require().NoError(s.repo.SaveSensorOwner(ctx, domain.SensorOwner{UserID: 1, SensorID: 1}))
+
+	cancelled := cancelledContext()
+	s.ErrorIs(s.repo.SaveSensorOwner(cancelled, domain.SensorOwner{UserID: 1, SensorID: 2}), context.Canceled)
+	_, err := s.repo.GetSensorsByUserID(cancelled, 1)
+	s.ErrorIs(err, context.Canceled)
+	_, err = s.repo.GetSensorOwners(cancelled)
+	s.ErrorIs(err, context.Canceled)
+}
+
+func (s *SensorOwnerSuite) TestConcurrent() {
+	ctx, cancel := testContext()
+	defer cancel()
+
+	want := make([]domain.SensorOwner, concurrency)
+	errs := make([]error, concurrency)
+	parallel(concurrency, func(i int) {
+		want[i] = domain.SensorOwner{UserID: int64(1 + i%2), SensorID: int64(i)}
+		errs[i] = s.repo.SaveSensorOwner(ctx, want[i])
+	})
+	for _, err := range errs {
+		s.Require().NoError(err)
+	}
+
+	var got []domain.SensorOwner
+	for _, userID := range []int64{1, 2} {
+		sensors, err := s.repo.GetSensorsByUserID(ctx, userID)
+		s.Require().NoError(err)
+		got = append(got, sensors...)
+	}
+	s.ElementsMatch(want, got, "Все привязки сохранены по одному разу")
+}
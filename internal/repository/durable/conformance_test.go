@@ -0,0 +1,54 @@
+package durable
+
+import (
+	"homework/internal/repository/conformance"
+	"homework/internal/usecase"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// openStore opens a store in a directory of the test, it is closed when the test is done
+func openStore(t *testing.T) *Store {
+	s, _ := open(t, t.TempDir())
+	t.Cleanup(func() {
+		require.NoError(t, s.Close())
+	})
+	return s
+}
+
+func TestConformance(t *testing.T) {
+	t.Run("sensors", func(t *testing.T) {
+		suite.Run(t, &conformance.SensorSuite{NewRepository: func(t *testing.T) usecase.SensorRepository {
+			return openStore(t).Sensors()
+		}})
+	})
+	t.Run("events", func(t *testing.T) {
+		suite.Run(t, &conformance.EventSuite{NewRepository: func(t *testing.T) usecase.EventRepository {
+			return openStore(t).Events()
+		}})
+	})
+	t.Run("users", func(t *testing.T) {
+		suite.Run(t, &conformance.UserSuite{NewRepository: func(t *testing.T) usecase.UserRepository {
+			return openStore(t).Users()
+		}})
+	})
+	t.Run("sensor owners", func(t *testing.T) {
+		suite.Run(t, &conformance.SensorOwnerSuite{NewRepository: func(t *testing.T) usecase.SensorOwnerRepository {
+			return openStore(t).SensorOwners()
+		}})
+	})
+	t.Run("changes", func(t *testing.T) {
+		suite.Run(t, &conformance.ChangeSuite{NewRepositories: func(t *testing.T) conformance.ChangeRepositories {
+			s := openStore(t)
+			return conformance.ChangeRepositories{
+				Sensors:      s.Sensors(),
+				Events:       s.Events(),
+				Users:        s.Users(),
+				SensorOwners: s.SensorOwners(),
+				Changes:      s.Changes(),
+			}
+		}})
+	})
+}
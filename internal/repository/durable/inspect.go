@@ -0,0 +1,78 @@
+package durable
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// FileReport describes a segment or a snapshot of the directory
+type FileReport struct {
+	Name     string `json:"name"`
+	Snapshot bool   `json:"snapshot"`
+	Seq      uint64 `json:"seq"`
+	Size     int64  `json:"size"`
+	// Records counts the records by their kinds
+	Records map[string]int `json:"records"`
+	// Status is ok, torn or corrupt, Offset and Reason tell where the damage is
+	Status string `json:"status"`
+	Offset int64  `json:"offset,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+const (
+	StatusOK      = "ok"
+	StatusTorn    = "torn"
+	StatusCorrupt = "corrupt"
+)
+
+// Record is a record as the inspection shows it
+type Record struct {
+	Offset int64           `json:"offset"`
+	Kind   string          `json:"kind"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Inspect reads every file of the directory without changing anything, so it may run beside the server.
+// The records are passed to fn as they are read if it is not nil. A torn tail is reported for any segment,
+// though only the last one is repaired on open.
+func Inspect(dir string, fn func(file string, record Record)) ([]FileReport, error) {
+	files, _, err := listFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]FileReport, 0, len(files))
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		report := FileReport{Name: f.name, Snapshot: f.snapshot, Seq: f.seq, Size: info.Size(), Records: map[string]int{}}
+
+		offset := int64(len(segmentMagic))
+		end, err := readFile(path, f.snapshot, func(kind byte, data []byte) error {
+			report.Records[kindName(kind)]++
+			if fn != nil {
+				fn(f.name, Record{Offset: offset, Kind: kindName(kind), Data: data})
+			}
+			offset += headerSize + int64(len(data))
+			return nil
+		})
+
+		report.Status = StatusOK
+		var corrupt *CorruptError
+		switch {
+		case errors.As(err, &corrupt):
+			report.Status, report.Offset, report.Reason = StatusCorrupt, corrupt.Offset, corrupt.Reason
+		case errors.Is(err, errTorn):
+			report.Status, report.Offset, report.Reason = StatusTorn, end, err.Error()
+		case err != nil:
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
@@ -0,0 +1,20 @@
+//go:build !unix
+
+package durable
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrLocked means another process has the directory open
+var ErrLocked = errors.New("persistence: directory is used by another process")
+
+// lockDir only creates the file, the directory is not guarded against another process here
+func lockDir(path string) (func() error, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o640)
+	if err != nil {
+		return nil, err
+	}
+	return f.Close, nil
+}
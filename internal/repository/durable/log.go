@@ -0,0 +1,267 @@
+package durable
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The files of the directory. A snapshot holds the state before the segment with the same sequence number,
+// so the state is the latest snapshot and the segments from its number on.
+const (
+	segmentPrefix  = "wal-"
+	segmentSuffix  = ".log"
+	snapshotPrefix = "snapshot-"
+	snapshotSuffix = ".snap"
+	tmpSuffix      = ".tmp"
+	lockFile       = "LOCK"
+)
+
+// Every file starts with its magic, so a stray file is not taken for a log
+var (
+	segmentMagic  = []byte("SHWAL001")
+	snapshotMagic = []byte("SHSNP001")
+)
+
+// ErrClosed is returned by the writes after the store is closed
+var ErrClosed = errors.New("persistence: store is closed")
+
+func segmentName(seq uint64) string {
+	return fmt.Sprintf("%s%016d%s", segmentPrefix, seq, segmentSuffix)
+}
+
+func snapshotName(seq uint64) string {
+	return fmt.Sprintf("%s%016d%s", snapshotPrefix, seq, snapshotSuffix)
+}
+
+// dirFile is a segment or a snapshot found in the directory
+type dirFile struct {
+	name     string
+	seq      uint64
+	snapshot bool
+}
+
+// listFiles returns the segments and the snapshots ordered by their sequence numbers, the leftovers
+// of the interrupted snapshots are returned apart
+func listFiles(dir string) (files []dirFile, tmp []string, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasSuffix(name, tmpSuffix) {
+			tmp = append(tmp, name)
+			continue
+		}
+		for _, kind := range []struct {
+			prefix, suffix string
+			snapshot       bool
+		}{
+			{segmentPrefix, segmentSuffix, false},
+			{snapshotPrefix, snapshotSuffix, true},
+		} {
+			if !strings.HasPrefix(name, kind.prefix) || !strings.HasSuffix(name, kind.suffix) {
+				continue
+			}
+			seq, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, kind.prefix), kind.suffix), 10, 64)
+			if err == nil {
+				files = append(files, dirFile{name: name, seq: seq, snapshot: kind.snapshot})
+			}
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].seq != files[j].seq {
+			return files[i].seq < files[j].seq
+		}
+		// the snapshot goes before the segment of the same number
+		return files[i].snapshot && !files[j].snapshot
+	})
+	return files, tmp, nil
+}
+
+// syncDir makes the creation, the renaming and the removal of the files in the directory durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// wal appends the records to the current segment. Every append gets a position in the log of this process,
+// a write is durable once the synced position has reached it. The callers waiting for a sync share one fsync.
+type wal struct {
+	dir string
+
+	mu      sync.Mutex
+	f       *os.File
+	seq     uint64
+	size    int64
+	written int64
+	// err is sticky: a write that has failed and can't be undone leaves the segment unusable
+	err    error
+	closed bool
+
+	syncMu sync.Mutex
+	synced int64
+}
+
+// createSegment creates the segment with its magic and makes it durable along with its name
+func createSegment(dir string, seq uint64) (*os.File, error) {
+	f, err := os.OpenFile(filepath.Join(dir, segmentName(seq)), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(segmentMagic); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := syncDir(dir); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func openWAL(dir string, seq uint64) (*wal, error) {
+	f, err := createSegment(dir, seq)
+	if err != nil {
+		return nil, err
+	}
+	return &wal{dir: dir, f: f, seq: seq, size: int64(len(segmentMagic))}, nil
+}
+
+// append applies the change and writes its record under one lock, so the records of the same sensor are
+// in the log in the order their changes were made. Nothing is written if apply fails.
+// It returns the position the record ends at.
+func (l *wal) append(kind byte, apply func() (any, error)) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return 0, ErrClosed
+	}
+	if l.err != nil {
+		return 0, l.err
+	}
+
+	v, err := apply()
+	if err != nil {
+		return 0, err
+	}
+	data, err := marshal(v)
+	if err != nil {
+		return 0, err
+	}
+	rec := appendRecord(nil, kind, data)
+	if n, err := l.f.Write(rec); err != nil {
+		// the part of the record that has got to the file would look like a corruption to the replay
+		if n > 0 {
+			if terr := l.f.Truncate(l.size); terr != nil {
+				l.err = fmt.Errorf("persistence: segment is left with a partial record: %w", errors.Join(err, terr))
+				return 0, l.err
+			}
+			if _, serr := l.f.Seek(l.size, io.SeekStart); serr != nil {
+				l.err = fmt.Errorf("persistence: segment is left at a wrong offset: %w", serr)
+				return 0, l.err
+			}
+		}
+		return 0, fmt.Errorf("persistence: can't write record: %w", err)
+	}
+	l.size += int64(len(rec))
+	l.written += int64(len(rec))
+	return l.written, nil
+}
+
+// sync makes the log durable up to the position. The first caller syncs for everyone who has written
+// by then, the rest find their records synced when they get the lock.
+func (l *wal) sync(upto int64) error {
+	l.syncMu.Lock()
+	defer l.syncMu.Unlock()
+	if l.synced >= upto {
+		return nil
+	}
+
+	l.mu.Lock()
+	f, written, closed := l.f, l.written, l.closed
+	l.mu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+	// the segment is not switched meanwhile, rotate takes syncMu as well
+	if err := f.Sync(); err != nil {
+		l.mu.Lock()
+		l.err = fmt.Errorf("persistence: can't sync segment: %w", err)
+		l.mu.Unlock()
+		return l.err
+	}
+	l.synced = written
+	return nil
+}
+
+// rotate syncs and closes the current segment and starts the next one, it returns the number of the new one
+func (l *wal) rotate() (uint64, error) {
+	l.syncMu.Lock()
+	defer l.syncMu.Unlock()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return 0, ErrClosed
+	}
+	if l.err != nil {
+		return 0, l.err
+	}
+
+	if err := l.f.Sync(); err != nil {
+		return 0, err
+	}
+	f, err := createSegment(l.dir, l.seq+1)
+	if err != nil {
+		return 0, err
+	}
+	if err := l.f.Close(); err != nil {
+		f.Close()
+		return 0, err
+	}
+	l.f, l.seq, l.size, l.synced = f, l.seq+1, int64(len(segmentMagic)), l.written
+	return l.seq, nil
+}
+
+// pending tells whether there are records that are not synced yet
+func (l *wal) pending() bool {
+	l.syncMu.Lock()
+	defer l.syncMu.Unlock()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.written > l.synced
+}
+
+func (l *wal) close() error {
+	l.syncMu.Lock()
+	defer l.syncMu.Unlock()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	return errors.Join(l.f.Sync(), l.f.Close())
+}
+
+// check reports the sticky error, the store takes no writes after it
+func (l *wal) check() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
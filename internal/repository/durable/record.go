@@ -0,0 +1,157 @@
+package durable
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Kinds of the records. A record is the whole state of what was saved, so replaying it twice changes nothing.
+const (
+	kindSensor      byte = 1
+	kindUser        byte = 2
+	kindSensorOwner byte = 3
+	kindEvent       byte = 4
+	kindEvents      byte = 5
+	// kindChanges is a save with the changes it has recorded into the feed, both are replayed from it
+	kindChanges byte = 6
+	// kindFeed are the changes kept in the feed, a snapshot has them apart from the entities
+	kindFeed byte = 7
+	// kindFeedHorizon are the cursors of the last recorded and the last deleted changes
+	kindFeedHorizon byte = 8
+	kindConsumer    byte = 9
+	kindSensorType  byte = 10
+	// kindSensorTypeDeleted is the name of a deleted sensor type, a snapshot has the deleted builtin ones
+	kindSensorTypeDeleted byte = 11
+	// kindEnd closes a snapshot, a snapshot without it is not complete
+	kindEnd byte = 0xff
+)
+
+func kindName(kind byte) string {
+	switch kind {
+	case kindSensor:
+		return "sensor"
+	case kindUser:
+		return "user"
+	case kindSensorOwner:
+		return "sensor_owner"
+	case kindEvent:
+		return "event"
+	case kindEvents:
+		return "events"
+	case kindChanges:
+		return "changes"
+	case kindFeed:
+		return "feed"
+	case kindFeedHorizon:
+		return "feed_horizon"
+	case kindConsumer:
+		return "consumer"
+	case kindSensorType:
+		return "sensor_type"
+	case kindSensorTypeDeleted:
+		return "sensor_type_deleted"
+	case kindEnd:
+		return "end"
+	}
+	return fmt.Sprintf("unknown(%d)", kind)
+}
+
+const (
+	// headerSize is the length, the checksum and the kind of a record followed by the checksum of these
+	headerSize = 4 + 4 + 1 + 4
+	// maxRecordSize bounds a record, a larger length is taken for a corruption
+	maxRecordSize = 64 << 20
+)
+
+var (
+	// ErrCorrupt means a log or a snapshot can't be read up to its end, the data after the damage is lost
+	ErrCorrupt = errors.New("persistence: data is corrupted")
+	// errTorn is a record cut short by a crash in the middle of its write
+	errTorn = errors.New("record is incomplete")
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// CorruptError tells where the damage is, the CLI shows it to decide on the repair
+type CorruptError struct {
+	Path   string
+	Offset int64
+	Reason string
+}
+
+func (e *CorruptError) Error() string {
+	return fmt.Sprintf("%s is corrupted at offset %d: %s", e.Path, e.Offset, e.Reason)
+}
+
+func (e *CorruptError) Is(target error) bool {
+	return target == ErrCorrupt
+}
+
+// appendRecord frames the data: the length, the CRC-32C of the kind and the data, the kind, the CRC-32C of
+// the header so far and the data. The header has its own checksum, so a damaged length isn't taken for a torn record.
+func appendRecord(buf []byte, kind byte, data []byte) []byte {
+	crc := crc32.Update(crc32.Checksum([]byte{kind}, castagnoli), castagnoli, data)
+	start := len(buf)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(data)))
+	buf = binary.BigEndian.AppendUint32(buf, crc)
+	buf = append(buf, kind)
+	buf = binary.BigEndian.AppendUint32(buf, crc32.Checksum(buf[start:], castagnoli))
+	return append(buf, data...)
+}
+
+// recordReader reads the records one by one and keeps the offset of the next one
+type recordReader struct {
+	r      *bufio.Reader
+	offset int64
+}
+
+func newRecordReader(r io.Reader, offset int64) *recordReader {
+	return &recordReader{r: bufio.NewReaderSize(r, 1<<16), offset: offset}
+}
+
+// next returns io.EOF at the end of the records and errTorn if the last one is cut short. A record that
+// doesn't match its checksum is torn as well if nothing follows it, otherwise it is a corruption.
+// A header is written before its data, so a complete header that doesn't match its checksum is always a corruption.
+func (rr *recordReader) next() (kind byte, data []byte, err error) {
+	var header [headerSize]byte
+	n, err := io.ReadFull(rr.r, header[:])
+	if err == io.EOF {
+		return 0, nil, io.EOF
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %d bytes of the header", errTorn, n)
+	}
+
+	if crc32.Checksum(header[:9], castagnoli) != binary.BigEndian.Uint32(header[9:13]) {
+		return 0, nil, errors.New("header checksum mismatch")
+	}
+	size := binary.BigEndian.Uint32(header[0:4])
+	crc := binary.BigEndian.Uint32(header[4:8])
+	kind = header[8]
+	if size > maxRecordSize {
+		return 0, nil, fmt.Errorf("length %d is too large", size)
+	}
+
+	data = make([]byte, size)
+	if n, err := io.ReadFull(rr.r, data); err != nil {
+		return 0, nil, fmt.Errorf("%w: %d of %d bytes", errTorn, n, size)
+	}
+	if crc32.Update(crc32.Checksum([]byte{kind}, castagnoli), castagnoli, data) != crc {
+		if rr.atEnd() {
+			return 0, nil, fmt.Errorf("%w: checksum mismatch", errTorn)
+		}
+		return 0, nil, errors.New("checksum mismatch")
+	}
+
+	rr.offset += headerSize + int64(size)
+	return kind, data, nil
+}
+
+func (rr *recordReader) atEnd() bool {
+	_, err := rr.r.Peek(1)
+	return err != nil
+}
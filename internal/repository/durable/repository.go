@@ -0,0 +1,235 @@
+package durable
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"time"
+)
+
+// Sensors returns the sensor repository that logs its saves
+func (s *Store) Sensors() *SensorRepository {
+	return &SensorRepository{store: s}
+}
+
+// Events returns the event repository that logs its saves
+func (s *Store) Events() *EventRepository {
+	return &EventRepository{store: s}
+}
+
+// Users returns the user repository that logs its saves
+func (s *Store) Users() *UserRepository {
+	return &UserRepository{store: s}
+}
+
+// SensorOwners returns the sensor owner repository that logs its saves
+func (s *Store) SensorOwners() *SensorOwnerRepository {
+	return &SensorOwnerRepository{store: s}
+}
+
+// Changes returns the change feed that logs the cursors of its consumers. The changes themselves are logged
+// with the saves, the deletion of the old ones is not: the snapshot keeps the feed as it is by then.
+func (s *Store) Changes() *ChangeRepository {
+	return &ChangeRepository{store: s}
+}
+
+// SensorTypes returns the sensor type registry that logs its saves and deletions
+func (s *Store) SensorTypes() *SensorTypeRepository {
+	return &SensorTypeRepository{store: s}
+}
+
+type SensorRepository struct {
+	store *Store
+}
+
+// SaveSensor logs the sensor as it is stored, with the id and the registration time the repository has given it
+func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor) error {
+	err := r.store.writeChanges(func() error {
+		return r.store.repos.Sensors.SaveSensor(context.WithoutCancel(ctx), sensor)
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+func (r *SensorRepository) GetSensors(ctx context.Context) ([]domain.Sensor, error) {
+	return r.store.repos.Sensors.GetSensors(ctx)
+}
+
+func (r *SensorRepository) GetSensorByID(ctx context.Context, id int64) (*domain.Sensor, error) {
+	return r.store.repos.Sensors.GetSensorByID(ctx, id)
+}
+
+func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn string) (*domain.Sensor, error) {
+	return r.store.repos.Sensors.GetSensorBySerialNumber(ctx, sn)
+}
+
+type EventRepository struct {
+	store *Store
+}
+
+// SaveEvent logs the event unless it conflicts with the stored one, a repeat is logged without changes
+func (r *EventRepository) SaveEvent(ctx context.Context, event *domain.Event) error {
+	if event == nil {
+		return r.store.repos.Events.SaveEvent(ctx, event)
+	}
+	// the change is logged even if the caller has gone meanwhile, it is already made
+	err := r.store.writeChanges(func() error {
+		return r.store.repos.Events.SaveEvent(context.WithoutCancel(ctx), event)
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// SaveEvents logs the batch as one record, so it is replayed whole or not at all.
+// The events whose timestamps are taken are not stored, so the record has only the stored ones.
+func (r *EventRepository) SaveEvents(ctx context.Context, events []*domain.Event) error {
+	if len(events) == 0 {
+		return ctx.Err()
+	}
+	err := r.store.writeChanges(func() error {
+		return r.store.repos.Events.SaveEvents(context.WithoutCancel(ctx), events)
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+func (r *EventRepository) GetLastEventBySensorID(ctx context.Context, id int64) (*domain.Event, error) {
+	return r.store.repos.Events.GetLastEventBySensorID(ctx, id)
+}
+
+func (r *EventRepository) GetHistoryBySensorID(ctx context.Context, id int64, from, to time.Time) ([]*domain.Event, error) {
+	return r.store.repos.Events.GetHistoryBySensorID(ctx, id, from, to)
+}
+
+func (r *EventRepository) StreamHistoryBySensorID(ctx context.Context, id int64, from, to time.Time, fn func(*domain.Event) error) error {
+	return r.store.repos.Events.StreamHistoryBySensorID(ctx, id, from, to, fn)
+}
+
+type UserRepository struct {
+	store *Store
+}
+
+// SaveUser logs the user with the id the repository has given it
+func (r *UserRepository) SaveUser(ctx context.Context, user *domain.User) error {
+	err := r.store.writeChanges(func() error {
+		return r.store.repos.Users.SaveUser(context.WithoutCancel(ctx), user)
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+func (r *UserRepository) GetUserByID(ctx context.Context, id int64) (*domain.User, error) {
+	return r.store.repos.Users.GetUserByID(ctx, id)
+}
+
+type SensorOwnerRepository struct {
+	store *Store
+}
+
+func (r *SensorOwnerRepository) SaveSensorOwner(ctx context.Context, sensorOwner domain.SensorOwner) error {
+	err := r.store.writeChanges(func() error {
+		return r.store.repos.SensorOwners.SaveSensorOwner(context.WithoutCancel(ctx), sensorOwner)
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+func (r *SensorOwnerRepository) GetSensorsByUserID(ctx context.Context, userID int64) ([]domain.SensorOwner, error) {
+	return r.store.repos.SensorOwners.GetSensorsByUserID(ctx, userID)
+}
+
+func (r *SensorOwnerRepository) GetSensorOwners(ctx context.Context) ([]domain.SensorOwner, error) {
+	return r.store.repos.SensorOwners.GetSensorOwners(ctx)
+}
+
+type ChangeRepository struct {
+	store *Store
+}
+
+func (r *ChangeRepository) GetChanges(ctx context.Context, after int64, limit int) ([]domain.Change, error) {
+	return r.store.repos.Changes.GetChanges(ctx, after, limit)
+}
+
+func (r *ChangeRepository) DeleteChanges(ctx context.Context, before time.Time) (int64, error) {
+	return r.store.repos.Changes.DeleteChanges(ctx, before)
+}
+
+func (r *ChangeRepository) SaveConsumer(ctx context.Context, consumer domain.ChangeConsumer) error {
+	err := r.store.write(kindConsumer, func() (any, error) {
+		if err := r.store.repos.Changes.SaveConsumer(context.WithoutCancel(ctx), consumer); err != nil {
+			return nil, err
+		}
+		return consumer, nil
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+func (r *ChangeRepository) GetConsumer(ctx context.Context, name string) (*domain.ChangeConsumer, error) {
+	return r.store.repos.Changes.GetConsumer(ctx, name)
+}
+
+func (r *ChangeRepository) Changed() <-chan struct{} {
+	return r.store.repos.Changes.Changed()
+}
+
+type SensorTypeRepository struct {
+	store *Store
+}
+
+func (r *SensorTypeRepository) SaveSensorType(ctx context.Context, sensorType domain.SensorTypeSpec) (bool, error) {
+	var created bool
+	err := r.store.write(kindSensorType, func() (any, error) {
+		var err error
+		created, err = r.store.repos.SensorTypes.SaveSensorType(context.WithoutCancel(ctx), sensorType)
+		return sensorType, err
+	})
+	if err != nil {
+		return false, err
+	}
+	return created, ctx.Err()
+}
+
+func (r *SensorTypeRepository) GetSensorTypes(ctx context.Context) ([]domain.SensorTypeSpec, error) {
+	return r.store.repos.SensorTypes.GetSensorTypes(ctx)
+}
+
+func (r *SensorTypeRepository) GetSensorType(ctx context.Context, name domain.SensorType) (*domain.SensorTypeSpec, error) {
+	return r.store.repos.SensorTypes.GetSensorType(ctx, name)
+}
+
+func (r *SensorTypeRepository) DeleteSensorType(ctx context.Context, name domain.SensorType) error {
+	err := r.store.write(kindSensorTypeDeleted, func() (any, error) {
+		if err := r.store.repos.SensorTypes.DeleteSensorType(context.WithoutCancel(ctx), name); err != nil {
+			return nil, err
+		}
+		return sensorTypeDeleted{Name: name}, nil
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+var (
+	_ usecase.SensorRepository      = (*SensorRepository)(nil)
+	_ usecase.EventRepository       = (*EventRepository)(nil)
+	_ usecase.EventBatchSaver       = (*EventRepository)(nil)
+	_ usecase.UserRepository        = (*UserRepository)(nil)
+	_ usecase.SensorOwnerRepository = (*SensorOwnerRepository)(nil)
+	_ usecase.ChangeRepository      = (*ChangeRepository)(nil)
+	_ usecase.ChangeNotifier        = (*ChangeRepository)(nil)
+	_ usecase.SensorTypeRepository  = (*SensorTypeRepository)(nil)
+)
@@ -0,0 +1,161 @@
+package durable
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// snapshotEnd closes a snapshot with the number of the records before it
+type snapshotEnd struct {
+	Records int64 `json:"records"`
+}
+
+// snapshotWriter writes a snapshot to a temporary file, it becomes the snapshot only when it is complete
+type snapshotWriter struct {
+	dir     string
+	seq     uint64
+	f       *os.File
+	w       *bufio.Writer
+	records int64
+	buf     []byte
+}
+
+func newSnapshotWriter(dir string, seq uint64) (*snapshotWriter, error) {
+	f, err := os.OpenFile(filepath.Join(dir, snapshotName(seq)+tmpSuffix), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriterSize(f, 1<<16)
+	if _, err := w.Write(snapshotMagic); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &snapshotWriter{dir: dir, seq: seq, f: f, w: w}, nil
+}
+
+func (s *snapshotWriter) write(kind byte, v any) error {
+	data, err := marshal(v)
+	if err != nil {
+		return err
+	}
+	s.buf = appendRecord(s.buf[:0], kind, data)
+	if _, err := s.w.Write(s.buf); err != nil {
+		return err
+	}
+	s.records++
+	return nil
+}
+
+// commit closes the snapshot with its end, syncs it and renames it into place
+func (s *snapshotWriter) commit() error {
+	err := s.write(kindEnd, snapshotEnd{Records: s.records})
+	if err == nil {
+		err = s.w.Flush()
+	}
+	if err == nil {
+		err = s.f.Sync()
+	}
+	if cerr := s.f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		s.abort()
+		return err
+	}
+
+	tmp := filepath.Join(s.dir, snapshotName(s.seq)+tmpSuffix)
+	if err := os.Rename(tmp, filepath.Join(s.dir, snapshotName(s.seq))); err != nil {
+		return err
+	}
+	return syncDir(s.dir)
+}
+
+// abort removes the unfinished snapshot, the previous one and the segments stay as they were
+func (s *snapshotWriter) abort() {
+	_ = s.f.Close()
+	_ = os.Remove(filepath.Join(s.dir, snapshotName(s.seq)+tmpSuffix))
+}
+
+// readFile checks the magic of a snapshot or a segment and passes its records to fn.
+// A snapshot must end with its end record, a segment may end anywhere between the records.
+// The offset of the last complete record's end is returned along with the error, so a torn segment can be cut there.
+func readFile(path string, snapshot bool, fn func(kind byte, data []byte) error) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	magic := segmentMagic
+	if snapshot {
+		magic = snapshotMagic
+	}
+	head := make([]byte, len(magic))
+	if n, err := io.ReadFull(f, head); err != nil {
+		if snapshot || n > 0 && !bytes.Equal(head[:n], magic[:n]) {
+			return 0, &CorruptError{Path: path, Reason: "file is too short"}
+		}
+		// the crash has come before the magic of a new segment has been written
+		return 0, fmt.Errorf("%w: %d bytes of the magic", errTorn, n)
+	}
+	if !bytes.Equal(head, magic) {
+		return 0, &CorruptError{Path: path, Reason: "unknown magic " + string(head)}
+	}
+
+	rr := newRecordReader(f, int64(len(magic)))
+	var records int64
+	for {
+		kind, data, err := rr.next()
+		if errors.Is(err, io.EOF) {
+			if snapshot {
+				return rr.offset, &CorruptError{Path: path, Offset: rr.offset, Reason: "snapshot has no end"}
+			}
+			return rr.offset, nil
+		}
+		if err != nil {
+			if !snapshot && errors.Is(err, errTorn) {
+				return rr.offset, err
+			}
+			if !snapshot && zeroTail(f, rr.offset) {
+				// some file systems grow the file before the data reaches it, a crash leaves zeros there
+				return rr.offset, fmt.Errorf("%w: zeros after the last record", errTorn)
+			}
+			return rr.offset, &CorruptError{Path: path, Offset: rr.offset, Reason: err.Error()}
+		}
+
+		if kind == kindEnd {
+			var end snapshotEnd
+			if !snapshot || json.Unmarshal(data, &end) != nil || end.Records != records {
+				return rr.offset, &CorruptError{Path: path, Offset: rr.offset, Reason: "unexpected end record"}
+			}
+			if !rr.atEnd() {
+				return rr.offset, &CorruptError{Path: path, Offset: rr.offset, Reason: "data after the end record"}
+			}
+			return rr.offset, nil
+		}
+		if err := fn(kind, data); err != nil {
+			return rr.offset, &CorruptError{Path: path, Offset: rr.offset, Reason: fmt.Sprintf("%s record: %v", kindName(kind), err)}
+		}
+		records++
+	}
+}
+
+// zeroTail tells whether the file holds nothing but zeros from the offset on
+func zeroTail(f *os.File, offset int64) bool {
+	r := bufio.NewReader(io.NewSectionReader(f, offset, 1<<62))
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return errors.Is(err, io.EOF)
+		}
+		if b != 0 {
+			return false
+		}
+	}
+}
@@ -0,0 +1,560 @@
+// Package durable keeps the in-memory repositories across the restarts: every save is appended to
+// a write-ahead log before it is acknowledged, a snapshot of the whole state compacts the log from time to time,
+// and the state is restored from the latest snapshot and the log after it on start.
+package durable
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	changeInmemory "homework/internal/repository/change/inmemory"
+	eventInmemory "homework/internal/repository/event/inmemory"
+	sensorInmemory "homework/internal/repository/sensor/inmemory"
+	sensorTypeInmemory "homework/internal/repository/sensortype/inmemory"
+	userInmemory "homework/internal/repository/user/inmemory"
+)
+
+// Repositories are the in-memory repositories the store keeps, it restores them on open
+type Repositories struct {
+	Sensors      *sensorInmemory.SensorRepository
+	Events       *eventInmemory.EventRepository
+	Users        *userInmemory.UserRepository
+	SensorOwners *userInmemory.SensorOwnerRepository
+	// Changes is the feed the rest record their saves into
+	Changes *changeInmemory.ChangeRepository
+	// SensorTypes is the registry the sensors are checked by, it is restored before them
+	SensorTypes *sensorTypeInmemory.SensorTypeRepository
+}
+
+// NewRepositories makes the empty repositories
+func NewRepositories() Repositories {
+	changes := changeInmemory.NewChangeRepository()
+	return Repositories{
+		Sensors:      sensorInmemory.NewSensorRepository(sensorInmemory.WithSensorChanges(changes.Record)),
+		Events:       eventInmemory.NewEventRepository(eventInmemory.WithEventChanges(changes.Record)),
+		Users:        userInmemory.NewUserRepository(userInmemory.WithUserChanges(changes.Record)),
+		SensorOwners: userInmemory.NewSensorOwnerRepository(userInmemory.WithSensorOwnerChanges(changes.Record)),
+		Changes:      changes,
+		SensorTypes:  sensorTypeInmemory.NewSensorTypeRepository(),
+	}
+}
+
+type Options struct {
+	// SyncInterval is how often the log is synced to the disk. With 0 every save waits for its sync,
+	// otherwise a crash loses the saves of the last interval.
+	SyncInterval time.Duration
+	// SnapshotInterval is how often Run takes a snapshot, never if 0
+	SnapshotInterval time.Duration
+}
+
+// Store is the persistence of the repositories in a directory, one process at a time may open it
+type Store struct {
+	dir    string
+	opts   Options
+	repos  Repositories
+	unlock func() error
+
+	// mu is taken for reading by the saves and for writing by the switch of the segments,
+	// so every save before the switch is in the old segments and every save after it is in the new one
+	mu  sync.RWMutex
+	log *wal
+
+	// snapshotMu lets one snapshot run at a time
+	snapshotMu sync.Mutex
+	stop       chan struct{}
+	done       sync.WaitGroup
+	closeOnce  sync.Once
+}
+
+// Open locks the directory, restores the repositories from it and starts a new segment of the log.
+// A segment cut short by a crash is truncated to its last complete record, any other damage fails
+// with a CorruptError, as the data after it would be lost.
+func Open(dir string, repos Repositories, opts Options) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	unlock, err := lockDir(filepath.Join(dir, lockFile))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{dir: dir, opts: opts, repos: repos, unlock: unlock, stop: make(chan struct{})}
+	last, err := s.restore()
+	if err != nil {
+		_ = unlock()
+		return nil, err
+	}
+	if s.log, err = openWAL(dir, last+1); err != nil {
+		_ = unlock()
+		return nil, err
+	}
+
+	if opts.SyncInterval > 0 {
+		s.done.Add(1)
+		go s.syncPeriodically()
+	}
+	return s, nil
+}
+
+// restore replays the latest snapshot and the segments after it, it returns the largest sequence number found
+func (s *Store) restore() (uint64, error) {
+	files, tmp, err := listFiles(s.dir)
+	if err != nil {
+		return 0, err
+	}
+	// a snapshot that has not been renamed into place was interrupted, the segments still have its data
+	for _, name := range tmp {
+		if err := os.Remove(filepath.Join(s.dir, name)); err != nil {
+			return 0, err
+		}
+	}
+
+	start := 0
+	for i, f := range files {
+		if f.snapshot {
+			start = i
+		}
+	}
+
+	// the changes come from the records with their original cursors, the replayed saves don't record new ones
+	resume := s.repos.Changes.Pause()
+	defer resume()
+
+	var last uint64
+	started := time.Now()
+	records := 0
+	apply := func(kind byte, data []byte) error {
+		records++
+		return s.apply(kind, data)
+	}
+	for i := start; i < len(files); i++ {
+		f := files[i]
+		last = max(last, f.seq)
+		path := filepath.Join(s.dir, f.name)
+
+		offset, err := readFile(path, f.snapshot, apply)
+		if errors.Is(err, errTorn) && i == len(files)-1 {
+			// only the segment written at the crash may be cut short
+			slog.Warn("Log segment is cut short, truncating it", "path", path, "offset", offset, "reason", err)
+			if err := truncate(path, offset); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if errors.Is(err, errTorn) {
+			return 0, &CorruptError{Path: path, Offset: offset, Reason: err.Error()}
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if len(files) > 0 {
+		slog.Info("Repositories are restored", "dir", s.dir, "files", len(files)-start, "records", records,
+			"duration", time.Since(started))
+	}
+	return last, nil
+}
+
+// truncate cuts the torn tail off, a segment without even its magic is removed
+func truncate(path string, offset int64) error {
+	if offset == 0 {
+		return os.Remove(path)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := f.Truncate(offset); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+func marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// apply replays a record, the saves are idempotent, so a record that is also in the snapshot changes nothing
+func (s *Store) apply(kind byte, data []byte) error {
+	ctx := context.Background()
+	switch kind {
+	case kindSensor:
+		var sensor domain.Sensor
+		if err := json.Unmarshal(data, &sensor); err != nil {
+			return err
+		}
+		return s.repos.Sensors.SaveSensor(ctx, &sensor)
+	case kindUser:
+		var user domain.User
+		if err := json.Unmarshal(data, &user); err != nil {
+			return err
+		}
+		return s.repos.Users.SaveUser(ctx, &user)
+	case kindSensorOwner:
+		var owner domain.SensorOwner
+		if err := json.Unmarshal(data, &owner); err != nil {
+			return err
+		}
+		return s.repos.SensorOwners.SaveSensorOwner(ctx, owner)
+	case kindEvent, kindEvents:
+		var events []*domain.Event
+		if kind == kindEvent {
+			events = append(events, &domain.Event{})
+			if err := json.Unmarshal(data, events[0]); err != nil {
+				return err
+			}
+		} else if err := json.Unmarshal(data, &events); err != nil {
+			return err
+		}
+		// an event saved again before the crash is skipped like any other taken timestamp
+		return s.repos.Events.SaveEvents(ctx, events)
+	case kindChanges:
+		var changes []domain.Change
+		if err := json.Unmarshal(data, &changes); err != nil {
+			return err
+		}
+		for _, change := range changes {
+			if err := s.applyChange(ctx, change); err != nil {
+				return err
+			}
+			s.repos.Changes.Restore(change)
+		}
+		return nil
+	case kindFeed:
+		var changes []domain.Change
+		if err := json.Unmarshal(data, &changes); err != nil {
+			return err
+		}
+		for _, change := range changes {
+			s.repos.Changes.Restore(change)
+		}
+		return nil
+	case kindFeedHorizon:
+		var h feedHorizon
+		if err := json.Unmarshal(data, &h); err != nil {
+			return err
+		}
+		s.repos.Changes.SetHorizon(h.Last, h.Pruned)
+		return nil
+	case kindConsumer:
+		var consumer domain.ChangeConsumer
+		if err := json.Unmarshal(data, &consumer); err != nil {
+			return err
+		}
+		return s.repos.Changes.SaveConsumer(ctx, consumer)
+	case kindSensorType:
+		var sensorType domain.SensorTypeSpec
+		if err := json.Unmarshal(data, &sensorType); err != nil {
+			return err
+		}
+		_, err := s.repos.SensorTypes.SaveSensorType(ctx, sensorType)
+		return err
+	case kindSensorTypeDeleted:
+		var deleted sensorTypeDeleted
+		if err := json.Unmarshal(data, &deleted); err != nil {
+			return err
+		}
+		// the type may be deleted already, the snapshot is taken while the saves go on
+		if err := s.repos.SensorTypes.DeleteSensorType(ctx, deleted.Name); err != nil && !errors.Is(err, usecase.ErrSensorTypeNotFound) {
+			return err
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown record kind %d", kind)
+}
+
+// feedHorizon is the record of the cursors the feed has got to
+type feedHorizon struct {
+	Last   int64
+	Pruned int64
+}
+
+// sensorTypeDeleted is the record of a deleted sensor type
+type sensorTypeDeleted struct {
+	Name domain.SensorType
+}
+
+// applyChange saves the entity of the change as it was stored
+func (s *Store) applyChange(ctx context.Context, change domain.Change) error {
+	if err := change.Validate(); err != nil {
+		return err
+	}
+	switch change.Kind {
+	case domain.ChangeKindSensor:
+		return s.repos.Sensors.SaveSensor(ctx, change.Sensor)
+	case domain.ChangeKindUser:
+		return s.repos.Users.SaveUser(ctx, change.User)
+	case domain.ChangeKindSensorOwner:
+		return s.repos.SensorOwners.SaveSensorOwner(ctx, *change.SensorOwner)
+	default:
+		return s.repos.Events.SaveEvents(ctx, []*domain.Event{change.Event})
+	}
+}
+
+// writeChanges applies the save and logs the changes it has recorded into the feed in one record,
+// so the save and its changes are replayed together or not at all
+func (s *Store) writeChanges(apply func() error) error {
+	return s.write(kindChanges, func() (any, error) {
+		// the saves are applied one at a time, the changes after the last cursor are all of this one
+		last := s.repos.Changes.Last()
+		if err := apply(); err != nil {
+			return nil, err
+		}
+		return s.repos.Changes.GetChanges(context.Background(), last, math.MaxInt)
+	})
+}
+
+// write applies the change and logs it, it returns when the record is durable as the options say
+func (s *Store) write(kind byte, apply func() (any, error)) error {
+	s.mu.RLock()
+	pos, err := s.log.append(kind, apply)
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if s.opts.SyncInterval > 0 {
+		return nil
+	}
+	return s.log.sync(pos)
+}
+
+// Snapshot writes the whole state to a new snapshot and removes the segments and the snapshots it replaces.
+// The saves go on meanwhile: they land in the new segment, which the snapshot doesn't replace.
+func (s *Store) Snapshot(ctx context.Context) error {
+	s.snapshotMu.Lock()
+	defer s.snapshotMu.Unlock()
+
+	// a binding saved twice is kept twice, so unlike the rest they are taken exactly at the switch
+	s.mu.Lock()
+	seq, err := s.log.rotate()
+	var owners []domain.SensorOwner
+	if err == nil {
+		owners, err = s.repos.SensorOwners.GetSensorOwners(ctx)
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	started := time.Now()
+	w, err := newSnapshotWriter(s.dir, seq)
+	if err != nil {
+		return err
+	}
+	if err := s.dump(ctx, w, owners); err != nil {
+		w.abort()
+		return err
+	}
+	records := w.records
+	if err := w.commit(); err != nil {
+		return err
+	}
+
+	files, _, err := listFiles(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.seq < seq {
+			if err := os.Remove(filepath.Join(s.dir, f.name)); err != nil {
+				return err
+			}
+		}
+	}
+	slog.Info("Snapshot is taken", "dir", s.dir, "seq", seq, "records", records, "duration", time.Since(started))
+	return syncDir(s.dir)
+}
+
+// farFuture bounds the history of a sensor, so the whole of it is taken
+var farFuture = time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// dump writes the state. It may already have some saves of the new segment, they are replayed on top of it.
+func (s *Store) dump(ctx context.Context, w *snapshotWriter, owners []domain.SensorOwner) error {
+	if err := s.dumpSensorTypes(ctx, w); err != nil {
+		return err
+	}
+
+	sensors, err := s.repos.Sensors.GetSensors(ctx)
+	if err != nil {
+		return err
+	}
+	for _, sensor := range sensors {
+		if err := w.write(kindSensor, sensor); err != nil {
+			return err
+		}
+	}
+
+	users, err := s.repos.Users.GetUsers(ctx)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if err := w.write(kindUser, user); err != nil {
+			return err
+		}
+	}
+
+	for _, owner := range owners {
+		if err := w.write(kindSensorOwner, owner); err != nil {
+			return err
+		}
+	}
+
+	ids, err := s.repos.Events.GetSensorIDs(ctx)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		events, err := s.repos.Events.GetHistoryBySensorID(ctx, id, time.Time{}, farFuture)
+		if err != nil {
+			return err
+		}
+		if len(events) > 0 {
+			if err := w.write(kindEvents, events); err != nil {
+				return err
+			}
+		}
+	}
+	return s.dumpFeed(ctx, w)
+}
+
+// dumpSensorTypes writes the registry. The builtin types are there from the start, so only the changed
+// and the deleted ones are written.
+func (s *Store) dumpSensorTypes(ctx context.Context, w *snapshotWriter) error {
+	types, err := s.repos.SensorTypes.GetSensorTypes(ctx)
+	if err != nil {
+		return err
+	}
+	for _, b := range domain.BuiltinSensorTypes {
+		if !slices.ContainsFunc(types, func(t domain.SensorTypeSpec) bool { return t.Name == b.Name }) {
+			if err := w.write(kindSensorTypeDeleted, sensorTypeDeleted{Name: b.Name}); err != nil {
+				return err
+			}
+		}
+	}
+	for _, t := range types {
+		if slices.ContainsFunc(domain.BuiltinSensorTypes, func(b domain.SensorTypeSpec) bool { return reflect.DeepEqual(t, b) }) {
+			continue
+		}
+		if err := w.write(kindSensorType, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// feedChunk is how many changes of the feed a snapshot record holds
+const feedChunk = 1000
+
+// dumpFeed writes the kept changes, then the horizon: the changes recorded meanwhile are in the new segment
+// and are restored after the ones of the snapshot
+func (s *Store) dumpFeed(ctx context.Context, w *snapshotWriter) error {
+	for after := int64(0); ; {
+		changes, err := s.repos.Changes.GetChanges(ctx, after, feedChunk)
+		if errors.Is(err, usecase.ErrChangesExpired) {
+			// the changes have been deleted under the dump, it starts from the oldest kept one again
+			after = 0
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			break
+		}
+		if err := w.write(kindFeed, changes); err != nil {
+			return err
+		}
+		after = changes[len(changes)-1].Cursor
+	}
+
+	last, pruned := s.repos.Changes.Horizon()
+	if err := w.write(kindFeedHorizon, feedHorizon{Last: last, Pruned: pruned}); err != nil {
+		return err
+	}
+	consumers, err := s.repos.Changes.GetConsumers(ctx)
+	if err != nil {
+		return err
+	}
+	for _, consumer := range consumers {
+		if err := w.write(kindConsumer, consumer); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// Run takes the snapshots until ctx is done, a failed one is logged and tried again the next time
+func (s *Store) Run(ctx context.Context) {
+	if s.opts.SnapshotInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.opts.SnapshotInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.Snapshot(ctx); err != nil && !errors.Is(err, ErrClosed) && ctx.Err() == nil {
+				slog.Error("Snapshot has failed", "dir", s.dir, "error", err)
+			}
+		}
+	}
+}
+
+func (s *Store) syncPeriodically() {
+	defer s.done.Done()
+	ticker := time.NewTicker(s.opts.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if !s.log.pending() {
+				continue
+			}
+			s.log.mu.Lock()
+			written := s.log.written
+			s.log.mu.Unlock()
+			if err := s.log.sync(written); err != nil && !errors.Is(err, ErrClosed) {
+				slog.Error("Log sync has failed", "dir", s.dir, "error", err)
+			}
+		}
+	}
+}
+
+// Check fails once a write has failed so that the log can't be trusted, the readiness probe shows it
+func (s *Store) Check(context.Context) error {
+	return s.log.check()
+}
+
+// Close syncs and closes the log and unlocks the directory, the running snapshot is finished first
+func (s *Store) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		s.done.Wait()
+		s.snapshotMu.Lock()
+		defer s.snapshotMu.Unlock()
+		err = errors.Join(s.log.close(), s.unlock())
+	})
+	return err
+}
@@ -0,0 +1,549 @@
+package durable
+
+import (
+	"context"
+	"encoding/json"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func open(t *testing.T, dir string) (*Store, Repositories) {
+	t.Helper()
+	repos := NewRepositories()
+	s, err := Open(dir, repos, Options{})
+	require.NoError(t, err)
+	return s, repos
+}
+
+// fill saves a sensor, a user with a binding and three events of the sensor
+func fill(t *testing.T, s *Store) (domain.Sensor, domain.User) {
+	t.Helper()
+	ctx := context.Background()
+	sensor := domain.Sensor{SerialNumber: "0123456789", Type: domain.SensorTypeADC, Description: "sensor", IsActive: true}
+	require.NoError(t, s.Sensors().SaveSensor(ctx, &sensor))
+	user := domain.User{Name: "user"}
+	require.NoError(t, s.Users().SaveUser(ctx, &user))
+	require.NoError(t, s.SensorOwners().SaveSensorOwner(ctx, domain.SensorOwner{UserID: user.ID, SensorID: sensor.ID}))
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, s.Events().SaveEvent(ctx, &domain.Event{Timestamp: start, SensorID: sensor.ID, Payload: domain.IntPayload(1)}))
+	require.NoError(t, s.Events().SaveEvents(ctx, []*domain.Event{
+		{Timestamp: start.Add(time.Second), SensorID: sensor.ID, Payload: domain.IntPayload(2)},
+		{Timestamp: start.Add(2 * time.Second), SensorID: sensor.ID, Payload: domain.IntPayload(3)},
+	}))
+	return sensor, user
+}
+
+// assertFilled checks the state fill has saved
+func assertFilled(t *testing.T, repos Repositories, sensor domain.Sensor, user domain.User) {
+	t.Helper()
+	ctx := context.Background()
+	got, err := repos.Sensors.GetSensorBySerialNumber(ctx, sensor.SerialNumber)
+	require.NoError(t, err)
+	assert.Equal(t, sensor.ID, got.ID, "датчик должен сохранить id")
+	assert.True(t, sensor.RegisteredAt.Equal(got.RegisteredAt), "датчик должен сохранить время регистрации")
+	assert.Equal(t, sensor.Description, got.Description)
+
+	gotUser, err := repos.Users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user, *gotUser)
+
+	owners, err := repos.SensorOwners.GetSensorsByUserID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.SensorOwner{{UserID: user.ID, SensorID: sensor.ID}}, owners, "привязка должна быть одна")
+
+	events, err := repos.Events.GetHistoryBySensorID(ctx, sensor.ID, time.Time{}, farFuture)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	for i, e := range events {
+		assert.True(t, e.Payload.Equal(domain.IntPayload(int64(i+1))), "событие %d", i)
+	}
+}
+
+func segments(t *testing.T, dir string) []dirFile {
+	t.Helper()
+	files, _, err := listFiles(dir)
+	require.NoError(t, err)
+	return files
+}
+
+func TestStore_Replay(t *testing.T) {
+	t.Run("ok, state is restored", func(t *testing.T) {
+		dir := t.TempDir()
+		s, _ := open(t, dir)
+		sensor, user := fill(t, s)
+		require.NoError(t, s.Close())
+
+		s, repos := open(t, dir)
+		defer s.Close()
+		assertFilled(t, repos, sensor, user)
+	})
+
+	t.Run("ok, ids continue after restart", func(t *testing.T) {
+		dir := t.TempDir()
+		s, _ := open(t, dir)
+		sensor, user := fill(t, s)
+		require.NoError(t, s.Close())
+
+		s, _ = open(t, dir)
+		defer s.Close()
+		next := domain.Sensor{SerialNumber: "1111111111", Type: domain.SensorTypeContactClosure}
+		require.NoError(t, s.Sensors().SaveSensor(context.Background(), &next))
+		assert.Greater(t, next.ID, sensor.ID)
+		nextUser := domain.User{Name: "next"}
+		require.NoError(t, s.Users().SaveUser(context.Background(), &nextUser))
+		assert.Greater(t, nextUser.ID, user.ID)
+	})
+
+	t.Run("ok, conflicting event is not logged", func(t *testing.T) {
+		dir := t.TempDir()
+		s, _ := open(t, dir)
+		sensor, user := fill(t, s)
+		err := s.Events().SaveEvent(context.Background(), &domain.Event{
+			Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), SensorID: sensor.ID, Payload: domain.IntPayload(42),
+		})
+		assert.Error(t, err)
+		require.NoError(t, s.Close())
+
+		s, repos := open(t, dir)
+		defer s.Close()
+		assertFilled(t, repos, sensor, user)
+	})
+
+	t.Run("fail, directory is locked", func(t *testing.T) {
+		dir := t.TempDir()
+		s, _ := open(t, dir)
+		defer s.Close()
+
+		_, err := Open(dir, NewRepositories(), Options{})
+		assert.ErrorIs(t, err, ErrLocked)
+	})
+
+	t.Run("fail, store is closed", func(t *testing.T) {
+		s, _ := open(t, t.TempDir())
+		require.NoError(t, s.Close())
+
+		err := s.Users().SaveUser(context.Background(), &domain.User{Name: "user"})
+		assert.ErrorIs(t, err, ErrClosed)
+	})
+}
+
+func TestStore_Snapshot(t *testing.T) {
+	t.Run("ok, log is compacted", func(t *testing.T) {
+		dir := t.TempDir()
+		s, _ := open(t, dir)
+		sensor, user := fill(t, s)
+		require.NoError(t, s.Snapshot(context.Background()))
+
+		files := segments(t, dir)
+		require.Len(t, files, 2, "должны остаться снимок и новый сегмент")
+		assert.True(t, files[0].snapshot)
+		assert.Equal(t, files[0].seq, files[1].seq)
+
+		// the change after the snapshot is in the new segment
+		sensor.Description = "changed"
+		require.NoError(t, s.Sensors().SaveSensor(context.Background(), &sensor))
+		require.NoError(t, s.Close())
+
+		s, repos := open(t, dir)
+		defer s.Close()
+		assertFilled(t, repos, sensor, user)
+	})
+
+	t.Run("ok, second snapshot replaces the first", func(t *testing.T) {
+		dir := t.TempDir()
+		s, _ := open(t, dir)
+		sensor, user := fill(t, s)
+		require.NoError(t, s.Snapshot(context.Background()))
+		require.NoError(t, s.Snapshot(context.Background()))
+		require.NoError(t, s.Close())
+
+		files := segments(t, dir)
+		require.Len(t, files, 2)
+		assert.Equal(t, uint64(3), files[0].seq)
+
+		s, repos := open(t, dir)
+		defer s.Close()
+		assertFilled(t, repos, sensor, user)
+	})
+
+	t.Run("ok, interrupted snapshot is ignored", func(t *testing.T) {
+		dir := t.TempDir()
+		s, _ := open(t, dir)
+		sensor, user := fill(t, s)
+		require.NoError(t, s.Close())
+		require.NoError(t, os.WriteFile(filepath.Join(dir, snapshotName(2)+tmpSuffix), []byte("SHSNP001garbage"), 0o600))
+
+		s, repos := open(t, dir)
+		defer s.Close()
+		assertFilled(t, repos, sensor, user)
+		_, err := os.Stat(filepath.Join(dir, snapshotName(2)+tmpSuffix))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("ok, saves during the snapshots", func(t *testing.T) {
+		dir := t.TempDir()
+		s, _ := open(t, dir)
+		ctx := context.Background()
+		start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		var wg sync.WaitGroup
+		for w := range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range 200 {
+					event := &domain.Event{Timestamp: start.Add(time.Duration(i) * time.Second), SensorID: int64(w + 1), Payload: domain.IntPayload(int64(i))}
+					assert.NoError(t, s.Events().SaveEvent(ctx, event))
+					if i%50 == 0 {
+						assert.NoError(t, s.SensorOwners().SaveSensorOwner(ctx, domain.SensorOwner{UserID: int64(w + 1), SensorID: int64(i)}))
+					}
+				}
+			}()
+		}
+		for range 5 {
+			require.NoError(t, s.Snapshot(ctx))
+		}
+		wg.Wait()
+		require.NoError(t, s.Close())
+
+		s, repos := open(t, dir)
+		defer s.Close()
+		for w := range 4 {
+			events, err := repos.Events.GetHistoryBySensorID(ctx, int64(w+1), time.Time{}, farFuture)
+			require.NoError(t, err)
+			assert.Len(t, events, 200)
+			owners, err := repos.SensorOwners.GetSensorsByUserID(ctx, int64(w+1))
+			require.NoError(t, err)
+			assert.Len(t, owners, 4, "привязки не должны повториться")
+		}
+	})
+}
+
+func TestStore_SensorTypes(t *testing.T) {
+	ctx := context.Background()
+	door := domain.SensorTypeSpec{Name: "door", Description: "door", SerialPattern: "^1",
+		Channels: []domain.ChannelSpec{{Name: "value", Bits: 1}}, Heartbeat: time.Minute}
+	require.NoError(t, door.CompileSerial())
+	window := domain.SensorTypeSpec{Name: "window"}
+
+	// change registers door and window, deletes window and the builtin adc
+	change := func(t *testing.T, s *Store) {
+		t.Helper()
+		for _, st := range []domain.SensorTypeSpec{door, window} {
+			created, err := s.SensorTypes().SaveSensorType(ctx, st)
+			require.NoError(t, err)
+			assert.True(t, created)
+		}
+		require.NoError(t, s.SensorTypes().DeleteSensorType(ctx, window.Name))
+		require.NoError(t, s.SensorTypes().DeleteSensorType(ctx, domain.SensorTypeADC))
+		assert.ErrorIs(t, s.SensorTypes().DeleteSensorType(ctx, window.Name), usecase.ErrSensorTypeNotFound)
+	}
+	assertChanged := func(t *testing.T, repos Repositories) {
+		t.Helper()
+		got, err := repos.SensorTypes.GetSensorType(ctx, door.Name)
+		require.NoError(t, err)
+		assert.Equal(t, door, *got)
+		_, err = repos.SensorTypes.GetSensorType(ctx, window.Name)
+		assert.ErrorIs(t, err, usecase.ErrSensorTypeNotFound, "удаленный тип не должен восстановиться")
+		_, err = repos.SensorTypes.GetSensorType(ctx, domain.SensorTypeADC)
+		assert.ErrorIs(t, err, usecase.ErrSensorTypeNotFound, "удаленный встроенный тип не должен восстановиться")
+		_, err = repos.SensorTypes.GetSensorType(ctx, domain.SensorTypeContactClosure)
+		assert.NoError(t, err)
+	}
+
+	t.Run("ok, types are replayed", func(t *testing.T) {
+		dir := t.TempDir()
+		s, _ := open(t, dir)
+		change(t, s)
+		require.NoError(t, s.Close())
+
+		s, repos := open(t, dir)
+		defer s.Close()
+		assertChanged(t, repos)
+	})
+
+	t.Run("ok, types are in the snapshot", func(t *testing.T) {
+		dir := t.TempDir()
+		s, _ := open(t, dir)
+		change(t, s)
+		require.NoError(t, s.Snapshot(ctx))
+		require.NoError(t, s.Close())
+
+		s, repos := open(t, dir)
+		defer s.Close()
+		assertChanged(t, repos)
+	})
+}
+
+func TestStore_Changes(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ok, feed and consumers are restored", func(t *testing.T) {
+		dir := t.TempDir()
+		s, _ := open(t, dir)
+		fill(t, s)
+		require.NoError(t, s.Changes().SaveConsumer(ctx, domain.ChangeConsumer{Name: "bridge", Cursor: 4, UpdatedAt: time.Unix(100, 0).UTC()}))
+		before, err := s.Changes().GetChanges(ctx, 0, 100)
+		require.NoError(t, err)
+		require.Len(t, before, 6)
+		require.NoError(t, s.Close())
+
+		s, _ = open(t, dir)
+		defer s.Close()
+		after, err := s.Changes().GetChanges(ctx, 0, 100)
+		require.NoError(t, err)
+		require.Len(t, after, len(before))
+		for i := range before {
+			assert.Equal(t, before[i].Cursor, after[i].Cursor)
+			assert.Equal(t, before[i].Kind, after[i].Kind)
+			assert.True(t, before[i].Time.Equal(after[i].Time), "время изменения %d должно сохраниться", i)
+		}
+		consumer, err := s.Changes().GetConsumer(ctx, "bridge")
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), consumer.Cursor)
+
+		user := domain.User{Name: "next"}
+		require.NoError(t, s.Users().SaveUser(ctx, &user))
+		changes, err := s.Changes().GetChanges(ctx, 6, 100)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, int64(7), changes[0].Cursor, "курсоры должны продолжиться после перезапуска")
+	})
+
+	t.Run("ok, repeated event has no change", func(t *testing.T) {
+		s, _ := open(t, t.TempDir())
+		defer s.Close()
+		event := domain.Event{Timestamp: time.Unix(100, 0), SensorID: 1, Payload: domain.IntPayload(1)}
+		require.NoError(t, s.Events().SaveEvent(ctx, &event))
+		require.NoError(t, s.Events().SaveEvent(ctx, &event))
+
+		changes, err := s.Changes().GetChanges(ctx, 0, 100)
+		require.NoError(t, err)
+		assert.Len(t, changes, 1)
+	})
+
+	t.Run("ok, horizon survives the snapshot", func(t *testing.T) {
+		dir := t.TempDir()
+		s, _ := open(t, dir)
+		fill(t, s)
+		deleted, err := s.Changes().DeleteChanges(ctx, farFuture)
+		require.NoError(t, err)
+		assert.Equal(t, int64(6), deleted)
+		require.NoError(t, s.Snapshot(ctx))
+		require.NoError(t, s.Close())
+
+		s, _ = open(t, dir)
+		defer s.Close()
+		_, err = s.Changes().GetChanges(ctx, 3, 100)
+		assert.ErrorIs(t, err, usecase.ErrChangesExpired)
+
+		user := domain.User{Name: "next"}
+		require.NoError(t, s.Users().SaveUser(ctx, &user))
+		changes, err := s.Changes().GetChanges(ctx, 0, 100)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, int64(7), changes[0].Cursor)
+	})
+
+	t.Run("ok, saves during the snapshots are recorded once", func(t *testing.T) {
+		dir := t.TempDir()
+		s, _ := open(t, dir)
+		start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		var wg sync.WaitGroup
+		for w := range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range 100 {
+					event := &domain.Event{Timestamp: start.Add(time.Duration(i) * time.Second), SensorID: int64(w + 1), Payload: domain.IntPayload(int64(i))}
+					assert.NoError(t, s.Events().SaveEvent(ctx, event))
+				}
+			}()
+		}
+		for range 5 {
+			require.NoError(t, s.Snapshot(ctx))
+		}
+		wg.Wait()
+		require.NoError(t, s.Close())
+
+		s, _ = open(t, dir)
+		defer s.Close()
+		changes, err := s.Changes().GetChanges(ctx, 0, 1000)
+		require.NoError(t, err)
+		require.Len(t, changes, 400, "изменения не должны повториться")
+		for i, c := range changes {
+			assert.Equal(t, int64(i+1), c.Cursor)
+		}
+	})
+}
+
+func TestStore_Damage(t *testing.T) {
+	t.Run("ok, torn tail is truncated", func(t *testing.T) {
+		dir := t.TempDir()
+		s, _ := open(t, dir)
+		sensor, user := fill(t, s)
+		require.NoError(t, s.Close())
+
+		path := filepath.Join(dir, segmentName(1))
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+		require.NoError(t, err)
+		rec := appendRecord(nil, kindUser, []byte(`{"ID":100,"Name":"lost"}`))
+		_, err = f.Write(rec[:len(rec)-3])
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		s, repos := open(t, dir)
+		defer s.Close()
+		assertFilled(t, repos, sensor, user)
+		_, err = repos.Users.GetUserByID(context.Background(), 100)
+		assert.Error(t, err, "неполная запись не должна примениться")
+
+		info2, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, info.Size(), info2.Size(), "сегмент должен быть обрезан до последней полной записи")
+	})
+
+	t.Run("ok, zeros after the last record", func(t *testing.T) {
+		dir := t.TempDir()
+		s, _ := open(t, dir)
+		sensor, user := fill(t, s)
+		require.NoError(t, s.Close())
+
+		f, err := os.OpenFile(filepath.Join(dir, segmentName(1)), os.O_APPEND|os.O_WRONLY, 0)
+		require.NoError(t, err)
+		_, err = f.Write(make([]byte, 100))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		s, repos := open(t, dir)
+		defer s.Close()
+		assertFilled(t, repos, sensor, user)
+	})
+
+	t.Run("fail, corruption in the middle", func(t *testing.T) {
+		dir := t.TempDir()
+		s, _ := open(t, dir)
+		fill(t, s)
+		require.NoError(t, s.Close())
+
+		path := filepath.Join(dir, segmentName(1))
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		// a byte of the first record's data
+		data[len(segmentMagic)+headerSize+2] ^= 0xff
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+
+		_, err = Open(dir, NewRepositories(), Options{})
+		var corrupt *CorruptError
+		require.ErrorAs(t, err, &corrupt)
+		assert.ErrorIs(t, err, ErrCorrupt)
+		assert.Equal(t, int64(len(segmentMagic)), corrupt.Offset)
+
+		reports, err := Inspect(dir, nil)
+		require.NoError(t, err)
+		require.Len(t, reports, 1)
+		assert.Equal(t, StatusCorrupt, reports[0].Status)
+	})
+
+	t.Run("fail, length past the end", func(t *testing.T) {
+		dir := t.TempDir()
+		s, _ := open(t, dir)
+		fill(t, s)
+		require.NoError(t, s.Close())
+
+		path := filepath.Join(dir, segmentName(1))
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		// the length of the first record points past the end of the segment
+		data[len(segmentMagic)] ^= 0x01
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+
+		_, err = Open(dir, NewRepositories(), Options{})
+		var corrupt *CorruptError
+		require.ErrorAs(t, err, &corrupt, "поврежденная длина не должна приниматься за неполную запись")
+		assert.Equal(t, int64(len(segmentMagic)), corrupt.Offset)
+
+		after, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, data, after, "поврежденный сегмент не должен обрезаться")
+	})
+
+	t.Run("fail, torn segment before the last one", func(t *testing.T) {
+		dir := t.TempDir()
+		s, _ := open(t, dir)
+		fill(t, s)
+		require.NoError(t, s.Close())
+		// the second open makes segment 2, so the first one is not the last anymore
+		s, _ = open(t, dir)
+		require.NoError(t, s.Close())
+
+		path := filepath.Join(dir, segmentName(1))
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, data[:len(data)-1], 0o600))
+
+		_, err = Open(dir, NewRepositories(), Options{})
+		assert.ErrorIs(t, err, ErrCorrupt)
+	})
+
+	t.Run("fail, snapshot without its end", func(t *testing.T) {
+		dir := t.TempDir()
+		s, _ := open(t, dir)
+		fill(t, s)
+		require.NoError(t, s.Snapshot(context.Background()))
+		require.NoError(t, s.Close())
+
+		path := filepath.Join(dir, snapshotName(2))
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, data[:len(data)-1], 0o600))
+
+		_, err = Open(dir, NewRepositories(), Options{})
+		assert.ErrorIs(t, err, ErrCorrupt)
+	})
+}
+
+func TestInspect(t *testing.T) {
+	dir := t.TempDir()
+	s, _ := open(t, dir)
+	fill(t, s)
+	require.NoError(t, s.Snapshot(context.Background()))
+	require.NoError(t, s.Users().SaveUser(context.Background(), &domain.User{Name: "after"}))
+	require.NoError(t, s.Close())
+
+	var records []Record
+	reports, err := Inspect(dir, func(_ string, r Record) {
+		records = append(records, r)
+	})
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+
+	assert.True(t, reports[0].Snapshot)
+	assert.Equal(t, map[string]int{"sensor": 1, "user": 1, "sensor_owner": 1, "events": 1, "feed": 1, "feed_horizon": 1},
+		reports[0].Records)
+	assert.Equal(t, StatusOK, reports[0].Status)
+	assert.False(t, reports[1].Snapshot)
+	assert.Equal(t, map[string]int{"changes": 1}, reports[1].Records)
+	assert.Equal(t, StatusOK, reports[1].Status)
+
+	require.Len(t, records, 7)
+	assert.Equal(t, int64(len(snapshotMagic)), records[0].Offset)
+	assert.JSONEq(t, `{"Last":6,"Pruned":0}`, string(records[5].Data))
+	var changes []domain.Change
+	require.NoError(t, json.Unmarshal(records[6].Data, &changes))
+	require.Len(t, changes, 1)
+	assert.Equal(t, int64(7), changes[0].Cursor)
+	assert.Equal(t, &domain.User{ID: 2, Name: "after"}, changes[0].User)
+}
@@ -0,0 +1,15 @@
+package inmemory
+
+import (
+	"homework/internal/repository/conformance"
+	"homework/internal/usecase"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestEventRepository_Conformance(t *testing.T) {
+	suite.Run(t, &conformance.EventSuite{NewRepository: func(*testing.T) usecase.EventRepository {
+		return NewEventRepository()
+	}})
+}
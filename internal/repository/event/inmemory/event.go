@@ -14,92 +14,237 @@ import (
 
 var ErrNilEventPointer = errors.New("nil event is provided")
 
+// shardCount is the number of the shards the sensors are spread over, the ids are sequential,
+// so the remainder spreads them evenly
+const shardCount = 64
+
 type SensorId int64
 
-type EventRepository struct {
-	// maps sensor to all of its event compared by timestamps
-	events map[SensorId]*redblacktree.Tree
+// sensorEvents are the events of one sensor compared by timestamps, the lock is taken only by the writers
+// and the readers of this sensor
+type sensorEvents struct {
+	m    sync.RWMutex
+	tree *redblacktree.Tree
+}
+
+// eventShard maps its sensors to their events, the lock guards only the map
+type eventShard struct {
 	m      sync.RWMutex
+	events map[SensorId]*sensorEvents
 }
 
-func NewEventRepository() *EventRepository {
-	return &EventRepository{events: map[SensorId]*redblacktree.Tree{}, m: sync.RWMutex{}}
+// EventRepository keeps the events per sensor, the events of different sensors are saved without
+// contending for a lock. The events are copied on the way in and out.
+type EventRepository struct {
+	shards [shardCount]eventShard
+	// record, if set, writes the change of a stored event to the feed
+	record func(domain.Change)
+}
+
+func NewEventRepository(options ...func(*EventRepository)) *EventRepository {
+	r := &EventRepository{}
+	for i := range r.shards {
+		r.shards[i].events = map[SensorId]*sensorEvents{}
+	}
+	for _, o := range options {
+		o(r)
+	}
+	return r
+}
+
+// WithEventChanges records every stored event into the change feed under the lock of its sensor.
+// A repeated event is not stored again, so it is not recorded either.
+func WithEventChanges(record func(domain.Change)) func(*EventRepository) {
+	return func(r *EventRepository) {
+		r.record = record
+	}
 }
 
 func (r *EventRepository) SaveEvent(ctx context.Context, event *domain.Event) error {
 	if event == nil {
 		return ErrNilEventPointer
 	}
-	r.m.Lock()
-	defer r.m.Unlock()
+	se := r.sensorEvents(event.SensorID, true)
+	se.m.Lock()
+	defer se.m.Unlock()
+
+	if existing, has := se.tree.Get(event.Timestamp); has {
+		if !existing.(domain.Event).Payload.Equal(event.Payload) {
+			return usecase.ErrEventConflict
+		}
+		return ctx.Err()
+	}
+	se.tree.Put(event.Timestamp, event.Clone())
+	if r.record != nil {
+		r.record(domain.EventChange(*event))
+	}
+
+	return ctx.Err()
+}
+
+// SaveEvents stores the events of every sensor under the lock of the sensor, the ones whose timestamps
+// are taken are skipped
+func (r *EventRepository) SaveEvents(ctx context.Context, events []*domain.Event) error {
+	if slices.Contains(events, nil) {
+		return ErrNilEventPointer
+	}
 
-	tree, has := r.events[SensorId(event.SensorID)]
-	if !has {
-		tree = redblacktree.NewWith(func(a, b interface{}) int {
+	bySensor := map[int64][]*domain.Event{}
+	for _, event := range events {
+		bySensor[event.SensorID] = append(bySensor[event.SensorID], event)
+	}
+	for id, events := range bySensor {
+		se := r.sensorEvents(id, true)
+		se.m.Lock()
+		for _, event := range events {
+			if _, has := se.tree.Get(event.Timestamp); !has {
+				se.tree.Put(event.Timestamp, event.Clone())
+				if r.record != nil {
+					r.record(domain.EventChange(*event))
+				}
+			}
+		}
+		se.m.Unlock()
+	}
+
+	return ctx.Err()
+}
+
+// sensorEvents returns the events of the sensor, nil if it has none. With create they are created instead.
+func (r *EventRepository) sensorEvents(sensorID int64, create bool) *sensorEvents {
+	shard := &r.shards[uint64(sensorID)%shardCount]
+	shard.m.RLock()
+	se, has := shard.events[SensorId(sensorID)]
+	shard.m.RUnlock()
+	if has || !create {
+		return se
+	}
+
+	shard.m.Lock()
+	defer shard.m.Unlock()
+	// another writer may have created them between the locks
+	if se, has = shard.events[SensorId(sensorID)]; !has {
+		se = &sensorEvents{tree: redblacktree.NewWith(func(a, b interface{}) int {
 			s1, _ := a.(time.Time)
 			s2, _ := b.(time.Time)
 			return s1.Compare(s2)
-		})
-		r.events[SensorId(event.SensorID)] = tree
+		})}
+		shard.events[SensorId(sensorID)] = se
 	}
-	tree.Put(event.Timestamp, *event)
+	return se
+}
 
-	return ctx.Err()
+// GetSensorIDs returns the ids of the sensors that have events, in no particular order
+func (r *EventRepository) GetSensorIDs(ctx context.Context) ([]int64, error) {
+	var ids []int64
+	for i := range r.shards {
+		shard := &r.shards[i]
+		shard.m.RLock()
+		for id := range shard.events {
+			ids = append(ids, int64(id))
+		}
+		shard.m.RUnlock()
+	}
+	return ids, ctx.Err()
 }
 
 func (r *EventRepository) GetLastEventBySensorID(ctx context.Context, id int64) (*domain.Event, error) {
-	r.m.RLock()
-	defer r.m.RUnlock()
-
-	tree, has := r.events[SensorId(id)]
-	if !has {
+	se := r.sensorEvents(id, false)
+	if se == nil {
 		if ctx.Err() != nil {
 			return nil, ctx.Err()
 		}
 		return nil, usecase.ErrEventNotFound
 	}
-	it := tree.Iterator()
-	it.Last()
-	v, _ := it.Value().(domain.Event)
+
+	se.m.RLock()
+	node := se.tree.Right()
+	var v domain.Event
+	if node != nil {
+		v = node.Value.(domain.Event).Clone() //nolint // the tree keeps only domain.Event
+	}
+	se.m.RUnlock()
 	return &v, ctx.Err()
 }
 
 func (r *EventRepository) GetHistoryBySensorID(ctx context.Context, id int64, from, to time.Time) ([]*domain.Event, error) {
-	r.m.RLock()
-	defer r.m.RUnlock()
-
-	tree, has := r.events[SensorId(id)]
-	if !has {
+	se := r.sensorEvents(id, false)
+	if se == nil {
 		if ctx.Err() != nil {
 			return nil, ctx.Err()
 		}
 		return nil, usecase.ErrEventNotFound
 	}
 
-	v := tree.Values()
-	comparator := func(e interface{}, t time.Time) int {
-		return e.(domain.Event).Timestamp.Compare(t) //nolint // the tree works with interface{}
-		// and we are sure that the elements have type domain.Event, because we store only them
-	}
-	lb, _ := slices.BinarySearchFunc(v, from, comparator)
-	rb, found := slices.BinarySearchFunc(v, to, comparator)
-
-	// result size, excluding rb-th element
-	resSize := rb - lb
-	// if rb is actually found we should include it in our result
-	if found {
-		resSize++
-	}
+	se.m.RLock()
+	defer se.m.RUnlock()
 
-	if resSize <= 0 {
-		return []*domain.Event{}, nil
+	res := []*domain.Event{}
+	node, found := se.tree.Ceiling(from)
+	if !found {
+		return res, ctx.Err()
 	}
-
-	res := make([]*domain.Event, 0, resSize)
-	for i := lb; i < lb+resSize; i++ {
-		e, _ := v[i].(domain.Event)
+	// the events are walked from the first one in the period, not copied out of the whole tree
+	for it := se.tree.IteratorAt(node); ; {
+		e := it.Value().(domain.Event) //nolint // the tree keeps only domain.Event
+		if e.Timestamp.After(to) {
+			break
+		}
+		e = e.Clone()
 		res = append(res, &e)
+		if !it.Next() {
+			break
+		}
 	}
 
 	return res, ctx.Err()
 }
+
+// streamBatch is how many events are copied out under the lock of the sensor before they are passed on
+const streamBatch = 256
+
+// StreamHistoryBySensorID passes the events to fn in batches, the lock of the sensor is not held while fn runs,
+// so a slow reader, e.g. an export, doesn't hold up the events of the sensor
+func (r *EventRepository) StreamHistoryBySensorID(ctx context.Context, id int64, from, to time.Time, fn func(*domain.Event) error) error {
+	se := r.sensorEvents(id, false)
+	if se == nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return usecase.ErrEventNotFound
+	}
+
+	batch := make([]*domain.Event, 0, streamBatch)
+	for next := from; ; {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		batch = batch[:0]
+		se.m.RLock()
+		if node, found := se.tree.Ceiling(next); found {
+			for it := se.tree.IteratorAt(node); len(batch) < streamBatch; {
+				e := it.Value().(domain.Event) //nolint // the tree keeps only domain.Event
+				if e.Timestamp.After(to) {
+					break
+				}
+				e = e.Clone()
+				batch = append(batch, &e)
+				if !it.Next() {
+					break
+				}
+			}
+		}
+		se.m.RUnlock()
+
+		for _, e := range batch {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+		if len(batch) < streamBatch {
+			return ctx.Err()
+		}
+		// the timestamps of a sensor are unique, the next batch starts right after the last one
+		next = batch[len(batch)-1].Timestamp.Add(time.Nanosecond)
+	}
+}
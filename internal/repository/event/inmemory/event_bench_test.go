@@ -0,0 +1,128 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/emirpasic/gods/trees/redblacktree"
+)
+
+// globalLockRepository is the design the repository had before the sharding: one lock over all the sensors.
+// It is kept to compare with.
+type globalLockRepository struct {
+	events map[SensorId]*redblacktree.Tree
+	m      sync.RWMutex
+}
+
+func (r *globalLockRepository) SaveEvent(ctx context.Context, event *domain.Event) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	tree, has := r.events[SensorId(event.SensorID)]
+	if !has {
+		tree = redblacktree.NewWith(func(a, b interface{}) int {
+			return a.(time.Time).Compare(b.(time.Time))
+		})
+		r.events[SensorId(event.SensorID)] = tree
+	}
+	if _, has := tree.Get(event.Timestamp); !has {
+		tree.Put(event.Timestamp, *event)
+	}
+	return ctx.Err()
+}
+
+func (r *globalLockRepository) GetLastEventBySensorID(ctx context.Context, id int64) (*domain.Event, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	tree, has := r.events[SensorId(id)]
+	if !has {
+		return nil, usecase.ErrEventNotFound
+	}
+	v, _ := tree.Right().Value.(domain.Event)
+	return &v, ctx.Err()
+}
+
+type eventStore interface {
+	SaveEvent(ctx context.Context, event *domain.Event) error
+	GetLastEventBySensorID(ctx context.Context, id int64) (*domain.Event, error)
+}
+
+var eventStores = []struct {
+	name string
+	new  func() eventStore
+}{
+	{"global_lock", func() eventStore { return &globalLockRepository{events: map[SensorId]*redblacktree.Tree{}} }},
+	{"sharded", func() eventStore { return NewEventRepository() }},
+}
+
+// BenchmarkSaveEventParallel saves the events of many sensors from all the procs, as the ingestion does
+func BenchmarkSaveEventParallel(b *testing.B) {
+	for _, sensors := range []int{16, 1024} {
+		for _, store := range eventStores {
+			b.Run(fmt.Sprintf("%s/sensors=%d", store.name, sensors), func(b *testing.B) {
+				r := store.new()
+				ctx := context.Background()
+				start := time.Now()
+				var seq atomic.Int64
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						n := seq.Add(1)
+						event := &domain.Event{
+							Timestamp: start.Add(time.Duration(n)),
+							SensorID:  n%int64(sensors) + 1,
+							Payload:   domain.IntPayload(n),
+						}
+						if err := r.SaveEvent(ctx, event); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+			})
+		}
+	}
+}
+
+// BenchmarkSaveAndPollParallel mixes the ingestion with the polls of the last events the websockets make
+func BenchmarkSaveAndPollParallel(b *testing.B) {
+	const sensors = 1024
+	for _, store := range eventStores {
+		b.Run(store.name, func(b *testing.B) {
+			r := store.new()
+			ctx := context.Background()
+			start := time.Now()
+			for id := int64(1); id <= sensors; id++ {
+				_ = r.SaveEvent(ctx, &domain.Event{Timestamp: start, SensorID: id, Payload: domain.IntPayload(0)})
+			}
+			var seq atomic.Int64
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					n := seq.Add(1)
+					id := n%sensors + 1
+					if n%4 == 0 {
+						if _, err := r.GetLastEventBySensorID(ctx, id); err != nil {
+							b.Fatal(err)
+						}
+						continue
+					}
+					event := &domain.Event{Timestamp: start.Add(time.Duration(n)), SensorID: id, Payload: domain.IntPayload(n)}
+					if err := r.SaveEvent(ctx, event); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
@@ -45,7 +45,7 @@ func TestEventRepository_SaveEvent(t *testing.T) {
 		event := &domain.Event{
 			Timestamp:          time.Now(),
 			SensorSerialNumber: "12345",
-			Payload:            0,
+			Payload:            domain.IntPayload(0),
 		}
 
 		err := er.SaveEvent(ctx, event)
@@ -70,7 +70,7 @@ func TestEventRepository_SaveEvent(t *testing.T) {
 			event := &domain.Event{
 				Timestamp:          time.Now(),
 				SensorSerialNumber: "12345",
-				Payload:            0,
+				Payload:            domain.IntPayload(0),
 			}
 			lastEvent = *event
 			wg.Add(1)
@@ -89,6 +89,104 @@ func TestEventRepository_SaveEvent(t *testing.T) {
 		assert.Equal(t, lastEvent.SensorSerialNumber, actualEvent.SensorSerialNumber)
 		assert.Equal(t, lastEvent.Payload, actualEvent.Payload)
 	})
+
+	t.Run("ok, same event again", func(t *testing.T) {
+		er := NewEventRepository()
+		event := &domain.Event{Timestamp: time.Now(), SensorID: 1, Payload: domain.IntPayload(10)}
+
+		assert.NoError(t, er.SaveEvent(context.Background(), event))
+		assert.NoError(t, er.SaveEvent(context.Background(), event), "Повтор того же события не ошибка")
+	})
+
+	t.Run("err, another event at the same time", func(t *testing.T) {
+		er := NewEventRepository()
+		now := time.Now()
+
+		assert.NoError(t, er.SaveEvent(context.Background(), &domain.Event{Timestamp: now, SensorID: 1, Payload: domain.IntPayload(10)}))
+		err := er.SaveEvent(context.Background(), &domain.Event{Timestamp: now, SensorID: 1, Payload: domain.IntPayload(20)})
+		assert.ErrorIs(t, err, usecase.ErrEventConflict)
+
+		last, err := er.GetLastEventBySensorID(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, domain.IntPayload(10), last.Payload, "Первое событие не должно перезаписываться")
+	})
+
+	t.Run("ok, stored event is not shared with caller", func(t *testing.T) {
+		er := NewEventRepository()
+		now := time.Now()
+		event := &domain.Event{Timestamp: now, SensorID: 1, Payload: domain.IntPayload(10)}
+
+		assert.NoError(t, er.SaveEvent(context.Background(), event))
+		event.Payload[0].Value = domain.Decimal{Units: 20}
+
+		last, err := er.GetLastEventBySensorID(context.Background(), 1)
+		assert.NoError(t, err)
+		last.Payload[0].Value = domain.Decimal{Units: 30}
+
+		history, err := er.GetHistoryBySensorID(context.Background(), 1, now, now)
+		assert.NoError(t, err)
+		assert.Len(t, history, 1)
+		assert.Equal(t, domain.IntPayload(10), history[0].Payload, "Сохраненное событие не должно меняться снаружи")
+	})
+}
+
+func TestEventRepository_SaveEvents(t *testing.T) {
+	t.Run("err, event is nil", func(t *testing.T) {
+		er := NewEventRepository()
+		err := er.SaveEvents(context.Background(), []*domain.Event{{}, nil})
+		assert.ErrorIs(t, err, ErrNilEventPointer)
+	})
+
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		er := NewEventRepository()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := er.SaveEvents(ctx, []*domain.Event{{}})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("ok, save and get history", func(t *testing.T) {
+		er := NewEventRepository()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		now := time.Now()
+		events := []*domain.Event{
+			{Timestamp: now, SensorID: 1, Payload: domain.IntPayload(1)},
+			{Timestamp: now.Add(time.Second), SensorID: 1, Payload: domain.IntPayload(2)},
+			{Timestamp: now, SensorID: 2, Payload: domain.IntPayload(3)},
+		}
+		assert.NoError(t, er.SaveEvents(ctx, events))
+
+		history, err := er.GetHistoryBySensorID(ctx, 1, now, now.Add(time.Second))
+		assert.NoError(t, err)
+		assert.Equal(t, events[:2], history)
+
+		last, err := er.GetLastEventBySensorID(ctx, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, events[2], last)
+	})
+
+	t.Run("ok, taken timestamps are skipped", func(t *testing.T) {
+		er := NewEventRepository()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		now := time.Now()
+		assert.NoError(t, er.SaveEvent(ctx, &domain.Event{Timestamp: now, SensorID: 1, Payload: domain.IntPayload(1)}))
+		assert.NoError(t, er.SaveEvents(ctx, []*domain.Event{
+			{Timestamp: now, SensorID: 1, Payload: domain.IntPayload(2)},
+			{Timestamp: now.Add(time.Second), SensorID: 1, Payload: domain.IntPayload(3)},
+		}))
+
+		history, err := er.GetHistoryBySensorID(ctx, 1, now, now.Add(time.Second))
+		assert.NoError(t, err)
+		assert.Equal(t, []*domain.Event{
+			{Timestamp: now, SensorID: 1, Payload: domain.IntPayload(1)},
+			{Timestamp: now.Add(time.Second), SensorID: 1, Payload: domain.IntPayload(3)},
+		}, history)
+	})
 }
 
 func TestEventRepository_GetLastEventBySensorID(t *testing.T) {
@@ -127,7 +225,7 @@ func TestEventRepository_GetLastEventBySensorID(t *testing.T) {
 			lastEvent = &domain.Event{
 				Timestamp: time.Now(),
 				SensorID:  sensorID,
-				Payload:   0,
+				Payload:   domain.IntPayload(0),
 			}
 			time.Sleep(10 * time.Millisecond)
 			assert.NoError(t, er.SaveEvent(ctx, lastEvent))
@@ -137,7 +235,7 @@ func TestEventRepository_GetLastEventBySensorID(t *testing.T) {
 			event := &domain.Event{
 				Timestamp: time.Now(),
 				SensorID:  54321,
-				Payload:   0,
+				Payload:   domain.IntPayload(0),
 			}
 			assert.NoError(t, er.SaveEvent(ctx, event))
 		}
@@ -279,7 +377,7 @@ func TestEventRepository_GetHistoryBySensorID(t *testing.T) {
 				event := &domain.Event{
 					Timestamp: time.Now(),
 					SensorID:  12345,
-					Payload:   int64(i),
+					Payload:   domain.IntPayload(int64(i)),
 				}
 				originalEvents[i] = event
 				time.Sleep(10 * time.Millisecond)
@@ -313,7 +411,7 @@ func FuzzEventRepository_GetHistoryBySensorID(f *testing.F) {
 		event := &domain.Event{
 			Timestamp: time.Unix(rand.Int64(), 0),
 			SensorID:  12345,
-			Payload:   int64(i),
+			Payload:   domain.IntPayload(int64(i)),
 		}
 		originalEvents[i] = event
 		_ = er.SaveEvent(context.Background(), event)
@@ -337,3 +435,36 @@ func FuzzEventRepository_GetHistoryBySensorID(f *testing.F) {
 		}
 	})
 }
+
+func TestEventRepository_StreamHistoryBySensorID(t *testing.T) {
+	t.Run("fail, event not found", func(t *testing.T) {
+		er := NewEventRepository()
+		err := er.StreamHistoryBySensorID(context.Background(), 1, time.Time{}, time.Now(), func(*domain.Event) error {
+			t.Fatal("Событий нет, обработчик не должен вызываться")
+			return nil
+		})
+		assert.ErrorIs(t, err, usecase.ErrEventNotFound, "Как и история, обход датчика без событий - ошибка")
+	})
+
+	t.Run("ok, events of several batches", func(t *testing.T) {
+		er := NewEventRepository()
+		base := time.Now()
+		var saved []*domain.Event
+		for i := range 2*streamBatch + 10 {
+			event := &domain.Event{Timestamp: base.Add(time.Duration(i) * time.Nanosecond), SensorID: 1, Payload: domain.IntPayload(int64(i))}
+			assert.NoError(t, er.SaveEvent(context.Background(), event))
+			saved = append(saved, event)
+		}
+
+		var streamed []*domain.Event
+		err := er.StreamHistoryBySensorID(context.Background(), 1, saved[1].Timestamp, saved[len(saved)-2].Timestamp,
+			func(e *domain.Event) error {
+				// the sensor is not locked while the events are passed on
+				assert.NoError(t, er.SaveEvent(context.Background(), &domain.Event{Timestamp: base.Add(-time.Hour), SensorID: 1}))
+				streamed = append(streamed, e)
+				return nil
+			})
+		assert.NoError(t, err)
+		assert.Equal(t, saved[1:len(saved)-1], streamed, "Все события периода должны пройти по одному разу и по порядку")
+	})
+}
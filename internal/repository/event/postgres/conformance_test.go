@@ -0,0 +1,23 @@
+package postgres
+
+import (
+	"context"
+	"homework/internal/repository/conformance"
+	"homework/internal/usecase"
+	"homework/pkg/pg_test"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestEventRepository_Conformance(t *testing.T) {
+	testDB := pg_test.SetupTestDatabase()
+	defer testDB.TearDown()
+
+	suite.Run(t, &conformance.EventSuite{NewRepository: func(t *testing.T) usecase.EventRepository {
+		_, err := testDB.DbInstance.Exec(context.Background(), `truncate db.public.events`)
+		require.NoError(t, err)
+		return NewEventRepository(testDB.DbInstance)
+	}})
+}
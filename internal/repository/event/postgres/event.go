@@ -2,14 +2,18 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"homework/internal/domain"
 	"homework/internal/usecase"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	changePostgres "homework/internal/repository/change/postgres"
 )
 
 var ErrEventNotFound = errors.New("event not found")
@@ -24,28 +28,128 @@ func NewEventRepository(pool *pgxpool.Pool) *EventRepository {
 	}
 }
 
-const saveEventQuery = `insert into db.public.events (timestamp, sensor_serial_number, sensor_id, payload)
-	values ($1, $2, $3, $4);`
+const saveEventQuery = `insert into db.public.events (timestamp, sensor_serial_number, sensor_id, payload, readings, raw_readings)
+	values ($1, $2, $3, $4, $5, $6)
+	on conflict (sensor_id, timestamp) do nothing;`
+
+const getPayloadQuery = `select payload, readings from db.public.events where sensor_id=$1 and timestamp=$2`
 
+// SaveEvent stores the event and records it into the change feed in the same transaction,
+// the event sent again is neither stored nor recorded
 func (r *EventRepository) SaveEvent(ctx context.Context, event *domain.Event) error {
-	_, err := r.pool.Exec(ctx, saveEventQuery, event.Timestamp, event.SensorSerialNumber, event.SensorID, event.Payload)
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	tag, err := tx.Exec(ctx, saveEventQuery, event.Timestamp, event.SensorSerialNumber, event.SensorID, event.Payload.Int(), event.Payload, rawReadings(event))
 	if err != nil {
 		return err
 	}
+	if tag.RowsAffected() > 0 {
+		if err := changePostgres.Record(ctx, tx, domain.EventChange(*event)); err != nil {
+			return err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("can't commit event: %w", err)
+		}
+		return ctx.Err()
+	}
+
+	// the timestamp is taken, it is fine if it is the same event sent again
+	var payload int64
+	var readings []byte
+	if err := tx.QueryRow(ctx, getPayloadQuery, event.SensorID, event.Timestamp).Scan(&payload, &readings); err != nil {
+		return fmt.Errorf("can't scan payload: %w", err)
+	}
+	saved, err := decodePayload(payload, readings)
+	if err != nil {
+		return err
+	}
+	if !saved.Equal(event.Payload) {
+		return usecase.ErrEventConflict
+	}
+	return ctx.Err()
+}
+
+var eventColumns = []string{"timestamp", "sensor_serial_number", "sensor_id", "payload", "readings", "raw_readings"}
+
+// rawReadings keeps the readings of the uncalibrated events NULL, they are the same as the calibrated ones
+func rawReadings(e *domain.Event) any {
+	if len(e.Raw) == 0 {
+		return nil
+	}
+	return e.Raw
+}
+
+const (
+	createEventsBatchQuery = `create temporary table events_batch (like db.public.events) on commit drop;`
+	saveEventsBatchQuery   = `insert into db.public.events select * from events_batch on conflict (sensor_id, timestamp) do nothing
+returning timestamp, sensor_serial_number, sensor_id, payload, readings, raw_readings;`
+)
+
+// SaveEvents stores the events with a single COPY. The COPY goes to a temporary table first,
+// as it can't skip the events whose timestamps are taken. The stored ones are recorded into the change feed.
+func (r *EventRepository) SaveEvents(ctx context.Context, events []*domain.Event) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := tx.Exec(ctx, createEventsBatchQuery); err != nil {
+		return fmt.Errorf("can't create events batch: %w", err)
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"events_batch"}, eventColumns,
+		pgx.CopyFromSlice(len(events), func(i int) ([]any, error) {
+			e := events[i]
+			return []any{e.Timestamp, e.SensorSerialNumber, e.SensorID, e.Payload.Int(), e.Payload, rawReadings(e)}, nil
+		}))
+	if err != nil {
+		return fmt.Errorf("can't copy events: %w", err)
+	}
+	rows, err := tx.Query(ctx, saveEventsBatchQuery)
+	if err != nil {
+		return fmt.Errorf("can't save events batch: %w", err)
+	}
+	changes := make([]domain.Change, 0, len(events))
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("can't scan event: %w", err)
+		}
+		changes = append(changes, domain.EventChange(*event))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("can't save events batch: %w", err)
+	}
+	if err := changePostgres.Record(ctx, tx, changes...); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("can't commit events: %w", err)
+	}
 	return ctx.Err()
 }
 
 const getLastEventBySensorIDQuery = `
-select *
-from db.public.events 
+select timestamp, sensor_serial_number, sensor_id, payload, readings, raw_readings
+from db.public.events
 where sensor_id=$1
 order by timestamp desc;`
 
 func (r *EventRepository) GetLastEventBySensorID(ctx context.Context, id int64) (*domain.Event, error) {
 	row := r.pool.QueryRow(ctx, getLastEventBySensorIDQuery, id)
 
-	event := &domain.Event{}
-	if err := row.Scan(&event.Timestamp, &event.SensorSerialNumber, &event.SensorID, &event.Payload); err != nil {
+	event, err := scanEvent(row)
+	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, usecase.ErrEventNotFound
 		}
@@ -54,3 +158,76 @@ func (r *EventRepository) GetLastEventBySensorID(ctx context.Context, id int64)
 
 	return event, ctx.Err()
 }
+
+const getHistoryBySensorIDQuery = `
+select timestamp, sensor_serial_number, sensor_id, payload, readings, raw_readings
+from db.public.events
+where sensor_id=$1 and timestamp between $2 and $3
+order by timestamp;`
+
+func (r *EventRepository) GetHistoryBySensorID(ctx context.Context, id int64, from, to time.Time) ([]*domain.Event, error) {
+	events := make([]*domain.Event, 0)
+	err := r.StreamHistoryBySensorID(ctx, id, from, to, func(event *domain.Event) error {
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *EventRepository) StreamHistoryBySensorID(ctx context.Context, id int64, from, to time.Time, fn func(*domain.Event) error) error {
+	rows, err := r.pool.Query(ctx, getHistoryBySensorIDQuery, id, from, to)
+	if err != nil {
+		return fmt.Errorf("can't select history of sensor %d: %w", id, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return fmt.Errorf("can't scan event: %w", err)
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("can't read history of sensor %d: %w", id, err)
+	}
+
+	return ctx.Err()
+}
+
+func scanEvent(row pgx.Row) (*domain.Event, error) {
+	event := &domain.Event{}
+	var payload int64
+	var readings, raw []byte
+	if err := row.Scan(&event.Timestamp, &event.SensorSerialNumber, &event.SensorID, &payload, &readings, &raw); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if event.Payload, err = decodePayload(payload, readings); err != nil {
+		return nil, err
+	}
+	if raw != nil {
+		if err := json.Unmarshal(raw, &event.Raw); err != nil {
+			return nil, fmt.Errorf("can't decode raw readings: %w", err)
+		}
+	}
+	return event, nil
+}
+
+// decodePayload reads the readings of the event, the events saved before them have only the integer payload
+func decodePayload(payload int64, readings []byte) (domain.Payload, error) {
+	if readings == nil {
+		return domain.IntPayload(payload), nil
+	}
+	var p domain.Payload
+	if err := json.Unmarshal(readings, &p); err != nil {
+		return nil, fmt.Errorf("can't decode readings: %w", err)
+	}
+	return p, nil
+}
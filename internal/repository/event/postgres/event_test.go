@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"homework/internal/domain"
+	"homework/internal/usecase"
 	"homework/pkg/pg_test"
 	"testing"
 	"time"
@@ -38,12 +39,86 @@ func (suite *EventTestSuite) TestEventRepository_SaveEvent() {
 		Timestamp:          time.Now().In(time.UTC),
 		SensorSerialNumber: "1234567890",
 		SensorID:           1,
-		Payload:            1,
+		Payload:            domain.IntPayload(1),
 	})
 
 	assert.Nil(suite.T(), err)
 }
 
+func (suite *EventTestSuite) TestEventRepository_SaveEvent_Conflict() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	event := domain.Event{
+		Timestamp:          time.Now().Truncate(time.Microsecond).In(time.UTC),
+		SensorSerialNumber: "1111111111",
+		SensorID:           11,
+		Payload:            domain.IntPayload(1),
+	}
+	assert.Nil(suite.T(), suite.repo.SaveEvent(ctx, &event))
+	assert.Nil(suite.T(), suite.repo.SaveEvent(ctx, &event), "Повтор того же события не ошибка")
+
+	conflicting := event
+	conflicting.Payload = domain.IntPayload(2)
+	assert.ErrorIs(suite.T(), suite.repo.SaveEvent(ctx, &conflicting), usecase.ErrEventConflict)
+
+	assert.Nil(suite.T(), suite.repo.SaveEvents(ctx, []*domain.Event{&conflicting}), "Пачка пропускает занятое время")
+	last, err := suite.repo.GetLastEventBySensorID(ctx, 11)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), event, *last)
+}
+
+func (suite *EventTestSuite) TestEventRepository_Readings() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	event := domain.Event{
+		Timestamp:          time.Now().Truncate(time.Microsecond).In(time.UTC),
+		SensorSerialNumber: "1212121212",
+		SensorID:           12,
+		Payload: domain.Payload{
+			{Channel: "temperature", Value: domain.Decimal{Units: 215, Scale: 1}, Unit: "°C"},
+			{Channel: "humidity", Value: domain.Decimal{Units: 40}, Unit: "%"},
+		},
+	}
+	assert.Nil(suite.T(), suite.repo.SaveEvent(ctx, &event))
+	assert.Nil(suite.T(), suite.repo.SaveEvent(ctx, &event), "Повтор того же события не ошибка")
+
+	last, err := suite.repo.GetLastEventBySensorID(ctx, 12)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), event, *last)
+
+	// the events saved before the readings have the integer payload only
+	_, err = suite.testDbInstance.Exec(ctx, `update db.public.events set readings = null, payload = 7 where sensor_id = 12`)
+	assert.Nil(suite.T(), err)
+	last, err = suite.repo.GetLastEventBySensorID(ctx, 12)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), domain.IntPayload(7), last.Payload)
+}
+
+func (suite *EventTestSuite) TestEventRepository_RawReadings() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	events := []*domain.Event{
+		{
+			Timestamp:          now,
+			SensorSerialNumber: "1313131313",
+			SensorID:           13,
+			Payload:            domain.Payload{{Channel: "value", Value: domain.Decimal{Units: 974}, Unit: "°C"}},
+			Raw:                domain.IntPayload(2048),
+		},
+		{Timestamp: now.Add(time.Minute), SensorSerialNumber: "1313131313", SensorID: 13, Payload: domain.IntPayload(1)},
+	}
+	assert.Nil(suite.T(), suite.repo.SaveEvent(ctx, events[0]))
+	assert.Nil(suite.T(), suite.repo.SaveEvents(ctx, events[1:]))
+
+	history, err := suite.repo.GetHistoryBySensorID(ctx, 13, now, now.Add(time.Minute))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), events, history, "Сырые показания есть только у откалиброванного события")
+}
+
 func (suite *EventTestSuite) TestEventRepository_GetLastEventBySensorID() {
 	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second) //nolint: govet // test stub
 
@@ -51,14 +126,14 @@ func (suite *EventTestSuite) TestEventRepository_GetLastEventBySensorID() {
 		Timestamp:          time.Now().Truncate(time.Microsecond).In(time.UTC),
 		SensorSerialNumber: "0987654321",
 		SensorID:           2,
-		Payload:            1,
+		Payload:            domain.IntPayload(1),
 	}
 
 	secondEvent := domain.Event{
 		Timestamp:          time.Now().Truncate(time.Microsecond).Add(time.Minute * 10).In(time.UTC),
 		SensorSerialNumber: "0987654321",
 		SensorID:           2,
-		Payload:            2,
+		Payload:            domain.IntPayload(2),
 	}
 
 	err := suite.repo.SaveEvent(ctx, &firstEvent)
@@ -73,6 +148,74 @@ func (suite *EventTestSuite) TestEventRepository_GetLastEventBySensorID() {
 	assert.Equal(suite.T(), secondEvent, *event)
 }
 
+func (suite *EventTestSuite) TestEventRepository_GetHistoryBySensorID() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	events := make([]domain.Event, 0, 5)
+	for i := 0; i < 5; i++ {
+		event := domain.Event{
+			Timestamp:          now.Add(time.Duration(i) * time.Minute),
+			SensorSerialNumber: "1111111111",
+			SensorID:           3,
+			Payload:            domain.IntPayload(int64(i)),
+		}
+		assert.Nil(suite.T(), suite.repo.SaveEvent(ctx, &event))
+		events = append(events, event)
+	}
+
+	history, err := suite.repo.GetHistoryBySensorID(ctx, 3, events[1].Timestamp, events[3].Timestamp)
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), history, 3)
+	for i, event := range history {
+		assert.Equal(suite.T(), events[i+1], *event)
+	}
+
+	history, err = suite.repo.GetHistoryBySensorID(ctx, 3, now.Add(time.Hour), now.Add(2*time.Hour))
+	assert.Nil(suite.T(), err)
+	assert.Empty(suite.T(), history)
+}
+
+func (suite *EventTestSuite) TestEventRepository_StreamHistoryBySensorID() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	for i := 0; i < 3; i++ {
+		assert.Nil(suite.T(), suite.repo.SaveEvent(ctx, &domain.Event{
+			Timestamp:          now.Add(time.Duration(i) * time.Minute),
+			SensorSerialNumber: "2222222222",
+			SensorID:           4,
+			Payload:            domain.IntPayload(int64(i)),
+		}))
+	}
+
+	var payloads []int64
+	err := suite.repo.StreamHistoryBySensorID(ctx, 4, now, now.Add(time.Hour), func(event *domain.Event) error {
+		payloads = append(payloads, event.Payload.Int())
+		return nil
+	})
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []int64{0, 1, 2}, payloads)
+}
+
+func (suite *EventTestSuite) TestEventRepository_SaveEvents() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	events := []*domain.Event{
+		{Timestamp: now, SensorSerialNumber: "3333333333", SensorID: 5, Payload: domain.IntPayload(1)},
+		{Timestamp: now.Add(time.Minute), SensorSerialNumber: "3333333333", SensorID: 5, Payload: domain.IntPayload(2)},
+	}
+	assert.Nil(suite.T(), suite.repo.SaveEvents(ctx, events))
+
+	history, err := suite.repo.GetHistoryBySensorID(ctx, 5, now, now.Add(time.Minute))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), events, history)
+}
+
 func TestEventTestSuite(t *testing.T) {
 	suite.Run(t, new(EventTestSuite))
 }
@@ -0,0 +1,23 @@
+package sqlite
+
+import (
+	"context"
+	"homework/internal/repository/conformance"
+	"homework/internal/usecase"
+	"path/filepath"
+	"testing"
+
+	schemaSqlite "homework/internal/repository/schema/sqlite"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestEventRepository_Conformance(t *testing.T) {
+	suite.Run(t, &conformance.EventSuite{NewRepository: func(t *testing.T) usecase.EventRepository {
+		db, err := schemaSqlite.Open(context.Background(), filepath.Join(t.TempDir(), "test.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		return NewEventRepository(db)
+	}})
+}
@@ -0,0 +1,268 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"math"
+	"slices"
+	"time"
+
+	changeSqlite "homework/internal/repository/change/sqlite"
+)
+
+var ErrNilEventPointer = errors.New("nil event is provided")
+
+type EventRepository struct {
+	db *sql.DB
+}
+
+func NewEventRepository(db *sql.DB) *EventRepository {
+	return &EventRepository{
+		db: db,
+	}
+}
+
+var (
+	minTime = time.Unix(0, math.MinInt64)
+	maxTime = time.Unix(0, math.MaxInt64)
+)
+
+// encodeTime keeps the time as unix nanoseconds, the times out of their range are clamped,
+// so the open bounds of a period like the zero time still work
+func encodeTime(t time.Time) int64 {
+	switch {
+	case t.Before(minTime):
+		return math.MinInt64
+	case t.After(maxTime):
+		return math.MaxInt64
+	}
+	return t.UnixNano()
+}
+
+func encodeReadings(p domain.Payload) (any, error) {
+	if len(p) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(p)
+	return string(data), err
+}
+
+const saveEventQuery = `insert into events (timestamp, sensor_serial_number, sensor_id, payload, readings, raw_readings)
+	values (?, ?, ?, ?, ?, ?)
+	on conflict (sensor_id, timestamp) do nothing`
+
+const getPayloadQuery = `select payload, readings from events where sensor_id = ? and timestamp = ?`
+
+// SaveEvent stores the event and records it into the change feed in the same transaction.
+// A repeated event is not stored again, so it is not recorded either.
+func (r *EventRepository) SaveEvent(ctx context.Context, event *domain.Event) error {
+	if event == nil {
+		return ErrNilEventPointer
+	}
+	readings, raw, err := encodeEvent(event)
+	if err != nil {
+		return err
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	res, err := tx.ExecContext(ctx, saveEventQuery, encodeTime(event.Timestamp), event.SensorSerialNumber, event.SensorID,
+		event.Payload.Int(), readings, raw)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		if err := changeSqlite.Record(ctx, tx, domain.EventChange(*event)); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("can't commit event: %w", err)
+		}
+		return ctx.Err()
+	}
+
+	// the timestamp is taken, it is fine if it is the same event sent again
+	var payload int64
+	var saved sql.NullString
+	if err := tx.QueryRowContext(ctx, getPayloadQuery, event.SensorID, encodeTime(event.Timestamp)).Scan(&payload, &saved); err != nil {
+		return fmt.Errorf("can't scan payload: %w", err)
+	}
+	p, err := decodePayload(payload, saved)
+	if err != nil {
+		return err
+	}
+	if !p.Equal(event.Payload) {
+		return usecase.ErrEventConflict
+	}
+	return ctx.Err()
+}
+
+// encodeEvent makes the readings columns, the readings of the uncalibrated events are kept NULL,
+// they are the same as the calibrated ones
+func encodeEvent(e *domain.Event) (readings, raw any, err error) {
+	if readings, err = encodeReadings(e.Payload); err != nil {
+		return nil, nil, fmt.Errorf("can't encode readings: %w", err)
+	}
+	if raw, err = encodeReadings(e.Raw); err != nil {
+		return nil, nil, fmt.Errorf("can't encode raw readings: %w", err)
+	}
+	return readings, raw, nil
+}
+
+// SaveEvents stores the events in one transaction, a single fsync for the whole batch.
+// The stored ones are recorded into the change feed in the same transaction.
+func (r *EventRepository) SaveEvents(ctx context.Context, events []*domain.Event) error {
+	if slices.Contains(events, nil) {
+		return ErrNilEventPointer
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	stmt, err := tx.PrepareContext(ctx, saveEventQuery)
+	if err != nil {
+		return fmt.Errorf("can't prepare events: %w", err)
+	}
+	defer stmt.Close()
+	changes := make([]domain.Change, 0, len(events))
+	for _, e := range events {
+		readings, raw, err := encodeEvent(e)
+		if err != nil {
+			return err
+		}
+		res, err := stmt.ExecContext(ctx, encodeTime(e.Timestamp), e.SensorSerialNumber, e.SensorID, e.Payload.Int(), readings, raw)
+		if err != nil {
+			return fmt.Errorf("can't save event: %w", err)
+		}
+		// the events whose timestamps are taken are skipped
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n > 0 {
+			changes = append(changes, domain.EventChange(*e))
+		}
+	}
+	if err := changeSqlite.Record(ctx, tx, changes...); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("can't commit events: %w", err)
+	}
+	return ctx.Err()
+}
+
+const getLastEventBySensorIDQuery = `
+select timestamp, sensor_serial_number, sensor_id, payload, readings, raw_readings
+from events
+where sensor_id = ?
+order by timestamp desc
+limit 1`
+
+func (r *EventRepository) GetLastEventBySensorID(ctx context.Context, id int64) (*domain.Event, error) {
+	row := r.db.QueryRowContext(ctx, getLastEventBySensorIDQuery, id)
+
+	event, err := scanEvent(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, usecase.ErrEventNotFound
+		}
+		return nil, fmt.Errorf("can't scan event: %w", err)
+	}
+
+	return event, ctx.Err()
+}
+
+const getHistoryBySensorIDQuery = `
+select timestamp, sensor_serial_number, sensor_id, payload, readings, raw_readings
+from events
+where sensor_id = ? and timestamp between ? and ?
+order by timestamp`
+
+func (r *EventRepository) GetHistoryBySensorID(ctx context.Context, id int64, from, to time.Time) ([]*domain.Event, error) {
+	events := make([]*domain.Event, 0)
+	err := r.StreamHistoryBySensorID(ctx, id, from, to, func(event *domain.Event) error {
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *EventRepository) StreamHistoryBySensorID(ctx context.Context, id int64, from, to time.Time, fn func(*domain.Event) error) error {
+	rows, err := r.db.QueryContext(ctx, getHistoryBySensorIDQuery, id, encodeTime(from), encodeTime(to))
+	if err != nil {
+		return fmt.Errorf("can't select history of sensor %d: %w", id, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return fmt.Errorf("can't scan event: %w", err)
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("can't read history of sensor %d: %w", id, err)
+	}
+
+	return ctx.Err()
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEvent(row scanner) (*domain.Event, error) {
+	event := &domain.Event{}
+	var timestamp, payload int64
+	var readings, raw sql.NullString
+	if err := row.Scan(&timestamp, &event.SensorSerialNumber, &event.SensorID, &payload, &readings, &raw); err != nil {
+		return nil, err
+	}
+	event.Timestamp = time.Unix(0, timestamp).UTC()
+
+	var err error
+	if event.Payload, err = decodePayload(payload, readings); err != nil {
+		return nil, err
+	}
+	if raw.Valid {
+		if err := json.Unmarshal([]byte(raw.String), &event.Raw); err != nil {
+			return nil, fmt.Errorf("can't decode raw readings: %w", err)
+		}
+	}
+	return event, nil
+}
+
+// decodePayload reads the readings of the event, an event without them has only the integer payload
+func decodePayload(payload int64, readings sql.NullString) (domain.Payload, error) {
+	if !readings.Valid {
+		return domain.IntPayload(payload), nil
+	}
+	var p domain.Payload
+	if err := json.Unmarshal([]byte(readings.String), &p); err != nil {
+		return nil, fmt.Errorf("can't decode readings: %w", err)
+	}
+	return p, nil
+}
@@ -0,0 +1,160 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"path/filepath"
+	"testing"
+	"time"
+
+	schemaSqlite "homework/internal/repository/schema/sqlite"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type EventTestSuite struct {
+	suite.Suite
+	db *sql.DB
+
+	repo *EventRepository
+}
+
+func (suite *EventTestSuite) SetupSuite() {
+	db, err := schemaSqlite.Open(context.Background(), filepath.Join(suite.T().TempDir(), "test.db"))
+	suite.Require().NoError(err)
+	suite.db = db
+
+	suite.repo = NewEventRepository(db)
+}
+
+func (suite *EventTestSuite) TearDownSuite() {
+	suite.db.Close()
+}
+
+func (suite *EventTestSuite) TestEventRepository_SaveEvent_Conflict() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	event := domain.Event{
+		Timestamp:          time.Now().In(time.UTC),
+		SensorSerialNumber: "1111111111",
+		SensorID:           11,
+		Payload:            domain.IntPayload(1),
+	}
+	assert.Nil(suite.T(), suite.repo.SaveEvent(ctx, &event))
+	assert.Nil(suite.T(), suite.repo.SaveEvent(ctx, &event), "Повтор того же события не ошибка")
+
+	conflicting := event
+	conflicting.Payload = domain.IntPayload(2)
+	assert.ErrorIs(suite.T(), suite.repo.SaveEvent(ctx, &conflicting), usecase.ErrEventConflict)
+
+	assert.Nil(suite.T(), suite.repo.SaveEvents(ctx, []*domain.Event{&conflicting}), "Пачка пропускает занятое время")
+	last, err := suite.repo.GetLastEventBySensorID(ctx, 11)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), event, *last)
+
+	assert.ErrorIs(suite.T(), suite.repo.SaveEvent(ctx, nil), ErrNilEventPointer)
+	assert.ErrorIs(suite.T(), suite.repo.SaveEvents(ctx, []*domain.Event{nil}), ErrNilEventPointer)
+}
+
+func (suite *EventTestSuite) TestEventRepository_Readings() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().In(time.UTC)
+	events := []*domain.Event{
+		{
+			Timestamp:          now,
+			SensorSerialNumber: "1313131313",
+			SensorID:           13,
+			Payload:            domain.Payload{{Channel: "value", Value: domain.Decimal{Units: 974, Scale: 1}, Unit: "°C"}},
+			Raw:                domain.IntPayload(2048),
+		},
+		{Timestamp: now.Add(time.Minute), SensorSerialNumber: "1313131313", SensorID: 13, Payload: domain.IntPayload(1)},
+	}
+	assert.Nil(suite.T(), suite.repo.SaveEvent(ctx, events[0]))
+	assert.Nil(suite.T(), suite.repo.SaveEvent(ctx, events[0]), "Повтор того же события не ошибка")
+	assert.Nil(suite.T(), suite.repo.SaveEvents(ctx, events[1:]))
+
+	history, err := suite.repo.GetHistoryBySensorID(ctx, 13, now, now.Add(time.Minute))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), events, history, "Сырые показания есть только у откалиброванного события")
+}
+
+func (suite *EventTestSuite) TestEventRepository_GetLastEventBySensorID() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := suite.repo.GetLastEventBySensorID(ctx, 2)
+	assert.ErrorIs(suite.T(), err, usecase.ErrEventNotFound)
+
+	now := time.Now().In(time.UTC)
+	secondEvent := domain.Event{Timestamp: now.Add(10 * time.Minute), SensorSerialNumber: "0987654321", SensorID: 2, Payload: domain.IntPayload(2)}
+	firstEvent := domain.Event{Timestamp: now, SensorSerialNumber: "0987654321", SensorID: 2, Payload: domain.IntPayload(1)}
+	assert.Nil(suite.T(), suite.repo.SaveEvent(ctx, &secondEvent))
+	assert.Nil(suite.T(), suite.repo.SaveEvent(ctx, &firstEvent))
+
+	event, err := suite.repo.GetLastEventBySensorID(ctx, 2)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), secondEvent, *event, "Последнее событие выбирается по времени, а не по порядку записи")
+}
+
+func (suite *EventTestSuite) TestEventRepository_GetHistoryBySensorID() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().In(time.UTC)
+	events := make([]domain.Event, 0, 5)
+	for i := 0; i < 5; i++ {
+		event := domain.Event{
+			Timestamp:          now.Add(time.Duration(i) * time.Minute),
+			SensorSerialNumber: "1111111111",
+			SensorID:           3,
+			Payload:            domain.IntPayload(int64(i)),
+		}
+		assert.Nil(suite.T(), suite.repo.SaveEvent(ctx, &event))
+		events = append(events, event)
+	}
+
+	history, err := suite.repo.GetHistoryBySensorID(ctx, 3, events[1].Timestamp, events[3].Timestamp)
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), history, 3)
+	for i, event := range history {
+		assert.Equal(suite.T(), events[i+1], *event)
+	}
+
+	history, err = suite.repo.GetHistoryBySensorID(ctx, 3, time.Time{}, now.Add(time.Hour))
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), history, 5, "Нулевое время начала не ограничивает историю")
+
+	history, err = suite.repo.GetHistoryBySensorID(ctx, 3, now.Add(time.Hour), now.Add(2*time.Hour))
+	assert.Nil(suite.T(), err)
+	assert.Empty(suite.T(), history)
+}
+
+func (suite *EventTestSuite) TestEventRepository_SaveEvents() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().In(time.UTC)
+	events := []*domain.Event{
+		{Timestamp: now, SensorSerialNumber: "3333333333", SensorID: 5, Payload: domain.IntPayload(1)},
+		{Timestamp: now.Add(time.Minute), SensorSerialNumber: "3333333333", SensorID: 5, Payload: domain.IntPayload(2)},
+	}
+	assert.Nil(suite.T(), suite.repo.SaveEvents(ctx, events))
+
+	var payloads []int64
+	err := suite.repo.StreamHistoryBySensorID(ctx, 5, now, now.Add(time.Minute), func(event *domain.Event) error {
+		payloads = append(payloads, event.Payload.Int())
+		return nil
+	})
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []int64{1, 2}, payloads)
+}
+
+func TestEventTestSuite(t *testing.T) {
+	suite.Run(t, new(EventTestSuite))
+}
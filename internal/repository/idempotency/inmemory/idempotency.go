@@ -0,0 +1,80 @@
+package inmemory
+
+import (
+	"container/list"
+	"context"
+	"homework/internal/domain"
+	"sync"
+	"time"
+)
+
+// IdempotencyRepository is an LRU of the records: when it is full, the least recently used key is forgotten
+// even if its window has not passed yet
+type IdempotencyRepository struct {
+	capacity int
+	records  map[string]*list.Element
+	// order keeps the records from the most recently used to the least
+	order *list.List
+	m     sync.Mutex
+	now   func() time.Time
+}
+
+func NewIdempotencyRepository(capacity int) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		capacity: capacity, records: map[string]*list.Element{}, order: list.New(), m: sync.Mutex{}, now: time.Now,
+	}
+}
+
+func (r *IdempotencyRepository) ReserveKey(ctx context.Context, record domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if el, has := r.records[record.Key]; has {
+		existing, _ := el.Value.(*domain.IdempotencyRecord)
+		if existing.ExpiresAt.After(r.now()) {
+			r.order.MoveToFront(el)
+			res := *existing
+			return &res, nil
+		}
+		r.remove(el)
+	}
+
+	r.records[record.Key] = r.order.PushFront(&record)
+	for r.order.Len() > r.capacity {
+		r.remove(r.order.Back())
+	}
+	return nil, nil
+}
+
+func (r *IdempotencyRepository) CompleteKey(ctx context.Context, record domain.IdempotencyRecord) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	// the key may have been evicted meanwhile, the result is kept anyway
+	if el, has := r.records[record.Key]; has {
+		r.remove(el)
+	}
+	r.records[record.Key] = r.order.PushFront(&record)
+	for r.order.Len() > r.capacity {
+		r.remove(r.order.Back())
+	}
+	return ctx.Err()
+}
+
+func (r *IdempotencyRepository) ReleaseKey(ctx context.Context, key string) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if el, has := r.records[key]; has {
+		r.remove(el)
+	}
+	return ctx.Err()
+}
+
+func (r *IdempotencyRepository) remove(el *list.Element) {
+	record, _ := r.order.Remove(el).(*domain.IdempotencyRecord)
+	delete(r.records, record.Key)
+}
@@ -0,0 +1,83 @@
+package inmemory
+
+import (
+	"context"
+	"homework/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyRepository(t *testing.T) {
+	record := func(key string, expiresAt time.Time) domain.IdempotencyRecord {
+		return domain.IdempotencyRecord{Key: key, Fingerprint: "fp", ExpiresAt: expiresAt}
+	}
+
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		ir := NewIdempotencyRepository(10)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := ir.ReserveKey(ctx, record("key", time.Now().Add(time.Hour)))
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("ok, reserve, complete and release", func(t *testing.T) {
+		ir := NewIdempotencyRepository(10)
+		ctx := context.Background()
+		expiresAt := time.Now().Add(time.Hour)
+
+		existing, err := ir.ReserveKey(ctx, record("key", expiresAt))
+		assert.NoError(t, err)
+		assert.Nil(t, existing, "Свободный ключ должен резервироваться")
+
+		existing, err = ir.ReserveKey(ctx, record("key", expiresAt))
+		assert.NoError(t, err)
+		assert.Equal(t, &domain.IdempotencyRecord{Key: "key", Fingerprint: "fp", ExpiresAt: expiresAt}, existing)
+
+		completed := domain.IdempotencyRecord{Key: "key", Fingerprint: "fp", Completed: true, Status: 201, ExpiresAt: expiresAt}
+		assert.NoError(t, ir.CompleteKey(ctx, completed))
+		existing, err = ir.ReserveKey(ctx, record("key", expiresAt))
+		assert.NoError(t, err)
+		assert.Equal(t, &completed, existing)
+
+		assert.NoError(t, ir.ReleaseKey(ctx, "key"))
+		existing, err = ir.ReserveKey(ctx, record("key", expiresAt))
+		assert.NoError(t, err)
+		assert.Nil(t, existing, "Освобожденный ключ должен резервироваться заново")
+	})
+
+	t.Run("ok, expired key is free", func(t *testing.T) {
+		ir := NewIdempotencyRepository(10)
+		now := time.Now()
+		ir.now = func() time.Time { return now }
+
+		_, err := ir.ReserveKey(context.Background(), record("key", now.Add(time.Minute)))
+		assert.NoError(t, err)
+
+		now = now.Add(time.Minute)
+		existing, err := ir.ReserveKey(context.Background(), record("key", now.Add(time.Minute)))
+		assert.NoError(t, err)
+		assert.Nil(t, existing)
+	})
+
+	t.Run("ok, least recently used key is evicted", func(t *testing.T) {
+		ir := NewIdempotencyRepository(2)
+		ctx := context.Background()
+		expiresAt := time.Now().Add(time.Hour)
+
+		for _, key := range []string{"a", "b", "a", "c"} {
+			_, err := ir.ReserveKey(ctx, record(key, expiresAt))
+			assert.NoError(t, err)
+		}
+
+		existing, err := ir.ReserveKey(ctx, record("a", expiresAt))
+		assert.NoError(t, err)
+		assert.NotNil(t, existing, "Недавно использованный ключ должен остаться")
+
+		existing, err = ir.ReserveKey(ctx, record("b", expiresAt))
+		assert.NoError(t, err)
+		assert.Nil(t, existing, "Давно использованный ключ должен быть вытеснен")
+	})
+}
@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// sweepEvery is how many keys are reserved between the deletions of the expired ones
+const sweepEvery = 1024
+
+type IdempotencyRepository struct {
+	pool     *pgxpool.Pool
+	reserved atomic.Int64
+}
+
+func NewIdempotencyRepository(pool *pgxpool.Pool) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		pool: pool,
+	}
+}
+
+// reserveKeyQuery takes the key if it is free or expired, nothing is returned if it is taken
+const reserveKeyQuery = `
+insert into db.public.idempotency_keys as k (key, fingerprint, expires_at)
+values ($1, $2, $3)
+on conflict (key) do update
+set fingerprint = excluded.fingerprint, completed = false, status = 0, content_type = '', body = null,
+    expires_at = excluded.expires_at
+where k.expires_at <= now()
+returning key;`
+
+const getKeyQuery = `
+select fingerprint, completed, status, content_type, body, expires_at
+from db.public.idempotency_keys
+where key=$1;`
+
+const sweepQuery = `delete from db.public.idempotency_keys where expires_at <= now()`
+
+func (r *IdempotencyRepository) ReserveKey(ctx context.Context, record domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	var key string
+	err := r.pool.QueryRow(ctx, reserveKeyQuery, record.Key, record.Fingerprint, record.ExpiresAt).Scan(&key)
+	if err == nil {
+		if r.reserved.Add(1)%sweepEvery == 0 {
+			if _, err := r.pool.Exec(ctx, sweepQuery); err != nil {
+				return nil, fmt.Errorf("can't delete expired keys: %w", err)
+			}
+		}
+		return nil, ctx.Err()
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("can't reserve key: %w", err)
+	}
+
+	existing := &domain.IdempotencyRecord{Key: record.Key}
+	err = r.pool.QueryRow(ctx, getKeyQuery, record.Key).Scan(&existing.Fingerprint, &existing.Completed,
+		&existing.Status, &existing.ContentType, &existing.Body, &existing.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("can't scan key: %w", err)
+	}
+	return existing, ctx.Err()
+}
+
+const completeKeyQuery = `
+insert into db.public.idempotency_keys (key, fingerprint, completed, status, content_type, body, expires_at)
+values ($1, $2, true, $3, $4, $5, $6)
+on conflict (key) do update
+set fingerprint = excluded.fingerprint, completed = true, status = excluded.status,
+    content_type = excluded.content_type, body = excluded.body, expires_at = excluded.expires_at;`
+
+func (r *IdempotencyRepository) CompleteKey(ctx context.Context, record domain.IdempotencyRecord) error {
+	_, err := r.pool.Exec(ctx, completeKeyQuery, record.Key, record.Fingerprint, record.Status,
+		record.ContentType, record.Body, record.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("can't complete key: %w", err)
+	}
+	return ctx.Err()
+}
+
+const releaseKeyQuery = `delete from db.public.idempotency_keys where key=$1`
+
+func (r *IdempotencyRepository) ReleaseKey(ctx context.Context, key string) error {
+	if _, err := r.pool.Exec(ctx, releaseKeyQuery, key); err != nil {
+		return fmt.Errorf("can't release key: %w", err)
+	}
+	return ctx.Err()
+}
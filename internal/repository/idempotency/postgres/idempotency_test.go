@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/pkg/pg_test"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type IdempotencyTestSuite struct {
+	suite.Suite
+	testDbInstance *pgxpool.Pool
+	testDB         *pg_test.TestDatabase
+
+	repo *IdempotencyRepository
+}
+
+func (suite *IdempotencyTestSuite) SetupSuite() {
+	suite.testDB = pg_test.SetupTestDatabase()
+	suite.testDbInstance = suite.testDB.DbInstance
+
+	suite.repo = NewIdempotencyRepository(suite.testDbInstance)
+}
+
+func (suite *IdempotencyTestSuite) TearDownSuite() {
+	suite.testDB.TearDown()
+}
+
+func (suite *IdempotencyTestSuite) TestIdempotencyRepository_ReserveKey() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Microsecond)
+	record := domain.IdempotencyRecord{Key: "events:1:reserve", Fingerprint: "fp", ExpiresAt: expiresAt}
+
+	existing, err := suite.repo.ReserveKey(ctx, record)
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), existing)
+
+	existing, err = suite.repo.ReserveKey(ctx, record)
+	assert.Nil(suite.T(), err)
+	if assert.NotNil(suite.T(), existing) {
+		assert.False(suite.T(), existing.Completed)
+		assert.Equal(suite.T(), "fp", existing.Fingerprint)
+	}
+
+	assert.Nil(suite.T(), suite.repo.ReleaseKey(ctx, record.Key))
+	existing, err = suite.repo.ReserveKey(ctx, record)
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), existing)
+}
+
+func (suite *IdempotencyTestSuite) TestIdempotencyRepository_CompleteKey() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Microsecond)
+	completed := domain.IdempotencyRecord{
+		Key: "events:1:complete", Fingerprint: "fp", Completed: true,
+		Status: 422, ContentType: "application/problem+json", Body: []byte(`{"code":"sensor_inactive"}`), ExpiresAt: expiresAt,
+	}
+	assert.Nil(suite.T(), suite.repo.CompleteKey(ctx, completed))
+
+	existing, err := suite.repo.ReserveKey(ctx, domain.IdempotencyRecord{Key: completed.Key, Fingerprint: "fp", ExpiresAt: expiresAt})
+	assert.Nil(suite.T(), err)
+	if assert.NotNil(suite.T(), existing) {
+		existing.ExpiresAt = existing.ExpiresAt.UTC()
+		assert.Equal(suite.T(), &completed, existing)
+	}
+}
+
+func (suite *IdempotencyTestSuite) TestIdempotencyRepository_ReserveKey_Expired() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	record := domain.IdempotencyRecord{Key: "events:1:expired", Fingerprint: "fp", ExpiresAt: time.Now().Add(-time.Minute)}
+	_, err := suite.repo.ReserveKey(ctx, record)
+	assert.Nil(suite.T(), err)
+
+	record.ExpiresAt = time.Now().Add(time.Hour)
+	existing, err := suite.repo.ReserveKey(ctx, record)
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), existing, "Истекший ключ должен резервироваться заново")
+}
+
+func TestIdempotencyTestSuite(t *testing.T) {
+	suite.Run(t, new(IdempotencyTestSuite))
+}
@@ -0,0 +1,73 @@
+package instrumented
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+)
+
+type ActuatorRepository struct {
+	*Instrument
+	repository usecase.ActuatorRepository
+}
+
+func NewActuatorRepository(ar usecase.ActuatorRepository, in *Instrument) *ActuatorRepository {
+	return &ActuatorRepository{Instrument: in, repository: ar}
+}
+
+func (r *ActuatorRepository) SaveActuator(ctx context.Context, actuator *domain.Actuator) (err error) {
+	ctx, end := r.start(ctx, "ActuatorRepository.SaveActuator")
+	defer end(&err)
+	return r.repository.SaveActuator(ctx, actuator)
+}
+
+func (r *ActuatorRepository) GetActuators(ctx context.Context) (_ []domain.Actuator, err error) {
+	ctx, end := r.start(ctx, "ActuatorRepository.GetActuators")
+	defer end(&err)
+	return r.repository.GetActuators(ctx)
+}
+
+func (r *ActuatorRepository) GetActuatorByID(ctx context.Context, id int64) (_ *domain.Actuator, err error) {
+	ctx, end := r.start(ctx, "ActuatorRepository.GetActuatorByID")
+	defer end(&err)
+	return r.repository.GetActuatorByID(ctx, id)
+}
+
+func (r *ActuatorRepository) GetActuatorBySerialNumber(ctx context.Context, sn string) (_ *domain.Actuator, err error) {
+	ctx, end := r.start(ctx, "ActuatorRepository.GetActuatorBySerialNumber")
+	defer end(&err)
+	return r.repository.GetActuatorBySerialNumber(ctx, sn)
+}
+
+type CommandRepository struct {
+	*Instrument
+	repository usecase.CommandRepository
+}
+
+func NewCommandRepository(cr usecase.CommandRepository, in *Instrument) *CommandRepository {
+	return &CommandRepository{Instrument: in, repository: cr}
+}
+
+func (r *CommandRepository) SaveCommand(ctx context.Context, command *domain.Command) (err error) {
+	ctx, end := r.start(ctx, "CommandRepository.SaveCommand")
+	defer end(&err)
+	return r.repository.SaveCommand(ctx, command)
+}
+
+func (r *CommandRepository) GetCommandByID(ctx context.Context, id int64) (_ *domain.Command, err error) {
+	ctx, end := r.start(ctx, "CommandRepository.GetCommandByID")
+	defer end(&err)
+	return r.repository.GetCommandByID(ctx, id)
+}
+
+func (r *CommandRepository) GetActiveCommands(ctx context.Context, actuatorID int64) (_ []*domain.Command, err error) {
+	ctx, end := r.start(ctx, "CommandRepository.GetActiveCommands")
+	defer end(&err)
+	return r.repository.GetActiveCommands(ctx, actuatorID)
+}
+
+func (r *CommandRepository) UpdateCommand(ctx context.Context, command *domain.Command, status domain.CommandStatus, attempts int) (err error) {
+	ctx, end := r.start(ctx, "CommandRepository.UpdateCommand")
+	defer end(&err)
+	return r.repository.UpdateCommand(ctx, command, status, attempts)
+}
@@ -0,0 +1,50 @@
+package instrumented
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"time"
+)
+
+// ChangeRepository keeps the notifications of the wrapped repository: a waiting read is woken up
+// by the new changes only if the wrapped one tells about them
+type ChangeRepository struct {
+	*Instrument
+	repository usecase.ChangeRepository
+}
+
+func NewChangeRepository(cr usecase.ChangeRepository, in *Instrument) usecase.ChangeRepository {
+	r := &ChangeRepository{Instrument: in, repository: cr}
+	if notifier, ok := cr.(usecase.ChangeNotifier); ok {
+		return &struct {
+			*ChangeRepository
+			usecase.ChangeNotifier
+		}{r, notifier}
+	}
+	return r
+}
+
+func (r *ChangeRepository) GetChanges(ctx context.Context, after int64, limit int) (_ []domain.Change, err error) {
+	ctx, end := r.start(ctx, "ChangeRepository.GetChanges")
+	defer end(&err)
+	return r.repository.GetChanges(ctx, after, limit)
+}
+
+func (r *ChangeRepository) DeleteChanges(ctx context.Context, before time.Time) (_ int64, err error) {
+	ctx, end := r.start(ctx, "ChangeRepository.DeleteChanges")
+	defer end(&err)
+	return r.repository.DeleteChanges(ctx, before)
+}
+
+func (r *ChangeRepository) SaveConsumer(ctx context.Context, consumer domain.ChangeConsumer) (err error) {
+	ctx, end := r.start(ctx, "ChangeRepository.SaveConsumer")
+	defer end(&err)
+	return r.repository.SaveConsumer(ctx, consumer)
+}
+
+func (r *ChangeRepository) GetConsumer(ctx context.Context, name string) (_ *domain.ChangeConsumer, err error) {
+	ctx, end := r.start(ctx, "ChangeRepository.GetConsumer")
+	defer end(&err)
+	return r.repository.GetConsumer(ctx, name)
+}
@@ -0,0 +1,36 @@
+package instrumented
+
+import (
+	"context"
+	"homework/internal/repository/change/inmemory"
+	"homework/internal/usecase"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewChangeRepository(t *testing.T) {
+	t.Run("ok, notifications are kept", func(t *testing.T) {
+		changes := inmemory.NewChangeRepository()
+		cr := NewChangeRepository(changes, NewInstrument(BackendInMemory, prometheus.NewRegistry()))
+
+		notifier, ok := cr.(usecase.ChangeNotifier)
+		require.True(t, ok, "Обертка не должна терять возможности")
+		assert.Equal(t, changes.Changed(), notifier.Changed())
+
+		_, err := cr.GetConsumer(context.Background(), "search")
+		assert.ErrorIs(t, err, usecase.ErrConsumerNotFound)
+	})
+
+	t.Run("ok, nothing is added", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cr := NewChangeRepository(usecase.NewMockChangeRepository(ctrl), NewInstrument(BackendPostgres, prometheus.NewRegistry()))
+		_, ok := cr.(usecase.ChangeNotifier)
+		assert.False(t, ok, "Обертка не должна добавлять возможности")
+	})
+}
@@ -0,0 +1,27 @@
+package instrumented
+
+import (
+	"context"
+	"homework/internal/usecase"
+)
+
+type CheckpointRepository struct {
+	*Instrument
+	repository usecase.ImportCheckpointRepository
+}
+
+func NewCheckpointRepository(cr usecase.ImportCheckpointRepository, in *Instrument) *CheckpointRepository {
+	return &CheckpointRepository{Instrument: in, repository: cr}
+}
+
+func (r *CheckpointRepository) SaveCheckpoint(ctx context.Context, key string, processed int64) (err error) {
+	ctx, end := r.start(ctx, "CheckpointRepository.SaveCheckpoint")
+	defer end(&err)
+	return r.repository.SaveCheckpoint(ctx, key, processed)
+}
+
+func (r *CheckpointRepository) GetCheckpoint(ctx context.Context, key string) (_ int64, err error) {
+	ctx, end := r.start(ctx, "CheckpointRepository.GetCheckpoint")
+	defer end(&err)
+	return r.repository.GetCheckpoint(ctx, key)
+}
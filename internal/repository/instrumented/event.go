@@ -0,0 +1,79 @@
+package instrumented
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"time"
+)
+
+// EventRepository keeps the optional extensions of the wrapped repository: it streams the history
+// and saves the events in batches only if the wrapped one can
+type EventRepository struct {
+	*Instrument
+	repository usecase.EventRepository
+}
+
+func NewEventRepository(er usecase.EventRepository, in *Instrument) usecase.EventRepository {
+	r := &EventRepository{Instrument: in, repository: er}
+
+	_, streams := er.(usecase.EventHistoryStreamer)
+	_, batches := er.(usecase.EventBatchSaver)
+	switch {
+	case streams && batches:
+		return &struct {
+			*EventRepository
+			*eventHistoryStreamer
+			*eventBatchSaver
+		}{r, &eventHistoryStreamer{r}, &eventBatchSaver{r}}
+	case streams:
+		return &struct {
+			*EventRepository
+			*eventHistoryStreamer
+		}{r, &eventHistoryStreamer{r}}
+	case batches:
+		return &struct {
+			*EventRepository
+			*eventBatchSaver
+		}{r, &eventBatchSaver{r}}
+	}
+	return r
+}
+
+func (r *EventRepository) SaveEvent(ctx context.Context, event *domain.Event) (err error) {
+	ctx, end := r.start(ctx, "EventRepository.SaveEvent")
+	defer end(&err)
+	return r.repository.SaveEvent(ctx, event)
+}
+
+func (r *EventRepository) GetLastEventBySensorID(ctx context.Context, id int64) (_ *domain.Event, err error) {
+	ctx, end := r.start(ctx, "EventRepository.GetLastEventBySensorID")
+	defer end(&err)
+	return r.repository.GetLastEventBySensorID(ctx, id)
+}
+
+func (r *EventRepository) GetHistoryBySensorID(ctx context.Context, id int64, from, to time.Time) (_ []*domain.Event, err error) {
+	ctx, end := r.start(ctx, "EventRepository.GetHistoryBySensorID")
+	defer end(&err)
+	return r.repository.GetHistoryBySensorID(ctx, id, from, to)
+}
+
+type eventHistoryStreamer struct {
+	*EventRepository
+}
+
+func (r *eventHistoryStreamer) StreamHistoryBySensorID(ctx context.Context, id int64, from, to time.Time, fn func(*domain.Event) error) (err error) {
+	ctx, end := r.start(ctx, "EventRepository.StreamHistoryBySensorID")
+	defer end(&err)
+	return r.repository.(usecase.EventHistoryStreamer).StreamHistoryBySensorID(ctx, id, from, to, fn)
+}
+
+type eventBatchSaver struct {
+	*EventRepository
+}
+
+func (r *eventBatchSaver) SaveEvents(ctx context.Context, events []*domain.Event) (err error) {
+	ctx, end := r.start(ctx, "EventRepository.SaveEvents")
+	defer end(&err)
+	return r.repository.(usecase.EventBatchSaver).SaveEvents(ctx, events)
+}
@@ -0,0 +1,70 @@
+package instrumented
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/repository/event/inmemory"
+	"homework/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestNewEventRepository(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	t.Run("ok, extensions are kept", func(t *testing.T) {
+		er := NewEventRepository(inmemory.NewEventRepository(), NewInstrument(BackendInMemory, prometheus.NewRegistry()))
+
+		_, streams := er.(usecase.EventHistoryStreamer)
+		assert.True(t, streams, "Обертка не должна терять возможности")
+		saver, batches := er.(usecase.EventBatchSaver)
+		require.True(t, batches, "Обертка не должна терять возможности")
+
+		require.NoError(t, saver.SaveEvents(context.Background(), []*domain.Event{{SensorID: 1, Timestamp: time.Now()}}))
+		_, err := er.GetLastEventBySensorID(context.Background(), 2)
+		assert.ErrorIs(t, err, usecase.ErrEventNotFound)
+	})
+
+	t.Run("ok, calls are traced and timed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mock := usecase.NewMockEventRepository(ctrl)
+		mock.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Return(nil)
+		reg := prometheus.NewRegistry()
+		er := NewEventRepository(mock, NewInstrument(BackendPostgres, reg))
+		_, streams := er.(usecase.EventHistoryStreamer)
+		assert.False(t, streams, "Обертка не должна добавлять возможности")
+
+		ctx, parent := otel.Tracer("test").Start(context.Background(), "Event.ReceiveEvent")
+		require.NoError(t, er.SaveEvent(ctx, &domain.Event{}))
+		parent.End()
+
+		spans := recorder.Ended()
+		require.GreaterOrEqual(t, len(spans), 2)
+		span := spans[len(spans)-2]
+		assert.Equal(t, "EventRepository.SaveEvent", span.Name())
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+		assert.Contains(t, span.Attributes(), backendAttribute(BackendPostgres))
+
+		families, err := reg.Gather()
+		require.NoError(t, err)
+		require.Len(t, families, 1)
+		require.Len(t, families[0].Metric, 1)
+		assert.Equal(t, uint64(1), families[0].Metric[0].Histogram.GetSampleCount())
+		labels := make(map[string]string)
+		for _, l := range families[0].Metric[0].Label {
+			labels[l.GetName()] = l.GetValue()
+		}
+		assert.Equal(t, map[string]string{"backend": BackendPostgres, "operation": "EventRepository.SaveEvent", "status": "ok"}, labels)
+	})
+}
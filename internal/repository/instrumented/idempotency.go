@@ -0,0 +1,34 @@
+package instrumented
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+)
+
+type IdempotencyRepository struct {
+	*Instrument
+	repository usecase.IdempotencyRepository
+}
+
+func NewIdempotencyRepository(ir usecase.IdempotencyRepository, in *Instrument) *IdempotencyRepository {
+	return &IdempotencyRepository{Instrument: in, repository: ir}
+}
+
+func (r *IdempotencyRepository) ReserveKey(ctx context.Context, record domain.IdempotencyRecord) (_ *domain.IdempotencyRecord, err error) {
+	ctx, end := r.start(ctx, "IdempotencyRepository.ReserveKey")
+	defer end(&err)
+	return r.repository.ReserveKey(ctx, record)
+}
+
+func (r *IdempotencyRepository) CompleteKey(ctx context.Context, record domain.IdempotencyRecord) (err error) {
+	ctx, end := r.start(ctx, "IdempotencyRepository.CompleteKey")
+	defer end(&err)
+	return r.repository.CompleteKey(ctx, record)
+}
+
+func (r *IdempotencyRepository) ReleaseKey(ctx context.Context, key string) (err error) {
+	ctx, end := r.start(ctx, "IdempotencyRepository.ReleaseKey")
+	defer end(&err)
+	return r.repository.ReleaseKey(ctx, key)
+}
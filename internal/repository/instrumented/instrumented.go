@@ -0,0 +1,62 @@
+// Package instrumented wraps the repositories to trace and time every call, whatever the backend is.
+package instrumented
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("homework/internal/repository")
+
+// Backends the repositories are kept in
+const (
+	BackendInMemory = "inmemory"
+	BackendPostgres = "postgres"
+	BackendSQLite   = "sqlite"
+)
+
+func backendAttribute(backend string) attribute.KeyValue {
+	return attribute.String("repository.backend", backend)
+}
+
+// Instrument is shared by the repositories of one backend
+type Instrument struct {
+	backend string
+	latency *prometheus.HistogramVec
+}
+
+// NewInstrument registers the latency histogram in reg, so the tests can use a registry of their own
+func NewInstrument(backend string, reg prometheus.Registerer) *Instrument {
+	return &Instrument{
+		backend: backend,
+		latency: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Name:        "repository_operation_duration_seconds",
+			Help:        "Keeps track of the duration of repository calls by operation",
+			Buckets:     []float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1, 5},
+			ConstLabels: prometheus.Labels{"backend": backend},
+		}, []string{"operation", "status"}),
+	}
+}
+
+// start begins the span of the repository call, the returned function ends it with the error of the call
+func (i *Instrument) start(ctx context.Context, operation string) (context.Context, func(*error)) {
+	ctx, span := tracer.Start(ctx, operation, trace.WithAttributes(backendAttribute(i.backend)))
+	begin := time.Now()
+	return ctx, func(err *error) {
+		status := "ok"
+		if *err != nil {
+			status = "error"
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, (*err).Error())
+		}
+		i.latency.WithLabelValues(operation, status).Observe(time.Since(begin).Seconds())
+		span.End()
+	}
+}
@@ -0,0 +1,23 @@
+package instrumented
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"time"
+)
+
+type RateLimitRepository struct {
+	*Instrument
+	repository usecase.RateLimitRepository
+}
+
+func NewRateLimitRepository(rr usecase.RateLimitRepository, in *Instrument) *RateLimitRepository {
+	return &RateLimitRepository{Instrument: in, repository: rr}
+}
+
+func (r *RateLimitRepository) TakeToken(ctx context.Context, key string, limit domain.RateLimit) (_ time.Duration, err error) {
+	ctx, end := r.start(ctx, "RateLimitRepository.TakeToken")
+	defer end(&err)
+	return r.repository.TakeToken(ctx, key, limit)
+}
@@ -0,0 +1,86 @@
+package instrumented
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"time"
+)
+
+type SceneRepository struct {
+	*Instrument
+	repository usecase.SceneRepository
+}
+
+func NewSceneRepository(sr usecase.SceneRepository, in *Instrument) *SceneRepository {
+	return &SceneRepository{Instrument: in, repository: sr}
+}
+
+func (r *SceneRepository) SaveScene(ctx context.Context, scene *domain.Scene) (err error) {
+	ctx, end := r.start(ctx, "SceneRepository.SaveScene")
+	defer end(&err)
+	return r.repository.SaveScene(ctx, scene)
+}
+
+func (r *SceneRepository) GetScenes(ctx context.Context) (_ []domain.Scene, err error) {
+	ctx, end := r.start(ctx, "SceneRepository.GetScenes")
+	defer end(&err)
+	return r.repository.GetScenes(ctx)
+}
+
+func (r *SceneRepository) GetSceneByID(ctx context.Context, id int64) (_ *domain.Scene, err error) {
+	ctx, end := r.start(ctx, "SceneRepository.GetSceneByID")
+	defer end(&err)
+	return r.repository.GetSceneByID(ctx, id)
+}
+
+func (r *SceneRepository) DeleteScene(ctx context.Context, id int64) (err error) {
+	ctx, end := r.start(ctx, "SceneRepository.DeleteScene")
+	defer end(&err)
+	return r.repository.DeleteScene(ctx, id)
+}
+
+type ScheduleRepository struct {
+	*Instrument
+	repository usecase.ScheduleRepository
+}
+
+func NewScheduleRepository(shr usecase.ScheduleRepository, in *Instrument) *ScheduleRepository {
+	return &ScheduleRepository{Instrument: in, repository: shr}
+}
+
+func (r *ScheduleRepository) SaveSchedule(ctx context.Context, schedule *domain.Schedule) (err error) {
+	ctx, end := r.start(ctx, "ScheduleRepository.SaveSchedule")
+	defer end(&err)
+	return r.repository.SaveSchedule(ctx, schedule)
+}
+
+func (r *ScheduleRepository) GetSchedules(ctx context.Context) (_ []domain.Schedule, err error) {
+	ctx, end := r.start(ctx, "ScheduleRepository.GetSchedules")
+	defer end(&err)
+	return r.repository.GetSchedules(ctx)
+}
+
+func (r *ScheduleRepository) GetScheduleByID(ctx context.Context, id int64) (_ *domain.Schedule, err error) {
+	ctx, end := r.start(ctx, "ScheduleRepository.GetScheduleByID")
+	defer end(&err)
+	return r.repository.GetScheduleByID(ctx, id)
+}
+
+func (r *ScheduleRepository) DeleteSchedule(ctx context.Context, id int64) (err error) {
+	ctx, end := r.start(ctx, "ScheduleRepository.DeleteSchedule")
+	defer end(&err)
+	return r.repository.DeleteSchedule(ctx, id)
+}
+
+func (r *ScheduleRepository) GetDueSchedules(ctx context.Context, now time.Time) (_ []*domain.Schedule, err error) {
+	ctx, end := r.start(ctx, "ScheduleRepository.GetDueSchedules")
+	defer end(&err)
+	return r.repository.GetDueSchedules(ctx, now)
+}
+
+func (r *ScheduleRepository) AdvanceSchedule(ctx context.Context, schedule *domain.Schedule, prevRun time.Time) (_ bool, err error) {
+	ctx, end := r.start(ctx, "ScheduleRepository.AdvanceSchedule")
+	defer end(&err)
+	return r.repository.AdvanceSchedule(ctx, schedule, prevRun)
+}
@@ -0,0 +1,40 @@
+package instrumented
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+)
+
+type SensorRepository struct {
+	*Instrument
+	repository usecase.SensorRepository
+}
+
+func NewSensorRepository(sr usecase.SensorRepository, in *Instrument) *SensorRepository {
+	return &SensorRepository{Instrument: in, repository: sr}
+}
+
+func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor) (err error) {
+	ctx, end := r.start(ctx, "SensorRepository.SaveSensor")
+	defer end(&err)
+	return r.repository.SaveSensor(ctx, sensor)
+}
+
+func (r *SensorRepository) GetSensors(ctx context.Context) (_ []domain.Sensor, err error) {
+	ctx, end := r.start(ctx, "SensorRepository.GetSensors")
+	defer end(&err)
+	return r.repository.GetSensors(ctx)
+}
+
+func (r *SensorRepository) GetSensorByID(ctx context.Context, id int64) (_ *domain.Sensor, err error) {
+	ctx, end := r.start(ctx, "SensorRepository.GetSensorByID")
+	defer end(&err)
+	return r.repository.GetSensorByID(ctx, id)
+}
+
+func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn string) (_ *domain.Sensor, err error) {
+	ctx, end := r.start(ctx, "SensorRepository.GetSensorBySerialNumber")
+	defer end(&err)
+	return r.repository.GetSensorBySerialNumber(ctx, sn)
+}
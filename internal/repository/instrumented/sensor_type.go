@@ -0,0 +1,40 @@
+package instrumented
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+)
+
+type SensorTypeRepository struct {
+	*Instrument
+	repository usecase.SensorTypeRepository
+}
+
+func NewSensorTypeRepository(tr usecase.SensorTypeRepository, in *Instrument) *SensorTypeRepository {
+	return &SensorTypeRepository{Instrument: in, repository: tr}
+}
+
+func (r *SensorTypeRepository) SaveSensorType(ctx context.Context, sensorType domain.SensorTypeSpec) (_ bool, err error) {
+	ctx, end := r.start(ctx, "SensorTypeRepository.SaveSensorType")
+	defer end(&err)
+	return r.repository.SaveSensorType(ctx, sensorType)
+}
+
+func (r *SensorTypeRepository) GetSensorTypes(ctx context.Context) (_ []domain.SensorTypeSpec, err error) {
+	ctx, end := r.start(ctx, "SensorTypeRepository.GetSensorTypes")
+	defer end(&err)
+	return r.repository.GetSensorTypes(ctx)
+}
+
+func (r *SensorTypeRepository) GetSensorType(ctx context.Context, name domain.SensorType) (_ *domain.SensorTypeSpec, err error) {
+	ctx, end := r.start(ctx, "SensorTypeRepository.GetSensorType")
+	defer end(&err)
+	return r.repository.GetSensorType(ctx, name)
+}
+
+func (r *SensorTypeRepository) DeleteSensorType(ctx context.Context, name domain.SensorType) (err error) {
+	ctx, end := r.start(ctx, "SensorTypeRepository.DeleteSensorType")
+	defer end(&err)
+	return r.repository.DeleteSensorType(ctx, name)
+}
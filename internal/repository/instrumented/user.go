@@ -0,0 +1,55 @@
+package instrumented
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+)
+
+type UserRepository struct {
+	*Instrument
+	repository usecase.UserRepository
+}
+
+func NewUserRepository(ur usecase.UserRepository, in *Instrument) *UserRepository {
+	return &UserRepository{Instrument: in, repository: ur}
+}
+
+func (r *UserRepository) SaveUser(ctx context.Context, user *domain.User) (err error) {
+	ctx, end := r.start(ctx, "UserRepository.SaveUser")
+	defer end(&err)
+	return r.repository.SaveUser(ctx, user)
+}
+
+func (r *UserRepository) GetUserByID(ctx context.Context, id int64) (_ *domain.User, err error) {
+	ctx, end := r.start(ctx, "UserRepository.GetUserByID")
+	defer end(&err)
+	return r.repository.GetUserByID(ctx, id)
+}
+
+type SensorOwnerRepository struct {
+	*Instrument
+	repository usecase.SensorOwnerRepository
+}
+
+func NewSensorOwnerRepository(sor usecase.SensorOwnerRepository, in *Instrument) *SensorOwnerRepository {
+	return &SensorOwnerRepository{Instrument: in, repository: sor}
+}
+
+func (r *SensorOwnerRepository) SaveSensorOwner(ctx context.Context, sensorOwner domain.SensorOwner) (err error) {
+	ctx, end := r.start(ctx, "SensorOwnerRepository.SaveSensorOwner")
+	defer end(&err)
+	return r.repository.SaveSensorOwner(ctx, sensorOwner)
+}
+
+func (r *SensorOwnerRepository) GetSensorsByUserID(ctx context.Context, userID int64) (_ []domain.SensorOwner, err error) {
+	ctx, end := r.start(ctx, "SensorOwnerRepository.GetSensorsByUserID")
+	defer end(&err)
+	return r.repository.GetSensorsByUserID(ctx, userID)
+}
+
+func (r *SensorOwnerRepository) GetSensorOwners(ctx context.Context) (_ []domain.SensorOwner, err error) {
+	ctx, end := r.start(ctx, "SensorOwnerRepository.GetSensorOwners")
+	defer end(&err)
+	return r.repository.GetSensorOwners(ctx)
+}
@@ -0,0 +1,85 @@
+package inmemory
+
+import (
+	"container/list"
+	"context"
+	"homework/internal/domain"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how many tokens are taken between the sweeps of the full buckets
+const sweepEvery = 1024
+
+// RateLimitRepository keeps the buckets as the GCRA does: the theoretical arrival time of the next request is stored
+// instead of the number of tokens. A bucket whose time has passed is full and is the same as no bucket at all.
+//
+// The buckets are an LRU: when there are more than capacity of them, the least recently used one is forgotten,
+// so a flood of new keys costs its sender the limit of an idle key at worst instead of the memory.
+type RateLimitRepository struct {
+	capacity int
+	buckets  map[string]*list.Element
+	// order keeps the buckets from the most recently used to the least
+	order *list.List
+	taken int
+	m     sync.Mutex
+	now   func() time.Time
+}
+
+type bucket struct {
+	key string
+	tat time.Time
+}
+
+func NewRateLimitRepository(capacity int) *RateLimitRepository {
+	return &RateLimitRepository{
+		capacity: capacity, buckets: map[string]*list.Element{}, order: list.New(), m: sync.Mutex{}, now: time.Now,
+	}
+}
+
+func (r *RateLimitRepository) TakeToken(ctx context.Context, key string, limit domain.RateLimit) (time.Duration, error) {
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	now := r.now()
+	interval := limit.Interval()
+	el, has := r.buckets[key]
+	if !has {
+		el = r.order.PushFront(&bucket{key: key})
+		r.buckets[key] = el
+	}
+	r.order.MoveToFront(el)
+	b, _ := el.Value.(*bucket)
+
+	tat := b.tat
+	if tat.Before(now) {
+		tat = now
+	}
+	next := tat.Add(interval)
+	if wait := next.Sub(now) - time.Duration(limit.Burst)*interval; wait > 0 {
+		return wait, nil
+	}
+	b.tat = next
+	for r.order.Len() > r.capacity {
+		r.remove(r.order.Back())
+	}
+
+	r.taken++
+	if r.taken%sweepEvery == 0 {
+		for _, el := range r.buckets {
+			if b, _ := el.Value.(*bucket); !b.tat.After(now) {
+				r.remove(el)
+			}
+		}
+	}
+	return 0, nil
+}
+
+func (r *RateLimitRepository) remove(el *list.Element) {
+	b, _ := r.order.Remove(el).(*bucket)
+	delete(r.buckets, b.key)
+}
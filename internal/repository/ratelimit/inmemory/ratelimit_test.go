@@ -0,0 +1,87 @@
+package inmemory
+
+import (
+	"context"
+	"homework/internal/domain"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitRepository_TakeToken(t *testing.T) {
+	limit := domain.RateLimit{Rate: 2, Burst: 3}
+
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		rr := NewRateLimitRepository(sweepEvery)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := rr.TakeToken(ctx, "key", limit)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("ok, burst then wait", func(t *testing.T) {
+		rr := NewRateLimitRepository(sweepEvery)
+		now := time.Now()
+		rr.now = func() time.Time { return now }
+
+		for i := 0; i < limit.Burst; i++ {
+			wait, err := rr.TakeToken(context.Background(), "key", limit)
+			assert.NoError(t, err)
+			assert.Zero(t, wait, "Запросы в пределах burst должны проходить")
+		}
+
+		wait, err := rr.TakeToken(context.Background(), "key", limit)
+		assert.NoError(t, err)
+		assert.Equal(t, 500*time.Millisecond, wait)
+
+		wait, err = rr.TakeToken(context.Background(), "other", limit)
+		assert.NoError(t, err)
+		assert.Zero(t, wait, "У каждого ключа своя корзина")
+
+		now = now.Add(500 * time.Millisecond)
+		wait, err = rr.TakeToken(context.Background(), "key", limit)
+		assert.NoError(t, err)
+		assert.Zero(t, wait, "Токен должен восстановиться")
+	})
+
+	t.Run("ok, full buckets are swept", func(t *testing.T) {
+		rr := NewRateLimitRepository(sweepEvery)
+		now := time.Now()
+		rr.now = func() time.Time { return now }
+
+		for i := 0; i < sweepEvery-1; i++ {
+			_, err := rr.TakeToken(context.Background(), strconv.Itoa(i), limit)
+			assert.NoError(t, err)
+		}
+		now = now.Add(time.Minute)
+		_, err := rr.TakeToken(context.Background(), "last", limit)
+		assert.NoError(t, err)
+		assert.Len(t, rr.buckets, 1)
+	})
+
+	t.Run("ok, least recently used bucket is forgotten", func(t *testing.T) {
+		rr := NewRateLimitRepository(2)
+		now := time.Now()
+		rr.now = func() time.Time { return now }
+
+		for i := 0; i < limit.Burst; i++ {
+			_, err := rr.TakeToken(context.Background(), "key", limit)
+			assert.NoError(t, err)
+		}
+		_, err := rr.TakeToken(context.Background(), "first", limit)
+		assert.NoError(t, err)
+		_, err = rr.TakeToken(context.Background(), "key", limit)
+		assert.NoError(t, err)
+		_, err = rr.TakeToken(context.Background(), "second", limit)
+		assert.NoError(t, err)
+
+		assert.Len(t, rr.buckets, 2, "Корзин не должно быть больше capacity")
+		assert.NotContains(t, rr.buckets, "first", "Должна быть забыта давно не использованная корзина")
+		wait, err := rr.TakeToken(context.Background(), "key", limit)
+		assert.NoError(t, err)
+		assert.Positive(t, wait, "Используемая корзина должна остаться пустой")
+	})
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// sweepEvery is how many tokens are taken between the deletions of the full buckets
+const sweepEvery = 1024
+
+// RateLimitRepository shares the buckets between the replicas. It uses the same GCRA as the in-memory one,
+// the clock of the database is used so the replicas agree on the time.
+type RateLimitRepository struct {
+	pool  *pgxpool.Pool
+	taken atomic.Int64
+}
+
+func NewRateLimitRepository(pool *pgxpool.Pool) *RateLimitRepository {
+	return &RateLimitRepository{
+		pool: pool,
+	}
+}
+
+// takeTokenQuery moves the arrival time only if the request fits into the burst, nothing is returned otherwise
+const takeTokenQuery = `
+insert into db.public.rate_limit_buckets as b (key, tat)
+values ($1, now() + $2 * interval '1 second')
+on conflict (key) do update set tat = greatest(b.tat, now()) + $2 * interval '1 second'
+where greatest(b.tat, now()) + $2 * interval '1 second' - now() <= $3 * interval '1 second'
+returning tat;`
+
+const waitQuery = `select extract(epoch from greatest(tat, now()) - now()) from db.public.rate_limit_buckets where key=$1`
+
+const sweepQuery = `delete from db.public.rate_limit_buckets where tat < now()`
+
+func (r *RateLimitRepository) TakeToken(ctx context.Context, key string, limit domain.RateLimit) (time.Duration, error) {
+	interval := limit.Interval().Seconds()
+	burst := float64(limit.Burst) * interval
+
+	var tat time.Time
+	err := r.pool.QueryRow(ctx, takeTokenQuery, key, interval, burst).Scan(&tat)
+	if err == nil {
+		if r.taken.Add(1)%sweepEvery == 0 {
+			if _, err := r.pool.Exec(ctx, sweepQuery); err != nil {
+				return 0, fmt.Errorf("can't delete full buckets: %w", err)
+			}
+		}
+		return 0, ctx.Err()
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("can't take token: %w", err)
+	}
+
+	var ahead float64
+	if err := r.pool.QueryRow(ctx, waitQuery, key).Scan(&ahead); err != nil {
+		return 0, fmt.Errorf("can't scan bucket: %w", err)
+	}
+	wait := time.Duration((ahead + interval - burst) * float64(time.Second))
+	// the bucket may have refilled between the queries, the caller must still wait for something
+	return max(wait, time.Millisecond), ctx.Err()
+}
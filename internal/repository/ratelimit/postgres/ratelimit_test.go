@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/pkg/pg_test"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RateLimitTestSuite struct {
+	suite.Suite
+	testDbInstance *pgxpool.Pool
+	testDB         *pg_test.TestDatabase
+
+	repo *RateLimitRepository
+}
+
+func (suite *RateLimitTestSuite) SetupSuite() {
+	suite.testDB = pg_test.SetupTestDatabase()
+	suite.testDbInstance = suite.testDB.DbInstance
+
+	suite.repo = NewRateLimitRepository(suite.testDbInstance)
+}
+
+func (suite *RateLimitTestSuite) TearDownSuite() {
+	suite.testDB.TearDown()
+}
+
+func (suite *RateLimitTestSuite) TestRateLimitRepository_TakeToken() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	limit := domain.RateLimit{Rate: 0.1, Burst: 2}
+	for i := 0; i < limit.Burst; i++ {
+		wait, err := suite.repo.TakeToken(ctx, "sensor:0000000001", limit)
+		assert.Nil(suite.T(), err)
+		assert.Zero(suite.T(), wait)
+	}
+
+	wait, err := suite.repo.TakeToken(ctx, "sensor:0000000001", limit)
+	assert.Nil(suite.T(), err)
+	assert.InDelta(suite.T(), 10*time.Second, wait, float64(time.Second))
+
+	wait, err = suite.repo.TakeToken(ctx, "sensor:0000000002", limit)
+	assert.Nil(suite.T(), err)
+	assert.Zero(suite.T(), wait)
+}
+
+func TestRateLimitTestSuite(t *testing.T) {
+	suite.Run(t, new(RateLimitTestSuite))
+}
@@ -0,0 +1,86 @@
+package inmemory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"slices"
+	"sync"
+)
+
+var ErrNilScenePointer = errors.New("nil scene is provided")
+
+type SceneRepository struct {
+	storage map[int64]domain.Scene
+	lastID  int64
+	m       sync.RWMutex
+}
+
+func NewSceneRepository() *SceneRepository {
+	return &SceneRepository{storage: map[int64]domain.Scene{}, m: sync.RWMutex{}}
+}
+
+func (r *SceneRepository) SaveScene(ctx context.Context, scene *domain.Scene) error {
+	if scene == nil {
+		return ErrNilScenePointer
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	if scene.ID == 0 {
+		r.lastID++
+		scene.ID = r.lastID
+	} else if _, ok := r.storage[scene.ID]; !ok {
+		return usecase.ErrSceneNotFound
+	}
+	// the commands are copied, so the caller can't change the stored scene
+	stored := *scene
+	stored.Commands = slices.Clone(scene.Commands)
+	r.storage[scene.ID] = stored
+	return nil
+}
+
+func (r *SceneRepository) GetScenes(ctx context.Context) ([]domain.Scene, error) {
+	r.m.RLock()
+	scenes := make([]domain.Scene, 0, len(r.storage))
+	for _, s := range r.storage {
+		scenes = append(scenes, s)
+	}
+	r.m.RUnlock()
+
+	slices.SortFunc(scenes, func(a, b domain.Scene) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return scenes, ctx.Err()
+}
+
+func (r *SceneRepository) GetSceneByID(ctx context.Context, id int64) (*domain.Scene, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	r.m.RLock()
+	defer r.m.RUnlock()
+	s, ok := r.storage[id]
+	if !ok {
+		return nil, usecase.ErrSceneNotFound
+	}
+	return &s, nil
+}
+
+func (r *SceneRepository) DeleteScene(ctx context.Context, id int64) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+	if _, ok := r.storage[id]; !ok {
+		return usecase.ErrSceneNotFound
+	}
+	delete(r.storage, id)
+	return nil
+}
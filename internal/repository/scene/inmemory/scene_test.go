@@ -0,0 +1,43 @@
+package inmemory
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSceneRepository(t *testing.T) {
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		sr := NewSceneRepository()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		assert.ErrorIs(t, sr.SaveScene(ctx, &domain.Scene{Name: "good_night"}), context.Canceled)
+		_, err := sr.GetScenes(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("ok, scene lifecycle", func(t *testing.T) {
+		sr := NewSceneRepository()
+		ctx := context.Background()
+		scene := &domain.Scene{Name: "good_night", Commands: []domain.SceneCommand{{ActuatorID: 1, Action: "switch"}}}
+		require.NoError(t, sr.SaveScene(ctx, scene))
+		assert.Equal(t, int64(1), scene.ID)
+
+		scene.Commands[0].Action = "lock"
+		got, err := sr.GetSceneByID(ctx, scene.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "switch", got.Commands[0].Action, "Сохраненная сцена не должна меняться вместе с исходной")
+
+		assert.ErrorIs(t, sr.SaveScene(ctx, &domain.Scene{ID: 100500, Name: "morning"}), usecase.ErrSceneNotFound)
+
+		require.NoError(t, sr.DeleteScene(ctx, scene.ID))
+		_, err = sr.GetSceneByID(ctx, scene.ID)
+		assert.ErrorIs(t, err, usecase.ErrSceneNotFound)
+		assert.ErrorIs(t, sr.DeleteScene(ctx, scene.ID), usecase.ErrSceneNotFound)
+	})
+}
@@ -0,0 +1,119 @@
+package inmemory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"slices"
+	"sync"
+	"time"
+)
+
+var ErrNilSchedulePointer = errors.New("nil schedule is provided")
+
+type ScheduleRepository struct {
+	storage map[int64]domain.Schedule
+	lastID  int64
+	m       sync.RWMutex
+}
+
+func NewScheduleRepository() *ScheduleRepository {
+	return &ScheduleRepository{storage: map[int64]domain.Schedule{}, m: sync.RWMutex{}}
+}
+
+func (r *ScheduleRepository) SaveSchedule(ctx context.Context, schedule *domain.Schedule) error {
+	if schedule == nil {
+		return ErrNilSchedulePointer
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	if schedule.ID == 0 {
+		r.lastID++
+		schedule.ID = r.lastID
+	}
+	r.storage[schedule.ID] = *schedule
+	return nil
+}
+
+func (r *ScheduleRepository) GetSchedules(ctx context.Context) ([]domain.Schedule, error) {
+	r.m.RLock()
+	schedules := make([]domain.Schedule, 0, len(r.storage))
+	for _, s := range r.storage {
+		schedules = append(schedules, s)
+	}
+	r.m.RUnlock()
+
+	slices.SortFunc(schedules, func(a, b domain.Schedule) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return schedules, ctx.Err()
+}
+
+func (r *ScheduleRepository) GetScheduleByID(ctx context.Context, id int64) (*domain.Schedule, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	r.m.RLock()
+	defer r.m.RUnlock()
+	s, ok := r.storage[id]
+	if !ok {
+		return nil, usecase.ErrScheduleNotFound
+	}
+	return &s, nil
+}
+
+func (r *ScheduleRepository) DeleteSchedule(ctx context.Context, id int64) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+	if _, ok := r.storage[id]; !ok {
+		return usecase.ErrScheduleNotFound
+	}
+	delete(r.storage, id)
+	return nil
+}
+
+func (r *ScheduleRepository) GetDueSchedules(ctx context.Context, now time.Time) ([]*domain.Schedule, error) {
+	r.m.RLock()
+	due := make([]*domain.Schedule, 0)
+	for _, s := range r.storage {
+		if s.Enabled && !s.NextRun.After(now) {
+			due = append(due, &s)
+		}
+	}
+	r.m.RUnlock()
+
+	slices.SortFunc(due, func(a, b *domain.Schedule) int {
+		return cmp.Or(a.NextRun.Compare(b.NextRun), cmp.Compare(a.ID, b.ID))
+	})
+	return due, ctx.Err()
+}
+
+func (r *ScheduleRepository) AdvanceSchedule(ctx context.Context, schedule *domain.Schedule, prevRun time.Time) (bool, error) {
+	if schedule == nil {
+		return false, ErrNilSchedulePointer
+	}
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	stored, ok := r.storage[schedule.ID]
+	if !ok {
+		return false, usecase.ErrScheduleNotFound
+	}
+	if !stored.NextRun.Equal(prevRun) {
+		return false, nil
+	}
+	r.storage[schedule.ID] = *schedule
+	return true, nil
+}
@@ -0,0 +1,64 @@
+package inmemory
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleRepository(t *testing.T) {
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		sr := NewScheduleRepository()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		assert.ErrorIs(t, sr.SaveSchedule(ctx, &domain.Schedule{Name: "morning"}), context.Canceled)
+		_, err := sr.GetDueSchedules(ctx, time.Now())
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("ok, due schedules are claimed once", func(t *testing.T) {
+		sr := NewScheduleRepository()
+		ctx := context.Background()
+		now := time.Now()
+		later := &domain.Schedule{Name: "later", Enabled: true, NextRun: now.Add(-time.Minute)}
+		earlier := &domain.Schedule{Name: "earlier", Enabled: true, NextRun: now.Add(-time.Hour)}
+		future := &domain.Schedule{Name: "future", Enabled: true, NextRun: now.Add(time.Hour)}
+		disabled := &domain.Schedule{Name: "disabled", NextRun: now.Add(-time.Hour)}
+		for _, s := range []*domain.Schedule{later, earlier, future, disabled} {
+			require.NoError(t, sr.SaveSchedule(ctx, s))
+		}
+
+		due, err := sr.GetDueSchedules(ctx, now)
+		require.NoError(t, err)
+		assert.Equal(t, []*domain.Schedule{earlier, later}, due, "Расписания должны идти по времени запуска")
+
+		prevRun := earlier.NextRun
+		advanced := *earlier
+		advanced.NextRun = now.Add(time.Hour)
+		claimed, err := sr.AdvanceSchedule(ctx, &advanced, prevRun)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+
+		claimed, err = sr.AdvanceSchedule(ctx, &advanced, prevRun)
+		require.NoError(t, err)
+		assert.False(t, claimed, "Запуск можно занять только один раз")
+
+		got, err := sr.GetScheduleByID(ctx, earlier.ID)
+		require.NoError(t, err)
+		assert.Equal(t, advanced, *got)
+
+		require.NoError(t, sr.DeleteSchedule(ctx, earlier.ID))
+		_, err = sr.GetScheduleByID(ctx, earlier.ID)
+		assert.ErrorIs(t, err, usecase.ErrScheduleNotFound)
+		_, err = sr.AdvanceSchedule(ctx, &advanced, advanced.NextRun)
+		assert.ErrorIs(t, err, usecase.ErrScheduleNotFound, "Удаленное расписание не должно создаваться заново")
+		_, err = sr.GetScheduleByID(ctx, earlier.ID)
+		assert.ErrorIs(t, err, usecase.ErrScheduleNotFound)
+	})
+}
@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SceneRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewSceneRepository(pool *pgxpool.Pool) *SceneRepository {
+	return &SceneRepository{
+		pool: pool,
+	}
+}
+
+// sceneCommand is how a command of the scene is kept in the jsonb column
+type sceneCommand struct {
+	ActuatorID int64          `json:"actuator_id"`
+	Action     string         `json:"action"`
+	Args       domain.Payload `json:"args,omitempty"`
+}
+
+const saveSceneQuery = `
+insert into db.public.scenes (name, description, commands)
+values ($1, $2, $3)
+returning id;`
+
+const updateSceneQuery = `
+update db.public.scenes
+set name = $2, description = $3, commands = $4
+where id = $1`
+
+func (r *SceneRepository) SaveScene(ctx context.Context, scene *domain.Scene) error {
+	commands := make([]sceneCommand, 0, len(scene.Commands))
+	for _, c := range scene.Commands {
+		commands = append(commands, sceneCommand(c))
+	}
+	encoded, err := json.Marshal(commands)
+	if err != nil {
+		return fmt.Errorf("can't encode commands: %w", err)
+	}
+
+	if scene.ID != 0 {
+		tag, err := r.pool.Exec(ctx, updateSceneQuery, scene.ID, scene.Name, scene.Description, encoded)
+		if err != nil {
+			return fmt.Errorf("can't update scene: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return usecase.ErrSceneNotFound
+		}
+		return ctx.Err()
+	}
+
+	if err := r.pool.QueryRow(ctx, saveSceneQuery, scene.Name, scene.Description, encoded).Scan(&scene.ID); err != nil {
+		return fmt.Errorf("can't insert scene: %w", err)
+	}
+	return ctx.Err()
+}
+
+const getScenesQuery = `
+select id, name, description, commands
+from db.public.scenes
+order by id;`
+
+func (r *SceneRepository) GetScenes(ctx context.Context) ([]domain.Scene, error) {
+	rows, err := r.pool.Query(ctx, getScenesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("can't query scenes: %w", err)
+	}
+	defer rows.Close()
+
+	scenes := make([]domain.Scene, 0)
+	for rows.Next() {
+		s, err := scanScene(rows)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan scene: %w", err)
+		}
+		scenes = append(scenes, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't read scenes: %w", err)
+	}
+	return scenes, ctx.Err()
+}
+
+const getSceneByIDQuery = `
+select id, name, description, commands
+from db.public.scenes
+where id = $1;`
+
+func (r *SceneRepository) GetSceneByID(ctx context.Context, id int64) (*domain.Scene, error) {
+	s, err := scanScene(r.pool.QueryRow(ctx, getSceneByIDQuery, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, usecase.ErrSceneNotFound
+		}
+		return nil, fmt.Errorf("can't scan scene: %w", err)
+	}
+	return &s, ctx.Err()
+}
+
+const deleteSceneQuery = `delete from db.public.scenes where id = $1`
+
+func (r *SceneRepository) DeleteScene(ctx context.Context, id int64) error {
+	tag, err := r.pool.Exec(ctx, deleteSceneQuery, id)
+	if err != nil {
+		return fmt.Errorf("can't delete scene: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return usecase.ErrSceneNotFound
+	}
+	return ctx.Err()
+}
+
+func scanScene(row pgx.Row) (domain.Scene, error) {
+	var s domain.Scene
+	var encoded []byte
+	if err := row.Scan(&s.ID, &s.Name, &s.Description, &encoded); err != nil {
+		return s, err
+	}
+
+	var commands []sceneCommand
+	if err := json.Unmarshal(encoded, &commands); err != nil {
+		return s, fmt.Errorf("can't decode commands: %w", err)
+	}
+	s.Commands = make([]domain.SceneCommand, 0, len(commands))
+	for _, c := range commands {
+		s.Commands = append(s.Commands, domain.SceneCommand(c))
+	}
+	return s, nil
+}
@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"homework/pkg/pg_test"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type SceneTestSuite struct {
+	suite.Suite
+	testDbInstance *pgxpool.Pool
+	testDB         *pg_test.TestDatabase
+
+	repo *SceneRepository
+}
+
+func (suite *SceneTestSuite) SetupSuite() {
+	suite.testDB = pg_test.SetupTestDatabase()
+	suite.testDbInstance = suite.testDB.DbInstance
+
+	suite.repo = NewSceneRepository(suite.testDbInstance)
+}
+
+func (suite *SceneTestSuite) TearDownSuite() {
+	suite.testDB.TearDown()
+}
+
+func (suite *SceneTestSuite) TestSceneRepository_Lifecycle() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	scene := domain.Scene{Name: "good_night", Description: "спокойной ночи", Commands: []domain.SceneCommand{
+		{ActuatorID: 1, Action: "switch", Args: domain.IntPayload(0)},
+		{ActuatorID: 2, Action: "set", Args: domain.Payload{{Channel: "temperature", Value: domain.Decimal{Units: 185, Scale: 1}, Unit: "°C"}}},
+	}}
+	assert.Nil(suite.T(), suite.repo.SaveScene(ctx, &scene))
+	assert.NotZero(suite.T(), scene.ID)
+
+	got, err := suite.repo.GetSceneByID(ctx, scene.ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), scene, *got)
+
+	scene.Commands = scene.Commands[:1]
+	assert.Nil(suite.T(), suite.repo.SaveScene(ctx, &scene))
+	scenes, err := suite.repo.GetScenes(ctx)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []domain.Scene{scene}, scenes)
+
+	assert.ErrorIs(suite.T(), suite.repo.SaveScene(ctx, &domain.Scene{ID: 100500, Name: "morning"}), usecase.ErrSceneNotFound)
+
+	assert.Nil(suite.T(), suite.repo.DeleteScene(ctx, scene.ID))
+	_, err = suite.repo.GetSceneByID(ctx, scene.ID)
+	assert.ErrorIs(suite.T(), err, usecase.ErrSceneNotFound)
+	assert.ErrorIs(suite.T(), suite.repo.DeleteScene(ctx, scene.ID), usecase.ErrSceneNotFound)
+}
+
+func TestSceneTestSuite(t *testing.T) {
+	suite.Run(t, new(SceneTestSuite))
+}
@@ -0,0 +1,198 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ScheduleRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewScheduleRepository(pool *pgxpool.Pool) *ScheduleRepository {
+	return &ScheduleRepository{
+		pool: pool,
+	}
+}
+
+const saveScheduleQuery = `
+insert into db.public.schedules (name, scene_id, kind, cron, offset_seconds, at, missed, enabled,
+                                 next_run, last_run, last_error, created_at)
+values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+returning id;`
+
+// updateScheduleQuery changes only what the runs change
+const updateScheduleQuery = `
+update db.public.schedules
+set enabled = $2, next_run = $3, last_run = $4, last_error = $5
+where id = $1`
+
+func (r *ScheduleRepository) SaveSchedule(ctx context.Context, schedule *domain.Schedule) error {
+	if schedule.ID != 0 {
+		_, err := r.pool.Exec(ctx, updateScheduleQuery, schedule.ID, schedule.Enabled,
+			nullTime(schedule.NextRun), nullTime(schedule.LastRun), schedule.LastError)
+		if err != nil {
+			return fmt.Errorf("can't update schedule: %w", err)
+		}
+		return ctx.Err()
+	}
+
+	err := r.pool.QueryRow(ctx, saveScheduleQuery, schedule.Name, schedule.SceneID, schedule.Kind, schedule.Cron,
+		int64(schedule.Offset/time.Second), nullTime(schedule.At), schedule.Missed, schedule.Enabled,
+		nullTime(schedule.NextRun), nullTime(schedule.LastRun), schedule.LastError, schedule.CreatedAt).Scan(&schedule.ID)
+	if err != nil {
+		return fmt.Errorf("can't insert schedule: %w", err)
+	}
+	return ctx.Err()
+}
+
+// nullTime keeps the moments that are not set as NULL
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+const scheduleColumns = `id, name, scene_id, kind, cron, offset_seconds, at, missed, enabled,
+       next_run, last_run, last_error, created_at`
+
+const getSchedulesQuery = `
+select ` + scheduleColumns + `
+from db.public.schedules
+order by id;`
+
+func (r *ScheduleRepository) GetSchedules(ctx context.Context) ([]domain.Schedule, error) {
+	schedules, err := r.querySchedules(ctx, getSchedulesQuery)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]domain.Schedule, 0, len(schedules))
+	for _, s := range schedules {
+		values = append(values, *s)
+	}
+	return values, ctx.Err()
+}
+
+const getScheduleByIDQuery = `
+select ` + scheduleColumns + `
+from db.public.schedules
+where id = $1;`
+
+func (r *ScheduleRepository) GetScheduleByID(ctx context.Context, id int64) (*domain.Schedule, error) {
+	s, err := scanSchedule(r.pool.QueryRow(ctx, getScheduleByIDQuery, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, usecase.ErrScheduleNotFound
+		}
+		return nil, fmt.Errorf("can't scan schedule: %w", err)
+	}
+	return s, ctx.Err()
+}
+
+const deleteScheduleQuery = `delete from db.public.schedules where id = $1`
+
+func (r *ScheduleRepository) DeleteSchedule(ctx context.Context, id int64) error {
+	tag, err := r.pool.Exec(ctx, deleteScheduleQuery, id)
+	if err != nil {
+		return fmt.Errorf("can't delete schedule: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return usecase.ErrScheduleNotFound
+	}
+	return ctx.Err()
+}
+
+const getDueSchedulesQuery = `
+select ` + scheduleColumns + `
+from db.public.schedules
+where enabled and next_run <= $1
+order by next_run, id;`
+
+func (r *ScheduleRepository) GetDueSchedules(ctx context.Context, now time.Time) ([]*domain.Schedule, error) {
+	schedules, err := r.querySchedules(ctx, getDueSchedulesQuery, now)
+	if err != nil {
+		return nil, err
+	}
+	return schedules, ctx.Err()
+}
+
+// advanceScheduleQuery updates the schedule only if no other replica has moved its next run,
+// the next run of a disabled schedule is NULL
+const advanceScheduleQuery = `
+update db.public.schedules
+set enabled = $3, next_run = $4, last_run = $5, last_error = $6
+where id = $1 and next_run is not distinct from $2`
+
+const scheduleExistsQuery = `select exists (select 1 from db.public.schedules where id = $1)`
+
+func (r *ScheduleRepository) AdvanceSchedule(ctx context.Context, schedule *domain.Schedule, prevRun time.Time) (bool, error) {
+	tag, err := r.pool.Exec(ctx, advanceScheduleQuery, schedule.ID, nullTime(prevRun), schedule.Enabled,
+		nullTime(schedule.NextRun), nullTime(schedule.LastRun), schedule.LastError)
+	if err != nil {
+		return false, fmt.Errorf("can't advance schedule: %w", err)
+	}
+	if tag.RowsAffected() > 0 {
+		return true, ctx.Err()
+	}
+
+	var exists bool
+	if err := r.pool.QueryRow(ctx, scheduleExistsQuery, schedule.ID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("can't check schedule: %w", err)
+	}
+	if !exists {
+		return false, usecase.ErrScheduleNotFound
+	}
+	return false, ctx.Err()
+}
+
+func (r *ScheduleRepository) querySchedules(ctx context.Context, query string, args ...any) ([]*domain.Schedule, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("can't query schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := make([]*domain.Schedule, 0)
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan schedule: %w", err)
+		}
+		schedules = append(schedules, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't read schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+func scanSchedule(row pgx.Row) (*domain.Schedule, error) {
+	var s domain.Schedule
+	var offset int64
+	var at, nextRun, lastRun *time.Time
+	err := row.Scan(&s.ID, &s.Name, &s.SceneID, &s.Kind, &s.Cron, &offset, &at, &s.Missed, &s.Enabled,
+		&nextRun, &lastRun, &s.LastError, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Offset = time.Duration(offset) * time.Second
+	if at != nil {
+		s.At = *at
+	}
+	if nextRun != nil {
+		s.NextRun = *nextRun
+	}
+	if lastRun != nil {
+		s.LastRun = *lastRun
+	}
+	return &s, nil
+}
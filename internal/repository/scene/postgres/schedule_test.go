@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"homework/pkg/pg_test"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ScheduleTestSuite struct {
+	suite.Suite
+	testDbInstance *pgxpool.Pool
+	testDB         *pg_test.TestDatabase
+
+	repo  *ScheduleRepository
+	scene domain.Scene
+}
+
+func (suite *ScheduleTestSuite) SetupSuite() {
+	suite.testDB = pg_test.SetupTestDatabase()
+	suite.testDbInstance = suite.testDB.DbInstance
+
+	suite.repo = NewScheduleRepository(suite.testDbInstance)
+	suite.scene = domain.Scene{Name: "good_night", Commands: []domain.SceneCommand{{ActuatorID: 1, Action: "switch"}}}
+	suite.Require().NoError(NewSceneRepository(suite.testDbInstance).SaveScene(context.Background(), &suite.scene))
+}
+
+func (suite *ScheduleTestSuite) TearDownSuite() {
+	suite.testDB.TearDown()
+}
+
+func (suite *ScheduleTestSuite) TestScheduleRepository_Lifecycle() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	schedule := domain.Schedule{
+		Name:      "evening",
+		SceneID:   suite.scene.ID,
+		Kind:      domain.ScheduleKindSunset,
+		Offset:    -30 * time.Minute,
+		Missed:    domain.MissedPolicyRun,
+		Enabled:   true,
+		NextRun:   now.Add(-time.Second),
+		CreatedAt: now,
+	}
+	assert.Nil(suite.T(), suite.repo.SaveSchedule(ctx, &schedule))
+	assert.NotZero(suite.T(), schedule.ID)
+
+	got, err := suite.repo.GetScheduleByID(ctx, schedule.ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), schedule, *got)
+
+	due, err := suite.repo.GetDueSchedules(ctx, now)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []*domain.Schedule{&schedule}, due)
+
+	prevRun := schedule.NextRun
+	schedule.NextRun, schedule.LastRun = now.Add(24*time.Hour), now
+	claimed, err := suite.repo.AdvanceSchedule(ctx, &schedule, prevRun)
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), claimed)
+	claimed, err = suite.repo.AdvanceSchedule(ctx, &schedule, prevRun)
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), claimed, "Запуск можно занять только один раз")
+
+	due, err = suite.repo.GetDueSchedules(ctx, now)
+	assert.Nil(suite.T(), err)
+	assert.Empty(suite.T(), due)
+
+	schedules, err := suite.repo.GetSchedules(ctx)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []domain.Schedule{schedule}, schedules)
+
+	assert.Nil(suite.T(), suite.repo.DeleteSchedule(ctx, schedule.ID))
+	_, err = suite.repo.GetScheduleByID(ctx, schedule.ID)
+	assert.ErrorIs(suite.T(), err, usecase.ErrScheduleNotFound)
+	_, err = suite.repo.AdvanceSchedule(ctx, &schedule, schedule.NextRun)
+	assert.ErrorIs(suite.T(), err, usecase.ErrScheduleNotFound, "Удаленное расписание не должно создаваться заново")
+}
+
+func TestScheduleTestSuite(t *testing.T) {
+	suite.Run(t, new(ScheduleTestSuite))
+}
@@ -0,0 +1,40 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrNoMigrations = errors.New("no migrations have been applied")
+
+// SchemaRepository reads the state of the schema left by golang-migrate
+type SchemaRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewSchemaRepository(pool *pgxpool.Pool) *SchemaRepository {
+	return &SchemaRepository{
+		pool: pool,
+	}
+}
+
+func (r *SchemaRepository) Ping(ctx context.Context) error {
+	return r.pool.Ping(ctx)
+}
+
+const getVersionQuery = `select version, dirty from db.public.schema_migrations limit 1`
+
+// GetVersion returns the applied migration version, dirty is set if the last migration has failed midway
+func (r *SchemaRepository) GetVersion(ctx context.Context) (version int64, dirty bool, err error) {
+	if err := r.pool.QueryRow(ctx, getVersionQuery).Scan(&version, &dirty); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, false, ErrNoMigrations
+		}
+		return 0, false, fmt.Errorf("can't scan schema version: %w", err)
+	}
+	return version, dirty, ctx.Err()
+}
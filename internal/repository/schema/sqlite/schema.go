@@ -0,0 +1,104 @@
+// Package sqlite opens the embedded database and keeps its schema up to date.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"homework/migrations"
+
+	"github.com/golang-migrate/migrate/v4"
+	migrateSqlite "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	_ "modernc.org/sqlite" // the pure go driver, the binary is built without cgo
+)
+
+var ErrNoMigrations = errors.New("no migrations have been applied")
+
+// pragmas are set on every connection: the readers don't block the writer in the WAL mode,
+// and a writer waits for another one instead of failing at once
+const pragmas = "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)&_txlock=immediate"
+
+// Open opens the database file, creating it if there is none, and applies the migrations it lacks.
+// Unlike postgres the database belongs to this process alone, so nobody else is there to migrate it.
+func Open(ctx context.Context, path string) (*sql.DB, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, err
+		}
+	}
+	if err := migrateUp(path); err != nil {
+		return nil, fmt.Errorf("can't migrate %s: %w", path, err)
+	}
+
+	db, err := sql.Open("sqlite", path+pragmas)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("can't open %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// migrateUp uses a connection of its own, the migration closes it when it is done
+func migrateUp(path string) error {
+	db, err := sql.Open("sqlite", path+pragmas)
+	if err != nil {
+		return err
+	}
+	driver, err := migrateSqlite.WithInstance(db, &migrateSqlite.Config{})
+	if err != nil {
+		db.Close()
+		return err
+	}
+	src, err := iofs.New(migrations.SQLite, ".")
+	if err != nil {
+		driver.Close()
+		return err
+	}
+	m, err := migrate.NewWithInstance("iofs", src, "sqlite", driver)
+	if err != nil {
+		driver.Close()
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// SchemaRepository reads the state of the schema left by golang-migrate
+type SchemaRepository struct {
+	db *sql.DB
+}
+
+func NewSchemaRepository(db *sql.DB) *SchemaRepository {
+	return &SchemaRepository{
+		db: db,
+	}
+}
+
+func (r *SchemaRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+const getVersionQuery = `select version, dirty from schema_migrations limit 1`
+
+// GetVersion returns the applied migration version, dirty is set if the last migration has failed midway
+func (r *SchemaRepository) GetVersion(ctx context.Context) (version int64, dirty bool, err error) {
+	if err := r.db.QueryRowContext(ctx, getVersionQuery).Scan(&version, &dirty); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, ErrNoMigrations
+		}
+		return 0, false, fmt.Errorf("can't scan schema version: %w", err)
+	}
+	return version, dirty, ctx.Err()
+}
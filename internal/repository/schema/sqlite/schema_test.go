@@ -0,0 +1,41 @@
+package sqlite
+
+import (
+	"context"
+	"homework/migrations"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen(t *testing.T) {
+	t.Run("ok, database is created and migrated", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "data", "smarthome.db")
+		db, err := Open(context.Background(), path)
+		require.NoError(t, err)
+		defer db.Close()
+
+		version, dirty, err := NewSchemaRepository(db).GetVersion(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, migrations.LatestSQLite(), version)
+		assert.False(t, dirty)
+	})
+
+	t.Run("ok, reopen keeps the data", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "smarthome.db")
+		db, err := Open(context.Background(), path)
+		require.NoError(t, err)
+		_, err = db.Exec(`insert into users (name) values ('user')`)
+		require.NoError(t, err)
+		require.NoError(t, db.Close())
+
+		db, err = Open(context.Background(), path)
+		require.NoError(t, err)
+		defer db.Close()
+		var name string
+		require.NoError(t, db.QueryRow(`select name from users`).Scan(&name))
+		assert.Equal(t, "user", name)
+	})
+}
@@ -1,86 +1,183 @@
 package inmemory
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"homework/internal/domain"
 	"homework/internal/usecase"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var ErrNilSensorPointer = errors.New("nil sensor is provided")
 
+// shardCount is the number of the shards of both indexes, a power of two so the id takes the shard by a mask
+const shardCount = 64
+
 type SensorSerialNumber string
 
-type SensorRepository struct {
-	storage map[SensorSerialNumber]*domain.Sensor
+// sensorShard keeps the sensors whose serial numbers hash to it
+type sensorShard struct {
 	m       sync.RWMutex
+	storage map[SensorSerialNumber]domain.Sensor
+}
+
+// idShard is the secondary index of the sensors whose ids fall to it
+type idShard struct {
+	m      sync.RWMutex
+	serial map[int64]SensorSerialNumber
+}
+
+// SensorRepository keeps the sensors in shards by the serial number, so the events of different sensors
+// don't contend for one lock, and indexes them by id. The sensors are copied on the way in and out,
+// a caller can't change a stored one behind the repository.
+type SensorRepository struct {
+	shards [shardCount]sensorShard
+	ids    [shardCount]idShard
+	// lastID is the id of the last inserted sensor, the ids are assigned as a sequence of the database does
+	lastID atomic.Int64
+	// record, if set, writes the change of a saved sensor to the feed
+	record func(domain.Change)
+}
+
+func NewSensorRepository(options ...func(*SensorRepository)) *SensorRepository {
+	r := &SensorRepository{}
+	for i := range r.shards {
+		r.shards[i].storage = map[SensorSerialNumber]domain.Sensor{}
+		r.ids[i].serial = map[int64]SensorSerialNumber{}
+	}
+	for _, o := range options {
+		o(r)
+	}
+	return r
+}
+
+// WithSensorChanges records every save into the change feed, under the lock of the sensor,
+// so the changes of a sensor are recorded in the order they are made
+func WithSensorChanges(record func(domain.Change)) func(*SensorRepository) {
+	return func(r *SensorRepository) {
+		r.record = record
+	}
+}
+
+// shard takes the shard by the FNV-1a hash of the serial number, hashed in place as it is on every event
+func (r *SensorRepository) shard(sn string) *sensorShard {
+	h := uint32(2166136261)
+	for i := 0; i < len(sn); i++ {
+		h ^= uint32(sn[i])
+		h *= 16777619
+	}
+	return &r.shards[h%shardCount]
 }
 
-func NewSensorRepository() *SensorRepository {
-	return &SensorRepository{storage: map[SensorSerialNumber]*domain.Sensor{}, m: sync.RWMutex{}}
+func (r *SensorRepository) idShard(id int64) *idShard {
+	return &r.ids[uint64(id)%shardCount]
 }
 
+// SaveSensor inserts a new sensor, assigning it the id and the registration time unless they are set,
+// or updates the stored one. The update keeps the id and the registration time of the stored sensor.
 func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor) error {
 	if sensor == nil {
 		return ErrNilSensorPointer
 	}
-	sensor.RegisteredAt = time.Now()
-	r.m.Lock()
-	r.storage[SensorSerialNumber(sensor.SerialNumber)] = sensor
-	r.m.Unlock()
+	sn := SensorSerialNumber(sensor.SerialNumber)
+	shard := r.shard(sensor.SerialNumber)
+
+	shard.m.Lock()
+	if old, has := shard.storage[sn]; has {
+		sensor.ID, sensor.RegisteredAt = old.ID, old.RegisteredAt
+		shard.storage[sn] = sensor.Clone()
+		if r.record != nil {
+			r.record(domain.SensorChange(*sensor))
+		}
+		shard.m.Unlock()
+		return ctx.Err()
+	}
+
+	if sensor.ID <= 0 {
+		sensor.ID = r.lastID.Add(1)
+	} else {
+		r.advanceID(sensor.ID)
+	}
+	// a sensor restored from a copy keeps its registration time
+	if sensor.RegisteredAt.IsZero() {
+		sensor.RegisteredAt = time.Now()
+	}
+	shard.storage[sn] = sensor.Clone()
+	if r.record != nil {
+		r.record(domain.SensorChange(*sensor))
+	}
+	shard.m.Unlock()
+
+	// the sensor is found by its serial number a moment before it is found by its id, as after a commit
+	ids := r.idShard(sensor.ID)
+	ids.m.Lock()
+	ids.serial[sensor.ID] = sn
+	ids.m.Unlock()
+
 	return ctx.Err()
 }
 
-func (r *SensorRepository) GetSensors(ctx context.Context) ([]domain.Sensor, error) {
-	sensors := make([]domain.Sensor, 0, len(r.storage))
+// advanceID moves the sequence past an id given by the caller, so it is never assigned again
+func (r *SensorRepository) advanceID(id int64) {
+	for {
+		last := r.lastID.Load()
+		if last >= id || r.lastID.CompareAndSwap(last, id) {
+			return
+		}
+	}
+}
 
-	r.m.RLock()
-	defer r.m.RUnlock()
-	for _, v := range r.storage {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
-			sensors = append(sensors, *v)
+// GetSensors returns the copies of the sensors ordered by id
+func (r *SensorRepository) GetSensors(ctx context.Context) ([]domain.Sensor, error) {
+	sensors := make([]domain.Sensor, 0)
+	for i := range r.shards {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		shard := &r.shards[i]
+		shard.m.RLock()
+		for _, v := range shard.storage {
+			sensors = append(sensors, v.Clone())
 		}
+		shard.m.RUnlock()
 	}
+	slices.SortFunc(sensors, func(a, b domain.Sensor) int { return cmp.Compare(a.ID, b.ID) })
 
 	return sensors, ctx.Err()
 }
 
 func (r *SensorRepository) GetSensorByID(ctx context.Context, id int64) (*domain.Sensor, error) {
-	r.m.RLock()
-	defer r.m.RUnlock()
-	for _, v := range r.storage {
-		select {
-		case <-ctx.Done():
+	ids := r.idShard(id)
+	ids.m.RLock()
+	sn, has := ids.serial[id]
+	ids.m.RUnlock()
+	if !has {
+		if ctx.Err() != nil {
 			return nil, ctx.Err()
-		default:
-			if v.ID == id {
-				return v, ctx.Err()
-			}
 		}
-	}
-
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
 		return nil, usecase.ErrSensorNotFound
 	}
+	return r.GetSensorBySerialNumber(ctx, string(sn))
 }
 
 func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn string) (*domain.Sensor, error) {
-	r.m.RLock()
-	defer r.m.RUnlock()
-	sensor, has := r.storage[SensorSerialNumber(sn)]
+	shard := r.shard(sn)
+	shard.m.RLock()
+	sensor, has := shard.storage[SensorSerialNumber(sn)]
+	if has {
+		sensor = sensor.Clone()
+	}
+	shard.m.RUnlock()
+
 	if !has {
 		if ctx.Err() != nil {
 			return nil, ctx.Err()
 		}
 		return nil, usecase.ErrSensorNotFound
 	}
-	return sensor, ctx.Err()
+	return &sensor, ctx.Err()
 }
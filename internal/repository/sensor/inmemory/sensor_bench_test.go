@@ -0,0 +1,132 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// globalLockRepository is the design the repository had before the sharding: one lock over all the sensors
+// and a scan for the id. It is kept to compare with.
+type globalLockRepository struct {
+	storage map[SensorSerialNumber]*domain.Sensor
+	m       sync.RWMutex
+}
+
+func (r *globalLockRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor) error {
+	r.m.Lock()
+	r.storage[SensorSerialNumber(sensor.SerialNumber)] = sensor
+	r.m.Unlock()
+	return ctx.Err()
+}
+
+func (r *globalLockRepository) GetSensorByID(ctx context.Context, id int64) (*domain.Sensor, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	for _, v := range r.storage {
+		if v.ID == id {
+			return v, ctx.Err()
+		}
+	}
+	return nil, usecase.ErrSensorNotFound
+}
+
+func (r *globalLockRepository) GetSensorBySerialNumber(ctx context.Context, sn string) (*domain.Sensor, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	sensor, has := r.storage[SensorSerialNumber(sn)]
+	if !has {
+		return nil, usecase.ErrSensorNotFound
+	}
+	return sensor, ctx.Err()
+}
+
+type sensorStore interface {
+	SaveSensor(ctx context.Context, sensor *domain.Sensor) error
+	GetSensorByID(ctx context.Context, id int64) (*domain.Sensor, error)
+	GetSensorBySerialNumber(ctx context.Context, sn string) (*domain.Sensor, error)
+}
+
+var sensorStores = []struct {
+	name string
+	new  func() sensorStore
+}{
+	{"global_lock", func() sensorStore { return &globalLockRepository{storage: map[SensorSerialNumber]*domain.Sensor{}} }},
+	{"sharded", func() sensorStore { return NewSensorRepository() }},
+}
+
+func fillSensors(b *testing.B, r sensorStore, n int) []string {
+	serials := make([]string, n)
+	for i := range serials {
+		serials[i] = fmt.Sprintf("%010d", i+1)
+		sensor := &domain.Sensor{ID: int64(i + 1), SerialNumber: serials[i], Type: domain.SensorTypeADC, CurrentState: domain.IntPayload(0)}
+		if err := r.SaveSensor(context.Background(), sensor); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return serials
+}
+
+// BenchmarkIngestParallel does what an event does to its sensor: finds it by the serial number
+// and saves its new state and activity
+func BenchmarkIngestParallel(b *testing.B) {
+	for _, sensors := range []int{16, 1024} {
+		for _, store := range sensorStores {
+			b.Run(fmt.Sprintf("%s/sensors=%d", store.name, sensors), func(b *testing.B) {
+				r := store.new()
+				serials := fillSensors(b, r, sensors)
+				ctx := context.Background()
+				var seq atomic.Int64
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						n := seq.Add(1)
+						sensor, err := r.GetSensorBySerialNumber(ctx, serials[n%int64(sensors)])
+						if err != nil {
+							b.Fatal(err)
+						}
+						// the old design hands out the stored sensor, so it is changed as a copy,
+						// as a caller that doesn't race with the others would do
+						updated := *sensor
+						updated.CurrentState = domain.IntPayload(n)
+						updated.LastActivity = time.Now()
+						if err := r.SaveSensor(ctx, &updated); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+			})
+		}
+	}
+}
+
+// BenchmarkGetSensorByIDParallel reads the sensors by id, as the api and the websockets do
+func BenchmarkGetSensorByIDParallel(b *testing.B) {
+	for _, sensors := range []int{16, 1024} {
+		for _, store := range sensorStores {
+			b.Run(fmt.Sprintf("%s/sensors=%d", store.name, sensors), func(b *testing.B) {
+				r := store.new()
+				fillSensors(b, r, sensors)
+				ctx := context.Background()
+				var seq atomic.Int64
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						if _, err := r.GetSensorByID(ctx, seq.Add(1)%int64(sensors)+1); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+			})
+		}
+	}
+}
@@ -47,7 +47,7 @@ func TestSensorRepository_SaveSensor(t *testing.T) {
 		sensor := &domain.Sensor{
 			SerialNumber: "12345678",
 			Type:         domain.SensorTypeContactClosure,
-			CurrentState: 0,
+			CurrentState: domain.IntPayload(0),
 			Description:  "sensor description",
 			IsActive:     true,
 		}
@@ -77,7 +77,7 @@ func TestSensorRepository_SaveSensor(t *testing.T) {
 			sensor := &domain.Sensor{
 				SerialNumber: strconv.Itoa(r.IntN(1000000000)),
 				Type:         domain.SensorTypeADC,
-				CurrentState: 0,
+				CurrentState: domain.IntPayload(0),
 				Description:  fmt.Sprintf("some description %d", i),
 				IsActive:     false,
 			}
@@ -93,6 +93,64 @@ func TestSensorRepository_SaveSensor(t *testing.T) {
 		sensors, err := sr.GetSensors(ctx)
 		assert.NoError(t, err)
 		assert.Len(t, sensors, 1000)
+		for i, s := range sensors {
+			assert.Equal(t, int64(i+1), s.ID, "Идентификаторы должны идти подряд")
+		}
+	})
+
+	t.Run("ok, update keeps id and registration time", func(t *testing.T) {
+		sr := NewSensorRepository()
+		ctx := context.Background()
+
+		sensor := &domain.Sensor{SerialNumber: "0000000001", Type: domain.SensorTypeADC, Description: "old"}
+		assert.NoError(t, sr.SaveSensor(ctx, sensor))
+		registeredAt := sensor.RegisteredAt
+
+		update := &domain.Sensor{SerialNumber: "0000000001", Type: domain.SensorTypeADC, Description: "new"}
+		assert.NoError(t, sr.SaveSensor(ctx, update))
+		assert.Equal(t, sensor.ID, update.ID)
+		assert.Equal(t, registeredAt, update.RegisteredAt)
+
+		actual, err := sr.GetSensorByID(ctx, sensor.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "new", actual.Description)
+	})
+
+	t.Run("ok, given id is not assigned again", func(t *testing.T) {
+		sr := NewSensorRepository()
+		ctx := context.Background()
+
+		assert.NoError(t, sr.SaveSensor(ctx, &domain.Sensor{ID: 10, SerialNumber: "0000000001"}))
+		sensor := &domain.Sensor{SerialNumber: "0000000002"}
+		assert.NoError(t, sr.SaveSensor(ctx, sensor))
+		assert.Equal(t, int64(11), sensor.ID)
+	})
+
+	t.Run("ok, stored sensor is not shared with caller", func(t *testing.T) {
+		sr := NewSensorRepository()
+		ctx := context.Background()
+
+		sensor := &domain.Sensor{
+			SerialNumber: "0000000001",
+			Type:         domain.SensorTypeADC,
+			CurrentState: domain.IntPayload(1),
+			Calibration:  []domain.Calibration{{Channel: domain.DefaultChannel, Table: []domain.CalibrationPoint{{}}}},
+		}
+		assert.NoError(t, sr.SaveSensor(ctx, sensor))
+		sensor.Description = "changed after save"
+		sensor.CurrentState[0].Channel = "changed"
+
+		got, err := sr.GetSensorByID(ctx, sensor.ID)
+		assert.NoError(t, err)
+		got.IsActive = true
+		got.Calibration[0].Table[0].Raw = domain.Decimal{Units: 5}
+
+		again, err := sr.GetSensorBySerialNumber(ctx, sensor.SerialNumber)
+		assert.NoError(t, err)
+		assert.Empty(t, again.Description, "Изменение после сохранения не должно попасть в репозиторий")
+		assert.Equal(t, domain.DefaultChannel, again.CurrentState[0].Channel, "Показания должны копироваться")
+		assert.False(t, again.IsActive, "Изменение полученного датчика не должно попасть в репозиторий")
+		assert.Equal(t, domain.Decimal{}, again.Calibration[0].Table[0].Raw, "Калибровки должны копироваться")
 	})
 }
 
@@ -135,7 +193,7 @@ func TestSensorRepository_GetSensors(t *testing.T) {
 			sensor := &domain.Sensor{
 				SerialNumber: strconv.Itoa(r.IntN(1000000000)),
 				Type:         domain.SensorTypeADC,
-				CurrentState: 0,
+				CurrentState: domain.IntPayload(0),
 				Description:  fmt.Sprintf("some description %d", i),
 				IsActive:     false,
 			}
@@ -211,7 +269,7 @@ func TestSensorRepository_GetSensorBySerialNumber(t *testing.T) {
 		sensor := &domain.Sensor{
 			SerialNumber: "12345678",
 			Type:         domain.SensorTypeContactClosure,
-			CurrentState: 0,
+			CurrentState: domain.IntPayload(0),
 			Description:  "sensor description",
 			IsActive:     true,
 		}
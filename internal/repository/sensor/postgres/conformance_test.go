@@ -0,0 +1,23 @@
+package postgres
+
+import (
+	"context"
+	"homework/internal/repository/conformance"
+	"homework/internal/usecase"
+	"homework/pkg/pg_test"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSensorRepository_Conformance(t *testing.T) {
+	testDB := pg_test.SetupTestDatabase()
+	defer testDB.TearDown()
+
+	suite.Run(t, &conformance.SensorSuite{NewRepository: func(t *testing.T) usecase.SensorRepository {
+		_, err := testDB.DbInstance.Exec(context.Background(), `truncate db.public.sensors restart identity`)
+		require.NoError(t, err)
+		return NewSensorRepository(testDB.DbInstance)
+	}})
+}
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"homework/internal/domain"
@@ -10,6 +11,8 @@ import (
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	changePostgres "homework/internal/repository/change/postgres"
 )
 
 type SensorRepository struct {
@@ -22,31 +25,122 @@ func NewSensorRepository(pool *pgxpool.Pool) *SensorRepository {
 	}
 }
 
+// calibrationRow is a calibration as it is kept in the calibration column
+type calibrationRow struct {
+	Channel string                `json:"channel"`
+	Gain    json.Number           `json:"gain"`
+	Offset  json.Number           `json:"offset"`
+	Table   []calibrationPointRow `json:"table,omitempty"`
+	Unit    string                `json:"unit,omitempty"`
+}
+
+type calibrationPointRow struct {
+	Raw   json.Number `json:"raw"`
+	Value json.Number `json:"value"`
+}
+
+func encodeCalibration(cs []domain.Calibration) ([]byte, error) {
+	if len(cs) == 0 {
+		return nil, nil
+	}
+	rows := make([]calibrationRow, len(cs))
+	for i, c := range cs {
+		rows[i] = calibrationRow{
+			Channel: c.Channel,
+			Gain:    json.Number(c.Gain.String()),
+			Offset:  json.Number(c.Offset.String()),
+			Unit:    c.Unit,
+		}
+		for _, p := range c.Table {
+			rows[i].Table = append(rows[i].Table, calibrationPointRow{Raw: json.Number(p.Raw.String()), Value: json.Number(p.Value.String())})
+		}
+	}
+	return json.Marshal(rows)
+}
+
+func decodeCalibration(data []byte) ([]domain.Calibration, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var rows []calibrationRow
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, err
+	}
+
+	var cs []domain.Calibration
+	for _, row := range rows {
+		c := domain.Calibration{Channel: row.Channel, Unit: row.Unit}
+		var err error
+		if c.Gain, err = domain.ParseDecimal(row.Gain.String()); err != nil {
+			return nil, err
+		}
+		if c.Offset, err = domain.ParseDecimal(row.Offset.String()); err != nil {
+			return nil, err
+		}
+		for _, p := range row.Table {
+			point := domain.CalibrationPoint{}
+			if point.Raw, err = domain.ParseDecimal(p.Raw.String()); err != nil {
+				return nil, err
+			}
+			if point.Value, err = domain.ParseDecimal(p.Value.String()); err != nil {
+				return nil, err
+			}
+			c.Table = append(c.Table, point)
+		}
+		cs = append(cs, c)
+	}
+	return cs, nil
+}
+
 const saveSensorQuery = `
-insert into db.public.sensors (serial_number, type, current_state, description, is_active, registered_at, last_activity) 
-values ($1, $2, $3, $4, $5, $6, $7)`
+insert into db.public.sensors (serial_number, type, current_state, description, is_active, registered_at, last_activity, current_readings, calibration, expression) 
+values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+returning *`
 
 const updateSensorQuery = `
 update db.public.sensors 
-set current_state = $2, description = $3, is_active = $4, last_activity = $5
-where serial_number = $1`
+set current_state = $2, description = $3, is_active = $4, last_activity = $5, current_readings = $6, calibration = $7
+where serial_number = $1
+returning *`
 
+// SaveSensor updates the stored sensor or inserts a new one, the stored sensor is recorded
+// into the change feed in the same transaction
 func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor) error {
-	var err error
-	if _, e := r.GetSensorBySerialNumber(ctx, sensor.SerialNumber); e == nil {
-		_, err = r.pool.Exec(ctx, updateSensorQuery, sensor.SerialNumber, sensor.CurrentState, sensor.Description, sensor.IsActive, sensor.LastActivity)
-	} else {
-		sensor.RegisteredAt = time.Now()
-		_, err = r.pool.Exec(ctx, saveSensorQuery, sensor.SerialNumber, sensor.Type, sensor.CurrentState, sensor.Description, sensor.IsActive, sensor.RegisteredAt, sensor.LastActivity)
+	calibration, err := encodeCalibration(sensor.Calibration)
+	if err != nil {
+		return fmt.Errorf("can't encode calibration: %w", err)
 	}
 
+	tx, err := r.pool.Begin(ctx)
 	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	stored := domain.Sensor{}
+	err = scanSensor(&stored, tx.QueryRow(ctx, updateSensorQuery, sensor.SerialNumber, sensor.CurrentState.Int(), sensor.Description, sensor.IsActive,
+		sensor.LastActivity, sensor.CurrentState, calibration))
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = scanSensor(&stored, tx.QueryRow(ctx, saveSensorQuery, sensor.SerialNumber, sensor.Type, sensor.CurrentState.Int(), sensor.Description, sensor.IsActive,
+			time.Now(), sensor.LastActivity, sensor.CurrentState, calibration, sensor.Expression))
+	}
+	if err != nil {
+		return err
+	}
+	if err := changePostgres.Record(ctx, tx, domain.SensorChange(stored)); err != nil {
 		return err
 	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("can't commit sensor: %w", err)
+	}
+	// the stored id and registration time are written back to the sensor either way
+	sensor.ID, sensor.RegisteredAt = stored.ID, stored.RegisteredAt
 	return ctx.Err()
 }
 
-const getSensorsQuery = `select * from db.public.sensors;`
+const getSensorsQuery = `select * from db.public.sensors order by id;`
 
 func (r *SensorRepository) GetSensors(ctx context.Context) ([]domain.Sensor, error) {
 	rows, err := r.pool.Query(ctx, getSensorsQuery)
@@ -69,7 +163,25 @@ func (r *SensorRepository) GetSensors(ctx context.Context) ([]domain.Sensor, err
 }
 
 func scanSensor(sensor *domain.Sensor, row pgx.Row) error {
-	return row.Scan(&sensor.ID, &sensor.SerialNumber, &sensor.Type, &sensor.CurrentState, &sensor.Description, &sensor.IsActive, &sensor.RegisteredAt, &sensor.LastActivity)
+	var state int64
+	var readings, calibration []byte
+	err := row.Scan(&sensor.ID, &sensor.SerialNumber, &sensor.Type, &state, &sensor.Description, &sensor.IsActive, &sensor.RegisteredAt, &sensor.LastActivity, &readings, &calibration, &sensor.Expression)
+	if err != nil {
+		return err
+	}
+	if sensor.Calibration, err = decodeCalibration(calibration); err != nil {
+		return fmt.Errorf("can't decode calibration: %w", err)
+	}
+
+	// the sensors saved before the readings have only the integer state
+	if readings == nil {
+		sensor.CurrentState = domain.IntPayload(state)
+		return nil
+	}
+	if err := json.Unmarshal(readings, &sensor.CurrentState); err != nil {
+		return fmt.Errorf("can't decode current readings: %w", err)
+	}
+	return nil
 }
 
 const getSensorByIDQuery = `select * from db.public.sensors where id=$1`
@@ -42,7 +42,7 @@ func (suite *SensorTestSuite) TestSensorRepository_SaveSensor() {
 	err := suite.repo.SaveSensor(ctx, &domain.Sensor{
 		SerialNumber: sn,
 		Type:         domain.SensorTypeADC,
-		CurrentState: 1,
+		CurrentState: domain.IntPayload(1),
 		Description:  "test_desc",
 		IsActive:     true,
 		RegisteredAt: now,
@@ -60,7 +60,7 @@ func (suite *SensorTestSuite) TestSensorRepository_SaveSensor() {
 		ID:           sensor.ID,
 		SerialNumber: sn,
 		Type:         domain.SensorTypeADC,
-		CurrentState: 2,
+		CurrentState: domain.IntPayload(2),
 		Description:  "test_desc_2",
 		IsActive:     false,
 		RegisteredAt: time.Now().Truncate(time.Microsecond).In(time.UTC),
@@ -88,7 +88,7 @@ func (suite *SensorTestSuite) TestSensorRepository_GetSensors() {
 	newSensor := domain.Sensor{
 		SerialNumber: sn,
 		Type:         domain.SensorTypeADC,
-		CurrentState: 1,
+		CurrentState: domain.IntPayload(1),
 		Description:  "test_desc_3",
 		IsActive:     true,
 		RegisteredAt: time.Now().Truncate(time.Microsecond).In(time.UTC),
@@ -119,7 +119,7 @@ func (suite *SensorTestSuite) TestSensorRepository_GetSensorByID() {
 	newSensor := domain.Sensor{
 		SerialNumber: sn,
 		Type:         domain.SensorTypeADC,
-		CurrentState: 1,
+		CurrentState: domain.IntPayload(1),
 		Description:  "test_desc_4",
 		IsActive:     true,
 		RegisteredAt: time.Now().Truncate(time.Microsecond).In(time.UTC),
@@ -150,7 +150,7 @@ func (suite *SensorTestSuite) TestSensorRepository_GetSensorBySerialNumber() {
 	newSensor := domain.Sensor{
 		SerialNumber: sn,
 		Type:         domain.SensorTypeADC,
-		CurrentState: 1,
+		CurrentState: domain.IntPayload(1),
 		Description:  "test_desc_5",
 		IsActive:     true,
 		RegisteredAt: time.Now().Truncate(time.Microsecond).In(time.UTC),
@@ -169,6 +169,70 @@ func (suite *SensorTestSuite) TestSensorRepository_GetSensorBySerialNumber() {
 	assert.Equal(suite.T(), newSensor, *sensor)
 }
 
+func (suite *SensorTestSuite) TestSensorRepository_Calibration() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sn := "3987654321"
+
+	newSensor := domain.Sensor{
+		SerialNumber: sn,
+		Type:         domain.SensorTypeADC,
+		CurrentState: domain.IntPayload(1),
+		Description:  "test_desc_6",
+		IsActive:     true,
+		RegisteredAt: time.Now().Truncate(time.Microsecond).In(time.UTC),
+		LastActivity: time.Now().Truncate(time.Microsecond).In(time.UTC),
+		Calibration: []domain.Calibration{
+			{Channel: "value", Gain: domain.Decimal{Units: 5, Scale: 1}, Offset: domain.Decimal{Units: -50}, Unit: "°C"},
+			{Channel: "humidity", Table: []domain.CalibrationPoint{
+				{Raw: domain.Decimal{Units: 0}, Value: domain.Decimal{Units: 0}},
+				{Raw: domain.Decimal{Units: 4095}, Value: domain.Decimal{Units: 100}},
+			}},
+		},
+	}
+	err := suite.repo.SaveSensor(ctx, &newSensor)
+
+	assert.Nil(suite.T(), err)
+
+	sensor, err := suite.repo.GetSensorBySerialNumber(ctx, sn)
+
+	newSensor.ID = sensor.ID
+	newSensor.RegisteredAt = sensor.RegisteredAt
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), newSensor, *sensor)
+}
+
+func (suite *SensorTestSuite) TestSensorRepository_Virtual() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sn := "4987654321"
+
+	newSensor := domain.Sensor{
+		SerialNumber: sn,
+		Type:         domain.SensorTypeVirtual,
+		CurrentState: domain.Payload{{Channel: domain.DefaultChannel, Value: domain.Decimal{Units: 215, Scale: 1}, Unit: "°C"}},
+		Description:  "test_desc_7",
+		IsActive:     true,
+		RegisteredAt: time.Now().Truncate(time.Microsecond).In(time.UTC),
+		LastActivity: time.Now().Truncate(time.Microsecond).In(time.UTC),
+		Expression:   "avg(#1, #2)",
+	}
+	err := suite.repo.SaveSensor(ctx, &newSensor)
+
+	assert.Nil(suite.T(), err)
+
+	sensor, err := suite.repo.GetSensorBySerialNumber(ctx, sn)
+
+	newSensor.ID = sensor.ID
+	newSensor.RegisteredAt = sensor.RegisteredAt
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), newSensor, *sensor)
+}
+
 func TestSensorTestSuite(t *testing.T) {
 	suite.Run(t, new(SensorTestSuite))
 }
@@ -0,0 +1,257 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"time"
+
+	changeSqlite "homework/internal/repository/change/sqlite"
+)
+
+var ErrNilSensorPointer = errors.New("nil sensor is provided")
+
+type SensorRepository struct {
+	db *sql.DB
+}
+
+func NewSensorRepository(db *sql.DB) *SensorRepository {
+	return &SensorRepository{
+		db: db,
+	}
+}
+
+// calibrationRow is a calibration as it is kept in the calibration column, the same json as in postgres
+type calibrationRow struct {
+	Channel string                `json:"channel"`
+	Gain    json.Number           `json:"gain"`
+	Offset  json.Number           `json:"offset"`
+	Table   []calibrationPointRow `json:"table,omitempty"`
+	Unit    string                `json:"unit,omitempty"`
+}
+
+type calibrationPointRow struct {
+	Raw   json.Number `json:"raw"`
+	Value json.Number `json:"value"`
+}
+
+func encodeCalibration(cs []domain.Calibration) (any, error) {
+	if len(cs) == 0 {
+		return nil, nil
+	}
+	rows := make([]calibrationRow, len(cs))
+	for i, c := range cs {
+		rows[i] = calibrationRow{
+			Channel: c.Channel,
+			Gain:    json.Number(c.Gain.String()),
+			Offset:  json.Number(c.Offset.String()),
+			Unit:    c.Unit,
+		}
+		for _, p := range c.Table {
+			rows[i].Table = append(rows[i].Table, calibrationPointRow{Raw: json.Number(p.Raw.String()), Value: json.Number(p.Value.String())})
+		}
+	}
+	data, err := json.Marshal(rows)
+	return string(data), err
+}
+
+func decodeCalibration(data sql.NullString) ([]domain.Calibration, error) {
+	if !data.Valid {
+		return nil, nil
+	}
+	var rows []calibrationRow
+	if err := json.Unmarshal([]byte(data.String), &rows); err != nil {
+		return nil, err
+	}
+
+	var cs []domain.Calibration
+	for _, row := range rows {
+		c := domain.Calibration{Channel: row.Channel, Unit: row.Unit}
+		var err error
+		if c.Gain, err = domain.ParseDecimal(row.Gain.String()); err != nil {
+			return nil, err
+		}
+		if c.Offset, err = domain.ParseDecimal(row.Offset.String()); err != nil {
+			return nil, err
+		}
+		for _, p := range row.Table {
+			point := domain.CalibrationPoint{}
+			if point.Raw, err = domain.ParseDecimal(p.Raw.String()); err != nil {
+				return nil, err
+			}
+			if point.Value, err = domain.ParseDecimal(p.Value.String()); err != nil {
+				return nil, err
+			}
+			c.Table = append(c.Table, point)
+		}
+		cs = append(cs, c)
+	}
+	return cs, nil
+}
+
+// encodeTime keeps the time as unix nanoseconds, the zero time as NULL
+func encodeTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UnixNano()
+}
+
+func decodeTime(ns sql.NullInt64) time.Time {
+	if !ns.Valid {
+		return time.Time{}
+	}
+	return time.Unix(0, ns.Int64).UTC()
+}
+
+func encodeReadings(p domain.Payload) (any, error) {
+	if len(p) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(p)
+	return string(data), err
+}
+
+// saveSensorQuery inserts the sensor or updates the one with the same serial number. The update keeps
+// the id, the type and the registration time, the stored sensor is returned either way.
+const saveSensorQuery = `
+insert into sensors (id, serial_number, type, current_state, description, is_active, registered_at, last_activity, current_readings, calibration, expression)
+values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+on conflict (serial_number) do update
+set current_state = excluded.current_state, description = excluded.description, is_active = excluded.is_active,
+    last_activity = excluded.last_activity, current_readings = excluded.current_readings, calibration = excluded.calibration
+returning ` + sensorColumns
+
+// SaveSensor inserts a new sensor, assigning it the id and the registration time unless they are set,
+// or updates the stored one. The update keeps the id and the registration time of the stored sensor.
+// The stored sensor is recorded into the change feed in the same transaction.
+func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor) error {
+	if sensor == nil {
+		return ErrNilSensorPointer
+	}
+	calibration, err := encodeCalibration(sensor.Calibration)
+	if err != nil {
+		return fmt.Errorf("can't encode calibration: %w", err)
+	}
+	readings, err := encodeReadings(sensor.CurrentState)
+	if err != nil {
+		return fmt.Errorf("can't encode current readings: %w", err)
+	}
+
+	var id any
+	if sensor.ID > 0 {
+		id = sensor.ID
+	}
+	registeredAt := sensor.RegisteredAt
+	if registeredAt.IsZero() {
+		registeredAt = time.Now()
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	stored := domain.Sensor{}
+	err = scanSensor(&stored, tx.QueryRowContext(ctx, saveSensorQuery, id, sensor.SerialNumber, sensor.Type, sensor.CurrentState.Int(),
+		sensor.Description, sensor.IsActive, encodeTime(registeredAt), encodeTime(sensor.LastActivity), readings, calibration, sensor.Expression))
+	if err != nil {
+		return err
+	}
+	if err := changeSqlite.Record(ctx, tx, domain.SensorChange(stored)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("can't commit sensor: %w", err)
+	}
+	sensor.ID, sensor.RegisteredAt = stored.ID, stored.RegisteredAt
+	return ctx.Err()
+}
+
+const sensorColumns = `id, serial_number, type, current_state, description, is_active, registered_at, last_activity, current_readings, calibration, expression`
+
+const getSensorsQuery = `select ` + sensorColumns + ` from sensors order by id`
+
+func (r *SensorRepository) GetSensors(ctx context.Context) ([]domain.Sensor, error) {
+	rows, err := r.db.QueryContext(ctx, getSensorsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("can't select sensors %w", err)
+	}
+	defer rows.Close()
+
+	sensors := make([]domain.Sensor, 0)
+	for rows.Next() {
+		sensor := domain.Sensor{}
+		if err := scanSensor(&sensor, rows); err != nil {
+			return nil, fmt.Errorf("can't scan sensor: %w", err)
+		}
+
+		sensors = append(sensors, sensor)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't read sensors: %w", err)
+	}
+
+	return sensors, ctx.Err()
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSensor(sensor *domain.Sensor, row scanner) error {
+	var state int64
+	var registeredAt, lastActivity sql.NullInt64
+	var readings, calibration sql.NullString
+	err := row.Scan(&sensor.ID, &sensor.SerialNumber, &sensor.Type, &state, &sensor.Description, &sensor.IsActive,
+		&registeredAt, &lastActivity, &readings, &calibration, &sensor.Expression)
+	if err != nil {
+		return err
+	}
+	sensor.RegisteredAt, sensor.LastActivity = decodeTime(registeredAt), decodeTime(lastActivity)
+	if sensor.Calibration, err = decodeCalibration(calibration); err != nil {
+		return fmt.Errorf("can't decode calibration: %w", err)
+	}
+
+	if !readings.Valid {
+		sensor.CurrentState = domain.IntPayload(state)
+		return nil
+	}
+	if err := json.Unmarshal([]byte(readings.String), &sensor.CurrentState); err != nil {
+		return fmt.Errorf("can't decode current readings: %w", err)
+	}
+	return nil
+}
+
+const getSensorByIDQuery = `select ` + sensorColumns + ` from sensors where id = ?`
+
+func (r *SensorRepository) GetSensorByID(ctx context.Context, id int64) (*domain.Sensor, error) {
+	row := r.db.QueryRowContext(ctx, getSensorByIDQuery, id)
+	return getSensor(ctx, row)
+}
+
+const getSensorBySerialNumberQuery = `select ` + sensorColumns + ` from sensors where serial_number = ?`
+
+func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn string) (*domain.Sensor, error) {
+	row := r.db.QueryRowContext(ctx, getSensorBySerialNumberQuery, sn)
+	return getSensor(ctx, row)
+}
+
+func getSensor(ctx context.Context, row *sql.Row) (*domain.Sensor, error) {
+	sensor := &domain.Sensor{}
+	if err := scanSensor(sensor, row); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, usecase.ErrSensorNotFound
+		}
+		return nil, fmt.Errorf("can't scan sensor: %w", err)
+	}
+
+	return sensor, ctx.Err()
+}
@@ -0,0 +1,151 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"path/filepath"
+	"testing"
+	"time"
+
+	schemaSqlite "homework/internal/repository/schema/sqlite"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type SensorTestSuite struct {
+	suite.Suite
+	db *sql.DB
+
+	repo *SensorRepository
+}
+
+func (suite *SensorTestSuite) SetupSuite() {
+	db, err := schemaSqlite.Open(context.Background(), filepath.Join(suite.T().TempDir(), "test.db"))
+	suite.Require().NoError(err)
+	suite.db = db
+
+	suite.repo = NewSensorRepository(db)
+}
+
+func (suite *SensorTestSuite) TearDownSuite() {
+	suite.db.Close()
+}
+
+func (suite *SensorTestSuite) TestSensorRepository_SaveSensor() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sn := "1234567890"
+	registeredAt := time.Now().Add(-time.Hour).UTC()
+	newSensor := domain.Sensor{
+		SerialNumber: sn,
+		Type:         domain.SensorTypeADC,
+		CurrentState: domain.IntPayload(1),
+		Description:  "test_desc",
+		IsActive:     true,
+		RegisteredAt: registeredAt,
+	}
+	assert.Nil(suite.T(), suite.repo.SaveSensor(ctx, &newSensor))
+	assert.Positive(suite.T(), newSensor.ID, "Новому датчику присваивается id")
+
+	sensor, err := suite.repo.GetSensorBySerialNumber(ctx, sn)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), newSensor, *sensor)
+
+	updatedSensor := domain.Sensor{
+		SerialNumber: sn,
+		Type:         domain.SensorTypeADC,
+		CurrentState: domain.IntPayload(2),
+		Description:  "test_desc_2",
+		IsActive:     false,
+		LastActivity: time.Now().UTC(),
+	}
+	// update old sensor
+	assert.Nil(suite.T(), suite.repo.SaveSensor(ctx, &updatedSensor))
+	assert.Equal(suite.T(), newSensor.ID, updatedSensor.ID, "Обновление сохраняет id")
+	assert.True(suite.T(), registeredAt.Equal(updatedSensor.RegisteredAt), "Обновление сохраняет время регистрации")
+
+	sensor, err = suite.repo.GetSensorByID(ctx, newSensor.ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), updatedSensor, *sensor)
+}
+
+func (suite *SensorTestSuite) TestSensorRepository_GetSensors() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	first := domain.Sensor{SerialNumber: "0987654321", Type: domain.SensorTypeADC, CurrentState: domain.IntPayload(1)}
+	second := domain.Sensor{SerialNumber: "0987654322", Type: domain.SensorTypeContactClosure, CurrentState: domain.IntPayload(0)}
+	assert.Nil(suite.T(), suite.repo.SaveSensor(ctx, &first))
+	assert.Nil(suite.T(), suite.repo.SaveSensor(ctx, &second))
+	assert.Greater(suite.T(), second.ID, first.ID)
+
+	sensors, err := suite.repo.GetSensors(ctx)
+	assert.Nil(suite.T(), err)
+	assert.Contains(suite.T(), sensors, first)
+	assert.Contains(suite.T(), sensors, second)
+	for i := 1; i < len(sensors); i++ {
+		assert.Less(suite.T(), sensors[i-1].ID, sensors[i].ID, "Датчики упорядочены по id")
+	}
+}
+
+func (suite *SensorTestSuite) TestSensorRepository_NotFound() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := suite.repo.GetSensorByID(ctx, 100500)
+	assert.ErrorIs(suite.T(), err, usecase.ErrSensorNotFound)
+	_, err = suite.repo.GetSensorBySerialNumber(ctx, "0000000000")
+	assert.ErrorIs(suite.T(), err, usecase.ErrSensorNotFound)
+	assert.ErrorIs(suite.T(), suite.repo.SaveSensor(ctx, nil), ErrNilSensorPointer)
+}
+
+func (suite *SensorTestSuite) TestSensorRepository_Calibration() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	newSensor := domain.Sensor{
+		SerialNumber: "3987654321",
+		Type:         domain.SensorTypeADC,
+		CurrentState: domain.Payload{{Channel: domain.DefaultChannel, Value: domain.Decimal{Units: 215, Scale: 1}, Unit: "°C"}},
+		Description:  "test_desc_6",
+		IsActive:     true,
+		LastActivity: time.Now().UTC(),
+		Calibration: []domain.Calibration{
+			{Channel: "value", Gain: domain.Decimal{Units: 5, Scale: 1}, Offset: domain.Decimal{Units: -50}, Unit: "°C"},
+			{Channel: "humidity", Table: []domain.CalibrationPoint{
+				{Raw: domain.Decimal{Units: 0}, Value: domain.Decimal{Units: 0}},
+				{Raw: domain.Decimal{Units: 4095}, Value: domain.Decimal{Units: 100}},
+			}},
+		},
+	}
+	assert.Nil(suite.T(), suite.repo.SaveSensor(ctx, &newSensor))
+
+	sensor, err := suite.repo.GetSensorBySerialNumber(ctx, newSensor.SerialNumber)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), newSensor, *sensor)
+}
+
+func (suite *SensorTestSuite) TestSensorRepository_Virtual() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	newSensor := domain.Sensor{
+		SerialNumber: "4987654321",
+		Type:         domain.SensorTypeVirtual,
+		CurrentState: domain.IntPayload(0),
+		Expression:   "avg(#1, #2)",
+	}
+	assert.Nil(suite.T(), suite.repo.SaveSensor(ctx, &newSensor))
+
+	sensor, err := suite.repo.GetSensorByID(ctx, newSensor.ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), newSensor, *sensor)
+}
+
+func TestSensorTestSuite(t *testing.T) {
+	suite.Run(t, new(SensorTestSuite))
+}
@@ -0,0 +1,85 @@
+package inmemory
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// SensorTypeRepository starts with the builtin types. It knows nothing about the sensors,
+// so the usecase checks that a deleted type is not in use.
+type SensorTypeRepository struct {
+	storage map[domain.SensorType]domain.SensorTypeSpec
+	m       sync.RWMutex
+}
+
+func NewSensorTypeRepository() *SensorTypeRepository {
+	storage := make(map[domain.SensorType]domain.SensorTypeSpec, len(domain.BuiltinSensorTypes))
+	for _, t := range domain.BuiltinSensorTypes {
+		storage[t.Name] = t
+	}
+	return &SensorTypeRepository{storage: storage, m: sync.RWMutex{}}
+}
+
+func (r *SensorTypeRepository) SaveSensorType(ctx context.Context, sensorType domain.SensorTypeSpec) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	sensorType.Channels = slices.Clone(sensorType.Channels)
+	if err := sensorType.CompileSerial(); err != nil {
+		return false, err
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	_, existed := r.storage[sensorType.Name]
+	r.storage[sensorType.Name] = sensorType
+	return !existed, nil
+}
+
+func (r *SensorTypeRepository) GetSensorTypes(ctx context.Context) ([]domain.SensorTypeSpec, error) {
+	r.m.RLock()
+	types := make([]domain.SensorTypeSpec, 0, len(r.storage))
+	for _, t := range r.storage {
+		t.Channels = slices.Clone(t.Channels)
+		types = append(types, t)
+	}
+	r.m.RUnlock()
+
+	slices.SortFunc(types, func(a, b domain.SensorTypeSpec) int {
+		return strings.Compare(string(a.Name), string(b.Name))
+	})
+	return types, ctx.Err()
+}
+
+func (r *SensorTypeRepository) GetSensorType(ctx context.Context, name domain.SensorType) (*domain.SensorTypeSpec, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	t, has := r.storage[name]
+	if !has {
+		return nil, usecase.ErrSensorTypeNotFound
+	}
+	t.Channels = slices.Clone(t.Channels)
+	return &t, nil
+}
+
+func (r *SensorTypeRepository) DeleteSensorType(ctx context.Context, name domain.SensorType) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if _, has := r.storage[name]; !has {
+		return usecase.ErrSensorTypeNotFound
+	}
+	delete(r.storage, name)
+	return nil
+}
@@ -0,0 +1,75 @@
+package inmemory
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSensorTypeRepository(t *testing.T) {
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		tr := NewSensorTypeRepository()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := tr.SaveSensorType(ctx, domain.SensorTypeSpec{Name: "door"})
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = tr.GetSensorType(ctx, domain.SensorTypeADC)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("fail, bad serial pattern", func(t *testing.T) {
+		tr := NewSensorTypeRepository()
+
+		_, err := tr.SaveSensorType(context.Background(), domain.SensorTypeSpec{Name: "door", SerialPattern: "("})
+		assert.ErrorIs(t, err, domain.ErrInvalidSensorType)
+		_, err = tr.GetSensorType(context.Background(), "door")
+		assert.ErrorIs(t, err, usecase.ErrSensorTypeNotFound)
+	})
+
+	t.Run("ok, builtin types are registered", func(t *testing.T) {
+		tr := NewSensorTypeRepository()
+
+		types, err := tr.GetSensorTypes(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.SensorTypeSpec{domain.BuiltinSensorTypes[1], domain.BuiltinSensorTypes[0], domain.BuiltinSensorTypes[2]}, types,
+			"Встроенные типы должны быть в реестре, упорядоченные по имени")
+	})
+
+	t.Run("ok, save, replace and delete", func(t *testing.T) {
+		tr := NewSensorTypeRepository()
+		ctx := context.Background()
+		door := domain.SensorTypeSpec{
+			Name:      "door",
+			Channels:  []domain.ChannelSpec{{Name: "open"}},
+			Heartbeat: time.Hour,
+		}
+
+		created, err := tr.SaveSensorType(ctx, door)
+		assert.NoError(t, err)
+		assert.True(t, created)
+
+		door.Description = "Дверь"
+		created, err = tr.SaveSensorType(ctx, door)
+		assert.NoError(t, err)
+		assert.False(t, created, "Тип с тем же именем должен заменяться")
+
+		got, err := tr.GetSensorType(ctx, "door")
+		assert.NoError(t, err)
+		assert.Equal(t, &door, got)
+
+		got.Channels[0].Name = "closed"
+		got, err = tr.GetSensorType(ctx, "door")
+		assert.NoError(t, err)
+		assert.Equal(t, "open", got.Channels[0].Name, "Изменение полученного типа не должно менять хранимый")
+
+		assert.NoError(t, tr.DeleteSensorType(ctx, "door"))
+		_, err = tr.GetSensorType(ctx, "door")
+		assert.ErrorIs(t, err, usecase.ErrSensorTypeNotFound)
+		assert.ErrorIs(t, tr.DeleteSensorType(ctx, "door"), usecase.ErrSensorTypeNotFound)
+	})
+}
@@ -0,0 +1,184 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// foreignKeyViolation is the code of the error returned when a type in use is deleted
+const foreignKeyViolation = "23503"
+
+type SensorTypeRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewSensorTypeRepository(pool *pgxpool.Pool) *SensorTypeRepository {
+	return &SensorTypeRepository{
+		pool: pool,
+	}
+}
+
+// channelRow is a channel as it is kept in the channels column
+type channelRow struct {
+	Name string       `json:"name"`
+	Unit string       `json:"unit,omitempty"`
+	Min  *json.Number `json:"min,omitempty"`
+	Max  *json.Number `json:"max,omitempty"`
+	Bits int          `json:"bits,omitempty"`
+}
+
+func encodeChannels(channels []domain.ChannelSpec) ([]byte, error) {
+	bound := func(d *domain.Decimal) *json.Number {
+		if d == nil {
+			return nil
+		}
+		n := json.Number(d.String())
+		return &n
+	}
+
+	rows := make([]channelRow, len(channels))
+	for i, c := range channels {
+		rows[i] = channelRow{Name: c.Name, Unit: c.Unit, Min: bound(c.Min), Max: bound(c.Max), Bits: c.Bits}
+	}
+	return json.Marshal(rows)
+}
+
+func decodeChannels(data []byte) ([]domain.ChannelSpec, error) {
+	var rows []channelRow
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, err
+	}
+	bound := func(n *json.Number) (*domain.Decimal, error) {
+		if n == nil {
+			return nil, nil
+		}
+		d, err := domain.ParseDecimal(n.String())
+		return &d, err
+	}
+
+	var channels []domain.ChannelSpec
+	for _, row := range rows {
+		c := domain.ChannelSpec{Name: row.Name, Unit: row.Unit, Bits: row.Bits}
+		var err error
+		if c.Min, err = bound(row.Min); err != nil {
+			return nil, err
+		}
+		if c.Max, err = bound(row.Max); err != nil {
+			return nil, err
+		}
+		channels = append(channels, c)
+	}
+	return channels, nil
+}
+
+// saveSensorTypeQuery returns whether the row has been inserted rather than updated
+const saveSensorTypeQuery = `
+insert into db.public.sensor_types (name, description, channels, serial_pattern, heartbeat_ms)
+values ($1, $2, $3, $4, $5)
+on conflict (name) do update
+set description = excluded.description, channels = excluded.channels,
+    serial_pattern = excluded.serial_pattern, heartbeat_ms = excluded.heartbeat_ms
+returning (xmax = 0);`
+
+func (r *SensorTypeRepository) SaveSensorType(ctx context.Context, sensorType domain.SensorTypeSpec) (bool, error) {
+	channels, err := encodeChannels(sensorType.Channels)
+	if err != nil {
+		return false, fmt.Errorf("can't encode channels: %w", err)
+	}
+
+	var created bool
+	err = r.pool.QueryRow(ctx, saveSensorTypeQuery, sensorType.Name, sensorType.Description, channels,
+		sensorType.SerialPattern, sensorType.Heartbeat.Milliseconds()).Scan(&created)
+	if err != nil {
+		return false, fmt.Errorf("can't save sensor type: %w", err)
+	}
+	return created, ctx.Err()
+}
+
+const getSensorTypesQuery = `
+select name, description, channels, serial_pattern, heartbeat_ms
+from db.public.sensor_types
+order by name;`
+
+func (r *SensorTypeRepository) GetSensorTypes(ctx context.Context) ([]domain.SensorTypeSpec, error) {
+	rows, err := r.pool.Query(ctx, getSensorTypesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("can't query sensor types: %w", err)
+	}
+	defer rows.Close()
+
+	types := make([]domain.SensorTypeSpec, 0)
+	for rows.Next() {
+		t, err := scanSensorType(rows)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan sensor type: %w", err)
+		}
+		types = append(types, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't read sensor types: %w", err)
+	}
+	return types, ctx.Err()
+}
+
+const getSensorTypeQuery = `
+select name, description, channels, serial_pattern, heartbeat_ms
+from db.public.sensor_types
+where name=$1;`
+
+func (r *SensorTypeRepository) GetSensorType(ctx context.Context, name domain.SensorType) (*domain.SensorTypeSpec, error) {
+	t, err := scanSensorType(r.pool.QueryRow(ctx, getSensorTypeQuery, name))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, usecase.ErrSensorTypeNotFound
+		}
+		return nil, fmt.Errorf("can't scan sensor type: %w", err)
+	}
+	return &t, ctx.Err()
+}
+
+func scanSensorType(row pgx.Row) (domain.SensorTypeSpec, error) {
+	var t domain.SensorTypeSpec
+	var channels []byte
+	var heartbeat int64
+	if err := row.Scan(&t.Name, &t.Description, &channels, &t.SerialPattern, &heartbeat); err != nil {
+		return t, err
+	}
+
+	var err error
+	if t.Channels, err = decodeChannels(channels); err != nil {
+		return t, fmt.Errorf("can't decode channels: %w", err)
+	}
+	t.Heartbeat = time.Duration(heartbeat) * time.Millisecond
+	if err := t.CompileSerial(); err != nil {
+		return t, err
+	}
+	return t, nil
+}
+
+const deleteSensorTypeQuery = `delete from db.public.sensor_types where name=$1`
+
+// DeleteSensorType relies on the foreign key of the sensors to keep the types in use
+func (r *SensorTypeRepository) DeleteSensorType(ctx context.Context, name domain.SensorType) error {
+	tag, err := r.pool.Exec(ctx, deleteSensorTypeQuery, name)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return usecase.ErrSensorTypeInUse
+		}
+		return fmt.Errorf("can't delete sensor type: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return usecase.ErrSensorTypeNotFound
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"homework/pkg/pg_test"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type SensorTypeTestSuite struct {
+	suite.Suite
+	testDbInstance *pgxpool.Pool
+	testDB         *pg_test.TestDatabase
+
+	repo *SensorTypeRepository
+}
+
+func (suite *SensorTypeTestSuite) SetupSuite() {
+	suite.testDB = pg_test.SetupTestDatabase()
+	suite.testDbInstance = suite.testDB.DbInstance
+
+	suite.repo = NewSensorTypeRepository(suite.testDbInstance)
+}
+
+func (suite *SensorTypeTestSuite) TearDownSuite() {
+	suite.testDB.TearDown()
+}
+
+func (suite *SensorTypeTestSuite) TestSensorTypeRepository_Builtin() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, b := range domain.BuiltinSensorTypes {
+		t, err := suite.repo.GetSensorType(ctx, b.Name)
+		assert.Nil(suite.T(), err)
+		assert.Equal(suite.T(), &b, t, "Встроенные типы должны создаваться миграцией")
+	}
+}
+
+func (suite *SensorTypeTestSuite) TestSensorTypeRepository_SaveSensorType() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	low, high := domain.Decimal{Units: -40}, domain.Decimal{Units: 125}
+	meter := domain.SensorTypeSpec{
+		Name:        "meter",
+		Description: "Термометр",
+		Channels: []domain.ChannelSpec{
+			{Name: "temperature", Unit: "°C", Min: &low, Max: &high},
+			{Name: "humidity", Unit: "%"},
+		},
+		SerialPattern: `^7\d+$`,
+		Heartbeat:     90 * time.Second,
+	}
+
+	created, err := suite.repo.SaveSensorType(ctx, meter)
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), created)
+
+	meter.Heartbeat = time.Minute
+	created, err = suite.repo.SaveSensorType(ctx, meter)
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), created, "Тип с тем же именем должен заменяться")
+
+	t, err := suite.repo.GetSensorType(ctx, "meter")
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), meter.CompileSerial())
+	assert.Equal(suite.T(), &meter, t, "Шаблон серийного номера должен компилироваться при чтении")
+
+	types, err := suite.repo.GetSensorTypes(ctx)
+	assert.Nil(suite.T(), err)
+	names := make([]domain.SensorType, 0, len(types))
+	for _, t := range types {
+		names = append(names, t.Name)
+	}
+	assert.Equal(suite.T(), []domain.SensorType{"adc", "cc", "meter"}, names)
+}
+
+func (suite *SensorTypeTestSuite) TestSensorTypeRepository_DeleteSensorType() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, name := range []domain.SensorType{"door", "window"} {
+		_, err := suite.repo.SaveSensorType(ctx, domain.SensorTypeSpec{Name: name})
+		assert.Nil(suite.T(), err)
+	}
+	_, err := suite.testDbInstance.Exec(ctx,
+		`insert into db.public.sensors (serial_number, type, current_state, description, is_active, registered_at, last_activity)
+		values ('0000000042', 'window', 0, '', true, now(), now())`)
+	assert.Nil(suite.T(), err)
+
+	assert.Nil(suite.T(), suite.repo.DeleteSensorType(ctx, "door"))
+	_, err = suite.repo.GetSensorType(ctx, "door")
+	assert.ErrorIs(suite.T(), err, usecase.ErrSensorTypeNotFound)
+	assert.ErrorIs(suite.T(), suite.repo.DeleteSensorType(ctx, "door"), usecase.ErrSensorTypeNotFound)
+
+	assert.ErrorIs(suite.T(), suite.repo.DeleteSensorType(ctx, "window"), usecase.ErrSensorTypeInUse,
+		"Тип зарегистрированного датчика не должен удаляться")
+}
+
+func TestSensorTypeTestSuite(t *testing.T) {
+	suite.Run(t, new(SensorTypeTestSuite))
+}
@@ -0,0 +1,220 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"time"
+)
+
+type SensorTypeRepository struct {
+	db *sql.DB
+}
+
+func NewSensorTypeRepository(db *sql.DB) *SensorTypeRepository {
+	return &SensorTypeRepository{
+		db: db,
+	}
+}
+
+// channelRow is a channel as it is kept in the channels column, the same json as in postgres
+type channelRow struct {
+	Name string       `json:"name"`
+	Unit string       `json:"unit,omitempty"`
+	Min  *json.Number `json:"min,omitempty"`
+	Max  *json.Number `json:"max,omitempty"`
+	Bits int          `json:"bits,omitempty"`
+}
+
+func encodeChannels(channels []domain.ChannelSpec) (string, error) {
+	bound := func(d *domain.Decimal) *json.Number {
+		if d == nil {
+			return nil
+		}
+		n := json.Number(d.String())
+		return &n
+	}
+
+	rows := make([]channelRow, len(channels))
+	for i, c := range channels {
+		rows[i] = channelRow{Name: c.Name, Unit: c.Unit, Min: bound(c.Min), Max: bound(c.Max), Bits: c.Bits}
+	}
+	data, err := json.Marshal(rows)
+	return string(data), err
+}
+
+func decodeChannels(data string) ([]domain.ChannelSpec, error) {
+	var rows []channelRow
+	if err := json.Unmarshal([]byte(data), &rows); err != nil {
+		return nil, err
+	}
+	bound := func(n *json.Number) (*domain.Decimal, error) {
+		if n == nil {
+			return nil, nil
+		}
+		d, err := domain.ParseDecimal(n.String())
+		return &d, err
+	}
+
+	var channels []domain.ChannelSpec
+	for _, row := range rows {
+		c := domain.ChannelSpec{Name: row.Name, Unit: row.Unit, Bits: row.Bits}
+		var err error
+		if c.Min, err = bound(row.Min); err != nil {
+			return nil, err
+		}
+		if c.Max, err = bound(row.Max); err != nil {
+			return nil, err
+		}
+		channels = append(channels, c)
+	}
+	return channels, nil
+}
+
+const (
+	sensorTypeExistsQuery = `select exists (select 1 from sensor_types where name = ?)`
+	saveSensorTypeQuery   = `
+insert into sensor_types (name, description, channels, serial_pattern, heartbeat_ms)
+values (?, ?, ?, ?, ?)
+on conflict (name) do update
+set description = excluded.description, channels = excluded.channels,
+    serial_pattern = excluded.serial_pattern, heartbeat_ms = excluded.heartbeat_ms`
+)
+
+func (r *SensorTypeRepository) SaveSensorType(ctx context.Context, sensorType domain.SensorTypeSpec) (bool, error) {
+	channels, err := encodeChannels(sensorType.Channels)
+	if err != nil {
+		return false, fmt.Errorf("can't encode channels: %w", err)
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("can't begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var existed bool
+	if err := tx.QueryRowContext(ctx, sensorTypeExistsQuery, sensorType.Name).Scan(&existed); err != nil {
+		return false, fmt.Errorf("can't check sensor type: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, saveSensorTypeQuery, sensorType.Name, sensorType.Description, channels,
+		sensorType.SerialPattern, sensorType.Heartbeat.Milliseconds())
+	if err != nil {
+		return false, fmt.Errorf("can't save sensor type: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("can't commit sensor type: %w", err)
+	}
+	return !existed, ctx.Err()
+}
+
+const getSensorTypesQuery = `
+select name, description, channels, serial_pattern, heartbeat_ms
+from sensor_types
+order by name`
+
+func (r *SensorTypeRepository) GetSensorTypes(ctx context.Context) ([]domain.SensorTypeSpec, error) {
+	rows, err := r.db.QueryContext(ctx, getSensorTypesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("can't query sensor types: %w", err)
+	}
+	defer rows.Close()
+
+	types := make([]domain.SensorTypeSpec, 0)
+	for rows.Next() {
+		t, err := scanSensorType(rows)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan sensor type: %w", err)
+		}
+		types = append(types, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't read sensor types: %w", err)
+	}
+	return types, ctx.Err()
+}
+
+const getSensorTypeQuery = `
+select name, description, channels, serial_pattern, heartbeat_ms
+from sensor_types
+where name = ?`
+
+func (r *SensorTypeRepository) GetSensorType(ctx context.Context, name domain.SensorType) (*domain.SensorTypeSpec, error) {
+	t, err := scanSensorType(r.db.QueryRowContext(ctx, getSensorTypeQuery, name))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, usecase.ErrSensorTypeNotFound
+		}
+		return nil, fmt.Errorf("can't scan sensor type: %w", err)
+	}
+	return &t, ctx.Err()
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSensorType(row scanner) (domain.SensorTypeSpec, error) {
+	var t domain.SensorTypeSpec
+	var channels string
+	var heartbeat int64
+	if err := row.Scan(&t.Name, &t.Description, &channels, &t.SerialPattern, &heartbeat); err != nil {
+		return t, err
+	}
+
+	var err error
+	if t.Channels, err = decodeChannels(channels); err != nil {
+		return t, fmt.Errorf("can't decode channels: %w", err)
+	}
+	t.Heartbeat = time.Duration(heartbeat) * time.Millisecond
+	if err := t.CompileSerial(); err != nil {
+		return t, err
+	}
+	return t, nil
+}
+
+// deleteSensorTypeQuery keeps the type of the registered sensors, the sensors have no foreign key to the types
+const deleteSensorTypeQuery = `
+delete from sensor_types
+where name = ? and not exists (select 1 from sensors where sensors.type = sensor_types.name)`
+
+// DeleteSensorType checks the sensors and deletes the type in one statement, so no sensor is left without its type
+func (r *SensorTypeRepository) DeleteSensorType(ctx context.Context, name domain.SensorType) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	res, err := tx.ExecContext(ctx, deleteSensorTypeQuery, name)
+	if err != nil {
+		return fmt.Errorf("can't delete sensor type: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("can't delete sensor type: %w", err)
+	}
+	if n == 0 {
+		var existed bool
+		if err := tx.QueryRowContext(ctx, sensorTypeExistsQuery, name).Scan(&existed); err != nil {
+			return fmt.Errorf("can't check sensor type: %w", err)
+		}
+		if existed {
+			return usecase.ErrSensorTypeInUse
+		}
+		return usecase.ErrSensorTypeNotFound
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("can't commit sensor type: %w", err)
+	}
+	return ctx.Err()
+}
+
+var _ usecase.SensorTypeRepository = (*SensorTypeRepository)(nil)
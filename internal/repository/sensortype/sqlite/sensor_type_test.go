@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"path/filepath"
+	"testing"
+	"time"
+
+	schemaSqlite "homework/internal/repository/schema/sqlite"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type SensorTypeTestSuite struct {
+	suite.Suite
+	db *sql.DB
+
+	repo *SensorTypeRepository
+}
+
+func (suite *SensorTypeTestSuite) SetupSuite() {
+	db, err := schemaSqlite.Open(context.Background(), filepath.Join(suite.T().TempDir(), "test.db"))
+	suite.Require().NoError(err)
+	suite.db = db
+
+	suite.repo = NewSensorTypeRepository(db)
+}
+
+func (suite *SensorTypeTestSuite) TearDownSuite() {
+	suite.db.Close()
+}
+
+func (suite *SensorTypeTestSuite) TestSensorTypeRepository_Builtin() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, b := range domain.BuiltinSensorTypes {
+		t, err := suite.repo.GetSensorType(ctx, b.Name)
+		assert.Nil(suite.T(), err)
+		assert.Equal(suite.T(), &b, t, "Встроенные типы должны создаваться миграцией")
+	}
+}
+
+func (suite *SensorTypeTestSuite) TestSensorTypeRepository_SaveSensorType() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	low, high := domain.Decimal{Units: -40}, domain.Decimal{Units: 125}
+	meter := domain.SensorTypeSpec{
+		Name:        "meter",
+		Description: "Термометр",
+		Channels: []domain.ChannelSpec{
+			{Name: "temperature", Unit: "°C", Min: &low, Max: &high},
+			{Name: "humidity", Unit: "%"},
+		},
+		SerialPattern: `^7\d+$`,
+		Heartbeat:     90 * time.Second,
+	}
+
+	created, err := suite.repo.SaveSensorType(ctx, meter)
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), created)
+
+	meter.Heartbeat = time.Minute
+	created, err = suite.repo.SaveSensorType(ctx, meter)
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), created, "Тип с тем же именем должен заменяться")
+
+	t, err := suite.repo.GetSensorType(ctx, "meter")
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), meter.CompileSerial())
+	assert.Equal(suite.T(), &meter, t, "Шаблон серийного номера должен компилироваться при чтении")
+
+	types, err := suite.repo.GetSensorTypes(ctx)
+	assert.Nil(suite.T(), err)
+	names := make([]domain.SensorType, 0, len(types))
+	for _, t := range types {
+		names = append(names, t.Name)
+	}
+	assert.Subset(suite.T(), names, []domain.SensorType{"adc", "cc", "meter", "virtual"})
+	assert.IsNonDecreasing(suite.T(), names, "Типы должны быть упорядочены по имени")
+}
+
+func (suite *SensorTypeTestSuite) TestSensorTypeRepository_DeleteSensorType() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, name := range []domain.SensorType{"door", "window"} {
+		_, err := suite.repo.SaveSensorType(ctx, domain.SensorTypeSpec{Name: name})
+		assert.Nil(suite.T(), err)
+	}
+	_, err := suite.db.ExecContext(ctx, `insert into sensors (serial_number, type) values ('0000000042', 'window')`)
+	assert.Nil(suite.T(), err)
+
+	assert.Nil(suite.T(), suite.repo.DeleteSensorType(ctx, "door"))
+	_, err = suite.repo.GetSensorType(ctx, "door")
+	assert.ErrorIs(suite.T(), err, usecase.ErrSensorTypeNotFound)
+	assert.ErrorIs(suite.T(), suite.repo.DeleteSensorType(ctx, "door"), usecase.ErrSensorTypeNotFound)
+
+	assert.ErrorIs(suite.T(), suite.repo.DeleteSensorType(ctx, "window"), usecase.ErrSensorTypeInUse,
+		"Тип зарегистрированного датчика не должен удаляться")
+}
+
+func TestSensorTypeTestSuite(t *testing.T) {
+	suite.Run(t, new(SensorTypeTestSuite))
+}
@@ -0,0 +1,21 @@
+package inmemory
+
+import (
+	"homework/internal/repository/conformance"
+	"homework/internal/usecase"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestUserRepository_Conformance(t *testing.T) {
+	suite.Run(t, &conformance.UserSuite{NewRepository: func(*testing.T) usecase.UserRepository {
+		return NewUserRepository()
+	}})
+}
+
+func TestSensorOwnerRepository_Conformance(t *testing.T) {
+	suite.Run(t, &conformance.SensorOwnerSuite{NewRepository: func(*testing.T) usecase.SensorOwnerRepository {
+		return NewSensorOwnerRepository()
+	}})
+}
@@ -3,30 +3,47 @@ package inmemory
 import (
 	"context"
 	"homework/internal/domain"
+	"slices"
 	"sync"
 )
 
 type SensorOwnerRepository struct {
 	storage []domain.SensorOwner
 	m       sync.RWMutex
+	// record, if set, writes the change of a saved binding to the feed
+	record func(domain.Change)
 }
 
-func NewSensorOwnerRepository() *SensorOwnerRepository {
-	return &SensorOwnerRepository{storage: []domain.SensorOwner{}, m: sync.RWMutex{}}
+func NewSensorOwnerRepository(options ...func(*SensorOwnerRepository)) *SensorOwnerRepository {
+	r := &SensorOwnerRepository{storage: []domain.SensorOwner{}, m: sync.RWMutex{}}
+	for _, o := range options {
+		o(r)
+	}
+	return r
+}
+
+// WithSensorOwnerChanges records every binding into the change feed under the lock of the repository
+func WithSensorOwnerChanges(record func(domain.Change)) func(*SensorOwnerRepository) {
+	return func(r *SensorOwnerRepository) {
+		r.record = record
+	}
 }
 
 func (r *SensorOwnerRepository) SaveSensorOwner(ctx context.Context, sensorOwner domain.SensorOwner) error {
 	r.m.Lock()
 	r.storage = append(r.storage, sensorOwner)
+	if r.record != nil {
+		r.record(domain.SensorOwnerChange(sensorOwner))
+	}
 	r.m.Unlock()
 	return ctx.Err()
 }
 
 func (r *SensorOwnerRepository) GetSensorsByUserID(ctx context.Context, userID int64) ([]domain.SensorOwner, error) {
-	sensors := make([]domain.SensorOwner, 0, len(r.storage))
-
 	r.m.RLock()
 	defer r.m.RUnlock()
+
+	sensors := make([]domain.SensorOwner, 0, len(r.storage))
 	for _, v := range r.storage {
 		select {
 		case <-ctx.Done():
@@ -45,3 +62,11 @@ func (r *SensorOwnerRepository) GetSensorsByUserID(ctx context.Context, userID i
 		return sensors, nil
 	}
 }
+
+// GetSensorOwners returns all the bindings in the order they were made
+func (r *SensorOwnerRepository) GetSensorOwners(ctx context.Context) ([]domain.SensorOwner, error) {
+	r.m.RLock()
+	owners := slices.Clone(r.storage)
+	r.m.RUnlock()
+	return owners, ctx.Err()
+}
@@ -1,10 +1,12 @@
 package inmemory
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"homework/internal/domain"
 	"homework/internal/usecase"
+	"slices"
 	"sync"
 )
 
@@ -13,12 +15,27 @@ var ErrNilUserPointer = errors.New("nil user is provided")
 type UserID int64
 
 type UserRepository struct {
-	storage map[UserID]*domain.User
+	storage map[UserID]domain.User
 	m       sync.RWMutex
+	// lastID is the largest id of the stored users, a new user without an id gets the next one
+	lastID int64
+	// record, if set, writes the change of a saved user to the feed
+	record func(domain.Change)
 }
 
-func NewUserRepository() *UserRepository {
-	return &UserRepository{storage: map[UserID]*domain.User{}, m: sync.RWMutex{}}
+func NewUserRepository(options ...func(*UserRepository)) *UserRepository {
+	r := &UserRepository{storage: map[UserID]domain.User{}, m: sync.RWMutex{}}
+	for _, o := range options {
+		o(r)
+	}
+	return r
+}
+
+// WithUserChanges records every save into the change feed under the lock of the repository
+func WithUserChanges(record func(domain.Change)) func(*UserRepository) {
+	return func(r *UserRepository) {
+		r.record = record
+	}
 }
 
 func (r *UserRepository) SaveUser(ctx context.Context, user *domain.User) error {
@@ -26,7 +43,14 @@ func (r *UserRepository) SaveUser(ctx context.Context, user *domain.User) error
 		return ErrNilUserPointer
 	}
 	r.m.Lock()
-	r.storage[UserID(user.ID)] = user
+	if user.ID <= 0 {
+		user.ID = r.lastID + 1
+	}
+	r.lastID = max(r.lastID, user.ID)
+	r.storage[UserID(user.ID)] = *user
+	if r.record != nil {
+		r.record(domain.UserChange(*user))
+	}
 	r.m.Unlock()
 	return ctx.Err()
 }
@@ -41,5 +65,17 @@ func (r *UserRepository) GetUserByID(ctx context.Context, id int64) (*domain.Use
 		}
 		return nil, usecase.ErrUserNotFound
 	}
-	return user, ctx.Err()
+	return &user, ctx.Err()
+}
+
+// GetUsers returns all the users ordered by id
+func (r *UserRepository) GetUsers(ctx context.Context) ([]domain.User, error) {
+	r.m.RLock()
+	users := make([]domain.User, 0, len(r.storage))
+	for _, u := range r.storage {
+		users = append(users, u)
+	}
+	r.m.RUnlock()
+	slices.SortFunc(users, func(a, b domain.User) int { return cmp.Compare(a.ID, b.ID) })
+	return users, ctx.Err()
 }
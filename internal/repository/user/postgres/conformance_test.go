@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"context"
+	"homework/internal/repository/conformance"
+	"homework/internal/usecase"
+	"homework/pkg/pg_test"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestUserRepository_Conformance(t *testing.T) {
+	testDB := pg_test.SetupTestDatabase()
+	defer testDB.TearDown()
+
+	suite.Run(t, &conformance.UserSuite{NewRepository: func(t *testing.T) usecase.UserRepository {
+		_, err := testDB.DbInstance.Exec(context.Background(), `truncate db.public.users restart identity`)
+		require.NoError(t, err)
+		return NewUserRepository(testDB.DbInstance)
+	}})
+}
+
+func TestSensorOwnerRepository_Conformance(t *testing.T) {
+	testDB := pg_test.SetupTestDatabase()
+	defer testDB.TearDown()
+
+	suite.Run(t, &conformance.SensorOwnerSuite{NewRepository: func(t *testing.T) usecase.SensorOwnerRepository {
+		_, err := testDB.DbInstance.Exec(context.Background(), `truncate db.public.sensors_users restart identity`)
+		require.NoError(t, err)
+		return NewSensorOwnerRepository(testDB.DbInstance)
+	}})
+}
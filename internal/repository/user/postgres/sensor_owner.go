@@ -6,6 +6,8 @@ import (
 	"homework/internal/domain"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	changePostgres "homework/internal/repository/change/postgres"
 )
 
 type SensorOwnerRepository struct {
@@ -20,15 +22,29 @@ func NewSensorOwnerRepository(pool *pgxpool.Pool) *SensorOwnerRepository {
 
 const saveSensorOwnerQuery = `insert into db.public.sensors_users (sensor_id, user_id) values ($1, $2);`
 
+// SaveSensorOwner binds the sensor to the user, the binding is recorded into the change feed in the same transaction
 func (r *SensorOwnerRepository) SaveSensorOwner(ctx context.Context, sensorOwner domain.SensorOwner) error {
-	_, err := r.pool.Exec(ctx, saveSensorOwnerQuery, sensorOwner.SensorID, sensorOwner.UserID)
+	tx, err := r.pool.Begin(ctx)
 	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := tx.Exec(ctx, saveSensorOwnerQuery, sensorOwner.SensorID, sensorOwner.UserID); err != nil {
+		return err
+	}
+	if err := changePostgres.Record(ctx, tx, domain.SensorOwnerChange(sensorOwner)); err != nil {
 		return err
 	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("can't commit sensor owner: %w", err)
+	}
 	return ctx.Err()
 }
 
-const getSensorsByUserId = `select sensor_id, user_id from db.public.sensors_users where user_id = $1;`
+const getSensorsByUserId = `select sensor_id, user_id from db.public.sensors_users where user_id = $1 order by id;`
 
 func (r *SensorOwnerRepository) GetSensorsByUserID(ctx context.Context, userID int64) ([]domain.SensorOwner, error) {
 	rows, err := r.pool.Query(ctx, getSensorsByUserId, userID)
@@ -49,3 +65,27 @@ func (r *SensorOwnerRepository) GetSensorsByUserID(ctx context.Context, userID i
 
 	return sensors, ctx.Err()
 }
+
+const getSensorOwnersQuery = `select sensor_id, user_id from db.public.sensors_users order by id;`
+
+func (r *SensorOwnerRepository) GetSensorOwners(ctx context.Context) ([]domain.SensorOwner, error) {
+	rows, err := r.pool.Query(ctx, getSensorOwnersQuery)
+	if err != nil {
+		return nil, fmt.Errorf("can't select sensor owners: %w", err)
+	}
+	defer rows.Close()
+
+	owners := make([]domain.SensorOwner, 0)
+	for rows.Next() {
+		owner := domain.SensorOwner{}
+		if err := rows.Scan(&owner.SensorID, &owner.UserID); err != nil {
+			return nil, fmt.Errorf("can't scan sensor owner: %w", err)
+		}
+		owners = append(owners, owner)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't read sensor owners: %w", err)
+	}
+
+	return owners, ctx.Err()
+}
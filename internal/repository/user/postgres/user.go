@@ -9,6 +9,8 @@ import (
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	changePostgres "homework/internal/repository/change/postgres"
 )
 
 type UserRepository struct {
@@ -21,13 +23,29 @@ func NewUserRepository(pool *pgxpool.Pool) *UserRepository {
 	}
 }
 
-const saveEventQuery = `insert into db.public.users (NAME) values ($1);`
+const saveUserQuery = `insert into db.public.users (NAME) values ($1) returning id;`
 
+// SaveUser inserts the user and assigns it the id, the user is recorded into the change feed in the same transaction
 func (r *UserRepository) SaveUser(ctx context.Context, user *domain.User) error {
-	_, err := r.pool.Exec(ctx, saveEventQuery, user.Name)
+	tx, err := r.pool.Begin(ctx)
 	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	stored := *user
+	if err := tx.QueryRow(ctx, saveUserQuery, user.Name).Scan(&stored.ID); err != nil {
 		return err
 	}
+	if err := changePostgres.Record(ctx, tx, domain.UserChange(stored)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("can't commit user: %w", err)
+	}
+	user.ID = stored.ID
 	return ctx.Err()
 }
 
@@ -0,0 +1,35 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"homework/internal/repository/conformance"
+	"homework/internal/usecase"
+	"path/filepath"
+	"testing"
+
+	schemaSqlite "homework/internal/repository/schema/sqlite"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// openDB opens an empty database of the test, it is closed when the test is done
+func openDB(t *testing.T) *sql.DB {
+	db, err := schemaSqlite.Open(context.Background(), filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestUserRepository_Conformance(t *testing.T) {
+	suite.Run(t, &conformance.UserSuite{NewRepository: func(t *testing.T) usecase.UserRepository {
+		return NewUserRepository(openDB(t))
+	}})
+}
+
+func TestSensorOwnerRepository_Conformance(t *testing.T) {
+	suite.Run(t, &conformance.SensorOwnerSuite{NewRepository: func(t *testing.T) usecase.SensorOwnerRepository {
+		return NewSensorOwnerRepository(openDB(t))
+	}})
+}
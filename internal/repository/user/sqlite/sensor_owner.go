@@ -0,0 +1,93 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"homework/internal/domain"
+
+	changeSqlite "homework/internal/repository/change/sqlite"
+)
+
+type SensorOwnerRepository struct {
+	db *sql.DB
+}
+
+func NewSensorOwnerRepository(db *sql.DB) *SensorOwnerRepository {
+	return &SensorOwnerRepository{
+		db: db,
+	}
+}
+
+const saveSensorOwnerQuery = `insert into sensors_users (sensor_id, user_id) values (?, ?)`
+
+// SaveSensorOwner binds the sensor and records the binding into the change feed in the same transaction
+func (r *SensorOwnerRepository) SaveSensorOwner(ctx context.Context, sensorOwner domain.SensorOwner) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.ExecContext(ctx, saveSensorOwnerQuery, sensorOwner.SensorID, sensorOwner.UserID); err != nil {
+		return err
+	}
+	if err := changeSqlite.Record(ctx, tx, domain.SensorOwnerChange(sensorOwner)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("can't commit sensor owner: %w", err)
+	}
+	return ctx.Err()
+}
+
+const getSensorsByUserIDQuery = `select sensor_id, user_id from sensors_users where user_id = ? order by id`
+
+func (r *SensorOwnerRepository) GetSensorsByUserID(ctx context.Context, userID int64) ([]domain.SensorOwner, error) {
+	rows, err := r.db.QueryContext(ctx, getSensorsByUserIDQuery, userID)
+	if err != nil {
+		return nil, fmt.Errorf("can't select sensors by user id %d %w", userID, err)
+	}
+	defer rows.Close()
+
+	sensors := make([]domain.SensorOwner, 0)
+	for rows.Next() {
+		sensor := domain.SensorOwner{}
+		if err := rows.Scan(&sensor.SensorID, &sensor.UserID); err != nil {
+			return nil, fmt.Errorf("can't scan sensor owner: %w", err)
+		}
+
+		sensors = append(sensors, sensor)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't read sensor owners: %w", err)
+	}
+
+	return sensors, ctx.Err()
+}
+
+const getSensorOwnersQuery = `select sensor_id, user_id from sensors_users order by id`
+
+func (r *SensorOwnerRepository) GetSensorOwners(ctx context.Context) ([]domain.SensorOwner, error) {
+	rows, err := r.db.QueryContext(ctx, getSensorOwnersQuery)
+	if err != nil {
+		return nil, fmt.Errorf("can't select sensor owners: %w", err)
+	}
+	defer rows.Close()
+
+	owners := make([]domain.SensorOwner, 0)
+	for rows.Next() {
+		owner := domain.SensorOwner{}
+		if err := rows.Scan(&owner.SensorID, &owner.UserID); err != nil {
+			return nil, fmt.Errorf("can't scan sensor owner: %w", err)
+		}
+		owners = append(owners, owner)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't read sensor owners: %w", err)
+	}
+
+	return owners, ctx.Err()
+}
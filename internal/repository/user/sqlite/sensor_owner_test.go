@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"homework/internal/domain"
+	"path/filepath"
+	"testing"
+	"time"
+
+	schemaSqlite "homework/internal/repository/schema/sqlite"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type SensorOwnerTestSuite struct {
+	suite.Suite
+	db *sql.DB
+
+	repo *SensorOwnerRepository
+}
+
+func (suite *SensorOwnerTestSuite) SetupSuite() {
+	db, err := schemaSqlite.Open(context.Background(), filepath.Join(suite.T().TempDir(), "test.db"))
+	suite.Require().NoError(err)
+	suite.db = db
+
+	suite.repo = NewSensorOwnerRepository(db)
+}
+
+func (suite *SensorOwnerTestSuite) TearDownSuite() {
+	suite.db.Close()
+}
+
+func (suite *SensorOwnerTestSuite) TestSensorOwnerRepository_GetSensorsByUserID() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.Nil(suite.T(), suite.repo.SaveSensorOwner(ctx, domain.SensorOwner{UserID: 2, SensorID: 3}))
+	assert.Nil(suite.T(), suite.repo.SaveSensorOwner(ctx, domain.SensorOwner{UserID: 2, SensorID: 2}))
+	assert.Nil(suite.T(), suite.repo.SaveSensorOwner(ctx, domain.SensorOwner{UserID: 1, SensorID: 2}))
+
+	sensors, err := suite.repo.GetSensorsByUserID(ctx, 2)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []domain.SensorOwner{
+		{UserID: 2, SensorID: 3},
+		{UserID: 2, SensorID: 2},
+	}, sensors, "Датчики возвращаются в порядке привязки")
+
+	sensors, err = suite.repo.GetSensorsByUserID(ctx, 3)
+	assert.Nil(suite.T(), err)
+	assert.Empty(suite.T(), sensors)
+}
+
+func TestSensorOwnerTestSuite(t *testing.T) {
+	suite.Run(t, new(SensorOwnerTestSuite))
+}
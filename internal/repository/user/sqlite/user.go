@@ -0,0 +1,78 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+
+	changeSqlite "homework/internal/repository/change/sqlite"
+)
+
+var ErrNilUserPointer = errors.New("nil user is provided")
+
+type UserRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{
+		db: db,
+	}
+}
+
+// saveUserQuery inserts the user or renames the one with the same id
+const saveUserQuery = `
+insert into users (id, name) values (?, ?)
+on conflict (id) do update set name = excluded.name
+returning id`
+
+// SaveUser assigns the id to a new user unless it is set and records the user into the change feed
+// in the same transaction
+func (r *UserRepository) SaveUser(ctx context.Context, user *domain.User) error {
+	if user == nil {
+		return ErrNilUserPointer
+	}
+	var id any
+	if user.ID > 0 {
+		id = user.ID
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	stored := *user
+	if err := tx.QueryRowContext(ctx, saveUserQuery, id, user.Name).Scan(&stored.ID); err != nil {
+		return err
+	}
+	if err := changeSqlite.Record(ctx, tx, domain.UserChange(stored)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("can't commit user: %w", err)
+	}
+	user.ID = stored.ID
+	return ctx.Err()
+}
+
+const getUserByIDQuery = `select id, name from users where id = ?`
+
+func (r *UserRepository) GetUserByID(ctx context.Context, id int64) (*domain.User, error) {
+	row := r.db.QueryRowContext(ctx, getUserByIDQuery, id)
+
+	user := &domain.User{}
+	if err := row.Scan(&user.ID, &user.Name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, usecase.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("can't scan user: %w", err)
+	}
+
+	return user, ctx.Err()
+}
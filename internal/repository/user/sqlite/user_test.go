@@ -0,0 +1,69 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"path/filepath"
+	"testing"
+	"time"
+
+	schemaSqlite "homework/internal/repository/schema/sqlite"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UserTestSuite struct {
+	suite.Suite
+	db *sql.DB
+
+	repo *UserRepository
+}
+
+func (suite *UserTestSuite) SetupSuite() {
+	db, err := schemaSqlite.Open(context.Background(), filepath.Join(suite.T().TempDir(), "test.db"))
+	suite.Require().NoError(err)
+	suite.db = db
+
+	suite.repo = NewUserRepository(db)
+}
+
+func (suite *UserTestSuite) TearDownSuite() {
+	suite.db.Close()
+}
+
+func (suite *UserTestSuite) TestUserRepository_SaveUser() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	first := domain.User{Name: "vasya pupkin"}
+	second := domain.User{Name: "petya pupkin"}
+	assert.Nil(suite.T(), suite.repo.SaveUser(ctx, &first))
+	assert.Nil(suite.T(), suite.repo.SaveUser(ctx, &second))
+	assert.Positive(suite.T(), first.ID, "Новому пользователю присваивается id")
+	assert.Greater(suite.T(), second.ID, first.ID)
+
+	renamed := domain.User{ID: first.ID, Name: "vasiliy pupkin"}
+	assert.Nil(suite.T(), suite.repo.SaveUser(ctx, &renamed))
+	assert.Equal(suite.T(), first.ID, renamed.ID)
+
+	user, err := suite.repo.GetUserByID(ctx, first.ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), renamed, *user)
+
+	assert.ErrorIs(suite.T(), suite.repo.SaveUser(ctx, nil), ErrNilUserPointer)
+}
+
+func (suite *UserTestSuite) TestUserRepository_GetUserByID() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := suite.repo.GetUserByID(ctx, 100500)
+	assert.ErrorIs(suite.T(), err, usecase.ErrUserNotFound)
+}
+
+func TestUserTestSuite(t *testing.T) {
+	suite.Run(t, new(UserTestSuite))
+}
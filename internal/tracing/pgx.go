@@ -0,0 +1,92 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PgxTracer makes a span of every query, it is set as the tracer of the pgx connection config
+type PgxTracer struct {
+	tracer trace.Tracer
+}
+
+var (
+	_ pgx.QueryTracer    = (*PgxTracer)(nil)
+	_ pgx.CopyFromTracer = (*PgxTracer)(nil)
+)
+
+func NewPgxTracer() *PgxTracer {
+	return &PgxTracer{tracer: otel.Tracer("homework/internal/tracing/pgx")}
+}
+
+func (t *PgxTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	operation, table := sqlName(data.SQL)
+	name := operation
+	if table != "" {
+		name += " " + table
+	}
+
+	ctx, _ = t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		semconv.DBSystemPostgreSQL,
+		semconv.DBOperation(operation),
+		semconv.DBSQLTable(table),
+		semconv.DBStatement(strings.TrimSpace(data.SQL)),
+	))
+	return ctx
+}
+
+func (t *PgxTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	endSpan(trace.SpanFromContext(ctx), data.Err, data.CommandTag.RowsAffected())
+}
+
+func (t *PgxTracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	table := data.TableName[len(data.TableName)-1]
+	ctx, _ = t.tracer.Start(ctx, "COPY "+table, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		semconv.DBSystemPostgreSQL,
+		semconv.DBOperation("COPY"),
+		semconv.DBSQLTable(table),
+	))
+	return ctx
+}
+
+func (t *PgxTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	endSpan(trace.SpanFromContext(ctx), data.Err, data.CommandTag.RowsAffected())
+}
+
+func endSpan(span trace.Span, err error, rows int64) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.SetAttributes(attribute.Int64("db.rows_affected", rows))
+	span.End()
+}
+
+// sqlName picks the operation and the table of the statement for the span name, as the semantic conventions advise:
+// "select ... from db.public.events where ..." becomes SELECT and events
+func sqlName(sql string) (operation, table string) {
+	words := strings.Fields(sql)
+	if len(words) == 0 {
+		return "QUERY", ""
+	}
+	operation = strings.ToUpper(words[0])
+
+	// the table follows the first from, into or update
+	for i, w := range words {
+		switch strings.ToLower(w) {
+		case "from", "into", "update":
+			if i+1 < len(words) {
+				name := strings.Trim(words[i+1], `"(;`)
+				return operation, name[strings.LastIndex(name, ".")+1:]
+			}
+		}
+	}
+	return operation, ""
+}
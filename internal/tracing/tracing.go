@@ -0,0 +1,47 @@
+// Package tracing sets up OpenTelemetry tracing: the OTLP exporter, the sampling and the propagation of the trace context.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+const ServiceName = "smart-home"
+
+type Config struct {
+	// Endpoint is the url of the OTLP/HTTP collector, e.g. http://localhost:4318. Tracing is off if it is empty.
+	Endpoint string
+	// SampleRatio is the share of the traces started here that are recorded, the incoming ones follow the caller's decision
+	SampleRatio float64
+}
+
+// Setup installs the global tracer provider and propagator. The returned function flushes the spans
+// that have not been exported yet and must be called on shutdown.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	// the trace context is propagated even if tracing is off here, so the traces are not broken across the services
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("can't create trace exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,119 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// collector is an in-process OTLP/HTTP collector that keeps the names of the spans it receives
+type collector struct {
+	m     sync.Mutex
+	spans []string
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil || r.URL.Path != "/v1/traces" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var req collectortrace.ExportTraceServiceRequest
+	if err := proto.Unmarshal(body, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			for _, s := range ss.Spans {
+				c.spans = append(c.spans, s.Name)
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (c *collector) received() []string {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.spans
+}
+
+func TestSetup(t *testing.T) {
+	t.Run("ok, spans are exported", func(t *testing.T) {
+		c := &collector{}
+		srv := httptest.NewServer(c)
+		defer srv.Close()
+
+		shutdown, err := Setup(context.Background(), Config{Endpoint: srv.URL, SampleRatio: 1})
+		require.NoError(t, err)
+
+		_, span := otel.Tracer("test").Start(context.Background(), "Event.ReceiveEvent")
+		span.End()
+		require.NoError(t, shutdown(context.Background()))
+
+		assert.Equal(t, []string{"Event.ReceiveEvent"}, c.received())
+	})
+
+	t.Run("ok, new traces are not sampled", func(t *testing.T) {
+		c := &collector{}
+		srv := httptest.NewServer(c)
+		defer srv.Close()
+
+		shutdown, err := Setup(context.Background(), Config{Endpoint: srv.URL, SampleRatio: 0})
+		require.NoError(t, err)
+
+		_, span := otel.Tracer("test").Start(context.Background(), "Event.ReceiveEvent")
+		span.End()
+
+		// the caller has decided to sample the trace, the decision is followed
+		header := http.Header{"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}}
+		ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(header))
+		_, span = otel.Tracer("test").Start(ctx, "Sensor.GetSensors")
+		span.End()
+		require.NoError(t, shutdown(context.Background()))
+
+		assert.Equal(t, []string{"Sensor.GetSensors"}, c.received())
+	})
+
+	t.Run("ok, tracing is off", func(t *testing.T) {
+		shutdown, err := Setup(context.Background(), Config{})
+		require.NoError(t, err)
+		assert.NoError(t, shutdown(context.Background()))
+	})
+}
+
+func Test_sqlName(t *testing.T) {
+	tests := []struct {
+		sql       string
+		operation string
+		table     string
+	}{
+		{"select timestamp, payload from db.public.events where sensor_id=$1", "SELECT", "events"},
+		{"\ninsert into db.public.sensors (serial_number) values ($1)", "INSERT", "sensors"},
+		{"update db.public.sensors set is_active=$2 where serial_number=$1", "UPDATE", "sensors"},
+		{"select 1", "SELECT", ""},
+		{"", "QUERY", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.operation+" "+tt.table, func(t *testing.T) {
+			operation, table := sqlName(tt.sql)
+			assert.Equal(t, tt.operation, operation)
+			assert.Equal(t, tt.table, table)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+)
+
+type Actuator struct {
+	actuatorRepository ActuatorRepository
+}
+
+func NewActuator(ar ActuatorRepository) *Actuator {
+	return &Actuator{actuatorRepository: ar}
+}
+
+// RegisterActuator saves a new actuator, the one already registered with the serial number is returned as it is
+func (a *Actuator) RegisterActuator(ctx context.Context, actuator *domain.Actuator) (_ *domain.Actuator, err error) {
+	ctx, end := startSpan(ctx, "Actuator.RegisterActuator")
+	defer end(&err)
+
+	if err := actuator.Validate(); err != nil {
+		return nil, err
+	}
+	old, err := a.actuatorRepository.GetActuatorBySerialNumber(ctx, actuator.SerialNumber)
+	if err == nil {
+		return old, nil
+	}
+	if !errors.Is(err, ErrActuatorNotFound) {
+		return nil, err
+	}
+	if err := a.actuatorRepository.SaveActuator(ctx, actuator); err != nil {
+		return nil, err
+	}
+	return actuator, nil
+}
+
+func (a *Actuator) GetActuators(ctx context.Context) (_ []domain.Actuator, err error) {
+	ctx, end := startSpan(ctx, "Actuator.GetActuators")
+	defer end(&err)
+	return a.actuatorRepository.GetActuators(ctx)
+}
+
+func (a *Actuator) GetActuatorByID(ctx context.Context, id int64) (_ *domain.Actuator, err error) {
+	ctx, end := startSpan(ctx, "Actuator.GetActuatorByID")
+	defer end(&err)
+	return a.actuatorRepository.GetActuatorByID(ctx, id)
+}
@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"context"
+	"homework/internal/domain"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_actuator_RegisterActuator(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("fail, actuator not valid", func(t *testing.T) {
+		ar := NewMockActuatorRepository(ctrl)
+		ar.EXPECT().SaveActuator(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := NewActuator(ar).RegisterActuator(context.Background(), &domain.Actuator{SerialNumber: "1", Type: "relay"})
+		assert.ErrorIs(t, err, domain.ErrInvalidActuator)
+	})
+
+	t.Run("ok, registered one is returned", func(t *testing.T) {
+		old := &domain.Actuator{ID: 1, SerialNumber: "0123456789", Type: "relay", Description: "старое"}
+		ar := NewMockActuatorRepository(ctrl)
+		ar.EXPECT().GetActuatorBySerialNumber(gomock.Any(), "0123456789").Times(1).Return(old, nil)
+		ar.EXPECT().SaveActuator(gomock.Any(), gomock.Any()).Times(0)
+
+		got, err := NewActuator(ar).RegisterActuator(context.Background(),
+			&domain.Actuator{SerialNumber: "0123456789", Type: "relay", Description: "новое"})
+		assert.NoError(t, err)
+		assert.Equal(t, old, got)
+	})
+
+	t.Run("ok, new one is saved", func(t *testing.T) {
+		a := &domain.Actuator{SerialNumber: "0123456789", Type: "relay"}
+		ar := NewMockActuatorRepository(ctrl)
+		ar.EXPECT().GetActuatorBySerialNumber(gomock.Any(), "0123456789").Times(1).Return(nil, ErrActuatorNotFound)
+		ar.EXPECT().SaveActuator(gomock.Any(), a).Times(1).Return(nil)
+
+		got, err := NewActuator(ar).RegisterActuator(context.Background(), a)
+		assert.NoError(t, err)
+		assert.Equal(t, a, got)
+	})
+}
@@ -0,0 +1,163 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"time"
+)
+
+// ChangeSettings are the retention of the change feed and how often a waiting read looks at it again
+type ChangeSettings struct {
+	// Retention is how long the changes are kept, forever if 0
+	Retention time.Duration
+	// PruneInterval is how often Run deletes the changes older than the retention
+	PruneInterval time.Duration
+	// PollInterval is how often a waiting read looks at the feed again, unless the repository tells it
+	// about the new changes itself. Another replica may have written them.
+	PollInterval time.Duration
+}
+
+var DefaultChangeSettings = ChangeSettings{Retention: 7 * 24 * time.Hour, PruneInterval: 10 * time.Minute, PollInterval: time.Second}
+
+// Changes is the feed of the changes of the sensors, the users, the bindings and the events. The changes are
+// delivered at least once: a consumer moves its cursor only after it has handled them, the ones after the cursor
+// are read again after a failure.
+type Changes struct {
+	changeRepository ChangeRepository
+	settings         ChangeSettings
+	now              func() time.Time
+	heartbeat        heartbeat
+}
+
+func NewChanges(cr ChangeRepository, options ...func(*Changes)) *Changes {
+	c := &Changes{
+		changeRepository: cr,
+		settings:         DefaultChangeSettings,
+		now:              time.Now,
+	}
+	for _, o := range options {
+		o(c)
+	}
+	return c
+}
+
+func WithChangeSettings(settings ChangeSettings) func(*Changes) {
+	return func(c *Changes) {
+		c.settings = settings
+	}
+}
+
+// GetChanges returns up to limit changes after the cursor. If there are none, it waits for them up to wait
+// and returns nothing if none has come.
+func (c *Changes) GetChanges(ctx context.Context, after int64, limit int, wait time.Duration) (_ []domain.Change, err error) {
+	ctx, end := startSpan(ctx, "Changes.GetChanges")
+	defer end(&err)
+
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+	tick := time.NewTicker(c.settings.PollInterval)
+	defer tick.Stop()
+	notifier, _ := c.changeRepository.(ChangeNotifier)
+	for {
+		// the channel is taken before the feed is read, so a change written in between is not missed
+		var changed <-chan struct{}
+		if notifier != nil {
+			changed = notifier.Changed()
+		}
+		changes, err := c.changeRepository.GetChanges(ctx, after, limit)
+		if err != nil || len(changes) > 0 || wait <= 0 {
+			return changes, err
+		}
+
+		select {
+		case <-changed:
+		case <-tick.C:
+		case <-deadline.C:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// GetConsumer returns the consumer with its cursor
+func (c *Changes) GetConsumer(ctx context.Context, name string) (_ *domain.ChangeConsumer, err error) {
+	ctx, end := startSpan(ctx, "Changes.GetConsumer")
+	defer end(&err)
+
+	return c.changeRepository.GetConsumer(ctx, name)
+}
+
+// GetConsumerCursor returns the cursor the consumer has stopped at, a new consumer starts from the beginning
+func (c *Changes) GetConsumerCursor(ctx context.Context, name string) (int64, error) {
+	consumer, err := c.GetConsumer(ctx, name)
+	if errors.Is(err, ErrConsumerNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return consumer.Cursor, nil
+}
+
+// CommitConsumer moves the cursor of the consumer, the consumer is created by its first commit.
+// The cursor may go back as well, then the consumer gets the changes after it once again.
+func (c *Changes) CommitConsumer(ctx context.Context, name string, cursor int64) (_ *domain.ChangeConsumer, err error) {
+	ctx, end := startSpan(ctx, "Changes.CommitConsumer")
+	defer end(&err)
+
+	consumer := domain.ChangeConsumer{Name: name, Cursor: cursor, UpdatedAt: c.now()}
+	if err := consumer.Validate(); err != nil {
+		return nil, err
+	}
+	if err := c.changeRepository.SaveConsumer(ctx, consumer); err != nil {
+		return nil, err
+	}
+	return &consumer, nil
+}
+
+// Prune deletes the changes older than the retention, nothing if they are kept forever
+func (c *Changes) Prune(ctx context.Context) (_ int64, err error) {
+	ctx, end := startSpan(ctx, "Changes.Prune")
+	defer end(&err)
+
+	if c.settings.Retention <= 0 {
+		return 0, nil
+	}
+	return c.changeRepository.DeleteChanges(ctx, c.now().Add(-c.settings.Retention))
+}
+
+// Check fails if Run has not pruned the changes successfully for a few intervals
+func (c *Changes) Check(ctx context.Context) error {
+	if err := c.heartbeat.check(c.now(), c.settings.PruneInterval); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// Run prunes the changes until ctx is done
+func (c *Changes) Run(ctx context.Context) {
+	if c.settings.Retention <= 0 || c.settings.PruneInterval <= 0 {
+		return
+	}
+	tick := time.NewTicker(c.settings.PruneInterval)
+	defer tick.Stop()
+	c.heartbeat.start(c.now())
+	for {
+		deleted, err := c.Prune(ctx)
+		if err != nil && ctx.Err() == nil {
+			logging.FromContext(ctx).Error("Changes are not pruned", "error", err)
+		}
+		c.heartbeat.beat(c.now(), err)
+		if deleted > 0 {
+			logging.FromContext(ctx).Info("Changes are pruned", "deleted", deleted)
+		}
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestChanges(cr ChangeRepository, now time.Time) *Changes {
+	c := NewChanges(cr, WithChangeSettings(ChangeSettings{
+		Retention: time.Hour, PruneInterval: time.Minute, PollInterval: 10 * time.Millisecond,
+	}))
+	c.now = func() time.Time { return now }
+	return c
+}
+
+// notifyingRepository tells about the new changes through a channel of the test
+type notifyingRepository struct {
+	*MockChangeRepository
+	changed chan struct{}
+}
+
+func (r notifyingRepository) Changed() <-chan struct{} {
+	return r.changed
+}
+
+func Test_changes_GetChanges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	now := time.Unix(100, 0)
+	change := domain.UserChange(domain.User{ID: 1, Name: "user"})
+	change.Cursor = 4
+
+	t.Run("ok, changes are returned at once", func(t *testing.T) {
+		cr := NewMockChangeRepository(ctrl)
+		cr.EXPECT().GetChanges(gomock.Any(), int64(3), 10).Times(1).Return([]domain.Change{change}, nil)
+
+		changes, err := newTestChanges(cr, now).GetChanges(context.Background(), 3, 10, time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.Change{change}, changes)
+	})
+
+	t.Run("fail, changes are expired", func(t *testing.T) {
+		cr := NewMockChangeRepository(ctrl)
+		cr.EXPECT().GetChanges(gomock.Any(), int64(1), 10).Times(1).Return(nil, ErrChangesExpired)
+
+		_, err := newTestChanges(cr, now).GetChanges(context.Background(), 1, 10, time.Hour)
+		assert.ErrorIs(t, err, ErrChangesExpired)
+	})
+
+	t.Run("ok, nothing comes in time", func(t *testing.T) {
+		cr := NewMockChangeRepository(ctrl)
+		cr.EXPECT().GetChanges(gomock.Any(), int64(4), 10).MinTimes(2).Return([]domain.Change{}, nil)
+
+		changes, err := newTestChanges(cr, now).GetChanges(context.Background(), 4, 10, 50*time.Millisecond)
+		assert.NoError(t, err)
+		assert.Empty(t, changes)
+	})
+
+	t.Run("ok, waiting read is woken by a notification", func(t *testing.T) {
+		cr := notifyingRepository{NewMockChangeRepository(ctrl), make(chan struct{})}
+		gomock.InOrder(
+			cr.EXPECT().GetChanges(gomock.Any(), int64(3), 10).Times(1).Return([]domain.Change{}, nil),
+			cr.EXPECT().GetChanges(gomock.Any(), int64(3), 10).Times(1).Return([]domain.Change{change}, nil),
+		)
+		changes := newTestChanges(cr, now)
+		changes.settings.PollInterval = time.Hour
+
+		done := make(chan []domain.Change)
+		go func() {
+			read, _ := changes.GetChanges(context.Background(), 3, 10, time.Hour)
+			done <- read
+		}()
+		time.Sleep(20 * time.Millisecond)
+		close(cr.changed)
+
+		select {
+		case read := <-done:
+			assert.Equal(t, []domain.Change{change}, read)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Ожидающее чтение не получило новое изменение")
+		}
+	})
+}
+
+func Test_changes_Consumers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	now := time.Unix(100, 0)
+
+	t.Run("ok, new consumer starts from the beginning", func(t *testing.T) {
+		cr := NewMockChangeRepository(ctrl)
+		cr.EXPECT().GetConsumer(gomock.Any(), "search").Times(1).Return(nil, ErrConsumerNotFound)
+
+		cursor, err := newTestChanges(cr, now).GetConsumerCursor(context.Background(), "search")
+		require.NoError(t, err)
+		assert.Zero(t, cursor)
+	})
+
+	t.Run("ok, cursor is committed", func(t *testing.T) {
+		consumer := domain.ChangeConsumer{Name: "search", Cursor: 5, UpdatedAt: now}
+		cr := NewMockChangeRepository(ctrl)
+		cr.EXPECT().SaveConsumer(gomock.Any(), consumer).Times(1).Return(nil)
+		cr.EXPECT().GetConsumer(gomock.Any(), "search").Times(1).Return(&consumer, nil)
+
+		changes := newTestChanges(cr, now)
+		committed, err := changes.CommitConsumer(context.Background(), "search", 5)
+		require.NoError(t, err)
+		assert.Equal(t, consumer, *committed)
+
+		cursor, err := changes.GetConsumerCursor(context.Background(), "search")
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), cursor)
+	})
+
+	t.Run("fail, consumer not valid", func(t *testing.T) {
+		cr := NewMockChangeRepository(ctrl)
+		cr.EXPECT().SaveConsumer(gomock.Any(), gomock.Any()).Times(0)
+
+		changes := newTestChanges(cr, now)
+		_, err := changes.CommitConsumer(context.Background(), "Search", 5)
+		assert.ErrorIs(t, err, domain.ErrInvalidConsumer)
+		_, err = changes.CommitConsumer(context.Background(), "search", -1)
+		assert.ErrorIs(t, err, domain.ErrInvalidConsumer)
+	})
+}
+
+func Test_changes_Prune(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	now := time.Unix(10000, 0)
+
+	t.Run("ok, changes older than retention are deleted", func(t *testing.T) {
+		cr := NewMockChangeRepository(ctrl)
+		cr.EXPECT().DeleteChanges(gomock.Any(), now.Add(-time.Hour)).Times(1).Return(int64(3), nil)
+
+		deleted, err := newTestChanges(cr, now).Prune(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), deleted)
+	})
+
+	t.Run("ok, changes are kept forever", func(t *testing.T) {
+		cr := NewMockChangeRepository(ctrl)
+		cr.EXPECT().DeleteChanges(gomock.Any(), gomock.Any()).Times(0)
+
+		changes := newTestChanges(cr, now)
+		changes.settings.Retention = 0
+		deleted, err := changes.Prune(context.Background())
+		require.NoError(t, err)
+		assert.Zero(t, deleted)
+	})
+}
+
+func Test_changes_Run(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var clock atomic.Int64
+	failure := errors.New("db is down")
+	cr := NewMockChangeRepository(ctrl)
+	pruned := make(chan struct{}, 1)
+	cr.EXPECT().DeleteChanges(gomock.Any(), gomock.Any()).MinTimes(1).DoAndReturn(func(context.Context, time.Time) (int64, error) {
+		select {
+		case pruned <- struct{}{}:
+		default:
+		}
+		return 0, failure
+	})
+	c := newTestChanges(cr, time.Time{})
+	c.now = func() time.Time { return time.Unix(clock.Load(), 0) }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	<-pruned
+	assert.NoError(t, c.Check(context.Background()), "Проверка не должна падать сразу после запуска")
+	clock.Store(int64(4 * time.Minute / time.Second))
+	// the failure is recorded once the round is over
+	assert.Eventually(t, func() bool {
+		return errors.Is(c.Check(context.Background()), failure)
+	}, time.Second, 5*time.Millisecond, "Долго не удающаяся очистка должна делать сервер неготовым")
+}
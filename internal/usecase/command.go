@@ -0,0 +1,269 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"sync"
+	"time"
+)
+
+// CommandSettings are the defaults of the commands that do not set their own limits
+type CommandSettings struct {
+	AckTimeout time.Duration
+	Attempts   int
+	TTL        time.Duration
+	// PollInterval is how often a waiting poll looks at the queue again: another replica may have queued
+	// a command, or the acknowledgement of a sent one may be overdue
+	PollInterval time.Duration
+}
+
+var DefaultCommandSettings = CommandSettings{
+	AckTimeout: 10 * time.Second, Attempts: 3, TTL: 5 * time.Minute, PollInterval: time.Second,
+}
+
+// Commands delivers the commands to the actuators at least once: a command that is not acknowledged in time
+// is sent again, so the devices should ignore the ids they have already executed. The replicas change a command
+// only in the state they have read it in, so a command queued once is sent by one of them.
+type Commands struct {
+	commandRepository  CommandRepository
+	actuatorRepository ActuatorRepository
+	settings           CommandSettings
+	now                func() time.Time
+
+	// the polls and the acknowledgements of one replica change the commands in turn
+	take sync.Mutex
+	// waiters are woken when a command is queued for the actuator
+	waiters map[int64]chan struct{}
+	m       sync.Mutex
+}
+
+func NewCommands(cr CommandRepository, ar ActuatorRepository, options ...func(*Commands)) *Commands {
+	c := &Commands{
+		commandRepository:  cr,
+		actuatorRepository: ar,
+		settings:           DefaultCommandSettings,
+		now:                time.Now,
+		waiters:            map[int64]chan struct{}{},
+	}
+	for _, o := range options {
+		o(c)
+	}
+	return c
+}
+
+func WithCommandSettings(settings CommandSettings) func(*Commands) {
+	return func(c *Commands) {
+		c.settings = settings
+	}
+}
+
+// SendCommand queues the command for the actuator. The limits the command does not set and ttl 0 are the defaults.
+func (c *Commands) SendCommand(ctx context.Context, actuatorID int64, command *domain.Command, ttl time.Duration) (_ *domain.Command, err error) {
+	ctx, end := startSpan(ctx, "Commands.SendCommand")
+	defer end(&err)
+
+	if _, err := c.actuatorRepository.GetActuatorByID(ctx, actuatorID); err != nil {
+		return nil, err
+	}
+
+	now := c.now()
+	if ttl == 0 {
+		ttl = c.settings.TTL
+	}
+	queued := domain.Command{
+		ActuatorID:  actuatorID,
+		Action:      command.Action,
+		Args:        command.Args,
+		Status:      domain.CommandStatusQueued,
+		MaxAttempts: command.MaxAttempts,
+		AckTimeout:  command.AckTimeout,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+	if queued.MaxAttempts == 0 {
+		queued.MaxAttempts = c.settings.Attempts
+	}
+	if queued.AckTimeout == 0 {
+		queued.AckTimeout = c.settings.AckTimeout
+	}
+	if err := queued.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := c.commandRepository.SaveCommand(ctx, &queued); err != nil {
+		return nil, err
+	}
+	c.wake(actuatorID)
+	return &queued, nil
+}
+
+// GetCommand returns the command of the actuator with the timeouts applied
+func (c *Commands) GetCommand(ctx context.Context, actuatorID, id int64) (_ *domain.Command, err error) {
+	ctx, end := startSpan(ctx, "Commands.GetCommand")
+	defer end(&err)
+
+	command, err := c.getCommand(ctx, actuatorID, id)
+	if err != nil {
+		return nil, err
+	}
+	status, attempts := command.Status, command.Attempts
+	if command.Refresh(c.now()) {
+		updated, err := c.update(ctx, command, status, attempts)
+		if err != nil {
+			return nil, err
+		}
+		if !updated {
+			return c.getCommand(ctx, actuatorID, id)
+		}
+	}
+	return command, nil
+}
+
+// update stores the change of the command read in the status after the attempts,
+// false means another replica has changed it since and nothing is stored
+func (c *Commands) update(ctx context.Context, command *domain.Command, status domain.CommandStatus, attempts int) (bool, error) {
+	err := c.commandRepository.UpdateCommand(ctx, command, status, attempts)
+	if errors.Is(err, ErrCommandChanged) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (c *Commands) getCommand(ctx context.Context, actuatorID, id int64) (*domain.Command, error) {
+	command, err := c.commandRepository.GetCommandByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// the id of another actuator's command is not disclosed
+	if command.ActuatorID != actuatorID {
+		return nil, ErrCommandNotFound
+	}
+	return command, nil
+}
+
+// PollCommands sends the queued commands of the actuator. If there are none, it waits for them up to wait
+// and returns nothing if none has come.
+func (c *Commands) PollCommands(ctx context.Context, actuatorID int64, wait time.Duration) (_ []*domain.Command, err error) {
+	ctx, end := startSpan(ctx, "Commands.PollCommands")
+	defer end(&err)
+
+	if _, err := c.actuatorRepository.GetActuatorByID(ctx, actuatorID); err != nil {
+		return nil, err
+	}
+
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+	tick := time.NewTicker(c.settings.PollInterval)
+	defer tick.Stop()
+	for {
+		// the waiter is taken before the queue, so a command queued in between is not missed
+		woken := c.waiter(actuatorID)
+		sent, err := c.takeQueued(ctx, actuatorID)
+		if err != nil || len(sent) > 0 || wait <= 0 {
+			return sent, err
+		}
+
+		select {
+		case <-woken:
+		case <-tick.C:
+		case <-deadline.C:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// takeQueued applies the timeouts to the active commands of the actuator and marks the queued ones as sent
+func (c *Commands) takeQueued(ctx context.Context, actuatorID int64) ([]*domain.Command, error) {
+	c.take.Lock()
+	defer c.take.Unlock()
+
+	active, err := c.commandRepository.GetActiveCommands(ctx, actuatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := c.now()
+	var sent []*domain.Command
+	for _, command := range active {
+		status, attempts := command.Status, command.Attempts
+		changed := command.Refresh(now)
+		send := command.Status == domain.CommandStatusQueued
+		if send {
+			command.Send(now)
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+		updated, err := c.update(ctx, command, status, attempts)
+		if err != nil {
+			return nil, err
+		}
+		// the command changed by another replica is sent by it, if at all
+		if updated && send {
+			sent = append(sent, command)
+		}
+	}
+	return sent, nil
+}
+
+// Acknowledge records the answer of the actuator to the command, an empty failure means success.
+// A late answer to a command queued again is accepted, a repeated acknowledgement of a retried command is not an error.
+func (c *Commands) Acknowledge(ctx context.Context, actuatorID, id int64, failure string) (_ *domain.Command, err error) {
+	ctx, end := startSpan(ctx, "Commands.Acknowledge")
+	defer end(&err)
+
+	c.take.Lock()
+	defer c.take.Unlock()
+
+	// the command changed by another replica in between is read again and the answer applies to what it has stored
+	for {
+		command, err := c.getCommand(ctx, actuatorID, id)
+		if err != nil {
+			return nil, err
+		}
+		// the device can't know the id of a command it has never got
+		if command.Attempts == 0 {
+			return nil, ErrCommandNotFound
+		}
+		if command.Finished() {
+			if command.Status == domain.CommandStatusAcknowledged && failure == "" {
+				return command, nil
+			}
+			return nil, ErrCommandFinished
+		}
+
+		status, attempts := command.Status, command.Attempts
+		command.Acknowledge(c.now(), failure)
+		updated, err := c.update(ctx, command, status, attempts)
+		if err != nil {
+			return nil, err
+		}
+		if updated {
+			return command, nil
+		}
+	}
+}
+
+func (c *Commands) waiter(actuatorID int64) <-chan struct{} {
+	c.m.Lock()
+	defer c.m.Unlock()
+	w, ok := c.waiters[actuatorID]
+	if !ok {
+		w = make(chan struct{})
+		c.waiters[actuatorID] = w
+	}
+	return w
+}
+
+func (c *Commands) wake(actuatorID int64) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if w, ok := c.waiters[actuatorID]; ok {
+		close(w)
+		delete(c.waiters, actuatorID)
+	}
+}
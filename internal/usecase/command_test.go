@@ -0,0 +1,278 @@
+package usecase
+
+import (
+	"context"
+	"homework/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCommands(cr CommandRepository, ar ActuatorRepository, now time.Time) *Commands {
+	c := NewCommands(cr, ar, WithCommandSettings(CommandSettings{
+		AckTimeout: 10 * time.Second, Attempts: 2, TTL: time.Minute, PollInterval: 10 * time.Millisecond,
+	}))
+	c.now = func() time.Time { return now }
+	return c
+}
+
+func Test_commands_SendCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	now := time.Unix(100, 0)
+
+	t.Run("fail, actuator not found", func(t *testing.T) {
+		ar := NewMockActuatorRepository(ctrl)
+		ar.EXPECT().GetActuatorByID(gomock.Any(), int64(1)).Times(1).Return(nil, ErrActuatorNotFound)
+		cr := NewMockCommandRepository(ctrl)
+		cr.EXPECT().SaveCommand(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := newTestCommands(cr, ar, now).SendCommand(context.Background(), 1, &domain.Command{Action: "switch"}, 0)
+		assert.ErrorIs(t, err, ErrActuatorNotFound)
+	})
+
+	t.Run("fail, command not valid", func(t *testing.T) {
+		ar := NewMockActuatorRepository(ctrl)
+		ar.EXPECT().GetActuatorByID(gomock.Any(), int64(1)).Times(1).Return(&domain.Actuator{ID: 1}, nil)
+		cr := NewMockCommandRepository(ctrl)
+		cr.EXPECT().SaveCommand(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := newTestCommands(cr, ar, now).SendCommand(context.Background(), 1, &domain.Command{Action: "Switch"}, 0)
+		assert.ErrorIs(t, err, domain.ErrInvalidCommand)
+	})
+
+	t.Run("ok, defaults are applied", func(t *testing.T) {
+		ar := NewMockActuatorRepository(ctrl)
+		ar.EXPECT().GetActuatorByID(gomock.Any(), int64(1)).Times(1).Return(&domain.Actuator{ID: 1}, nil)
+		cr := NewMockCommandRepository(ctrl)
+		cr.EXPECT().SaveCommand(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+
+		c, err := newTestCommands(cr, ar, now).SendCommand(context.Background(), 1, &domain.Command{Action: "switch", MaxAttempts: 5}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, domain.CommandStatusQueued, c.Status)
+		assert.Equal(t, int64(1), c.ActuatorID)
+		assert.Equal(t, 5, c.MaxAttempts, "Заданное число попыток не должно заменяться")
+		assert.Equal(t, 10*time.Second, c.AckTimeout)
+		assert.Equal(t, now.Add(time.Minute), c.ExpiresAt)
+	})
+}
+
+func Test_commands_PollCommands(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	now := time.Unix(100, 0)
+
+	t.Run("ok, queued and overdue are sent", func(t *testing.T) {
+		queued := &domain.Command{ID: 1, ActuatorID: 1, Status: domain.CommandStatusQueued, MaxAttempts: 2,
+			AckTimeout: 10 * time.Second, ExpiresAt: now.Add(time.Minute)}
+		overdue := &domain.Command{ID: 2, ActuatorID: 1, Status: domain.CommandStatusSent, Attempts: 1, MaxAttempts: 2,
+			AckTimeout: 10 * time.Second, SentAt: now.Add(-10 * time.Second), ExpiresAt: now.Add(time.Minute)}
+		waiting := &domain.Command{ID: 3, ActuatorID: 1, Status: domain.CommandStatusSent, Attempts: 1, MaxAttempts: 2,
+			AckTimeout: 10 * time.Second, SentAt: now, ExpiresAt: now.Add(time.Minute)}
+
+		ar := NewMockActuatorRepository(ctrl)
+		ar.EXPECT().GetActuatorByID(gomock.Any(), int64(1)).Times(1).Return(&domain.Actuator{ID: 1}, nil)
+		cr := NewMockCommandRepository(ctrl)
+		cr.EXPECT().GetActiveCommands(gomock.Any(), int64(1)).Times(1).Return([]*domain.Command{queued, overdue, waiting}, nil)
+		cr.EXPECT().UpdateCommand(gomock.Any(), queued, domain.CommandStatusQueued, 0).Times(1).Return(nil)
+		cr.EXPECT().UpdateCommand(gomock.Any(), overdue, domain.CommandStatusSent, 1).Times(1).Return(nil)
+
+		sent, err := newTestCommands(cr, ar, now).PollCommands(context.Background(), 1, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []*domain.Command{queued, overdue}, sent)
+		assert.Equal(t, 1, queued.Attempts)
+		assert.Equal(t, 2, overdue.Attempts, "Неподтвержденная команда должна быть отправлена повторно")
+		assert.Equal(t, domain.CommandStatusSent, waiting.Status)
+	})
+
+	t.Run("ok, command taken by another replica is not sent", func(t *testing.T) {
+		queued := &domain.Command{ID: 1, ActuatorID: 1, Status: domain.CommandStatusQueued, MaxAttempts: 2,
+			AckTimeout: 10 * time.Second, ExpiresAt: now.Add(time.Minute)}
+
+		ar := NewMockActuatorRepository(ctrl)
+		ar.EXPECT().GetActuatorByID(gomock.Any(), int64(1)).Times(1).Return(&domain.Actuator{ID: 1}, nil)
+		cr := NewMockCommandRepository(ctrl)
+		cr.EXPECT().GetActiveCommands(gomock.Any(), int64(1)).Times(1).Return([]*domain.Command{queued}, nil)
+		cr.EXPECT().UpdateCommand(gomock.Any(), queued, domain.CommandStatusQueued, 0).Times(1).Return(ErrCommandChanged)
+
+		sent, err := newTestCommands(cr, ar, now).PollCommands(context.Background(), 1, 0)
+		require.NoError(t, err)
+		assert.Empty(t, sent, "Команду, взятую другой репликой, отправлять нельзя")
+	})
+
+	t.Run("ok, nothing comes in time", func(t *testing.T) {
+		ar := NewMockActuatorRepository(ctrl)
+		ar.EXPECT().GetActuatorByID(gomock.Any(), int64(1)).Times(1).Return(&domain.Actuator{ID: 1}, nil)
+		cr := NewMockCommandRepository(ctrl)
+		cr.EXPECT().GetActiveCommands(gomock.Any(), int64(1)).MinTimes(1).Return(nil, nil)
+
+		sent, err := newTestCommands(cr, ar, now).PollCommands(context.Background(), 1, 50*time.Millisecond)
+		assert.NoError(t, err)
+		assert.Empty(t, sent)
+	})
+
+	t.Run("ok, waiting poll is woken by a new command", func(t *testing.T) {
+		var saved []*domain.Command
+		ar := NewMockActuatorRepository(ctrl)
+		ar.EXPECT().GetActuatorByID(gomock.Any(), int64(1)).AnyTimes().Return(&domain.Actuator{ID: 1}, nil)
+		cr := NewMockCommandRepository(ctrl)
+		cr.EXPECT().GetActiveCommands(gomock.Any(), int64(1)).AnyTimes().DoAndReturn(func(context.Context, int64) ([]*domain.Command, error) {
+			return saved, nil
+		})
+		commands := newTestCommands(cr, ar, now)
+		cr.EXPECT().SaveCommand(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(_ context.Context, c *domain.Command) error {
+			c.ID = 1
+			saved = []*domain.Command{c}
+			return nil
+		})
+		cr.EXPECT().UpdateCommand(gomock.Any(), gomock.Any(), domain.CommandStatusQueued, 0).AnyTimes().Return(nil)
+
+		commands.settings.PollInterval = time.Hour
+		done := make(chan []*domain.Command)
+		go func() {
+			sent, _ := commands.PollCommands(context.Background(), 1, time.Hour)
+			done <- sent
+		}()
+		time.Sleep(20 * time.Millisecond)
+		// the poll reads the queue under the same mutex, so it sees the saved command whole
+		commands.take.Lock()
+		_, err := commands.SendCommand(context.Background(), 1, &domain.Command{Action: "switch"}, 0)
+		commands.take.Unlock()
+		require.NoError(t, err)
+
+		select {
+		case sent := <-done:
+			require.Len(t, sent, 1)
+			assert.Equal(t, int64(1), sent[0].ID)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Ожидающий опрос не получил новую команду")
+		}
+	})
+}
+
+func Test_commands_Acknowledge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	now := time.Unix(100, 0)
+	sent := func() *domain.Command {
+		return &domain.Command{ID: 7, ActuatorID: 1, Status: domain.CommandStatusSent, Attempts: 1, MaxAttempts: 2,
+			AckTimeout: 10 * time.Second, SentAt: now, ExpiresAt: now.Add(time.Minute)}
+	}
+
+	t.Run("fail, command of another actuator", func(t *testing.T) {
+		cr := NewMockCommandRepository(ctrl)
+		cr.EXPECT().GetCommandByID(gomock.Any(), int64(7)).Times(1).Return(sent(), nil)
+
+		_, err := newTestCommands(cr, nil, now).Acknowledge(context.Background(), 2, 7, "")
+		assert.ErrorIs(t, err, ErrCommandNotFound)
+	})
+
+	t.Run("fail, command never sent", func(t *testing.T) {
+		c := sent()
+		c.Status, c.Attempts = domain.CommandStatusQueued, 0
+		cr := NewMockCommandRepository(ctrl)
+		cr.EXPECT().GetCommandByID(gomock.Any(), int64(7)).Times(1).Return(c, nil)
+
+		_, err := newTestCommands(cr, nil, now).Acknowledge(context.Background(), 1, 7, "")
+		assert.ErrorIs(t, err, ErrCommandNotFound)
+	})
+
+	t.Run("ok, late ack of a queued again command", func(t *testing.T) {
+		c := sent()
+		c.Status = domain.CommandStatusQueued
+		cr := NewMockCommandRepository(ctrl)
+		cr.EXPECT().GetCommandByID(gomock.Any(), int64(7)).Times(1).Return(c, nil)
+		cr.EXPECT().UpdateCommand(gomock.Any(), c, domain.CommandStatusQueued, 1).Times(1).Return(nil)
+
+		got, err := newTestCommands(cr, nil, now).Acknowledge(context.Background(), 1, 7, "")
+		require.NoError(t, err)
+		assert.Equal(t, domain.CommandStatusAcknowledged, got.Status)
+		assert.Equal(t, now, got.CompletedAt)
+	})
+
+	t.Run("ok, failure is recorded", func(t *testing.T) {
+		cr := NewMockCommandRepository(ctrl)
+		cr.EXPECT().GetCommandByID(gomock.Any(), int64(7)).Times(1).Return(sent(), nil)
+		cr.EXPECT().UpdateCommand(gomock.Any(), gomock.Any(), domain.CommandStatusSent, 1).Times(1).Return(nil)
+
+		got, err := newTestCommands(cr, nil, now).Acknowledge(context.Background(), 1, 7, "jammed")
+		require.NoError(t, err)
+		assert.Equal(t, domain.CommandStatusFailed, got.Status)
+		assert.Equal(t, "jammed", got.Error)
+	})
+
+	t.Run("ok, repeated ack of a retried command", func(t *testing.T) {
+		c := sent()
+		c.Acknowledge(now, "")
+		cr := NewMockCommandRepository(ctrl)
+		cr.EXPECT().GetCommandByID(gomock.Any(), int64(7)).Times(1).Return(c, nil)
+		cr.EXPECT().UpdateCommand(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := newTestCommands(cr, nil, now).Acknowledge(context.Background(), 1, 7, "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("ok, command changed by another replica is read again", func(t *testing.T) {
+		// another replica has sent the command again before the answer to the first attempt is stored
+		resent := sent()
+		resent.Attempts = 2
+		cr := NewMockCommandRepository(ctrl)
+		first := cr.EXPECT().GetCommandByID(gomock.Any(), int64(7)).Times(1).Return(sent(), nil)
+		cr.EXPECT().GetCommandByID(gomock.Any(), int64(7)).Times(1).Return(resent, nil).After(first)
+		cr.EXPECT().UpdateCommand(gomock.Any(), gomock.Any(), domain.CommandStatusSent, 1).Times(1).Return(ErrCommandChanged)
+		cr.EXPECT().UpdateCommand(gomock.Any(), resent, domain.CommandStatusSent, 2).Times(1).Return(nil)
+
+		got, err := newTestCommands(cr, nil, now).Acknowledge(context.Background(), 1, 7, "")
+		require.NoError(t, err)
+		assert.Equal(t, domain.CommandStatusAcknowledged, got.Status)
+		assert.Equal(t, 2, got.Attempts)
+	})
+
+	t.Run("fail, finished with another result", func(t *testing.T) {
+		c := sent()
+		c.Acknowledge(now, "")
+		cr := NewMockCommandRepository(ctrl)
+		cr.EXPECT().GetCommandByID(gomock.Any(), int64(7)).Times(1).Return(c, nil)
+
+		_, err := newTestCommands(cr, nil, now).Acknowledge(context.Background(), 1, 7, "jammed")
+		assert.ErrorIs(t, err, ErrCommandFinished)
+	})
+}
+
+func Test_commands_GetCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	now := time.Unix(100, 0)
+
+	expired := func() *domain.Command {
+		return &domain.Command{ID: 7, ActuatorID: 1, Status: domain.CommandStatusQueued, MaxAttempts: 2,
+			AckTimeout: 10 * time.Second, ExpiresAt: now}
+	}
+
+	t.Run("ok, expired command is saved", func(t *testing.T) {
+		c := expired()
+		cr := NewMockCommandRepository(ctrl)
+		cr.EXPECT().GetCommandByID(gomock.Any(), int64(7)).Times(1).Return(c, nil)
+		cr.EXPECT().UpdateCommand(gomock.Any(), c, domain.CommandStatusQueued, 0).Times(1).Return(nil)
+
+		got, err := newTestCommands(cr, nil, now).GetCommand(context.Background(), 1, 7)
+		require.NoError(t, err)
+		assert.Equal(t, domain.CommandStatusExpired, got.Status, "Истекшая команда должна сохраниться с новым статусом")
+	})
+
+	t.Run("ok, command changed by another replica is read again", func(t *testing.T) {
+		stored := expired()
+		stored.Status = domain.CommandStatusExpired
+		cr := NewMockCommandRepository(ctrl)
+		first := cr.EXPECT().GetCommandByID(gomock.Any(), int64(7)).Times(1).Return(expired(), nil)
+		cr.EXPECT().GetCommandByID(gomock.Any(), int64(7)).Times(1).Return(stored, nil).After(first)
+		cr.EXPECT().UpdateCommand(gomock.Any(), gomock.Any(), domain.CommandStatusQueued, 0).Times(1).Return(ErrCommandChanged)
+
+		got, err := newTestCommands(cr, nil, now).GetCommand(context.Background(), 1, 7)
+		require.NoError(t, err)
+		assert.Same(t, stored, got)
+	})
+}
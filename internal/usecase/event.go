@@ -2,25 +2,104 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"homework/internal/domain"
+	"homework/internal/logging"
 	"time"
 )
 
 type Event struct {
 	eventRepository  EventRepository
 	sensorRepository SensorRepository
+	metrics          EventMetrics
+	limiter          *RateLimiter
+	sensorTypes      *SensorTypes
+	virtual          *VirtualSensors
 }
 
-func NewEvent(er EventRepository, sr SensorRepository) *Event {
-	return &Event{eventRepository: er, sensorRepository: sr}
+func NewEvent(er EventRepository, sr SensorRepository, options ...func(*Event)) *Event {
+	e := &Event{eventRepository: er, sensorRepository: sr}
+	for _, o := range options {
+		o(e)
+	}
+	return e
+}
+
+// WithEventMetrics makes the usecase report the received and the rejected events
+func WithEventMetrics(m EventMetrics) func(*Event) {
+	return func(e *Event) {
+		e.metrics = m
+	}
+}
+
+// WithEventRateLimiter limits the events of every sensor
+func WithEventRateLimiter(l *RateLimiter) func(*Event) {
+	return func(e *Event) {
+		e.limiter = l
+	}
+}
+
+// WithEventSensorTypes checks the readings of the events by the types of the registry
+func WithEventSensorTypes(t *SensorTypes) func(*Event) {
+	return func(e *Event) {
+		e.sensorTypes = t
+	}
+}
+
+// WithEventVirtualSensors recomputes the virtual sensors that read the sensor of every stored event
+func WithEventVirtualSensors(v *VirtualSensors) func(*Event) {
+	return func(e *Event) {
+		e.virtual = v
+	}
 }
 
-func (e *Event) ReceiveEvent(ctx context.Context, event *domain.Event) error {
+func (e *Event) rejected(ctx context.Context, event *domain.Event, reason string) {
+	logging.FromContext(ctx).Debug("Event is rejected", "serial_number", event.SensorSerialNumber, "reason", reason)
+	if e.metrics != nil {
+		e.metrics.EventRejected(reason)
+	}
+}
+
+func (e *Event) ReceiveEvent(ctx context.Context, event *domain.Event) (err error) {
+	ctx, end := startSpan(ctx, "Event.ReceiveEvent")
+	defer end(&err)
+
 	if event.Timestamp.IsZero() {
+		e.rejected(ctx, event, RejectReasonBadTimestamp)
 		return ErrInvalidEventTimestamp
 	}
 	s, err := e.sensorRepository.GetSensorBySerialNumber(ctx, event.SensorSerialNumber)
 	if err != nil {
+		if errors.Is(err, ErrSensorNotFound) {
+			if e.limiter != nil {
+				if lErr := e.limiter.AllowUnknownSerial(ctx); lErr != nil {
+					e.rejected(ctx, event, RejectReasonThrottled)
+					return lErr
+				}
+			}
+			e.rejected(ctx, event, RejectReasonUnknownSerial)
+		}
+		return err
+	}
+	if !s.IsActive {
+		e.rejected(ctx, event, RejectReasonInactive)
+		return ErrSensorInactive
+	}
+	if s.Type == domain.SensorTypeVirtual {
+		return fmt.Errorf("%w: virtual sensor computes its events itself", ErrWrongSensorType)
+	}
+	if e.limiter != nil {
+		if err = e.limiter.AllowSensor(ctx, s); err != nil {
+			e.rejected(ctx, event, RejectReasonThrottled)
+			return err
+		}
+	}
+	if err = e.checkReadings(ctx, s, event); err != nil {
+		return err
+	}
+	if err = calibrate(s, event); err != nil {
+		e.rejected(ctx, event, RejectReasonBadReadings)
 		return err
 	}
 
@@ -28,16 +107,97 @@ func (e *Event) ReceiveEvent(ctx context.Context, event *domain.Event) error {
 	s.LastActivity = event.Timestamp
 	s.CurrentState = event.Payload
 
+	// the event and the sensor are saved and recorded into the change feed in transactions of their own,
+	// so a consumer may see the event before the new state of the sensor, or without it if the sensor fails to save.
+	// The event sent again is taken for a repeat and saves the sensor then.
 	if err = e.eventRepository.SaveEvent(ctx, event); err != nil {
 		return err
 	}
-	return e.sensorRepository.SaveSensor(ctx, s)
+	if err = e.sensorRepository.SaveSensor(ctx, s); err != nil {
+		return err
+	}
+	// the event is stored, so the virtual sensors that have failed don't fail it
+	if e.virtual != nil {
+		if vErr := e.virtual.Update(ctx, s, event.Timestamp); vErr != nil {
+			logging.FromContext(ctx).Warn("Virtual sensors are not updated", "sensor_id", s.ID, "error", vErr)
+		}
+	}
+
+	if e.metrics != nil {
+		e.metrics.EventReceived(s.Type)
+		// the server stamps the events without the time of the device, they have no lag to measure
+		if !event.Received.IsZero() {
+			e.metrics.EventLag(s.Type, event.Received.Sub(event.Timestamp))
+		}
+	}
+	return nil
+}
+
+// checkReadings checks the payload against the type of the sensor and fills the units the sensor has omitted.
+// Any readings are accepted without the registry.
+func (e *Event) checkReadings(ctx context.Context, s *domain.Sensor, event *domain.Event) error {
+	if e.sensorTypes == nil {
+		return nil
+	}
+	spec, err := lookupSensorType(ctx, e.sensorTypes, s.Type)
+	if err != nil {
+		return err
+	}
+	payload, err := spec.CheckPayload(event.Payload)
+	if err != nil {
+		e.rejected(ctx, event, RejectReasonBadReadings)
+		return fmt.Errorf("%w: %w", ErrInvalidReadings, err)
+	}
+	event.Payload = payload
+	return nil
+}
+
+// calibrate converts the readings of the event by the calibration of the sensor, the raw ones are kept in Raw
+func calibrate(s *domain.Sensor, event *domain.Event) error {
+	if len(s.Calibration) == 0 {
+		return nil
+	}
+	calibrated, err := event.Payload.Calibrate(s.Calibration)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidReadings, err)
+	}
+	event.Raw, event.Payload = event.Payload, calibrated
+	return nil
 }
 
-func (e *Event) GetLastEventBySensorID(ctx context.Context, id int64) (*domain.Event, error) {
+func (e *Event) GetLastEventBySensorID(ctx context.Context, id int64) (_ *domain.Event, err error) {
+	ctx, end := startSpan(ctx, "Event.GetLastEventBySensorID")
+	defer end(&err)
 	return e.eventRepository.GetLastEventBySensorID(ctx, id)
 }
 
-func (e *Event) GetHistoryBySensorID(ctx context.Context, id int64, from, to time.Time) ([]*domain.Event, error) {
+func (e *Event) GetHistoryBySensorID(ctx context.Context, id int64, from, to time.Time) (_ []*domain.Event, err error) {
+	ctx, end := startSpan(ctx, "Event.GetHistoryBySensorID")
+	defer end(&err)
 	return e.eventRepository.GetHistoryBySensorID(ctx, id, from, to)
 }
+
+// StreamHistoryBySensorID passes events of the sensor in [from, to] to fn one by one.
+// If the repository can't stream, the history is loaded with GetHistoryBySensorID.
+func (e *Event) StreamHistoryBySensorID(ctx context.Context, id int64, from, to time.Time, fn func(*domain.Event) error) (err error) {
+	ctx, end := startSpan(ctx, "Event.StreamHistoryBySensorID")
+	defer end(&err)
+	return streamHistory(ctx, e.eventRepository, id, from, to, fn)
+}
+
+func streamHistory(ctx context.Context, er EventRepository, id int64, from, to time.Time, fn func(*domain.Event) error) error {
+	if s, ok := er.(EventHistoryStreamer); ok {
+		return s.StreamHistoryBySensorID(ctx, id, from, to, fn)
+	}
+
+	events, err := er.GetHistoryBySensorID(ctx, id, from, to)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
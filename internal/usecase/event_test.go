@@ -11,6 +11,24 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+type eventMetrics struct {
+	received []domain.SensorType
+	lags     []time.Duration
+	rejected []string
+}
+
+func (m *eventMetrics) EventReceived(sensorType domain.SensorType) {
+	m.received = append(m.received, sensorType)
+}
+
+func (m *eventMetrics) EventLag(_ domain.SensorType, lag time.Duration) {
+	m.lags = append(m.lags, lag)
+}
+
+func (m *eventMetrics) EventRejected(reason string) {
+	m.rejected = append(m.rejected, reason)
+}
+
 func Test_event_ReceiveEvent(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -19,10 +37,12 @@ func Test_event_ReceiveEvent(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
-		e := NewEvent(nil, nil)
+		m := &eventMetrics{}
+		e := NewEvent(nil, nil, WithEventMetrics(m))
 
 		err := e.ReceiveEvent(ctx, &domain.Event{})
 		assert.ErrorIs(t, err, ErrInvalidEventTimestamp)
+		assert.Equal(t, []string{RejectReasonBadTimestamp}, m.rejected)
 	})
 
 	t.Run("err, sensor not found", func(t *testing.T) {
@@ -31,14 +51,58 @@ func Test_event_ReceiveEvent(t *testing.T) {
 
 		sr := NewMockSensorRepository(ctrl)
 
-		sr.EXPECT().GetSensorBySerialNumber(ctx, gomock.Any()).Times(1).Return(nil, ErrSensorNotFound)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), gomock.Any()).Times(1).Return(nil, ErrSensorNotFound)
 
-		e := NewEvent(nil, sr)
+		m := &eventMetrics{}
+		e := NewEvent(nil, sr, WithEventMetrics(m))
 
 		err := e.ReceiveEvent(ctx, &domain.Event{
 			Timestamp: time.Now(),
 		})
 		assert.ErrorIs(t, err, ErrSensorNotFound)
+		assert.Equal(t, []string{RejectReasonUnknownSerial}, m.rejected)
+	})
+
+	t.Run("err, sensor is inactive", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "123").Times(1).Return(&domain.Sensor{ID: 1}, nil)
+
+		m := &eventMetrics{}
+		e := NewEvent(nil, sr, WithEventMetrics(m))
+
+		err := e.ReceiveEvent(ctx, &domain.Event{
+			Timestamp:          time.Now(),
+			SensorSerialNumber: "123",
+		})
+		assert.ErrorIs(t, err, ErrSensorInactive)
+		assert.Equal(t, []string{RejectReasonInactive}, m.rejected)
+	})
+
+	t.Run("err, sensor is throttled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "123").Times(1).Return(&domain.Sensor{
+			ID: 1, SerialNumber: "123", Type: domain.SensorTypeADC, IsActive: true,
+		}, nil)
+
+		rr := NewMockRateLimitRepository(ctrl)
+		rr.EXPECT().TakeToken(gomock.Any(), "sensor:123", gomock.Any()).Times(1).Return(time.Second, nil)
+
+		m := &eventMetrics{}
+		limiter := NewRateLimiter(rr, RateLimits{Sensor: domain.RateLimit{Rate: 1, Burst: 1}})
+		e := NewEvent(nil, sr, WithEventMetrics(m), WithEventRateLimiter(limiter))
+
+		err := e.ReceiveEvent(ctx, &domain.Event{
+			Timestamp:          time.Now(),
+			SensorSerialNumber: "123",
+		})
+		assert.ErrorIs(t, err, ErrRateLimited)
+		assert.Equal(t, []string{RejectReasonThrottled}, m.rejected)
 	})
 
 	t.Run("err, event save error", func(t *testing.T) {
@@ -47,13 +111,14 @@ func Test_event_ReceiveEvent(t *testing.T) {
 
 		sr := NewMockSensorRepository(ctrl)
 
-		sr.EXPECT().GetSensorBySerialNumber(ctx, "123").Times(1).Return(&domain.Sensor{
-			ID: 1,
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "123").Times(1).Return(&domain.Sensor{
+			ID:       1,
+			IsActive: true,
 		}, nil)
 
 		er := NewMockEventRepository(ctrl)
 		expectedError := errors.New("some error")
-		er.EXPECT().SaveEvent(ctx, gomock.Any()).Times(1).Return(expectedError)
+		er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Times(1).Return(expectedError)
 
 		e := NewEvent(er, sr)
 
@@ -70,14 +135,15 @@ func Test_event_ReceiveEvent(t *testing.T) {
 
 		sr := NewMockSensorRepository(ctrl)
 
-		sr.EXPECT().GetSensorBySerialNumber(ctx, "123").Times(1).Return(&domain.Sensor{
-			ID: 1,
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "123").Times(1).Return(&domain.Sensor{
+			ID:       1,
+			IsActive: true,
 		}, nil)
 		expectedError := errors.New("some error")
-		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Times(1).Return(expectedError)
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(1).Times(1).Return(expectedError)
 
 		er := NewMockEventRepository(ctrl)
-		er.EXPECT().SaveEvent(ctx, gomock.Any()).Times(1).Return(nil)
+		er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Times(1).Return(nil)
 
 		e := NewEvent(er, sr)
 
@@ -94,29 +160,60 @@ func Test_event_ReceiveEvent(t *testing.T) {
 
 		sr := NewMockSensorRepository(ctrl)
 
-		sr.EXPECT().GetSensorBySerialNumber(ctx, "123").Times(1).Return(&domain.Sensor{
-			ID: 1,
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "123").Times(1).Return(&domain.Sensor{
+			ID:       1,
+			Type:     domain.SensorTypeADC,
+			IsActive: true,
 		}, nil)
-		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Do(func(_ context.Context, s *domain.Sensor) {
-			assert.Equal(t, int64(8), s.CurrentState)
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(1).Do(func(_ context.Context, s *domain.Sensor) {
+			assert.Equal(t, domain.IntPayload(8), s.CurrentState)
 			assert.NotEmpty(t, s.LastActivity)
 		})
 
 		er := NewMockEventRepository(ctrl)
-		er.EXPECT().SaveEvent(ctx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, event *domain.Event) error {
+		er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, event *domain.Event) error {
 			assert.Equal(t, int64(1), event.SensorID)
 			assert.Equal(t, "123", event.SensorSerialNumber)
 
 			return nil
 		})
 
-		e := NewEvent(er, sr)
+		m := &eventMetrics{}
+		e := NewEvent(er, sr, WithEventMetrics(m))
 		err := e.ReceiveEvent(ctx, &domain.Event{
 			Timestamp:          time.Now(),
 			SensorSerialNumber: "123",
-			Payload:            8,
+			Payload:            domain.IntPayload(8),
 		})
 		assert.NoError(t, err)
+		assert.Equal(t, []domain.SensorType{domain.SensorTypeADC}, m.received)
+		assert.Empty(t, m.lags, "Время события поставил сервер, задержки нет")
+		assert.Empty(t, m.rejected)
+	})
+
+	t.Run("ok, lag of the time of the device", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "123").Times(1).Return(&domain.Sensor{
+			ID: 1, Type: domain.SensorTypeADC, IsActive: true,
+		}, nil)
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(1)
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Times(1)
+
+		m := &eventMetrics{}
+		e := NewEvent(er, sr, WithEventMetrics(m))
+		received := time.Now()
+		err := e.ReceiveEvent(ctx, &domain.Event{
+			Timestamp:          received.Add(-3 * time.Second),
+			Received:           received,
+			SensorSerialNumber: "123",
+			Payload:            domain.IntPayload(8),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []time.Duration{3 * time.Second}, m.lags)
 	})
 }
 
@@ -129,7 +226,7 @@ func Test_event_GetHistoryBySensorID(t *testing.T) {
 		defer cancel()
 
 		er := NewMockEventRepository(ctrl)
-		er.EXPECT().GetHistoryBySensorID(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, ErrEventNotFound)
+		er.EXPECT().GetHistoryBySensorID(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, ErrEventNotFound)
 
 		e := NewEvent(er, nil)
 
@@ -148,14 +245,14 @@ func Test_event_GetHistoryBySensorID(t *testing.T) {
 			event := &domain.Event{
 				Timestamp: time.Now(),
 				SensorID:  sensorID,
-				Payload:   int64(i),
+				Payload:   domain.IntPayload(int64(i)),
 			}
 			originalEvents = append(originalEvents, event)
 			time.Sleep(10 * time.Millisecond)
 		}
 
 		er := NewMockEventRepository(ctrl)
-		er.EXPECT().GetHistoryBySensorID(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(originalEvents, nil)
+		er.EXPECT().GetHistoryBySensorID(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(originalEvents, nil)
 
 		events, err := er.GetHistoryBySensorID(ctx, sensorID, time.Time{}, time.Now())
 		assert.NoError(t, err)
@@ -167,3 +264,65 @@ func Test_event_GetHistoryBySensorID(t *testing.T) {
 		}
 	})
 }
+
+func Test_event_StreamHistoryBySensorID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("err, event not found", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().GetHistoryBySensorID(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, ErrEventNotFound)
+
+		e := NewEvent(er, nil)
+
+		err := e.StreamHistoryBySensorID(ctx, 0, time.Time{}, time.Time{}, func(*domain.Event) error { return nil })
+		assert.ErrorIs(t, err, ErrEventNotFound)
+	})
+
+	t.Run("err, callback error stops the stream", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().GetHistoryBySensorID(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).
+			Return([]*domain.Event{{Payload: domain.IntPayload(1)}, {Payload: domain.IntPayload(2)}}, nil)
+
+		e := NewEvent(er, nil)
+
+		expectedError := errors.New("some error")
+		calls := 0
+		err := e.StreamHistoryBySensorID(ctx, 0, time.Time{}, time.Now(), func(*domain.Event) error {
+			calls++
+			return expectedError
+		})
+		assert.ErrorIs(t, err, expectedError)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("ok, repository streams by itself", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		streamer := NewMockEventHistoryStreamer(ctrl)
+		streamer.EXPECT().StreamHistoryBySensorID(gomock.Any(), int64(1), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, _ int64, _, _ time.Time, fn func(*domain.Event) error) error {
+				return fn(&domain.Event{SensorID: 1, Payload: domain.IntPayload(5)})
+			})
+
+		e := NewEvent(struct {
+			EventRepository
+			EventHistoryStreamer
+		}{NewMockEventRepository(ctrl), streamer}, nil)
+
+		var got []*domain.Event
+		err := e.StreamHistoryBySensorID(ctx, 1, time.Time{}, time.Now(), func(event *domain.Event) error {
+			got = append(got, event)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []*domain.Event{{SensorID: 1, Payload: domain.IntPayload(5)}}, got)
+	})
+}
@@ -0,0 +1,354 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+const exportBatchSize = 1024
+
+// exportPruneInterval is how often Run looks for the expired exports
+const exportPruneInterval = time.Minute
+
+// exportRow is a single row of the parquet file produced by an export
+type exportRow struct {
+	SensorID     int64     `parquet:"sensor_id"`
+	SerialNumber string    `parquet:"serial_number,dict"`
+	Timestamp    time.Time `parquet:"timestamp,timestamp(millisecond)"`
+	Payload      int64     `parquet:"payload"`
+	// Readings holds every channel of the event, Payload is the integer part of the main one
+	Readings []exportReading `parquet:"readings,list"`
+}
+
+type exportReading struct {
+	Channel string  `parquet:"channel,dict"`
+	Value   float64 `parquet:"value"`
+	Unit    string  `parquet:"unit,dict"`
+}
+
+type Export struct {
+	eventRepository  EventRepository
+	sensorRepository SensorRepository
+	dir              string
+	// ttl is how long a finished export and its file are kept, forever if 0
+	ttl time.Duration
+	now func() time.Time
+	// heartbeat is the one of the pruning Run
+	heartbeat heartbeat
+
+	exports map[int64]*domain.Export
+	lastID  int64
+	m       sync.RWMutex
+	wg      sync.WaitGroup
+}
+
+// NewExport creates an export usecase which writes the resulting files into dir
+func NewExport(er EventRepository, sr SensorRepository, dir string, options ...func(*Export)) *Export {
+	e := &Export{
+		eventRepository:  er,
+		sensorRepository: sr,
+		dir:              dir,
+		now:              time.Now,
+		exports:          map[int64]*domain.Export{},
+	}
+	for _, o := range options {
+		o(e)
+	}
+	return e
+}
+
+// WithExportTTL makes Run forget the exports finished longer than ttl ago and delete their files
+func WithExportTTL(ttl time.Duration) func(*Export) {
+	return func(e *Export) {
+		e.ttl = ttl
+	}
+}
+
+// StartExport registers a new export of the sensors' history in [from, to] and runs it in the background
+func (e *Export) StartExport(ctx context.Context, sensorIDs []int64, from, to time.Time) (*domain.Export, error) {
+	if len(sensorIDs) == 0 {
+		return nil, ErrEmptyExport
+	}
+
+	ids := slices.Clone(sensorIDs)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	sensors := make([]*domain.Sensor, 0, len(ids))
+	for _, id := range ids {
+		s, err := e.sensorRepository.GetSensorByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		sensors = append(sensors, s)
+	}
+
+	e.m.Lock()
+	e.lastID++
+	export := &domain.Export{
+		ID:        e.lastID,
+		SensorIDs: ids,
+		From:      from,
+		To:        to,
+		Status:    domain.ExportStatusPending,
+		CreatedAt: e.now(),
+	}
+	e.exports[export.ID] = export
+	res := *export
+	e.m.Unlock()
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		e.run(context.WithoutCancel(ctx), export.ID, sensors, from, to)
+	}()
+
+	return &res, ctx.Err()
+}
+
+// GetExport returns a snapshot of the export state
+func (e *Export) GetExport(ctx context.Context, id int64) (*domain.Export, error) {
+	e.m.RLock()
+	defer e.m.RUnlock()
+
+	export, has := e.exports[id]
+	if !has {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, ErrExportNotFound
+	}
+	res := *export
+	return &res, ctx.Err()
+}
+
+// GetExportFile returns the path to the file of a completed export
+func (e *Export) GetExportFile(ctx context.Context, id int64) (string, error) {
+	export, err := e.GetExport(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if export.Status != domain.ExportStatusCompleted {
+		return "", ErrExportNotReady
+	}
+	return export.Path, nil
+}
+
+// Wait blocks until all running exports are finished
+func (e *Export) Wait() {
+	e.wg.Wait()
+}
+
+// Drain waits for the running exports like Wait, but gives up when the context is done
+func (e *Export) Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		e.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("exports are still running: %w", ctx.Err())
+	}
+}
+
+// Check reports whether the exports can be written, it is used by the readiness probe
+func (e *Export) Check(ctx context.Context) error {
+	f, err := os.CreateTemp(e.dir, "check-*")
+	if err != nil {
+		return fmt.Errorf("export dir is not writable: %w", err)
+	}
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+	return ctx.Err()
+}
+
+// Prune forgets the exports finished longer than the ttl ago and deletes their files. The files left by
+// the previous runs of the server are deleted by their age, the exports they belong to are gone.
+func (e *Export) Prune(ctx context.Context) (_ int, err error) {
+	ctx, end := startSpan(ctx, "Export.Prune")
+	defer end(&err)
+
+	if e.ttl <= 0 {
+		return 0, nil
+	}
+	expired := e.now().Add(-e.ttl)
+	var errs []error
+	e.m.Lock()
+	pruned := 0
+	kept := map[string]bool{}
+	for id, export := range e.exports {
+		if export.CompletedAt.IsZero() || export.CompletedAt.After(expired) {
+			kept[export.Path] = true
+			continue
+		}
+		if export.Path != "" {
+			if err := os.Remove(export.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		delete(e.exports, id)
+		pruned++
+	}
+	e.m.Unlock()
+
+	// the running exports write to the temporary files, they don't match
+	files, err := filepath.Glob(filepath.Join(e.dir, "export-*.parquet"))
+	if err != nil {
+		return pruned, err
+	}
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if kept[file] || err != nil || info.ModTime().After(expired) {
+			continue
+		}
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return pruned, errors.Join(errs...)
+}
+
+// CheckPruning fails if Run has not pruned the exports successfully for a few intervals
+func (e *Export) CheckPruning(ctx context.Context) error {
+	if err := e.heartbeat.check(e.now(), exportPruneInterval); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// Run prunes the exports until ctx is done
+func (e *Export) Run(ctx context.Context) {
+	if e.ttl <= 0 {
+		return
+	}
+	tick := time.NewTicker(exportPruneInterval)
+	defer tick.Stop()
+	e.heartbeat.start(e.now())
+	for {
+		pruned, err := e.Prune(ctx)
+		if err != nil && ctx.Err() == nil {
+			logging.FromContext(ctx).Error("Exports are not pruned", "error", err)
+		}
+		e.heartbeat.beat(e.now(), err)
+		if pruned > 0 {
+			logging.FromContext(ctx).Info("Exports are pruned", "pruned", pruned)
+		}
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (e *Export) setStatus(id int64, status domain.ExportStatus, path string, err error) {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	export := e.exports[id]
+	export.Status = status
+	export.Path = path
+	if err != nil {
+		export.Error = err.Error()
+	}
+	if status == domain.ExportStatusCompleted || status == domain.ExportStatusFailed {
+		export.CompletedAt = e.now()
+	}
+}
+
+func (e *Export) run(ctx context.Context, id int64, sensors []*domain.Sensor, from, to time.Time) {
+	e.setStatus(id, domain.ExportStatusRunning, "", nil)
+
+	path := filepath.Join(e.dir, fmt.Sprintf("export-%d.parquet", id))
+	if err := e.writeParquet(ctx, path, sensors, from, to); err != nil {
+		// nobody is waiting for the export, the log is the only place the cause is seen besides its status
+		logging.FromContext(ctx).Error("Export has failed", "export_id", id, "error", err)
+		e.setStatus(id, domain.ExportStatusFailed, "", err)
+		return
+	}
+	e.setStatus(id, domain.ExportStatusCompleted, path, nil)
+}
+
+func (e *Export) writeParquet(ctx context.Context, path string, sensors []*domain.Sensor, from, to time.Time) (err error) {
+	// the file becomes visible under its final name only after it's fully written
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("can't create export file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	w := parquet.NewGenericWriter[exportRow](f)
+	batch := make([]exportRow, 0, exportBatchSize)
+	flush := func() error {
+		if _, err := w.Write(batch); err != nil {
+			return fmt.Errorf("can't write export rows: %w", err)
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for _, s := range sensors {
+		err = streamHistory(ctx, e.eventRepository, s.ID, from, to, func(event *domain.Event) error {
+			batch = append(batch, exportRow{
+				SensorID:     s.ID,
+				SerialNumber: s.SerialNumber,
+				Timestamp:    event.Timestamp,
+				Payload:      event.Payload.Int(),
+				Readings:     exportReadings(event.Payload),
+			})
+			if len(batch) == exportBatchSize {
+				return flush()
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, ErrEventNotFound) {
+			return err
+		}
+	}
+
+	if err = flush(); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return fmt.Errorf("can't finish export file: %w", err)
+	}
+	if err = f.Sync(); err != nil {
+		return fmt.Errorf("can't sync export file: %w", err)
+	}
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("can't close export file: %w", err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("can't rename export file: %w", err)
+	}
+	return nil
+}
+
+func exportReadings(p domain.Payload) []exportReading {
+	readings := make([]exportReading, len(p))
+	for i, r := range p {
+		readings[i] = exportReading{Channel: r.Channel, Value: r.Value.Float64(), Unit: r.Unit}
+	}
+	return readings
+}
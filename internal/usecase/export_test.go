@@ -0,0 +1,182 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_export_StartExport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("err, no sensors", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		e := NewExport(nil, nil, t.TempDir())
+
+		_, err := e.StartExport(ctx, nil, time.Time{}, time.Now())
+		assert.ErrorIs(t, err, ErrEmptyExport)
+	})
+
+	t.Run("err, sensor not found", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(nil, ErrSensorNotFound)
+
+		e := NewExport(nil, sr, t.TempDir())
+
+		_, err := e.StartExport(ctx, []int64{1}, time.Time{}, time.Now())
+		assert.ErrorIs(t, err, ErrSensorNotFound)
+	})
+
+	t.Run("ok, export failed", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1}, nil)
+
+		er := NewMockEventRepository(ctrl)
+		expectedError := errors.New("some error")
+		er.EXPECT().GetHistoryBySensorID(gomock.Any(), int64(1), gomock.Any(), gomock.Any()).Times(1).Return(nil, expectedError)
+
+		e := NewExport(er, sr, t.TempDir())
+
+		export, err := e.StartExport(ctx, []int64{1}, time.Time{}, time.Now())
+		require.NoError(t, err)
+		e.Wait()
+
+		export, err = e.GetExport(ctx, export.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ExportStatusFailed, export.Status)
+		assert.Equal(t, expectedError.Error(), export.Error)
+
+		_, err = e.GetExportFile(ctx, export.ID)
+		assert.ErrorIs(t, err, ErrExportNotReady)
+	})
+
+	t.Run("ok, parquet is written", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1, SerialNumber: "0123456789"}, nil)
+		sr.EXPECT().GetSensorByID(ctx, int64(2)).Times(1).Return(&domain.Sensor{ID: 2, SerialNumber: "9876543210"}, nil)
+
+		now := time.Now().Truncate(time.Millisecond)
+		size := exportBatchSize + 10
+		events := make([]*domain.Event, 0, size)
+		for i := 0; i < size; i++ {
+			events = append(events, &domain.Event{
+				Timestamp: now.Add(time.Duration(i) * time.Second),
+				SensorID:  1,
+				Payload:   domain.IntPayload(int64(i)),
+			})
+		}
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().GetHistoryBySensorID(gomock.Any(), int64(1), gomock.Any(), gomock.Any()).Times(1).Return(events, nil)
+		er.EXPECT().GetHistoryBySensorID(gomock.Any(), int64(2), gomock.Any(), gomock.Any()).Times(1).Return(nil, ErrEventNotFound)
+
+		e := NewExport(er, sr, t.TempDir())
+
+		export, err := e.StartExport(ctx, []int64{2, 1, 2}, time.Time{}, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1, 2}, export.SensorIDs)
+		e.Wait()
+
+		path, err := e.GetExportFile(ctx, export.ID)
+		require.NoError(t, err)
+
+		rows, err := parquet.ReadFile[exportRow](path)
+		require.NoError(t, err)
+		require.Len(t, rows, size)
+		for i, row := range rows {
+			assert.Equal(t, int64(1), row.SensorID)
+			assert.Equal(t, "0123456789", row.SerialNumber)
+			assert.True(t, events[i].Timestamp.Equal(row.Timestamp))
+			assert.Equal(t, events[i].Payload.Int(), row.Payload)
+		}
+
+		_, err = os.Stat(path + ".tmp")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func Test_export_GetExport(t *testing.T) {
+	t.Run("err, export not found", func(t *testing.T) {
+		e := NewExport(nil, nil, t.TempDir())
+
+		_, err := e.GetExport(context.Background(), 1)
+		assert.ErrorIs(t, err, ErrExportNotFound)
+
+		_, err = e.GetExportFile(context.Background(), 1)
+		assert.ErrorIs(t, err, ErrExportNotFound)
+	})
+
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		e := NewExport(nil, nil, t.TempDir())
+
+		_, err := e.GetExport(ctx, 1)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func Test_export_Prune(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sr := NewMockSensorRepository(ctrl)
+	sr.EXPECT().GetSensorByID(gomock.Any(), int64(1)).Times(1).Return(&domain.Sensor{ID: 1}, nil)
+	er := NewMockEventRepository(ctrl)
+	er.EXPECT().GetHistoryBySensorID(gomock.Any(), int64(1), gomock.Any(), gomock.Any()).Times(1).Return(nil, ErrEventNotFound)
+
+	dir := t.TempDir()
+	now := time.Now()
+	e := NewExport(er, sr, dir, WithExportTTL(time.Hour))
+	e.now = func() time.Time { return now }
+
+	export, err := e.StartExport(context.Background(), []int64{1}, time.Time{}, now)
+	require.NoError(t, err)
+	e.Wait()
+	path, err := e.GetExportFile(context.Background(), export.ID)
+	require.NoError(t, err)
+
+	// a file of the previous run of the server and a file that isn't old yet
+	stale, fresh := filepath.Join(dir, "export-99.parquet"), filepath.Join(dir, "export-100.parquet")
+	require.NoError(t, os.WriteFile(stale, nil, 0o600))
+	require.NoError(t, os.Chtimes(stale, now.Add(-2*time.Hour), now.Add(-2*time.Hour)))
+	require.NoError(t, os.WriteFile(fresh, nil, 0o600))
+	require.NoError(t, os.Chtimes(fresh, now.Add(90*time.Minute), now.Add(90*time.Minute)))
+
+	pruned, err := e.Prune(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, pruned, "Свежая выгрузка должна остаться")
+	assert.FileExists(t, path)
+
+	now = now.Add(2 * time.Hour)
+	pruned, err = e.Prune(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+	_, err = e.GetExport(context.Background(), export.ID)
+	assert.ErrorIs(t, err, ErrExportNotFound, "Устаревшая выгрузка должна быть забыта")
+	assert.NoFileExists(t, path)
+	assert.NoFileExists(t, stale, "Файл прошлого запуска должен быть удален")
+	assert.FileExists(t, fresh)
+}
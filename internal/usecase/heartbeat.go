@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// heartbeatMisses is how many rounds a background worker may miss before it is taken for stuck
+const heartbeatMisses = 3
+
+// heartbeat keeps the last successful round of a background worker and the error of the last failed one
+type heartbeat struct {
+	m       sync.Mutex
+	started bool
+	last    time.Time
+	err     error
+}
+
+// start marks the worker as running, the rounds are expected from now on
+func (h *heartbeat) start(now time.Time) {
+	h.m.Lock()
+	defer h.m.Unlock()
+	h.started, h.last, h.err = true, now, nil
+}
+
+// beat records the round that has ended with err
+func (h *heartbeat) beat(now time.Time, err error) {
+	h.m.Lock()
+	defer h.m.Unlock()
+	if err != nil {
+		h.err = err
+		return
+	}
+	h.last, h.err = now, nil
+}
+
+// check fails if the worker has had no successful round for heartbeatMisses intervals.
+// A worker that is not running is not checked.
+func (h *heartbeat) check(now time.Time, interval time.Duration) error {
+	h.m.Lock()
+	defer h.m.Unlock()
+	if !h.started {
+		return nil
+	}
+	since := now.Sub(h.last)
+	if since <= heartbeatMisses*interval {
+		return nil
+	}
+	if h.err != nil {
+		return fmt.Errorf("no successful round for %s: %w", since.Round(time.Second), h.err)
+	}
+	return fmt.Errorf("no round for %s", since.Round(time.Second))
+}
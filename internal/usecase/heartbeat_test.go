@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_heartbeat(t *testing.T) {
+	now := time.Unix(100, 0)
+
+	t.Run("ok, worker is not running", func(t *testing.T) {
+		var h heartbeat
+		assert.NoError(t, h.check(now.Add(time.Hour), time.Minute), "Не запущенный обработчик не проверяется")
+	})
+
+	t.Run("ok, rounds are in time", func(t *testing.T) {
+		var h heartbeat
+		h.start(now)
+		assert.NoError(t, h.check(now.Add(3*time.Minute), time.Minute))
+		h.beat(now.Add(3*time.Minute), nil)
+		assert.NoError(t, h.check(now.Add(5*time.Minute), time.Minute))
+	})
+
+	t.Run("fail, no round", func(t *testing.T) {
+		var h heartbeat
+		h.start(now)
+		assert.EqualError(t, h.check(now.Add(4*time.Minute), time.Minute), "no round for 4m0s")
+	})
+
+	t.Run("fail, rounds are failing", func(t *testing.T) {
+		var h heartbeat
+		h.start(now)
+		failure := errors.New("db is down")
+		h.beat(now.Add(time.Minute), failure)
+		h.beat(now.Add(2*time.Minute), failure)
+		assert.NoError(t, h.check(now.Add(2*time.Minute), time.Minute), "Одна неудача не делает сервер неготовым")
+
+		err := h.check(now.Add(4*time.Minute), time.Minute)
+		assert.ErrorIs(t, err, failure)
+		assert.ErrorContains(t, err, "no successful round for 4m0s")
+
+		h.beat(now.Add(4*time.Minute), nil)
+		assert.NoError(t, h.check(now.Add(4*time.Minute), time.Minute), "Успешный проход восстанавливает готовность")
+	})
+}
@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"context"
+	"homework/internal/domain"
+	"time"
+)
+
+// Idempotency remembers the results of the requests by their keys for the window, so a retry gets the original result
+type Idempotency struct {
+	idempotencyRepository IdempotencyRepository
+	window                time.Duration
+}
+
+func NewIdempotency(ir IdempotencyRepository, window time.Duration) *Idempotency {
+	return &Idempotency{idempotencyRepository: ir, window: window}
+}
+
+// Begin reserves the key for the request. The result of the first request with the key is returned if it is done,
+// nil means the request is the first one and must be completed or released.
+func (i *Idempotency) Begin(ctx context.Context, key, fingerprint string) (_ *domain.IdempotencyRecord, err error) {
+	ctx, end := startSpan(ctx, "Idempotency.Begin")
+	defer end(&err)
+
+	existing, err := i.idempotencyRepository.ReserveKey(ctx, domain.IdempotencyRecord{
+		Key:         key,
+		Fingerprint: fingerprint,
+		ExpiresAt:   time.Now().Add(i.window),
+	})
+	if err != nil || existing == nil {
+		return nil, err
+	}
+	if existing.Fingerprint != fingerprint {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if !existing.Completed {
+		return nil, ErrIdempotencyKeyInUse
+	}
+	return existing, nil
+}
+
+// Complete keeps the result of the request for the window
+func (i *Idempotency) Complete(ctx context.Context, record domain.IdempotencyRecord) (err error) {
+	ctx, end := startSpan(ctx, "Idempotency.Complete")
+	defer end(&err)
+
+	record.Completed = true
+	record.ExpiresAt = time.Now().Add(i.window)
+	return i.idempotencyRepository.CompleteKey(ctx, record)
+}
+
+// Release frees the key of a request that has failed in a way worth retrying
+func (i *Idempotency) Release(ctx context.Context, key string) (err error) {
+	ctx, end := startSpan(ctx, "Idempotency.Release")
+	defer end(&err)
+	return i.idempotencyRepository.ReleaseKey(ctx, key)
+}
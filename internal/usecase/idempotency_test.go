@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotency_Begin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("ok, first request", func(t *testing.T) {
+		ir := NewMockIdempotencyRepository(ctrl)
+		ir.EXPECT().ReserveKey(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+			func(_ context.Context, record domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+				assert.Equal(t, "key", record.Key)
+				assert.WithinDuration(t, time.Now().Add(time.Hour), record.ExpiresAt, time.Minute)
+				return nil, nil
+			})
+
+		replay, err := NewIdempotency(ir, time.Hour).Begin(context.Background(), "key", "fp")
+		assert.NoError(t, err)
+		assert.Nil(t, replay)
+	})
+
+	t.Run("ok, retry gets the result", func(t *testing.T) {
+		completed := &domain.IdempotencyRecord{Key: "key", Fingerprint: "fp", Completed: true, Status: 201}
+		ir := NewMockIdempotencyRepository(ctrl)
+		ir.EXPECT().ReserveKey(gomock.Any(), gomock.Any()).Times(1).Return(completed, nil)
+
+		replay, err := NewIdempotency(ir, time.Hour).Begin(context.Background(), "key", "fp")
+		assert.NoError(t, err)
+		assert.Equal(t, completed, replay)
+	})
+
+	t.Run("err, request in progress", func(t *testing.T) {
+		ir := NewMockIdempotencyRepository(ctrl)
+		ir.EXPECT().ReserveKey(gomock.Any(), gomock.Any()).Times(1).Return(&domain.IdempotencyRecord{Key: "key", Fingerprint: "fp"}, nil)
+
+		_, err := NewIdempotency(ir, time.Hour).Begin(context.Background(), "key", "fp")
+		assert.ErrorIs(t, err, ErrIdempotencyKeyInUse)
+	})
+
+	t.Run("err, key reused", func(t *testing.T) {
+		ir := NewMockIdempotencyRepository(ctrl)
+		ir.EXPECT().ReserveKey(gomock.Any(), gomock.Any()).Times(1).Return(
+			&domain.IdempotencyRecord{Key: "key", Fingerprint: "other", Completed: true}, nil)
+
+		_, err := NewIdempotency(ir, time.Hour).Begin(context.Background(), "key", "fp")
+		assert.ErrorIs(t, err, ErrIdempotencyKeyReused)
+	})
+
+	t.Run("err, repository error", func(t *testing.T) {
+		expectedError := errors.New("some error")
+		ir := NewMockIdempotencyRepository(ctrl)
+		ir.EXPECT().ReserveKey(gomock.Any(), gomock.Any()).Times(1).Return(nil, expectedError)
+
+		_, err := NewIdempotency(ir, time.Hour).Begin(context.Background(), "key", "fp")
+		assert.ErrorIs(t, err, expectedError)
+	})
+}
+
+func TestIdempotency_Complete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ir := NewMockIdempotencyRepository(ctrl)
+	ir.EXPECT().CompleteKey(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+		func(_ context.Context, record domain.IdempotencyRecord) error {
+			assert.True(t, record.Completed)
+			assert.WithinDuration(t, time.Now().Add(time.Hour), record.ExpiresAt, time.Minute,
+				"Окно должно отсчитываться от завершения запроса")
+			return nil
+		})
+
+	assert.NoError(t, NewIdempotency(ir, time.Hour).Complete(context.Background(), domain.IdempotencyRecord{Key: "key", Status: 201}))
+}
@@ -0,0 +1,351 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"io"
+)
+
+const defaultImportBatchSize = 500
+
+// SensorSource yields sensors to import one by one, io.EOF marks the end
+type SensorSource interface {
+	Next() (*domain.Sensor, error)
+}
+
+// EventSource yields events to import one by one, io.EOF marks the end
+type EventSource interface {
+	Next() (*domain.Event, error)
+}
+
+// ImportResult - итог импорта
+// Processed - количество прочитанных записей, включая пропущенные
+// Imported - количество сохранённых записей
+// Skipped - количество записей, пропущенных как дубликаты или уже обработанных до прерывания
+type ImportResult struct {
+	Processed int64
+	Imported  int64
+	Skipped   int64
+}
+
+type Import struct {
+	sensor               *Sensor
+	sensorRepository     SensorRepository
+	eventRepository      EventRepository
+	checkpointRepository ImportCheckpointRepository
+	sensorTypes          *SensorTypes
+	batchSize            int
+}
+
+func NewImport(sr SensorRepository, er EventRepository, cr ImportCheckpointRepository, options ...func(*Import)) *Import {
+	i := &Import{
+		sensor:               NewSensor(sr),
+		sensorRepository:     sr,
+		eventRepository:      er,
+		checkpointRepository: cr,
+		batchSize:            defaultImportBatchSize,
+	}
+	for _, o := range options {
+		o(i)
+	}
+	return i
+}
+
+func WithImportBatchSize(size int) func(*Import) {
+	return func(i *Import) {
+		if size > 0 {
+			i.batchSize = size
+		}
+	}
+}
+
+// WithImportSensorTypes checks the sensors and the readings of the events by the types of the registry
+func WithImportSensorTypes(t *SensorTypes) func(*Import) {
+	return func(i *Import) {
+		i.sensorTypes = t
+		i.sensor.sensorTypes = t
+	}
+}
+
+// WithImportVirtualSensors keeps the index of the virtual sensors up to date with the imported sensors
+func WithImportVirtualSensors(v *VirtualSensors) func(*Import) {
+	return func(i *Import) {
+		i.sensor.virtual = v
+	}
+}
+
+// ImportSensors registers the sensors from src, skipping the ones already known by serial number.
+// A non-empty key enables resuming: records processed by a previous run with the same key are skipped.
+func (i *Import) ImportSensors(ctx context.Context, key string, src SensorSource) (ImportResult, error) {
+	res := ImportResult{}
+	offset, err := i.getCheckpoint(ctx, key)
+	if err != nil {
+		return res, err
+	}
+
+	for {
+		sensor, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return res, fmt.Errorf("record %d: %w", res.Processed+1, err)
+		}
+		res.Processed++
+		if res.Processed <= offset {
+			res.Skipped++
+			continue
+		}
+
+		err = i.sensor.sensorTypes.WithTypeLocked(ctx, sensor.Type, func(spec *domain.SensorTypeSpec) error {
+			return i.sensor.validate(ctx, spec, sensor)
+		})
+		if err != nil {
+			return res, fmt.Errorf("record %d: %w", res.Processed, err)
+		}
+		if _, err := i.sensorRepository.GetSensorBySerialNumber(ctx, sensor.SerialNumber); err == nil {
+			res.Skipped++
+		} else if errors.Is(err, ErrSensorNotFound) {
+			if _, err := i.sensor.RegisterSensor(ctx, sensor); err != nil {
+				return res, err
+			}
+			res.Imported++
+		} else {
+			return res, err
+		}
+
+		if res.Processed%int64(i.batchSize) == 0 {
+			if err := i.saveCheckpoint(ctx, key, res.Processed); err != nil {
+				return res, err
+			}
+		}
+	}
+
+	return res, i.saveCheckpoint(ctx, key, res.Processed)
+}
+
+type importEventKey struct {
+	sensorID  int64
+	timestamp int64
+}
+
+// ImportEvents stores the events from src in batches.
+// Events are deduplicated by (sensor, timestamp) inside a batch and against the already stored ones, so the
+// duplicates in different batches are caught by the repository and the memory doesn't grow with src.
+// A sensor's current state is updated only by events newer than its last activity.
+// A non-empty key enables resuming: records processed by a previous run with the same key are skipped.
+func (i *Import) ImportEvents(ctx context.Context, key string, src EventSource) (ImportResult, error) {
+	res := ImportResult{}
+	offset, err := i.getCheckpoint(ctx, key)
+	if err != nil {
+		return res, err
+	}
+
+	sensors := map[string]*domain.Sensor{}
+	types := map[domain.SensorType]*domain.SensorTypeSpec{}
+	seen := map[importEventKey]struct{}{}
+	batch := make([]*domain.Event, 0, i.batchSize)
+
+	flush := func() error {
+		imported, err := i.saveEventsBatch(ctx, batch)
+		res.Imported += imported
+		res.Skipped += int64(len(batch)) - imported
+		batch = batch[:0]
+		clear(seen)
+		if err != nil {
+			return err
+		}
+		return i.saveCheckpoint(ctx, key, res.Processed)
+	}
+
+	for {
+		event, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return res, fmt.Errorf("record %d: %w", res.Processed+1, err)
+		}
+		res.Processed++
+		if res.Processed <= offset {
+			res.Skipped++
+			continue
+		}
+
+		if event.Timestamp.IsZero() {
+			return res, fmt.Errorf("record %d: %w", res.Processed, ErrInvalidEventTimestamp)
+		}
+		if err := validateSerialNumber(event.SensorSerialNumber); err != nil {
+			return res, fmt.Errorf("record %d: %w", res.Processed, err)
+		}
+
+		sensor, has := sensors[event.SensorSerialNumber]
+		if !has {
+			sensor, err = i.sensorRepository.GetSensorBySerialNumber(ctx, event.SensorSerialNumber)
+			if err != nil {
+				return res, fmt.Errorf("record %d: %w", res.Processed, err)
+			}
+			sensors[event.SensorSerialNumber] = sensor
+		}
+		if sensor.Type == domain.SensorTypeVirtual {
+			return res, fmt.Errorf("record %d: %w: virtual sensor computes its events itself", res.Processed, ErrWrongSensorType)
+		}
+		event.SensorID = sensor.ID
+
+		if err := i.checkReadings(ctx, types, sensor, event); err != nil {
+			return res, fmt.Errorf("record %d: %w", res.Processed, err)
+		}
+		if err := calibrate(sensor, event); err != nil {
+			return res, fmt.Errorf("record %d: %w", res.Processed, err)
+		}
+
+		k := importEventKey{sensorID: sensor.ID, timestamp: event.Timestamp.UnixNano()}
+		if _, dup := seen[k]; dup {
+			res.Skipped++
+			continue
+		}
+		seen[k] = struct{}{}
+
+		batch = append(batch, event)
+		if len(batch) == i.batchSize {
+			if err := flush(); err != nil {
+				return res, err
+			}
+		}
+	}
+
+	return res, flush()
+}
+
+// checkReadings checks the payload against the type of the sensor, the types are cached in types.
+// Any readings are accepted without the registry.
+func (i *Import) checkReadings(ctx context.Context, types map[domain.SensorType]*domain.SensorTypeSpec, sensor *domain.Sensor, event *domain.Event) error {
+	if i.sensorTypes == nil {
+		return nil
+	}
+	spec, has := types[sensor.Type]
+	if !has {
+		var err error
+		if spec, err = lookupSensorType(ctx, i.sensorTypes, sensor.Type); err != nil {
+			return err
+		}
+		types[sensor.Type] = spec
+	}
+
+	payload, err := spec.CheckPayload(event.Payload)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidReadings, err)
+	}
+	event.Payload = payload
+	return nil
+}
+
+// saveEventsBatch stores the events which aren't in the repository yet and returns how many were stored
+func (i *Import) saveEventsBatch(ctx context.Context, batch []*domain.Event) (int64, error) {
+	bySensor := map[int64][]*domain.Event{}
+	for _, e := range batch {
+		bySensor[e.SensorID] = append(bySensor[e.SensorID], e)
+	}
+
+	toSave := make([]*domain.Event, 0, len(batch))
+	for id, events := range bySensor {
+		fresh, err := i.filterStored(ctx, id, events)
+		if err != nil {
+			return 0, err
+		}
+		toSave = append(toSave, fresh...)
+	}
+	if len(toSave) == 0 {
+		return 0, ctx.Err()
+	}
+
+	if err := i.saveEvents(ctx, toSave); err != nil {
+		return 0, err
+	}
+
+	latest := map[int64]*domain.Event{}
+	for _, e := range toSave {
+		if l, has := latest[e.SensorID]; !has || e.Timestamp.After(l.Timestamp) {
+			latest[e.SensorID] = e
+		}
+	}
+	for id, e := range latest {
+		// the sensor is read again, the events received and the changes made during the import must not be lost
+		sensor, err := i.sensorRepository.GetSensorByID(ctx, id)
+		if err != nil {
+			return int64(len(toSave)), err
+		}
+		// historical data must not override a newer state
+		if !e.Timestamp.After(sensor.LastActivity) {
+			continue
+		}
+		sensor.LastActivity = e.Timestamp
+		sensor.CurrentState = e.Payload
+		if err := i.sensorRepository.SaveSensor(ctx, sensor); err != nil {
+			return int64(len(toSave)), err
+		}
+	}
+
+	return int64(len(toSave)), ctx.Err()
+}
+
+// filterStored drops the events whose (sensor, timestamp) is already present in the repository
+func (i *Import) filterStored(ctx context.Context, sensorID int64, events []*domain.Event) ([]*domain.Event, error) {
+	from, to := events[0].Timestamp, events[0].Timestamp
+	for _, e := range events {
+		if e.Timestamp.Before(from) {
+			from = e.Timestamp
+		}
+		if e.Timestamp.After(to) {
+			to = e.Timestamp
+		}
+	}
+
+	stored := map[int64]struct{}{}
+	err := streamHistory(ctx, i.eventRepository, sensorID, from, to, func(e *domain.Event) error {
+		stored[e.Timestamp.UnixNano()] = struct{}{}
+		return nil
+	})
+	if err != nil && !errors.Is(err, ErrEventNotFound) {
+		return nil, err
+	}
+	if len(stored) == 0 {
+		return events, nil
+	}
+
+	fresh := make([]*domain.Event, 0, len(events))
+	for _, e := range events {
+		if _, has := stored[e.Timestamp.UnixNano()]; !has {
+			fresh = append(fresh, e)
+		}
+	}
+	return fresh, nil
+}
+
+func (i *Import) saveEvents(ctx context.Context, events []*domain.Event) error {
+	if s, ok := i.eventRepository.(EventBatchSaver); ok {
+		return s.SaveEvents(ctx, events)
+	}
+	for _, e := range events {
+		if err := i.eventRepository.SaveEvent(ctx, e); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+func (i *Import) getCheckpoint(ctx context.Context, key string) (int64, error) {
+	if key == "" || i.checkpointRepository == nil {
+		return 0, ctx.Err()
+	}
+	return i.checkpointRepository.GetCheckpoint(ctx, key)
+}
+
+func (i *Import) saveCheckpoint(ctx context.Context, key string, processed int64) error {
+	if key == "" || i.checkpointRepository == nil {
+		return ctx.Err()
+	}
+	return i.checkpointRepository.SaveCheckpoint(ctx, key, processed)
+}
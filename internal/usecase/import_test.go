@@ -0,0 +1,270 @@
+package usecase
+
+import (
+	"context"
+	"homework/internal/domain"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sliceSource[T any] struct {
+	items []*T
+}
+
+func (s *sliceSource[T]) Next() (*T, error) {
+	if len(s.items) == 0 {
+		return nil, io.EOF
+	}
+	item := s.items[0]
+	s.items = s.items[1:]
+	return item, nil
+}
+
+func Test_import_ImportSensors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("err, invalid sensor", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		i := NewImport(nil, nil, nil)
+
+		_, err := i.ImportSensors(ctx, "", &sliceSource[domain.Sensor]{items: []*domain.Sensor{
+			{SerialNumber: "1234567890", Type: "some"},
+		}})
+		assert.ErrorIs(t, err, ErrWrongSensorType)
+		assert.ErrorContains(t, err, "record 1")
+	})
+
+	t.Run("ok, known sensors are skipped", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "1111111111").Times(1).Return(&domain.Sensor{ID: 1}, nil)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "2222222222").Times(2).Return(nil, ErrSensorNotFound)
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+
+		cr := NewMockImportCheckpointRepository(ctrl)
+		cr.EXPECT().GetCheckpoint(gomock.Any(), "key").Times(1).Return(int64(0), nil)
+		cr.EXPECT().SaveCheckpoint(gomock.Any(), "key", int64(2)).Times(1).Return(nil)
+
+		i := NewImport(sr, nil, cr)
+
+		res, err := i.ImportSensors(ctx, "key", &sliceSource[domain.Sensor]{items: []*domain.Sensor{
+			{SerialNumber: "1111111111", Type: domain.SensorTypeADC},
+			{SerialNumber: "2222222222", Type: domain.SensorTypeContactClosure},
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, ImportResult{Processed: 2, Imported: 1, Skipped: 1}, res)
+	})
+
+	t.Run("ok, resume from checkpoint", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "2222222222").Times(2).Return(nil, ErrSensorNotFound)
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+
+		cr := NewMockImportCheckpointRepository(ctrl)
+		cr.EXPECT().GetCheckpoint(gomock.Any(), "key").Times(1).Return(int64(1), nil)
+		cr.EXPECT().SaveCheckpoint(gomock.Any(), "key", int64(2)).Times(1).Return(nil)
+
+		i := NewImport(sr, nil, cr)
+
+		res, err := i.ImportSensors(ctx, "key", &sliceSource[domain.Sensor]{items: []*domain.Sensor{
+			{SerialNumber: "1111111111", Type: domain.SensorTypeADC},
+			{SerialNumber: "2222222222", Type: domain.SensorTypeContactClosure},
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, ImportResult{Processed: 2, Imported: 1, Skipped: 1}, res)
+	})
+}
+
+func Test_import_ImportEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+
+	t.Run("err, invalid serial number", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		i := NewImport(nil, nil, nil)
+
+		_, err := i.ImportEvents(ctx, "", &sliceSource[domain.Event]{items: []*domain.Event{
+			{SensorSerialNumber: "123", Timestamp: now},
+		}})
+		assert.ErrorIs(t, err, ErrWrongSensorSerialNumber)
+	})
+
+	t.Run("err, invalid timestamp", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		i := NewImport(nil, nil, nil)
+
+		_, err := i.ImportEvents(ctx, "", &sliceSource[domain.Event]{items: []*domain.Event{
+			{SensorSerialNumber: "1234567890"},
+		}})
+		assert.ErrorIs(t, err, ErrInvalidEventTimestamp)
+	})
+
+	t.Run("err, unknown sensor", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "1234567890").Times(1).Return(nil, ErrSensorNotFound)
+
+		i := NewImport(sr, nil, nil)
+
+		_, err := i.ImportEvents(ctx, "", &sliceSource[domain.Event]{items: []*domain.Event{
+			{SensorSerialNumber: "1234567890", Timestamp: now},
+		}})
+		assert.ErrorIs(t, err, ErrSensorNotFound)
+	})
+
+	t.Run("err, readings don't fit the sensor type", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		door := &domain.SensorTypeSpec{Name: "door", Channels: []domain.ChannelSpec{{Name: "open"}}}
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "1234567890").Times(1).Return(&domain.Sensor{ID: 1, Type: door.Name}, nil)
+		tr := NewMockSensorTypeRepository(ctrl)
+		tr.EXPECT().GetSensorType(gomock.Any(), door.Name).Times(1).Return(door, nil)
+
+		i := NewImport(sr, nil, nil, WithImportSensorTypes(NewSensorTypes(tr, sr)))
+
+		_, err := i.ImportEvents(ctx, "", &sliceSource[domain.Event]{items: []*domain.Event{
+			{SensorSerialNumber: "1234567890", Timestamp: now, Payload: domain.IntPayload(1)},
+		}})
+		assert.ErrorIs(t, err, ErrInvalidReadings)
+	})
+
+	t.Run("err, virtual sensor", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "1234567890").Times(1).Return(&domain.Sensor{ID: 1, Type: domain.SensorTypeVirtual}, nil)
+
+		i := NewImport(sr, nil, nil)
+
+		_, err := i.ImportEvents(ctx, "", &sliceSource[domain.Event]{items: []*domain.Event{
+			{SensorSerialNumber: "1234567890", Timestamp: now, Payload: domain.IntPayload(1)},
+		}})
+		assert.ErrorIs(t, err, ErrWrongSensorType)
+	})
+
+	t.Run("ok, duplicates are skipped and old events keep the state", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sensor := &domain.Sensor{ID: 1, SerialNumber: "1234567890", CurrentState: domain.IntPayload(42), LastActivity: now}
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "1234567890").Times(1).Return(sensor, nil)
+		sr.EXPECT().GetSensorByID(gomock.Any(), int64(1)).Times(1).Return(sensor, nil)
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(0)
+
+		er := NewMockEventRepository(ctrl)
+		// the second event has already been imported before
+		er.EXPECT().GetHistoryBySensorID(gomock.Any(), int64(1), now.Add(-3*time.Hour), now.Add(-time.Hour)).Times(1).
+			Return([]*domain.Event{{SensorID: 1, Timestamp: now.Add(-2 * time.Hour)}}, nil)
+		er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Times(2).Return(nil)
+
+		i := NewImport(sr, er, nil)
+
+		res, err := i.ImportEvents(ctx, "", &sliceSource[domain.Event]{items: []*domain.Event{
+			{SensorSerialNumber: "1234567890", Timestamp: now.Add(-3 * time.Hour), Payload: domain.IntPayload(1)},
+			{SensorSerialNumber: "1234567890", Timestamp: now.Add(-2 * time.Hour), Payload: domain.IntPayload(2)},
+			{SensorSerialNumber: "1234567890", Timestamp: now.Add(-time.Hour), Payload: domain.IntPayload(3)},
+			{SensorSerialNumber: "1234567890", Timestamp: now.Add(-time.Hour), Payload: domain.IntPayload(4)},
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, ImportResult{Processed: 4, Imported: 2, Skipped: 2}, res)
+		assert.Equal(t, domain.IntPayload(42), sensor.CurrentState)
+	})
+
+	t.Run("ok, duplicate in a later batch is skipped as stored", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sensor := &domain.Sensor{ID: 1, SerialNumber: "1234567890", LastActivity: now}
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "1234567890").Times(1).Return(sensor, nil)
+		sr.EXPECT().GetSensorByID(gomock.Any(), int64(1)).Times(1).Return(sensor, nil)
+
+		stored := &domain.Event{SensorID: 1, Timestamp: now.Add(-time.Hour), Payload: domain.IntPayload(1)}
+		er := NewMockEventRepository(ctrl)
+		first := er.EXPECT().GetHistoryBySensorID(gomock.Any(), int64(1), stored.Timestamp, stored.Timestamp).Times(1).
+			Return(nil, ErrEventNotFound)
+		er.EXPECT().GetHistoryBySensorID(gomock.Any(), int64(1), stored.Timestamp, stored.Timestamp).Times(1).
+			Return([]*domain.Event{stored}, nil).After(first)
+		er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+
+		i := NewImport(sr, er, nil, WithImportBatchSize(1))
+
+		res, err := i.ImportEvents(ctx, "", &sliceSource[domain.Event]{items: []*domain.Event{
+			{SensorSerialNumber: "1234567890", Timestamp: stored.Timestamp, Payload: domain.IntPayload(1)},
+			{SensorSerialNumber: "1234567890", Timestamp: stored.Timestamp, Payload: domain.IntPayload(1)},
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, ImportResult{Processed: 2, Imported: 1, Skipped: 1}, res)
+	})
+
+	t.Run("ok, batches with checkpoints and a newer state", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sensor := &domain.Sensor{ID: 1, SerialNumber: "1234567890", LastActivity: now.Add(-time.Hour)}
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "1234567890").Times(1).Return(sensor, nil)
+		// the sensor is changed while the import runs, the first batch finds it as it was read
+		first := sr.EXPECT().GetSensorByID(gomock.Any(), int64(1)).Times(1).Return(&domain.Sensor{
+			ID: 1, SerialNumber: "1234567890", LastActivity: now.Add(-time.Hour),
+		}, nil)
+		sr.EXPECT().GetSensorByID(gomock.Any(), int64(1)).Times(1).Return(&domain.Sensor{
+			ID: 1, SerialNumber: "1234567890", Description: "изменён", LastActivity: now.Add(-time.Hour),
+		}, nil).After(first)
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(1).Do(func(_ context.Context, s *domain.Sensor) {
+			assert.Equal(t, domain.IntPayload(3), s.CurrentState)
+			assert.True(t, s.LastActivity.Equal(now))
+			assert.Equal(t, "изменён", s.Description, "Изменения датчика во время импорта не должны теряться")
+		})
+
+		saver := NewMockEventBatchSaver(ctrl)
+		saver.EXPECT().SaveEvents(gomock.Any(), gomock.Len(2)).Times(1).Return(nil)
+		saver.EXPECT().SaveEvents(gomock.Any(), gomock.Len(1)).Times(1).Return(nil)
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().GetHistoryBySensorID(gomock.Any(), int64(1), gomock.Any(), gomock.Any()).Times(2).Return(nil, ErrEventNotFound)
+
+		cr := NewMockImportCheckpointRepository(ctrl)
+		cr.EXPECT().GetCheckpoint(gomock.Any(), "key").Times(1).Return(int64(0), nil)
+		cr.EXPECT().SaveCheckpoint(gomock.Any(), "key", int64(2)).Times(1).Return(nil)
+		cr.EXPECT().SaveCheckpoint(gomock.Any(), "key", int64(3)).Times(1).Return(nil)
+
+		i := NewImport(sr, struct {
+			EventRepository
+			EventBatchSaver
+		}{er, saver}, cr, WithImportBatchSize(2))
+
+		res, err := i.ImportEvents(ctx, "key", &sliceSource[domain.Event]{items: []*domain.Event{
+			{SensorSerialNumber: "1234567890", Timestamp: now.Add(-2 * time.Hour), Payload: domain.IntPayload(1)},
+			{SensorSerialNumber: "1234567890", Timestamp: now.Add(-90 * time.Minute), Payload: domain.IntPayload(2)},
+			{SensorSerialNumber: "1234567890", Timestamp: now, Payload: domain.IntPayload(3)},
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, ImportResult{Processed: 3, Imported: 3}, res)
+	})
+}
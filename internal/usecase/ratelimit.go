@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"time"
+)
+
+// Области ограничений, по ним же размечены метрики
+const (
+	RateLimitScopeClient        = "client"
+	RateLimitScopeSensor        = "sensor"
+	RateLimitScopeUnknownSerial = "unknown_serial"
+)
+
+// RateLimits - ограничения клиентов API и датчиков. Датчик ограничивается по своему типу,
+// если для него есть ограничение в SensorTypes, иначе по Sensor.
+type RateLimits struct {
+	Client      domain.RateLimit
+	Sensor      domain.RateLimit
+	SensorTypes map[domain.SensorType]domain.RateLimit
+}
+
+// ThrottledError tells how long the client has to wait, it is ErrRateLimited for errors.Is
+type ThrottledError struct {
+	Scope      string
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("%s: %s, retry after %s", ErrRateLimited, e.Scope, e.RetryAfter)
+}
+
+func (e *ThrottledError) Unwrap() error {
+	return ErrRateLimited
+}
+
+type RateLimiter struct {
+	rateLimitRepository RateLimitRepository
+	limits              RateLimits
+}
+
+func NewRateLimiter(rr RateLimitRepository, limits RateLimits) *RateLimiter {
+	return &RateLimiter{rateLimitRepository: rr, limits: limits}
+}
+
+// AllowClient takes a token of the client, it is the ip or the api key of the caller
+func (l *RateLimiter) AllowClient(ctx context.Context, client string) (err error) {
+	ctx, end := startSpan(ctx, "RateLimiter.AllowClient")
+	defer end(&err)
+	return l.take(ctx, RateLimitScopeClient, client, l.limits.Client)
+}
+
+type clientKey struct{}
+
+// ContextWithClient returns a context carrying the client of the request, the events of the unknown sensors
+// are limited by it
+func ContextWithClient(ctx context.Context, client string) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// AllowUnknownSerial takes a token of the client of the context for an event of an unknown sensor.
+// The events are limited by the sensor, so nothing else stops a client from guessing the serial numbers.
+func (l *RateLimiter) AllowUnknownSerial(ctx context.Context) (err error) {
+	ctx, end := startSpan(ctx, "RateLimiter.AllowUnknownSerial")
+	defer end(&err)
+
+	client, ok := ctx.Value(clientKey{}).(string)
+	if !ok {
+		return nil
+	}
+	return l.take(ctx, RateLimitScopeUnknownSerial, client, l.limits.Client)
+}
+
+// AllowSensor takes a token of the sensor, the events of a sensor are limited whoever sends them
+func (l *RateLimiter) AllowSensor(ctx context.Context, sensor *domain.Sensor) (err error) {
+	ctx, end := startSpan(ctx, "RateLimiter.AllowSensor")
+	defer end(&err)
+
+	limit, ok := l.limits.SensorTypes[sensor.Type]
+	if !ok {
+		limit = l.limits.Sensor
+	}
+	return l.take(ctx, RateLimitScopeSensor, sensor.SerialNumber, limit)
+}
+
+func (l *RateLimiter) take(ctx context.Context, scope, key string, limit domain.RateLimit) error {
+	if limit.Unlimited() {
+		return nil
+	}
+	wait, err := l.rateLimitRepository.TakeToken(ctx, scope+":"+key, limit)
+	if err != nil {
+		// the storage of the limits being down is no reason to stop serving
+		logging.FromContext(ctx).Warn("Rate limit is not checked", "scope", scope, "error", err)
+		return nil
+	}
+	if wait > 0 {
+		return &ThrottledError{Scope: scope, RetryAfter: wait}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	limits := RateLimits{
+		Client:      domain.RateLimit{Rate: 10, Burst: 20},
+		Sensor:      domain.RateLimit{Rate: 1, Burst: 5},
+		SensorTypes: map[domain.SensorType]domain.RateLimit{domain.SensorTypeADC: {Rate: 0.5, Burst: 2}},
+	}
+
+	t.Run("ok, client has tokens", func(t *testing.T) {
+		rr := NewMockRateLimitRepository(ctrl)
+		rr.EXPECT().TakeToken(gomock.Any(), "client:ip:127.0.0.1", limits.Client).Times(1).Return(time.Duration(0), nil)
+
+		assert.NoError(t, NewRateLimiter(rr, limits).AllowClient(context.Background(), "ip:127.0.0.1"))
+	})
+
+	t.Run("err, client is throttled", func(t *testing.T) {
+		rr := NewMockRateLimitRepository(ctrl)
+		rr.EXPECT().TakeToken(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(1500*time.Millisecond, nil)
+
+		err := NewRateLimiter(rr, limits).AllowClient(context.Background(), "ip:127.0.0.1")
+		assert.ErrorIs(t, err, ErrRateLimited)
+
+		var throttled *ThrottledError
+		if assert.ErrorAs(t, err, &throttled) {
+			assert.Equal(t, RateLimitScopeClient, throttled.Scope)
+			assert.Equal(t, 1500*time.Millisecond, throttled.RetryAfter)
+		}
+	})
+
+	t.Run("ok, sensor limit by type", func(t *testing.T) {
+		rr := NewMockRateLimitRepository(ctrl)
+		rr.EXPECT().TakeToken(gomock.Any(), "sensor:1", limits.SensorTypes[domain.SensorTypeADC]).Times(1).Return(time.Duration(0), nil)
+		rr.EXPECT().TakeToken(gomock.Any(), "sensor:2", limits.Sensor).Times(1).Return(time.Duration(0), nil)
+
+		l := NewRateLimiter(rr, limits)
+		assert.NoError(t, l.AllowSensor(context.Background(), &domain.Sensor{SerialNumber: "1", Type: domain.SensorTypeADC}))
+		assert.NoError(t, l.AllowSensor(context.Background(), &domain.Sensor{SerialNumber: "2", Type: domain.SensorTypeContactClosure}))
+	})
+
+	t.Run("ok, unknown serials are limited by the client", func(t *testing.T) {
+		rr := NewMockRateLimitRepository(ctrl)
+		rr.EXPECT().TakeToken(gomock.Any(), "unknown_serial:ip:127.0.0.1", limits.Client).Times(1).Return(time.Second, nil)
+
+		l := NewRateLimiter(rr, limits)
+		err := l.AllowUnknownSerial(ContextWithClient(context.Background(), "ip:127.0.0.1"))
+		var throttled *ThrottledError
+		if assert.ErrorAs(t, err, &throttled) {
+			assert.Equal(t, RateLimitScopeUnknownSerial, throttled.Scope)
+		}
+		assert.NoError(t, l.AllowUnknownSerial(context.Background()), "Без клиента в контексте ограничивать некого")
+	})
+
+	t.Run("ok, no limit", func(t *testing.T) {
+		rr := NewMockRateLimitRepository(ctrl)
+
+		l := NewRateLimiter(rr, RateLimits{})
+		assert.NoError(t, l.AllowClient(context.Background(), "ip:127.0.0.1"))
+		assert.NoError(t, l.AllowSensor(context.Background(), &domain.Sensor{SerialNumber: "1"}))
+	})
+
+	t.Run("ok, repository error lets the request through", func(t *testing.T) {
+		rr := NewMockRateLimitRepository(ctrl)
+		rr.EXPECT().TakeToken(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(time.Duration(0), errors.New("connection refused"))
+
+		assert.NoError(t, NewRateLimiter(rr, limits).AllowClient(context.Background(), "ip:127.0.0.1"))
+	})
+}
@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"context"
+	"homework/internal/domain"
+)
+
+type Scenes struct {
+	sceneRepository    SceneRepository
+	scheduleRepository ScheduleRepository
+	actuatorRepository ActuatorRepository
+	commands           *Commands
+}
+
+func NewScenes(sr SceneRepository, shr ScheduleRepository, ar ActuatorRepository, commands *Commands) *Scenes {
+	return &Scenes{sceneRepository: sr, scheduleRepository: shr, actuatorRepository: ar, commands: commands}
+}
+
+// SaveScene creates the scene without ID or replaces the existing one, all its actuators must be registered
+func (s *Scenes) SaveScene(ctx context.Context, scene *domain.Scene) (_ *domain.Scene, err error) {
+	ctx, end := startSpan(ctx, "Scenes.SaveScene")
+	defer end(&err)
+
+	if err := scene.Validate(); err != nil {
+		return nil, err
+	}
+	for _, c := range scene.Commands {
+		if _, err := s.actuatorRepository.GetActuatorByID(ctx, c.ActuatorID); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.sceneRepository.SaveScene(ctx, scene); err != nil {
+		return nil, err
+	}
+	return scene, nil
+}
+
+func (s *Scenes) GetScenes(ctx context.Context) (_ []domain.Scene, err error) {
+	ctx, end := startSpan(ctx, "Scenes.GetScenes")
+	defer end(&err)
+	return s.sceneRepository.GetScenes(ctx)
+}
+
+func (s *Scenes) GetSceneByID(ctx context.Context, id int64) (_ *domain.Scene, err error) {
+	ctx, end := startSpan(ctx, "Scenes.GetSceneByID")
+	defer end(&err)
+	return s.sceneRepository.GetSceneByID(ctx, id)
+}
+
+// DeleteScene deletes the scene no schedule runs
+func (s *Scenes) DeleteScene(ctx context.Context, id int64) (err error) {
+	ctx, end := startSpan(ctx, "Scenes.DeleteScene")
+	defer end(&err)
+
+	schedules, err := s.scheduleRepository.GetSchedules(ctx)
+	if err != nil {
+		return err
+	}
+	for _, schedule := range schedules {
+		if schedule.SceneID == id {
+			return ErrSceneInUse
+		}
+	}
+	return s.sceneRepository.DeleteScene(ctx, id)
+}
+
+// TriggerScene queues the commands of the scene and returns them. The commands are queued one by one,
+// so the ones before a failed command stay queued.
+func (s *Scenes) TriggerScene(ctx context.Context, id int64) (_ []*domain.Command, err error) {
+	ctx, end := startSpan(ctx, "Scenes.TriggerScene")
+	defer end(&err)
+
+	scene, err := s.sceneRepository.GetSceneByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	commands := make([]*domain.Command, 0, len(scene.Commands))
+	for _, c := range scene.Commands {
+		command, err := s.commands.SendCommand(ctx, c.ActuatorID, &domain.Command{Action: c.Action, Args: c.Args}, 0)
+		if err != nil {
+			return commands, err
+		}
+		commands = append(commands, command)
+	}
+	return commands, nil
+}
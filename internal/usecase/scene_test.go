@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"context"
+	"homework/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var goodNight = domain.Scene{ID: 1, Name: "good_night", Commands: []domain.SceneCommand{
+	{ActuatorID: 1, Action: "switch", Args: domain.IntPayload(0)},
+	{ActuatorID: 2, Action: "lock"},
+}}
+
+func Test_scenes_SaveScene(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("fail, scene not valid", func(t *testing.T) {
+		sr := NewMockSceneRepository(ctrl)
+		sr.EXPECT().SaveScene(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := NewScenes(sr, nil, nil, nil).SaveScene(context.Background(), &domain.Scene{Name: "good_night"})
+		assert.ErrorIs(t, err, domain.ErrInvalidScene)
+	})
+
+	t.Run("fail, actuator not found", func(t *testing.T) {
+		ar := NewMockActuatorRepository(ctrl)
+		ar.EXPECT().GetActuatorByID(gomock.Any(), int64(1)).Times(1).Return(&domain.Actuator{ID: 1}, nil)
+		ar.EXPECT().GetActuatorByID(gomock.Any(), int64(2)).Times(1).Return(nil, ErrActuatorNotFound)
+		sr := NewMockSceneRepository(ctrl)
+		sr.EXPECT().SaveScene(gomock.Any(), gomock.Any()).Times(0)
+
+		scene := goodNight
+		_, err := NewScenes(sr, nil, ar, nil).SaveScene(context.Background(), &scene)
+		assert.ErrorIs(t, err, ErrActuatorNotFound)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		ar := NewMockActuatorRepository(ctrl)
+		ar.EXPECT().GetActuatorByID(gomock.Any(), gomock.Any()).Times(2).Return(&domain.Actuator{}, nil)
+		scene := goodNight
+		sr := NewMockSceneRepository(ctrl)
+		sr.EXPECT().SaveScene(gomock.Any(), &scene).Times(1).Return(nil)
+
+		got, err := NewScenes(sr, nil, ar, nil).SaveScene(context.Background(), &scene)
+		assert.NoError(t, err)
+		assert.Equal(t, &scene, got)
+	})
+}
+
+func Test_scenes_DeleteScene(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("fail, scene in use", func(t *testing.T) {
+		shr := NewMockScheduleRepository(ctrl)
+		shr.EXPECT().GetSchedules(gomock.Any()).Times(1).Return([]domain.Schedule{{ID: 1, SceneID: 1}}, nil)
+		sr := NewMockSceneRepository(ctrl)
+		sr.EXPECT().DeleteScene(gomock.Any(), gomock.Any()).Times(0)
+
+		err := NewScenes(sr, shr, nil, nil).DeleteScene(context.Background(), 1)
+		assert.ErrorIs(t, err, ErrSceneInUse)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		shr := NewMockScheduleRepository(ctrl)
+		shr.EXPECT().GetSchedules(gomock.Any()).Times(1).Return([]domain.Schedule{{ID: 1, SceneID: 2}}, nil)
+		sr := NewMockSceneRepository(ctrl)
+		sr.EXPECT().DeleteScene(gomock.Any(), int64(1)).Times(1).Return(nil)
+
+		assert.NoError(t, NewScenes(sr, shr, nil, nil).DeleteScene(context.Background(), 1))
+	})
+}
+
+func Test_scenes_TriggerScene(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	now := time.Unix(100, 0)
+
+	t.Run("fail, scene not found", func(t *testing.T) {
+		sr := NewMockSceneRepository(ctrl)
+		sr.EXPECT().GetSceneByID(gomock.Any(), int64(1)).Times(1).Return(nil, ErrSceneNotFound)
+
+		_, err := NewScenes(sr, nil, nil, nil).TriggerScene(context.Background(), 1)
+		assert.ErrorIs(t, err, ErrSceneNotFound)
+	})
+
+	t.Run("ok, commands are queued", func(t *testing.T) {
+		sr := NewMockSceneRepository(ctrl)
+		sr.EXPECT().GetSceneByID(gomock.Any(), int64(1)).Times(1).Return(&goodNight, nil)
+		ar := NewMockActuatorRepository(ctrl)
+		ar.EXPECT().GetActuatorByID(gomock.Any(), gomock.Any()).Times(2).Return(&domain.Actuator{}, nil)
+		cr := NewMockCommandRepository(ctrl)
+		cr.EXPECT().SaveCommand(gomock.Any(), gomock.Any()).Times(2).Return(nil)
+
+		commands, err := NewScenes(sr, nil, ar, newTestCommands(cr, ar, now)).TriggerScene(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, commands, 2)
+		assert.Equal(t, int64(2), commands[1].ActuatorID)
+		assert.Equal(t, "lock", commands[1].Action)
+		assert.Equal(t, domain.CommandStatusQueued, commands[0].Status)
+	})
+}
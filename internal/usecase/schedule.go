@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"time"
+)
+
+// ScheduleSettings tell where the home is: the cron runs by its clock and the sun rises at its coordinates
+type ScheduleSettings struct {
+	Location    *time.Location
+	Coordinates domain.Coordinates
+	// Tick is how often the due schedules are looked for
+	Tick time.Duration
+	// MisfireGrace is how late a run may be and still not count as missed, it should not be less than Tick
+	MisfireGrace time.Duration
+}
+
+var DefaultScheduleSettings = ScheduleSettings{Location: time.Local, Tick: 15 * time.Second, MisfireGrace: time.Minute}
+
+// Schedules runs the scenes on time. A run is claimed in the repository before the scene is triggered,
+// so with several replicas the scene is triggered once.
+type Schedules struct {
+	scheduleRepository ScheduleRepository
+	sceneRepository    SceneRepository
+	scenes             *Scenes
+	settings           ScheduleSettings
+	now                func() time.Time
+	heartbeat          heartbeat
+}
+
+func NewSchedules(shr ScheduleRepository, sr SceneRepository, scenes *Scenes, options ...func(*Schedules)) *Schedules {
+	s := &Schedules{
+		scheduleRepository: shr,
+		sceneRepository:    sr,
+		scenes:             scenes,
+		settings:           DefaultScheduleSettings,
+		now:                time.Now,
+	}
+	for _, o := range options {
+		o(s)
+	}
+	return s
+}
+
+func WithScheduleSettings(settings ScheduleSettings) func(*Schedules) {
+	return func(s *Schedules) {
+		s.settings = settings
+	}
+}
+
+func (s *Schedules) localNow() time.Time {
+	return s.now().In(s.settings.Location)
+}
+
+// CreateSchedule saves the enabled schedule of an existing scene with its first run
+func (s *Schedules) CreateSchedule(ctx context.Context, schedule *domain.Schedule) (_ *domain.Schedule, err error) {
+	ctx, end := startSpan(ctx, "Schedules.CreateSchedule")
+	defer end(&err)
+
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := s.sceneRepository.GetSceneByID(ctx, schedule.SceneID); err != nil {
+		return nil, err
+	}
+
+	now := s.localNow()
+	created := domain.Schedule{
+		Name:      schedule.Name,
+		SceneID:   schedule.SceneID,
+		Kind:      schedule.Kind,
+		Cron:      schedule.Cron,
+		Offset:    schedule.Offset,
+		At:        schedule.At,
+		Missed:    schedule.Missed,
+		Enabled:   true,
+		CreatedAt: now,
+	}
+	if created.NextRun = created.Next(now, s.settings.Coordinates); created.NextRun.IsZero() {
+		return nil, fmt.Errorf("%w: it never runs", domain.ErrInvalidSchedule)
+	}
+	if err := s.scheduleRepository.SaveSchedule(ctx, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (s *Schedules) GetSchedules(ctx context.Context) (_ []domain.Schedule, err error) {
+	ctx, end := startSpan(ctx, "Schedules.GetSchedules")
+	defer end(&err)
+	return s.scheduleRepository.GetSchedules(ctx)
+}
+
+func (s *Schedules) GetScheduleByID(ctx context.Context, id int64) (_ *domain.Schedule, err error) {
+	ctx, end := startSpan(ctx, "Schedules.GetScheduleByID")
+	defer end(&err)
+	return s.scheduleRepository.GetScheduleByID(ctx, id)
+}
+
+func (s *Schedules) DeleteSchedule(ctx context.Context, id int64) (err error) {
+	ctx, end := startSpan(ctx, "Schedules.DeleteSchedule")
+	defer end(&err)
+	return s.scheduleRepository.DeleteSchedule(ctx, id)
+}
+
+// RunDue triggers the scenes of the due schedules and moves them to their next runs.
+// A run later than the misfire grace, e.g. after a restart, is missed: it is run once if the schedule
+// asks for it, however many runs have been missed, and skipped otherwise.
+// A failure of one schedule doesn't stop the others, the schedules deleted meanwhile are skipped.
+func (s *Schedules) RunDue(ctx context.Context) (err error) {
+	ctx, end := startSpan(ctx, "Schedules.RunDue")
+	defer end(&err)
+
+	now := s.localNow()
+	due, err := s.scheduleRepository.GetDueSchedules(ctx, now)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, schedule := range due {
+		if err := s.run(ctx, schedule, now); err != nil && !errors.Is(err, ErrScheduleNotFound) {
+			errs = append(errs, fmt.Errorf("schedule %d: %w", schedule.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Schedules) run(ctx context.Context, schedule *domain.Schedule, now time.Time) error {
+	prevRun := schedule.NextRun
+	missed := now.Sub(prevRun) > s.settings.MisfireGrace
+	run := !missed || schedule.Missed == domain.MissedPolicyRun
+
+	if schedule.NextRun = schedule.Next(now, s.settings.Coordinates); schedule.NextRun.IsZero() {
+		schedule.Enabled = false
+	}
+	if run {
+		schedule.LastRun = now
+	}
+	claimed, err := s.scheduleRepository.AdvanceSchedule(ctx, schedule, prevRun)
+	if err != nil || !claimed {
+		return err
+	}
+	if !run {
+		logging.FromContext(ctx).Info("Missed schedule run is skipped", "schedule_id", schedule.ID, "run", prevRun)
+		return nil
+	}
+
+	schedule.LastError = ""
+	if _, err := s.scenes.TriggerScene(ctx, schedule.SceneID); err != nil {
+		logging.FromContext(ctx).Error("Scheduled scene has failed", "schedule_id", schedule.ID, "error", err)
+		schedule.LastError = err.Error()
+	}
+	// the run is recorded only into the schedule that is still there, a deleted one is not brought back
+	_, err = s.scheduleRepository.AdvanceSchedule(ctx, schedule, schedule.NextRun)
+	return err
+}
+
+// Check fails if Run has not looked for the due schedules successfully for a few ticks
+func (s *Schedules) Check(ctx context.Context) error {
+	if err := s.heartbeat.check(s.now(), s.settings.Tick); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// Run runs the due schedules until ctx is done, the ones missed while the server was down are handled at once
+func (s *Schedules) Run(ctx context.Context) {
+	tick := time.NewTicker(s.settings.Tick)
+	defer tick.Stop()
+	s.heartbeat.start(s.now())
+	for {
+		err := s.RunDue(ctx)
+		if err != nil && ctx.Err() == nil {
+			logging.FromContext(ctx).Error("Schedules are not run", "error", err)
+		}
+		s.heartbeat.beat(s.now(), err)
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
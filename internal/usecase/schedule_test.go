@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSchedules(shr ScheduleRepository, sr SceneRepository, scenes *Scenes, now time.Time) *Schedules {
+	s := NewSchedules(shr, sr, scenes, WithScheduleSettings(ScheduleSettings{
+		Location: time.UTC, Tick: time.Second, MisfireGrace: time.Minute,
+	}))
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func Test_schedules_CreateSchedule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	now := time.Date(2024, 3, 13, 10, 0, 0, 0, time.UTC)
+
+	t.Run("fail, scene not found", func(t *testing.T) {
+		sr := NewMockSceneRepository(ctrl)
+		sr.EXPECT().GetSceneByID(gomock.Any(), int64(1)).Times(1).Return(nil, ErrSceneNotFound)
+		shr := NewMockScheduleRepository(ctrl)
+		shr.EXPECT().SaveSchedule(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := newTestSchedules(shr, sr, nil, now).CreateSchedule(context.Background(),
+			&domain.Schedule{Name: "morning", SceneID: 1, Kind: domain.ScheduleKindCron, Cron: "0 7 * * *", Missed: domain.MissedPolicySkip})
+		assert.ErrorIs(t, err, ErrSceneNotFound)
+	})
+
+	t.Run("fail, timer in the past", func(t *testing.T) {
+		sr := NewMockSceneRepository(ctrl)
+		sr.EXPECT().GetSceneByID(gomock.Any(), int64(1)).Times(1).Return(&goodNight, nil)
+		shr := NewMockScheduleRepository(ctrl)
+		shr.EXPECT().SaveSchedule(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := newTestSchedules(shr, sr, nil, now).CreateSchedule(context.Background(),
+			&domain.Schedule{Name: "once", SceneID: 1, Kind: domain.ScheduleKindOnce, At: now.Add(-time.Hour), Missed: domain.MissedPolicySkip})
+		assert.ErrorIs(t, err, domain.ErrInvalidSchedule)
+	})
+
+	t.Run("ok, next run is set", func(t *testing.T) {
+		sr := NewMockSceneRepository(ctrl)
+		sr.EXPECT().GetSceneByID(gomock.Any(), int64(1)).Times(1).Return(&goodNight, nil)
+		shr := NewMockScheduleRepository(ctrl)
+		shr.EXPECT().SaveSchedule(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+
+		s, err := newTestSchedules(shr, sr, nil, now).CreateSchedule(context.Background(),
+			&domain.Schedule{Name: "morning", SceneID: 1, Kind: domain.ScheduleKindCron, Cron: "0 7 * * *",
+				Missed: domain.MissedPolicySkip, LastError: "чужое"})
+		require.NoError(t, err)
+		assert.True(t, s.Enabled)
+		assert.Empty(t, s.LastError)
+		assert.Equal(t, time.Date(2024, 3, 14, 7, 0, 0, 0, time.UTC), s.NextRun)
+	})
+}
+
+func Test_schedules_RunDue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	now := time.Date(2024, 3, 13, 7, 0, 10, 0, time.UTC)
+
+	newScenes := func(commands int) *Scenes {
+		sr := NewMockSceneRepository(ctrl)
+		sr.EXPECT().GetSceneByID(gomock.Any(), int64(1)).Times(commands).Return(&goodNight, nil)
+		ar := NewMockActuatorRepository(ctrl)
+		ar.EXPECT().GetActuatorByID(gomock.Any(), gomock.Any()).Times(2*commands).Return(&domain.Actuator{}, nil)
+		cr := NewMockCommandRepository(ctrl)
+		cr.EXPECT().SaveCommand(gomock.Any(), gomock.Any()).Times(2 * commands).Return(nil)
+		return NewScenes(sr, nil, ar, newTestCommands(cr, ar, now))
+	}
+	cron := func(nextRun time.Time, missed domain.MissedPolicy) *domain.Schedule {
+		return &domain.Schedule{ID: 1, SceneID: 1, Kind: domain.ScheduleKindCron, Cron: "0 7 * * *",
+			Missed: missed, Enabled: true, NextRun: nextRun}
+	}
+
+	t.Run("ok, due one is run", func(t *testing.T) {
+		prevRun := time.Date(2024, 3, 13, 7, 0, 0, 0, time.UTC)
+		s := cron(prevRun, domain.MissedPolicySkip)
+		shr := NewMockScheduleRepository(ctrl)
+		shr.EXPECT().GetDueSchedules(gomock.Any(), now).Times(1).Return([]*domain.Schedule{s}, nil)
+		shr.EXPECT().AdvanceSchedule(gomock.Any(), s, prevRun).Times(1).Return(true, nil)
+		shr.EXPECT().AdvanceSchedule(gomock.Any(), s, prevRun.AddDate(0, 0, 1)).Times(1).Return(true, nil)
+
+		require.NoError(t, newTestSchedules(shr, nil, newScenes(1), now).RunDue(context.Background()))
+		assert.Equal(t, now, s.LastRun)
+		assert.Equal(t, prevRun.AddDate(0, 0, 1), s.NextRun)
+	})
+
+	t.Run("ok, claimed by another replica", func(t *testing.T) {
+		prevRun := time.Date(2024, 3, 13, 7, 0, 0, 0, time.UTC)
+		s := cron(prevRun, domain.MissedPolicySkip)
+		shr := NewMockScheduleRepository(ctrl)
+		shr.EXPECT().GetDueSchedules(gomock.Any(), now).Times(1).Return([]*domain.Schedule{s}, nil)
+		shr.EXPECT().AdvanceSchedule(gomock.Any(), s, prevRun).Times(1).Return(false, nil)
+		shr.EXPECT().SaveSchedule(gomock.Any(), gomock.Any()).Times(0)
+
+		require.NoError(t, newTestSchedules(shr, nil, newScenes(0), now).RunDue(context.Background()))
+	})
+
+	t.Run("ok, missed run is skipped", func(t *testing.T) {
+		prevRun := time.Date(2024, 3, 12, 7, 0, 0, 0, time.UTC)
+		s := cron(prevRun, domain.MissedPolicySkip)
+		shr := NewMockScheduleRepository(ctrl)
+		shr.EXPECT().GetDueSchedules(gomock.Any(), now).Times(1).Return([]*domain.Schedule{s}, nil)
+		shr.EXPECT().AdvanceSchedule(gomock.Any(), s, prevRun).Times(1).Return(true, nil)
+		shr.EXPECT().SaveSchedule(gomock.Any(), gomock.Any()).Times(0)
+
+		require.NoError(t, newTestSchedules(shr, nil, newScenes(0), now).RunDue(context.Background()))
+		assert.True(t, s.LastRun.IsZero(), "Пропущенный запуск не выполняется")
+		assert.Equal(t, time.Date(2024, 3, 14, 7, 0, 0, 0, time.UTC), s.NextRun)
+	})
+
+	t.Run("ok, missed run is run once", func(t *testing.T) {
+		prevRun := time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC)
+		s := cron(prevRun, domain.MissedPolicyRun)
+		shr := NewMockScheduleRepository(ctrl)
+		shr.EXPECT().GetDueSchedules(gomock.Any(), now).Times(1).Return([]*domain.Schedule{s}, nil)
+		shr.EXPECT().AdvanceSchedule(gomock.Any(), s, prevRun).Times(1).Return(true, nil)
+		shr.EXPECT().AdvanceSchedule(gomock.Any(), s, time.Date(2024, 3, 14, 7, 0, 0, 0, time.UTC)).Times(1).Return(true, nil)
+
+		require.NoError(t, newTestSchedules(shr, nil, newScenes(1), now).RunDue(context.Background()))
+		assert.Equal(t, now, s.LastRun)
+	})
+
+	t.Run("ok, timer is disabled and failure recorded", func(t *testing.T) {
+		prevRun := time.Date(2024, 3, 13, 7, 0, 0, 0, time.UTC)
+		s := &domain.Schedule{ID: 1, SceneID: 1, Kind: domain.ScheduleKindOnce, At: prevRun,
+			Missed: domain.MissedPolicySkip, Enabled: true, NextRun: prevRun}
+		sr := NewMockSceneRepository(ctrl)
+		sr.EXPECT().GetSceneByID(gomock.Any(), int64(1)).Times(1).Return(nil, errors.New("db is down"))
+		shr := NewMockScheduleRepository(ctrl)
+		shr.EXPECT().GetDueSchedules(gomock.Any(), now).Times(1).Return([]*domain.Schedule{s}, nil)
+		shr.EXPECT().AdvanceSchedule(gomock.Any(), s, prevRun).Times(1).Return(true, nil)
+		shr.EXPECT().AdvanceSchedule(gomock.Any(), s, time.Time{}).Times(1).Return(true, nil)
+
+		require.NoError(t, newTestSchedules(shr, nil, NewScenes(sr, nil, nil, nil), now).RunDue(context.Background()))
+		assert.False(t, s.Enabled, "Однократный таймер выключается после запуска")
+		assert.True(t, s.NextRun.IsZero())
+		assert.Equal(t, "db is down", s.LastError)
+	})
+
+	t.Run("ok, schedule deleted while its scene runs is not saved again", func(t *testing.T) {
+		prevRun := time.Date(2024, 3, 13, 7, 0, 0, 0, time.UTC)
+		s := cron(prevRun, domain.MissedPolicySkip)
+		shr := NewMockScheduleRepository(ctrl)
+		shr.EXPECT().GetDueSchedules(gomock.Any(), now).Times(1).Return([]*domain.Schedule{s}, nil)
+		shr.EXPECT().AdvanceSchedule(gomock.Any(), s, prevRun).Times(1).Return(true, nil)
+		shr.EXPECT().AdvanceSchedule(gomock.Any(), s, prevRun.AddDate(0, 0, 1)).Times(1).Return(false, ErrScheduleNotFound)
+		shr.EXPECT().SaveSchedule(gomock.Any(), gomock.Any()).Times(0)
+
+		require.NoError(t, newTestSchedules(shr, nil, newScenes(1), now).RunDue(context.Background()))
+	})
+
+	t.Run("err, failed schedule doesn't stop the others", func(t *testing.T) {
+		prevRun := time.Date(2024, 3, 13, 7, 0, 0, 0, time.UTC)
+		failed := cron(prevRun, domain.MissedPolicySkip)
+		s := cron(prevRun, domain.MissedPolicySkip)
+		s.ID = 2
+		shr := NewMockScheduleRepository(ctrl)
+		shr.EXPECT().GetDueSchedules(gomock.Any(), now).Times(1).Return([]*domain.Schedule{failed, s}, nil)
+		shr.EXPECT().AdvanceSchedule(gomock.Any(), failed, prevRun).Times(1).Return(false, errors.New("db is down"))
+		shr.EXPECT().AdvanceSchedule(gomock.Any(), s, prevRun).Times(1).Return(true, nil)
+		shr.EXPECT().AdvanceSchedule(gomock.Any(), s, prevRun.AddDate(0, 0, 1)).Times(1).Return(true, nil)
+
+		err := newTestSchedules(shr, nil, newScenes(1), now).RunDue(context.Background())
+		assert.ErrorContains(t, err, "schedule 1: db is down")
+		assert.Equal(t, now, s.LastRun, "Следующее расписание должно запуститься")
+	})
+}
@@ -15,6 +15,8 @@ const (
 
 type Sensor struct {
 	sensorRepository SensorRepository
+	sensorTypes      *SensorTypes
+	virtual          *VirtualSensors
 }
 
 var (
@@ -22,50 +24,167 @@ var (
 	sensorIds      int64 = 1
 )
 
-func NewSensor(sr SensorRepository) *Sensor {
-	return &Sensor{sensorRepository: sr}
+func NewSensor(sr SensorRepository, options ...func(*Sensor)) *Sensor {
+	s := &Sensor{sensorRepository: sr}
+	for _, o := range options {
+		o(s)
+	}
+	return s
+}
+
+// WithSensorTypes makes the usecase accept the types of the registry instead of the builtin ones only
+func WithSensorTypes(t *SensorTypes) func(*Sensor) {
+	return func(s *Sensor) {
+		s.sensorTypes = t
+	}
+}
+
+// WithSensorVirtualSensors keeps the index of the virtual sensors up to date with the registered and changed sensors
+func WithSensorVirtualSensors(v *VirtualSensors) func(*Sensor) {
+	return func(s *Sensor) {
+		s.virtual = v
+	}
 }
 
-func validate(sensor *domain.Sensor) error {
-	if _, has := domain.AcceptableSensorTypes[sensor.Type]; !has {
-		return ErrWrongSensorType
+// refreshVirtual tells the virtual sensors about the saved sensor
+func (s *Sensor) refreshVirtual(sensor *domain.Sensor) {
+	if s.virtual != nil {
+		s.virtual.Refresh(sensor)
 	}
-	reg := regexp.MustCompile(fmt.Sprintf("^\\d{%d}$", sensorSerialNumberLength))
-	if m := reg.MatchString(sensor.SerialNumber); !m {
+}
+
+var sensorSerialNumberRegexp = regexp.MustCompile(fmt.Sprintf("^\\d{%d}$", sensorSerialNumberLength))
+
+// validate checks that the serial number fits the type of the sensor
+func (s *Sensor) validate(ctx context.Context, spec *domain.SensorTypeSpec, sensor *domain.Sensor) error {
+	if err := validateSerialNumber(sensor.SerialNumber); err != nil {
+		return err
+	}
+	if !spec.MatchSerial(sensor.SerialNumber) {
 		return ErrWrongSensorSerialNumber
 	}
-	return nil
+	return s.validateExpression(ctx, sensor)
 }
 
-func (s *Sensor) RegisterSensor(ctx context.Context, sensor *domain.Sensor) (*domain.Sensor, error) {
-	if err := validate(sensor); err != nil {
-		return nil, err
+// validateExpression checks that only a virtual sensor has an expression and it reads the registered sensors.
+// The expression is kept in the canonical form.
+func (s *Sensor) validateExpression(ctx context.Context, sensor *domain.Sensor) error {
+	if sensor.Type != domain.SensorTypeVirtual {
+		if sensor.Expression != "" {
+			return fmt.Errorf("%w: only a virtual sensor has an expression", domain.ErrInvalidExpression)
+		}
+		return nil
 	}
-	old, err := s.sensorRepository.GetSensorBySerialNumber(ctx, sensor.SerialNumber)
+
+	expr, err := domain.ParseExpression(sensor.Expression)
 	if err != nil {
-		if errors.Is(err, ErrSensorNotFound) {
-			if err = s.sensorRepository.SaveSensor(ctx, sensor); err != nil {
-				return nil, err
+		return err
+	}
+	for _, id := range expr.Inputs() {
+		if _, err := s.sensorRepository.GetSensorByID(ctx, id); err != nil {
+			if errors.Is(err, ErrSensorNotFound) {
+				return fmt.Errorf("%w: sensor #%d is not registered", domain.ErrInvalidExpression, id)
 			}
-			if sensor.ID <= 0 {
-				sensorIdsMutex.Lock()
-				sensor.ID = sensorIds
-				sensorIds++
-				sensorIdsMutex.Unlock()
+			return err
+		}
+	}
+	sensor.Expression = expr.String()
+	return nil
+}
+
+func validateSerialNumber(sn string) error {
+	if m := sensorSerialNumberRegexp.MatchString(sn); !m {
+		return ErrWrongSensorSerialNumber
+	}
+	return nil
+}
+
+func (s *Sensor) RegisterSensor(ctx context.Context, sensor *domain.Sensor) (_ *domain.Sensor, err error) {
+	ctx, end := startSpan(ctx, "Sensor.RegisterSensor")
+	defer end(&err)
+
+	registered := sensor
+	err = s.sensorTypes.WithTypeLocked(ctx, sensor.Type, func(spec *domain.SensorTypeSpec) error {
+		if err := s.validate(ctx, spec, sensor); err != nil {
+			return err
+		}
+		old, err := s.sensorRepository.GetSensorBySerialNumber(ctx, sensor.SerialNumber)
+		if err != nil {
+			if errors.Is(err, ErrSensorNotFound) {
+				if err = s.sensorRepository.SaveSensor(ctx, sensor); err != nil {
+					return err
+				}
+				if sensor.ID <= 0 {
+					sensorIdsMutex.Lock()
+					sensor.ID = sensorIds
+					sensorIds++
+					sensorIdsMutex.Unlock()
+				}
+				s.refreshVirtual(sensor)
+				return nil
 			}
-			return sensor, nil
+
+			return err
 		}
 
+		registered = old
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-
-	return old, nil
+	return registered, nil
 }
 
-func (s *Sensor) GetSensors(ctx context.Context) ([]domain.Sensor, error) {
+func (s *Sensor) GetSensors(ctx context.Context) (_ []domain.Sensor, err error) {
+	ctx, end := startSpan(ctx, "Sensor.GetSensors")
+	defer end(&err)
 	return s.sensorRepository.GetSensors(ctx)
 }
 
-func (s *Sensor) GetSensorByID(ctx context.Context, id int64) (*domain.Sensor, error) {
+func (s *Sensor) GetSensorByID(ctx context.Context, id int64) (_ *domain.Sensor, err error) {
+	ctx, end := startSpan(ctx, "Sensor.GetSensorByID")
+	defer end(&err)
 	return s.sensorRepository.GetSensorByID(ctx, id)
 }
+
+// UpdateSensor changes the description of the sensor or deactivates it, an inactive sensor's events are rejected
+func (s *Sensor) UpdateSensor(ctx context.Context, id int64, patch domain.SensorPatch) (_ *domain.Sensor, err error) {
+	ctx, end := startSpan(ctx, "Sensor.UpdateSensor")
+	defer end(&err)
+
+	sensor, err := s.sensorRepository.GetSensorByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if patch.Description != nil {
+		sensor.Description = *patch.Description
+	}
+	if patch.IsActive != nil {
+		sensor.IsActive = *patch.IsActive
+	}
+	if err := s.sensorRepository.SaveSensor(ctx, sensor); err != nil {
+		return nil, err
+	}
+	s.refreshVirtual(sensor)
+	return sensor, nil
+}
+
+// SetCalibration replaces the calibration of the sensor, the events received before are left as they are
+func (s *Sensor) SetCalibration(ctx context.Context, id int64, calibration []domain.Calibration) (_ *domain.Sensor, err error) {
+	ctx, end := startSpan(ctx, "Sensor.SetCalibration")
+	defer end(&err)
+
+	if err := domain.ValidateCalibrations(calibration); err != nil {
+		return nil, err
+	}
+	sensor, err := s.sensorRepository.GetSensorByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sensor.Calibration = calibration
+	if err := s.sensorRepository.SaveSensor(ctx, sensor); err != nil {
+		return nil, err
+	}
+	return sensor, nil
+}
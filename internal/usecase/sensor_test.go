@@ -20,7 +20,7 @@ func Test_sensor_RegisterSensor(t *testing.T) {
 		defer cancel()
 
 		sr := NewMockSensorRepository(ctrl)
-		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(0)
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(0)
 
 		s := NewSensor(sr)
 
@@ -49,7 +49,7 @@ func Test_sensor_RegisterSensor(t *testing.T) {
 
 		sr := NewMockSensorRepository(ctrl)
 		expectedError := errors.New("some error")
-		sr.EXPECT().GetSensorBySerialNumber(ctx, gomock.Any()).Return(nil, expectedError)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), gomock.Any()).Return(nil, expectedError)
 
 		s := NewSensor(sr)
 
@@ -67,8 +67,8 @@ func Test_sensor_RegisterSensor(t *testing.T) {
 
 		sr := NewMockSensorRepository(ctrl)
 		expectedError := errors.New("some error")
-		sr.EXPECT().GetSensorBySerialNumber(ctx, gomock.Any()).Return(nil, ErrSensorNotFound)
-		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Return(expectedError)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), gomock.Any()).Return(nil, ErrSensorNotFound)
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(1).Return(expectedError)
 
 		a := NewSensor(sr)
 
@@ -91,7 +91,7 @@ func Test_sensor_RegisterSensor(t *testing.T) {
 		}
 
 		sr := NewMockSensorRepository(ctrl)
-		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, ss *domain.Sensor) error {
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, ss *domain.Sensor) error {
 			assert.Empty(t, ss.RegisteredAt)
 			assert.Empty(t, ss.LastActivity)
 			assert.Equal(t, sensor.Description, ss.Description)
@@ -103,7 +103,7 @@ func Test_sensor_RegisterSensor(t *testing.T) {
 
 			return nil
 		})
-		sr.EXPECT().GetSensorBySerialNumber(ctx, sensor.SerialNumber).Return(nil, ErrSensorNotFound)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), sensor.SerialNumber).Return(nil, ErrSensorNotFound)
 
 		s := NewSensor(sr)
 
@@ -114,6 +114,40 @@ func Test_sensor_RegisterSensor(t *testing.T) {
 		assert.Equal(t, int64(1), sensor.ID)
 	})
 
+	t.Run("fail, expression not valid", func(t *testing.T) {
+		ctx := context.Background()
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(gomock.Any(), int64(1)).Return(&domain.Sensor{ID: 1}, nil).AnyTimes()
+		sr.EXPECT().GetSensorByID(gomock.Any(), int64(2)).Return(nil, ErrSensorNotFound).AnyTimes()
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(0)
+
+		s := NewSensor(sr)
+		for _, sensor := range []*domain.Sensor{
+			{Type: domain.SensorTypeVirtual, SerialNumber: "1234567890", Expression: "any(#1"},
+			{Type: domain.SensorTypeVirtual, SerialNumber: "1234567890", Expression: "any(#1, #2)"},
+			{Type: domain.SensorTypeADC, SerialNumber: "1234567890", Expression: "any(#1)"},
+		} {
+			_, err := s.RegisterSensor(ctx, sensor)
+			assert.ErrorIs(t, err, domain.ErrInvalidExpression, "Выражение %q датчика %s не должно приниматься", sensor.Expression, sensor.Type)
+		}
+	})
+
+	t.Run("ok, virtual sensor", func(t *testing.T) {
+		ctx := context.Background()
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(gomock.Any(), gomock.Any()).Times(2).Return(&domain.Sensor{}, nil)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "1234567890").Return(nil, ErrSensorNotFound)
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+
+		sensor, err := NewSensor(sr).RegisterSensor(ctx, &domain.Sensor{
+			Type:         domain.SensorTypeVirtual,
+			SerialNumber: "1234567890",
+			Expression:   "any(#1,#2)",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "any(#1, #2)", sensor.Expression, "Выражение хранится в каноническом виде")
+	})
+
 	t.Run("ok, register idempotency", func(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
@@ -125,7 +159,7 @@ func Test_sensor_RegisterSensor(t *testing.T) {
 		}
 
 		sr := NewMockSensorRepository(ctrl)
-		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, ss *domain.Sensor) error {
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, ss *domain.Sensor) error {
 			assert.Empty(t, ss.RegisteredAt)
 			assert.Empty(t, ss.LastActivity)
 			assert.Equal(t, sensor.Description, ss.Description)
@@ -137,7 +171,7 @@ func Test_sensor_RegisterSensor(t *testing.T) {
 
 			return nil
 		})
-		sr.EXPECT().GetSensorBySerialNumber(ctx, sensor.SerialNumber).Return(nil, ErrSensorNotFound)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), sensor.SerialNumber).Return(nil, ErrSensorNotFound)
 
 		s := NewSensor(sr)
 
@@ -147,7 +181,7 @@ func Test_sensor_RegisterSensor(t *testing.T) {
 		assert.NotEmpty(t, sensor.RegisteredAt)
 		assert.Equal(t, int64(1), sensor.ID)
 
-		sr.EXPECT().GetSensorBySerialNumber(ctx, sensor.SerialNumber).Return(sensor, nil)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), sensor.SerialNumber).Return(sensor, nil)
 
 		sensor2, err := s.RegisterSensor(ctx, &domain.Sensor{
 			Type:         domain.SensorTypeContactClosure,
@@ -174,7 +208,7 @@ func Test_sensor_GetSensors(t *testing.T) {
 
 		sr := NewMockSensorRepository(ctrl)
 		expectedError := errors.New("some error")
-		sr.EXPECT().GetSensors(ctx).Times(1).Return(nil, expectedError)
+		sr.EXPECT().GetSensors(gomock.Any()).Times(1).Return(nil, expectedError)
 
 		s := NewSensor(sr)
 
@@ -187,7 +221,7 @@ func Test_sensor_GetSensors(t *testing.T) {
 		defer cancel()
 
 		sr := NewMockSensorRepository(ctrl)
-		sr.EXPECT().GetSensors(ctx).Times(1).Return([]domain.Sensor{
+		sr.EXPECT().GetSensors(gomock.Any()).Times(1).Return([]domain.Sensor{
 			{},
 			{},
 		}, nil)
@@ -210,7 +244,7 @@ func Test_sensor_GetSensorByID(t *testing.T) {
 
 		sr := NewMockSensorRepository(ctrl)
 		expectedError := errors.New("some error")
-		sr.EXPECT().GetSensorByID(ctx, gomock.Any()).Times(1).Return(nil, expectedError)
+		sr.EXPECT().GetSensorByID(gomock.Any(), gomock.Any()).Times(1).Return(nil, expectedError)
 
 		s := NewSensor(sr)
 
@@ -223,7 +257,7 @@ func Test_sensor_GetSensorByID(t *testing.T) {
 		defer cancel()
 
 		sr := NewMockSensorRepository(ctrl)
-		sr.EXPECT().GetSensorByID(ctx, gomock.Any()).Times(1).Return(nil, ErrSensorNotFound)
+		sr.EXPECT().GetSensorByID(gomock.Any(), gomock.Any()).Times(1).Return(nil, ErrSensorNotFound)
 
 		s := NewSensor(sr)
 
@@ -236,11 +270,11 @@ func Test_sensor_GetSensorByID(t *testing.T) {
 		defer cancel()
 
 		sr := NewMockSensorRepository(ctrl)
-		sr.EXPECT().GetSensorByID(ctx, gomock.Any()).Times(1).Return(&domain.Sensor{
+		sr.EXPECT().GetSensorByID(gomock.Any(), gomock.Any()).Times(1).Return(&domain.Sensor{
 			ID:           1,
 			SerialNumber: "12345",
 			Type:         domain.SensorTypeADC,
-			CurrentState: 255,
+			CurrentState: domain.IntPayload(255),
 			Description:  "some desc",
 			IsActive:     true,
 			RegisteredAt: time.Now(),
@@ -253,3 +287,72 @@ func Test_sensor_GetSensorByID(t *testing.T) {
 		assert.NotNil(t, sensor)
 	})
 }
+
+func Test_sensor_SetCalibration(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	calibration := []domain.Calibration{{Channel: domain.DefaultChannel, Gain: domain.Decimal{Units: 2}}}
+
+	t.Run("err, calibration is invalid", func(t *testing.T) {
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(gomock.Any(), gomock.Any()).Times(0)
+
+		s := NewSensor(sr)
+
+		_, err := s.SetCalibration(context.Background(), 1, []domain.Calibration{{Channel: domain.DefaultChannel}})
+		assert.ErrorIs(t, err, domain.ErrInvalidCalibration)
+	})
+
+	t.Run("err, sensor not found", func(t *testing.T) {
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(gomock.Any(), int64(1)).Times(1).Return(nil, ErrSensorNotFound)
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(0)
+
+		s := NewSensor(sr)
+
+		_, err := s.SetCalibration(context.Background(), 1, calibration)
+		assert.ErrorIs(t, err, ErrSensorNotFound)
+	})
+
+	t.Run("ok, calibration is saved", func(t *testing.T) {
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(gomock.Any(), int64(1)).Times(1).Return(&domain.Sensor{ID: 1, Type: domain.SensorTypeADC}, nil)
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(1).Do(func(_ context.Context, s *domain.Sensor) {
+			assert.Equal(t, calibration, s.Calibration)
+		})
+
+		s := NewSensor(sr)
+
+		sensor, err := s.SetCalibration(context.Background(), 1, calibration)
+		assert.NoError(t, err)
+		assert.Equal(t, calibration, sensor.Calibration)
+	})
+}
+
+func Test_sensor_UpdateSensor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("err, sensor not found", func(t *testing.T) {
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(gomock.Any(), int64(1)).Times(1).Return(nil, ErrSensorNotFound)
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := NewSensor(sr).UpdateSensor(context.Background(), 1, domain.SensorPatch{})
+		assert.ErrorIs(t, err, ErrSensorNotFound)
+	})
+
+	t.Run("ok, deactivated", func(t *testing.T) {
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(gomock.Any(), int64(1)).Times(1).
+			Return(&domain.Sensor{ID: 1, Description: "Кухня", IsActive: true}, nil)
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+
+		inactive := false
+		sensor, err := NewSensor(sr).UpdateSensor(context.Background(), 1, domain.SensorPatch{IsActive: &inactive})
+		assert.NoError(t, err)
+		assert.False(t, sensor.IsActive)
+		assert.Equal(t, "Кухня", sensor.Description, "Поле без изменения остается прежним")
+	})
+}
@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"sync"
+)
+
+// SensorTypes is the registry of the sensor types: the sensors are registered and their events are checked by it
+type SensorTypes struct {
+	sensorTypeRepository SensorTypeRepository
+	sensorRepository     SensorRepository
+
+	// mu is held for reading by WithTypeLocked and for writing while a type is deleted
+	mu sync.RWMutex
+}
+
+func NewSensorTypes(tr SensorTypeRepository, sr SensorRepository) *SensorTypes {
+	return &SensorTypes{sensorTypeRepository: tr, sensorRepository: sr}
+}
+
+// SaveSensorType adds the type or replaces the one with the same name, created tells which has happened
+func (t *SensorTypes) SaveSensorType(ctx context.Context, sensorType domain.SensorTypeSpec) (created bool, err error) {
+	ctx, end := startSpan(ctx, "SensorTypes.SaveSensorType")
+	defer end(&err)
+
+	if err := sensorType.Validate(); err != nil {
+		return false, err
+	}
+	return t.sensorTypeRepository.SaveSensorType(ctx, sensorType)
+}
+
+func (t *SensorTypes) GetSensorTypes(ctx context.Context) (_ []domain.SensorTypeSpec, err error) {
+	ctx, end := startSpan(ctx, "SensorTypes.GetSensorTypes")
+	defer end(&err)
+	return t.sensorTypeRepository.GetSensorTypes(ctx)
+}
+
+func (t *SensorTypes) GetSensorType(ctx context.Context, name domain.SensorType) (_ *domain.SensorTypeSpec, err error) {
+	ctx, end := startSpan(ctx, "SensorTypes.GetSensorType")
+	defer end(&err)
+	return t.sensorTypeRepository.GetSensorType(ctx, name)
+}
+
+// DeleteSensorType deletes the type if no sensor is registered with it
+func (t *SensorTypes) DeleteSensorType(ctx context.Context, name domain.SensorType) (err error) {
+	ctx, end := startSpan(ctx, "SensorTypes.DeleteSensorType")
+	defer end(&err)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, err := t.sensorTypeRepository.GetSensorType(ctx, name); err != nil {
+		return err
+	}
+	// the repository may check it too, but the in-memory one knows nothing about the sensors
+	sensors, err := t.sensorRepository.GetSensors(ctx)
+	if err != nil {
+		return err
+	}
+	for _, s := range sensors {
+		if s.Type == name {
+			return ErrSensorTypeInUse
+		}
+	}
+	return t.sensorTypeRepository.DeleteSensorType(ctx, name)
+}
+
+// WithTypeLocked finds the type and calls fn with it, the type can't be deleted until fn returns.
+// A new sensor is checked and saved in fn, so it isn't left with a deleted type.
+// Only the builtin types are known without the registry.
+func (t *SensorTypes) WithTypeLocked(ctx context.Context, name domain.SensorType, fn func(spec *domain.SensorTypeSpec) error) error {
+	if t != nil {
+		t.mu.RLock()
+		defer t.mu.RUnlock()
+	}
+	spec, err := lookupSensorType(ctx, t, name)
+	if err != nil {
+		return err
+	}
+	return fn(spec)
+}
+
+// lookupSensorType finds the type in the registry, only the builtin types are known without it
+func lookupSensorType(ctx context.Context, t *SensorTypes, name domain.SensorType) (*domain.SensorTypeSpec, error) {
+	if t == nil {
+		for _, b := range domain.BuiltinSensorTypes {
+			if b.Name == name {
+				return &b, nil
+			}
+		}
+		return nil, ErrWrongSensorType
+	}
+
+	spec, err := t.sensorTypeRepository.GetSensorType(ctx, name)
+	if errors.Is(err, ErrSensorTypeNotFound) {
+		return nil, ErrWrongSensorType
+	}
+	return spec, err
+}
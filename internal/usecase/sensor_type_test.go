@@ -0,0 +1,309 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_sensorTypes_SaveSensorType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("fail, type not valid", func(t *testing.T) {
+		tr := NewMockSensorTypeRepository(ctrl)
+		tr.EXPECT().SaveSensorType(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := NewSensorTypes(tr, nil).SaveSensorType(context.Background(), domain.SensorTypeSpec{Name: "Door"})
+		assert.ErrorIs(t, err, domain.ErrInvalidSensorType)
+	})
+
+	t.Run("ok, type is saved", func(t *testing.T) {
+		door := domain.SensorTypeSpec{Name: "door", Channels: []domain.ChannelSpec{{Name: "open"}}}
+		tr := NewMockSensorTypeRepository(ctrl)
+		tr.EXPECT().SaveSensorType(gomock.Any(), door).Times(1).Return(true, nil)
+
+		created, err := NewSensorTypes(tr, nil).SaveSensorType(context.Background(), door)
+		assert.NoError(t, err)
+		assert.True(t, created)
+	})
+}
+
+func Test_sensorTypes_DeleteSensorType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("fail, type not found", func(t *testing.T) {
+		tr := NewMockSensorTypeRepository(ctrl)
+		tr.EXPECT().GetSensorType(gomock.Any(), domain.SensorType("door")).Times(1).Return(nil, ErrSensorTypeNotFound)
+		tr.EXPECT().DeleteSensorType(gomock.Any(), gomock.Any()).Times(0)
+
+		err := NewSensorTypes(tr, nil).DeleteSensorType(context.Background(), "door")
+		assert.ErrorIs(t, err, ErrSensorTypeNotFound)
+	})
+
+	t.Run("fail, type in use", func(t *testing.T) {
+		tr := NewMockSensorTypeRepository(ctrl)
+		tr.EXPECT().GetSensorType(gomock.Any(), domain.SensorType("door")).Times(1).Return(&domain.SensorTypeSpec{Name: "door"}, nil)
+		tr.EXPECT().DeleteSensorType(gomock.Any(), gomock.Any()).Times(0)
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensors(gomock.Any()).Times(1).Return([]domain.Sensor{{ID: 1, Type: "door"}}, nil)
+
+		err := NewSensorTypes(tr, sr).DeleteSensorType(context.Background(), "door")
+		assert.ErrorIs(t, err, ErrSensorTypeInUse)
+	})
+
+	t.Run("ok, type is deleted", func(t *testing.T) {
+		tr := NewMockSensorTypeRepository(ctrl)
+		tr.EXPECT().GetSensorType(gomock.Any(), domain.SensorType("door")).Times(1).Return(&domain.SensorTypeSpec{Name: "door"}, nil)
+		tr.EXPECT().DeleteSensorType(gomock.Any(), domain.SensorType("door")).Times(1).Return(nil)
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensors(gomock.Any()).Times(1).Return([]domain.Sensor{{ID: 1, Type: domain.SensorTypeADC}}, nil)
+
+		assert.NoError(t, NewSensorTypes(tr, sr).DeleteSensorType(context.Background(), "door"))
+	})
+
+	t.Run("fail, type of a sensor being registered", func(t *testing.T) {
+		door := &domain.SensorTypeSpec{Name: "door"}
+		tr := NewMockSensorTypeRepository(ctrl)
+		tr.EXPECT().GetSensorType(gomock.Any(), door.Name).Times(2).Return(door, nil)
+		tr.EXPECT().DeleteSensorType(gomock.Any(), gomock.Any()).Times(0)
+
+		var (
+			mu      sync.Mutex
+			saved   []domain.Sensor
+			saving  = make(chan struct{})
+			release = make(chan struct{})
+		)
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "7000000001").Times(1).Return(nil, ErrSensorNotFound)
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, s *domain.Sensor) error {
+			close(saving)
+			<-release
+			mu.Lock()
+			defer mu.Unlock()
+			saved = append(saved, *s)
+			return nil
+		})
+		sr.EXPECT().GetSensors(gomock.Any()).Times(1).DoAndReturn(func(context.Context) ([]domain.Sensor, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return saved, nil
+		})
+
+		types := NewSensorTypes(tr, sr)
+		registered := make(chan error, 1)
+		go func() {
+			_, err := NewSensor(sr, WithSensorTypes(types)).RegisterSensor(context.Background(),
+				&domain.Sensor{SerialNumber: "7000000001", Type: door.Name})
+			registered <- err
+		}()
+		<-saving
+
+		deleted := make(chan error, 1)
+		go func() {
+			deleted <- types.DeleteSensorType(context.Background(), door.Name)
+		}()
+		select {
+		case err := <-deleted:
+			t.Fatalf("Тип удалён, пока регистрируется датчик этого типа: %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(release)
+		assert.NoError(t, <-registered)
+		assert.ErrorIs(t, <-deleted, ErrSensorTypeInUse, "Тип зарегистрированного датчика не должен удаляться")
+	})
+}
+
+func Test_sensorTypes_WithTypeLocked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("fail, type not registered", func(t *testing.T) {
+		tr := NewMockSensorTypeRepository(ctrl)
+		tr.EXPECT().GetSensorType(gomock.Any(), domain.SensorType("window")).Times(1).Return(nil, ErrSensorTypeNotFound)
+
+		err := NewSensorTypes(tr, nil).WithTypeLocked(context.Background(), "window", func(*domain.SensorTypeSpec) error {
+			t.Fatal("fn must not be called for an unknown type")
+			return nil
+		})
+		assert.ErrorIs(t, err, ErrWrongSensorType)
+	})
+
+	t.Run("fail, error of fn", func(t *testing.T) {
+		door := &domain.SensorTypeSpec{Name: "door"}
+		tr := NewMockSensorTypeRepository(ctrl)
+		tr.EXPECT().GetSensorType(gomock.Any(), door.Name).Times(1).Return(door, nil)
+
+		expectedError := errors.New("some error")
+		err := NewSensorTypes(tr, nil).WithTypeLocked(context.Background(), door.Name, func(spec *domain.SensorTypeSpec) error {
+			assert.Equal(t, door, spec)
+			return expectedError
+		})
+		assert.ErrorIs(t, err, expectedError)
+	})
+
+	t.Run("ok, builtin types without the registry", func(t *testing.T) {
+		var types *SensorTypes
+		err := types.WithTypeLocked(context.Background(), domain.SensorTypeADC, func(spec *domain.SensorTypeSpec) error {
+			assert.Equal(t, domain.SensorTypeADC, spec.Name)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.ErrorIs(t, types.WithTypeLocked(context.Background(), "door", func(*domain.SensorTypeSpec) error { return nil }),
+			ErrWrongSensorType, "Без реестра известны только встроенные типы")
+	})
+}
+
+func Test_sensor_RegisterSensor_SensorTypes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	door := &domain.SensorTypeSpec{Name: "door", SerialPattern: `^7\d+$`}
+
+	t.Run("fail, type not registered", func(t *testing.T) {
+		tr := NewMockSensorTypeRepository(ctrl)
+		tr.EXPECT().GetSensorType(gomock.Any(), domain.SensorType("window")).Times(1).Return(nil, ErrSensorTypeNotFound)
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(0)
+
+		s := NewSensor(sr, WithSensorTypes(NewSensorTypes(tr, sr)))
+		_, err := s.RegisterSensor(context.Background(), &domain.Sensor{SerialNumber: "7000000001", Type: "window"})
+		assert.ErrorIs(t, err, ErrWrongSensorType)
+	})
+
+	t.Run("fail, serial number doesn't match the type", func(t *testing.T) {
+		tr := NewMockSensorTypeRepository(ctrl)
+		tr.EXPECT().GetSensorType(gomock.Any(), door.Name).Times(1).Return(door, nil)
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(0)
+
+		s := NewSensor(sr, WithSensorTypes(NewSensorTypes(tr, sr)))
+		_, err := s.RegisterSensor(context.Background(), &domain.Sensor{SerialNumber: "1000000001", Type: door.Name})
+		assert.ErrorIs(t, err, ErrWrongSensorSerialNumber)
+	})
+
+	t.Run("ok, registered type", func(t *testing.T) {
+		tr := NewMockSensorTypeRepository(ctrl)
+		tr.EXPECT().GetSensorType(gomock.Any(), door.Name).Times(1).Return(door, nil)
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "7000000001").Times(1).Return(nil, ErrSensorNotFound)
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+
+		s := NewSensor(sr, WithSensorTypes(NewSensorTypes(tr, sr)))
+		sensor, err := s.RegisterSensor(context.Background(), &domain.Sensor{SerialNumber: "7000000001", Type: door.Name})
+		assert.NoError(t, err)
+		assert.Equal(t, door.Name, sensor.Type)
+	})
+}
+
+func Test_event_ReceiveEvent_SensorTypes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	meter := &domain.SensorTypeSpec{Name: "meter", Channels: []domain.ChannelSpec{{Name: "temperature", Unit: "°C"}}}
+	sensor := func() *domain.Sensor {
+		return &domain.Sensor{ID: 1, SerialNumber: "0000000001", Type: meter.Name, IsActive: true}
+	}
+
+	t.Run("fail, readings don't fit the type", func(t *testing.T) {
+		tr := NewMockSensorTypeRepository(ctrl)
+		tr.EXPECT().GetSensorType(gomock.Any(), meter.Name).Times(1).Return(meter, nil)
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "0000000001").Times(1).Return(sensor(), nil)
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(0)
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Times(0)
+
+		m := &eventMetrics{}
+		e := NewEvent(er, sr, WithEventMetrics(m), WithEventSensorTypes(NewSensorTypes(tr, sr)))
+		err := e.ReceiveEvent(context.Background(), &domain.Event{
+			Timestamp: time.Now(), SensorSerialNumber: "0000000001", Payload: domain.IntPayload(1),
+		})
+		assert.ErrorIs(t, err, ErrInvalidReadings)
+		assert.ErrorIs(t, err, domain.ErrInvalidPayload)
+		assert.Equal(t, []string{RejectReasonBadReadings}, m.rejected)
+	})
+
+	t.Run("ok, unit of the channel is filled", func(t *testing.T) {
+		expected := domain.Payload{{Channel: "temperature", Value: domain.Decimal{Units: 215, Scale: 1}, Unit: "°C"}}
+
+		tr := NewMockSensorTypeRepository(ctrl)
+		tr.EXPECT().GetSensorType(gomock.Any(), meter.Name).Times(1).Return(meter, nil)
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "0000000001").Times(1).Return(sensor(), nil)
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+
+		e := NewEvent(er, sr, WithEventSensorTypes(NewSensorTypes(tr, sr)))
+		event := &domain.Event{
+			Timestamp:          time.Now(),
+			SensorSerialNumber: "0000000001",
+			Payload:            domain.Payload{{Channel: "temperature", Value: domain.Decimal{Units: 215, Scale: 1}}},
+		}
+		assert.NoError(t, e.ReceiveEvent(context.Background(), event))
+		assert.Equal(t, expected, event.Payload)
+	})
+}
+
+func Test_event_ReceiveEvent_Calibration(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	adc := domain.BuiltinSensorTypes[1]
+	sensor := func() *domain.Sensor {
+		return &domain.Sensor{ID: 1, SerialNumber: "0000000001", Type: domain.SensorTypeADC, IsActive: true,
+			Calibration: []domain.Calibration{{
+				Channel: domain.DefaultChannel, Gain: domain.Decimal{Units: 5, Scale: 1}, Offset: domain.Decimal{Units: -50}, Unit: "°C",
+			}},
+		}
+	}
+
+	t.Run("fail, raw value is beyond the bit depth", func(t *testing.T) {
+		tr := NewMockSensorTypeRepository(ctrl)
+		tr.EXPECT().GetSensorType(gomock.Any(), domain.SensorTypeADC).Times(1).Return(&adc, nil)
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "0000000001").Times(1).Return(sensor(), nil)
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Times(0)
+
+		m := &eventMetrics{}
+		e := NewEvent(er, sr, WithEventMetrics(m), WithEventSensorTypes(NewSensorTypes(tr, sr)))
+		err := e.ReceiveEvent(context.Background(), &domain.Event{
+			Timestamp: time.Now(), SensorSerialNumber: "0000000001", Payload: domain.IntPayload(4096),
+		})
+		assert.ErrorIs(t, err, ErrInvalidReadings)
+		assert.Equal(t, []string{RejectReasonBadReadings}, m.rejected)
+	})
+
+	t.Run("ok, calibrated and raw readings are saved", func(t *testing.T) {
+		calibrated := domain.Payload{{Channel: domain.DefaultChannel, Value: domain.Decimal{Units: 974}, Unit: "°C"}}
+
+		tr := NewMockSensorTypeRepository(ctrl)
+		tr.EXPECT().GetSensorType(gomock.Any(), domain.SensorTypeADC).Times(1).Return(&adc, nil)
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "0000000001").Times(1).Return(sensor(), nil)
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(1).Do(func(_ context.Context, s *domain.Sensor) {
+			assert.Equal(t, calibrated, s.CurrentState, "Состояние датчика должно быть откалибровано")
+		})
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, event *domain.Event) error {
+			assert.Equal(t, calibrated, event.Payload)
+			assert.Equal(t, domain.IntPayload(2048), event.Raw)
+			return nil
+		})
+
+		e := NewEvent(er, sr, WithEventSensorTypes(NewSensorTypes(tr, sr)))
+		assert.NoError(t, e.ReceiveEvent(context.Background(), &domain.Event{
+			Timestamp: time.Now(), SensorSerialNumber: "0000000001", Payload: domain.IntPayload(2048),
+		}))
+	})
+}
@@ -0,0 +1,25 @@
+package usecase
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+)
+
+var tracer = otel.Tracer("homework/internal/usecase")
+
+// startSpan begins the span of the usecase method, the returned function ends it with the error the method returns:
+//
+//	ctx, end := startSpan(ctx, "Event.ReceiveEvent")
+//	defer end(&err)
+func startSpan(ctx context.Context, name string) (context.Context, func(*error)) {
+	ctx, span := tracer.Start(ctx, name)
+	return ctx, func(err *error) {
+		if *err != nil {
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, (*err).Error())
+		}
+		span.End()
+	}
+}
@@ -13,8 +13,37 @@ var (
 	ErrInvalidEventTimestamp   = errors.New("invalid event timestamp")
 	ErrInvalidUserName         = errors.New("invalid user name")
 	ErrSensorNotFound          = errors.New("sensor not found")
+	ErrSensorInactive          = errors.New("sensor is not active")
 	ErrUserNotFound            = errors.New("user not found")
 	ErrEventNotFound           = errors.New("event not found")
+	ErrExportNotFound          = errors.New("export not found")
+	ErrExportNotReady          = errors.New("export is not completed yet")
+	ErrEmptyExport             = errors.New("no sensors to export")
+	ErrRateLimited             = errors.New("rate limit exceeded")
+	ErrEventConflict           = errors.New("sensor already has another event with this timestamp")
+	ErrIdempotencyKeyInUse     = errors.New("request with this idempotency key is in progress")
+	ErrIdempotencyKeyReused    = errors.New("idempotency key is used with another request")
+	ErrSensorTypeNotFound      = errors.New("sensor type not found")
+	ErrSensorTypeInUse         = errors.New("sensor type is used by sensors")
+	ErrInvalidReadings         = errors.New("readings don't fit the sensor type or its calibration")
+	ErrActuatorNotFound        = errors.New("actuator not found")
+	ErrCommandNotFound         = errors.New("command not found")
+	ErrCommandFinished         = errors.New("command is already finished")
+	ErrCommandChanged          = errors.New("command is changed concurrently")
+	ErrSceneNotFound           = errors.New("scene not found")
+	ErrSceneInUse              = errors.New("scene is used by schedules")
+	ErrScheduleNotFound        = errors.New("schedule not found")
+	ErrChangesExpired          = errors.New("changes after the cursor are deleted by the retention")
+	ErrConsumerNotFound        = errors.New("change consumer not found")
+)
+
+// Причины, по которым событие может быть отклонено
+const (
+	RejectReasonBadTimestamp  = "bad_timestamp"
+	RejectReasonUnknownSerial = "unknown_serial"
+	RejectReasonInactive      = "inactive"
+	RejectReasonThrottled     = "throttled"
+	RejectReasonBadReadings   = "bad_readings"
 )
 
 //go:generate mockgen -source usecase.go -package usecase -destination usecase_mock.go
@@ -29,6 +58,20 @@ type SensorRepository interface {
 	GetSensorBySerialNumber(ctx context.Context, sn string) (*domain.Sensor, error)
 }
 
+type SensorTypeRepository interface {
+	// SaveSensorType - функция сохранения типа датчиков, created - тип добавлен, а не заменен
+	SaveSensorType(ctx context.Context, sensorType domain.SensorTypeSpec) (created bool, err error)
+	// GetSensorTypes - функция получения списка типов датчиков, упорядоченного по имени
+	GetSensorTypes(ctx context.Context) ([]domain.SensorTypeSpec, error)
+	// GetSensorType - функция получения типа датчиков по имени
+	GetSensorType(ctx context.Context, name domain.SensorType) (*domain.SensorTypeSpec, error)
+	// DeleteSensorType - функция удаления типа датчиков. Тип, которым зарегистрированы датчики,
+	// не удаляется, возвращается ErrSensorTypeInUse
+	DeleteSensorType(ctx context.Context, name domain.SensorType) error
+}
+
+// EventRepository хранит не больше одного события датчика на момент времени. Повтор того же события
+// не сохраняется и не считается ошибкой, другое событие с тем же временем отклоняется с ErrEventConflict
 type EventRepository interface {
 	// SaveEvent - функция сохранения события по датчику
 	SaveEvent(ctx context.Context, event *domain.Event) error
@@ -37,6 +80,20 @@ type EventRepository interface {
 	GetHistoryBySensorID(ctx context.Context, id int64, from, to time.Time) ([]*domain.Event, error)
 }
 
+// EventHistoryStreamer - необязательное расширение EventRepository,
+// позволяющее обходить историю датчика, не загружая весь диапазон в память
+type EventHistoryStreamer interface {
+	// StreamHistoryBySensorID - функция последовательного обхода событий датчика в диапазоне [from, to]
+	StreamHistoryBySensorID(ctx context.Context, id int64, from, to time.Time, fn func(*domain.Event) error) error
+}
+
+// EventBatchSaver - необязательное расширение EventRepository для сохранения событий пачкой
+type EventBatchSaver interface {
+	// SaveEvents - функция сохранения нескольких событий за одну операцию.
+	// События, время которых уже занято, пропускаются
+	SaveEvents(ctx context.Context, events []*domain.Event) error
+}
+
 type UserRepository interface {
 	// SaveUser - функция сохранения пользователя
 	SaveUser(ctx context.Context, user *domain.User) error
@@ -49,4 +106,118 @@ type SensorOwnerRepository interface {
 	SaveSensorOwner(ctx context.Context, sensorOwner domain.SensorOwner) error
 	// GetSensorsByUserID -функция, возвращающая список привязок для пользователя
 	GetSensorsByUserID(ctx context.Context, userID int64) ([]domain.SensorOwner, error)
+	// GetSensorOwners - функция, возвращающая все привязки в порядке сохранения
+	GetSensorOwners(ctx context.Context) ([]domain.SensorOwner, error)
+}
+
+// ChangeRepository - журнал изменений датчиков, пользователей, привязок и событий. Записи в него добавляют
+// репозитории этих сущностей в той же транзакции, что и само изменение, поэтому записанное изменение
+// не теряется, а незаписанное не попадает в журнал
+type ChangeRepository interface {
+	// GetChanges - функция получения не больше limit записей с курсором больше after в порядке курсоров.
+	// Записи, видимые читателю, не обгоняют еще не видимые, поэтому продолжение с последнего курсора ничего не пропускает.
+	// Если записи после after уже удалены по сроку хранения, возвращает ErrChangesExpired
+	GetChanges(ctx context.Context, after int64, limit int) ([]domain.Change, error)
+	// DeleteChanges - функция удаления записей, сделанных раньше before, возвращает их количество
+	DeleteChanges(ctx context.Context, before time.Time) (int64, error)
+	// SaveConsumer - функция сохранения курсора потребителя
+	SaveConsumer(ctx context.Context, consumer domain.ChangeConsumer) error
+	// GetConsumer - функция получения потребителя по имени
+	GetConsumer(ctx context.Context, name string) (*domain.ChangeConsumer, error)
+}
+
+// ChangeNotifier - необязательное расширение ChangeRepository, которое сообщает о новых записях
+// без опроса журнала
+type ChangeNotifier interface {
+	// Changed - функция получения канала, который закрывается при следующей записи в журнал
+	Changed() <-chan struct{}
+}
+
+type ActuatorRepository interface {
+	// SaveActuator - функция сохранения исполнительного устройства, новому устройству присваивается ID
+	SaveActuator(ctx context.Context, actuator *domain.Actuator) error
+	// GetActuators - функция получения списка устройств, упорядоченного по ID
+	GetActuators(ctx context.Context) ([]domain.Actuator, error)
+	// GetActuatorByID - функция получения устройства по ID
+	GetActuatorByID(ctx context.Context, id int64) (*domain.Actuator, error)
+	// GetActuatorBySerialNumber - функция получения устройства по серийному номеру
+	GetActuatorBySerialNumber(ctx context.Context, sn string) (*domain.Actuator, error)
+}
+
+type CommandRepository interface {
+	// SaveCommand - функция сохранения команды, новой команде присваивается ID
+	SaveCommand(ctx context.Context, command *domain.Command) error
+	// GetCommandByID - функция получения команды по ID
+	GetCommandByID(ctx context.Context, id int64) (*domain.Command, error)
+	// GetActiveCommands - функция получения незавершенных команд устройства в порядке постановки в очередь
+	GetActiveCommands(ctx context.Context, actuatorID int64) ([]*domain.Command, error)
+	// UpdateCommand - функция сохранения изменений команды, прочитанной в статусе status после attempts попыток.
+	// Если с тех пор команду изменили, она не сохраняется и возвращается ErrCommandChanged
+	UpdateCommand(ctx context.Context, command *domain.Command, status domain.CommandStatus, attempts int) error
+}
+
+type SceneRepository interface {
+	// SaveScene - функция сохранения сцены, новой сцене присваивается ID, у несуществующей ErrSceneNotFound
+	SaveScene(ctx context.Context, scene *domain.Scene) error
+	// GetScenes - функция получения списка сцен, упорядоченного по ID
+	GetScenes(ctx context.Context) ([]domain.Scene, error)
+	// GetSceneByID - функция получения сцены по ID
+	GetSceneByID(ctx context.Context, id int64) (*domain.Scene, error)
+	// DeleteScene - функция удаления сцены
+	DeleteScene(ctx context.Context, id int64) error
+}
+
+type ScheduleRepository interface {
+	// SaveSchedule - функция сохранения расписания, новому расписанию присваивается ID
+	SaveSchedule(ctx context.Context, schedule *domain.Schedule) error
+	// GetSchedules - функция получения списка расписаний, упорядоченного по ID
+	GetSchedules(ctx context.Context) ([]domain.Schedule, error)
+	// GetScheduleByID - функция получения расписания по ID
+	GetScheduleByID(ctx context.Context, id int64) (*domain.Schedule, error)
+	// DeleteSchedule - функция удаления расписания
+	DeleteSchedule(ctx context.Context, id int64) error
+	// GetDueSchedules - функция получения включенных расписаний, время запуска которых не позже now,
+	// упорядоченных по времени запуска
+	GetDueSchedules(ctx context.Context, now time.Time) ([]*domain.Schedule, error)
+	// AdvanceSchedule - функция сохранения расписания после запуска, только если его время запуска все еще prevRun.
+	// false - расписание уже запустил кто-то другой, и оно не сохранено. Удаленное расписание не создается заново,
+	// возвращается ErrScheduleNotFound
+	AdvanceSchedule(ctx context.Context, schedule *domain.Schedule, prevRun time.Time) (bool, error)
+}
+
+type ImportCheckpointRepository interface {
+	// SaveCheckpoint - функция сохранения количества обработанных записей импорта
+	SaveCheckpoint(ctx context.Context, key string, processed int64) error
+	// GetCheckpoint - функция получения количества обработанных записей импорта, 0 если импорт не начинался
+	GetCheckpoint(ctx context.Context, key string) (int64, error)
+}
+
+type RateLimitRepository interface {
+	// TakeToken - функция взятия токена из корзины key с ограничением limit.
+	// Если токенов нет, возвращает время, через которое появится следующий
+	TakeToken(ctx context.Context, key string, limit domain.RateLimit) (time.Duration, error)
+}
+
+type IdempotencyRepository interface {
+	// ReserveKey - функция резервирования ключа за запросом. Если ключ уже занят, возвращает его запись,
+	// истекшие записи не учитываются
+	ReserveKey(ctx context.Context, record domain.IdempotencyRecord) (*domain.IdempotencyRecord, error)
+	// CompleteKey - функция сохранения результата запроса
+	CompleteKey(ctx context.Context, record domain.IdempotencyRecord) error
+	// ReleaseKey - функция освобождения ключа, чтобы запрос можно было повторить
+	ReleaseKey(ctx context.Context, key string) error
+}
+
+type EventMetrics interface {
+	// EventReceived - событие датчика типа sensorType сохранено
+	EventReceived(sensorType domain.SensorType)
+	// EventLag - событие датчика типа sensorType со временем устройства принято через lag после этого времени
+	EventLag(sensorType domain.SensorType, lag time.Duration)
+	// EventRejected - событие отклонено, reason - одна из RejectReason*
+	EventRejected(reason string)
+}
+
+type UserMetrics interface {
+	// SensorsBound - у пользователя userID теперь count привязанных датчиков
+	SensorsBound(userID int64, count int)
 }
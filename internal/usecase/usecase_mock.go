@@ -95,6 +95,88 @@ func (mr *MockSensorRepositoryMockRecorder) SaveSensor(ctx, sensor interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSensor", reflect.TypeOf((*MockSensorRepository)(nil).SaveSensor), ctx, sensor)
 }
 
+// MockSensorTypeRepository is a mock of SensorTypeRepository interface.
+type MockSensorTypeRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSensorTypeRepositoryMockRecorder
+}
+
+// MockSensorTypeRepositoryMockRecorder is the mock recorder for MockSensorTypeRepository.
+type MockSensorTypeRepositoryMockRecorder struct {
+	mock *MockSensorTypeRepository
+}
+
+// NewMockSensorTypeRepository creates a new mock instance.
+func NewMockSensorTypeRepository(ctrl *gomock.Controller) *MockSensorTypeRepository {
+	mock := &MockSensorTypeRepository{ctrl: ctrl}
+	mock.recorder = &MockSensorTypeRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSensorTypeRepository) EXPECT() *MockSensorTypeRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteSensorType mocks base method.
+func (m *MockSensorTypeRepository) DeleteSensorType(ctx context.Context, name domain.SensorType) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSensorType", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSensorType indicates an expected call of DeleteSensorType.
+func (mr *MockSensorTypeRepositoryMockRecorder) DeleteSensorType(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSensorType", reflect.TypeOf((*MockSensorTypeRepository)(nil).DeleteSensorType), ctx, name)
+}
+
+// GetSensorType mocks base method.
+func (m *MockSensorTypeRepository) GetSensorType(ctx context.Context, name domain.SensorType) (*domain.SensorTypeSpec, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSensorType", ctx, name)
+	ret0, _ := ret[0].(*domain.SensorTypeSpec)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSensorType indicates an expected call of GetSensorType.
+func (mr *MockSensorTypeRepositoryMockRecorder) GetSensorType(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSensorType", reflect.TypeOf((*MockSensorTypeRepository)(nil).GetSensorType), ctx, name)
+}
+
+// GetSensorTypes mocks base method.
+func (m *MockSensorTypeRepository) GetSensorTypes(ctx context.Context) ([]domain.SensorTypeSpec, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSensorTypes", ctx)
+	ret0, _ := ret[0].([]domain.SensorTypeSpec)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSensorTypes indicates an expected call of GetSensorTypes.
+func (mr *MockSensorTypeRepositoryMockRecorder) GetSensorTypes(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSensorTypes", reflect.TypeOf((*MockSensorTypeRepository)(nil).GetSensorTypes), ctx)
+}
+
+// SaveSensorType mocks base method.
+func (m *MockSensorTypeRepository) SaveSensorType(ctx context.Context, sensorType domain.SensorTypeSpec) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSensorType", ctx, sensorType)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveSensorType indicates an expected call of SaveSensorType.
+func (mr *MockSensorTypeRepositoryMockRecorder) SaveSensorType(ctx, sensorType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSensorType", reflect.TypeOf((*MockSensorTypeRepository)(nil).SaveSensorType), ctx, sensorType)
+}
+
 // MockEventRepository is a mock of EventRepository interface.
 type MockEventRepository struct {
 	ctrl     *gomock.Controller
@@ -162,6 +244,80 @@ func (mr *MockEventRepositoryMockRecorder) SaveEvent(ctx, event interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveEvent", reflect.TypeOf((*MockEventRepository)(nil).SaveEvent), ctx, event)
 }
 
+// MockEventHistoryStreamer is a mock of EventHistoryStreamer interface.
+type MockEventHistoryStreamer struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventHistoryStreamerMockRecorder
+}
+
+// MockEventHistoryStreamerMockRecorder is the mock recorder for MockEventHistoryStreamer.
+type MockEventHistoryStreamerMockRecorder struct {
+	mock *MockEventHistoryStreamer
+}
+
+// NewMockEventHistoryStreamer creates a new mock instance.
+func NewMockEventHistoryStreamer(ctrl *gomock.Controller) *MockEventHistoryStreamer {
+	mock := &MockEventHistoryStreamer{ctrl: ctrl}
+	mock.recorder = &MockEventHistoryStreamerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventHistoryStreamer) EXPECT() *MockEventHistoryStreamerMockRecorder {
+	return m.recorder
+}
+
+// StreamHistoryBySensorID mocks base method.
+func (m *MockEventHistoryStreamer) StreamHistoryBySensorID(ctx context.Context, id int64, from, to time.Time, fn func(*domain.Event) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamHistoryBySensorID", ctx, id, from, to, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamHistoryBySensorID indicates an expected call of StreamHistoryBySensorID.
+func (mr *MockEventHistoryStreamerMockRecorder) StreamHistoryBySensorID(ctx, id, from, to, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamHistoryBySensorID", reflect.TypeOf((*MockEventHistoryStreamer)(nil).StreamHistoryBySensorID), ctx, id, from, to, fn)
+}
+
+// MockEventBatchSaver is a mock of EventBatchSaver interface.
+type MockEventBatchSaver struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventBatchSaverMockRecorder
+}
+
+// MockEventBatchSaverMockRecorder is the mock recorder for MockEventBatchSaver.
+type MockEventBatchSaverMockRecorder struct {
+	mock *MockEventBatchSaver
+}
+
+// NewMockEventBatchSaver creates a new mock instance.
+func NewMockEventBatchSaver(ctrl *gomock.Controller) *MockEventBatchSaver {
+	mock := &MockEventBatchSaver{ctrl: ctrl}
+	mock.recorder = &MockEventBatchSaverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventBatchSaver) EXPECT() *MockEventBatchSaverMockRecorder {
+	return m.recorder
+}
+
+// SaveEvents mocks base method.
+func (m *MockEventBatchSaver) SaveEvents(ctx context.Context, events []*domain.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveEvents", ctx, events)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveEvents indicates an expected call of SaveEvents.
+func (mr *MockEventBatchSaverMockRecorder) SaveEvents(ctx, events interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveEvents", reflect.TypeOf((*MockEventBatchSaver)(nil).SaveEvents), ctx, events)
+}
+
 // MockUserRepository is a mock of UserRepository interface.
 type MockUserRepository struct {
 	ctrl     *gomock.Controller
@@ -237,6 +393,21 @@ func (m *MockSensorOwnerRepository) EXPECT() *MockSensorOwnerRepositoryMockRecor
 	return m.recorder
 }
 
+// GetSensorOwners mocks base method.
+func (m *MockSensorOwnerRepository) GetSensorOwners(ctx context.Context) ([]domain.SensorOwner, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSensorOwners", ctx)
+	ret0, _ := ret[0].([]domain.SensorOwner)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSensorOwners indicates an expected call of GetSensorOwners.
+func (mr *MockSensorOwnerRepositoryMockRecorder) GetSensorOwners(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSensorOwners", reflect.TypeOf((*MockSensorOwnerRepository)(nil).GetSensorOwners), ctx)
+}
+
 // GetSensorsByUserID mocks base method.
 func (m *MockSensorOwnerRepository) GetSensorsByUserID(ctx context.Context, userID int64) ([]domain.SensorOwner, error) {
 	m.ctrl.T.Helper()
@@ -265,3 +436,727 @@ func (mr *MockSensorOwnerRepositoryMockRecorder) SaveSensorOwner(ctx, sensorOwne
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSensorOwner", reflect.TypeOf((*MockSensorOwnerRepository)(nil).SaveSensorOwner), ctx, sensorOwner)
 }
+
+// MockChangeRepository is a mock of ChangeRepository interface.
+type MockChangeRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockChangeRepositoryMockRecorder
+}
+
+// MockChangeRepositoryMockRecorder is the mock recorder for MockChangeRepository.
+type MockChangeRepositoryMockRecorder struct {
+	mock *MockChangeRepository
+}
+
+// NewMockChangeRepository creates a new mock instance.
+func NewMockChangeRepository(ctrl *gomock.Controller) *MockChangeRepository {
+	mock := &MockChangeRepository{ctrl: ctrl}
+	mock.recorder = &MockChangeRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockChangeRepository) EXPECT() *MockChangeRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteChanges mocks base method.
+func (m *MockChangeRepository) DeleteChanges(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteChanges", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteChanges indicates an expected call of DeleteChanges.
+func (mr *MockChangeRepositoryMockRecorder) DeleteChanges(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteChanges", reflect.TypeOf((*MockChangeRepository)(nil).DeleteChanges), ctx, before)
+}
+
+// GetChanges mocks base method.
+func (m *MockChangeRepository) GetChanges(ctx context.Context, after int64, limit int) ([]domain.Change, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChanges", ctx, after, limit)
+	ret0, _ := ret[0].([]domain.Change)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChanges indicates an expected call of GetChanges.
+func (mr *MockChangeRepositoryMockRecorder) GetChanges(ctx, after, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChanges", reflect.TypeOf((*MockChangeRepository)(nil).GetChanges), ctx, after, limit)
+}
+
+// GetConsumer mocks base method.
+func (m *MockChangeRepository) GetConsumer(ctx context.Context, name string) (*domain.ChangeConsumer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConsumer", ctx, name)
+	ret0, _ := ret[0].(*domain.ChangeConsumer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConsumer indicates an expected call of GetConsumer.
+func (mr *MockChangeRepositoryMockRecorder) GetConsumer(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConsumer", reflect.TypeOf((*MockChangeRepository)(nil).GetConsumer), ctx, name)
+}
+
+// SaveConsumer mocks base method.
+func (m *MockChangeRepository) SaveConsumer(ctx context.Context, consumer domain.ChangeConsumer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveConsumer", ctx, consumer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveConsumer indicates an expected call of SaveConsumer.
+func (mr *MockChangeRepositoryMockRecorder) SaveConsumer(ctx, consumer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveConsumer", reflect.TypeOf((*MockChangeRepository)(nil).SaveConsumer), ctx, consumer)
+}
+
+// MockChangeNotifier is a mock of ChangeNotifier interface.
+type MockChangeNotifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockChangeNotifierMockRecorder
+}
+
+// MockChangeNotifierMockRecorder is the mock recorder for MockChangeNotifier.
+type MockChangeNotifierMockRecorder struct {
+	mock *MockChangeNotifier
+}
+
+// NewMockChangeNotifier creates a new mock instance.
+func NewMockChangeNotifier(ctrl *gomock.Controller) *MockChangeNotifier {
+	mock := &MockChangeNotifier{ctrl: ctrl}
+	mock.recorder = &MockChangeNotifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockChangeNotifier) EXPECT() *MockChangeNotifierMockRecorder {
+	return m.recorder
+}
+
+// Changed mocks base method.
+func (m *MockChangeNotifier) Changed() <-chan struct{} {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Changed")
+	ret0, _ := ret[0].(<-chan struct{})
+	return ret0
+}
+
+// Changed indicates an expected call of Changed.
+func (mr *MockChangeNotifierMockRecorder) Changed() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Changed", reflect.TypeOf((*MockChangeNotifier)(nil).Changed))
+}
+
+// MockActuatorRepository is a mock of ActuatorRepository interface.
+type MockActuatorRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockActuatorRepositoryMockRecorder
+}
+
+// MockActuatorRepositoryMockRecorder is the mock recorder for MockActuatorRepository.
+type MockActuatorRepositoryMockRecorder struct {
+	mock *MockActuatorRepository
+}
+
+// NewMockActuatorRepository creates a new mock instance.
+func NewMockActuatorRepository(ctrl *gomock.Controller) *MockActuatorRepository {
+	mock := &MockActuatorRepository{ctrl: ctrl}
+	mock.recorder = &MockActuatorRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockActuatorRepository) EXPECT() *MockActuatorRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetActuatorByID mocks base method.
+func (m *MockActuatorRepository) GetActuatorByID(ctx context.Context, id int64) (*domain.Actuator, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActuatorByID", ctx, id)
+	ret0, _ := ret[0].(*domain.Actuator)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActuatorByID indicates an expected call of GetActuatorByID.
+func (mr *MockActuatorRepositoryMockRecorder) GetActuatorByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActuatorByID", reflect.TypeOf((*MockActuatorRepository)(nil).GetActuatorByID), ctx, id)
+}
+
+// GetActuatorBySerialNumber mocks base method.
+func (m *MockActuatorRepository) GetActuatorBySerialNumber(ctx context.Context, sn string) (*domain.Actuator, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActuatorBySerialNumber", ctx, sn)
+	ret0, _ := ret[0].(*domain.Actuator)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActuatorBySerialNumber indicates an expected call of GetActuatorBySerialNumber.
+func (mr *MockActuatorRepositoryMockRecorder) GetActuatorBySerialNumber(ctx, sn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActuatorBySerialNumber", reflect.TypeOf((*MockActuatorRepository)(nil).GetActuatorBySerialNumber), ctx, sn)
+}
+
+// GetActuators mocks base method.
+func (m *MockActuatorRepository) GetActuators(ctx context.Context) ([]domain.Actuator, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActuators", ctx)
+	ret0, _ := ret[0].([]domain.Actuator)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActuators indicates an expected call of GetActuators.
+func (mr *MockActuatorRepositoryMockRecorder) GetActuators(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActuators", reflect.TypeOf((*MockActuatorRepository)(nil).GetActuators), ctx)
+}
+
+// SaveActuator mocks base method.
+func (m *MockActuatorRepository) SaveActuator(ctx context.Context, actuator *domain.Actuator) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveActuator", ctx, actuator)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveActuator indicates an expected call of SaveActuator.
+func (mr *MockActuatorRepositoryMockRecorder) SaveActuator(ctx, actuator interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveActuator", reflect.TypeOf((*MockActuatorRepository)(nil).SaveActuator), ctx, actuator)
+}
+
+// MockCommandRepository is a mock of CommandRepository interface.
+type MockCommandRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCommandRepositoryMockRecorder
+}
+
+// MockCommandRepositoryMockRecorder is the mock recorder for MockCommandRepository.
+type MockCommandRepositoryMockRecorder struct {
+	mock *MockCommandRepository
+}
+
+// NewMockCommandRepository creates a new mock instance.
+func NewMockCommandRepository(ctrl *gomock.Controller) *MockCommandRepository {
+	mock := &MockCommandRepository{ctrl: ctrl}
+	mock.recorder = &MockCommandRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCommandRepository) EXPECT() *MockCommandRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetActiveCommands mocks base method.
+func (m *MockCommandRepository) GetActiveCommands(ctx context.Context, actuatorID int64) ([]*domain.Command, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveCommands", ctx, actuatorID)
+	ret0, _ := ret[0].([]*domain.Command)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveCommands indicates an expected call of GetActiveCommands.
+func (mr *MockCommandRepositoryMockRecorder) GetActiveCommands(ctx, actuatorID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveCommands", reflect.TypeOf((*MockCommandRepository)(nil).GetActiveCommands), ctx, actuatorID)
+}
+
+// GetCommandByID mocks base method.
+func (m *MockCommandRepository) GetCommandByID(ctx context.Context, id int64) (*domain.Command, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCommandByID", ctx, id)
+	ret0, _ := ret[0].(*domain.Command)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCommandByID indicates an expected call of GetCommandByID.
+func (mr *MockCommandRepositoryMockRecorder) GetCommandByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommandByID", reflect.TypeOf((*MockCommandRepository)(nil).GetCommandByID), ctx, id)
+}
+
+// SaveCommand mocks base method.
+func (m *MockCommandRepository) SaveCommand(ctx context.Context, command *domain.Command) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveCommand", ctx, command)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveCommand indicates an expected call of SaveCommand.
+func (mr *MockCommandRepositoryMockRecorder) SaveCommand(ctx, command interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCommand", reflect.TypeOf((*MockCommandRepository)(nil).SaveCommand), ctx, command)
+}
+
+// UpdateCommand mocks base method.
+func (m *MockCommandRepository) UpdateCommand(ctx context.Context, command *domain.Command, status domain.CommandStatus, attempts int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCommand", ctx, command, status, attempts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCommand indicates an expected call of UpdateCommand.
+func (mr *MockCommandRepositoryMockRecorder) UpdateCommand(ctx, command, status, attempts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCommand", reflect.TypeOf((*MockCommandRepository)(nil).UpdateCommand), ctx, command, status, attempts)
+}
+
+// MockSceneRepository is a mock of SceneRepository interface.
+type MockSceneRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSceneRepositoryMockRecorder
+}
+
+// MockSceneRepositoryMockRecorder is the mock recorder for MockSceneRepository.
+type MockSceneRepositoryMockRecorder struct {
+	mock *MockSceneRepository
+}
+
+// NewMockSceneRepository creates a new mock instance.
+func NewMockSceneRepository(ctrl *gomock.Controller) *MockSceneRepository {
+	mock := &MockSceneRepository{ctrl: ctrl}
+	mock.recorder = &MockSceneRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSceneRepository) EXPECT() *MockSceneRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteScene mocks base method.
+func (m *MockSceneRepository) DeleteScene(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteScene", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteScene indicates an expected call of DeleteScene.
+func (mr *MockSceneRepositoryMockRecorder) DeleteScene(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteScene", reflect.TypeOf((*MockSceneRepository)(nil).DeleteScene), ctx, id)
+}
+
+// GetSceneByID mocks base method.
+func (m *MockSceneRepository) GetSceneByID(ctx context.Context, id int64) (*domain.Scene, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSceneByID", ctx, id)
+	ret0, _ := ret[0].(*domain.Scene)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSceneByID indicates an expected call of GetSceneByID.
+func (mr *MockSceneRepositoryMockRecorder) GetSceneByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSceneByID", reflect.TypeOf((*MockSceneRepository)(nil).GetSceneByID), ctx, id)
+}
+
+// GetScenes mocks base method.
+func (m *MockSceneRepository) GetScenes(ctx context.Context) ([]domain.Scene, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScenes", ctx)
+	ret0, _ := ret[0].([]domain.Scene)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScenes indicates an expected call of GetScenes.
+func (mr *MockSceneRepositoryMockRecorder) GetScenes(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScenes", reflect.TypeOf((*MockSceneRepository)(nil).GetScenes), ctx)
+}
+
+// SaveScene mocks base method.
+func (m *MockSceneRepository) SaveScene(ctx context.Context, scene *domain.Scene) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveScene", ctx, scene)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveScene indicates an expected call of SaveScene.
+func (mr *MockSceneRepositoryMockRecorder) SaveScene(ctx, scene interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveScene", reflect.TypeOf((*MockSceneRepository)(nil).SaveScene), ctx, scene)
+}
+
+// MockScheduleRepository is a mock of ScheduleRepository interface.
+type MockScheduleRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockScheduleRepositoryMockRecorder
+}
+
+// MockScheduleRepositoryMockRecorder is the mock recorder for MockScheduleRepository.
+type MockScheduleRepositoryMockRecorder struct {
+	mock *MockScheduleRepository
+}
+
+// NewMockScheduleRepository creates a new mock instance.
+func NewMockScheduleRepository(ctrl *gomock.Controller) *MockScheduleRepository {
+	mock := &MockScheduleRepository{ctrl: ctrl}
+	mock.recorder = &MockScheduleRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScheduleRepository) EXPECT() *MockScheduleRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AdvanceSchedule mocks base method.
+func (m *MockScheduleRepository) AdvanceSchedule(ctx context.Context, schedule *domain.Schedule, prevRun time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdvanceSchedule", ctx, schedule, prevRun)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdvanceSchedule indicates an expected call of AdvanceSchedule.
+func (mr *MockScheduleRepositoryMockRecorder) AdvanceSchedule(ctx, schedule, prevRun interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdvanceSchedule", reflect.TypeOf((*MockScheduleRepository)(nil).AdvanceSchedule), ctx, schedule, prevRun)
+}
+
+// DeleteSchedule mocks base method.
+func (m *MockScheduleRepository) DeleteSchedule(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSchedule", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSchedule indicates an expected call of DeleteSchedule.
+func (mr *MockScheduleRepositoryMockRecorder) DeleteSchedule(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSchedule", reflect.TypeOf((*MockScheduleRepository)(nil).DeleteSchedule), ctx, id)
+}
+
+// GetDueSchedules mocks base method.
+func (m *MockScheduleRepository) GetDueSchedules(ctx context.Context, now time.Time) ([]*domain.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueSchedules", ctx, now)
+	ret0, _ := ret[0].([]*domain.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueSchedules indicates an expected call of GetDueSchedules.
+func (mr *MockScheduleRepositoryMockRecorder) GetDueSchedules(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueSchedules", reflect.TypeOf((*MockScheduleRepository)(nil).GetDueSchedules), ctx, now)
+}
+
+// GetScheduleByID mocks base method.
+func (m *MockScheduleRepository) GetScheduleByID(ctx context.Context, id int64) (*domain.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduleByID", ctx, id)
+	ret0, _ := ret[0].(*domain.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduleByID indicates an expected call of GetScheduleByID.
+func (mr *MockScheduleRepositoryMockRecorder) GetScheduleByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduleByID", reflect.TypeOf((*MockScheduleRepository)(nil).GetScheduleByID), ctx, id)
+}
+
+// GetSchedules mocks base method.
+func (m *MockScheduleRepository) GetSchedules(ctx context.Context) ([]domain.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchedules", ctx)
+	ret0, _ := ret[0].([]domain.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSchedules indicates an expected call of GetSchedules.
+func (mr *MockScheduleRepositoryMockRecorder) GetSchedules(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchedules", reflect.TypeOf((*MockScheduleRepository)(nil).GetSchedules), ctx)
+}
+
+// SaveSchedule mocks base method.
+func (m *MockScheduleRepository) SaveSchedule(ctx context.Context, schedule *domain.Schedule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSchedule", ctx, schedule)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSchedule indicates an expected call of SaveSchedule.
+func (mr *MockScheduleRepositoryMockRecorder) SaveSchedule(ctx, schedule interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSchedule", reflect.TypeOf((*MockScheduleRepository)(nil).SaveSchedule), ctx, schedule)
+}
+
+// MockImportCheckpointRepository is a mock of ImportCheckpointRepository interface.
+type MockImportCheckpointRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockImportCheckpointRepositoryMockRecorder
+}
+
+// MockImportCheckpointRepositoryMockRecorder is the mock recorder for MockImportCheckpointRepository.
+type MockImportCheckpointRepositoryMockRecorder struct {
+	mock *MockImportCheckpointRepository
+}
+
+// NewMockImportCheckpointRepository creates a new mock instance.
+func NewMockImportCheckpointRepository(ctrl *gomock.Controller) *MockImportCheckpointRepository {
+	mock := &MockImportCheckpointRepository{ctrl: ctrl}
+	mock.recorder = &MockImportCheckpointRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImportCheckpointRepository) EXPECT() *MockImportCheckpointRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetCheckpoint mocks base method.
+func (m *MockImportCheckpointRepository) GetCheckpoint(ctx context.Context, key string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCheckpoint", ctx, key)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCheckpoint indicates an expected call of GetCheckpoint.
+func (mr *MockImportCheckpointRepositoryMockRecorder) GetCheckpoint(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCheckpoint", reflect.TypeOf((*MockImportCheckpointRepository)(nil).GetCheckpoint), ctx, key)
+}
+
+// SaveCheckpoint mocks base method.
+func (m *MockImportCheckpointRepository) SaveCheckpoint(ctx context.Context, key string, processed int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveCheckpoint", ctx, key, processed)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveCheckpoint indicates an expected call of SaveCheckpoint.
+func (mr *MockImportCheckpointRepositoryMockRecorder) SaveCheckpoint(ctx, key, processed interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCheckpoint", reflect.TypeOf((*MockImportCheckpointRepository)(nil).SaveCheckpoint), ctx, key, processed)
+}
+
+// MockRateLimitRepository is a mock of RateLimitRepository interface.
+type MockRateLimitRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRateLimitRepositoryMockRecorder
+}
+
+// MockRateLimitRepositoryMockRecorder is the mock recorder for MockRateLimitRepository.
+type MockRateLimitRepositoryMockRecorder struct {
+	mock *MockRateLimitRepository
+}
+
+// NewMockRateLimitRepository creates a new mock instance.
+func NewMockRateLimitRepository(ctrl *gomock.Controller) *MockRateLimitRepository {
+	mock := &MockRateLimitRepository{ctrl: ctrl}
+	mock.recorder = &MockRateLimitRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRateLimitRepository) EXPECT() *MockRateLimitRepositoryMockRecorder {
+	return m.recorder
+}
+
+// TakeToken mocks base method.
+func (m *MockRateLimitRepository) TakeToken(ctx context.Context, key string, limit domain.RateLimit) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeToken", ctx, key, limit)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeToken indicates an expected call of TakeToken.
+func (mr *MockRateLimitRepositoryMockRecorder) TakeToken(ctx, key, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeToken", reflect.TypeOf((*MockRateLimitRepository)(nil).TakeToken), ctx, key, limit)
+}
+
+// MockIdempotencyRepository is a mock of IdempotencyRepository interface.
+type MockIdempotencyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyRepositoryMockRecorder
+}
+
+// MockIdempotencyRepositoryMockRecorder is the mock recorder for MockIdempotencyRepository.
+type MockIdempotencyRepositoryMockRecorder struct {
+	mock *MockIdempotencyRepository
+}
+
+// NewMockIdempotencyRepository creates a new mock instance.
+func NewMockIdempotencyRepository(ctrl *gomock.Controller) *MockIdempotencyRepository {
+	mock := &MockIdempotencyRepository{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotencyRepository) EXPECT() *MockIdempotencyRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CompleteKey mocks base method.
+func (m *MockIdempotencyRepository) CompleteKey(ctx context.Context, record domain.IdempotencyRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteKey", ctx, record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteKey indicates an expected call of CompleteKey.
+func (mr *MockIdempotencyRepositoryMockRecorder) CompleteKey(ctx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteKey", reflect.TypeOf((*MockIdempotencyRepository)(nil).CompleteKey), ctx, record)
+}
+
+// ReleaseKey mocks base method.
+func (m *MockIdempotencyRepository) ReleaseKey(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseKey indicates an expected call of ReleaseKey.
+func (mr *MockIdempotencyRepositoryMockRecorder) ReleaseKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseKey", reflect.TypeOf((*MockIdempotencyRepository)(nil).ReleaseKey), ctx, key)
+}
+
+// ReserveKey mocks base method.
+func (m *MockIdempotencyRepository) ReserveKey(ctx context.Context, record domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveKey", ctx, record)
+	ret0, _ := ret[0].(*domain.IdempotencyRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReserveKey indicates an expected call of ReserveKey.
+func (mr *MockIdempotencyRepositoryMockRecorder) ReserveKey(ctx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveKey", reflect.TypeOf((*MockIdempotencyRepository)(nil).ReserveKey), ctx, record)
+}
+
+// MockEventMetrics is a mock of EventMetrics interface.
+type MockEventMetrics struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventMetricsMockRecorder
+}
+
+// MockEventMetricsMockRecorder is the mock recorder for MockEventMetrics.
+type MockEventMetricsMockRecorder struct {
+	mock *MockEventMetrics
+}
+
+// NewMockEventMetrics creates a new mock instance.
+func NewMockEventMetrics(ctrl *gomock.Controller) *MockEventMetrics {
+	mock := &MockEventMetrics{ctrl: ctrl}
+	mock.recorder = &MockEventMetricsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventMetrics) EXPECT() *MockEventMetricsMockRecorder {
+	return m.recorder
+}
+
+// EventLag mocks base method.
+func (m *MockEventMetrics) EventLag(sensorType domain.SensorType, lag time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "EventLag", sensorType, lag)
+}
+
+// EventLag indicates an expected call of EventLag.
+func (mr *MockEventMetricsMockRecorder) EventLag(sensorType, lag interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EventLag", reflect.TypeOf((*MockEventMetrics)(nil).EventLag), sensorType, lag)
+}
+
+// EventReceived mocks base method.
+func (m *MockEventMetrics) EventReceived(sensorType domain.SensorType) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "EventReceived", sensorType)
+}
+
+// EventReceived indicates an expected call of EventReceived.
+func (mr *MockEventMetricsMockRecorder) EventReceived(sensorType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EventReceived", reflect.TypeOf((*MockEventMetrics)(nil).EventReceived), sensorType)
+}
+
+// EventRejected mocks base method.
+func (m *MockEventMetrics) EventRejected(reason string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "EventRejected", reason)
+}
+
+// EventRejected indicates an expected call of EventRejected.
+func (mr *MockEventMetricsMockRecorder) EventRejected(reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EventRejected", reflect.TypeOf((*MockEventMetrics)(nil).EventRejected), reason)
+}
+
+// MockUserMetrics is a mock of UserMetrics interface.
+type MockUserMetrics struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserMetricsMockRecorder
+}
+
+// MockUserMetricsMockRecorder is the mock recorder for MockUserMetrics.
+type MockUserMetricsMockRecorder struct {
+	mock *MockUserMetrics
+}
+
+// NewMockUserMetrics creates a new mock instance.
+func NewMockUserMetrics(ctrl *gomock.Controller) *MockUserMetrics {
+	mock := &MockUserMetrics{ctrl: ctrl}
+	mock.recorder = &MockUserMetricsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserMetrics) EXPECT() *MockUserMetricsMockRecorder {
+	return m.recorder
+}
+
+// SensorsBound mocks base method.
+func (m *MockUserMetrics) SensorsBound(userID int64, count int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SensorsBound", userID, count)
+}
+
+// SensorsBound indicates an expected call of SensorsBound.
+func (mr *MockUserMetricsMockRecorder) SensorsBound(userID, count interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SensorsBound", reflect.TypeOf((*MockUserMetrics)(nil).SensorsBound), userID, count)
+}
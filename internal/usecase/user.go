@@ -3,13 +3,27 @@ package usecase
 import (
 	"context"
 	"homework/internal/domain"
+	"maps"
+	"slices"
 	"sync"
+	"time"
 )
 
+// ownersTTL is how long the owners of the sensors are cached, the bindings made by the other replicas
+// are seen after it
+const ownersTTL = time.Minute
+
 type User struct {
 	userRepository        UserRepository
 	sensorRepository      SensorRepository
 	sensorOwnerRepository SensorOwnerRepository
+	metrics               UserMetrics
+	now                   func() time.Time
+
+	// owners are the users of every bound sensor, loaded at ownersLoaded and kept up with the bindings since
+	owners       map[int64][]int64
+	ownersLoaded time.Time
+	ownersM      sync.Mutex
 }
 
 var (
@@ -17,34 +31,112 @@ var (
 	userIds     int64 = 1
 )
 
-func NewUser(ur UserRepository, sor SensorOwnerRepository, sr SensorRepository) *User {
-	return &User{userRepository: ur, sensorRepository: sr, sensorOwnerRepository: sor}
+func NewUser(ur UserRepository, sor SensorOwnerRepository, sr SensorRepository, options ...func(*User)) *User {
+	u := &User{userRepository: ur, sensorRepository: sr, sensorOwnerRepository: sor, now: time.Now}
+	for _, o := range options {
+		o(u)
+	}
+	return u
+}
+
+// WithUserMetrics makes the usecase report how many sensors the users have
+func WithUserMetrics(m UserMetrics) func(*User) {
+	return func(u *User) {
+		u.metrics = m
+	}
 }
 
-func (u *User) RegisterUser(ctx context.Context, user *domain.User) (*domain.User, error) {
+func (u *User) RegisterUser(ctx context.Context, user *domain.User) (_ *domain.User, err error) {
+	ctx, end := startSpan(ctx, "User.RegisterUser")
+	defer end(&err)
+
 	if len(user.Name) == 0 {
 		return nil, ErrInvalidUserName
 	}
+	if err := u.userRepository.SaveUser(ctx, user); err != nil {
+		return nil, err
+	}
+	// the repositories that keep no sequence of their own leave the id to the usecase
 	if user.ID <= 0 {
 		userIdMutex.Lock()
 		user.ID = userIds
 		userIds++
 		userIdMutex.Unlock()
 	}
-	return user, u.userRepository.SaveUser(ctx, user)
+	return user, nil
 }
 
-func (u *User) AttachSensorToUser(ctx context.Context, userID, sensorID int64) error {
+func (u *User) AttachSensorToUser(ctx context.Context, userID, sensorID int64) (err error) {
+	ctx, end := startSpan(ctx, "User.AttachSensorToUser")
+	defer end(&err)
+
 	if _, err := u.userRepository.GetUserByID(ctx, userID); err != nil {
 		return err
 	}
 	if _, err := u.sensorRepository.GetSensorByID(ctx, sensorID); err != nil {
 		return err
 	}
-	return u.sensorOwnerRepository.SaveSensorOwner(ctx, domain.SensorOwner{UserID: userID, SensorID: sensorID})
+	if err := u.sensorOwnerRepository.SaveSensorOwner(ctx, domain.SensorOwner{UserID: userID, SensorID: sensorID}); err != nil {
+		return err
+	}
+	u.ownerBound(userID, sensorID)
+
+	if u.metrics != nil {
+		// the binding is already saved, failing to count them must not fail the request
+		if bindings, err := u.sensorOwnerRepository.GetSensorsByUserID(ctx, userID); err == nil {
+			u.metrics.SensorsBound(userID, len(bindings))
+		}
+	}
+	return nil
 }
 
-func (u *User) GetUserSensors(ctx context.Context, userID int64) ([]domain.Sensor, error) {
+// GetSensorOwners returns the ids of the users of every bound sensor in ascending order
+func (u *User) GetSensorOwners(ctx context.Context) (_ map[int64][]int64, err error) {
+	ctx, end := startSpan(ctx, "User.GetSensorOwners")
+	defer end(&err)
+
+	u.ownersM.Lock()
+	defer u.ownersM.Unlock()
+	if u.owners == nil || u.now().Sub(u.ownersLoaded) > ownersTTL {
+		bindings, err := u.sensorOwnerRepository.GetSensorOwners(ctx)
+		if err != nil {
+			return nil, err
+		}
+		u.owners, u.ownersLoaded = map[int64][]int64{}, u.now()
+		for _, b := range bindings {
+			u.addOwner(b.UserID, b.SensorID)
+		}
+	}
+
+	owners := maps.Clone(u.owners)
+	for id, users := range owners {
+		owners[id] = slices.Clone(users)
+	}
+	return owners, nil
+}
+
+// ownerBound keeps the loaded owners up with the binding
+func (u *User) ownerBound(userID, sensorID int64) {
+	u.ownersM.Lock()
+	defer u.ownersM.Unlock()
+	if u.owners != nil {
+		u.addOwner(userID, sensorID)
+	}
+}
+
+// addOwner adds the user to the owners of the sensor once, u.ownersM must be held
+func (u *User) addOwner(userID, sensorID int64) {
+	users := u.owners[sensorID]
+	i, found := slices.BinarySearch(users, userID)
+	if !found {
+		u.owners[sensorID] = slices.Insert(users, i, userID)
+	}
+}
+
+func (u *User) GetUserSensors(ctx context.Context, userID int64) (_ []domain.Sensor, err error) {
+	ctx, end := startSpan(ctx, "User.GetUserSensors")
+	defer end(&err)
+
 	if _, err := u.userRepository.GetUserByID(ctx, userID); err != nil {
 		return nil, err
 	}
@@ -5,9 +5,11 @@ import (
 	"errors"
 	"homework/internal/domain"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_user_RegisterUser(t *testing.T) {
@@ -30,7 +32,7 @@ func Test_user_RegisterUser(t *testing.T) {
 
 		ur := NewMockUserRepository(ctrl)
 		expectedError := errors.New("doh")
-		ur.EXPECT().SaveUser(ctx, gomock.Any()).Times(1).Return(expectedError)
+		ur.EXPECT().SaveUser(gomock.Any(), gomock.Any()).Times(1).Return(expectedError)
 
 		u := NewUser(ur, nil, nil)
 
@@ -45,7 +47,7 @@ func Test_user_RegisterUser(t *testing.T) {
 		defer cancel()
 
 		ur := NewMockUserRepository(ctrl)
-		ur.EXPECT().SaveUser(ctx, gomock.Any()).Times(1).Do(func(_ context.Context, u *domain.User) {
+		ur.EXPECT().SaveUser(gomock.Any(), gomock.Any()).Times(1).Do(func(_ context.Context, u *domain.User) {
 			assert.Equal(t, "Homer Simpson", u.Name)
 			u.ID = 1
 		})
@@ -69,7 +71,7 @@ func Test_user_AttachSensorToUser(t *testing.T) {
 		defer cancel()
 
 		ur := NewMockUserRepository(ctrl)
-		ur.EXPECT().GetUserByID(ctx, gomock.Any()).Times(1).Return(nil, ErrUserNotFound)
+		ur.EXPECT().GetUserByID(gomock.Any(), gomock.Any()).Times(1).Return(nil, ErrUserNotFound)
 
 		u := NewUser(ur, nil, nil)
 
@@ -82,10 +84,10 @@ func Test_user_AttachSensorToUser(t *testing.T) {
 		defer cancel()
 
 		ur := NewMockUserRepository(ctrl)
-		ur.EXPECT().GetUserByID(ctx, gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
+		ur.EXPECT().GetUserByID(gomock.Any(), gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
 
 		sr := NewMockSensorRepository(ctrl)
-		sr.EXPECT().GetSensorByID(ctx, gomock.Any()).Times(1).Return(nil, ErrSensorNotFound)
+		sr.EXPECT().GetSensorByID(gomock.Any(), gomock.Any()).Times(1).Return(nil, ErrSensorNotFound)
 
 		u := NewUser(ur, nil, sr)
 
@@ -98,14 +100,14 @@ func Test_user_AttachSensorToUser(t *testing.T) {
 		defer cancel()
 
 		ur := NewMockUserRepository(ctrl)
-		ur.EXPECT().GetUserByID(ctx, gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
+		ur.EXPECT().GetUserByID(gomock.Any(), gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
 
 		sr := NewMockSensorRepository(ctrl)
-		sr.EXPECT().GetSensorByID(ctx, gomock.Any()).Times(1).Return(&domain.Sensor{ID: 1}, nil)
+		sr.EXPECT().GetSensorByID(gomock.Any(), gomock.Any()).Times(1).Return(&domain.Sensor{ID: 1}, nil)
 
 		sor := NewMockSensorOwnerRepository(ctrl)
 		expectedError := errors.New("some error")
-		sor.EXPECT().SaveSensorOwner(ctx, gomock.Any()).Times(1).Return(expectedError)
+		sor.EXPECT().SaveSensorOwner(gomock.Any(), gomock.Any()).Times(1).Return(expectedError)
 
 		u := NewUser(ur, sor, sr)
 
@@ -118,13 +120,13 @@ func Test_user_AttachSensorToUser(t *testing.T) {
 		defer cancel()
 
 		ur := NewMockUserRepository(ctrl)
-		ur.EXPECT().GetUserByID(ctx, gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
+		ur.EXPECT().GetUserByID(gomock.Any(), gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
 
 		sr := NewMockSensorRepository(ctrl)
-		sr.EXPECT().GetSensorByID(ctx, gomock.Any()).Times(1).Return(&domain.Sensor{ID: 1}, nil)
+		sr.EXPECT().GetSensorByID(gomock.Any(), gomock.Any()).Times(1).Return(&domain.Sensor{ID: 1}, nil)
 
 		sor := NewMockSensorOwnerRepository(ctrl)
-		sor.EXPECT().SaveSensorOwner(ctx, gomock.Any()).Times(1).Return(nil).Do(func(_ context.Context, o domain.SensorOwner) {
+		sor.EXPECT().SaveSensorOwner(gomock.Any(), gomock.Any()).Times(1).Return(nil).Do(func(_ context.Context, o domain.SensorOwner) {
 			assert.Equal(t, int64(1), o.UserID)
 			assert.Equal(t, int64(1), o.SensorID)
 		})
@@ -145,7 +147,7 @@ func Test_user_GetUserSensors(t *testing.T) {
 		defer cancel()
 
 		ur := NewMockUserRepository(ctrl)
-		ur.EXPECT().GetUserByID(ctx, gomock.Any()).Times(1).Return(nil, ErrUserNotFound)
+		ur.EXPECT().GetUserByID(gomock.Any(), gomock.Any()).Times(1).Return(nil, ErrUserNotFound)
 
 		u := NewUser(ur, nil, nil)
 
@@ -158,11 +160,11 @@ func Test_user_GetUserSensors(t *testing.T) {
 		defer cancel()
 
 		ur := NewMockUserRepository(ctrl)
-		ur.EXPECT().GetUserByID(ctx, gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
+		ur.EXPECT().GetUserByID(gomock.Any(), gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
 
 		sor := NewMockSensorOwnerRepository(ctrl)
 		expectedError := errors.New("some error")
-		sor.EXPECT().GetSensorsByUserID(ctx, gomock.Any()).Times(1).Return(nil, expectedError)
+		sor.EXPECT().GetSensorsByUserID(gomock.Any(), gomock.Any()).Times(1).Return(nil, expectedError)
 
 		u := NewUser(ur, sor, nil)
 
@@ -175,10 +177,10 @@ func Test_user_GetUserSensors(t *testing.T) {
 		defer cancel()
 
 		ur := NewMockUserRepository(ctrl)
-		ur.EXPECT().GetUserByID(ctx, gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
+		ur.EXPECT().GetUserByID(gomock.Any(), gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
 
 		sor := NewMockSensorOwnerRepository(ctrl)
-		sor.EXPECT().GetSensorsByUserID(ctx, gomock.Any()).Times(1).Return([]domain.SensorOwner{
+		sor.EXPECT().GetSensorsByUserID(gomock.Any(), gomock.Any()).Times(1).Return([]domain.SensorOwner{
 			{
 				UserID:   1,
 				SensorID: 1,
@@ -187,7 +189,7 @@ func Test_user_GetUserSensors(t *testing.T) {
 
 		sr := NewMockSensorRepository(ctrl)
 		expectedError := errors.New("some error")
-		sr.EXPECT().GetSensorByID(ctx, gomock.Any()).Times(1).Return(nil, expectedError)
+		sr.EXPECT().GetSensorByID(gomock.Any(), gomock.Any()).Times(1).Return(nil, expectedError)
 
 		u := NewUser(ur, sor, sr)
 
@@ -200,10 +202,10 @@ func Test_user_GetUserSensors(t *testing.T) {
 		defer cancel()
 
 		ur := NewMockUserRepository(ctrl)
-		ur.EXPECT().GetUserByID(ctx, gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
+		ur.EXPECT().GetUserByID(gomock.Any(), gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
 
 		sor := NewMockSensorOwnerRepository(ctrl)
-		sor.EXPECT().GetSensorsByUserID(ctx, gomock.Any()).Times(1).Return([]domain.SensorOwner{
+		sor.EXPECT().GetSensorsByUserID(gomock.Any(), gomock.Any()).Times(1).Return([]domain.SensorOwner{
 			{
 				UserID:   1,
 				SensorID: 1,
@@ -219,9 +221,9 @@ func Test_user_GetUserSensors(t *testing.T) {
 		}, nil)
 
 		sr := NewMockSensorRepository(ctrl)
-		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1, Type: domain.SensorTypeADC}, nil)
-		sr.EXPECT().GetSensorByID(ctx, int64(2)).Times(1).Return(&domain.Sensor{ID: 2, Type: domain.SensorTypeContactClosure}, nil)
-		sr.EXPECT().GetSensorByID(ctx, int64(3)).Times(1).Return(&domain.Sensor{ID: 3, Type: domain.SensorTypeContactClosure}, nil)
+		sr.EXPECT().GetSensorByID(gomock.Any(), int64(1)).Times(1).Return(&domain.Sensor{ID: 1, Type: domain.SensorTypeADC}, nil)
+		sr.EXPECT().GetSensorByID(gomock.Any(), int64(2)).Times(1).Return(&domain.Sensor{ID: 2, Type: domain.SensorTypeContactClosure}, nil)
+		sr.EXPECT().GetSensorByID(gomock.Any(), int64(3)).Times(1).Return(&domain.Sensor{ID: 3, Type: domain.SensorTypeContactClosure}, nil)
 
 		u := NewUser(ur, sor, sr)
 
@@ -230,3 +232,55 @@ func Test_user_GetUserSensors(t *testing.T) {
 		assert.Len(t, sensors, 3)
 	})
 }
+
+func Test_user_GetSensorOwners(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("fail, repo error", func(t *testing.T) {
+		sor := NewMockSensorOwnerRepository(ctrl)
+		expectedError := errors.New("some error")
+		sor.EXPECT().GetSensorOwners(gomock.Any()).Times(1).Return(nil, expectedError)
+
+		u := NewUser(nil, sor, nil)
+
+		_, err := u.GetSensorOwners(context.Background())
+		assert.ErrorIs(t, err, expectedError)
+	})
+
+	t.Run("ok, cached and kept up with the bindings", func(t *testing.T) {
+		ctx := context.Background()
+		now := time.Now()
+
+		ur := NewMockUserRepository(ctrl)
+		ur.EXPECT().GetUserByID(gomock.Any(), int64(1)).Times(1).Return(&domain.User{ID: 1}, nil)
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(gomock.Any(), int64(1)).Times(1).Return(&domain.Sensor{ID: 1}, nil)
+		sor := NewMockSensorOwnerRepository(ctrl)
+		sor.EXPECT().GetSensorOwners(gomock.Any()).Times(1).Return([]domain.SensorOwner{
+			{UserID: 2, SensorID: 1},
+			{UserID: 2, SensorID: 3},
+		}, nil)
+		sor.EXPECT().SaveSensorOwner(gomock.Any(), domain.SensorOwner{UserID: 1, SensorID: 1}).Times(1).Return(nil)
+
+		u := NewUser(ur, sor, sr)
+		u.now = func() time.Time { return now }
+
+		owners, err := u.GetSensorOwners(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, map[int64][]int64{1: {2}, 3: {2}}, owners)
+
+		owners[1][0] = 100500
+		require.NoError(t, u.AttachSensorToUser(ctx, 1, 1))
+		owners, err = u.GetSensorOwners(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, map[int64][]int64{1: {1, 2}, 3: {2}}, owners,
+			"Привязка должна попасть в кэш без чтения из репозитория, id по возрастанию")
+
+		sor.EXPECT().GetSensorOwners(gomock.Any()).Times(1).Return([]domain.SensorOwner{{UserID: 3, SensorID: 1}}, nil)
+		now = now.Add(ownersTTL + time.Second)
+		owners, err = u.GetSensorOwners(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, map[int64][]int64{1: {3}}, owners, "Устаревший кэш должен перечитываться")
+	})
+}
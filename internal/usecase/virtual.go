@@ -0,0 +1,234 @@
+package usecase
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"slices"
+	"sync"
+	"time"
+)
+
+// VirtualSensors recomputes the virtual sensors when the sensors they read receive events.
+// A virtual sensor writes its own events, so its history and streaming are the ones of any other sensor.
+//
+// The virtual sensors are indexed by their inputs, the index is loaded from the repository on the first event
+// and then kept by Refresh, so the sensors registered by the other instances sharing the database are seen
+// after a restart.
+type VirtualSensors struct {
+	sensorRepository SensorRepository
+	eventRepository  EventRepository
+
+	mu     sync.RWMutex
+	loaded bool
+	// sensors are the active virtual sensors by ID
+	sensors map[int64]*virtualSensor
+	// readers are the IDs of the virtual sensors reading a sensor by the ID of the sensor
+	readers map[int64][]int64
+}
+
+func NewVirtualSensors(sr SensorRepository, er EventRepository) *VirtualSensors {
+	return &VirtualSensors{
+		sensorRepository: sr,
+		eventRepository:  er,
+		sensors:          map[int64]*virtualSensor{},
+		readers:          map[int64][]int64{},
+	}
+}
+
+type virtualSensor struct {
+	// mu serializes the recomputations, so the concurrent events of the inputs don't lose the updates
+	mu   sync.Mutex
+	id   int64
+	expr *domain.Expression
+}
+
+// load fills the index once, the lock is held over the read so a Refresh of a sensor saved meanwhile isn't lost
+func (v *VirtualSensors) load(ctx context.Context) error {
+	v.mu.RLock()
+	loaded := v.loaded
+	v.mu.RUnlock()
+	if loaded {
+		return nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.loaded {
+		return nil
+	}
+	sensors, err := v.sensorRepository.GetSensors(ctx)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for i := range sensors {
+		if err := v.index(&sensors[i]); err != nil {
+			errs = append(errs, fmt.Errorf("sensor %d: %w", sensors[i].ID, err))
+		}
+	}
+	v.loaded = true
+	return errors.Join(errs...)
+}
+
+// Refresh updates the index after the sensor is registered or changed. The expressions are validated
+// on the registration, so a sensor with a broken one is just left out.
+func (v *VirtualSensors) Refresh(sensor *domain.Sensor) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.loaded {
+		_ = v.index(sensor)
+	}
+}
+
+// index puts the sensor into the index if it is an active virtual one and takes it out otherwise.
+// The expression never changes, so an indexed sensor keeps its entry and the lock of its recomputations.
+func (v *VirtualSensors) index(sensor *domain.Sensor) error {
+	active := sensor.Type == domain.SensorTypeVirtual && sensor.IsActive
+	if vs, has := v.sensors[sensor.ID]; has {
+		if active {
+			return nil
+		}
+		for _, id := range vs.expr.Inputs() {
+			v.readers[id] = slices.DeleteFunc(v.readers[id], func(r int64) bool { return r == sensor.ID })
+			if len(v.readers[id]) == 0 {
+				delete(v.readers, id)
+			}
+		}
+		delete(v.sensors, sensor.ID)
+	}
+	if !active {
+		return nil
+	}
+
+	expr, err := domain.ParseExpression(sensor.Expression)
+	if err != nil {
+		return err
+	}
+	v.sensors[sensor.ID] = &virtualSensor{id: sensor.ID, expr: expr}
+	for _, id := range expr.Inputs() {
+		v.readers[id] = append(v.readers[id], sensor.ID)
+	}
+	return nil
+}
+
+// dependents returns the virtual sensors reading the sensor directly or through the other virtual sensors
+func (v *VirtualSensors) dependents(id int64) []*virtualSensor {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	var found []*virtualSensor
+	seen := map[int64]bool{}
+	queue := []int64{id}
+	for len(queue) > 0 {
+		id, queue = queue[0], queue[1:]
+		for _, r := range v.readers[id] {
+			if seen[r] {
+				continue
+			}
+			seen[r] = true
+			found = append(found, v.sensors[r])
+			queue = append(queue, r)
+		}
+	}
+	return found
+}
+
+// Update recomputes the virtual sensors that read the input directly or through the other virtual sensors.
+// A sensor gets an event at the time of the input event only if its value has changed, and waits while
+// some of its inputs have no readings. A failure of one sensor doesn't stop the others.
+func (v *VirtualSensors) Update(ctx context.Context, input *domain.Sensor, at time.Time) (err error) {
+	ctx, end := startSpan(ctx, "VirtualSensors.Update")
+	defer end(&err)
+
+	var errs []error
+	if err := v.load(ctx); err != nil {
+		if !v.isLoaded() {
+			return err
+		}
+		errs = append(errs, err)
+	}
+	virtual := v.dependents(input.ID)
+	if len(virtual) == 0 {
+		return errors.Join(errs...)
+	}
+
+	// A virtual sensor reads only the sensors registered before it and its expression never changes,
+	// so in the order of the IDs its inputs are recomputed before it and there are no cycles.
+	slices.SortFunc(virtual, func(a, b *virtualSensor) int { return cmp.Compare(a.id, b.id) })
+	changed := map[int64]bool{input.ID: true}
+	for _, vs := range virtual {
+		if !slices.ContainsFunc(vs.expr.Inputs(), func(id int64) bool { return changed[id] }) {
+			continue
+		}
+		ok, err := v.recompute(ctx, vs, at)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sensor %d: %w", vs.id, err))
+			continue
+		}
+		if ok {
+			changed[vs.id] = true
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (v *VirtualSensors) isLoaded() bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.loaded
+}
+
+// recompute evaluates the sensor over the stored states of its inputs and stores its event,
+// false means the value is the same
+func (v *VirtualSensors) recompute(ctx context.Context, vs *virtualSensor, at time.Time) (bool, error) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	s, err := v.sensorRepository.GetSensorByID(ctx, vs.id)
+	if err != nil {
+		return false, err
+	}
+	if !s.IsActive {
+		return false, nil
+	}
+	inputs := vs.expr.Inputs()
+	states := make(map[int64]domain.Payload, len(inputs))
+	for _, id := range inputs {
+		input, err := v.sensorRepository.GetSensorByID(ctx, id)
+		if errors.Is(err, ErrSensorNotFound) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		states[id] = input.CurrentState
+	}
+
+	payload, err := vs.expr.Eval(states)
+	if errors.Is(err, domain.ErrNoReading) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if payload.Equal(s.CurrentState) {
+		return false, nil
+	}
+
+	// the events of a sensor can't share a timestamp, the late inputs don't move the sensor back in time
+	if !at.After(s.LastActivity) {
+		at = s.LastActivity.Add(time.Microsecond)
+	}
+	event := &domain.Event{Timestamp: at, SensorSerialNumber: s.SerialNumber, SensorID: s.ID, Payload: payload}
+	if err := v.eventRepository.SaveEvent(ctx, event); err != nil {
+		return false, err
+	}
+	s.CurrentState, s.LastActivity = payload, at
+	if err := v.sensorRepository.SaveSensor(ctx, s); err != nil {
+		return false, err
+	}
+	return true, nil
+}
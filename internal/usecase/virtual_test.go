@@ -0,0 +1,249 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// virtualHouse has two windows, the "any window open" sensor, the "all closed" one reading it and an unrelated average
+func virtualHouse(lastActivity time.Time) []domain.Sensor {
+	return []domain.Sensor{
+		{ID: 1, Type: domain.SensorTypeContactClosure, IsActive: true, CurrentState: domain.IntPayload(0)},
+		{ID: 2, Type: domain.SensorTypeContactClosure, IsActive: true, CurrentState: domain.IntPayload(0)},
+		{ID: 3, SerialNumber: "0000000003", Type: domain.SensorTypeVirtual, IsActive: true, Expression: "any(#1, #2)",
+			CurrentState: domain.IntPayload(0), LastActivity: lastActivity},
+		{ID: 4, SerialNumber: "0000000004", Type: domain.SensorTypeVirtual, IsActive: true, Expression: "not(#3)",
+			CurrentState: domain.IntPayload(1), LastActivity: lastActivity},
+		{ID: 5, SerialNumber: "0000000005", Type: domain.SensorTypeVirtual, IsActive: true, Expression: "avg(#6, #7)"},
+	}
+}
+
+// expectHouse makes the repository keep the sensors, the saved ones are returned by GetSensorByID
+func expectHouse(sr *MockSensorRepository, sensors []domain.Sensor) {
+	var mu sync.Mutex
+	byID := map[int64]domain.Sensor{}
+	for _, s := range sensors {
+		byID[s.ID] = s
+	}
+	sr.EXPECT().GetSensors(gomock.Any()).MaxTimes(1).Return(sensors, nil)
+	sr.EXPECT().GetSensorByID(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(_ context.Context, id int64) (*domain.Sensor, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		s, has := byID[id]
+		if !has {
+			return nil, ErrSensorNotFound
+		}
+		return &s, nil
+	})
+	sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(_ context.Context, s *domain.Sensor) error {
+		mu.Lock()
+		defer mu.Unlock()
+		byID[s.ID] = *s
+		return nil
+	})
+}
+
+func Test_virtualSensors_Update(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	at := time.Unix(1000, 0)
+
+	t.Run("ok, dependents are recomputed in order", func(t *testing.T) {
+		house := virtualHouse(at.Add(-time.Hour))
+		house[0].CurrentState = domain.IntPayload(1)
+		sr := NewMockSensorRepository(ctrl)
+		expectHouse(sr, house)
+		var events []domain.Event
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Times(2).DoAndReturn(func(_ context.Context, e *domain.Event) error {
+			events = append(events, *e)
+			return nil
+		})
+
+		require.NoError(t, NewVirtualSensors(sr, er).Update(context.Background(), &house[0], at))
+
+		require.Len(t, events, 2)
+		assert.Equal(t, domain.Event{Timestamp: at, SensorSerialNumber: "0000000003", SensorID: 3, Payload: domain.IntPayload(1)}, events[0])
+		assert.Equal(t, domain.Event{Timestamp: at, SensorSerialNumber: "0000000004", SensorID: 4, Payload: domain.IntPayload(0)}, events[1])
+		saved, err := sr.GetSensorByID(context.Background(), 3)
+		require.NoError(t, err)
+		assert.Equal(t, domain.IntPayload(1), saved.CurrentState)
+		assert.Equal(t, at, saved.LastActivity)
+	})
+
+	t.Run("ok, nothing is written without change", func(t *testing.T) {
+		sr := NewMockSensorRepository(ctrl)
+		expectHouse(sr, virtualHouse(at.Add(-time.Hour)))
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Times(0)
+
+		input := &domain.Sensor{ID: 2, Type: domain.SensorTypeContactClosure, CurrentState: domain.IntPayload(0)}
+		assert.NoError(t, NewVirtualSensors(sr, er).Update(context.Background(), input, at))
+	})
+
+	t.Run("ok, late input moves timestamp forward", func(t *testing.T) {
+		house := virtualHouse(at)
+		house[1].CurrentState = domain.IntPayload(1)
+		sr := NewMockSensorRepository(ctrl)
+		expectHouse(sr, house)
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Times(2).DoAndReturn(func(_ context.Context, e *domain.Event) error {
+			assert.Equal(t, at.Add(time.Microsecond), e.Timestamp, "События датчика не должны совпадать по времени")
+			return nil
+		})
+
+		assert.NoError(t, NewVirtualSensors(sr, er).Update(context.Background(), &house[1], at))
+	})
+
+	t.Run("ok, sensors without readers are not read", func(t *testing.T) {
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensors(gomock.Any()).Times(1).Return(virtualHouse(at), nil)
+		sr.EXPECT().GetSensorByID(gomock.Any(), gomock.Any()).Times(0)
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Times(0)
+
+		virtual := NewVirtualSensors(sr, er)
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, virtual.Update(context.Background(), &domain.Sensor{ID: 10}, at))
+		}
+	})
+
+	t.Run("ok, index is refreshed", func(t *testing.T) {
+		house := virtualHouse(at.Add(-time.Hour))
+		sr := NewMockSensorRepository(ctrl)
+		expectHouse(sr, house[:2])
+		var events []domain.Event
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, e *domain.Event) error {
+			events = append(events, *e)
+			return nil
+		})
+
+		virtual := NewVirtualSensors(sr, er)
+		require.NoError(t, virtual.Update(context.Background(), &house[0], at))
+
+		require.NoError(t, sr.SaveSensor(context.Background(), &house[2]))
+		virtual.Refresh(&house[2])
+		house[0].CurrentState = domain.IntPayload(1)
+		require.NoError(t, sr.SaveSensor(context.Background(), &house[0]))
+		require.NoError(t, virtual.Update(context.Background(), &house[0], at))
+		require.Len(t, events, 1, "Зарегистрированный датчик должен пересчитываться")
+		assert.Equal(t, int64(3), events[0].SensorID)
+
+		house[2].IsActive = false
+		virtual.Refresh(&house[2])
+		house[1].CurrentState = domain.IntPayload(1)
+		require.NoError(t, sr.SaveSensor(context.Background(), &house[1]))
+		assert.NoError(t, virtual.Update(context.Background(), &house[1], at.Add(time.Second)),
+			"Выключенный датчик не должен пересчитываться")
+	})
+
+	t.Run("ok, concurrent updates are serialized", func(t *testing.T) {
+		house := virtualHouse(at.Add(-time.Hour))
+		house[0].CurrentState = domain.IntPayload(1)
+		house[1].CurrentState = domain.IntPayload(1)
+		sr := NewMockSensorRepository(ctrl)
+		expectHouse(sr, house)
+		var saved atomic.Int64
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(_ context.Context, e *domain.Event) error {
+			if e.SensorID == 3 {
+				saved.Add(1)
+			}
+			return nil
+		})
+
+		virtual := NewVirtualSensors(sr, er)
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(input *domain.Sensor) {
+				defer wg.Done()
+				assert.NoError(t, virtual.Update(context.Background(), input, at))
+			}(&house[i%2])
+		}
+		wg.Wait()
+		assert.Equal(t, int64(1), saved.Load(), "Значение датчика должно пересчитываться по одному разу")
+	})
+
+	t.Run("fail, dependents of failed sensor are left", func(t *testing.T) {
+		expectedError := errors.New("some error")
+		house := virtualHouse(at.Add(-time.Hour))
+		house[0].CurrentState = domain.IntPayload(1)
+		sr := NewMockSensorRepository(ctrl)
+		expectHouse(sr, house)
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Times(1).Return(expectedError)
+
+		assert.ErrorIs(t, NewVirtualSensors(sr, er).Update(context.Background(), &house[0], at), expectedError)
+	})
+
+	t.Run("fail, index is not loaded", func(t *testing.T) {
+		expectedError := errors.New("some error")
+		sr := NewMockSensorRepository(ctrl)
+		gomock.InOrder(
+			sr.EXPECT().GetSensors(gomock.Any()).Times(1).Return(nil, expectedError),
+			sr.EXPECT().GetSensors(gomock.Any()).Times(1).Return(nil, nil),
+		)
+		virtual := NewVirtualSensors(sr, NewMockEventRepository(ctrl))
+
+		assert.ErrorIs(t, virtual.Update(context.Background(), &domain.Sensor{ID: 1}, at), expectedError)
+		assert.NoError(t, virtual.Update(context.Background(), &domain.Sensor{ID: 1}, at), "Индекс загружается повторно")
+	})
+}
+
+func Test_event_ReceiveEvent_Virtual(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	at := time.Unix(1000, 0)
+
+	t.Run("err, virtual sensor takes no events", func(t *testing.T) {
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "0000000003").Times(1).
+			Return(&domain.Sensor{ID: 3, Type: domain.SensorTypeVirtual, IsActive: true}, nil)
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Times(0)
+
+		err := NewEvent(er, sr).ReceiveEvent(context.Background(),
+			&domain.Event{Timestamp: at, SensorSerialNumber: "0000000003", Payload: domain.IntPayload(1)})
+		assert.ErrorIs(t, err, ErrWrongSensorType)
+	})
+
+	t.Run("ok, virtual sensors are updated", func(t *testing.T) {
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "0000000001").Times(1).
+			Return(&domain.Sensor{ID: 1, Type: domain.SensorTypeContactClosure, IsActive: true}, nil)
+		house := virtualHouse(at.Add(-time.Hour))
+		house[0].CurrentState = domain.IntPayload(1)
+		expectHouse(sr, house)
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Times(3).Return(nil)
+
+		err := NewEvent(er, sr, WithEventVirtualSensors(NewVirtualSensors(sr, er))).ReceiveEvent(context.Background(),
+			&domain.Event{Timestamp: at, SensorSerialNumber: "0000000001", Payload: domain.IntPayload(1)})
+		assert.NoError(t, err)
+	})
+
+	t.Run("ok, failed virtual sensors don't fail event", func(t *testing.T) {
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "0000000001").Times(1).
+			Return(&domain.Sensor{ID: 1, Type: domain.SensorTypeContactClosure, IsActive: true}, nil)
+		sr.EXPECT().GetSensors(gomock.Any()).Times(1).Return(nil, errors.New("some error"))
+		sr.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+
+		err := NewEvent(er, sr, WithEventVirtualSensors(NewVirtualSensors(sr, er))).ReceiveEvent(context.Background(),
+			&domain.Event{Timestamp: at, SensorSerialNumber: "0000000001", Payload: domain.IntPayload(1)})
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1 @@
+drop table import_checkpoints;
\ No newline at end of file
@@ -0,0 +1,6 @@
+create table import_checkpoints
+(
+    key         text        not null primary key,
+    processed   bigint      not null,
+    updated_at  timestamp   not null
+);
\ No newline at end of file
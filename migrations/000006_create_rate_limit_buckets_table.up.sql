@@ -0,0 +1,7 @@
+create table rate_limit_buckets
+(
+    key         text        not null primary key,
+    tat         timestamptz not null
+);
+
+create index rate_limit_buckets_tat_idx on rate_limit_buckets (tat);
\ No newline at end of file
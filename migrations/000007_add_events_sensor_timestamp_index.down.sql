@@ -0,0 +1,6 @@
+drop index events_sensor_id_timestamp_idx;
+
+insert into events
+select * from events_duplicates;
+
+drop table events_duplicates;
\ No newline at end of file
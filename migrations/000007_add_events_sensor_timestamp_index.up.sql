@@ -0,0 +1,15 @@
+-- a sensor has at most one event at a time, the duplicates saved before are moved aside for the down migration
+create table events_duplicates (like events);
+
+with duplicates as (
+    delete from events a
+        using events b
+    where a.ctid > b.ctid
+      and a.sensor_id = b.sensor_id
+      and a.timestamp = b.timestamp
+    returning a.*
+)
+insert into events_duplicates
+select * from duplicates;
+
+create unique index events_sensor_id_timestamp_idx on events (sensor_id, timestamp);
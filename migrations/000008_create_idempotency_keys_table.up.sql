@@ -0,0 +1,12 @@
+create table idempotency_keys
+(
+    key             text        not null primary key,
+    fingerprint     text        not null,
+    completed       boolean     not null default false,
+    status          integer     not null default 0,
+    content_type    text        not null default '',
+    body            bytea,
+    expires_at      timestamptz not null
+);
+
+create index idempotency_keys_expires_at_idx on idempotency_keys (expires_at);
@@ -0,0 +1,2 @@
+alter table sensors drop column current_readings;
+alter table events drop column readings;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- the readings of every channel; payload and current_state keep the integer part of the main one for the old clients.
+-- The rows written before have no readings, their integer is all there is.
+alter table events add column readings jsonb;
+alter table sensors add column current_readings jsonb;
\ No newline at end of file
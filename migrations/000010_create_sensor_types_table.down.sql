@@ -0,0 +1,7 @@
+-- fails if there are sensors of the types added to the registry, they don't fit the enum
+create type sensor_type as enum ('cc', 'adc');
+
+alter table sensors drop constraint sensors_type_fkey;
+alter table sensors alter column type type sensor_type using type::sensor_type;
+
+drop table sensor_types;
@@ -0,0 +1,18 @@
+-- the registry of the sensor types replaces the enum, the builtin types are registered from the start
+create table sensor_types
+(
+    name            text    not null primary key,
+    description     text    not null default '',
+    channels        jsonb   not null default '[]',
+    serial_pattern  text    not null default '',
+    heartbeat_ms    bigint  not null default 0
+);
+
+insert into sensor_types (name, description)
+values ('cc', 'Контактный датчик'),
+       ('adc', 'Аналого-цифровой преобразователь');
+
+alter table sensors alter column type type text using type::text;
+alter table sensors add constraint sensors_type_fkey foreign key (type) references sensor_types (name);
+
+drop type sensor_type;
@@ -0,0 +1,4 @@
+update sensor_types set channels = '[]' where name in ('cc', 'adc');
+
+alter table sensors drop column calibration;
+alter table events drop column raw_readings;
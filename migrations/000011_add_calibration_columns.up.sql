@@ -0,0 +1,7 @@
+-- the readings of the calibrated events as the sensors have sent them, NULL if there was no calibration
+alter table events add column raw_readings jsonb;
+alter table sensors add column calibration jsonb;
+
+-- the builtin types validate their readings: a contact is 0 or 1, an adc gives 12-bit counts
+update sensor_types set channels = '[{"name": "value", "bits": 1}]' where name = 'cc';
+update sensor_types set channels = '[{"name": "value", "bits": 12}]' where name = 'adc';
@@ -0,0 +1,2 @@
+drop table if exists commands;
+drop table if exists actuators;
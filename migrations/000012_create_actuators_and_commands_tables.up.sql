@@ -0,0 +1,28 @@
+create table actuators
+(
+    id              bigserial   primary key,
+    serial_number   text        not null unique,
+    type            text        not null,
+    description     text        not null default '',
+    registered_at   timestamptz not null
+);
+
+create table commands
+(
+    id              bigserial   primary key,
+    actuator_id     bigint      not null references actuators (id) on delete cascade,
+    action          text        not null,
+    args            jsonb,
+    status          text        not null,
+    attempts        integer     not null default 0,
+    max_attempts    integer     not null,
+    ack_timeout_ms  bigint      not null,
+    created_at      timestamptz not null,
+    sent_at         timestamptz,
+    expires_at      timestamptz not null,
+    completed_at    timestamptz,
+    error           text        not null default ''
+);
+
+-- the polls read the queue of an actuator, the finished commands are looked up by id only
+create index commands_active_idx on commands (actuator_id, id) where status in ('queued', 'sent');
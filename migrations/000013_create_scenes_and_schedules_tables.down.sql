@@ -0,0 +1,2 @@
+drop table if exists schedules;
+drop table if exists scenes;
@@ -0,0 +1,27 @@
+create table scenes
+(
+    id              bigserial   primary key,
+    name            text        not null,
+    description     text        not null default '',
+    commands        jsonb       not null
+);
+
+create table schedules
+(
+    id              bigserial   primary key,
+    name            text        not null,
+    scene_id        bigint      not null references scenes (id),
+    kind            text        not null,
+    cron            text        not null default '',
+    offset_seconds  bigint      not null default 0,
+    at              timestamptz,
+    missed          text        not null,
+    enabled         boolean     not null,
+    next_run        timestamptz,
+    last_run        timestamptz,
+    last_error      text        not null default '',
+    created_at      timestamptz not null
+);
+
+-- the scheduler looks for the enabled schedules that are due
+create index schedules_due_idx on schedules (next_run) where enabled;
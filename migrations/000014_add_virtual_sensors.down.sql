@@ -0,0 +1,4 @@
+-- fails if there are virtual sensors, they would lose their expressions
+delete from sensor_types where name = 'virtual';
+
+alter table sensors drop column expression;
@@ -0,0 +1,5 @@
+-- the virtual sensors compute their state by the expression over the other sensors
+alter table sensors add column expression text not null default '';
+
+insert into sensor_types (name, description)
+values ('virtual', 'Виртуальный датчик, вычисляемый по другим датчикам');
@@ -0,0 +1,3 @@
+drop table if exists change_horizon;
+drop table if exists change_consumers;
+drop table if exists changes;
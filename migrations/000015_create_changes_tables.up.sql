@@ -0,0 +1,26 @@
+-- the feed of the changes, the writers record them one at a time under an advisory lock,
+-- so the ids grow in the order of the commits
+create table changes
+(
+    id      bigserial   primary key,
+    kind    text        not null,
+    time    timestamptz not null,
+    data    jsonb       not null
+);
+
+create index changes_time_idx on changes (time);
+
+create table change_consumers
+(
+    name        text        primary key,
+    cursor      bigint      not null,
+    updated_at  timestamptz not null
+);
+
+-- the id of the last deleted change, a consumer behind it has missed some
+create table change_horizon
+(
+    pruned  bigint  not null
+);
+
+insert into change_horizon (pruned) values (0);
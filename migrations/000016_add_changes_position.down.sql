@@ -0,0 +1,4 @@
+-- the ids of the changes recorded since differ from their positions, the consumers have to resync
+drop index if exists changes_unpublished_idx;
+
+alter table changes drop column position;
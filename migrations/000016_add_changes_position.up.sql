@@ -0,0 +1,9 @@
+-- the place of a change in the feed is given once it is committed, so the writers don't take turns.
+-- the changes recorded so far keep their ids
+alter table changes add column position bigint;
+
+update changes set position = id;
+
+create unique index changes_position_idx on changes (position);
+
+create index changes_unpublished_idx on changes (id) where position is null;
@@ -0,0 +1,44 @@
+// Package migrations embeds the schema migrations so the server knows which schema version it expects.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// FS holds the migrations in the golang-migrate layout: <version>_<name>.<up|down>.sql
+//
+//go:embed *.sql
+var FS embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFS embed.FS
+
+// SQLite holds the migrations of the embedded database in the same layout. It keeps only the sensors with their types,
+// the events and the users, so it has its own versions.
+var SQLite, _ = fs.Sub(sqliteFS, "sqlite")
+
+// Latest returns the version of the newest migration
+func Latest() int64 {
+	return latest(FS)
+}
+
+// LatestSQLite returns the version of the newest migration of the embedded database
+func LatestSQLite() int64 {
+	return latest(SQLite)
+}
+
+func latest(fsys fs.FS) int64 {
+	entries, _ := fs.ReadDir(fsys, ".")
+
+	var latest int64
+	for _, e := range entries {
+		prefix, _, _ := strings.Cut(e.Name(), "_")
+		if v, err := strconv.ParseInt(prefix, 10, 64); err == nil && v > latest {
+			latest = v
+		}
+	}
+	return latest
+}
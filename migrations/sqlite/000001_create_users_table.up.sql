@@ -0,0 +1,5 @@
+create table users
+(
+    id   integer not null primary key autoincrement,
+    name text    not null
+);
@@ -0,0 +1,15 @@
+-- the times are unix nanoseconds, NULL if they are not set; the readings and the calibration are json
+create table sensors
+(
+    id               integer not null primary key autoincrement,
+    serial_number    text    not null unique,
+    type             text    not null,
+    current_state    integer not null default 0,
+    description      text    not null default '',
+    is_active        integer not null default 0,
+    registered_at    integer,
+    last_activity    integer,
+    current_readings text,
+    calibration      text,
+    expression       text    not null default ''
+);
@@ -0,0 +1 @@
+drop table sensors_users;
@@ -0,0 +1,8 @@
+create table sensors_users
+(
+    id        integer not null primary key autoincrement,
+    sensor_id integer not null,
+    user_id   integer not null
+);
+
+create index sensors_users_user_id_idx on sensors_users (user_id);
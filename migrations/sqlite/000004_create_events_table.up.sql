@@ -0,0 +1,11 @@
+-- a sensor has at most one event at a time, the timestamp is in unix nanoseconds
+create table events
+(
+    timestamp            integer not null,
+    sensor_serial_number text    not null,
+    sensor_id            integer not null,
+    payload              integer not null,
+    readings             text,
+    raw_readings         text,
+    primary key (sensor_id, timestamp)
+) without rowid;
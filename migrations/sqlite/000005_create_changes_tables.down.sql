@@ -0,0 +1,3 @@
+drop table change_horizon;
+drop table change_consumers;
+drop table changes;
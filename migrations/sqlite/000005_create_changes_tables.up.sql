@@ -0,0 +1,26 @@
+-- the feed of the changes, the writers of the database take turns, so the ids grow in the order of the commits.
+-- autoincrement never gives out an id again, even after the old changes are deleted.
+create table changes
+(
+    id   integer not null primary key autoincrement,
+    kind text    not null,
+    time integer not null,
+    data text    not null
+);
+
+create index changes_time_idx on changes (time);
+
+create table change_consumers
+(
+    name       text    not null primary key,
+    cursor     integer not null,
+    updated_at integer not null
+);
+
+-- the id of the last deleted change, a consumer behind it has missed some
+create table change_horizon
+(
+    pruned integer not null
+);
+
+insert into change_horizon (pruned) values (0);
@@ -0,0 +1,18 @@
+-- the registry of the sensor types, the builtin types are registered from the start; the channels are json
+create table sensor_types
+(
+    name           text    not null primary key,
+    description    text    not null default '',
+    channels       text    not null default '[]',
+    serial_pattern text    not null default '',
+    heartbeat_ms   integer not null default 0
+);
+
+insert into sensor_types (name, description, channels)
+values ('cc', 'Контактный датчик', '[{"name": "value", "bits": 1}]'),
+       ('adc', 'Аналого-цифровой преобразователь', '[{"name": "value", "bits": 12}]'),
+       ('virtual', 'Виртуальный датчик, вычисляемый по другим датчикам', '[]');
+
+-- the types were kept in memory before, the ones of the registered sensors come back accepting any readings
+insert or ignore into sensor_types (name)
+select distinct type from sensors;